@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+// adminAuthMiddleware requires a bearer token matching ADMIN_TOKEN. Admin routes are hidden when no token is configured.
+func (app *App) adminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if app.AdminToken == "" {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		token, _ := bearerToken(c)
+		if !tokenMatches(token, app.AdminToken) {
+			logWarn("Rejected admin request to %s from %s", c.Request.URL.Path, c.ClientIP())
+			writeProblem(c, http.StatusUnauthorized, ErrorCodeUnauthorized, "admin token missing or invalid")
+			return
+		}
+		c.Next()
+	}
+}
+
+// bearerToken returns the bearer token in the request's Authorization header.
+func bearerToken(c *gin.Context) (string, bool) {
+	return strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+// tokenMatches reports whether token equals want in constant time. An empty want matches nothing.
+func tokenMatches(token, want string) bool {
+	return want != "" && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
+
+// blocklistRequest is the JSON body accepted by the admin blocklist endpoints.
+type blocklistRequest struct {
+	Entry string `json:"entry" binding:"required"`
+}
+
+// adminBlocklistHandler lists permanent blocklist entries and active temporary bans.
+func (app *App) adminBlocklistHandler(c *gin.Context) {
+	entries, bans := app.Blocklist.snapshot()
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"bans":    bans,
+	})
+}
+
+// adminBlocklistAddHandler adds an IP address or CIDR to the blocklist.
+func (app *App) adminBlocklistAddHandler(c *gin.Context) {
+	var req blocklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "entry is required")
+		return
+	}
+	entry, err := app.Blocklist.add(strings.TrimSpace(req.Entry))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+	logInfo("Admin added blocklist entry: %s", entry)
+	auditChange(c, entry, "", "blocked")
+	app.broadcast(c.Request.Context(), FleetEvent{Type: FleetBlocklistAdd, Entry: entry})
+	c.JSON(http.StatusCreated, gin.H{"entry": entry})
+}
+
+// adminBlocklistRemoveHandler removes an IP address or CIDR from the blocklist, lifting temporary bans.
+func (app *App) adminBlocklistRemoveHandler(c *gin.Context) {
+	var req blocklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "entry is required")
+		return
+	}
+	removed, err := app.Blocklist.remove(strings.TrimSpace(req.Entry))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+	if !removed {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "entry not found")
+		return
+	}
+	logInfo("Admin removed blocklist entry: %s", req.Entry)
+	auditChange(c, strings.TrimSpace(req.Entry), "blocked", "")
+	app.broadcast(c.Request.Context(), FleetEvent{Type: FleetBlocklistRemove, Entry: strings.TrimSpace(req.Entry)})
+	c.Status(http.StatusNoContent)
+}
+
+// scheduleRequest is the JSON body accepted by the admin puzzle schedule endpoints.
+type scheduleRequest struct {
+	Date string `json:"date" binding:"required"`
+	Word string `json:"word"`
+}
+
+// adminDailyHandler reports the active daily puzzle date and word.
+func (app *App) adminDailyHandler(c *gin.Context) {
+	date := app.Daily.puzzleDate(app.now(), app.Daily.Location)
+	entry, _ := app.dailyWord(date)
+	_, pinned := app.Calendar.lookup(date)
+	c.JSON(http.StatusOK, gin.H{
+		"puzzle_date": date.Format(time.DateOnly),
+		"word":        entry.Word,
+		"hint":        entry.Hint,
+		"pinned":      pinned,
+	})
+}
+
+// adminScheduleHandler lists all pinned puzzles by date.
+func (app *App) adminScheduleHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"schedule": app.Calendar.snapshot()})
+}
+
+// adminScheduleAddHandler pins a word from the word list to a current or future puzzle date.
+func (app *App) adminScheduleAddHandler(c *gin.Context) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "date and word are required")
+		return
+	}
+	date, err := parsePuzzleDate(req.Date)
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+	if date.Before(app.Daily.puzzleDate(app.now(), app.Daily.Location)) {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "date is in the past")
+		return
+	}
+	word := engine.NormalizeGuess(req.Word)
+	if _, ok := app.lists().WordSet[word]; !ok {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeNotInWordList, "word is not in the word list")
+		return
+	}
+	previous, _ := app.Calendar.lookup(date)
+	if err := app.Calendar.pin(date, word); err != nil {
+		logWarn("Failed to save puzzle calendar: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "failed to save schedule")
+		return
+	}
+	logInfo("Admin pinned a puzzle for %s", req.Date)
+	auditChange(c, req.Date, previous, word)
+	app.broadcast(c.Request.Context(), FleetEvent{Type: FleetSchedulePin, Date: req.Date, Word: word})
+	c.JSON(http.StatusCreated, gin.H{"date": req.Date, "word": word})
+}
+
+// adminScheduleRemoveHandler removes the pinned puzzle for a date.
+func (app *App) adminScheduleRemoveHandler(c *gin.Context) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "date is required")
+		return
+	}
+	date, err := parsePuzzleDate(req.Date)
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+	previous, _ := app.Calendar.lookup(date)
+	removed, err := app.Calendar.unpin(date)
+	if err != nil {
+		logWarn("Failed to save puzzle calendar: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "failed to save schedule")
+		return
+	}
+	if !removed {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "no puzzle pinned for date")
+		return
+	}
+	logInfo("Admin unpinned the puzzle for %s", req.Date)
+	auditChange(c, req.Date, previous, "")
+	app.broadcast(c.Request.Context(), FleetEvent{Type: FleetScheduleUnpin, Date: req.Date})
+	c.Status(http.StatusNoContent)
+}
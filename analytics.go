@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Analytics mode constants
+const (
+	AnalyticsModeOff       = "off"
+	AnalyticsModeInternal  = "internal"
+	AnalyticsModePlausible = "plausible"
+)
+
+// Analytics event name constants
+const (
+	EventGameStarted = "game_started"
+	EventGameWon     = "game_won"
+	EventGameLost    = "game_lost"
+)
+
+// analyticsEvent is a single aggregate event in the Plausible events API format.
+type analyticsEvent struct {
+	Name   string            `json:"name"`
+	URL    string            `json:"url"`
+	Domain string            `json:"domain"`
+	Props  map[string]string `json:"props,omitempty"`
+	// RequestID is sent as a header rather than in the event body.
+	RequestID string `json:"-"`
+}
+
+// Analytics records cookie-less aggregate gameplay events, either as internal counters only or
+// additionally forwarded to a Plausible-compatible endpoint. No identifiers or IPs are sent.
+type Analytics struct {
+	Mode     string
+	Endpoint string
+	Domain   string
+	client   *http.Client
+	breaker  *CircuitBreaker
+	events   chan analyticsEvent
+}
+
+// newAnalytics returns an Analytics for the given mode, or nil when analytics are disabled.
+func newAnalytics(mode, endpoint, domain string) *Analytics {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "", AnalyticsModeOff:
+		return nil
+	case AnalyticsModeInternal:
+		return &Analytics{Mode: mode}
+	case AnalyticsModePlausible:
+		if endpoint == "" || domain == "" {
+			logWarn("Plausible analytics requires ANALYTICS_ENDPOINT and ANALYTICS_DOMAIN, using internal counters only")
+			return &Analytics{Mode: AnalyticsModeInternal}
+		}
+		a := &Analytics{
+			Mode:     mode,
+			Endpoint: endpoint,
+			Domain:   domain,
+			client:   &http.Client{Timeout: 5 * time.Second},
+			events:   make(chan analyticsEvent, 256),
+		}
+		go a.run()
+		return a
+	default:
+		logWarn("Unknown analytics mode %q, analytics disabled", mode)
+		return nil
+	}
+}
+
+// run forwards queued events to the configured endpoint until the queue is closed.
+func (a *Analytics) run() {
+	for ev := range a.events {
+		body, err := json.Marshal(ev)
+		if err != nil {
+			logWarn("Failed to marshal analytics event: %v", err)
+			continue
+		}
+		err = a.breaker.call(context.Background(), func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.Endpoint, bytes.NewReader(body))
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "vortludo")
+			if ev.RequestID != "" {
+				req.Header.Set("X-Request-Id", ev.RequestID)
+			}
+			resp, err := a.client.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			return nil
+		})
+		// Events are dropped rather than retried while the endpoint is down.
+		if err != nil && !errors.Is(err, errCircuitOpen) {
+			logWarn("Failed to send analytics event %s: %v", ev.Name, err)
+		}
+	}
+}
+
+// enqueue queues an event for delivery, dropping it if the queue is full so requests never block.
+func (a *Analytics) enqueue(ev analyticsEvent) {
+	if a.events == nil {
+		return
+	}
+	select {
+	case a.events <- ev:
+	default:
+		logWarn("Analytics queue full, dropping event %s", ev.Name)
+	}
+}
+
+// doNotTrack reports whether the client asked not to be tracked via DNT or Global Privacy Control.
+func doNotTrack(c *gin.Context) bool {
+	return c.GetHeader("DNT") == "1" || c.GetHeader("Sec-GPC") == "1"
+}
+
+// trackEvent records an aggregate analytics event unless analytics are disabled or the client opted out.
+func (app *App) trackEvent(c *gin.Context, name string, props map[string]string) {
+	if app.Analytics == nil || doNotTrack(c) {
+		return
+	}
+	app.incMetric("analytics_" + name)
+	app.Analytics.enqueue(analyticsEvent{
+		Name:      name,
+		URL:       "https://" + app.Analytics.Domain + c.FullPath(),
+		Domain:    app.Analytics.Domain,
+		Props:     props,
+		RequestID: requestIDFrom(c.Request.Context()),
+	})
+}
+
+// trackGameOver records the outcome of a finished game along with its guess count.
+func (app *App) trackGameOver(c *gin.Context, game *GameState) {
+	if app.Analytics == nil || doNotTrack(c) {
+		return
+	}
+	guesses := strconv.Itoa(len(game.GuessHistory))
+	if game.Won {
+		app.incMetric("analytics_won_in_" + guesses)
+		app.trackEvent(c, EventGameWon, map[string]string{"guesses": guesses})
+		return
+	}
+	app.trackEvent(c, EventGameLost, map[string]string{"guesses": guesses})
+}
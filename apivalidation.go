@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxAPIBodyBytes caps the size of a JSON API request body.
+const MaxAPIBodyBytes = 16 << 10
+
+// apiParam describes one query parameter or JSON body field of an API endpoint.
+type apiParam struct {
+	Name     string
+	Required bool
+	MaxLen   int
+	Pattern  *regexp.Regexp
+	// Integer parameters must parse and fall within [Min, Max].
+	Integer  bool
+	Min, Max int
+	// Boolean body fields must be JSON true or false rather than strings.
+	Boolean bool
+}
+
+// apiSchema describes the accepted shape of an API request. Endpoints with a Body take a JSON
+// object whose keys must all be listed; fields are strings unless marked Boolean.
+type apiSchema struct {
+	Query []apiParam
+	Body  []apiParam
+}
+
+// letterPattern matches guesses and words before normalization.
+var letterPattern = regexp.MustCompile(`^[A-Za-z\s]*$`)
+
+// fragmentFormatParam selects HTML or the JSON view-model on fragment endpoints.
+var fragmentFormatParam = apiParam{Name: "format", Pattern: regexp.MustCompile(`^(html|json)?$`)}
+
+// apiSchemas maps "METHOD route" to the schema its requests must satisfy.
+var apiSchemas = map[string]apiSchema{
+	http.MethodGet + " " + RouteValidate: {Query: []apiParam{
+		{Name: "guess", Required: true, MaxLen: 32, Pattern: letterPattern},
+	}},
+	http.MethodGet + " " + RouteGlobalStats: {Query: []apiParam{
+		{Name: "days", Integer: true, Min: 1, Max: maxStatsDays},
+	}},
+	http.MethodGet + " " + RouteGameState:                  {Query: []apiParam{fragmentFormatParam}},
+	http.MethodPost + " " + RouteGuess:                     {Query: []apiParam{fragmentFormatParam}},
+	http.MethodPost + " " + RouteNewGame:                   {Query: []apiParam{fragmentFormatParam}},
+	http.MethodGet + " " + RouteSpectate + "/:token/board": {Query: []apiParam{fragmentFormatParam}},
+	http.MethodPost + " " + RouteGamesAPI: {Body: []apiParam{
+		{Name: "seed", MaxLen: maxGameSeedLength},
+		{Name: "word", MaxLen: 32, Pattern: letterPattern},
+		{Name: "pack", MaxLen: 64, Pattern: regexp.MustCompile(`^[a-z0-9-]*$`)},
+	}},
+	http.MethodPut + " " + RoutePreferencesAPI: {Body: []apiParam{
+		{Name: "theme", Pattern: regexp.MustCompile(`^(light|dark)?$`)},
+		{Name: "language", MaxLen: 35, Pattern: regexp.MustCompile(`^([A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*)?$`)},
+		{Name: "keyboard_layout", Pattern: regexp.MustCompile(`^(qwerty|azerty|qwertz|dvorak|colemak)?$`)},
+		{Name: "mode", Pattern: regexp.MustCompile(`^(purist|kids)?$`)},
+		{Name: "hard_mode", Boolean: true},
+		{Name: "colorblind", Boolean: true},
+	}},
+}
+
+// check validates one value against the parameter's rules.
+func (p apiParam) check(value string) string {
+	if p.MaxLen > 0 && len(value) > p.MaxLen {
+		return fmt.Sprintf("must be at most %d characters", p.MaxLen)
+	}
+	if p.Pattern != nil && !p.Pattern.MatchString(value) {
+		return "contains invalid characters"
+	}
+	if p.Integer {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "must be an integer"
+		}
+		if n < p.Min || n > p.Max {
+			return fmt.Sprintf("must be between %d and %d", p.Min, p.Max)
+		}
+	}
+	return ""
+}
+
+// validateQuery checks the request's query parameters against the schema.
+func (s apiSchema) validateQuery(c *gin.Context) []fieldError {
+	var errs []fieldError
+	for _, p := range s.Query {
+		value, ok := c.GetQuery(p.Name)
+		if !ok {
+			if p.Required {
+				errs = append(errs, fieldError{Field: p.Name, In: "query", Detail: "is required"})
+			}
+			continue
+		}
+		if msg := p.check(value); msg != "" {
+			errs = append(errs, fieldError{Field: p.Name, In: "query", Detail: msg})
+		}
+	}
+	return errs
+}
+
+// errAPIBody reports a body that is not a JSON object.
+var errAPIBody = errors.New("body must be a JSON object")
+
+// validateBody checks a JSON object body against the schema. The body is put back so the
+// handler can bind it.
+func (s apiSchema) validateBody(c *gin.Context) ([]fieldError, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxAPIBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return nil, errAPIBody
+	}
+	var errs []fieldError
+	for name := range fields {
+		if !slices.ContainsFunc(s.Body, func(p apiParam) bool { return p.Name == name }) {
+			errs = append(errs, fieldError{Field: name, In: "body", Detail: "is not a known field"})
+		}
+	}
+	for _, p := range s.Body {
+		raw, ok := fields[p.Name]
+		if !ok {
+			if p.Required {
+				errs = append(errs, fieldError{Field: p.Name, In: "body", Detail: "is required"})
+			}
+			continue
+		}
+		if p.Boolean {
+			if _, ok := raw.(bool); !ok {
+				errs = append(errs, fieldError{Field: p.Name, In: "body", Detail: "must be true or false"})
+			}
+			continue
+		}
+		value, ok := raw.(string)
+		if !ok {
+			errs = append(errs, fieldError{Field: p.Name, In: "body", Detail: "must be a string"})
+			continue
+		}
+		if msg := p.check(value); msg != "" {
+			errs = append(errs, fieldError{Field: p.Name, In: "body", Detail: msg})
+		}
+	}
+	slices.SortFunc(errs, func(a, b fieldError) int { return cmp.Compare(a.Field, b.Field) })
+	return errs, nil
+}
+
+// apiValidationMiddleware rejects API requests that do not match their route's schema with a
+// problem details response, so handlers only see well-formed input.
+func (app *App) apiValidationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		schema, ok := apiSchemas[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+		errs := schema.validateQuery(c)
+		if schema.Body != nil {
+			bodyErrs, err := schema.validateBody(c)
+			var tooLarge *http.MaxBytesError
+			switch {
+			case errors.As(err, &tooLarge):
+				writeProblem(c, http.StatusRequestEntityTooLarge, ErrorCodePayloadTooLarge, "request body too large")
+				return
+			case err != nil:
+				writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, errAPIBody.Error())
+				return
+			}
+			errs = append(errs, bodyErrs...)
+		}
+		if len(errs) > 0 {
+			app.incMetric(MetricRejectedAPIRequests)
+			writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "request does not match the API schema", errs...)
+			return
+		}
+		c.Next()
+	}
+}
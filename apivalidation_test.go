@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIValidationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Games = newGameTokens(newKeyring(make([]byte, 32)))
+	router := gin.New()
+	router.Use(app.apiValidationMiddleware())
+	router.GET(RouteValidate, app.validateGuessHandler)
+	router.GET(RouteGlobalStats, app.globalStatsHandler)
+	router.POST(RouteGamesAPI, app.createGameAPIHandler)
+
+	tests := []struct {
+		method, target, body string
+		status               int
+		code                 string
+		fields               []string
+	}{
+		{"GET", RouteValidate + "?guess=crane", "", http.StatusOK, "", nil},
+		{"GET", RouteValidate, "", http.StatusBadRequest, ErrorCodeInvalidRequest, []string{"guess"}},
+		{"GET", RouteValidate + "?guess=cr4ne", "", http.StatusBadRequest, ErrorCodeInvalidRequest, []string{"guess"}},
+		{"GET", RouteGlobalStats + "?days=7", "", http.StatusOK, "", nil},
+		{"GET", RouteGlobalStats + "?days=0", "", http.StatusBadRequest, ErrorCodeInvalidRequest, []string{"days"}},
+		{"GET", RouteGlobalStats + "?days=week", "", http.StatusBadRequest, ErrorCodeInvalidRequest, []string{"days"}},
+		{"POST", RouteGamesAPI, `{"word":"slate"}`, http.StatusCreated, "", nil},
+		{"POST", RouteGamesAPI, `{"word":5,"level":"hard"}`, http.StatusBadRequest, ErrorCodeInvalidRequest, []string{"level", "word"}},
+		{"POST", RouteGamesAPI, `[]`, http.StatusBadRequest, ErrorCodeInvalidRequest, nil},
+		{"POST", RouteGamesAPI, `{"word":"zzzzz"}`, http.StatusUnprocessableEntity, ErrorCodeWordNotAccepted, nil},
+		{"POST", RouteGamesAPI, `{"seed":"a","pack":"missing"}`, http.StatusUnprocessableEntity, ErrorCodeUnknownPack, nil},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s %s %s: got %d %s", tt.method, tt.target, tt.body, w.Code, w.Body.String())
+			continue
+		}
+		if tt.code == "" {
+			continue
+		}
+		var p problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		if w.Header().Get("Content-Type") != ProblemContentType || p.Code != tt.code || p.Type != ProblemTypePrefix+tt.code || p.Status != tt.status {
+			t.Errorf("%s %s: unexpected problem %q %+v", tt.method, tt.target, w.Header().Get("Content-Type"), p)
+		}
+		var fields []string
+		for _, e := range p.Errors {
+			fields = append(fields, e.Field)
+		}
+		if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+			t.Errorf("%s %s %s: invalid fields %v, want %v", tt.method, tt.target, tt.body, fields, tt.fields)
+		}
+	}
+}
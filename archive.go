@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"time"
+)
+
+// PlayerArchive holds finished games moved out of memory once they are older than After, one
+// gzip-compressed JSON file per player. Games are appended as batches of concatenated gzip
+// members, so archiving never rewrites what is already there, and reading the file back
+// decompresses every batch in order.
+type PlayerArchive struct {
+	storage Storage
+	dir     string
+	After   time.Duration
+}
+
+// newPlayerArchive returns an archive under dir for games older than after, or nil when after
+// is not positive, which keeps every game in memory.
+func newPlayerArchive(dir string, after time.Duration) *PlayerArchive {
+	if after <= 0 {
+		return nil
+	}
+	return &PlayerArchive{storage: DirStorage{}, dir: dir, After: after}
+}
+
+// name returns the archive file for a stats key. Keys are hashed since they embed session IDs.
+func (a *PlayerArchive) name(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return path.Join(a.dir, hex.EncodeToString(sum[:16])+".json.gz")
+}
+
+// read returns the raw archive file for key, or nil when there is none.
+func (a *PlayerArchive) read(key string) ([]byte, error) {
+	data, err := a.storage.ReadFile(a.name(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// load returns the archived games of key, oldest first.
+func (a *PlayerArchive) load(key string) ([]GameRecord, error) {
+	if a == nil {
+		return nil, nil
+	}
+	data, err := a.read(key)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var games []GameRecord
+	dec := json.NewDecoder(zr)
+	for {
+		var batch []GameRecord
+		if err := dec.Decode(&batch); err == io.EOF {
+			return games, nil
+		} else if err != nil {
+			return nil, err
+		}
+		games = append(games, batch...)
+	}
+}
+
+// append adds a batch of games to the end of key's archive.
+func (a *PlayerArchive) append(key string, games []GameRecord) error {
+	data, err := a.read(key)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(games); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return a.storage.WriteFile(a.name(key), append(data, buf.Bytes()...))
+}
+
+// take returns the archived games of key and empties its archive.
+func (a *PlayerArchive) take(key string) ([]GameRecord, error) {
+	games, err := a.load(key)
+	if err != nil || len(games) == 0 {
+		return games, err
+	}
+	return games, a.storage.WriteFile(a.name(key), nil)
+}
+
+// dropBefore deletes key's archived games finished before cutoff, rewriting the archive as a
+// single batch, and returns the games kept and the number deleted. A nil archive has nothing.
+func (a *PlayerArchive) dropBefore(key string, cutoff time.Time) ([]GameRecord, int, error) {
+	games, err := a.load(key)
+	if err != nil || len(games) == 0 {
+		return games, 0, err
+	}
+	i := 0
+	for i < len(games) && games[i].FinishedAt.Before(cutoff) {
+		i++
+	}
+	if i == 0 {
+		return games, 0, nil
+	}
+	if err := a.storage.WriteFile(a.name(key), nil); err != nil {
+		return nil, 0, err
+	}
+	kept := games[i:]
+	if len(kept) == 0 {
+		return nil, i, nil
+	}
+	return kept, i, a.append(key, kept)
+}
+
+// archiveBefore moves each player's games finished before cutoff to the archive, folding them
+// into the player's carried aggregates so summaries do not change. Players are handled one at
+// a time so recording new games is only held up for one player's write.
+func (ps *PlayerStatsStore) archiveBefore(cutoff time.Time) (players, games int, err error) {
+	var keys []string
+	ps.mu.RLock()
+	for key, history := range ps.players {
+		if len(history) > 0 && history[0].FinishedAt.Before(cutoff) {
+			keys = append(keys, key)
+		}
+	}
+	ps.mu.RUnlock()
+
+	for _, key := range keys {
+		n, keyErr := ps.archiveKey(key, cutoff)
+		if keyErr != nil {
+			err = errors.Join(err, keyErr)
+			continue
+		}
+		if n > 0 {
+			players++
+			games += n
+		}
+	}
+	return players, games, err
+}
+
+// archiveKey moves one player's games finished before cutoff to the archive.
+func (ps *PlayerStatsStore) archiveKey(key string, cutoff time.Time) (int, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	history := ps.players[key]
+	n := 0
+	for n < len(history) && history[n].FinishedAt.Before(cutoff) {
+		n++
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	if err := ps.archive.append(key, history[:n]); err != nil {
+		return 0, err
+	}
+	carry := ps.carry[key]
+	for _, rec := range history[:n] {
+		carry.add(rec, ps.streaks)
+	}
+	ps.carry[key] = carry
+	ps.dirty = true
+	if n == len(history) {
+		delete(ps.players, key)
+	} else {
+		ps.players[key] = slices.Clone(history[n:])
+	}
+	return n, nil
+}
+
+// archivePlayers moves old games to the archive every interval for the life of the process.
+func (app *App) archivePlayers(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		app.archiveOldGames()
+	}
+}
+
+// archiveOldGames moves games older than the archive age out of memory and counts them.
+func (app *App) archiveOldGames() {
+	cutoff := app.now().UTC().Add(-app.Players.archive.After)
+	players, games, err := app.Players.archiveBefore(cutoff)
+	if err != nil {
+		logWarn("Failed to archive some finished games: %v", err)
+	}
+	if games > 0 {
+		if app.Metrics != nil {
+			app.Metrics.Add(MetricGamesArchived, int64(games))
+		}
+		logInfo("Archived %d finished games from %d players", games, players)
+	}
+}
+
+// rehydrateHistory prepends key's archived games to history, the games still in memory.
+func (app *App) rehydrateHistory(key string, history []GameRecord) ([]GameRecord, error) {
+	archived, err := app.Players.archive.load(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(archived) == 0 {
+		return history, nil
+	}
+	app.incMetric(MetricArchiveRehydrated)
+	return append(archived, history...), nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestArchiveKeepsSummaryAndRehydratesHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rules := StreakFreezeRules{WinsPerFreeze: 2, MaxFreezes: 1}
+	app := &App{Players: newPlayerStatsStore(rules)}
+	app.Players.archive = newPlayerArchive("archive", 30*24*time.Hour)
+	app.Players.archive.storage = newMemStorage()
+
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return now.AddDate(0, 0, n) }
+	for i, rec := range []GameRecord{
+		{FinishedAt: day(-60), Word: "CRANE", Won: true, Guesses: 3, PuzzleDate: "2026-04-02"},
+		{FinishedAt: day(-59), Word: "SLATE", Won: true, Guesses: 4, PuzzleDate: "2026-04-03"},
+		{FinishedAt: day(-45), Word: "TIGER", Won: false, Guesses: MaxGuesses, PuzzleDate: "2026-04-17"},
+		{FinishedAt: day(-1), Word: "PLANT", Won: true, Guesses: 2, PuzzleDate: "2026-05-31"},
+	} {
+		app.Players.record("player", rec)
+		if i == 1 {
+			// A second archive pass appends another batch to the same file.
+			if _, games, err := app.Players.archiveBefore(day(-50)); err != nil || games != 2 {
+				t.Fatalf("first archive pass moved %d games: %v", games, err)
+			}
+		}
+	}
+	_, _, before := app.Players.summary("player", "2026-06-01")
+	if _, games, err := app.Players.archiveBefore(day(-30)); err != nil || games != 1 {
+		t.Fatalf("second archive pass moved %d games: %v", games, err)
+	}
+
+	history, _, after := app.Players.summary("player", "2026-06-01")
+	if len(history) != 1 || history[0].Word != "PLANT" {
+		t.Errorf("hot history = %+v, want only the recent game", history)
+	}
+	if after != before {
+		t.Errorf("summary changed by archiving: %+v, want %+v", after, before)
+	}
+	if want := summarize(append(mustLoadArchive(t, app), history...), rules, "2026-06-01"); after != want {
+		t.Errorf("summary = %+v, want %+v from the full history", after, want)
+	}
+
+	router := gin.New()
+	router.GET(RouteStatsExport, app.exportStatsHandler)
+	req := httptest.NewRequest("GET", RouteStatsExport, nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "player"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var body struct {
+		History []GameRecord `json:"history"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	var words []string
+	for _, rec := range body.History {
+		words = append(words, rec.Word)
+	}
+	if len(words) != 4 || words[0] != "CRANE" || words[2] != "TIGER" || words[3] != "PLANT" {
+		t.Errorf("exported history = %v, want all four games oldest first", words)
+	}
+}
+
+func TestAdoptRehydratesArchivedGames(t *testing.T) {
+	ps := newPlayerStatsStore(StreakFreezeRules{})
+	ps.archive = newPlayerArchive("archive", time.Hour)
+	ps.archive.storage = newMemStorage()
+	old := time.Now().Add(-2 * time.Hour)
+	ps.record("session", GameRecord{FinishedAt: old, Word: "CRANE", Won: true, Guesses: 3})
+	ps.record("session", GameRecord{FinishedAt: time.Now(), Word: "SLATE", Won: true, Guesses: 4})
+	if _, games, err := ps.archiveBefore(time.Now().Add(-time.Hour)); err != nil || games != 1 {
+		t.Fatalf("archived %d games: %v", games, err)
+	}
+
+	ps.adopt("session", "player")
+	history, _, summary := ps.summary("player", "")
+	if len(history) != 2 || summary.Played != 2 || summary.CurrentStreak != 2 {
+		t.Errorf("adopted history = %+v, summary = %+v; want both games", history, summary)
+	}
+	if archived, _ := ps.archive.load("session"); len(archived) != 0 {
+		t.Errorf("old key still has %d archived games", len(archived))
+	}
+}
+
+// mustLoadArchive returns the archived games of the test player.
+func mustLoadArchive(t *testing.T, app *App) []GameRecord {
+	t.Helper()
+	games, err := app.Players.archive.load("player")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return games
+}
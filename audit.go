@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Audit log constants
+const (
+	// AuditPageSize is the number of entries the admin audit page shows.
+	AuditPageSize = 200
+	// AuditActorHeader lets an operator sharing the admin token name themselves in the log.
+	AuditActorHeader = "X-Audit-Actor"
+	// maxAuditActorLength bounds the operator name taken from AuditActorHeader.
+	maxAuditActorLength = 64
+	// auditSummaryKey holds a handler's before/after summary in the Gin context.
+	auditSummaryKey = "audit_summary"
+	// AuditActionPruned marks the checkpoint the retention job leaves in place of the entries
+	// it pruned. Its Before holds the hash the first remaining entry chains from.
+	AuditActionPruned = "retention prune"
+)
+
+// AuditEntry is one admin or destructive action. Hash chains each entry to the one before it,
+// so an edited or deleted line breaks every hash after it.
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`
+	IP     string    `json:"ip,omitempty"`
+	Action string    `json:"action"`
+	Target string    `json:"target,omitempty"`
+	// Status is the HTTP status of the response, or 0 for a command-line run.
+	Status    int    `json:"status"`
+	Before    string `json:"before,omitempty"`
+	After     string `json:"after,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Hash      string `json:"hash"`
+}
+
+// auditSummary is what a handler reports about the change it made.
+type auditSummary struct {
+	target, before, after string
+}
+
+// AuditLog is an append-only JSON Lines file of AuditEntry, each entry synced to disk as it is
+// written. Only the retention job rewrites it, replacing the entries it prunes with a single
+// checkpoint so the rest of the chain still verifies.
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	last string
+}
+
+// openAuditLog opens the audit log at path for appending, creating it when missing, and picks
+// up the hash chain from its last entry.
+func openAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	entries, err := readAuditEntries(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{path: path, file: f, last: auditChainHead(entries)}, nil
+}
+
+// auditChainHead returns the hash the entry after entries chains from.
+func auditChainHead(entries []AuditEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	if len(entries) == 1 && entries[0].Action == AuditActionPruned {
+		return entries[0].Before
+	}
+	return entries[len(entries)-1].Hash
+}
+
+// auditHash returns the chained hash of entry, computed with its Hash field empty.
+func auditHash(prev string, entry AuditEntry) string {
+	entry.Hash = ""
+	data, _ := json.Marshal(entry)
+	sum := sha256.Sum256(append([]byte(prev), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+// record appends entry to the log. A nil log records nothing.
+func (al *AuditLog) record(entry AuditEntry) error {
+	if al == nil {
+		return nil
+	}
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	entry.Time = entry.Time.UTC()
+	entry.Hash = auditHash(al.last, entry)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := al.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err := al.file.Sync(); err != nil {
+		return err
+	}
+	al.last = entry.Hash
+	return nil
+}
+
+// raw returns the log file as stored.
+func (al *AuditLog) raw() ([]byte, error) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	data, err := os.ReadFile(al.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// entries returns every entry in the log, oldest first.
+func (al *AuditLog) entries() ([]AuditEntry, error) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	return readAuditEntries(al.path)
+}
+
+// pruneBefore replaces the entries written before cutoff with a checkpoint dated cutoff and
+// returns how many were removed. The checkpoint records the hash the first kept entry chains
+// from, so the chain of what is left still verifies, and a later prune replaces it in turn.
+func (al *AuditLog) pruneBefore(cutoff time.Time) (int, error) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	entries, err := readAuditEntries(al.path)
+	if err != nil {
+		return 0, err
+	}
+	n, removed := 0, 0
+	for n < len(entries) && entries[n].Time.Before(cutoff) {
+		if entries[n].Action != AuditActionPruned {
+			removed++
+		}
+		n++
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	checkpoint := AuditEntry{
+		Time:   cutoff.UTC(),
+		Actor:  "retention",
+		Action: AuditActionPruned,
+		Before: auditChainHead(entries[:n]),
+		After:  strconv.Itoa(removed) + " older entries removed",
+	}
+	checkpoint.Hash = auditHash("", checkpoint)
+	var buf bytes.Buffer
+	for _, entry := range append([]AuditEntry{checkpoint}, entries[n:]...) {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return 0, err
+		}
+		buf.Write(append(data, '\n'))
+	}
+	if err := (DirStorage{}).WriteFile(al.path, buf.Bytes()); err != nil {
+		return 0, err
+	}
+	// The rename left the open file pointing at the old log.
+	f, err := os.OpenFile(al.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return removed, err
+	}
+	al.file.Close()
+	al.file = f
+	return removed, nil
+}
+
+// close closes the log file.
+func (al *AuditLog) close() error {
+	if al == nil {
+		return nil
+	}
+	return al.file.Close()
+}
+
+// readAuditEntries parses the audit log at path. A missing file has no entries.
+func readAuditEntries(path string) ([]AuditEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return entries, errors.New("audit log line " + strconv.Itoa(line) + " is not valid JSON")
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// verifyAuditChain returns the index of the first entry whose hash does not follow from the
+// entries before it, or -1 when the chain is intact. A pruning checkpoint is only accepted as
+// the first entry.
+func verifyAuditChain(entries []AuditEntry) int {
+	prev := ""
+	for i, entry := range entries {
+		if auditHash(prev, entry) != entry.Hash {
+			return i
+		}
+		prev = entry.Hash
+		if i == 0 && entry.Action == AuditActionPruned {
+			prev = entry.Before
+		}
+	}
+	return -1
+}
+
+// auditChange records what a handler changed, for the audit entry written after it returns.
+// Target names what was acted on and must already be redacted where it would expose a session.
+func auditChange(c *gin.Context, target, before, after string) {
+	c.Set(auditSummaryKey, auditSummary{target: target, before: before, after: after})
+}
+
+// auditActor names who made a request: the admin token holder, with the operator's own name
+// when they send one in AuditActorHeader.
+func auditActor(c *gin.Context) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(c.GetHeader(AuditActorHeader)))
+	if name == "" {
+		return "admin"
+	}
+	if len(name) > maxAuditActorLength {
+		name = name[:maxAuditActorLength]
+	}
+	return "admin:" + strings.ToValidUTF8(name, "")
+}
+
+// auditMiddleware writes an audit entry for every authenticated admin request that can change
+// something, whether or not it succeeded. Reads are not audited. The route pattern is logged rather than
+// the path, so session IDs in the path stay out of the log.
+func (app *App) auditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if app.Audit == nil || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			return
+		}
+		entry := AuditEntry{
+			Time:      app.now(),
+			Actor:     auditActor(c),
+			IP:        c.ClientIP(),
+			Action:    c.Request.Method + " " + c.FullPath(),
+			Status:    c.Writer.Status(),
+			RequestID: requestIDFrom(c.Request.Context()),
+		}
+		if v, ok := c.Get(auditSummaryKey); ok {
+			s := v.(auditSummary)
+			entry.Target, entry.Before, entry.After = s.target, s.before, s.after
+		}
+		if query := c.Request.URL.Query(); len(query) > 0 {
+			entry.Target = strings.TrimSpace(entry.Target + " " + query.Encode())
+		}
+		if err := app.Audit.record(entry); err != nil {
+			logWarn("Failed to write audit log: %v", err)
+			app.incMetric(MetricAuditFailures)
+		}
+	}
+}
+
+// adminAuditHandler shows the latest audit entries, newest first, as a page or as JSON with
+// Accept: application/json. An action query parameter filters by action prefix.
+func (app *App) adminAuditHandler(c *gin.Context) {
+	if app.Audit == nil {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "audit log is disabled")
+		return
+	}
+	entries, err := app.Audit.entries()
+	if err != nil {
+		logWarn("Failed to read audit log: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not read audit log")
+		return
+	}
+	broken := verifyAuditChain(entries)
+	action := c.Query("action")
+	shown := make([]AuditEntry, 0, AuditPageSize)
+	for _, entry := range slices.Backward(entries) {
+		if len(shown) == AuditPageSize {
+			break
+		}
+		if strings.HasPrefix(entry.Action, action) {
+			shown = append(shown, entry)
+		}
+	}
+	c.Header("Cache-Control", "no-store")
+	if wantsJSON(c) {
+		c.JSON(http.StatusOK, gin.H{"entries": shown, "total": len(entries), "chain_intact": broken < 0})
+		return
+	}
+	c.HTML(http.StatusOK, "admin-audit.html", gin.H{
+		"title":       "Vortludo - Audit Log",
+		"entries":     shown,
+		"total":       len(entries),
+		"action":      action,
+		"brokenAt":    broken + 1,
+		"chainIntact": broken < 0,
+	})
+}
+
+// adminAuditExportHandler downloads the whole audit log as JSON Lines, exactly as stored so
+// the hash chain can be checked offline, or as CSV with format=csv.
+func (app *App) adminAuditExportHandler(c *gin.Context) {
+	if app.Audit == nil {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "audit log is disabled")
+		return
+	}
+	format := c.DefaultQuery("format", ExportFormatJSON)
+	if format != ExportFormatJSON && format != ExportFormatCSV {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "format must be json or csv")
+		return
+	}
+	raw, err := app.Audit.raw()
+	var entries []AuditEntry
+	if err == nil && format == ExportFormatCSV {
+		entries, err = app.Audit.entries()
+	}
+	if err != nil {
+		logWarn("Failed to read audit log: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not read audit log")
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	if format == ExportFormatJSON {
+		c.Header("Content-Disposition", `attachment; filename="vortludo-audit.jsonl"`)
+		c.Data(http.StatusOK, "application/x-ndjson", raw)
+		return
+	}
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{"time", "actor", "ip", "action", "target", "status", "before", "after", "request_id", "hash"})
+	for _, e := range entries {
+		w.Write([]string{e.Time.Format(time.RFC3339), e.Actor, e.IP, e.Action, e.Target, strconv.Itoa(e.Status), e.Before, e.After, e.RequestID, e.Hash})
+	}
+	w.Flush()
+	c.Header("Content-Disposition", `attachment; filename="vortludo-audit.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", b.Bytes())
+}
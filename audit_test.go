@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditLogChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	al, err := openAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	al.record(AuditEntry{Time: now, Actor: "admin", Action: "POST /admin/daily/roll", Status: 200})
+	al.close()
+
+	al, err = openAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer al.close()
+	al.record(AuditEntry{Time: now.Add(time.Minute), Actor: "admin", Action: "POST /admin/blocklist", Target: "192.0.2.1", After: "blocked"})
+	entries, err := al.entries()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("entries = %+v, %v", entries, err)
+	}
+	if broken := verifyAuditChain(entries); broken != -1 {
+		t.Errorf("Expected an intact chain across reopening, broken at %d", broken)
+	}
+
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), "192.0.2.1", "192.0.2.9", 1)), 0o600)
+	entries, _ = readAuditEntries(path)
+	if broken := verifyAuditChain(entries); broken != 1 {
+		t.Errorf("Expected the edited entry to break the chain, broken at %d", broken)
+	}
+}
+
+func TestAdminActionsAreAudited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	al, err := openAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer al.close()
+	app := &App{
+		AdminToken: "secret",
+		Audit:      al,
+		Blocklist:  newBlocklist("", 0, time.Minute, time.Minute),
+		Quarantine: newSessionQuarantine(time.Hour, 10),
+	}
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	admin := router.Group(RouteAdmin, app.adminAuthMiddleware(), app.auditMiddleware())
+	admin.POST("/blocklist", app.adminBlocklistAddHandler)
+	admin.GET("/blocklist", app.adminBlocklistHandler)
+	admin.POST("/sessions/:id/restore", app.adminRestoreSessionHandler)
+	admin.GET("/audit", app.adminAuditHandler)
+	admin.GET("/audit/export", app.adminAuditExportHandler)
+	do := func(method, path, body, token string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	do("POST", RouteAdmin+"/blocklist", `{"entry":"192.0.2.1"}`, "secret", AuditActorHeader, "alice")
+	do("GET", RouteAdmin+"/blocklist", "", "secret")
+	do("POST", RouteAdmin+"/sessions/secret-session-id/restore", "", "secret")
+	do("POST", RouteAdmin+"/blocklist", `{"entry":"192.0.2.2"}`, "wrong")
+
+	entries, err := al.entries()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected the two authenticated writes to be audited, got %+v, %v", entries, err)
+	}
+	if e := entries[0]; e.Actor != "admin:alice" || e.Action != "POST /admin/blocklist" || e.Target != "192.0.2.1/32" || e.After != "blocked" || e.Status != http.StatusCreated {
+		t.Errorf("blocklist entry = %+v", e)
+	}
+	if e := entries[1]; e.Action != "POST /admin/sessions/:id/restore" || e.Status != http.StatusNotFound || strings.Contains(e.Target+e.Action, "secret-session-id") {
+		t.Errorf("restore entry = %+v", e)
+	}
+
+	w := do("GET", RouteAdmin+"/audit", "", "secret", "Accept", "application/json")
+	var page struct {
+		Entries     []AuditEntry `json:"entries"`
+		ChainIntact bool         `json:"chain_intact"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || len(page.Entries) != 2 || !page.ChainIntact || page.Entries[0].Action != "POST /admin/sessions/:id/restore" {
+		t.Errorf("audit page = %s", w.Body.String())
+	}
+	if w := do("GET", RouteAdmin+"/audit", "", "secret"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "admin:alice") {
+		t.Errorf("audit HTML page = %d", w.Code)
+	}
+	if w := do("GET", RouteAdmin+"/audit/export", "", "secret"); strings.Count(w.Body.String(), "\n") != 2 {
+		t.Errorf("JSON Lines export = %q", w.Body.String())
+	}
+	if w := do("GET", RouteAdmin+"/audit/export?format=csv", "", "secret"); !strings.HasPrefix(w.Body.String(), "time,actor,") {
+		t.Errorf("CSV export = %q", w.Body.String())
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"errors"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Blocklist tracks blocked networks, temporary bans, and abuse strikes per client IP.
+// Permanent entries and active bans are persisted to a JSON file so they survive restarts.
+type Blocklist struct {
+	mu          sync.RWMutex
+	storage     Storage
+	path        string
+	prefixes    map[netip.Prefix]struct{}
+	bans        map[netip.Addr]time.Time
+	strikes     map[netip.Addr][]time.Time
+	threshold   int
+	window      time.Duration
+	banDuration time.Duration
+	clock       Clock
+}
+
+// blocklistFile is the on-disk representation of a Blocklist.
+type blocklistFile struct {
+	Entries []string             `json:"entries"`
+	Bans    map[string]time.Time `json:"bans"`
+}
+
+// newBlocklist creates an empty Blocklist persisted at path (empty path disables persistence).
+// A client is banned for banDuration after threshold strikes within window; threshold <= 0 disables
+// auto-bans while still counting strikes.
+func newBlocklist(path string, threshold int, window, banDuration time.Duration) *Blocklist {
+	return &Blocklist{
+		storage:     DirStorage{},
+		path:        path,
+		prefixes:    make(map[netip.Prefix]struct{}),
+		bans:        make(map[netip.Addr]time.Time),
+		strikes:     make(map[netip.Addr][]time.Time),
+		threshold:   threshold,
+		window:      window,
+		banDuration: banDuration,
+		clock:       systemClock{},
+	}
+}
+
+// parseBlocklistEntry parses an exact IP or a CIDR into a masked prefix.
+func parseBlocklistEntry(entry string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(entry); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, errors.New("entry must be an IP address or CIDR")
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// parseClientAddr parses a client IP string as returned by gin's ClientIP.
+func parseClientAddr(ip string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// load reads persisted entries and unexpired bans from disk. A missing file is not an error.
+func (b *Blocklist) load() error {
+	var f blocklistFile
+	if found, err := readJSONFile(b.storage, b.path, &f); err != nil || !found {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, entry := range f.Entries {
+		prefix, err := parseBlocklistEntry(entry)
+		if err != nil {
+			logWarn("Skipping invalid blocklist entry %q: %v", entry, err)
+			continue
+		}
+		b.prefixes[prefix] = struct{}{}
+	}
+	now := b.clock.Now()
+	for ip, until := range f.Bans {
+		addr, ok := parseClientAddr(ip)
+		if !ok || !until.After(now) {
+			continue
+		}
+		b.bans[addr] = until
+	}
+	return nil
+}
+
+// save writes the blocklist to disk atomically. Callers must hold b.mu.
+func (b *Blocklist) save() error {
+	if b.path == "" {
+		return nil
+	}
+	f := blocklistFile{Entries: b.entriesLocked(), Bans: make(map[string]time.Time, len(b.bans))}
+	for addr, until := range b.bans {
+		f.Bans[addr.String()] = until
+	}
+	return writeJSONFile(b.storage, b.path, f)
+}
+
+// entriesLocked returns the sorted permanent entries. Callers must hold b.mu.
+func (b *Blocklist) entriesLocked() []string {
+	entries := make([]string, 0, len(b.prefixes))
+	for prefix := range b.prefixes {
+		entries = append(entries, prefix.String())
+	}
+	slices.Sort(entries)
+	return entries
+}
+
+// add blocks an IP address or CIDR and persists the change.
+func (b *Blocklist) add(entry string) (string, error) {
+	prefix, err := parseBlocklistEntry(entry)
+	if err != nil {
+		return "", err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prefixes[prefix] = struct{}{}
+	return prefix.String(), b.save()
+}
+
+// remove unblocks an IP address or CIDR, also lifting any temporary ban on an exact IP.
+// It reports whether anything was removed.
+func (b *Blocklist) remove(entry string) (bool, error) {
+	prefix, err := parseBlocklistEntry(entry)
+	if err != nil {
+		return false, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, existed := b.prefixes[prefix]
+	delete(b.prefixes, prefix)
+	if prefix.IsSingleIP() {
+		if _, banned := b.bans[prefix.Addr()]; banned {
+			existed = true
+			delete(b.bans, prefix.Addr())
+			delete(b.strikes, prefix.Addr())
+		}
+	}
+	if !existed {
+		return false, nil
+	}
+	return true, b.save()
+}
+
+// isBlocked reports whether the client IP is covered by a permanent entry or an active ban.
+func (b *Blocklist) isBlocked(ip string) bool {
+	addr, ok := parseClientAddr(ip)
+	if !ok {
+		return false
+	}
+
+	b.mu.RLock()
+	until, banned := b.bans[addr]
+	if banned && b.clock.Now().Before(until) {
+		b.mu.RUnlock()
+		return true
+	}
+	for prefix := range b.prefixes {
+		if prefix.Contains(addr) {
+			b.mu.RUnlock()
+			return true
+		}
+	}
+	b.mu.RUnlock()
+
+	if banned {
+		b.mu.Lock()
+		if until, ok := b.bans[addr]; ok && !b.clock.Now().Before(until) {
+			delete(b.bans, addr)
+		}
+		b.mu.Unlock()
+	}
+	return false
+}
+
+// recordStrike registers an abuse strike for the client IP and bans it once the threshold
+// is reached within the window. It reports whether the strike resulted in a new ban.
+func (b *Blocklist) recordStrike(ip string) bool {
+	addr, ok := parseClientAddr(ip)
+	if !ok || addr.IsLoopback() {
+		return false
+	}
+
+	now := b.clock.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until, banned := b.bans[addr]; banned && now.Before(until) {
+		return false
+	}
+
+	recent := slices.DeleteFunc(b.strikes[addr], func(t time.Time) bool {
+		return now.Sub(t) > b.window
+	})
+	recent = append(recent, now)
+	if b.threshold <= 0 || len(recent) < b.threshold {
+		b.strikes[addr] = recent
+		return false
+	}
+
+	delete(b.strikes, addr)
+	b.bans[addr] = now.Add(b.banDuration)
+	if err := b.save(); err != nil {
+		logWarn("Failed to persist blocklist: %v", err)
+	}
+	return true
+}
+
+// strikeCount returns the number of strikes recorded for the client IP within the window.
+func (b *Blocklist) strikeCount(ip string) int {
+	addr, ok := parseClientAddr(ip)
+	if !ok {
+		return 0
+	}
+	now := b.clock.Now()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	count := 0
+	for _, t := range b.strikes[addr] {
+		if now.Sub(t) <= b.window {
+			count++
+		}
+	}
+	return count
+}
+
+// clearStrikes forgets all strikes recorded for the client IP.
+func (b *Blocklist) clearStrikes(ip string) {
+	addr, ok := parseClientAddr(ip)
+	if !ok {
+		return
+	}
+	b.mu.Lock()
+	delete(b.strikes, addr)
+	b.mu.Unlock()
+}
+
+// prune forgets strikes older than the window and bans that have run out, so clients that
+// stop misbehaving do not stay in memory. It returns how many clients were forgotten.
+func (b *Blocklist) prune() int {
+	now := b.clock.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for addr, times := range b.strikes {
+		recent := slices.DeleteFunc(times, func(t time.Time) bool {
+			return now.Sub(t) > b.window
+		})
+		if len(recent) == 0 {
+			delete(b.strikes, addr)
+			n++
+			continue
+		}
+		b.strikes[addr] = recent
+	}
+	for addr, until := range b.bans {
+		if !now.Before(until) {
+			delete(b.bans, addr)
+			n++
+		}
+	}
+	return n
+}
+
+// snapshot returns the permanent entries and active bans for display.
+func (b *Blocklist) snapshot() ([]string, map[string]time.Time) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	now := b.clock.Now()
+	bans := make(map[string]time.Time, len(b.bans))
+	for addr, until := range b.bans {
+		if until.After(now) {
+			bans[addr.String()] = until
+		}
+	}
+	return b.entriesLocked(), bans
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBlocklistEntry(t *testing.T) {
+	cases := map[string]string{
+		"203.0.113.7":     "203.0.113.7/32",
+		"203.0.113.77/24": "203.0.113.0/24",
+		"2001:db8::1":     "2001:db8::1/128",
+	}
+	for in, want := range cases {
+		got, err := parseBlocklistEntry(in)
+		if err != nil || got.String() != want {
+			t.Errorf("parseBlocklistEntry(%q) = %v, %v; want %s", in, got, err, want)
+		}
+	}
+	if _, err := parseBlocklistEntry("not-an-ip"); err == nil {
+		t.Error("Expected error for invalid entry")
+	}
+}
+
+func TestBlocklistAddRemove(t *testing.T) {
+	b := newBlocklist("", 0, time.Minute, time.Minute)
+	if _, err := b.add("198.51.100.0/24"); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if !b.isBlocked("198.51.100.42") {
+		t.Error("Expected IP inside CIDR to be blocked")
+	}
+	if b.isBlocked("198.51.101.1") {
+		t.Error("Expected IP outside CIDR not to be blocked")
+	}
+	removed, err := b.remove("198.51.100.0/24")
+	if err != nil || !removed {
+		t.Fatalf("remove = %v, %v; want true, nil", removed, err)
+	}
+	if b.isBlocked("198.51.100.42") {
+		t.Error("Expected IP to be unblocked after removal")
+	}
+}
+
+func TestBlocklistRecordStrike(t *testing.T) {
+	b := newBlocklist("", 3, time.Minute, time.Hour)
+	for i := 0; i < 2; i++ {
+		if b.recordStrike("192.0.2.1") {
+			t.Fatal("Should not ban before threshold")
+		}
+	}
+	if !b.recordStrike("192.0.2.1") {
+		t.Fatal("Expected ban at threshold")
+	}
+	if !b.isBlocked("192.0.2.1") {
+		t.Error("Expected banned IP to be blocked")
+	}
+	if b.recordStrike("127.0.0.1") || b.recordStrike("127.0.0.1") || b.recordStrike("127.0.0.1") {
+		t.Error("Loopback should never be banned")
+	}
+}
+
+func TestBlocklistPersistence(t *testing.T) {
+	st := newMemStorage()
+	b := newBlocklist("blocklist.json", 1, time.Minute, time.Hour)
+	b.storage = st
+	if _, err := b.add("203.0.113.0/24"); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	b.recordStrike("192.0.2.9")
+
+	reloaded := newBlocklist("blocklist.json", 1, time.Minute, time.Hour)
+	reloaded.storage = st
+	if err := reloaded.load(); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if !reloaded.isBlocked("203.0.113.5") {
+		t.Error("Expected persisted CIDR to be blocked after reload")
+	}
+	if !reloaded.isBlocked("192.0.2.9") {
+		t.Error("Expected persisted ban to be active after reload")
+	}
+}
+
+func TestBlocklistPrune(t *testing.T) {
+	clock := newFakeClock()
+	b := newBlocklist("", 2, time.Minute, time.Hour)
+	b.clock = clock
+	b.recordStrike("192.0.2.1")
+	b.recordStrike("192.0.2.2")
+	b.recordStrike("192.0.2.2")
+	clock.advance(30 * time.Second)
+	b.recordStrike("192.0.2.3")
+
+	clock.advance(45 * time.Second)
+	if n := b.prune(); n != 1 || len(b.strikes) != 1 || b.strikeCount("192.0.2.3") != 1 {
+		t.Errorf("prune() = %d leaving %d clients with strikes, want only the strike inside the window kept", n, len(b.strikes))
+	}
+	clock.advance(time.Hour)
+	if n := b.prune(); n != 2 || len(b.strikes)+len(b.bans) != 0 {
+		t.Errorf("prune() after the ban ran out = %d, want the ban and the last strike forgotten", n)
+	}
+}
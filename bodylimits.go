@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bodyRule limits the size and content type of a route's request body.
+type bodyRule struct {
+	MaxBytes     int64
+	ContentTypes []string
+}
+
+// Body rules for the two kinds of request body the app accepts
+var (
+	formBodyRule = bodyRule{MaxBytes: MaxFormBodyBytes, ContentTypes: []string{gin.MIMEPOSTForm, gin.MIMEMultipartPOSTForm}}
+	jsonBodyRule = bodyRule{MaxBytes: MaxAPIBodyBytes, ContentTypes: []string{gin.MIMEJSON}}
+)
+
+// bodyRules overrides the body rule of routes that do not take forms, keyed "METHOD route".
+// Admin routes take JSON and are matched by prefix in bodyRuleFor.
+var bodyRules = map[string]bodyRule{
+	http.MethodPost + " " + RouteGamesAPI:    jsonBodyRule,
+	http.MethodPost + " " + RouteStatsImport: jsonBodyRule,
+}
+
+// bodyRuleFor returns the body rule for a route.
+func bodyRuleFor(method, route string) bodyRule {
+	if rule, ok := bodyRules[method+" "+route]; ok {
+		return rule
+	}
+	if strings.HasPrefix(route, RouteAdmin+"/") {
+		return jsonBodyRule
+	}
+	return formBodyRule
+}
+
+// bodyLimitMiddleware rejects request bodies that are too large or of an unexpected content type
+// before anything reads them, and caps how much of the body handlers can read. Requests with an
+// empty body, such as fetch POSTs that carry only headers, are let through.
+func (app *App) bodyLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+		rule := bodyRuleFor(c.Request.Method, c.FullPath())
+		if c.Request.ContentLength > rule.MaxBytes {
+			app.incMetric(MetricRejectedBodies)
+			writeProblem(c, http.StatusRequestEntityTooLarge, ErrorCodePayloadTooLarge,
+				fmt.Sprintf("request body must be at most %d bytes", rule.MaxBytes))
+			return
+		}
+		if c.Request.ContentLength != 0 {
+			mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+			if !slices.Contains(rule.ContentTypes, mediaType) {
+				app.incMetric(MetricRejectedBodies)
+				writeProblem(c, http.StatusUnsupportedMediaType, ErrorCodeUnsupportedMediaType,
+					"content type must be one of "+strings.Join(rule.ContentTypes, ", "))
+				return
+			}
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, rule.MaxBytes)
+		c.Next()
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{Metrics: newMetrics()}
+	router := gin.New()
+	router.Use(app.bodyLimitMiddleware())
+	echo := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.String(http.StatusOK, "%d", len(body))
+	}
+	router.POST("/guess", echo)
+	router.POST(RouteGamesAPI, echo)
+	router.POST(RouteAdmin+"/blocklist", echo)
+
+	tests := []struct {
+		name, target, contentType, body string
+		chunked                         bool
+		status                          int
+	}{
+		{"form", "/guess", "application/x-www-form-urlencoded", "guess=crane", false, http.StatusOK},
+		{"form with charset", "/guess", "application/x-www-form-urlencoded; charset=UTF-8", "guess=crane", false, http.StatusOK},
+		{"json to form route", "/guess", "application/json", `{"guess":"crane"}`, false, http.StatusUnsupportedMediaType},
+		{"empty body", "/guess", "", "", false, http.StatusOK},
+		{"oversized form", "/guess", "application/x-www-form-urlencoded", strings.Repeat("a", MaxFormBodyBytes+1), false, http.StatusRequestEntityTooLarge},
+		{"oversized chunked form", "/guess", "application/x-www-form-urlencoded", strings.Repeat("a", MaxFormBodyBytes+1), true, http.StatusRequestEntityTooLarge},
+		{"json api", RouteGamesAPI, "application/json", `{"seed":"a"}`, false, http.StatusOK},
+		{"form to json api", RouteGamesAPI, "application/x-www-form-urlencoded", "seed=a", false, http.StatusUnsupportedMediaType},
+		{"oversized json", RouteGamesAPI, "application/json", `{"seed":"` + strings.Repeat("a", MaxAPIBodyBytes) + `"}`, false, http.StatusRequestEntityTooLarge},
+		{"admin json", RouteAdmin + "/blocklist", "application/json", `{"entry":"192.0.2.1"}`, false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body))
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: got %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
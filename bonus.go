@@ -0,0 +1,209 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+// Bonus round points
+const (
+	BonusAnagramPoints = 2
+	BonusRelatedPoints = 1
+)
+
+// bonusGrace absorbs network latency on answers submitted as the timer runs out.
+const bonusGrace = 2 * time.Second
+
+// bonusRound is one player's bonus round for the word they just won with.
+type bonusRound struct {
+	Word      string
+	StartedAt time.Time
+	Done      bool
+}
+
+// bonusView is the template data for the bonus round panel.
+type bonusView struct {
+	Active      bool
+	SecondsLeft int
+	Error       string
+	Result      string
+	Points      int
+	Answers     []string
+}
+
+// BonusRounds runs the optional bonus round offered after a win: within Duration, name an
+// anagram of the word or a related word from its pack entry. Anagrams are precomputed from the
+// accepted words at startup.
+type BonusRounds struct {
+	Duration time.Duration
+
+	mu       sync.Mutex
+	rounds   map[string]*bonusRound
+	anagrams map[string][]string
+	related  map[string][]string
+}
+
+// newBonusRounds indexes the anagrams in accepted and the related words listed in packs.
+func newBonusRounds(duration time.Duration, accepted map[string]struct{}, packs []*WordPack) *BonusRounds {
+	b := &BonusRounds{
+		Duration: duration,
+		rounds:   make(map[string]*bonusRound),
+		anagrams: make(map[string][]string),
+		related:  make(map[string][]string),
+	}
+	for word := range accepted {
+		key := sortedLetters(word)
+		b.anagrams[key] = append(b.anagrams[key], word)
+	}
+	for _, pack := range packs {
+		for _, entry := range pack.Words {
+			for _, r := range entry.Related {
+				b.related[entry.Word] = append(b.related[entry.Word], engine.NormalizeGuess(r))
+			}
+		}
+	}
+	return b
+}
+
+// sortedLetters returns the letters of word in alphabetical order, the key shared by anagrams.
+func sortedLetters(word string) string {
+	letters := strings.Split(word, "")
+	slices.Sort(letters)
+	return strings.Join(letters, "")
+}
+
+// answers returns the anagrams and related words accepted for word.
+func (b *BonusRounds) answers(word string) (anagrams, related []string) {
+	for _, w := range b.anagrams[sortedLetters(word)] {
+		if w != word {
+			anagrams = append(anagrams, w)
+		}
+	}
+	slices.Sort(anagrams)
+	return anagrams, b.related[word]
+}
+
+// offered reports whether a bonus round is available for game: it must be a won, non-custom
+// game whose word has at least one answer and whose round has not been played yet.
+func (b *BonusRounds) offered(sessionID string, game *GameState) bool {
+	if b == nil || b.Duration <= 0 || game == nil || !game.Won || game.Custom {
+		return false
+	}
+	anagrams, related := b.answers(game.SessionWord)
+	if len(anagrams) == 0 && len(related) == 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	round, ok := b.rounds[sessionID]
+	return !ok || round.Word != game.SessionWord
+}
+
+// start begins the round for word, dropping rounds that finished long ago. It reports false
+// when the session already played a round for this word.
+func (b *BonusRounds) start(sessionID, word string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if round, ok := b.rounds[sessionID]; ok && round.Word == word {
+		return false
+	}
+	for id, round := range b.rounds {
+		if now.Sub(round.StartedAt) > time.Hour {
+			delete(b.rounds, id)
+		}
+	}
+	b.rounds[sessionID] = &bonusRound{Word: word, StartedAt: now}
+	return true
+}
+
+// answer checks guess against the session's running round and returns the points earned.
+// The round ends on a correct answer or once time is up; wrong answers within time may retry.
+func (b *BonusRounds) answer(sessionID, guess string, now time.Time) (view bonusView, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	round, exists := b.rounds[sessionID]
+	if !exists || round.Done {
+		return bonusView{}, false
+	}
+	anagrams, related := b.answers(round.Word)
+	elapsed := now.Sub(round.StartedAt)
+	switch {
+	case elapsed > b.Duration+bonusGrace:
+		round.Done = true
+		view.Result = "Time's up!"
+	case slices.Contains(anagrams, guess):
+		round.Done = true
+		view.Result, view.Points = "Anagram found!", BonusAnagramPoints
+	case slices.Contains(related, guess):
+		round.Done = true
+		view.Result, view.Points = "Related word found!", BonusRelatedPoints
+	default:
+		view.Active = true
+		view.SecondsLeft = max(int((b.Duration - elapsed).Seconds()), 0)
+		view.Error = "Not that one, keep trying!"
+		return view, true
+	}
+	view.Answers = append(anagrams, related...)
+	return view, true
+}
+
+// finishedWonGame returns the session's game when it is won, for the bonus round handlers.
+func (app *App) finishedWonGame(sessionID string) (*GameState, bool) {
+	app.SessionMutex.RLock()
+	defer app.SessionMutex.RUnlock()
+	game, ok := app.GameSessions[sessionID]
+	return game, ok && game.Won
+}
+
+// bonusStartHandler starts the bonus round for the session's won game.
+func (app *App) bonusStartHandler(c *gin.Context) {
+	sessionID := app.getOrCreateSession(c)
+	game, ok := app.finishedWonGame(sessionID)
+	if !ok || !app.Bonus.offered(sessionID, game) {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNoActiveGame, "no bonus round available")
+		return
+	}
+	if !app.Bonus.start(sessionID, game.SessionWord, app.now()) {
+		writeProblem(c, http.StatusConflict, ErrorCodeDuplicate, "bonus round already played")
+		return
+	}
+	app.renderBonus(c, bonusView{Active: true, SecondsLeft: int(app.Bonus.Duration.Seconds())})
+}
+
+// bonusAnswerHandler checks a bonus round answer and adds any points to the player's score.
+func (app *App) bonusAnswerHandler(c *gin.Context) {
+	sessionID := app.getOrCreateSession(c)
+	guess := engine.NormalizeGuess(c.PostForm("guess"))
+	view, ok := app.Bonus.answer(sessionID, guess, app.now())
+	if !ok {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "no bonus round in progress")
+		return
+	}
+	if view.Points > 0 && app.Players != nil {
+		app.Players.addBonus(app.playerKey(c, sessionID), view.Points)
+	}
+	app.renderBonus(c, view)
+}
+
+// renderBonus renders the bonus round panel, or its view as JSON for non-HTMX clients.
+func (app *App) renderBonus(c *gin.Context, view bonusView) {
+	if wantsJSONView(c) {
+		c.JSON(http.StatusOK, gin.H{
+			"active":       view.Active,
+			"seconds_left": view.SecondsLeft,
+			"error":        view.Error,
+			"result":       view.Result,
+			"points":       view.Points,
+			"answers":      view.Answers,
+		})
+		return
+	}
+	csrfToken, _ := c.Cookie(app.cookieName(CSRFCookieName))
+	c.HTML(http.StatusOK, "bonus-round", gin.H{"bonus": view, "csrf_token": csrfToken})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestBonusRoundsAnswers(t *testing.T) {
+	accepted := map[string]struct{}{"ALERT": {}, "ALTER": {}, "LATER": {}, "CRANE": {}}
+	packs := []*WordPack{newWordPack(DefaultPackName, []WordEntry{{Word: "ALERT", Related: []string{"alarm"}}})}
+	b := newBonusRounds(30*time.Second, accepted, packs)
+
+	anagrams, related := b.answers("ALERT")
+	if !slices.Equal(anagrams, []string{"ALTER", "LATER"}) || !slices.Equal(related, []string{"ALARM"}) {
+		t.Errorf("answers(ALERT) = %v, %v", anagrams, related)
+	}
+	if !b.offered("session-123", &GameState{GameState: engine.GameState{SessionWord: "ALERT", Won: true}}) {
+		t.Error("bonus round not offered after a win with answers")
+	}
+	if b.offered("session-123", &GameState{GameState: engine.GameState{SessionWord: "CRANE", Won: true}}) {
+		t.Error("bonus round offered for a word without answers")
+	}
+	if b.offered("session-123", &GameState{GameState: engine.GameState{SessionWord: "ALERT", Won: true}, Custom: true}) {
+		t.Error("bonus round offered for a custom game")
+	}
+
+	start := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	if !b.start("session-123", "ALERT", start) || b.start("session-123", "ALERT", start) {
+		t.Fatal("start() should succeed once per word")
+	}
+	if view, _ := b.answer("session-123", "CRANE", start.Add(time.Second)); !view.Active || view.Points != 0 {
+		t.Errorf("wrong answer = %+v, want the round to continue", view)
+	}
+	if view, _ := b.answer("session-123", "LATER", start.Add(2*time.Second)); view.Active || view.Points != BonusAnagramPoints {
+		t.Errorf("anagram answer = %+v, want %d points", view, BonusAnagramPoints)
+	}
+	if _, ok := b.answer("session-123", "ALTER", start.Add(3*time.Second)); ok {
+		t.Error("answer accepted after the round ended")
+	}
+
+	b.start("session-456", "ALERT", start)
+	if view, _ := b.answer("session-456", "ALARM", start.Add(time.Minute)); view.Points != 0 || view.Result != "Time's up!" {
+		t.Errorf("late answer = %+v, want time's up", view)
+	}
+}
+
+func TestBonusHandlersAwardPoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "ALERT"}, {Word: "ALTER"}})
+	clock := newFakeClock()
+	app.Clock = clock
+	app.Players = newPlayerStatsStore(StreakFreezeRules{})
+	app.Bonus = newBonusRounds(30*time.Second, app.lists().AcceptedWordSet, nil)
+	router := gin.New()
+	router.POST(RouteBonus+"/start", app.bonusStartHandler)
+	router.POST(RouteBonus+"/answer", app.bonusAnswerHandler)
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path+"?format=json", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session-123"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	app.GameSessions["session-123"] = &GameState{GameState: engine.GameState{SessionWord: "ALERT", GameOver: true}}
+	if w := post(RouteBonus+"/start", nil); w.Code != http.StatusNotFound {
+		t.Errorf("start after a loss = %d, want 404", w.Code)
+	}
+	app.GameSessions["session-123"].Won = true
+	if w := post(RouteBonus+"/start", nil); w.Code != http.StatusOK {
+		t.Fatalf("start after a win = %d, want 200", w.Code)
+	}
+	clock.advance(5 * time.Second)
+	w := post(RouteBonus+"/answer", url.Values{"guess": {"alter"}})
+	var body struct {
+		Points int `json:"points"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Points != BonusAnagramPoints {
+		t.Fatalf("answer = %d %s", w.Code, w.Body)
+	}
+	if _, _, summary := app.Players.summary("session-123", ""); summary.BonusPoints != BonusAnagramPoints {
+		t.Errorf("BonusPoints = %d, want %d", summary.BonusPoints, BonusAnagramPoints)
+	}
+	if w := post(RouteBonus+"/start", nil); w.Code != http.StatusNotFound {
+		t.Errorf("second start = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// errCircuitOpen is returned instead of calling a third party whose circuit is open.
+var errCircuitOpen = errors.New("circuit open")
+
+// CircuitBreaker stops calling a third party after Threshold consecutive failures, failing fast
+// for Cooldown before letting a single trial call through. Every call is bounded by Timeout, so
+// a slow integration costs at most that long even before the circuit opens. A nil breaker
+// calls straight through.
+type CircuitBreaker struct {
+	Name      string
+	Threshold int
+	Cooldown  time.Duration
+	Timeout   time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	rejected int64
+	now      func() time.Time
+}
+
+// newCircuitBreaker returns a closed breaker, or nil when threshold is not positive.
+func newCircuitBreaker(name string, threshold int, cooldown, timeout time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &CircuitBreaker{
+		Name:      name,
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		Timeout:   timeout,
+		state:     CircuitClosed,
+		now:       time.Now,
+	}
+}
+
+// call runs fn under the breaker's timeout unless the circuit is open, and records whether it
+// failed. Errors fn reports for the request itself, rather than the integration, should not
+// be returned from fn, or they will count toward opening the circuit.
+func (b *CircuitBreaker) call(ctx context.Context, fn func(context.Context) error) error {
+	if b == nil {
+		return fn(ctx)
+	}
+	if !b.allow() {
+		return fmt.Errorf("%s: %w", b.Name, errCircuitOpen)
+	}
+	if b.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.Timeout)
+		defer cancel()
+	}
+	err := fn(ctx)
+	b.record(err)
+	return err
+}
+
+// allow reports whether a call may go through, moving an open circuit to half-open once the
+// cooldown has passed. Only one trial call is let through while half-open.
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.Cooldown {
+			b.rejected++
+			return false
+		}
+		b.state = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		b.rejected++
+		return false
+	}
+	return true
+}
+
+// record updates the circuit with the outcome of a call.
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		if b.state != CircuitClosed {
+			logInfo("Circuit for %s closed", b.Name)
+		}
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.Threshold {
+		if b.state != CircuitOpen {
+			logWarn("Circuit for %s opened after %d failures: %v", b.Name, b.failures, err)
+		}
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// isOpen reports whether calls are currently being refused, for callers that pick a fallback
+// up front. A nil breaker is never open.
+func (b *CircuitBreaker) isOpen() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == CircuitOpen && b.now().Sub(b.openedAt) < b.Cooldown
+}
+
+// circuitView describes a breaker for metrics and health checks.
+type circuitView struct {
+	State    string `json:"state"`
+	Failures int    `json:"failures"`
+	Rejected int64  `json:"rejected"`
+}
+
+// view returns the breaker's current state.
+func (b *CircuitBreaker) view() circuitView {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return circuitView{State: b.state, Failures: b.failures, Rejected: b.rejected}
+}
+
+// circuitBreakers returns the breakers of the configured integrations.
+func (app *App) circuitBreakers() []*CircuitBreaker {
+	var breakers []*CircuitBreaker
+	if app.Captcha != nil && app.Captcha.breaker != nil {
+		breakers = append(breakers, app.Captcha.breaker)
+	}
+	if app.Analytics != nil && app.Analytics.breaker != nil {
+		breakers = append(breakers, app.Analytics.breaker)
+	}
+	if app.Fleet != nil && app.Fleet.breaker != nil {
+		breakers = append(breakers, app.Fleet.breaker)
+	}
+	if app.Reminders != nil && app.Reminders.mailer.breaker != nil {
+		breakers = append(breakers, app.Reminders.mailer.breaker)
+	}
+	if app.Push != nil && app.Push.breaker != nil {
+		breakers = append(breakers, app.Push.breaker)
+	}
+	return breakers
+}
+
+// checkCircuits reports integrations whose circuit is open.
+func (app *App) checkCircuits() healthCheck {
+	check := healthCheck{Name: "integrations", Status: HealthStatusOK}
+	for _, b := range app.circuitBreakers() {
+		if b.isOpen() {
+			check.Status = HealthStatusDegraded
+			if check.Detail != "" {
+				check.Detail += "; "
+			}
+			check.Detail += b.Name + " circuit open"
+		}
+	}
+	return check
+}
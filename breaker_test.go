@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	now := time.Date(2030, 4, 1, 12, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker("test", 2, time.Minute, 0)
+	b.now = func() time.Time { return now }
+	down := errors.New("connection refused")
+	calls := 0
+	fail := func(context.Context) error { calls++; return down }
+	succeed := func(context.Context) error { calls++; return nil }
+
+	b.call(context.Background(), fail)
+	b.call(context.Background(), fail)
+	if !b.isOpen() {
+		t.Fatal("breaker should open after two failures")
+	}
+	if err := b.call(context.Background(), succeed); !errors.Is(err, errCircuitOpen) || calls != 2 {
+		t.Fatalf("open breaker: err = %v, calls = %d; want errCircuitOpen without a call", err, calls)
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.call(context.Background(), fail); !errors.Is(err, down) || !b.isOpen() {
+		t.Fatalf("failed trial: err = %v, open = %v; want the error and the circuit open again", err, b.isOpen())
+	}
+	now = now.Add(time.Minute)
+	if err := b.call(context.Background(), succeed); err != nil || b.view().State != CircuitClosed {
+		t.Errorf("successful trial: err = %v, state = %s; want the circuit closed", err, b.view().State)
+	}
+}
+
+func TestCircuitBreakerTimesOutSlowCalls(t *testing.T) {
+	b := newCircuitBreaker("slow", 1, time.Minute, 10*time.Millisecond)
+	err := b.call(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !b.isOpen() {
+		t.Errorf("slow call: err = %v, open = %v; want a timeout that opens the circuit", err, b.isOpen())
+	}
+}
+
+func TestCaptchaFailsOpenWhileProviderDown(t *testing.T) {
+	app := &App{
+		Blocklist: newBlocklist("", 0, time.Minute, time.Minute),
+		Captcha:   newCaptcha("hcaptcha", "site", "secret", 1, time.Hour),
+	}
+	app.Captcha.breaker = newCircuitBreaker("captcha", 1, time.Minute, 0)
+	ip := "198.51.100.7"
+	app.Blocklist.recordStrike(ip)
+	if !app.captchaRequired(ip) {
+		t.Fatal("flagged client should be challenged")
+	}
+	app.Captcha.breaker.record(errors.New("timeout"))
+	if app.captchaRequired(ip) {
+		t.Error("client challenged while the provider circuit is open")
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	cachecontrol "go.eigsys.de/gin-cachecontrol/v2"
+)
+
+// fingerprintPattern matches content-hashed asset names such as app.3f9a2c1b.css.
+var fingerprintPattern = regexp.MustCompile(`\.[0-9a-f]{8,}\.[a-z0-9]+$`)
+
+// noStoreConfig is the Cache-Control policy for dynamic, per-session responses.
+var noStoreConfig = cachecontrol.Config{
+	NoStore:        true,
+	NoCache:        true,
+	MustRevalidate: true,
+}
+
+// cachePolicy declares the Cache-Control behaviour for requests matching a method and path.
+// A path ending in "/" matches as a prefix; Match, when set, further restricts the policy.
+type cachePolicy struct {
+	Name   string
+	Method string
+	Path   string
+	Match  func(path string) bool
+	Config cachecontrol.Config
+	ETag   bool
+}
+
+// matches reports whether the policy applies to the given method and path.
+func (p cachePolicy) matches(method, path string) bool {
+	if p.Method != "" && p.Method != method {
+		return false
+	}
+	if strings.HasSuffix(p.Path, "/") {
+		if !strings.HasPrefix(path, p.Path) {
+			return false
+		}
+	} else if p.Path != path {
+		return false
+	}
+	return p.Match == nil || p.Match(path)
+}
+
+// cachePolicies returns the ordered cache policy table; the first matching policy wins.
+// Outside production every route is served with no-store so edits show up immediately.
+func (app *App) cachePolicies(production bool) []cachePolicy {
+	if !production {
+		return nil
+	}
+	return []cachePolicy{
+		{
+			Name:  "fingerprinted-static",
+			Path:  "/static/",
+			Match: fingerprintPattern.MatchString,
+			Config: cachecontrol.Config{
+				Public:    true,
+				MaxAge:    cachecontrol.Duration(365 * 24 * time.Hour),
+				Immutable: true,
+			},
+		},
+		{
+			Name: "static",
+			Path: "/static/",
+			Config: cachecontrol.Config{
+				Public: true,
+				MaxAge: cachecontrol.Duration(app.StaticCacheAge),
+			},
+		},
+		{
+			Name:   "game-state",
+			Method: http.MethodGet,
+			Path:   RouteGameState,
+			Config: cachecontrol.Config{
+				Private:        true,
+				MaxAge:         cachecontrol.Duration(5 * time.Second),
+				MustRevalidate: true,
+			},
+			ETag: true,
+		},
+		{
+			Name:   "accepted-words",
+			Method: http.MethodGet,
+			Path:   RouteAccepted,
+			Config: cachecontrol.Config{
+				Public: true,
+				MaxAge: cachecontrol.Duration(app.StaticCacheAge),
+			},
+		},
+		{
+			Name:   "healthz",
+			Method: http.MethodGet,
+			Path:   "/healthz",
+			Config: cachecontrol.Config{NoCache: true},
+		},
+	}
+}
+
+// cacheHeadersMiddleware applies the first matching cache policy, defaulting to no-store.
+func (app *App) cacheHeadersMiddleware(production bool) gin.HandlerFunc {
+	policies := app.cachePolicies(production)
+	handlers := make([]gin.HandlerFunc, len(policies))
+	for i, p := range policies {
+		handlers[i] = cachecontrol.New(p.Config)
+	}
+	noStore := cachecontrol.New(noStoreConfig)
+
+	return func(c *gin.Context) {
+		method, path := c.Request.Method, c.Request.URL.Path
+		for i, p := range policies {
+			if !p.matches(method, path) {
+				continue
+			}
+			handlers[i](c)
+			if strings.HasPrefix(path, "/static/") {
+				c.Header("Vary", "Accept-Encoding")
+			}
+			if p.ETag {
+				etagResponse(c)
+				return
+			}
+			c.Next()
+			return
+		}
+		noStore(c)
+		c.Next()
+	}
+}
+
+// etagWriter buffers a response body so an ETag can be computed before anything is sent.
+type etagWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+// Write buffers the response body.
+func (w *etagWriter) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
+
+// WriteString buffers the response body.
+func (w *etagWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+// etagResponse runs the remaining handlers with a buffered writer, then sets a weak ETag and
+// answers 304 Not Modified when it matches If-None-Match.
+func etagResponse(c *gin.Context) {
+	original := c.Writer
+	w := &etagWriter{ResponseWriter: original}
+	c.Writer = w
+	c.Next()
+	c.Writer = original
+
+	if w.Status() != http.StatusOK {
+		_, _ = original.Write(w.buf.Bytes())
+		return
+	}
+
+	sum := sha256.Sum256(w.buf.Bytes())
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	if match := c.GetHeader("If-None-Match"); match != "" && strings.Contains(match, etag) {
+		original.WriteHeader(http.StatusNotModified)
+		original.WriteHeaderNow()
+		return
+	}
+	_, _ = original.Write(w.buf.Bytes())
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCachePolicyMatches(t *testing.T) {
+	app := &App{StaticCacheAge: time.Minute}
+	policies := app.cachePolicies(true)
+	find := func(method, path string) string {
+		for _, p := range policies {
+			if p.matches(method, path) {
+				return p.Name
+			}
+		}
+		return "default"
+	}
+	cases := []struct{ method, path, want string }{
+		{"GET", "/static/style.css", "static"},
+		{"GET", "/static/style.3f9a2c1b.css", "fingerprinted-static"},
+		{"GET", RouteGameState, "game-state"},
+		{"POST", RouteGuess, "default"},
+		{"GET", RouteHome, "default"},
+	}
+	for _, c := range cases {
+		if got := find(c.method, c.path); got != c.want {
+			t.Errorf("%s %s matched %q, want %q", c.method, c.path, got, c.want)
+		}
+	}
+	if len(app.cachePolicies(false)) != 0 {
+		t.Error("Expected no cache policies outside production")
+	}
+}
+
+func TestCacheHeadersMiddlewareETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{StaticCacheAge: time.Minute}
+	router := gin.New()
+	router.Use(app.cacheHeadersMiddleware(true))
+	router.GET(RouteGameState, func(c *gin.Context) { c.String(http.StatusOK, "board") })
+	router.POST(RouteGuess, func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", RouteGameState, nil))
+	etag := w.Header().Get("ETag")
+	if w.Body.String() != "board" || etag == "" {
+		t.Fatalf("Expected body and ETag, got %q, %q", w.Body.String(), etag)
+	}
+	if !strings.Contains(w.Header().Get("Cache-Control"), "private") {
+		t.Errorf("Expected private caching, got %q", w.Header().Get("Cache-Control"))
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", RouteGameState, nil)
+	req.Header.Set("If-None-Match", etag)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected empty 304, got %d with %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", RouteGuess, nil))
+	if !strings.Contains(w.Header().Get("Cache-Control"), "no-store") {
+		t.Errorf("Expected no-store for guess, got %q", w.Header().Get("Cache-Control"))
+	}
+}
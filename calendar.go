@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+)
+
+// PuzzleCalendar holds words pinned to specific daily puzzle dates, overriding the deterministic
+// daily selection. Pins are persisted to a JSON file keyed by YYYY-MM-DD.
+type PuzzleCalendar struct {
+	mu      sync.RWMutex
+	storage Storage
+	path    string
+	pins    map[string]string
+}
+
+// newPuzzleCalendar creates an empty calendar persisted at path (empty path disables persistence).
+func newPuzzleCalendar(path string) *PuzzleCalendar {
+	return &PuzzleCalendar{storage: DirStorage{}, path: path, pins: make(map[string]string)}
+}
+
+// parsePuzzleDate parses a YYYY-MM-DD puzzle date.
+func parsePuzzleDate(s string) (time.Time, error) {
+	date, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, errors.New("date must be in YYYY-MM-DD format")
+	}
+	return date, nil
+}
+
+// load reads pinned puzzles from disk. A missing file is not an error.
+func (pc *PuzzleCalendar) load() error {
+	var pins map[string]string
+	if found, err := readJSONFile(pc.storage, pc.path, &pins); err != nil || !found {
+		return err
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	for date, word := range pins {
+		if _, err := parsePuzzleDate(date); err != nil {
+			logWarn("Skipping invalid puzzle calendar date %q: %v", date, err)
+			continue
+		}
+		pc.pins[date] = word
+	}
+	return nil
+}
+
+// save writes the calendar to disk atomically. Callers must hold pc.mu.
+func (pc *PuzzleCalendar) save() error {
+	return writeJSONFile(pc.storage, pc.path, pc.pins)
+}
+
+// pin schedules word for the given date, replacing any existing pin.
+func (pc *PuzzleCalendar) pin(date time.Time, word string) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.pins[date.Format(time.DateOnly)] = word
+	return pc.save()
+}
+
+// unpin removes the pin for date, reporting whether one existed.
+func (pc *PuzzleCalendar) unpin(date time.Time) (bool, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	key := date.Format(time.DateOnly)
+	if _, ok := pc.pins[key]; !ok {
+		return false, nil
+	}
+	delete(pc.pins, key)
+	return true, pc.save()
+}
+
+// lookup returns the word pinned to date, if any.
+func (pc *PuzzleCalendar) lookup(date time.Time) (string, bool) {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+	word, ok := pc.pins[date.Format(time.DateOnly)]
+	return word, ok
+}
+
+// snapshot returns a copy of all pins keyed by date.
+func (pc *PuzzleCalendar) snapshot() map[string]string {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+	return maps.Clone(pc.pins)
+}
+
+// dailyWord returns the word for a puzzle date: the pinned word if one is scheduled, otherwise a
+// deterministic pick from the word list so every instance agrees on the same date.
+func (app *App) dailyWord(date time.Time) (WordEntry, bool) {
+	if app.Calendar != nil {
+		if word, ok := app.Calendar.lookup(date); ok {
+			return WordEntry{Word: word, Hint: app.getHintForWord(word)}, true
+		}
+	}
+	words := app.lists().WordList
+	if len(words) == 0 {
+		return WordEntry{}, false
+	}
+	return app.selector(SelectionModeDaily).Select(context.Background(), words, selectionRequest{Date: date}), true
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPuzzleCalendarPersistence(t *testing.T) {
+	st := newMemStorage()
+	pc := newPuzzleCalendar("calendar.json")
+	pc.storage = st
+	date := time.Date(2030, 4, 1, 0, 0, 0, 0, time.UTC)
+	if err := pc.pin(date, "APPLE"); err != nil {
+		t.Fatalf("pin() error = %v", err)
+	}
+
+	reloaded := newPuzzleCalendar("calendar.json")
+	reloaded.storage = st
+	if err := reloaded.load(); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if word, ok := reloaded.lookup(date); !ok || word != "APPLE" {
+		t.Errorf("lookup() = %q, %v, want APPLE, true", word, ok)
+	}
+
+	if removed, err := reloaded.unpin(date); err != nil || !removed {
+		t.Errorf("unpin() = %v, %v, want true, nil", removed, err)
+	}
+	if removed, _ := reloaded.unpin(date); removed {
+		t.Error("unpin() of missing date reported removal")
+	}
+}
+
+func TestDailyWord(t *testing.T) {
+	app := &App{
+		Words: newWordRegistry(&WordLists{
+			WordList: []WordEntry{{Word: "APPLE", Hint: "fruit"}, {Word: "BREAD", Hint: "food"}, {Word: "CRANE", Hint: "bird"}},
+			HintMap:  map[string]string{"APPLE": "fruit", "BREAD": "food", "CRANE": "bird"},
+		}),
+		Calendar: newPuzzleCalendar(""),
+	}
+	date := time.Date(2030, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	first, ok := app.dailyWord(date)
+	if !ok {
+		t.Fatal("dailyWord() found no word")
+	}
+	if again, _ := app.dailyWord(date); again.Word != first.Word {
+		t.Errorf("dailyWord() not deterministic: %v then %v", first, again)
+	}
+
+	if err := app.Calendar.pin(date, "CRANE"); err != nil {
+		t.Fatal(err)
+	}
+	if pinned, _ := app.dailyWord(date); pinned.Word != "CRANE" || pinned.Hint != "bird" {
+		t.Errorf("dailyWord() with pin = %v, want CRANE/bird", pinned)
+	}
+}
+
+func TestParsePuzzleDate(t *testing.T) {
+	if _, err := parsePuzzleDate("2030-04-01"); err != nil {
+		t.Errorf("parsePuzzleDate(valid) error = %v", err)
+	}
+	if _, err := parsePuzzleDate("04/01/2030"); err == nil {
+		t.Error("parsePuzzleDate(invalid) expected error")
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/internal/htmx"
+)
+
+// CAPTCHA provider constants
+const (
+	CaptchaProviderTurnstile = "turnstile"
+	CaptchaProviderHCaptcha  = "hcaptcha"
+)
+
+// captchaProviderConfig describes the endpoints and form field used by a CAPTCHA provider.
+type captchaProviderConfig struct {
+	ScriptURL     string
+	VerifyURL     string
+	ResponseField string
+	WidgetClass   string
+	Origins       []string
+}
+
+// captchaProviders holds the supported privacy-friendly CAPTCHA providers.
+var captchaProviders = map[string]captchaProviderConfig{
+	CaptchaProviderTurnstile: {
+		ScriptURL:     "https://challenges.cloudflare.com/turnstile/v0/api.js",
+		VerifyURL:     "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+		ResponseField: "cf-turnstile-response",
+		WidgetClass:   "cf-turnstile",
+		Origins:       []string{"https://challenges.cloudflare.com"},
+	},
+	CaptchaProviderHCaptcha: {
+		ScriptURL:     "https://js.hcaptcha.com/1/api.js",
+		VerifyURL:     "https://api.hcaptcha.com/siteverify",
+		ResponseField: "h-captcha-response",
+		WidgetClass:   "h-captcha",
+		Origins:       []string{"https://hcaptcha.com", "https://*.hcaptcha.com"},
+	},
+}
+
+// Captcha gates abusive clients behind a CAPTCHA challenge and remembers clients that solved it.
+type Captcha struct {
+	Provider     string
+	SiteKey      string
+	Secret       string
+	Threshold    int
+	PassDuration time.Duration
+	client       *http.Client
+	breaker      *CircuitBreaker
+	mu           sync.Mutex
+	passed       map[string]time.Time
+	clock        Clock
+}
+
+// newCaptcha returns a Captcha for the given provider, or nil if the provider is unknown or keys are missing.
+func newCaptcha(provider, siteKey, secret string, threshold int, passDuration time.Duration) *Captcha {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return nil
+	}
+	if _, ok := captchaProviders[provider]; !ok {
+		logWarn("Unknown CAPTCHA provider %q, CAPTCHA disabled", provider)
+		return nil
+	}
+	if siteKey == "" || secret == "" {
+		logWarn("CAPTCHA provider %s configured without site key or secret, CAPTCHA disabled", provider)
+		return nil
+	}
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return &Captcha{
+		Provider:     provider,
+		SiteKey:      siteKey,
+		Secret:       secret,
+		Threshold:    threshold,
+		PassDuration: passDuration,
+		client:       &http.Client{Timeout: 5 * time.Second},
+		passed:       make(map[string]time.Time),
+		clock:        systemClock{},
+	}
+}
+
+// config returns the provider configuration for this Captcha.
+func (cp *Captcha) config() captchaProviderConfig {
+	return captchaProviders[cp.Provider]
+}
+
+// hasPassed reports whether the client IP solved a challenge within the pass duration.
+func (cp *Captcha) hasPassed(ip string) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	until, ok := cp.passed[ip]
+	return ok && cp.clock.Now().Before(until)
+}
+
+// markPassed records that the client IP solved a challenge, pruning expired entries.
+func (cp *Captcha) markPassed(ip string) {
+	now := cp.clock.Now()
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	for k, until := range cp.passed {
+		if !now.Before(until) {
+			delete(cp.passed, k)
+		}
+	}
+	cp.passed[ip] = now.Add(cp.PassDuration)
+}
+
+// verify checks a CAPTCHA response token with the provider's siteverify endpoint.
+func (cp *Captcha) verify(ctx context.Context, token, ip string) error {
+	if token == "" {
+		return errors.New("missing captcha response")
+	}
+	form := url.Values{
+		"secret":   {cp.Secret},
+		"response": {token},
+		"remoteip": {ip},
+	}
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	// Only a provider that cannot be reached or answers garbage counts against the circuit; a
+	// wrong answer from the player does not.
+	err := cp.breaker.call(ctx, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cp.config().VerifyURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		setOutboundRequestID(req)
+		resp, err := cp.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		return json.NewDecoder(resp.Body).Decode(&result)
+	})
+	if err != nil {
+		return err
+	}
+	if !result.Success {
+		return errors.New("captcha verification failed: " + strings.Join(result.ErrorCodes, ", "))
+	}
+	return nil
+}
+
+// contentSecurityPolicy returns the base CSP extended with the provider's origins.
+func (cp *Captcha) contentSecurityPolicy() string {
+	origins := cp.config().Origins
+	csp := addCSPSources(cspHeader, "script-src", origins...)
+	csp = addCSPSources(csp, "style-src", origins...)
+	csp = addCSPSources(csp, "connect-src", origins...)
+	return addCSPSources(csp, "frame-src", origins...)
+}
+
+// addCSPSources appends sources to a directive in a CSP string, adding the directive if absent.
+func addCSPSources(csp, directive string, sources ...string) string {
+	parts := strings.Split(strings.TrimSuffix(csp, ";"), ";")
+	for i, part := range parts {
+		fields := strings.Fields(part)
+		if len(fields) > 0 && fields[0] == directive {
+			parts[i] = " " + strings.Join(append(fields, sources...), " ")
+			return strings.TrimSpace(strings.Join(parts, ";")) + ";"
+		}
+	}
+	parts = append(parts, " "+directive+" "+strings.Join(sources, " "))
+	return strings.TrimSpace(strings.Join(parts, ";")) + ";"
+}
+
+// captchaRequired reports whether the client must solve a CAPTCHA before continuing.
+func (app *App) captchaRequired(ip string) bool {
+	if app.Captcha == nil || app.Blocklist == nil {
+		return false
+	}
+	if app.Captcha.hasPassed(ip) {
+		return false
+	}
+	// While the provider is down nobody could pass the challenge, so flagged clients fall back
+	// to the rate limits alone rather than being locked out.
+	if app.Captcha.breaker.isOpen() {
+		return false
+	}
+	return app.Blocklist.strikeCount(ip) >= app.Captcha.Threshold
+}
+
+// captchaMiddleware redirects clients flagged for abuse to the CAPTCHA challenge page.
+func (app *App) captchaMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !app.captchaRequired(c.ClientIP()) {
+			c.Next()
+			return
+		}
+		app.incMetric(MetricCaptchaChallenges)
+		if htmx.IsRequest(c.Request) {
+			htmx.Redirect(c.Writer.Header(), RouteCaptcha)
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, RouteCaptcha)
+		c.Abort()
+	}
+}
+
+// renderCaptcha renders the CAPTCHA challenge page with the provider's CSP.
+func (app *App) renderCaptcha(c *gin.Context, status int, errMsg string) {
+	cfg := app.Captcha.config()
+	csrfToken, _ := c.Cookie(app.cookieName(CSRFCookieName))
+	c.Header("Content-Security-Policy", app.Captcha.contentSecurityPolicy())
+	c.HTML(status, "captcha.html", gin.H{
+		"title":        "Vortludo - Quick Check",
+		"script_url":   cfg.ScriptURL,
+		"widget_class": cfg.WidgetClass,
+		"site_key":     app.Captcha.SiteKey,
+		"error":        errMsg,
+		"error_ref":    errorRef(c),
+		"csrf_token":   csrfToken,
+	})
+}
+
+// captchaPageHandler shows the CAPTCHA challenge, or sends the client home if none is needed.
+func (app *App) captchaPageHandler(c *gin.Context) {
+	if !app.captchaRequired(c.ClientIP()) {
+		c.Redirect(http.StatusSeeOther, RouteHome)
+		return
+	}
+	app.renderCaptcha(c, http.StatusOK, "")
+}
+
+// captchaVerifyHandler verifies a submitted CAPTCHA response and lifts the gate on success.
+func (app *App) captchaVerifyHandler(c *gin.Context) {
+	if app.Captcha == nil {
+		c.Redirect(http.StatusSeeOther, RouteHome)
+		return
+	}
+	ip := c.ClientIP()
+	token := c.PostForm(app.Captcha.config().ResponseField)
+	// A solved response is spent before it is checked, so it cannot lift the gate for a
+	// second client even where the provider would accept it twice.
+	if token != "" && !app.spendToken(TokenKindCaptcha, token, app.now().Add(captchaTokenTTL)) {
+		app.incMetric(MetricCaptchaFailed)
+		app.renderCaptcha(c, http.StatusForbidden, "Verification failed. Please try again.")
+		return
+	}
+	if err := app.Captcha.verify(c.Request.Context(), token, ip); err != nil {
+		app.incMetric(MetricCaptchaFailed)
+		logWarn("CAPTCHA verification failed for %s: %v", ip, err)
+		app.renderCaptcha(c, http.StatusForbidden, "Verification failed. Please try again.")
+		return
+	}
+
+	app.incMetric(MetricCaptchaPassed)
+	app.Captcha.markPassed(ip)
+	if app.Blocklist != nil {
+		app.Blocklist.clearStrikes(ip)
+	}
+	logInfo("CAPTCHA solved by %s", ip)
+	c.Redirect(http.StatusSeeOther, RouteHome)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddCSPSources(t *testing.T) {
+	csp := "default-src 'self'; script-src 'self';"
+	got := addCSPSources(csp, "script-src", "https://example.com")
+	if got != "default-src 'self'; script-src 'self' https://example.com;" {
+		t.Errorf("Unexpected CSP: %q", got)
+	}
+	got = addCSPSources(csp, "frame-src", "https://example.com")
+	if !strings.HasSuffix(got, "; frame-src https://example.com;") {
+		t.Errorf("Expected frame-src to be appended, got %q", got)
+	}
+}
+
+func TestNewCaptchaRequiresKeys(t *testing.T) {
+	if newCaptcha("", "site", "secret", 3, time.Hour) != nil {
+		t.Error("Expected nil captcha without provider")
+	}
+	if newCaptcha("turnstile", "", "secret", 3, time.Hour) != nil {
+		t.Error("Expected nil captcha without site key")
+	}
+	if newCaptcha("unknown", "site", "secret", 3, time.Hour) != nil {
+		t.Error("Expected nil captcha for unknown provider")
+	}
+	if newCaptcha("Turnstile", "site", "secret", 3, time.Hour) == nil {
+		t.Error("Expected captcha for configured provider")
+	}
+}
+
+func TestCaptchaRequired(t *testing.T) {
+	app := &App{
+		Blocklist: newBlocklist("", 0, time.Minute, time.Minute),
+		Captcha:   newCaptcha("hcaptcha", "site", "secret", 2, time.Hour),
+	}
+	ip := "192.0.2.50"
+	app.Blocklist.recordStrike(ip)
+	if app.captchaRequired(ip) {
+		t.Error("Should not require captcha below threshold")
+	}
+	app.Blocklist.recordStrike(ip)
+	if !app.captchaRequired(ip) {
+		t.Error("Should require captcha at threshold")
+	}
+	app.Captcha.markPassed(ip)
+	if app.captchaRequired(ip) {
+		t.Error("Should not require captcha after passing")
+	}
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Classroom limits
+const (
+	MaxClassroomStudents = 60
+	// ClassroomTTL is how long a classroom stays open after it is created.
+	ClassroomTTL = 12 * time.Hour
+	// ClassroomStreamDuration bounds one progress stream so it ends well inside the server's
+	// write timeout; the browser reconnects on its own.
+	ClassroomStreamDuration = 20 * time.Second
+)
+
+// classroomTeacherCookie holds a classroom's teacher key, scoped to that classroom's path.
+const classroomTeacherCookie = "classroom_teacher"
+
+// classroomContextKey is the gin context key holding the classroom a teacher request is for.
+const classroomContextKey = "classroom"
+
+// Classroom is a set of students who each play their own board with the same word. Students
+// are listed by join order under a numbered alias, so the teacher's dashboard never shows a
+// name or session.
+type Classroom struct {
+	mu         sync.Mutex
+	Code       string
+	Word       WordEntry
+	Pack       string
+	TeacherKey string
+	Created    time.Time
+	students   []*classStudent
+	bySession  map[string]*classStudent
+	subs       map[chan struct{}]struct{}
+}
+
+// classStudent is one student's progress on the classroom word.
+type classStudent struct {
+	Alias    string `json:"student"`
+	RowsUsed int    `json:"rows_used"`
+	Rows     int    `json:"rows"`
+	Solved   bool   `json:"solved"`
+	Done     bool   `json:"done"`
+	game     *GameState
+}
+
+// Classrooms maps classroom codes to classrooms and students' sessions to the classroom they joined.
+type Classrooms struct {
+	mu        sync.Mutex
+	rooms     map[string]*Classroom
+	bySession map[string]*Classroom
+}
+
+// newClassrooms returns an empty classroom registry.
+func newClassrooms() *Classrooms {
+	return &Classrooms{rooms: make(map[string]*Classroom), bySession: make(map[string]*Classroom)}
+}
+
+// newTeacherKey returns a random key that opens a classroom's dashboard.
+func newTeacherKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// create opens a classroom for word, dropping classrooms past ClassroomTTL.
+func (cs *Classrooms) create(word WordEntry, pack string, now time.Time) (*Classroom, error) {
+	key, err := newTeacherKey()
+	if err != nil {
+		return nil, err
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.pruneLocked(now)
+	var code string
+	for {
+		if code, err = newRoomCode(); err != nil {
+			return nil, err
+		}
+		if _, taken := cs.rooms[code]; !taken {
+			break
+		}
+	}
+	room := &Classroom{
+		Code:       code,
+		Word:       word,
+		Pack:       pack,
+		TeacherKey: key,
+		Created:    now,
+		bySession:  make(map[string]*classStudent),
+		subs:       make(map[chan struct{}]struct{}),
+	}
+	cs.rooms[code] = room
+	return room, nil
+}
+
+// pruneLocked drops expired classrooms. Callers must hold cs.mu.
+func (cs *Classrooms) pruneLocked(now time.Time) {
+	for code, room := range cs.rooms {
+		if now.Sub(room.Created) > ClassroomTTL {
+			delete(cs.rooms, code)
+		}
+	}
+	for sessionID, room := range cs.bySession {
+		if _, open := cs.rooms[room.Code]; !open {
+			delete(cs.bySession, sessionID)
+		}
+	}
+}
+
+// get returns the open classroom with code.
+func (cs *Classrooms) get(code string, now time.Time) (*Classroom, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	room, ok := cs.rooms[normalizeRoomCode(code)]
+	if !ok || now.Sub(room.Created) > ClassroomTTL {
+		return nil, false
+	}
+	return room, true
+}
+
+// join adds sessionID to the classroom with code and returns its game on the classroom word.
+// A student who joins again gets the game they already started back.
+func (cs *Classrooms) join(code, sessionID string, now time.Time, newGame func(*Classroom) *GameState) (*Classroom, *GameState, error) {
+	room, ok := cs.get(code, now)
+	if !ok {
+		return nil, nil, errRoomNotFound
+	}
+	room.mu.Lock()
+	student, member := room.bySession[sessionID]
+	if !member {
+		if len(room.students) >= MaxClassroomStudents {
+			room.mu.Unlock()
+			return nil, nil, errRoomFull
+		}
+		student = &classStudent{Alias: "Student " + strconv.Itoa(len(room.students)+1), game: newGame(room)}
+		student.update(student.game)
+		room.students = append(room.students, student)
+		room.bySession[sessionID] = student
+	}
+	game := student.game
+	room.mu.Unlock()
+
+	cs.mu.Lock()
+	cs.bySession[sessionID] = room
+	cs.mu.Unlock()
+	if !member {
+		room.notify()
+	}
+	return room, game, nil
+}
+
+// report records sessionID's progress when game is its classroom game, and tells the
+// classroom's dashboards.
+func (cs *Classrooms) report(sessionID string, game *GameState) {
+	if cs == nil {
+		return
+	}
+	cs.mu.Lock()
+	room, ok := cs.bySession[sessionID]
+	cs.mu.Unlock()
+	if !ok {
+		return
+	}
+	room.mu.Lock()
+	student, ok := room.bySession[sessionID]
+	if ok && student.game == game {
+		student.update(game)
+	}
+	room.mu.Unlock()
+	if ok {
+		room.notify()
+	}
+}
+
+// update copies the progress of game into the student's record.
+func (s *classStudent) update(game *GameState) {
+	s.RowsUsed = len(game.GuessHistory)
+	s.Rows = game.Rows()
+	s.Solved = game.Won
+	s.Done = game.GameOver
+}
+
+// progress returns every student's progress in join order.
+func (room *Classroom) progress() []classStudent {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	students := make([]classStudent, len(room.students))
+	for i, s := range room.students {
+		students[i] = *s
+		students[i].game = nil
+	}
+	return students
+}
+
+// subscribe returns a channel that receives a value whenever progress changes.
+func (room *Classroom) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+	room.mu.Lock()
+	room.subs[ch] = struct{}{}
+	room.mu.Unlock()
+	return ch
+}
+
+// unsubscribe stops notifications on ch.
+func (room *Classroom) unsubscribe(ch chan struct{}) {
+	room.mu.Lock()
+	delete(room.subs, ch)
+	room.mu.Unlock()
+}
+
+// notify wakes every subscriber without blocking; a subscriber already due an update keeps
+// its pending one.
+func (room *Classroom) notify() {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	for ch := range room.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// classroomData is the template data shared by the dashboard page and its progress fragment.
+func classroomData(room *Classroom) gin.H {
+	students := room.progress()
+	solved := 0
+	for _, s := range students {
+		if s.Solved {
+			solved++
+		}
+	}
+	return gin.H{
+		"classroom":   room,
+		"students":    students,
+		"solved":      solved,
+		"joinURL":     RouteHome + "?class=" + room.Code,
+		"progressURL": RouteClassroom + "/" + room.Code + "/teacher/progress",
+		"eventsURL":   RouteClassroom + "/" + room.Code + "/teacher/events",
+	}
+}
+
+// classroomTeacherMiddleware admits requests for a classroom's dashboard that carry its teacher
+// key, or the admin token, as a bearer token or in the teacher cookie. A key in the query is
+// moved into the cookie so the page and its event stream can use it.
+func (app *App) classroomTeacherMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := app.Classrooms.get(c.Param("code"), app.now())
+		if !ok {
+			writeProblem(c, http.StatusNotFound, ErrorCodeRoomNotFound, "classroom not found")
+			return
+		}
+		key, ok := bearerToken(c)
+		if !ok {
+			key = c.Query("key")
+		}
+		if key == "" {
+			key, _ = c.Cookie(app.cookieName(classroomTeacherCookie))
+		}
+		if !tokenMatches(key, room.TeacherKey) && !tokenMatches(key, app.AdminToken) {
+			logWarn("Rejected classroom dashboard request from %s", c.ClientIP())
+			writeProblem(c, http.StatusUnauthorized, ErrorCodeUnauthorized, "teacher key missing or invalid")
+			return
+		}
+		if c.Query("key") != "" {
+			app.setTeacherCookie(c, room)
+		}
+		c.Set(classroomContextKey, room)
+		c.Next()
+	}
+}
+
+// setTeacherCookie stores room's teacher key in a cookie limited to the classroom's routes.
+func (app *App) setTeacherCookie(c *gin.Context, room *Classroom) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(app.cookieName(classroomTeacherCookie), room.TeacherKey, int(ClassroomTTL.Seconds()), RouteClassroom+"/"+room.Code, "", app.IsProduction, true)
+}
+
+// classroomPageHandler renders the page teachers use to open a classroom.
+func (app *App) classroomPageHandler(c *gin.Context) {
+	csrfToken, _ := c.Cookie(app.cookieName(CSRFCookieName))
+	c.HTML(http.StatusOK, "classroom.html", gin.H{
+		"title":      "Vortludo - Classroom",
+		"csrf_token": csrfToken,
+		"packs":      app.lists().PackNames,
+	})
+}
+
+// createClassroomHandler opens a classroom on a word from the chosen pack and sends the
+// teacher to its dashboard, holding the teacher key in a cookie.
+func (app *App) createClassroomHandler(c *gin.Context) {
+	pack := app.wordPack(c.PostForm("pack"))
+	entry, _ := app.selectWordEntry(c.Request.Context(), pack, nil, selectionRequest{})
+	room, err := app.Classrooms.create(entry, pack.Name, app.now())
+	if err != nil {
+		logWarn("Failed to create classroom: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not create classroom")
+		return
+	}
+	logInfo("Created classroom with word %s from pack %s", redactWord(entry.Word), pack.Name)
+	app.setTeacherCookie(c, room)
+	c.Redirect(http.StatusSeeOther, RouteClassroom+"/"+room.Code+"/teacher")
+}
+
+// joinClassroomHandler switches the session's game to its board in the classroom in the code
+// field, and leaves any co-op room.
+func (app *App) joinClassroomHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := app.getOrCreateSession(c)
+	room, game, err := app.Classrooms.join(c.PostForm("code"), sessionID, app.now(), func(room *Classroom) *GameState {
+		game := newGame(room.Word.Word)
+		game.Pack = room.Pack
+		app.pinWords(game)
+		return game
+	})
+	if err != nil {
+		if errors.Is(err, errRoomFull) {
+			writeProblem(c, http.StatusConflict, ErrorCodeRoomFull, "classroom is full")
+			return
+		}
+		writeProblem(c, http.StatusNotFound, ErrorCodeRoomNotFound, "classroom not found")
+		return
+	}
+	app.Rooms.leave(sessionID)
+	app.saveGameState(sessionID, game)
+	app.Journal.start(ctx, sessionID, game)
+	logInfo("Session %s joined a classroom", redactSession(sessionID))
+	c.JSON(http.StatusOK, gin.H{"code": room.Code})
+}
+
+// classroomDashboardHandler renders a classroom's teacher dashboard. A key passed in the query
+// has been moved to the cookie, so the page is reloaded without it.
+func (app *App) classroomDashboardHandler(c *gin.Context) {
+	room := c.MustGet(classroomContextKey).(*Classroom)
+	if c.Query("key") != "" {
+		c.Redirect(http.StatusSeeOther, RouteClassroom+"/"+room.Code+"/teacher")
+		return
+	}
+	data := classroomData(room)
+	data["title"] = "Vortludo - Classroom " + room.Code
+	c.Header("Cache-Control", "no-store")
+	c.HTML(http.StatusOK, "classroom.html", data)
+}
+
+// classroomProgressHandler renders the dashboard's progress fragment, or the progress as JSON
+// with format=json.
+func (app *App) classroomProgressHandler(c *gin.Context) {
+	room := c.MustGet(classroomContextKey).(*Classroom)
+	c.Header("Cache-Control", "no-store")
+	if wantsJSONView(c) {
+		c.JSON(http.StatusOK, gin.H{"code": room.Code, "students": room.progress()})
+		return
+	}
+	c.HTML(http.StatusOK, "classroom-progress", classroomData(room))
+}
+
+// classroomEventsHandler streams a progress event as server-sent events whenever a student
+// joins or guesses. Each stream ends after ClassroomStreamDuration and the browser reconnects.
+func (app *App) classroomEventsHandler(c *gin.Context) {
+	room := c.MustGet(classroomContextKey).(*Classroom)
+	ch := room.subscribe()
+	defer room.unsubscribe(ch)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", time.Second.Milliseconds())
+	send := func() {
+		data, _ := json.Marshal(room.progress())
+		fmt.Fprintf(c.Writer, "event: progress\ndata: %s\n\n", data)
+		c.Writer.Flush()
+	}
+	send()
+
+	done := time.NewTimer(ClassroomStreamDuration)
+	defer done.Stop()
+	for {
+		select {
+		case <-ch:
+			send()
+		case <-done.C:
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClassroomDashboard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{GameSessions: make(map[string]*GameState), AdminToken: "admin-secret", Rooms: newCoopRooms(), Classrooms: newClassrooms()}
+	app.registerWordPacks([]*WordPack{newWordPack(DefaultPackName, []WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})}, nil, nil)
+	app.Journal = newSessionJournal(t.TempDir())
+
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.POST(RouteClassroom, app.createClassroomHandler)
+	router.POST(RouteClassroom+"/join", app.joinClassroomHandler)
+	router.POST(RouteGuess, app.guessHandler)
+	teacher := router.Group(RouteClassroom+"/:code/teacher", app.classroomTeacherMiddleware())
+	teacher.GET("/progress", app.classroomProgressHandler)
+	teacher.GET("/events", app.classroomEventsHandler)
+	post := func(path, sessionID string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: sessionID})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(RouteClassroom, "teacher-session", url.Values{"pack": {DefaultPackName}})
+	if w.Code != http.StatusSeeOther || len(w.Result().Cookies()) == 0 {
+		t.Fatalf("create = %d with cookies %v, want a redirect holding the teacher key", w.Code, w.Result().Cookies())
+	}
+	keyCookie := w.Result().Cookies()[0]
+	base := w.Header().Get("Location")
+	room, ok := app.Classrooms.get(strings.Split(base, "/")[2], app.now())
+	if !ok || keyCookie.Value != room.TeacherKey {
+		t.Fatalf("Expected the redirect to name the new classroom, got %q", base)
+	}
+
+	for _, sessionID := range []string{"student-session-a", "student-session-b"} {
+		if w := post(RouteClassroom+"/join", sessionID, url.Values{"code": {strings.ToLower(room.Code)}}); w.Code != http.StatusOK {
+			t.Fatalf("join = %d: %s", w.Code, w.Body)
+		}
+		if game := app.GameSessions[sessionID]; game == nil || game.SessionWord != room.Word.Word {
+			t.Fatalf("%s game = %+v, want the classroom word %s", sessionID, game, room.Word.Word)
+		}
+	}
+	first := app.GameSessions["student-session-a"]
+	post(RouteClassroom+"/join", "student-session-a", url.Values{"code": {room.Code}})
+	if app.GameSessions["student-session-a"] != first {
+		t.Error("Expected joining again to keep the student's game")
+	}
+	if w := post(RouteClassroom+"/join", "student-session-c", url.Values{"code": {"ZZZZZZ"}}); w.Code != http.StatusNotFound {
+		t.Errorf("join(unknown) = %d, want 404", w.Code)
+	}
+
+	miss := "SLATE"
+	if room.Word.Word == miss {
+		miss = "CRANE"
+	}
+	post(RouteGuess, "student-session-b", url.Values{"guess": {miss}, "row": {"0"}})
+	progress := room.progress()
+	if len(progress) != 2 || progress[1].Alias != "Student 2" || progress[1].RowsUsed != 1 || progress[0].RowsUsed != 0 || progress[1].Solved {
+		t.Errorf("progress = %+v, want one row used by Student 2", progress)
+	}
+
+	get := func(path, token string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := get(base+"/progress?format=json", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("progress without a key = %d, want 401", w.Code)
+	}
+	if w := get(base+"/progress?format=json", "admin-secret", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"rows_used":1`) {
+		t.Errorf("progress with the admin token = %d: %s", w.Code, w.Body)
+	}
+	if w := get(base+"/progress", "", keyCookie); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "1 / 6") || strings.Contains(w.Body.String(), "student-session-b") {
+		t.Errorf("progress fragment = %d: %s", w.Code, w.Body)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("GET", base+"/events", nil).WithContext(ctx)
+	req.AddCookie(keyCookie)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if body := w.Body.String(); w.Header().Get("Content-Type") != "text/event-stream" || !strings.Contains(body, "event: progress\ndata: [") || !strings.Contains(body, `"student":"Student 2"`) {
+		t.Errorf("events = %q, want a progress event", body)
+	}
+}
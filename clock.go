@@ -0,0 +1,40 @@
+package main
+
+import "time"
+
+// Clock tells the time. The app reads the time through a Clock for session access times, daily
+// rollover, rate limiting, and abuse bans, so tests can control it and operators can shift it.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the wall clock.
+type systemClock struct{}
+
+// Now returns the current time.
+func (systemClock) Now() time.Time { return time.Now() }
+
+// offsetClock is the wall clock shifted by a fixed offset, set with CLOCK_OFFSET to rehearse a
+// daily rollover without waiting for it.
+type offsetClock struct {
+	offset time.Duration
+}
+
+// Now returns the current time plus the offset.
+func (c offsetClock) Now() time.Time { return time.Now().Add(c.offset) }
+
+// newClock returns the wall clock, shifted by offset when it is non-zero.
+func newClock(offset time.Duration) Clock {
+	if offset == 0 {
+		return systemClock{}
+	}
+	return offsetClock{offset: offset}
+}
+
+// now returns the app's current time, falling back to the wall clock when no Clock is set.
+func (app *App) now() time.Time {
+	if app.Clock == nil {
+		return time.Now()
+	}
+	return app.Clock.Now()
+}
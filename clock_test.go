@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// fakeClock is a Clock that only moves when told to.
+type fakeClock struct {
+	now time.Time
+}
+
+// newFakeClock returns a fake clock stopped at an arbitrary fixed time.
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+// Now returns the fake time.
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// advance moves the fake time forward by d.
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestNewClock(t *testing.T) {
+	if _, ok := newClock(0).(systemClock); !ok {
+		t.Error("Expected the wall clock without an offset")
+	}
+	shifted := newClock(time.Hour).Now()
+	if d := time.Until(shifted); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("Expected the clock to run an hour ahead, got %v", d)
+	}
+}
+
+func TestBlocklistBanExpiresWithClock(t *testing.T) {
+	clock := newFakeClock()
+	b := newBlocklist("", 2, time.Minute, time.Hour)
+	b.clock = clock
+
+	b.recordStrike("192.0.2.1")
+	clock.advance(2 * time.Minute)
+	if b.recordStrike("192.0.2.1") {
+		t.Fatal("Strikes outside the window should not count towards a ban")
+	}
+	if !b.recordStrike("192.0.2.1") || !b.isBlocked("192.0.2.1") {
+		t.Fatal("Expected a ban after two strikes within the window")
+	}
+	clock.advance(time.Hour)
+	if b.isBlocked("192.0.2.1") {
+		t.Error("Expected the ban to lapse after the ban duration")
+	}
+}
+
+func TestRateLimitRefillsWithClock(t *testing.T) {
+	clock := newFakeClock()
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Clock = clock
+	app.RateLimitRPS = 1
+	app.RateLimitBurst = 1
+	app.LimiterMap = make(map[string]*rate.Limiter)
+	limiter := app.getLimiter("192.0.2.1")
+	if !limiter.AllowN(app.now(), 1) || limiter.AllowN(app.now(), 1) {
+		t.Fatal("Expected the burst of one to be used up")
+	}
+	clock.advance(time.Second)
+	if !limiter.AllowN(app.now(), 1) {
+		t.Error("Expected the limiter to refill after a second")
+	}
+}
@@ -0,0 +1,198 @@
+// Command benchreport compares benchmark results against a stored baseline and exits non-zero
+// when a benchmark allocates more than the threshold allows, so performance work has a target
+// and a change that makes a hot path allocate is caught before it ships.
+//
+// Run from the repository root, feeding it the output of go test:
+//
+//	go test -run '^$' -bench . -benchmem -count 5 ./... | go run ./cmd/benchreport
+//
+// With -update the results are written as the new baseline instead. Benchmarks are keyed by
+// package and name, and with -count above 1 the lowest of each measurement over the runs is
+// used. Only allocations per op gate: they are the same on every run and every machine, while
+// a single time sample varies by more than any useful threshold even on an unchanged tree, so
+// time is reported, and flagged when it grew past the threshold, but never fails the run.
+// Timings depend on the machine, so the baseline records the CPU it was made on and a mismatch
+// is warned about.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// defaultBaselinePath is where the baseline is kept, relative to the repository root.
+const defaultBaselinePath = "bench-baseline.json"
+
+// procSuffix is the -GOMAXPROCS suffix go test appends to benchmark names.
+var procSuffix = regexp.MustCompile(`-\d+$`)
+
+// result is one benchmark's measurements.
+type result struct {
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+}
+
+// baseline is the stored set of results and the CPU they were measured on.
+type baseline struct {
+	CPU        string            `json:"cpu"`
+	Benchmarks map[string]result `json:"benchmarks"`
+}
+
+func main() {
+	baselinePath := flag.String("baseline", defaultBaselinePath, "baseline file")
+	threshold := flag.Float64("threshold", 0.2, "allowed allocation increase, as a fraction; slowdowns past it are reported only")
+	update := flag.Bool("update", false, "write the results as the new baseline")
+	flag.Parse()
+
+	current, err := parse(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(current.Benchmarks) == 0 {
+		log.Fatal("no benchmark results on stdin; pipe in go test -bench output")
+	}
+
+	if *update {
+		data, err := json.MarshalIndent(current, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*baselinePath, append(data, '\n'), 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Wrote %d benchmarks to %s\n", len(current.Benchmarks), *baselinePath)
+		return
+	}
+
+	data, err := os.ReadFile(*baselinePath)
+	if err != nil {
+		log.Fatalf("%v; create one with -update", err)
+	}
+	var base baseline
+	if err := json.Unmarshal(data, &base); err != nil {
+		log.Fatalf("%s: %v", *baselinePath, err)
+	}
+	if base.CPU != current.CPU {
+		fmt.Fprintf(os.Stderr, "warning: baseline was recorded on %q, this run is on %q; timings may not be comparable\n", base.CPU, current.CPU)
+	}
+	if regressions := compare(os.Stdout, base, current, *threshold); regressions > 0 {
+		fmt.Fprintf(os.Stderr, "%d benchmarks allocate more than %.0f%% over the baseline\n", regressions, *threshold*100)
+		os.Exit(1)
+	}
+}
+
+// parse reads go test -bench output, keeping the lowest of each measurement over the runs of
+// each benchmark.
+func parse(r io.Reader) (baseline, error) {
+	b := baseline{Benchmarks: map[string]result{}}
+	pkg := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(rest)
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "cpu: "); ok {
+			b.CPU = strings.TrimSpace(rest)
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		var res result
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return b, fmt.Errorf("%s: %v", line, err)
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				res.NsPerOp = v
+			case "B/op":
+				res.BytesPerOp = v
+			case "allocs/op":
+				res.AllocsPerOp = v
+			}
+		}
+		key := pkg + "." + procSuffix.ReplaceAllString(fields[0], "")
+		if prev, ok := b.Benchmarks[key]; ok {
+			res.NsPerOp = min(res.NsPerOp, prev.NsPerOp)
+			res.BytesPerOp = min(res.BytesPerOp, prev.BytesPerOp)
+			res.AllocsPerOp = min(res.AllocsPerOp, prev.AllocsPerOp)
+		}
+		b.Benchmarks[key] = res
+	}
+	return b, scanner.Err()
+}
+
+// compare writes a table of current against base and returns how many benchmarks regressed in
+// allocations. Time past the threshold is marked slower without counting, and benchmarks
+// missing from either side are listed but do not count either.
+func compare(w io.Writer, base, current baseline, threshold float64) int {
+	keys := make([]string, 0, len(current.Benchmarks))
+	for key := range current.Benchmarks {
+		keys = append(keys, key)
+	}
+	for key := range base.Benchmarks {
+		if _, ok := current.Benchmarks[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BENCHMARK\tBASE NS/OP\tNS/OP\tDELTA\tBASE ALLOCS\tALLOCS\tSTATUS")
+	regressions := 0
+	for _, key := range keys {
+		old, inBase := base.Benchmarks[key]
+		cur, inCurrent := current.Benchmarks[key]
+		switch {
+		case !inCurrent:
+			fmt.Fprintf(tw, "%s\t%.1f\t-\t-\t%.0f\t-\tmissing\n", key, old.NsPerOp, old.AllocsPerOp)
+		case !inBase:
+			fmt.Fprintf(tw, "%s\t-\t%.1f\t-\t-\t%.0f\tnew\n", key, cur.NsPerOp, cur.AllocsPerOp)
+		default:
+			status := "ok"
+			switch {
+			case exceeds(cur.AllocsPerOp, old.AllocsPerOp, threshold):
+				status = "REGRESSED"
+				regressions++
+			case exceeds(cur.NsPerOp, old.NsPerOp, threshold):
+				status = "slower"
+			}
+			fmt.Fprintf(tw, "%s\t%.1f\t%.1f\t%s\t%.0f\t%.0f\t%s\n", key, old.NsPerOp, cur.NsPerOp, delta(cur.NsPerOp, old.NsPerOp), old.AllocsPerOp, cur.AllocsPerOp, status)
+		}
+	}
+	tw.Flush()
+	return regressions
+}
+
+// exceeds reports whether cur is more than threshold above old. Any increase from zero counts,
+// so a path that stops being allocation-free is caught.
+func exceeds(cur, old, threshold float64) bool {
+	if old == 0 {
+		return cur > 0
+	}
+	return cur > old*(1+threshold)
+}
+
+// delta formats the change from old to cur as a percentage.
+func delta(cur, old float64) string {
+	if old == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", (cur-old)/old*100)
+}
@@ -0,0 +1,354 @@
+// Command report summarizes what an instance stores and roughly how much memory its
+// in-process structures need, for capacity planning on small hosts: disk usage by data type,
+// the largest word packs, and estimates for sessions, player history, the guess cache, and the
+// feedback matrix.
+//
+// It reads the same settings as the server (NAMESPACE, the *_FILE and *_DIR paths,
+// SESSION_JOURNAL_DIR, MAX_SESSIONS, GUESS_CACHE_SIZE, FEEDBACK_MATRIX_MAX_MB, and
+// RETENTION_HISTORY_GAMES), so run it where the server runs:
+//
+//	go run ./cmd/report
+//	go run ./cmd/report -json
+//
+// Memory figures are estimates from type sizes and the word lists on disk, sized for the
+// configured limits, not measurements of a running server.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unsafe"
+
+	"github.com/mooship/vortludo/engine"
+)
+
+// Paths and defaults shared with the server
+const (
+	dataDir             = "data"
+	namespacesDir       = "data/namespaces"
+	wordsFile           = "data/words.json"
+	acceptedWordsFile   = "data/accepted_words.txt"
+	packsDir            = "data/packs"
+	acceptedOverlaysDir = "data/accepted"
+	defaultPackName     = "classic"
+)
+
+// Rough sizes of what the type sizes below do not cover
+const (
+	// mapEntryBytes is the per-entry overhead of a Go map beyond its key and value.
+	mapEntryBytes = 32
+	// gameRecordBytes is a finished-game record (112 bytes) with its word, pack, and date.
+	gameRecordBytes = 136
+	// sessionIDBytes is a session ID string and its header.
+	sessionIDBytes = 16 + 32
+	// sessionFieldsBytes is what the server keeps about a game beside the engine's state: its
+	// pack, word-list version, mode flags, extra rows, reveals, completion bitmap, and token.
+	sessionFieldsBytes = 112
+)
+
+// usage is the disk usage of one data type.
+type usage struct {
+	Type  string `json:"type"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// packSize is the size of one word pack on disk and in memory.
+type packSize struct {
+	Pack        string `json:"pack"`
+	Words       int    `json:"words"`
+	FileBytes   int64  `json:"file_bytes"`
+	MemoryBytes int64  `json:"memory_bytes"`
+}
+
+// estimate is the estimated memory of one in-process structure at its configured limit.
+type estimate struct {
+	Structure string `json:"structure"`
+	Count     int    `json:"count"`
+	EachBytes int64  `json:"each_bytes"`
+	Bytes     int64  `json:"bytes"`
+	Note      string `json:"note,omitempty"`
+}
+
+// report is everything the command prints.
+type report struct {
+	Namespace string     `json:"namespace,omitempty"`
+	Storage   []usage    `json:"storage"`
+	Packs     []packSize `json:"packs"`
+	Memory    []estimate `json:"memory"`
+}
+
+func main() {
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	top := flag.Int("top", 5, "number of word packs to list")
+	flag.Parse()
+
+	namespace := os.Getenv("NAMESPACE")
+	r := report{Namespace: namespace}
+
+	counted := map[string]bool{}
+	for _, t := range dataTypes(namespace) {
+		u := usage{Type: t.name}
+		for _, path := range t.paths {
+			files, bytes, err := walk(path, counted)
+			if err != nil {
+				log.Fatalf("%s: %v", path, err)
+			}
+			u.Files += files
+			u.Bytes += bytes
+		}
+		r.Storage = append(r.Storage, u)
+	}
+	other := usage{Type: "other"}
+	files, bytes, err := walk(dataPath(namespace, ""), counted)
+	if err != nil {
+		log.Fatal(err)
+	}
+	other.Files, other.Bytes = files, bytes
+	r.Storage = append(r.Storage, other)
+
+	packs, playable, err := packSizes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].MemoryBytes > packs[j].MemoryBytes })
+	r.Packs = packs[:min(*top, len(packs))]
+
+	accepted, err := engine.LoadAcceptedWords(acceptedWordsFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatal(err)
+	}
+	if accepted == nil {
+		accepted = map[string]struct{}{}
+	}
+	for word := range playable {
+		accepted[word] = struct{}{}
+	}
+	r.Memory = memoryEstimates(len(accepted), len(playable))
+
+	if *asJSON {
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(out))
+		return
+	}
+	printReport(r)
+}
+
+// dataType is a kind of stored data and the paths it lives in.
+type dataType struct {
+	name  string
+	paths []string
+}
+
+// dataTypes returns where each kind of data is stored, with the server's defaults.
+func dataTypes(namespace string) []dataType {
+	file := func(key, name string) string { return getenv(key, dataPath(namespace, name)) }
+	var journals []string
+	for dir := range strings.SplitSeq(os.Getenv("SESSION_JOURNAL_DIR"), ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			journals = append(journals, dir)
+		}
+	}
+	return []dataType{
+		{"sessions", journals},
+		{"history", []string{file("PLAYER_STATS_FILE", "player-stats.json"), file("PLAYER_ARCHIVE_DIR", "archive")}},
+		{"stats", []string{file("GLOBAL_STATS_FILE", "global-stats.json")}},
+		{"preferences", []string{file("PREFERENCES_FILE", "preferences.json")}},
+		{"subscribers", []string{file("REMINDERS_FILE", "reminders.json"), file("PUSH_SUBSCRIPTIONS_FILE", "push-subscriptions.json")}},
+		{"audit", []string{file("AUDIT_LOG_FILE", "audit.jsonl")}},
+		{"moderation", []string{file("SUGGESTIONS_FILE", "suggestions.json"), file("WORD_FEEDBACK_FILE", "word-feedback.json"), file("BLOCKLIST_FILE", "blocklist.json")}},
+		{"tokens", []string{file("USED_TOKENS_FILE", "used-tokens.json")}},
+		{"calendar", []string{file("PUZZLE_CALENDAR_FILE", "puzzle-calendar.json")}},
+		{"word packs", []string{wordsFile, acceptedWordsFile, packsDir, acceptedOverlaysDir}},
+	}
+}
+
+// dataPath returns the default path of a data file, as the server resolves it.
+func dataPath(namespace, name string) string {
+	if namespace == "" {
+		return filepath.Join(dataDir, name)
+	}
+	return filepath.Join(namespacesDir, namespace, name)
+}
+
+// getenv returns the environment variable key, or fallback when it is unset.
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// getenvInt returns the environment variable key as an int, or fallback when it is unset.
+func getenvInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("%s: %v", key, err)
+	}
+	return n
+}
+
+// walk totals the files under path not yet in counted, adding them to it. Other namespaces'
+// data is skipped when walking the default data directory. A missing path is empty.
+func walk(path string, counted map[string]bool) (int, int64, error) {
+	files, bytes := 0, int64(0)
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if filepath.Clean(p) == filepath.Clean(namespacesDir) && filepath.Clean(path) != filepath.Clean(p) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		abs, _ := filepath.Abs(p)
+		if counted[abs] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		counted[abs] = true
+		files++
+		bytes += info.Size()
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+	return files, bytes, err
+}
+
+// packSizes returns the size of the default word list and every pack, and the set of playable
+// words across all of them.
+func packSizes() ([]packSize, map[string]struct{}, error) {
+	paths := map[string]string{defaultPackName: wordsFile}
+	files, err := filepath.Glob(filepath.Join(packsDir, "*.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, f := range files {
+		paths[strings.TrimSuffix(filepath.Base(f), ".json")] = f
+	}
+	playable := map[string]struct{}{}
+	var packs []packSize
+	for name, path := range paths {
+		words, _, err := engine.LoadWordList(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		p := packSize{Pack: name, Words: len(words), FileBytes: info.Size()}
+		for _, w := range words {
+			// Each word is held as an entry with its hint and once more as a word-set key.
+			p.MemoryBytes += int64(unsafe.Sizeof(w)) + int64(len(w.Word)+len(w.Hint))
+			p.MemoryBytes += int64(unsafe.Sizeof(w.Word)) + int64(len(w.Word)) + mapEntryBytes
+			playable[w.Word] = struct{}{}
+		}
+		packs = append(packs, p)
+	}
+	return packs, playable, nil
+}
+
+// memoryEstimates sizes the in-process structures at the configured limits, for accepted
+// guesses and playable words.
+func memoryEstimates(accepted, playable int) []estimate {
+	maxSessions := getenvInt("MAX_SESSIONS", 50000)
+	historyGames := getenvInt("RETENTION_HISTORY_GAMES", 1000)
+	cacheSize := getenvInt("GUESS_CACHE_SIZE", 4096)
+	matrixLimit := int64(getenvInt("FEEDBACK_MATRIX_MAX_MB", 64)) << 20
+
+	row := int64(unsafe.Sizeof([]engine.GuessResult{})) + engine.WordLength*int64(unsafe.Sizeof(engine.GuessResult{}))
+	session := int64(unsafe.Sizeof(engine.GameState{})) + sessionFieldsBytes + engine.MaxGuesses*row +
+		engine.MaxGuesses*(int64(unsafe.Sizeof(""))+engine.WordLength) + sessionIDBytes + mapEntryBytes
+	history := int64(historyGames) * gameRecordBytes
+	// A cached guess is its guess and target key, an LRU list element, and its result row.
+	cached := 2*(int64(unsafe.Sizeof(""))+engine.WordLength) + 48 + row + mapEntryBytes
+	word := int64(unsafe.Sizeof("")) + engine.WordLength + mapEntryBytes
+
+	matrix := int64(accepted) * int64(playable) * int64(unsafe.Sizeof(engine.Pattern(0)))
+	matrixNote := "precomputed at startup"
+	switch {
+	case matrixLimit <= 0:
+		matrix, matrixNote = 0, "disabled"
+	case matrix > matrixLimit:
+		matrix, matrixNote = matrixLimit, fmt.Sprintf("capped by FEEDBACK_MATRIX_MAX_MB; the full matrix needs %s", formatBytes(matrix))
+	}
+
+	return []estimate{
+		{Structure: "sessions", Count: maxSessions, EachBytes: session, Bytes: int64(maxSessions) * session, Note: "at MAX_SESSIONS"},
+		{Structure: "player history", Count: maxSessions, EachBytes: history, Bytes: int64(maxSessions) * history, Note: fmt.Sprintf("worst case: every session with %d games", historyGames)},
+		{Structure: "guess cache", Count: cacheSize, EachBytes: cached, Bytes: int64(max(cacheSize, 0)) * cached},
+		{Structure: "accepted words", Count: accepted, EachBytes: word, Bytes: int64(accepted) * word},
+		{Structure: "feedback matrix", Count: accepted * playable, EachBytes: 1, Bytes: matrix, Note: matrixNote},
+	}
+}
+
+// printReport writes r as aligned tables.
+func printReport(r report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if r.Namespace != "" {
+		fmt.Fprintf(w, "Namespace %s\n\n", r.Namespace)
+	}
+	fmt.Fprintln(w, "STORAGE\tFILES\tSIZE")
+	var files int
+	var bytes int64
+	for _, u := range r.Storage {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", u.Type, u.Files, formatBytes(u.Bytes))
+		files += u.Files
+		bytes += u.Bytes
+	}
+	fmt.Fprintf(w, "total\t%d\t%s\n\n", files, formatBytes(bytes))
+
+	fmt.Fprintln(w, "WORD PACK\tWORDS\tFILE\tMEMORY")
+	for _, p := range r.Packs {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", p.Pack, p.Words, formatBytes(p.FileBytes), formatBytes(p.MemoryBytes))
+	}
+
+	fmt.Fprintln(w, "\nMEMORY\tCOUNT\tEACH\tTOTAL\tNOTE")
+	bytes = 0
+	for _, e := range r.Memory {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", e.Structure, e.Count, formatBytes(e.EachBytes), formatBytes(e.Bytes), e.Note)
+		bytes += e.Bytes
+	}
+	fmt.Fprintf(w, "total\t\t\t%s\tupper bound\n", formatBytes(bytes))
+	w.Flush()
+}
+
+// formatBytes formats n in B, KB, MB, or GB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KB"
+	for _, s := range []string{"MB", "GB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, s
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
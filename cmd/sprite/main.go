@@ -0,0 +1,72 @@
+// Command sprite composes the SVG icons in static/icons/ into a single sprite,
+// static/icons.svg, with one <symbol> per icon whose id is the file name without ".svg".
+// Templates reference icons with {{icon "name"}}, which uses the sprite when it holds the icon
+// and the Bootstrap Icons font otherwise.
+//
+// Copy the Bootstrap Icons SVGs the templates use into static/icons/, then run from the
+// repository root:
+//
+//	go run ./cmd/sprite
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	svgRoot     = regexp.MustCompile(`(?s)<svg\b([^>]*)>(.*)</svg>`)
+	viewBoxAttr = regexp.MustCompile(`\sviewBox="([^"]+)"`)
+)
+
+func main() {
+	files, err := filepath.Glob(filepath.Join("static", "icons", "*.svg"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(files) == 0 {
+		log.Fatal("no icons found in static/icons")
+	}
+	sort.Strings(files)
+
+	var out bytes.Buffer
+	out.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" style="display:none">` + "\n")
+	for _, f := range files {
+		symbol, err := toSymbol(f)
+		if err != nil {
+			log.Fatalf("%s: %v", f, err)
+		}
+		out.WriteString(symbol)
+	}
+	out.WriteString("</svg>\n")
+
+	dest := filepath.Join("static", "icons.svg")
+	if err := os.WriteFile(dest, out.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d icons to %s", len(files), dest)
+}
+
+// toSymbol converts the SVG file at path into a <symbol> element keyed by its file name.
+func toSymbol(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	m := svgRoot.FindSubmatch(data)
+	if m == nil {
+		return "", fmt.Errorf("no <svg> element")
+	}
+	viewBox := viewBoxAttr.FindSubmatch(m[1])
+	if viewBox == nil {
+		return "", fmt.Errorf("missing viewBox")
+	}
+	id := strings.TrimSuffix(filepath.Base(path), ".svg")
+	return fmt.Sprintf("  <symbol id=%q viewBox=%q>%s</symbol>\n", id, viewBox[1], bytes.TrimSpace(m[2])), nil
+}
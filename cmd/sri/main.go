@@ -0,0 +1,99 @@
+// Command sri writes the subresource integrity hashes for the CDN dependencies referenced by
+// the templates to cdn-integrity.json, which the server merges with the hashes it computes for
+// local assets at startup.
+//
+// Run from the repository root whenever a CDN dependency changes:
+//
+//	go run ./cmd/sri
+//
+// Only URLs pinned to an exact version (for example bootstrap@5.3.3) are hashed. Floating tags
+// such as bootstrap@5 can change content under the same URL, which would make a pinned hash
+// block the asset, so they are reported and skipped.
+package main
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var (
+	sriCall     = regexp.MustCompile(`\{\{\s*sri\s+"(https://[^"]+)"\s*\}\}`)
+	exactPinned = regexp.MustCompile(`@\d+\.\d+\.\d+/`)
+)
+
+func main() {
+	files, err := filepath.Glob("templates/*.html")
+	if err != nil {
+		log.Fatal(err)
+	}
+	partials, err := filepath.Glob("templates/partials/*.html")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	urls := map[string]bool{}
+	for _, f := range append(files, partials...) {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, m := range sriCall.FindAllSubmatch(data, -1) {
+			urls[string(m[1])] = true
+		}
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	hashes := map[string]string{}
+	sorted := make([]string, 0, len(urls))
+	for url := range urls {
+		sorted = append(sorted, url)
+	}
+	sort.Strings(sorted)
+	for _, url := range sorted {
+		if !exactPinned.MatchString(url) {
+			log.Printf("skipping %s: not pinned to an exact version", url)
+			continue
+		}
+		hash, err := fetchHash(client, url)
+		if err != nil {
+			log.Fatalf("%s: %v", url, err)
+		}
+		hashes[url] = hash
+		log.Printf("%s %s", hash, url)
+	}
+
+	out, err := json.MarshalIndent(hashes, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("cdn-integrity.json", append(out, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// fetchHash downloads url and returns its sha384 integrity value.
+func fetchHash(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	h := sha512.New384()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return "", err
+	}
+	return "sha384-" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
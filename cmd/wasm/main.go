@@ -0,0 +1,66 @@
+//go:build js && wasm
+
+// Command wasm exposes the game engine to the browser so accepted-word checks and tile
+// coloring can run client-side. The server remains authoritative for every guess.
+//
+// Build with:
+//
+//	GOOS=js GOARCH=wasm go build -o static/engine.wasm ./cmd/wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" static/
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/mooship/vortludo/engine"
+)
+
+// accepted holds the accepted-word set loaded from the server.
+var accepted map[string]struct{}
+
+// loadAcceptedWords parses a newline-separated word list passed from JavaScript.
+func loadAcceptedWords(this js.Value, args []js.Value) any {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return false
+	}
+	accepted = engine.ParseAcceptedWords([]byte(args[0].String()))
+	this.Set("ready", true)
+	return len(accepted)
+}
+
+// isAccepted reports whether a guess is in the accepted-word set.
+func isAccepted(_ js.Value, args []js.Value) any {
+	if len(args) != 1 || accepted == nil {
+		return false
+	}
+	_, ok := accepted[engine.NormalizeGuess(args[0].String())]
+	return ok
+}
+
+// checkGuess returns the per-letter results for a guess as an array of {letter, status} objects.
+func checkGuess(_ js.Value, args []js.Value) any {
+	if len(args) != 2 {
+		return js.Null()
+	}
+	results := engine.CheckGuess(engine.NormalizeGuess(args[0].String()), engine.NormalizeGuess(args[1].String()))
+	if results == nil {
+		return js.Null()
+	}
+	out := make([]any, len(results))
+	for i, r := range results {
+		out[i] = map[string]any{"letter": r.Letter, "status": r.Status}
+	}
+	return out
+}
+
+func main() {
+	api := js.Global().Get("Object").New()
+	api.Set("ready", false)
+	api.Set("wordLength", engine.WordLength)
+	api.Set("maxGuesses", engine.MaxGuesses)
+	api.Set("loadAcceptedWords", js.FuncOf(loadAcceptedWords))
+	api.Set("isAccepted", js.FuncOf(isAccepted))
+	api.Set("checkGuess", js.FuncOf(checkGuess))
+	js.Global().Set("vortludo", api)
+	select {}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxExtraRows caps how many extra rows a single game can buy.
+const MaxExtraRows = 2
+
+// CoinRules prices the casual-mode coin economy. Coins are earned by winning casual games and
+// spent on letter reveals and extra rows; PerWin of 0 disables the economy.
+type CoinRules struct {
+	PerWin       int
+	RevealCost   int
+	ExtraRowCost int
+}
+
+// enabled reports whether coins are earned and spent at all.
+func (r CoinRules) enabled() bool {
+	return r.PerWin > 0
+}
+
+// coinsView is the coin balance and shop shown above a casual game's board.
+type coinsView struct {
+	Balance      int
+	RevealCost   int
+	ExtraRowCost int
+	CanReveal    bool
+	CanAddRow    bool
+	Revealed     string
+}
+
+// casualGame reports whether game is a casual game, the only mode that earns or spends coins.
+// Purist, kids, custom, daily, and co-op games stay free of purchased help so their results
+// remain comparable.
+func (app *App) casualGame(sessionID string, game *GameState) bool {
+	return app.Coins.enabled() && game != nil && !game.Purist && !game.Kids && !game.Custom && game.Daily == "" && app.coopRoom(sessionID, game) == nil
+}
+
+// awardCoins credits the coins for a won casual game.
+func (app *App) awardCoins(c *gin.Context, sessionID string, game *GameState) {
+	if app.Players == nil || !game.Won || !app.casualGame(sessionID, game) {
+		return
+	}
+	app.Players.addCoins(app.playerKey(c, sessionID), app.Coins.PerWin)
+}
+
+// revealedPattern shows the revealed letters of word in place, such as "C _ _ N _".
+func revealedPattern(word string, revealed []int) string {
+	if len(revealed) == 0 {
+		return ""
+	}
+	cells := make([]string, len(word))
+	for i := range cells {
+		cells[i] = "_"
+	}
+	for _, i := range revealed {
+		if i >= 0 && i < len(word) {
+			cells[i] = word[i : i+1]
+		}
+	}
+	return strings.Join(cells, " ")
+}
+
+// coinsStatus returns the coin shop for a casual game, or nil in other modes or when the
+// economy is disabled.
+func (app *App) coinsStatus(c *gin.Context, game *GameState) *coinsView {
+	sessionID := c.GetString(sessionContextKey)
+	if app.Players == nil || sessionID == "" || !app.casualGame(sessionID, game) {
+		return nil
+	}
+	balance := app.Players.coinBalance(app.playerKey(c, sessionID))
+	return &coinsView{
+		Balance:      balance,
+		RevealCost:   app.Coins.RevealCost,
+		ExtraRowCost: app.Coins.ExtraRowCost,
+		CanReveal:    !game.GameOver && balance >= app.Coins.RevealCost,
+		CanAddRow:    !game.GameOver && game.ExtraRows < MaxExtraRows && balance >= app.Coins.ExtraRowCost,
+		Revealed:     revealedPattern(game.SessionWord, game.Revealed),
+	}
+}
+
+// spendCoins charges cost for a purchase on the session's running casual game and applies it
+// with buy. The coins are refunded when buy fails, and the game is rendered either way.
+func (app *App) spendCoins(c *gin.Context, cost int, event string, buy func(*GameState) error) {
+	ctx := c.Request.Context()
+	sessionID := app.getOrCreateSession(c)
+	game := app.getGameState(ctx, sessionID)
+	hint := app.gameHint(game)
+
+	if err := app.validateGameState(c, game); err != nil {
+		app.renderGameError(c, game, hint, err.Error())
+		return
+	}
+	if app.Players == nil || !app.casualGame(sessionID, game) {
+		app.renderGameError(c, game, hint, ErrorCodeCoinsUnavailable)
+		return
+	}
+	key := app.playerKey(c, sessionID)
+	if !app.Players.spendCoins(key, cost) {
+		app.renderGameError(c, game, hint, ErrorCodeInsufficientCoins)
+		return
+	}
+	if err := buy(game); err != nil {
+		app.Players.addCoins(key, cost)
+		app.renderGameError(c, game, hint, err.Error())
+		return
+	}
+	app.saveGameState(sessionID, game)
+	app.Journal.record(c.Request.Context(), sessionID, game, event)
+	app.renderGame(c, game, hint, nil)
+}
+
+// coinRevealHandler spends coins to reveal one more letter of the target word.
+func (app *App) coinRevealHandler(c *gin.Context) {
+	app.spendCoins(c, app.Coins.RevealCost, JournalReveal, func(game *GameState) error {
+		if game.reveal(game.SessionWord) < 0 {
+			return errors.New(ErrorCodeNothingToBuy)
+		}
+		return nil
+	})
+}
+
+// coinExtraRowHandler spends coins to add a row to the board, up to MaxExtraRows per game.
+func (app *App) coinExtraRowHandler(c *gin.Context) {
+	app.spendCoins(c, app.Coins.ExtraRowCost, JournalRow, func(game *GameState) error {
+		if game.ExtraRows >= MaxExtraRows {
+			return errors.New(ErrorCodeNothingToBuy)
+		}
+		game.addRow()
+		return nil
+	})
+}
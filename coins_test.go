@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestCoinsEarnedAndSpentInCasualGamesOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Players = newPlayerStatsStore(StreakFreezeRules{})
+	app.Coins = CoinRules{PerWin: 10, RevealCost: 5, ExtraRowCost: 15}
+	router := gin.New()
+	router.POST(RouteCoins+"/reveal", app.coinRevealHandler)
+	router.POST(RouteCoins+"/extra-row", app.coinExtraRowHandler)
+
+	buy := func(path string) string {
+		req := httptest.NewRequest(http.MethodPost, path+"?format=json", nil)
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session-123"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var body struct {
+			ErrorCode string `json:"errorCode"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: %d %s", path, w.Code, w.Body)
+		}
+		return body.ErrorCode
+	}
+
+	app.awardCoins(&gin.Context{}, "session-123", &GameState{GameState: engine.GameState{SessionWord: "CRANE", GameOver: true, Won: true}, Purist: true})
+	if got := app.Players.coinBalance("session-123"); got != 0 {
+		t.Fatalf("purist win earned %d coins, want 0", got)
+	}
+	app.awardCoins(&gin.Context{}, "session-123", &GameState{GameState: engine.GameState{SessionWord: "CRANE", GameOver: true, Won: true}})
+	if got := app.Players.coinBalance("session-123"); got != 10 {
+		t.Fatalf("casual win earned %d coins, want 10", got)
+	}
+
+	game := &GameState{GameState: engine.GameState{SessionWord: "CRANE", Guesses: [][]GuessResult{make([]GuessResult, WordLength)}}}
+	app.GameSessions["session-123"] = game
+	if code := buy(RouteCoins + "/reveal"); code != "" {
+		t.Fatalf("reveal = %q, want success", code)
+	}
+	if len(game.Revealed) != 1 || app.Players.coinBalance("session-123") != 5 {
+		t.Errorf("after reveal: revealed %v with %d coins, want one letter and 5 coins", game.Revealed, app.Players.coinBalance("session-123"))
+	}
+	if code := buy(RouteCoins + "/extra-row"); code != ErrorCodeInsufficientCoins {
+		t.Errorf("extra row with 5 coins = %q, want %q", code, ErrorCodeInsufficientCoins)
+	}
+
+	game.Purist = true
+	if code := buy(RouteCoins + "/reveal"); code != ErrorCodeCoinsUnavailable {
+		t.Errorf("reveal in purist mode = %q, want %q", code, ErrorCodeCoinsUnavailable)
+	}
+	if got := app.Players.coinBalance("session-123"); got != 5 {
+		t.Errorf("balance = %d after refused purchases, want 5", got)
+	}
+}
+
+func TestRevealedPattern(t *testing.T) {
+	if got := revealedPattern("CRANE", []int{0, 3}); got != "C _ _ N _" {
+		t.Errorf("revealedPattern() = %q", got)
+	}
+	if got := revealedPattern("CRANE", nil); got != "" {
+		t.Errorf("revealedPattern(nil) = %q, want empty", got)
+	}
+}
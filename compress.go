@@ -0,0 +1,181 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/internal/htmx"
+)
+
+// GzipConfig controls response compression. Responses smaller than MinSize, or HTMXMinSize for
+// HTMX fragments, are sent as-is: compressing a few hundred bytes costs more CPU than it saves.
+type GzipConfig struct {
+	Level              int
+	ExcludedExtensions []string
+	ExcludedPaths      []string
+	MinSize            int
+	HTMXMinSize        int
+}
+
+// loadGzipConfig reads the GZIP_* settings.
+func loadGzipConfig() GzipConfig {
+	level := getEnvInt("GZIP_LEVEL", gzip.DefaultCompression)
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		logWarn("Invalid GZIP_LEVEL %d, using default compression", level)
+		level = gzip.DefaultCompression
+	}
+	return GzipConfig{
+		Level:              level,
+		ExcludedExtensions: getEnvList("GZIP_EXCLUDED_EXTENSIONS", []string{".svg", ".ico", ".png", ".jpg", ".jpeg", ".gif"}),
+		ExcludedPaths:      getEnvList("GZIP_EXCLUDED_PATHS", []string{"/static/fonts"}),
+		MinSize:            getEnvInt("GZIP_MIN_SIZE", 512),
+		HTMXMinSize:        getEnvInt("GZIP_HTMX_MIN_SIZE", 2048),
+	}
+}
+
+// excluded reports whether responses for path are never compressed.
+func (cfg GzipConfig) excluded(path string) bool {
+	if slices.Contains(cfg.ExcludedExtensions, filepath.Ext(path)) {
+		return true
+	}
+	return slices.ContainsFunc(cfg.ExcludedPaths, func(prefix string) bool {
+		return strings.HasPrefix(path, prefix)
+	})
+}
+
+// gzipMiddleware compresses responses for clients that accept gzip. The response is buffered
+// until it reaches the size threshold, so small responses go out uncompressed.
+func gzipMiddleware(cfg GzipConfig) gin.HandlerFunc {
+	pool := &sync.Pool{New: func() any {
+		gz, _ := gzip.NewWriterLevel(io.Discard, cfg.Level)
+		return gz
+	}}
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || cfg.excluded(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+		minSize := cfg.MinSize
+		if htmx.IsRequest(c.Request) {
+			minSize = max(minSize, cfg.HTMXMinSize)
+		}
+		w := &gzipResponseWriter{ResponseWriter: c.Writer, pool: pool, minSize: minSize}
+		c.Writer = w
+		defer w.close()
+		c.Next()
+	}
+}
+
+// gzipResponseWriter holds back the start of a response until it knows whether the response is
+// big enough to compress.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	pool    *sync.Pool
+	gz      *gzip.Writer
+	buf     []byte
+	minSize int
+	raw     bool
+}
+
+// Write buffers b until the threshold is reached, then compresses everything written so far.
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(b)
+	case w.raw:
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) < w.minSize {
+		return len(b), nil
+	}
+	if err := w.start(); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// WriteString writes s.
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports whether anything has been written, including buffered output.
+func (w *gzipResponseWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// compressible reports whether the response, as it stands, may be compressed. Partial content,
+// already encoded bodies, empty statuses, and responses whose headers are already out are left
+// alone.
+func (w *gzipResponseWriter) compressible() bool {
+	if w.ResponseWriter.Written() {
+		return false
+	}
+	h := w.Header()
+	switch w.Status() {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+	return h.Get("Content-Encoding") == "" && h.Get("Content-Range") == ""
+}
+
+// start decides how to send the response and writes out the buffer.
+func (w *gzipResponseWriter) start() error {
+	buf := w.buf
+	w.buf = nil
+	if !w.compressible() {
+		w.raw = true
+		_, err := w.ResponseWriter.Write(buf)
+		return err
+	}
+	h := w.Header()
+	h.Set("Content-Encoding", "gzip")
+	h.Del("Content-Length")
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+	w.gz = w.pool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	_, err := w.gz.Write(buf)
+	return err
+}
+
+// Flush sends buffered output. A response flushed before reaching the threshold is sent
+// uncompressed.
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	} else if !w.raw {
+		w.raw = true
+		_, _ = w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+	w.ResponseWriter.Flush()
+}
+
+// close finishes the response: it sends a short response as-is or ends the gzip stream.
+func (w *gzipResponseWriter) close() {
+	if w.gz == nil {
+		if len(w.buf) > 0 {
+			_, _ = w.ResponseWriter.Write(w.buf)
+			w.buf = nil
+		}
+		return
+	}
+	_ = w.gz.Close()
+	w.gz.Reset(io.Discard)
+	w.pool.Put(w.gz)
+	w.gz = nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/internal/htmx"
+)
+
+func TestGzipMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := GzipConfig{
+		Level:              gzip.DefaultCompression,
+		ExcludedExtensions: []string{".png"},
+		ExcludedPaths:      []string{"/static/fonts"},
+		MinSize:            100,
+		HTMXMinSize:        1000,
+	}
+	big := strings.Repeat("crane ", 100)
+	router := gin.New()
+	router.Use(gzipMiddleware(cfg))
+	router.GET("/small", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.GET("/big", func(c *gin.Context) { c.String(http.StatusOK, big) })
+	router.GET("/logo.png", func(c *gin.Context) { c.String(http.StatusOK, big) })
+	router.GET("/static/fonts/a.woff2", func(c *gin.Context) { c.String(http.StatusOK, big) })
+
+	get := func(path string, hx bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		if hx {
+			req.Header.Set(htmx.HeaderRequest, "true")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		path     string
+		hx, gzip bool
+	}{
+		{"/small", false, false},
+		{"/big", false, true},
+		{"/big", true, false},
+		{"/logo.png", false, false},
+		{"/static/fonts/a.woff2", false, false},
+	}
+	for _, tt := range tests {
+		w := get(tt.path, tt.hx)
+		if got := w.Header().Get("Content-Encoding") == "gzip"; got != tt.gzip {
+			t.Errorf("%s (htmx=%v): compressed = %v, want %v", tt.path, tt.hx, got, tt.gzip)
+			continue
+		}
+		body := w.Body.String()
+		if tt.gzip {
+			r, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(r)
+			body = string(b)
+		}
+		if body != big && body != "ok" {
+			t.Errorf("%s: body did not round-trip, got %d bytes", tt.path, len(body))
+		}
+	}
+	if w := get("/big", false); !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+		t.Error("Expected Vary: Accept-Encoding on compressible routes")
+	}
+}
+
+func TestGetEnvList(t *testing.T) {
+	t.Setenv("TEST_LIST", " .svg, .png ,,")
+	if got := getEnvList("TEST_LIST", nil); strings.Join(got, "|") != ".svg|.png" {
+		t.Errorf("getEnvList() = %q", got)
+	}
+	if got := getEnvList("TEST_LIST_UNSET", []string{"x"}); len(got) != 1 || got[0] != "x" {
+		t.Errorf("Expected the fallback, got %q", got)
+	}
+}
@@ -1,40 +1,132 @@
 package main
 
+import (
+	"time"
+
+	"github.com/mooship/vortludo/engine"
+)
+
 // Game configuration constants
 const (
-	MaxGuesses = 6
-	WordLength = 5
+	MaxGuesses = engine.MaxGuesses
+	WordLength = engine.WordLength
 )
 
 // Guess status constants
 const (
-	GuessStatusCorrect = "correct"
-	GuessStatusPresent = "present"
-	GuessStatusAbsent  = "absent"
+	GuessStatusCorrect = engine.StatusCorrect
+	GuessStatusPresent = engine.StatusPresent
+	GuessStatusAbsent  = engine.StatusAbsent
+)
+
+// Tile reveal animation timing, shared by full-page renders and HTMX swaps
+const (
+	TileRevealStagger = 100 * time.Millisecond
+	TileFlipDuration  = 600 * time.Millisecond
+)
+
+// Data file constants
+const (
+	DataDir           = "data"
+	WordsFile         = "data/words.json"
+	AcceptedWordsFile = "data/accepted_words.txt"
+	PacksDir          = "data/packs"
+	// AcceptedOverlaysDir holds the per-mode accepted-word overlays.
+	AcceptedOverlaysDir = "data/accepted"
 )
 
+// DefaultPackName is the name of the word pack loaded from WordsFile.
+const DefaultPackName = "classic"
+
 // Session configuration constants
 const (
 	SessionCookieName = "session_id"
+	CSRFCookieName    = "csrf_token"
+
+	// DefaultSessionTimeout is how long an idle session is kept, and the default cookie lifetime.
+	DefaultSessionTimeout = 2 * time.Hour
 )
 
 // Route constants
 const (
-	RouteHome      = "/"
-	RouteNewGame   = "/new-game"
-	RouteRetryWord = "/retry-word"
-	RouteGuess     = "/guess"
-	RouteGameState = "/game-state"
+	RouteHome           = "/"
+	RouteNewGame        = "/new-game"
+	RouteRetryWord      = "/retry-word"
+	RouteGuess          = "/guess"
+	RouteGameState      = "/game-state"
+	RouteAccepted       = "/accepted-words"
+	RouteNextDaily      = "/next-puzzle"
+	RouteDaily          = "/daily"
+	RouteSuggest        = "/suggest-word"
+	RouteFeedback       = "/feedback"
+	RouteBonus          = "/bonus"
+	RouteCoins          = "/coins"
+	RouteHintAPI        = "/api/v1/hint"
+	RouteGamesAPI       = "/api/v1/games"
+	RoutePreferencesAPI = "/api/v1/preferences"
+	RoutePlay           = "/play"
+	RouteDataInventory  = "/privacy/data"
+	RouteQR             = "/qr"
+	RouteValidate       = "/validate"
+	RouteManifest       = "/manifest.webmanifest"
+	RouteGlobalStats    = "/stats/global"
+	RouteStatsExport    = "/stats/export"
+	RouteStatsImport    = "/stats/import"
+	RouteStatsWidgets   = "/stats/widgets"
+	RouteSpectate       = "/spectate"
+	RouteRoom           = "/room"
+	RouteClassroom      = "/classroom"
+	RoutePrint          = "/print"
+	RouteCalendar       = "/calendar"
+	RouteTransfer       = "/transfer"
+	RouteReminders      = "/reminders"
+	RoutePush           = "/push"
+	RouteServiceWorker  = "/sw.js"
+	RouteCaptcha        = "/captcha"
+	RouteAdmin          = "/admin"
 )
 
 // Error code constants
 const (
-	ErrorCodeGameOver        = "game_over"
-	ErrorCodeInvalidLength   = "invalid_length"
-	ErrorCodeNoMoreGuesses   = "no_more_guesses"
-	ErrorCodeNotInWordList   = "not_in_word_list"
-	ErrorCodeWordNotAccepted = "word_not_accepted"
-	ErrorCodeDuplicateGuess  = "duplicate_guess"
+	ErrorCodeGameOver             = "game_over"
+	ErrorCodeInvalidLength        = "invalid_length"
+	ErrorCodeNoMoreGuesses        = "no_more_guesses"
+	ErrorCodeNotInWordList        = "not_in_word_list"
+	ErrorCodeWordNotAccepted      = "word_not_accepted"
+	ErrorCodeDuplicateGuess       = "duplicate_guess"
+	ErrorCodeGuessConflict        = "guess_conflict"
+	ErrorCodeInvalidRequest       = "invalid_request"
+	ErrorCodeUnknownPack          = "unknown_pack"
+	ErrorCodePayloadTooLarge      = "payload_too_large"
+	ErrorCodeUnsupportedMediaType = "unsupported_media_type"
+	ErrorCodeUnauthorized         = "unauthorized"
+	ErrorCodeAccessDenied         = "access_denied"
+	ErrorCodeCSRFMissing          = "csrf_missing"
+	ErrorCodeCSRFExpired          = "csrf_expired"
+	ErrorCodeCSRFMismatch         = "csrf_mismatch"
+	ErrorCodeRateLimited          = "rate_limited"
+	ErrorCodeTimeout              = "request_timeout"
+	ErrorCodeNotFound             = "not_found"
+	ErrorCodeNoActiveGame         = "no_active_game"
+	ErrorCodeHintsDisabled        = "hints_disabled"
+	ErrorCodeCoinsUnavailable     = "coins_unavailable"
+	ErrorCodeInsufficientCoins    = "insufficient_coins"
+	ErrorCodeNothingToBuy         = "nothing_to_buy"
+	ErrorCodeNoActiveSession      = "no_active_session"
+	ErrorCodeRoomNotFound         = "room_not_found"
+	ErrorCodeRoomFull             = "room_full"
+	ErrorCodeDuplicate            = "duplicate"
+	ErrorCodeQueueFull            = "queue_full"
+	ErrorCodeOverloaded           = "overloaded"
+	ErrorCodeInternal             = "internal_error"
+	ErrorCodeConfirmationRequired = "confirmation_required"
+)
+
+// CSRF failure reason constants
+const (
+	CSRFReasonMissing  = "missing"
+	CSRFReasonExpired  = "expired"
+	CSRFReasonMismatch = "mismatch"
 )
 
 // Context key constants
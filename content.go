@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"html"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultContentDir holds the Markdown files of the content pages.
+const DefaultContentDir = "content"
+
+// contentPageNames are the content pages, each served at /<name> from <name>.md.
+var contentPageNames = []string{"about", "rules", "privacy", "changelog"}
+
+// Markdown patterns
+var (
+	mdHeading    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule       = regexp.MustCompile(`^(\*\s*\*\s*\*|-\s*-\s*-|_\s*_\s*_)[\s*_-]*$`)
+	mdBullet     = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdNumbered   = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdQuote      = regexp.MustCompile(`^>\s?(.*)$`)
+	mdLink       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdStrong     = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdEmphasis   = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+	mdSlugStrip  = regexp.MustCompile(`[^a-z0-9]+`)
+	mdSafeScheme = regexp.MustCompile(`^(https?:|mailto:|/|#)`)
+)
+
+// contentPage is a rendered content page.
+type contentPage struct {
+	Title   string
+	HTML    template.HTML
+	modTime time.Time
+}
+
+// ContentPages renders the Markdown content pages and caches them until their file changes, so
+// pages like the FAQ or changelog can be edited without writing HTML or restarting.
+type ContentPages struct {
+	dir   string
+	mu    sync.Mutex
+	cache map[string]contentPage
+}
+
+// newContentPages returns the content pages read from dir.
+func newContentPages(dir string) *ContentPages {
+	return &ContentPages{dir: dir, cache: make(map[string]contentPage)}
+}
+
+// page returns the rendered page name, re-rendering it only when its file has changed.
+func (cp *ContentPages) page(name string) (contentPage, error) {
+	path := filepath.Join(cp.dir, name+".md")
+	info, err := os.Stat(path)
+	if err != nil {
+		return contentPage{}, err
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if page, ok := cp.cache[name]; ok && page.modTime.Equal(info.ModTime()) {
+		return page, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return contentPage{}, err
+	}
+	title, body := renderMarkdown(string(data))
+	page := contentPage{Title: title, HTML: body, modTime: info.ModTime()}
+	cp.cache[name] = page
+	return page, nil
+}
+
+// renderMarkdown renders the Markdown subset the content pages use: ATX headings, paragraphs,
+// bullet and numbered lists, block quotes, fenced code, horizontal rules, and inline code,
+// links, bold, and italics. Raw HTML is escaped rather than passed through. The title is the
+// text of the first level-one heading.
+func renderMarkdown(src string) (string, template.HTML) {
+	var b strings.Builder
+	var title string
+	var para, items []string
+	list := ""
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + mdInline(strings.Join(para, " ")) + "</p>\n")
+			para = nil
+		}
+		if len(items) > 0 {
+			b.WriteString("<" + list + ">\n")
+			for _, item := range items {
+				b.WriteString("<li>" + mdInline(item) + "</li>\n")
+			}
+			b.WriteString("</" + list + ">\n")
+			items, list = nil, ""
+		}
+	}
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+		case trimmed == "":
+			flush()
+		case mdHeading.MatchString(trimmed):
+			flush()
+			m := mdHeading.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(m[1])))
+			if title == "" && level == "1" {
+				title = m[2]
+			}
+			slug := strings.Trim(mdSlugStrip.ReplaceAllString(strings.ToLower(m[2]), "-"), "-")
+			b.WriteString("<h" + level + ` id="` + slug + `">` + mdInline(m[2]) + "</h" + level + ">\n")
+		case mdRule.MatchString(trimmed):
+			flush()
+			b.WriteString("<hr />\n")
+		case mdBullet.MatchString(line), mdNumbered.MatchString(line):
+			kind, m := "ul", mdBullet.FindStringSubmatch(line)
+			if m == nil {
+				kind, m = "ol", mdNumbered.FindStringSubmatch(line)
+			}
+			if len(para) > 0 || (list != "" && list != kind) {
+				flush()
+			}
+			list = kind
+			items = append(items, m[1])
+		case mdQuote.MatchString(trimmed):
+			flush()
+			var quote []string
+			for ; i < len(lines) && mdQuote.MatchString(strings.TrimSpace(lines[i])); i++ {
+				quote = append(quote, mdQuote.FindStringSubmatch(strings.TrimSpace(lines[i]))[1])
+			}
+			i--
+			b.WriteString("<blockquote><p>" + mdInline(strings.Join(quote, " ")) + "</p></blockquote>\n")
+		case len(items) > 0 && (strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")):
+			// An indented line continues the list item above it.
+			items[len(items)-1] += " " + trimmed
+		default:
+			if len(items) > 0 {
+				flush()
+			}
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+	return mdPlain(title), template.HTML(b.String())
+}
+
+// mdInline renders inline Markdown in text, escaping everything else. Code spans are left
+// untouched, and links with other than web, mail, or relative URLs are shown as plain text.
+func mdInline(text string) string {
+	parts := strings.Split(text, "`")
+	for i, part := range parts {
+		part = html.EscapeString(part)
+		if i%2 == 1 && i < len(parts)-1 {
+			parts[i] = "<code>" + part + "</code>"
+			continue
+		}
+		if i%2 == 1 {
+			// An unmatched backtick is literal.
+			part = "`" + part
+		}
+		part = mdLink.ReplaceAllStringFunc(part, func(s string) string {
+			m := mdLink.FindStringSubmatch(s)
+			if !mdSafeScheme.MatchString(html.UnescapeString(m[2])) {
+				return m[1]
+			}
+			return `<a href="` + m[2] + `">` + m[1] + "</a>"
+		})
+		part = mdStrong.ReplaceAllString(part, "<strong>$1$2</strong>")
+		part = mdEmphasis.ReplaceAllString(part, "<em>$1$2</em>")
+		parts[i] = part
+	}
+	return strings.Join(parts, "")
+}
+
+// mdPlain strips inline Markdown from text for use as a page title.
+func mdPlain(text string) string {
+	text = mdLink.ReplaceAllString(text, "$1")
+	return strings.NewReplacer("`", "", "**", "", "__", "", "*", "").Replace(text)
+}
+
+// contentPageHandler serves the content page name through the page layout. The privacy page
+// is followed by the data inventory.
+func (app *App) contentPageHandler(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := app.Content.page(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "page not found")
+			return
+		}
+		if err != nil {
+			logWarn("Failed to render content page %s: %v", name, err)
+			writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not load page")
+			return
+		}
+		title := "Vortludo"
+		if page.Title != "" {
+			title = "Vortludo - " + page.Title
+		}
+		data := gin.H{
+			"title":   title,
+			"content": page.HTML,
+			"page":    name,
+			"pages":   contentPageNames,
+		}
+		if name == "privacy" {
+			// The inventory follows the configuration, so the page cannot be cached by its file alone.
+			data["inventory"] = app.dataInventory()
+		} else {
+			c.Header("Last-Modified", page.modTime.UTC().Format(http.TimeFormat))
+		}
+		c.HTML(http.StatusOK, "page.html", data)
+	}
+}
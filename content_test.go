@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	src := "# About `Vortludo`\n\nA **bold** and *quiet* [game](/play) with <script>x</script>.\nSecond line.\n\n" +
+		"- one\n- two\n  continued\n\n1. first\n2. second\n\n> quoted\n> text\n\n---\n\n```\n<b>code</b>\n```\n\n" +
+		"[bad](javascript:alert) and snake_case_name\n"
+	title, body := renderMarkdown(src)
+	if title != "About Vortludo" {
+		t.Errorf("title = %q", title)
+	}
+	for _, want := range []string{
+		`<h1 id="about-vortludo">About <code>Vortludo</code></h1>`,
+		`<p>A <strong>bold</strong> and <em>quiet</em> <a href="/play">game</a> with &lt;script&gt;x&lt;/script&gt;. Second line.</p>`,
+		"<ul>\n<li>one</li>\n<li>two continued</li>\n</ul>",
+		"<ol>\n<li>first</li>\n<li>second</li>\n</ol>",
+		"<blockquote><p>quoted text</p></blockquote>",
+		"<hr />",
+		"<pre><code>&lt;b&gt;code&lt;/b&gt;</code></pre>",
+		"<p>bad and snake_case_name</p>",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestContentPages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "about.md")
+	os.WriteFile(path, []byte("# About\n\nFirst version."), 0o600)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Content = newContentPages(dir)
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.GET("/about", app.contentPageHandler("about"))
+	router.GET("/rules", app.contentPageHandler("rules"))
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	w := get("/about")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<title>Vortludo - About</title>") || !strings.Contains(w.Body.String(), "First version.") {
+		t.Fatalf("about = %d:\n%s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `aria-current="page"`) {
+		t.Error("Expected the current page to be marked in the nav")
+	}
+
+	info, _ := os.Stat(path)
+	os.WriteFile(path, []byte("# About\n\nSecond version."), 0o600)
+	os.Chtimes(path, info.ModTime(), info.ModTime())
+	if body := get("/about").Body.String(); !strings.Contains(body, "First version.") {
+		t.Error("Expected the cached page while the file's modification time is unchanged")
+	}
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Hour))
+	if body := get("/about").Body.String(); !strings.Contains(body, "Second version.") {
+		t.Error("Expected an edited page to be rendered again")
+	}
+	if w := get("/rules"); w.Code != http.StatusNotFound {
+		t.Errorf("missing page = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Co-op room limits
+const (
+	RoomCodeLength    = 6
+	MaxRoomMembers    = 8
+	MaxRoomMemberName = 24
+)
+
+// roomCodeAlphabet omits letters and digits that are easy to confuse when read aloud.
+const roomCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var (
+	errRoomNotFound = errors.New("room not found")
+	errRoomFull     = errors.New("room is full")
+)
+
+// CoopRoom is a game shared by several sessions. Guesses from any member advance the same
+// board; mu serialises them so each guess is checked against the row its sender last saw.
+type CoopRoom struct {
+	mu        sync.Mutex
+	Code      string
+	Game      *GameState
+	Members   map[string]string
+	GuessedBy []string
+}
+
+// roomGuess is a guess on a shared board with the member who made it.
+type roomGuess struct {
+	Guess string `json:"guess"`
+	By    string `json:"by"`
+}
+
+// CoopRooms maps room codes to shared games and sessions to the room they joined.
+type CoopRooms struct {
+	mu        sync.RWMutex
+	rooms     map[string]*CoopRoom
+	bySession map[string]*CoopRoom
+}
+
+// newCoopRooms returns an empty room registry.
+func newCoopRooms() *CoopRooms {
+	return &CoopRooms{rooms: make(map[string]*CoopRoom), bySession: make(map[string]*CoopRoom)}
+}
+
+// newRoomCode returns a random room code from roomCodeAlphabet.
+func newRoomCode() (string, error) {
+	return randomCode(RoomCodeLength)
+}
+
+// randomCode returns n characters drawn uniformly from roomCodeAlphabet. Random bytes past the
+// largest multiple of the alphabet's length are discarded, so no character is favored.
+func randomCode(n int) (string, error) {
+	limit := 256 - 256%len(roomCodeAlphabet)
+	code := make([]byte, 0, n)
+	b := make([]byte, n)
+	for len(code) < n {
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		for _, v := range b {
+			if int(v) < limit && len(code) < n {
+				code = append(code, roomCodeAlphabet[int(v)%len(roomCodeAlphabet)])
+			}
+		}
+	}
+	return string(code), nil
+}
+
+// normalizeRoomCode uppercases and trims a room code typed by a player.
+func normalizeRoomCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// memberName trims a display name, falling back to a numbered player name.
+func memberName(name string, n int) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "Player " + strconv.Itoa(n)
+	}
+	if len(name) > MaxRoomMemberName {
+		name = name[:MaxRoomMemberName]
+	}
+	return name
+}
+
+// create opens a room around game with sessionID as its first member.
+func (cr *CoopRooms) create(sessionID, name string, game *GameState) (*CoopRoom, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	var code string
+	for {
+		var err error
+		if code, err = newRoomCode(); err != nil {
+			return nil, err
+		}
+		if _, taken := cr.rooms[code]; !taken {
+			break
+		}
+	}
+	room := &CoopRoom{
+		Code:      code,
+		Game:      game,
+		Members:   map[string]string{sessionID: memberName(name, 1)},
+		GuessedBy: []string{},
+	}
+	cr.rooms[code] = room
+	cr.bySession[sessionID] = room
+	return room, nil
+}
+
+// join adds sessionID to the room with code, or renames it if it is already a member.
+func (cr *CoopRooms) join(code, sessionID, name string) (*CoopRoom, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	room, ok := cr.rooms[normalizeRoomCode(code)]
+	if !ok {
+		return nil, errRoomNotFound
+	}
+	room.mu.Lock()
+	if _, member := room.Members[sessionID]; !member && len(room.Members) >= MaxRoomMembers {
+		room.mu.Unlock()
+		return nil, errRoomFull
+	}
+	room.Members[sessionID] = memberName(name, len(room.Members)+1)
+	room.mu.Unlock()
+	if prev, ok := cr.bySession[sessionID]; ok && prev != room {
+		prev.mu.Lock()
+		cr.removeMember(prev, sessionID)
+		prev.mu.Unlock()
+	}
+	cr.bySession[sessionID] = room
+	return room, nil
+}
+
+// leave removes sessionID from its room, closing the room once it is empty.
+func (cr *CoopRooms) leave(sessionID string) bool {
+	if cr == nil {
+		return false
+	}
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	room, ok := cr.bySession[sessionID]
+	if !ok {
+		return false
+	}
+	room.mu.Lock()
+	cr.removeMember(room, sessionID)
+	room.mu.Unlock()
+	delete(cr.bySession, sessionID)
+	return true
+}
+
+// removeMember drops a member and closes the room when nobody is left. Callers must hold
+// cr.mu and room.mu.
+func (cr *CoopRooms) removeMember(room *CoopRoom, sessionID string) {
+	delete(room.Members, sessionID)
+	if len(room.Members) == 0 {
+		delete(cr.rooms, room.Code)
+	}
+}
+
+// members returns every session in a room with the shared game of its room.
+func (cr *CoopRooms) members() map[string]*GameState {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	members := make(map[string]*GameState, len(cr.bySession))
+	for sessionID, room := range cr.bySession {
+		members[sessionID] = room.Game
+	}
+	return members
+}
+
+// prune removes the given sessions from their rooms if they are still in a room playing game,
+// closing rooms left empty, and returns how many rooms were closed.
+func (cr *CoopRooms) prune(stale map[string]*GameState) int {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	closed := 0
+	for sessionID, game := range stale {
+		room, ok := cr.bySession[sessionID]
+		if !ok || room.Game != game {
+			continue
+		}
+		room.mu.Lock()
+		cr.removeMember(room, sessionID)
+		if len(room.Members) == 0 {
+			closed++
+		}
+		room.mu.Unlock()
+		delete(cr.bySession, sessionID)
+	}
+	return closed
+}
+
+// pruneRooms drops room members whose session has expired or moved on to another game, so
+// rooms everyone abandoned are closed. It returns how many rooms were closed.
+func (app *App) pruneRooms() int {
+	if app.Rooms == nil {
+		return 0
+	}
+	members := app.Rooms.members()
+	app.SessionMutex.RLock()
+	for sessionID, game := range members {
+		if app.GameSessions[sessionID] == game {
+			delete(members, sessionID)
+		}
+	}
+	app.SessionMutex.RUnlock()
+	return app.Rooms.prune(members)
+}
+
+// room returns the room sessionID joined.
+func (cr *CoopRooms) room(sessionID string) (*CoopRoom, bool) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	room, ok := cr.bySession[sessionID]
+	return room, ok
+}
+
+// guesses returns the room's guesses with the member who made each one. Callers must hold room.mu.
+func (room *CoopRoom) guesses() []roomGuess {
+	guesses := make([]roomGuess, len(room.Game.GuessHistory))
+	for i, guess := range room.Game.GuessHistory {
+		guesses[i].Guess = guess
+		if i < len(room.GuessedBy) {
+			guesses[i].By = room.GuessedBy[i]
+		}
+	}
+	return guesses
+}
+
+// coopRoom returns the room whose shared board is game. Starting a new game replaces the
+// session's game, so a member who does so is no longer playing in the room.
+func (app *App) coopRoom(sessionID string, game *GameState) *CoopRoom {
+	if app.Rooms == nil {
+		return nil
+	}
+	room, ok := app.Rooms.room(sessionID)
+	if !ok || room.Game != game {
+		return nil
+	}
+	return room
+}
+
+// checkRoomRow implements the optimistic lock on a shared board: the client sends the row it
+// was about to fill, and the guess is rejected if a teammate filled that row first.
+func checkRoomRow(c *gin.Context, game *GameState) error {
+	row, err := strconv.Atoi(c.PostForm("row"))
+	if err != nil || row != game.CurrentRow {
+		return errors.New(ErrorCodeGuessConflict)
+	}
+	return nil
+}
+
+// roomGuessHandler processes a guess on a shared board while holding the room lock, and
+// attributes it to the member who made it.
+func (app *App) roomGuessHandler(c *gin.Context, sessionID string, room *CoopRoom, guess, hint string) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	game := room.Game
+	if err := app.validateGameState(c, game); err != nil {
+		app.renderGameError(c, game, hint, err.Error())
+		return
+	}
+	if err := checkRoomRow(c, game); err != nil {
+		app.renderGameError(c, game, hint, err.Error())
+		return
+	}
+	if slices.Contains(game.GuessHistory, guess) {
+		app.renderGameError(c, game, hint, ErrorCodeDuplicateGuess)
+		return
+	}
+	before := len(game.GuessHistory)
+	if err := app.processGuess(c.Request.Context(), c, sessionID, game, guess, hint); err != nil {
+		app.renderGameError(c, game, hint, err.Error())
+		return
+	}
+	if len(game.GuessHistory) > before {
+		room.GuessedBy = append(room.GuessedBy, room.Members[sessionID])
+	}
+}
+
+// createRoomHandler starts a fresh game shared under a new room code.
+func (app *App) createRoomHandler(c *gin.Context) {
+	sessionID := app.getOrCreateSession(c)
+	game := app.createNewGame(c.Request.Context(), sessionID)
+	app.Rooms.leave(sessionID)
+	room, err := app.Rooms.create(sessionID, c.PostForm("name"), game)
+	if err != nil {
+		logWarn("Failed to create co-op room: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not create room")
+		return
+	}
+	logInfo("Session %s created co-op room", redactSession(sessionID))
+	c.JSON(http.StatusOK, gin.H{"code": room.Code})
+}
+
+// joinRoomHandler switches the session's game to the shared board of the room in the code field.
+func (app *App) joinRoomHandler(c *gin.Context) {
+	sessionID := app.getOrCreateSession(c)
+	room, err := app.Rooms.join(c.PostForm("code"), sessionID, c.PostForm("name"))
+	if err != nil {
+		if errors.Is(err, errRoomFull) {
+			writeProblem(c, http.StatusConflict, ErrorCodeRoomFull, err.Error())
+			return
+		}
+		writeProblem(c, http.StatusNotFound, ErrorCodeRoomNotFound, err.Error())
+		return
+	}
+	app.saveGameState(sessionID, room.Game)
+	logInfo("Session %s joined co-op room", redactSession(sessionID))
+	c.JSON(http.StatusOK, gin.H{"code": room.Code})
+}
+
+// leaveRoomHandler removes the session from its room and gives it a game of its own.
+func (app *App) leaveRoomHandler(c *gin.Context) {
+	sessionID, _ := c.Cookie(app.cookieName(SessionCookieName))
+	left := app.Rooms.leave(sessionID)
+	if left {
+		app.createNewGame(c.Request.Context(), sessionID)
+	}
+	c.JSON(http.StatusOK, gin.H{"left": left})
+}
+
+// roomStateHandler reports the shared board's row, members, and guess attribution so members
+// can tell when a teammate has moved the game on.
+func (app *App) roomStateHandler(c *gin.Context) {
+	sessionID, _ := c.Cookie(app.cookieName(SessionCookieName))
+	room, ok := app.Rooms.room(sessionID)
+	if !ok {
+		writeProblem(c, http.StatusNotFound, ErrorCodeRoomNotFound, errRoomNotFound.Error())
+		return
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	members := slices.Sorted(maps.Values(room.Members))
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"code":      room.Code,
+		"row":       room.Game.CurrentRow,
+		"game_over": room.Game.GameOver,
+		"members":   members,
+		"guesses":   room.guesses(),
+	})
+}
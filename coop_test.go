@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCoopRooms(t *testing.T) {
+	cr := newCoopRooms()
+	room, err := cr.create("host-session", "", newGame("CRANE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(room.Code) != RoomCodeLength || room.Members["host-session"] != "Player 1" {
+		t.Fatalf("Unexpected room %q with members %v", room.Code, room.Members)
+	}
+	if _, err := cr.join("nope", "guest-session", "Ana"); err != errRoomNotFound {
+		t.Errorf("join(unknown) = %v, want errRoomNotFound", err)
+	}
+	if joined, err := cr.join(" "+strings.ToLower(room.Code)+" ", "guest-session", "Ana"); err != nil || joined != room {
+		t.Fatalf("join() = %v, %v", joined, err)
+	}
+	for i := len(room.Members); i < MaxRoomMembers; i++ {
+		if _, err := cr.join(room.Code, "extra"+strconv.Itoa(i), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cr.join(room.Code, "late", ""); err != errRoomFull {
+		t.Errorf("join(full) = %v, want errRoomFull", err)
+	}
+
+	for id := range room.Members {
+		cr.leave(id)
+	}
+	if _, err := cr.join(room.Code, "late", ""); err != errRoomNotFound {
+		t.Errorf("Expected the room to close once empty, got %v", err)
+	}
+}
+
+func TestRoomGuessConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}, {Word: "TRACE"}})
+	app.Rooms = newCoopRooms()
+	game := app.createNewGame(dummyContext(), "host-session")
+	game.SessionWord = "CRANE"
+	room, err := app.Rooms.create("host-session", "Host", game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Rooms.join(room.Code, "guest-session", "Guest"); err != nil {
+		t.Fatal(err)
+	}
+	app.saveGameState("guest-session", game)
+
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.POST(RouteGuess, app.guessHandler)
+	guess := func(sessionID, word string, row int) *httptest.ResponseRecorder {
+		form := url.Values{"guess": {word}, "row": {strconv.Itoa(row)}}
+		req := httptest.NewRequest("POST", RouteGuess, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: sessionID})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	guess("guest-session", "SLATE", 0)
+	w := guess("host-session", "TRACE", 0)
+	if !strings.Contains(w.Header().Get("HX-Trigger"), ErrorCodeGuessConflict) {
+		t.Errorf("Expected a stale row to conflict, got trigger %q", w.Header().Get("HX-Trigger"))
+	}
+	guess("host-session", "TRACE", 1)
+
+	want := []roomGuess{{Guess: "SLATE", By: "Guest"}, {Guess: "TRACE", By: "Host"}}
+	got := room.guesses()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("guesses() = %v, want %v", got, want)
+	}
+}
+
+func TestPruneRooms(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Rooms = newCoopRooms()
+	shared := newGame("CRANE")
+	room, err := app.Rooms.create("host-session", "Host", shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Rooms.join(room.Code, "guest-session", "Guest"); err != nil {
+		t.Fatal(err)
+	}
+	app.saveGameState("host-session", shared)
+	app.saveGameState("guest-session", shared)
+
+	if n := app.pruneRooms(); n != 0 || len(room.Members) != 2 {
+		t.Fatalf("pruneRooms() = %d with members %v, want the active room kept", n, room.Members)
+	}
+	app.saveGameState("guest-session", newGame("SLATE"))
+	delete(app.GameSessions, "host-session")
+	if n := app.pruneRooms(); n != 1 {
+		t.Errorf("pruneRooms() = %d, want the abandoned room closed", n)
+	}
+	if _, ok := app.Rooms.room("guest-session"); ok {
+		t.Error("Expected a member who started another game to leave the room")
+	}
+	if _, err := app.Rooms.join(room.Code, "late-session", ""); err != errRoomNotFound {
+		t.Errorf("join(closed room) = %v, want errRoomNotFound", err)
+	}
+}
+
+func TestRandomCode(t *testing.T) {
+	for range 100 {
+		code, err := randomCode(TransferCodeLength)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != TransferCodeLength || strings.Trim(code, roomCodeAlphabet) != "" {
+			t.Fatalf("randomCode() = %q, want %d characters from the room code alphabet", code, TransferCodeLength)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+// maxGameSeedLength caps the seed accepted by the custom game API.
+const maxGameSeedLength = 128
+
+// Custom game errors
+var (
+	errGameSeedAndWord = errors.New("send either seed or word, not both")
+	errGameSeedLength  = errors.New("seed must be at most 128 characters")
+	errGameWord        = errors.New("word must be an accepted 5-letter word")
+	errGamePack        = errors.New("unknown pack")
+	errGameToken       = errors.New("invalid game token")
+)
+
+// customGameRequest is the JSON body accepted by the custom game API. Send a seed for a
+// reproducible word from the pack, a word to play that exact word, or neither for a random seed.
+type customGameRequest struct {
+	Seed string `json:"seed"`
+	Word string `json:"word"`
+	Pack string `json:"pack"`
+}
+
+// gameTokenSaltLength is the length of the random salt sealed into each game token.
+const gameTokenSaltLength = 8
+
+// GameTokens seals a custom game's pack and target word into an opaque URL token, so links
+// survive restarts without server state and the word cannot be read from the link. Each token
+// seals a random salt with the game, so the same game yields a different token every time and
+// the game API, which mints a token for any word, cannot be used to look up a link's word by
+// comparing tokens. Encryption and the nonce use separate subkeys. A token starts with the ID
+// of the key that sealed it, so links keep working after the key is rotated.
+type GameTokens struct {
+	keys   *Keyring
+	nonces *Keyring
+	aeads  map[string]cipher.AEAD
+	// legacy opens tokens sealed, without a salt, under the keys themselves before the subkeys.
+	legacy map[string]cipher.AEAD
+}
+
+// newGameTokens returns a sealer keyed by keys of 16, 24, or 32 bytes.
+func newGameTokens(keys *Keyring) *GameTokens {
+	return &GameTokens{
+		keys:   keys,
+		nonces: keys.derive("game-token-nonce"),
+		aeads:  newGameTokenAEADs(keys.derive("game-token-enc")),
+		legacy: newGameTokenAEADs(keys),
+	}
+}
+
+// newGameTokenAEADs returns an AES-GCM cipher for each key, by key ID.
+func newGameTokenAEADs(keys *Keyring) map[string]cipher.AEAD {
+	aeads := make(map[string]cipher.AEAD)
+	for _, k := range keys.keys {
+		block, err := aes.NewCipher(k.secret)
+		if err != nil {
+			logFatal("Failed to create game token cipher: %v", err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			logFatal("Failed to create game token cipher: %v", err)
+		}
+		aeads[k.id] = aead
+	}
+	return aeads
+}
+
+// seal returns a new token for a game of word from pack.
+func (gt *GameTokens) seal(pack, word string) string {
+	key := gt.keys.current()
+	aead := gt.aeads[key.id]
+	plaintext := make([]byte, gameTokenSaltLength, gameTokenSaltLength+len(pack)+1+len(word))
+	if _, err := rand.Read(plaintext); err != nil {
+		logFatal("Failed to generate game token salt: %v", err)
+	}
+	plaintext = append(plaintext, pack+"\x00"+word...)
+	h := hmac.New(sha256.New, gt.nonces.current().secret)
+	h.Write(plaintext)
+	nonce := h.Sum(nil)[:aead.NonceSize()]
+	sealed := append([]byte(key.id), nonce...)
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(sealed, nonce, plaintext, nil))
+}
+
+// open returns the pack and word sealed in token. Tokens from before key IDs have none and are
+// tried with every key.
+func (gt *GameTokens) open(token string) (string, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", "", errGameToken
+	}
+	var plaintext []byte
+	unseal := func(key signingKey, sealed []byte) bool {
+		aead := gt.aeads[key.id]
+		if len(sealed) < aead.NonceSize() {
+			return false
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if plaintext, err = aead.Open(nil, nonce, ciphertext, nil); err == nil && len(plaintext) >= gameTokenSaltLength {
+			plaintext = plaintext[gameTokenSaltLength:]
+			return true
+		}
+		plaintext, err = gt.legacy[key.id].Open(nil, nonce, ciphertext, nil)
+		return err == nil
+	}
+	opened := len(raw) > keyIDLength && gt.keys.verify(string(raw[:keyIDLength]), func(key signingKey) bool {
+		return unseal(key, raw[keyIDLength:])
+	})
+	if !opened && !gt.keys.verify("", func(key signingKey) bool { return unseal(key, raw) }) {
+		return "", "", errGameToken
+	}
+	pack, word, ok := strings.Cut(string(plaintext), "\x00")
+	if !ok {
+		return "", "", errGameToken
+	}
+	return pack, word, nil
+}
+
+// seededWord picks the pack word for seed. The same seed gives the same word for as long as
+// the pack's word list is unchanged.
+func seededWord(pack *WordPack, seed string) (WordEntry, bool) {
+	if len(pack.Words) == 0 {
+		return WordEntry{}, false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	return pack.Words[h.Sum64()%uint64(len(pack.Words))], true
+}
+
+// newGameSeed returns a random seed for requests that send neither seed nor word.
+func newGameSeed() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		logFatal("Failed to generate game seed: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// resolveCustomGame validates a custom game request and returns the pack and target word.
+// It fills in a random seed when neither seed nor word was sent.
+func (app *App) resolveCustomGame(req *customGameRequest) (*WordPack, string, error) {
+	pack := app.wordPack(DefaultPackName)
+	if req.Pack != "" {
+		p, ok := app.lists().Packs[req.Pack]
+		if !ok {
+			return nil, "", errGamePack
+		}
+		pack = p
+	}
+
+	switch {
+	case req.Seed != "" && req.Word != "":
+		return nil, "", errGameSeedAndWord
+	case req.Word != "":
+		word := engine.NormalizeGuess(req.Word)
+		if len(word) != WordLength || !app.isAcceptedWord(ModeCustom, word) {
+			return nil, "", errGameWord
+		}
+		return pack, word, nil
+	case len(req.Seed) > maxGameSeedLength:
+		return nil, "", errGameSeedLength
+	case req.Seed == "":
+		req.Seed = newGameSeed()
+	}
+	entry, ok := seededWord(pack, req.Seed)
+	if !ok {
+		return nil, "", errGamePack
+	}
+	return pack, entry.Word, nil
+}
+
+// createGameAPIHandler generates a custom game and returns a token and play URL for it. Seeded
+// games echo the seed so callers can regenerate the same game later.
+func (app *App) createGameAPIHandler(c *gin.Context) {
+	var req customGameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, errAPIBody.Error())
+		return
+	}
+	pack, word, err := app.resolveCustomGame(&req)
+	switch {
+	case errors.Is(err, errGameWord):
+		writeProblem(c, http.StatusUnprocessableEntity, ErrorCodeWordNotAccepted, err.Error())
+		return
+	case errors.Is(err, errGamePack):
+		writeProblem(c, http.StatusUnprocessableEntity, ErrorCodeUnknownPack, err.Error())
+		return
+	case err != nil:
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	token := app.Games.seal(pack.Name, word)
+	body := gin.H{
+		"token": token,
+		"url":   RoutePlay + "/" + token,
+		"pack":  pack.Name,
+	}
+	if req.Word == "" {
+		body["seed"] = req.Seed
+	}
+	c.JSON(http.StatusCreated, body)
+}
+
+// playGameHandler starts the custom game in token for the current session and shows the board.
+// Custom games do not count towards pack progress.
+func (app *App) playGameHandler(c *gin.Context) {
+	packName, word, err := app.Games.open(c.Param("token"))
+	if err != nil || !app.isAcceptedWord(ModeCustom, word) {
+		c.String(http.StatusNotFound, "game not found")
+		return
+	}
+	sessionID := app.getOrCreateSession(c)
+	app.Rooms.leave(sessionID)
+	game := newGame(word)
+	game.Pack = app.wordPack(packName).Name
+	game.Custom = true
+	app.pinWords(game)
+	app.saveGameState(sessionID, game)
+	app.Journal.start(c.Request.Context(), sessionID, game)
+	logInfo("Started custom game for session %s with word: %s", redactSession(sessionID), redactWord(word))
+	app.trackEvent(c, EventGameStarted, map[string]string{"pack": game.Pack, "custom": "true"})
+	c.Redirect(http.StatusSeeOther, RouteHome)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGameTokens(t *testing.T) {
+	gt := newGameTokens(newKeyring(make([]byte, 32)))
+	token := gt.seal("classic", "CRANE")
+	if again := gt.seal("classic", "CRANE"); again == token {
+		t.Error("Expected each sealing of the same game to give a new token")
+	}
+	if strings.Contains(token, "CRANE") {
+		t.Error("Token should not reveal the word")
+	}
+	pack, word, err := gt.open(token)
+	if err != nil || pack != "classic" || word != "CRANE" {
+		t.Errorf("open() = %q, %q, %v", pack, word, err)
+	}
+	tampered := []byte(token)
+	tampered[0] ^= 1
+	if _, _, err := gt.open(string(tampered)); err != errGameToken {
+		t.Errorf("Expected tampered token to fail, got %v", err)
+	}
+	if _, _, err := newGameTokens(newKeyring(bytes.Repeat([]byte{1}, 32))).open(token); err != errGameToken {
+		t.Errorf("Expected a token from another key to fail, got %v", err)
+	}
+}
+
+func TestGameTokensOpenLegacyToken(t *testing.T) {
+	keys := newKeyring(make([]byte, 32))
+	key := keys.current()
+	aead := newGameTokenAEADs(keys)[key.id]
+	plaintext := []byte("classic\x00CRANE")
+	h := hmac.New(sha256.New, key.secret)
+	h.Write(plaintext)
+	nonce := h.Sum(nil)[:aead.NonceSize()]
+	legacy := base64.RawURLEncoding.EncodeToString(aead.Seal(append([]byte(key.id), nonce...), nonce, plaintext, nil))
+
+	pack, word, err := newGameTokens(keys).open(legacy)
+	if err != nil || pack != "classic" || word != "CRANE" {
+		t.Errorf("open() of a token sealed before subkeys = %q, %q, %v", pack, word, err)
+	}
+}
+
+func TestResolveCustomGame(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}, {Word: "TRACE"}})
+	_, first, err := app.resolveCustomGame(&customGameRequest{Seed: "class-7b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, again, _ := app.resolveCustomGame(&customGameRequest{Seed: "class-7b"}); again != first {
+		t.Errorf("Seeded word changed: %s then %s", first, again)
+	}
+
+	req := &customGameRequest{}
+	if _, _, err := app.resolveCustomGame(req); err != nil || req.Seed == "" {
+		t.Errorf("Expected a random seed to be filled in, got %q, %v", req.Seed, err)
+	}
+
+	tests := []struct {
+		req  customGameRequest
+		want error
+	}{
+		{customGameRequest{Word: " slate "}, nil},
+		{customGameRequest{Word: "ZZZZZ"}, errGameWord},
+		{customGameRequest{Word: "CRANES"}, errGameWord},
+		{customGameRequest{Seed: "a", Word: "CRANE"}, errGameSeedAndWord},
+		{customGameRequest{Seed: strings.Repeat("a", maxGameSeedLength+1)}, errGameSeedLength},
+		{customGameRequest{Seed: "a", Pack: "missing"}, errGamePack},
+	}
+	for _, tt := range tests {
+		if _, _, err := app.resolveCustomGame(&tt.req); err != tt.want {
+			t.Errorf("resolveCustomGame(%+v) = %v, want %v", tt.req, err, tt.want)
+		}
+	}
+}
+
+func TestCreateAndPlayCustomGame(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Games = newGameTokens(newKeyring(make([]byte, 32)))
+	router := gin.New()
+	router.POST(RouteGamesAPI, app.createGameAPIHandler)
+	router.GET(RoutePlay+"/:token", app.playGameHandler)
+
+	req := httptest.NewRequest("POST", RouteGamesAPI, strings.NewReader(`{"word":"slate"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var body struct {
+		URL  string `json:"url"`
+		Seed string `json:"seed"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusCreated || !strings.HasPrefix(body.URL, RoutePlay+"/") || body.Seed != "" {
+		t.Fatalf("Got %d %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", body.URL, nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "custom-session"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	game := app.GameSessions["custom-session"]
+	if w.Code != http.StatusSeeOther || game == nil || game.SessionWord != "SLATE" || !game.Custom {
+		t.Errorf("Expected a custom SLATE game, got %d %+v", w.Code, game)
+	}
+
+	req = httptest.NewRequest("GET", RoutePlay+"/bogus", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a bogus token, got %d", w.Code)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/internal/htmx"
+)
+
+// Daily puzzle constants
+const (
+	// TimezoneCookieName is the cookie in which clients report their IANA timezone.
+	TimezoneCookieName = "tz"
+	// ModeDaily is the new-game mode that plays the day's puzzle, the same word for everyone.
+	ModeDaily = "daily"
+)
+
+// DailySchedule decides when the daily puzzle rolls over. Rollover happens at Hour:00 local
+// time in Location, or in the player's own timezone when UserTimezones is enabled. Admins
+// can force an early rollover, which advances the puzzle date by one day until the next
+// natural rollover catches up.
+type DailySchedule struct {
+	Location      *time.Location
+	Hour          int
+	UserTimezones bool
+
+	mu     sync.RWMutex
+	offset int
+}
+
+// newDailySchedule returns a schedule for the given timezone name and rollover hour (0-23).
+func newDailySchedule(tz string, hour int, userTimezones bool) *DailySchedule {
+	if hour < 0 || hour > 23 {
+		logWarn("Invalid DAILY_ROLLOVER_HOUR %d, using 0", hour)
+		hour = 0
+	}
+	return &DailySchedule{
+		Location:      loadDailyLocation(tz),
+		Hour:          hour,
+		UserTimezones: userTimezones,
+	}
+}
+
+// loadDailyLocation resolves the daily rollover timezone, falling back to UTC if it is invalid.
+func loadDailyLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logWarn("Invalid DAILY_TIMEZONE %q, using UTC: %v", name, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// location returns the timezone to use for a request, honoring a valid tz cookie when enabled.
+func (d *DailySchedule) location(c *gin.Context) *time.Location {
+	if d.UserTimezones && c != nil {
+		if name, err := c.Cookie(TimezoneCookieName); err == nil && name != "" {
+			if loc, err := time.LoadLocation(name); err == nil {
+				return loc
+			}
+		}
+	}
+	return d.Location
+}
+
+// rolloverAt returns the rollover instant on the calendar day of t in loc. If the rollover hour
+// falls in a DST gap, the first valid instant after the gap is used instead.
+func (d *DailySchedule) rolloverAt(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	at := time.Date(local.Year(), local.Month(), local.Day(), d.Hour, 0, 0, 0, loc)
+	// time.Date may resolve a skipped local time to before the gap; step forward past it.
+	for i := 0; i < 16; i++ {
+		l := at.In(loc)
+		if l.Day() == local.Day() && l.Hour() >= d.Hour {
+			break
+		}
+		at = at.Add(15 * time.Minute)
+	}
+	return at
+}
+
+// nextRollover returns the first rollover strictly after now in loc.
+func (d *DailySchedule) nextRollover(now time.Time, loc *time.Location) time.Time {
+	next := d.rolloverAt(now, loc)
+	for !next.After(now) {
+		local := next.In(loc)
+		next = d.rolloverAt(time.Date(local.Year(), local.Month(), local.Day()+1, 12, 0, 0, 0, loc), loc)
+	}
+	return next
+}
+
+// puzzleDate returns the calendar date (as midnight UTC) of the puzzle active at now in loc,
+// including any forced rollovers.
+func (d *DailySchedule) puzzleDate(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	if now.Before(d.rolloverAt(now, loc)) {
+		day = day.AddDate(0, 0, -1)
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return day.AddDate(0, 0, d.offset)
+}
+
+// forceRoll advances the active puzzle by one day and returns the new puzzle date.
+func (d *DailySchedule) forceRoll(now time.Time) time.Time {
+	d.mu.Lock()
+	d.offset++
+	d.mu.Unlock()
+	return d.puzzleDate(now, d.Location)
+}
+
+// formatCountdown formats a duration as HH:MM:SS, clamping negative values to zero.
+func formatCountdown(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	total := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total/60%60, total%60)
+}
+
+// nextPuzzleHandler reports the time remaining until the next daily rollover, as an HTML
+// fragment for HTMX requests and as JSON otherwise.
+func (app *App) nextPuzzleHandler(c *gin.Context) {
+	now := app.now()
+	loc := app.Daily.location(c)
+	next := app.Daily.nextRollover(now, loc)
+	remaining := next.Sub(now).Truncate(time.Second)
+
+	if htmx.IsRequest(c.Request) {
+		c.HTML(http.StatusOK, "next-puzzle", gin.H{
+			"seconds":   int(remaining.Seconds()),
+			"countdown": formatCountdown(remaining),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"next_rollover":     next.Format(time.RFC3339),
+		"seconds_remaining": int(remaining.Seconds()),
+		"countdown":         formatCountdown(remaining),
+		"timezone":          loc.String(),
+		"puzzle_date":       app.Daily.puzzleDate(now, loc).Format(time.DateOnly),
+	})
+}
+
+// adminDailyRollHandler forces the daily puzzle to roll over immediately.
+func (app *App) adminDailyRollHandler(c *gin.Context) {
+	before := app.Daily.puzzleDate(app.now(), app.Daily.Location)
+	date := app.Daily.forceRoll(app.now())
+	logInfo("Admin forced daily rollover to %s", date.Format(time.DateOnly))
+	auditChange(c, "", before.Format(time.DateOnly), date.Format(time.DateOnly))
+	app.broadcast(c.Request.Context(), FleetEvent{Type: FleetDailyRoll})
+	c.JSON(http.StatusOK, gin.H{"puzzle_date": date.Format(time.DateOnly)})
+}
+
+// requestedDaily reports whether a new-game request asked for the daily puzzle.
+func requestedDaily(c *gin.Context) bool {
+	return c.PostForm("mode") == ModeDaily || c.Query("mode") == ModeDaily
+}
+
+// todaysPuzzle returns the date of the daily puzzle active for the request.
+func (app *App) todaysPuzzle(c *gin.Context) time.Time {
+	return app.Daily.puzzleDate(app.now(), app.Daily.location(c))
+}
+
+// requestedPuzzle returns the puzzle date a daily request names with ?date=, as links in
+// reminders and notifications do so they open the puzzle they describe, or today's puzzle
+// for the request. Dates not yet released, here or on the server's schedule, are ignored so
+// a link cannot reveal a future word.
+func (app *App) requestedPuzzle(c *gin.Context) time.Time {
+	today := app.todaysPuzzle(c)
+	date, err := parsePuzzleDate(c.Query("date"))
+	if err != nil {
+		return today
+	}
+	if date.After(today) && date.After(app.Daily.puzzleDate(app.now(), app.Daily.Location)) {
+		return today
+	}
+	return date
+}
+
+// dailyLink returns the path that opens the daily puzzle of date.
+func dailyLink(date string) string {
+	return RouteDaily + "?date=" + date
+}
+
+// createDailyGame starts the daily puzzle of date for a session and stores it, reporting false
+// when there is no word to play. The word comes from the whole word list, never narrowed by
+// the player's completed words, so every player gets the same puzzle.
+func (app *App) createDailyGame(ctx context.Context, sessionID string, date time.Time) (*GameState, bool) {
+	entry, ok := app.dailyWord(date)
+	if !ok {
+		return nil, false
+	}
+	logInfo("Daily game for %s created for session %s", date.Format(time.DateOnly), redactSession(sessionID))
+	game := newGame(entry.Word)
+	game.Pack = DefaultPackName
+	game.Daily = date.Format(time.DateOnly)
+	app.pinWords(game)
+	app.saveGameState(sessionID, game)
+	app.Journal.start(ctx, sessionID, game)
+	app.recordExperiments(sessionID, "started")
+	return game, true
+}
+
+// dailyHandler starts the day's puzzle, or the released one named by ?date=, for the session,
+// keeping it if it is already being played, and shows the game. Reminders, notifications, and calendar events link here.
+func (app *App) dailyHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := app.getOrCreateSession(c)
+	date := app.requestedPuzzle(c)
+	if game := app.getGameState(ctx, sessionID); game.Daily != date.Format(time.DateOnly) {
+		app.Rooms.leave(sessionID)
+		if _, ok := app.createDailyGame(ctx, sessionID, date); !ok {
+			writeProblem(c, http.StatusServiceUnavailable, ErrorCodeInternal, "no daily puzzle available")
+			return
+		}
+		app.trackEvent(c, EventGameStarted, map[string]string{"pack": DefaultPackName})
+	}
+	c.Redirect(http.StatusSeeOther, RouteHome)
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNextRollover(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &DailySchedule{Location: loc}
+	now := time.Date(2024, 3, 9, 23, 30, 0, 0, loc)
+	if got, want := d.nextRollover(now, loc), time.Date(2024, 3, 10, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("nextRollover() = %v, want %v", got, want)
+	}
+
+	midnight := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := d.nextRollover(midnight, time.UTC); !got.Equal(midnight.AddDate(0, 0, 1)) {
+		t.Errorf("nextRollover(midnight) = %v, want next day", got)
+	}
+}
+
+func TestNextRolloverDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &DailySchedule{Location: loc, Hour: 2}
+
+	// 02:00 does not exist on 2024-03-10; rollover moves forward to 03:00 EDT.
+	now := time.Date(2024, 3, 10, 1, 30, 0, 0, loc)
+	got := d.nextRollover(now, loc)
+	if got.Sub(now) != 30*time.Minute {
+		t.Errorf("spring-forward rollover = %v, want 30m after %v", got, now)
+	}
+
+	// The next rollover returns to 02:00 local, 23 hours later.
+	after := d.nextRollover(got, loc)
+	if after.Sub(got) != 23*time.Hour || after.In(loc).Hour() != 2 {
+		t.Errorf("rollover after spring-forward = %v (%v later), want 02:00 local 23h later", after, after.Sub(got))
+	}
+
+	// 01:00 occurs twice on 2024-11-03; a 1:00 rollover fires once that day.
+	d.Hour = 1
+	now = time.Date(2024, 11, 3, 0, 30, 0, 0, loc)
+	first := d.nextRollover(now, loc)
+	if second := d.nextRollover(first, loc); second.In(loc).Day() != 4 {
+		t.Errorf("fall-back rollover fired twice: %v then %v", first, second)
+	}
+}
+
+func TestPuzzleDate(t *testing.T) {
+	d := &DailySchedule{Location: time.UTC, Hour: 6}
+	before := time.Date(2024, 5, 1, 5, 59, 0, 0, time.UTC)
+	after := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	if got := d.puzzleDate(before, time.UTC).Format(time.DateOnly); got != "2024-04-30" {
+		t.Errorf("puzzleDate(before) = %s, want 2024-04-30", got)
+	}
+	if got := d.puzzleDate(after, time.UTC).Format(time.DateOnly); got != "2024-05-01" {
+		t.Errorf("puzzleDate(after) = %s, want 2024-05-01", got)
+	}
+	if got := d.forceRoll(after).Format(time.DateOnly); got != "2024-05-02" {
+		t.Errorf("forceRoll() = %s, want 2024-05-02", got)
+	}
+}
+
+func TestDailyLocationCookie(t *testing.T) {
+	d := newDailySchedule("UTC", 0, true)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: TimezoneCookieName, Value: "Europe/Paris"})
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	if loc := d.location(c); loc.String() != "Europe/Paris" {
+		t.Errorf("location() = %s, want Europe/Paris", loc)
+	}
+
+	d.UserTimezones = false
+	if loc := d.location(c); loc != time.UTC {
+		t.Errorf("location() with user timezones disabled = %s, want UTC", loc)
+	}
+}
+
+func TestFormatCountdown(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "00:00:00"},
+		{-time.Minute, "00:00:00"},
+		{90 * time.Second, "00:01:30"},
+		{23*time.Hour + 59*time.Minute + 59*time.Second, "23:59:59"},
+	}
+	for _, tt := range tests {
+		if got := formatCountdown(tt.d); got != tt.want {
+			t.Errorf("formatCountdown(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestLoadDailyLocationFallback(t *testing.T) {
+	if loc := loadDailyLocation("Not/AZone"); loc != time.UTC {
+		t.Errorf("loadDailyLocation(invalid) = %v, want UTC", loc)
+	}
+	if loc := loadDailyLocation(""); loc != time.UTC {
+		t.Errorf("loadDailyLocation(\"\") = %v, want UTC", loc)
+	}
+}
+
+// dailyTestApp returns an app with a daily schedule, a puzzle calendar, and a journal.
+func dailyTestApp(t *testing.T) *App {
+	gin.SetMode(gin.TestMode)
+	app := &App{
+		GameSessions: make(map[string]*GameState),
+		Daily:        newDailySchedule("UTC", 0, false),
+		Calendar:     newPuzzleCalendar(""),
+		Clock:        newFakeClock(),
+		Journal:      newSessionJournal(t.TempDir()),
+	}
+	app.registerWordPacks([]*WordPack{
+		newWordPack(DefaultPackName, []WordEntry{{Word: "CRANE", Hint: "a bird"}, {Word: "SLATE", Hint: "a rock"}, {Word: "TRACE", Hint: "a mark"}}),
+	}, nil, nil)
+	return app
+}
+
+// dailyNewGame posts a daily-mode new-game request for sessionID.
+func dailyNewGame(router *gin.Engine, sessionID string) {
+	req := httptest.NewRequest("POST", RouteNewGame, strings.NewReader(url.Values{"mode": {ModeDaily}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: sessionID})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestDailyGameIsTheSameForEveryone(t *testing.T) {
+	app := dailyTestApp(t)
+	router := gin.New()
+	router.POST(RouteNewGame, app.newGameHandler)
+	router.GET(RouteDaily, app.dailyHandler)
+	today := app.Daily.puzzleDate(app.now(), time.UTC)
+	want, _ := app.dailyWord(today)
+
+	dailyNewGame(router, "session-aaa")
+	req := httptest.NewRequest("GET", RouteDaily, nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session-bbb"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("GET %s = %d, want a redirect to the game", RouteDaily, w.Code)
+	}
+	for _, id := range []string{"session-aaa", "session-bbb"} {
+		game := app.GameSessions[id]
+		if game == nil || game.SessionWord != want.Word || game.Daily != today.Format(time.DateOnly) {
+			t.Fatalf("%s game = %+v, want the daily word %s", id, game, want.Word)
+		}
+	}
+
+	b := app.GameSessions["session-bbb"]
+	b.ApplyGuess("SLATE", b.SessionWord, checkGuess("SLATE", b.SessionWord), true)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if app.GameSessions["session-bbb"] != b {
+		t.Error("revisiting the daily link restarted the puzzle in progress")
+	}
+
+	delete(app.GameSessions, "session-aaa")
+	if restored := app.getGameState(dummyContext(), "session-aaa"); restored.Daily != today.Format(time.DateOnly) {
+		t.Errorf("restored game = %+v, want the daily date back from the journal", restored)
+	}
+
+	if err := app.Calendar.pin(today, "TRACE"); err != nil {
+		t.Fatal(err)
+	}
+	dailyNewGame(router, "session-aaa")
+	if got := app.GameSessions["session-aaa"].SessionWord; got != "TRACE" {
+		t.Errorf("daily game after pinning = %s, want the pinned TRACE", got)
+	}
+}
+
+func TestDailySelectionIgnoresCompletedWords(t *testing.T) {
+	app := dailyTestApp(t)
+	pack := app.wordPack(DefaultPackName)
+	req := selectionRequest{Mode: SelectionModeDaily, Date: time.Date(2030, 4, 1, 0, 0, 0, 0, time.UTC)}
+	want, _ := app.selectWordEntry(dummyContext(), pack, nil, req)
+	got, needsReset := app.selectWordEntry(dummyContext(), pack, []string{want.Word}, req)
+	if got.Word != want.Word || needsReset {
+		t.Errorf("daily pick with %s completed = %s, want %s for everyone", want.Word, got.Word, want.Word)
+	}
+}
+
+func TestForcedRollChangesTheDailyGame(t *testing.T) {
+	app := dailyTestApp(t)
+	router := gin.New()
+	router.POST(RouteNewGame, app.newGameHandler)
+	router.POST(RouteAdmin+"/daily/roll", app.adminDailyRollHandler)
+	today := app.Daily.puzzleDate(app.now(), time.UTC)
+	tomorrow := today.AddDate(0, 0, 1)
+	if err := app.Calendar.pin(today, "CRANE"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Calendar.pin(tomorrow, "SLATE"); err != nil {
+		t.Fatal(err)
+	}
+
+	dailyNewGame(router, "session-roll")
+	if got := app.GameSessions["session-roll"].SessionWord; got != "CRANE" {
+		t.Fatalf("daily game before the roll = %s, want CRANE", got)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", RouteAdmin+"/daily/roll", nil))
+	dailyNewGame(router, "session-roll")
+	game := app.GameSessions["session-roll"]
+	if game.SessionWord != "SLATE" || game.Daily != tomorrow.Format(time.DateOnly) {
+		t.Errorf("daily game after the roll = %s on %s, want SLATE on %s", game.SessionWord, game.Daily, tomorrow.Format(time.DateOnly))
+	}
+}
+
+func TestFinishedDailyIsNotRestarted(t *testing.T) {
+	app := dailyTestApp(t)
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.POST(RouteNewGame, app.newGameHandler)
+	router.POST(RouteGuess, app.guessHandler)
+
+	dailyNewGame(router, "session-aaa")
+	game := app.GameSessions["session-aaa"]
+	if game == nil || game.Daily == "" {
+		t.Fatalf("daily game = %+v", game)
+	}
+	req := httptest.NewRequest("POST", RouteGuess, strings.NewReader(url.Values{"guess": {game.SessionWord}, "row": {"0"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session-aaa"})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if !game.GameOver || !game.Won {
+		t.Fatalf("Expected the daily to be won, got %+v", game)
+	}
+
+	dailyNewGame(router, "session-aaa")
+	if got := app.GameSessions["session-aaa"]; got != game || !got.GameOver || len(got.GuessHistory) != 1 {
+		t.Errorf("daily after finishing = %+v, want the finished game resumed", got)
+	}
+}
+
+func TestReplayedDailyIsRecordedOnce(t *testing.T) {
+	app := dailyTestApp(t)
+	app.Stats = newGlobalStats("")
+	app.Players = newPlayerStatsStore(StreakFreezeRules{})
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.POST(RouteGuess, app.guessHandler)
+	today := app.Daily.puzzleDate(app.now(), time.UTC)
+
+	for range 2 {
+		// However the puzzle is started again, finishing it must not count a second time.
+		game, ok := app.createDailyGame(dummyContext(), "session-aaa", today)
+		if !ok {
+			t.Fatal("no daily word")
+		}
+		req := httptest.NewRequest("POST", RouteGuess, strings.NewReader(url.Values{"guess": {game.SessionWord}, "row": {"0"}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session-aaa"})
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		if !game.Won {
+			t.Fatalf("Expected the daily to be won, got %+v", game)
+		}
+	}
+	if played := app.Stats.day(today.Format(time.DateOnly)).Played; played != 1 {
+		t.Errorf("global stats counted %d games for the daily, want 1", played)
+	}
+	if history := app.Players.history("session-aaa"); len(history) != 1 {
+		t.Errorf("player history has %d games, want 1", len(history))
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Destructive operation names, which double as the confirmation value
+const (
+	OpCleanup          = "cleanup"
+	OpQuarantinePurge  = "quarantine-purge"
+	OpJournalRebalance = "journal-rebalance"
+)
+
+// errConfirmationRequired is returned when a destructive operation runs without being confirmed.
+var errConfirmationRequired = errors.New("confirmation required")
+
+// destructiveOp is a persistence operation that deletes or moves stored data. plan lists what
+// it would touch and apply acts on one item, re-checking it first since the data may have
+// changed since the plan. Keeping the two apart gives every such operation the same dry run,
+// confirmation, and report, whether it is run from an admin endpoint or the command line.
+type destructiveOp struct {
+	Name  string
+	plan  func() ([]opItem, []error)
+	apply func(opItem) error
+}
+
+// opItem is one thing a destructive operation touches. IDs are redacted where they would
+// expose a session; key is the real identifier apply works on.
+type opItem struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+	key    string
+}
+
+// opOptions controls how a destructive operation runs. Confirm must equal the operation's
+// name for anything to change unless DryRun is set; Verbose lists every item in the report.
+type opOptions struct {
+	DryRun  bool
+	Verbose bool
+	Confirm string
+}
+
+// OpReport describes a run of a destructive operation. Candidates and Applied count items by
+// kind; in a dry run nothing is applied.
+type OpReport struct {
+	Operation  string         `json:"operation"`
+	DryRun     bool           `json:"dry_run"`
+	Candidates map[string]int `json:"candidates"`
+	Applied    map[string]int `json:"applied"`
+	Items      []opItem       `json:"items,omitempty"`
+	Errors     []string       `json:"errors"`
+	DurationMS int64          `json:"duration_ms"`
+}
+
+// run plans the operation and, when confirmed and not a dry run, applies each item. It
+// returns errConfirmationRequired without touching anything when neither is set.
+func (op destructiveOp) run(opts opOptions) (*OpReport, error) {
+	if !opts.DryRun && opts.Confirm != op.Name {
+		return nil, fmt.Errorf("%w: pass confirm=%s or dry-run", errConfirmationRequired, op.Name)
+	}
+	start := time.Now()
+	report := &OpReport{
+		Operation:  op.Name,
+		DryRun:     opts.DryRun,
+		Candidates: make(map[string]int),
+		Applied:    make(map[string]int),
+		Errors:     []string{},
+	}
+	items, errs := op.plan()
+	for _, err := range errs {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	for _, item := range items {
+		report.Candidates[item.Kind]++
+		if !opts.DryRun {
+			switch err := op.apply(item); {
+			case err == nil:
+				report.Applied[item.Kind]++
+			case errors.Is(err, errOpSkipped):
+				item.Error = err.Error()
+			default:
+				item.Error = err.Error()
+				report.Errors = append(report.Errors, fmt.Sprintf("%s %s: %v", item.Kind, item.ID, err))
+			}
+		}
+		if opts.Verbose {
+			report.Items = append(report.Items, item)
+		}
+	}
+	report.DurationMS = time.Since(start).Milliseconds()
+	if !opts.DryRun {
+		logInfo("Ran %s: applied %v of %v candidates, %d errors", op.Name, report.Applied, report.Candidates, len(report.Errors))
+	}
+	return report, nil
+}
+
+// summary describes the report in one line for the audit log.
+func (r *OpReport) summary() string {
+	counts := func(m map[string]int) string {
+		parts := make([]string, 0, len(m))
+		for _, kind := range slices.Sorted(maps.Keys(m)) {
+			parts = append(parts, fmt.Sprintf("%d %s", m[kind], kind))
+		}
+		if len(parts) == 0 {
+			return "none"
+		}
+		return strings.Join(parts, ", ")
+	}
+	if r.DryRun {
+		return fmt.Sprintf("dry run: %s would change, %d errors", counts(r.Candidates), len(r.Errors))
+	}
+	return fmt.Sprintf("applied %s of %s, %d errors", counts(r.Applied), counts(r.Candidates), len(r.Errors))
+}
+
+// errOpSkipped is returned by apply when an item no longer qualifies, for example a session
+// that became active again after the plan. Skipped items are not counted as errors.
+var errOpSkipped = errors.New("no longer applies")
+
+// cleanupOp expires sessions and deletes journals idle longer than maxAge. Expired sessions
+// are quarantined as the scheduled sweep does.
+func (app *App) cleanupOp(maxAge time.Duration) destructiveOp {
+	cutoff := time.Now().Add(-maxAge)
+	return destructiveOp{
+		Name: OpCleanup,
+		plan: func() ([]opItem, []error) {
+			var items []opItem
+			for _, id := range app.idleSessionIDs(maxAge) {
+				items = append(items, opItem{Kind: "session", ID: redactSession(id), Action: "expire", key: id})
+			}
+			paths, errs := app.Journal.stale(cutoff)
+			for _, path := range paths {
+				items = append(items, opItem{Kind: "journal", ID: journalID(path), Action: "delete", key: path})
+			}
+			return items, errs
+		},
+		apply: func(item opItem) error {
+			if item.Kind == "session" {
+				if !app.expireSession(item.key, maxAge) {
+					return errOpSkipped
+				}
+				return nil
+			}
+			return app.Journal.removeStale(item.key, cutoff)
+		},
+	}
+}
+
+// quarantinePurgeOp drops quarantined sessions for good, only those quarantined for reason
+// when it is set.
+func (app *App) quarantinePurgeOp(reason string) destructiveOp {
+	return destructiveOp{
+		Name: OpQuarantinePurge,
+		plan: func() ([]opItem, []error) {
+			var items []opItem
+			for _, id := range app.Quarantine.ids(reason, app.now()) {
+				items = append(items, opItem{Kind: "quarantined_session", ID: redactSession(id), Action: "purge", key: id})
+			}
+			return items, nil
+		},
+		apply: func(item opItem) error {
+			if _, ok := app.Quarantine.take(item.key, app.now()); !ok {
+				return errOpSkipped
+			}
+			return nil
+		},
+	}
+}
+
+// journalRebalanceOp moves journals found on the wrong shard to the one that owns them.
+func (app *App) journalRebalanceOp() destructiveOp {
+	return destructiveOp{
+		Name: OpJournalRebalance,
+		plan: func() ([]opItem, []error) {
+			names, errs := app.Journal.misplaced()
+			items := make([]opItem, 0, len(names))
+			for _, name := range names {
+				items = append(items, opItem{Kind: "journal", ID: journalID(name), Action: "move", key: name})
+			}
+			return items, errs
+		},
+		apply: func(item opItem) error {
+			if err := app.Journal.settleLocked(item.key); err != nil {
+				return err
+			}
+			app.incMetric(MetricJournalsRebalanced)
+			return nil
+		},
+	}
+}
+
+// runDestructiveHandler runs op with the dry-run, verbose, and confirm query parameters and
+// returns its report. Without dry-run=true, confirm must name the operation.
+func runDestructiveHandler(c *gin.Context, op destructiveOp) {
+	var opts opOptions
+	flags := []struct {
+		name string
+		dst  *bool
+	}{{"dry-run", &opts.DryRun}, {"verbose", &opts.Verbose}}
+	for _, param := range flags {
+		if raw := c.Query(param.name); raw != "" {
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, param.name+" must be true or false")
+				return
+			}
+			*param.dst = b
+		}
+	}
+	opts.Confirm = c.Query("confirm")
+	report, err := op.run(opts)
+	if err != nil {
+		writeProblem(c, http.StatusPreconditionRequired, ErrorCodeConfirmationRequired, err.Error())
+		return
+	}
+	auditChange(c, op.Name, "", report.summary())
+	c.JSON(http.StatusOK, report)
+}
+
+// adminQuarantinePurgeHandler purges quarantined sessions, optionally only those with reason.
+func (app *App) adminQuarantinePurgeHandler(c *gin.Context) {
+	runDestructiveHandler(c, app.quarantinePurgeOp(c.Query("reason")))
+}
+
+// runMaintenance runs a destructive operation on the journals from the command line, as
+// "vortludo maintenance <operation> [flags]", printing the report as JSON. Sessions live in
+// the server's memory, so only journal work applies offline. It returns the exit code.
+func runMaintenance(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("maintenance", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var opts opOptions
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "report what would change without changing it")
+	fs.BoolVar(&opts.Verbose, "verbose", false, "list every item in the report")
+	fs.StringVar(&opts.Confirm, "confirm", "", "the operation name, required unless -dry-run is set")
+	maxAge := fs.Duration("max-age", getEnvDuration("SESSION_TIMEOUT", DefaultSessionTimeout), "journal age for "+OpCleanup)
+	usage := fmt.Sprintf("usage: vortludo maintenance {%s|%s} [flags]", OpCleanup, OpJournalRebalance)
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+	name := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	app := &App{GameSessions: make(map[string]*GameState), Journal: newSessionJournal(getEnvString("SESSION_JOURNAL_DIR", ""))}
+	if app.Journal == nil {
+		fmt.Fprintln(stderr, "SESSION_JOURNAL_DIR is not set")
+		return 1
+	}
+	var op destructiveOp
+	switch name {
+	case OpCleanup:
+		if *maxAge <= 0 {
+			fmt.Fprintln(stderr, "-max-age must be positive")
+			return 2
+		}
+		op = app.cleanupOp(*maxAge)
+	case OpJournalRebalance:
+		op = app.journalRebalanceOp()
+	default:
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+	namespace, _ := parseNamespace(os.Getenv("NAMESPACE"))
+	audit, err := openAuditLog(getEnvString("AUDIT_LOG_FILE", dataPath(namespace, "audit.jsonl")))
+	if err != nil {
+		fmt.Fprintln(stderr, "cannot open audit log:", err)
+		return 1
+	}
+	defer audit.close()
+	report, err := op.run(opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	entry := AuditEntry{Time: time.Now(), Actor: "cli", Action: "maintenance " + name, Target: strings.Join(args[1:], " "), After: report.summary()}
+	if user := os.Getenv("USER"); user != "" {
+		entry.Actor = "cli:" + user
+	}
+	if err := audit.record(entry); err != nil {
+		fmt.Fprintln(stderr, "cannot write audit log:", err)
+		return 1
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "    ")
+	enc.Encode(report)
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
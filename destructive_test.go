@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestDestructiveOpNeedsConfirmation(t *testing.T) {
+	applied := 0
+	op := destructiveOp{
+		Name: "wipe",
+		plan: func() ([]opItem, []error) {
+			return []opItem{{Kind: "thing", ID: "a"}, {Kind: "thing", ID: "b"}, {Kind: "thing", ID: "gone"}}, nil
+		},
+		apply: func(item opItem) error {
+			if item.ID == "gone" {
+				return errOpSkipped
+			}
+			applied++
+			return nil
+		},
+	}
+	if _, err := op.run(opOptions{Confirm: "wrong"}); !errors.Is(err, errConfirmationRequired) || applied != 0 {
+		t.Fatalf("run without confirmation = %v, applied %d", err, applied)
+	}
+	report, err := op.run(opOptions{DryRun: true})
+	if err != nil || report.Candidates["thing"] != 3 || len(report.Applied) != 0 || applied != 0 {
+		t.Fatalf("dry run = %+v, %v; applied %d", report, err, applied)
+	}
+	report, err = op.run(opOptions{Confirm: "wipe", Verbose: true})
+	if err != nil || report.Applied["thing"] != 2 || len(report.Errors) != 0 || len(report.Items) != 3 {
+		t.Fatalf("run = %+v, %v; want 2 applied and the skipped item listed without an error", report, err)
+	}
+	if report.Items[2].Error != errOpSkipped.Error() {
+		t.Errorf("skipped item = %+v", report.Items[2])
+	}
+}
+
+func TestAdminQuarantinePurgeHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Quarantine = newSessionQuarantine(time.Hour, 10)
+	now := app.now()
+	app.Quarantine.add("evicted-session", newGame("CRANE"), "capacity", now)
+	app.Quarantine.add("expired-session", newGame("CRANE"), "expired", now)
+
+	router := gin.New()
+	router.POST("/admin/sessions/quarantine/purge", app.adminQuarantinePurgeHandler)
+	purge := func(query string) (int, OpReport) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/admin/sessions/quarantine/purge"+query, nil))
+		var report OpReport
+		json.Unmarshal(w.Body.Bytes(), &report)
+		return w.Code, report
+	}
+
+	if code, _ := purge(""); code != http.StatusPreconditionRequired {
+		t.Errorf("Expected 428 without confirm, got %d", code)
+	}
+	code, report := purge("?reason=expired&confirm=" + OpQuarantinePurge)
+	if code != http.StatusOK || report.Applied["quarantined_session"] != 1 {
+		t.Errorf("Purge = %d %+v, want one session purged", code, report)
+	}
+	if entries, total := app.Quarantine.list(app.now()); total != 1 || entries[0].SessionID != "evicted-session" {
+		t.Errorf("Expected only the evicted session to remain, got %+v", entries)
+	}
+}
+
+func TestRunMaintenance(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SESSION_JOURNAL_DIR", dir)
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv("AUDIT_LOG_FILE", auditPath)
+	j := newSessionJournal(dir)
+	j.start(dummyContext(), "session-old", &GameState{GameState: engine.GameState{SessionWord: "CRANE"}})
+	old := time.Now().Add(-3 * time.Hour)
+	if err := os.Chtimes(j.path("session-old"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runMaintenance([]string{OpCleanup, "-max-age", "1h"}, &stdout, &stderr); code != 1 || stderr.Len() == 0 {
+		t.Errorf("unconfirmed cleanup exited %d with %q", code, stderr.String())
+	}
+	if code := runMaintenance([]string{"defrag"}, &stdout, &stderr); code != 2 {
+		t.Errorf("unknown operation exited %d", code)
+	}
+
+	stdout.Reset()
+	if code := runMaintenance([]string{OpCleanup, "-max-age", "1h", "-confirm", OpCleanup}, &stdout, &stderr); code != 0 {
+		t.Fatalf("cleanup exited %d: %s", code, stderr.String())
+	}
+	var report OpReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil || report.Applied["journal"] != 1 {
+		t.Errorf("report = %+v, %v; want one journal deleted", report, err)
+	}
+	if _, err := os.Stat(j.path("session-old")); !os.IsNotExist(err) {
+		t.Errorf("journal not deleted: %v", err)
+	}
+	entries, err := readAuditEntries(auditPath)
+	if err != nil || len(entries) != 1 || entries[0].Action != "maintenance "+OpCleanup || !strings.HasPrefix(entries[0].Actor, "cli") {
+		t.Errorf("audit entries = %+v, %v; want the confirmed cleanup", entries, err)
+	}
+}
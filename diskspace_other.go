@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package main
+
+import "errors"
+
+// diskFreeBytes is not supported on this platform.
+func diskFreeBytes(_ string) (uint64, error) {
+	return 0, errors.New("disk space check not supported on this platform")
+}
@@ -0,0 +1,207 @@
+// Package engine implements the Vortludo game rules with no web framework dependencies,
+// so bots, terminal front-ends, and serverless functions can embed the same logic as the server.
+package engine
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Game configuration constants
+const (
+	MaxGuesses = 6
+	WordLength = 5
+)
+
+// Guess status constants
+const (
+	StatusCorrect = "correct"
+	StatusPresent = "present"
+	StatusAbsent  = "absent"
+)
+
+// WordEntry represents a word and its associated hint. Related lists words accepted in the
+// bonus round besides anagrams.
+type WordEntry struct {
+	Word    string   `json:"word"`
+	Hint    string   `json:"hint"`
+	Related []string `json:"related,omitempty"`
+}
+
+// WordList is a container for a list of WordEntry items, used for JSON unmarshalling.
+type WordList struct {
+	Words []WordEntry `json:"words"`
+}
+
+// GuessResult represents the result of a single letter in a guess.
+type GuessResult struct {
+	Letter string `json:"letter"`
+	Status string `json:"status"`
+}
+
+// GameState holds the state of a single game. The board has a row per allowed guess.
+type GameState struct {
+	Guesses        [][]GuessResult `json:"guesses"`
+	CurrentRow     int             `json:"currentRow"`
+	GameOver       bool            `json:"gameOver"`
+	Won            bool            `json:"won"`
+	TargetWord     string          `json:"targetWord"`
+	SessionWord    string          `json:"sessionWord"`
+	HintsUsed      int             `json:"hintsUsed"`
+	GuessHistory   []string        `json:"guessHistory"`
+	LastAccessTime time.Time       `json:"lastAccessTime"`
+}
+
+// Outcome describes the effect of applying a guess to a game.
+type Outcome int
+
+// Outcome constants
+const (
+	OutcomeIgnored Outcome = iota
+	OutcomeContinue
+	OutcomeWon
+	OutcomeLost
+)
+
+// NewBoard returns an empty MaxGuesses x WordLength board.
+func NewBoard() [][]GuessResult {
+	board := make([][]GuessResult, MaxGuesses)
+	for i := range board {
+		board[i] = make([]GuessResult, WordLength)
+	}
+	return board
+}
+
+// NewGame returns a fresh game for the given target word.
+func NewGame(target string) *GameState {
+	return &GameState{
+		Guesses:        NewBoard(),
+		SessionWord:    target,
+		GuessHistory:   []string{},
+		LastAccessTime: time.Now(),
+	}
+}
+
+// NormalizeGuess trims and uppercases a guess string for comparison.
+func NormalizeGuess(input string) string {
+	return strings.ToUpper(strings.TrimSpace(input))
+}
+
+// runeBufPool reuses scratch buffers for CheckGuess to avoid per-call allocations.
+var runeBufPool = sync.Pool{
+	New: func() any { buf := make([]rune, WordLength); return &buf },
+}
+
+// CheckGuess compares a guess to the target word and returns per-letter results.
+// Both words must be WordLength bytes long; otherwise nil is returned.
+func CheckGuess(guess, target string) []GuessResult {
+	if len(guess) != WordLength || len(target) != WordLength {
+		return nil
+	}
+
+	result := make([]GuessResult, WordLength)
+	bufPtr := runeBufPool.Get().(*[]rune)
+	targetCopy := (*bufPtr)[:WordLength]
+	for i := range WordLength {
+		targetCopy[i] = rune(target[i])
+	}
+
+	for i := range WordLength {
+		if guess[i] == target[i] {
+			result[i] = GuessResult{Letter: string(guess[i]), Status: StatusCorrect}
+			targetCopy[i] = ' '
+		}
+	}
+
+	for i := range WordLength {
+		if result[i].Status != "" {
+			continue
+		}
+		result[i] = GuessResult{Letter: string(guess[i]), Status: StatusAbsent}
+		for j := range WordLength {
+			if targetCopy[j] == rune(guess[i]) {
+				result[i].Status = StatusPresent
+				targetCopy[j] = ' '
+				break
+			}
+		}
+	}
+
+	clear(targetCopy)
+	runeBufPool.Put(bufPtr)
+	return result
+}
+
+// ApplyGuess records an evaluated guess on the board and advances the game.
+// A guess only wins when it matches the target and countsAsWin is true; the target is
+// revealed in TargetWord once the game is over.
+func (g *GameState) ApplyGuess(guess, target string, result []GuessResult, countsAsWin bool) Outcome {
+	if g.GameOver || g.CurrentRow >= g.Rows() {
+		return OutcomeIgnored
+	}
+
+	g.Guesses[g.CurrentRow] = result
+	g.GuessHistory = append(g.GuessHistory, guess)
+	g.LastAccessTime = time.Now()
+
+	outcome := OutcomeContinue
+	if countsAsWin && guess == target {
+		g.Won = true
+		g.GameOver = true
+		outcome = OutcomeWon
+	} else {
+		g.CurrentRow++
+		if g.CurrentRow >= g.Rows() {
+			g.GameOver = true
+			outcome = OutcomeLost
+		}
+	}
+
+	if g.GameOver {
+		g.TargetWord = target
+	}
+	return outcome
+}
+
+// Rows returns the number of guesses allowed: MaxGuesses plus any rows added.
+func (g *GameState) Rows() int {
+	return len(g.Guesses)
+}
+
+// AddRow appends an empty row to the board, allowing one more guess.
+func (g *GameState) AddRow() {
+	g.Guesses = append(g.Guesses, make([]GuessResult, WordLength))
+}
+
+// Reset clears the board, including added rows, and history while keeping the same target word.
+func (g *GameState) Reset() {
+	g.Guesses = NewBoard()
+	g.CurrentRow = 0
+	g.GameOver = false
+	g.Won = false
+	g.TargetWord = ""
+	g.GuessHistory = []string{}
+	g.HintsUsed = 0
+	g.LastAccessTime = time.Now()
+}
+
+// statusRank orders letter statuses so a better result is never downgraded on the keyboard.
+var statusRank = map[string]int{StatusAbsent: 1, StatusPresent: 2, StatusCorrect: 3}
+
+// KeyStatuses returns the best known status for each guessed letter, as shown on a keyboard:
+// correct beats present, which beats absent.
+func KeyStatuses(guesses [][]GuessResult) map[string]string {
+	statuses := make(map[string]string)
+	for _, row := range guesses {
+		for _, tile := range row {
+			if tile.Letter == "" || statusRank[tile.Status] == 0 {
+				continue
+			}
+			if statusRank[tile.Status] > statusRank[statuses[tile.Letter]] {
+				statuses[tile.Letter] = tile.Status
+			}
+		}
+	}
+	return statuses
+}
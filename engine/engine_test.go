@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func statuses(results []GuessResult) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.Status
+	}
+	return out
+}
+
+func TestCheckGuess(t *testing.T) {
+	cases := []struct {
+		guess, target string
+		want          []string
+	}{
+		{"APPLE", "APPLE", []string{StatusCorrect, StatusCorrect, StatusCorrect, StatusCorrect, StatusCorrect}},
+		{"ZZZZZ", "APPLE", []string{StatusAbsent, StatusAbsent, StatusAbsent, StatusAbsent, StatusAbsent}},
+		{"PLEAP", "APPLE", []string{StatusPresent, StatusPresent, StatusPresent, StatusPresent, StatusPresent}},
+		{"LLAMA", "HELLO", []string{StatusPresent, StatusPresent, StatusAbsent, StatusAbsent, StatusAbsent}},
+		{"ALLEY", "HELLO", []string{StatusAbsent, StatusPresent, StatusCorrect, StatusPresent, StatusAbsent}},
+	}
+	for _, c := range cases {
+		got := statuses(CheckGuess(c.guess, c.target))
+		if !slices.Equal(got, c.want) {
+			t.Errorf("CheckGuess(%q, %q) = %v, want %v", c.guess, c.target, got, c.want)
+		}
+	}
+	if CheckGuess("TOOLONG", "APPLE") != nil {
+		t.Error("Expected nil result for wrong-length guess")
+	}
+}
+
+func TestApplyGuessWin(t *testing.T) {
+	g := NewGame("APPLE")
+	if out := g.ApplyGuess("APPLE", "APPLE", CheckGuess("APPLE", "APPLE"), true); out != OutcomeWon {
+		t.Fatalf("Expected OutcomeWon, got %v", out)
+	}
+	if !g.Won || !g.GameOver || g.TargetWord != "APPLE" {
+		t.Error("Game should be won and target revealed")
+	}
+	if out := g.ApplyGuess("TABLE", "APPLE", nil, true); out != OutcomeIgnored {
+		t.Errorf("Expected guesses after game over to be ignored, got %v", out)
+	}
+}
+
+func TestApplyGuessLoseAndReset(t *testing.T) {
+	g := NewGame("APPLE")
+	for i := range MaxGuesses {
+		out := g.ApplyGuess("TABLE", "APPLE", CheckGuess("TABLE", "APPLE"), true)
+		if i < MaxGuesses-1 && out != OutcomeContinue {
+			t.Fatalf("Expected OutcomeContinue on guess %d, got %v", i+1, out)
+		}
+		if i == MaxGuesses-1 && out != OutcomeLost {
+			t.Fatalf("Expected OutcomeLost on final guess, got %v", out)
+		}
+	}
+	if g.Won || !g.GameOver || g.TargetWord != "APPLE" {
+		t.Error("Game should be lost and target revealed")
+	}
+
+	g.Reset()
+	if g.GameOver || g.CurrentRow != 0 || len(g.GuessHistory) != 0 || g.SessionWord != "APPLE" {
+		t.Error("Reset should clear progress but keep the word")
+	}
+}
+
+func TestAddRow(t *testing.T) {
+	g := NewGame("APPLE")
+	g.AddRow()
+	for range MaxGuesses {
+		g.ApplyGuess("TABLE", "APPLE", CheckGuess("TABLE", "APPLE"), true)
+	}
+	if g.GameOver || g.Rows() != MaxGuesses+1 {
+		t.Fatalf("game with an extra row ended after %d guesses", MaxGuesses)
+	}
+	if out := g.ApplyGuess("TABLE", "APPLE", CheckGuess("TABLE", "APPLE"), true); out != OutcomeLost {
+		t.Errorf("Expected OutcomeLost on the extra row, got %v", out)
+	}
+	g.Reset()
+	if g.Rows() != MaxGuesses {
+		t.Error("Reset should drop extra rows")
+	}
+}
+
+func TestApplyGuessInvalidWordNeverWins(t *testing.T) {
+	g := NewGame("APPLE")
+	if out := g.ApplyGuess("APPLE", "APPLE", CheckGuess("APPLE", "APPLE"), false); out != OutcomeContinue {
+		t.Errorf("Expected OutcomeContinue when win is not counted, got %v", out)
+	}
+}
+
+func TestParseWordList(t *testing.T) {
+	data := []byte(`{"words":[{"word":"APPLE","hint":"fruit"},{"word":"TOOLONG","hint":"x"}]}`)
+	words, skipped, err := ParseWordList(data)
+	if err != nil {
+		t.Fatalf("ParseWordList failed: %v", err)
+	}
+	if len(words) != 1 || words[0].Word != "APPLE" {
+		t.Errorf("Unexpected words: %v", words)
+	}
+	if !slices.Equal(skipped, []string{"TOOLONG"}) {
+		t.Errorf("Unexpected skipped words: %v", skipped)
+	}
+	if _, _, err := ParseWordList([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestParseAcceptedWords(t *testing.T) {
+	set := ParseAcceptedWords([]byte("apple\n  Table \n\n"))
+	if len(set) != 2 {
+		t.Fatalf("Expected 2 words, got %d", len(set))
+	}
+	if _, ok := set["TABLE"]; !ok {
+		t.Error("Expected words to be trimmed and uppercased")
+	}
+}
+
+func TestKeyStatuses(t *testing.T) {
+	guesses := [][]GuessResult{
+		CheckGuess("CRANE", "TRACE"),
+		CheckGuess("TRACE", "TRACE"),
+		NewBoard()[0],
+	}
+	got := KeyStatuses(guesses)
+	want := map[string]string{"C": StatusCorrect, "R": StatusCorrect, "A": StatusCorrect, "N": StatusAbsent, "E": StatusCorrect, "T": StatusCorrect}
+	if len(got) != len(want) {
+		t.Fatalf("KeyStatuses() = %v, want %v", got, want)
+	}
+	for letter, status := range want {
+		if got[letter] != status {
+			t.Errorf("KeyStatuses()[%s] = %q, want %q", letter, got[letter], status)
+		}
+	}
+
+	downgrade := KeyStatuses([][]GuessResult{{{Letter: "A", Status: StatusPresent}}, {{Letter: "A", Status: StatusAbsent}}})
+	if downgrade["A"] != StatusPresent {
+		t.Errorf("present should not be downgraded to absent, got %q", downgrade["A"])
+	}
+}
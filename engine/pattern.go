@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"cmp"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// Pattern packs the feedback for one guess into a byte: one base-3 digit per letter, lowest
+// position first, where 0 is absent, 1 present, and 2 correct. 3^5 = 243 patterns fit in a byte.
+type Pattern uint8
+
+// PatternSolved is the pattern of a guess that matches the target.
+const PatternSolved Pattern = 242
+
+// patternDigits maps a digit of a Pattern to its status.
+var patternDigits = [3]string{StatusAbsent, StatusPresent, StatusCorrect}
+
+// ComputePattern returns the feedback pattern of guess against target, matching CheckGuess
+// without allocating. Words that are not WordLength bytes long get pattern 0.
+func ComputePattern(guess, target string) Pattern {
+	if len(guess) != WordLength || len(target) != WordLength {
+		return 0
+	}
+	var used [WordLength]bool
+	var digits [WordLength]uint8
+	for i := range WordLength {
+		if guess[i] == target[i] {
+			digits[i] = 2
+			used[i] = true
+		}
+	}
+	for i := range WordLength {
+		if digits[i] == 2 {
+			continue
+		}
+		for j := range WordLength {
+			if !used[j] && guess[i] == target[j] {
+				digits[i] = 1
+				used[j] = true
+				break
+			}
+		}
+	}
+	var p Pattern
+	for i := WordLength - 1; i >= 0; i-- {
+		p = p*3 + Pattern(digits[i])
+	}
+	return p
+}
+
+// Results expands the pattern into per-letter results for guess, as CheckGuess returns them.
+func (p Pattern) Results(guess string) []GuessResult {
+	if len(guess) != WordLength {
+		return nil
+	}
+	result := make([]GuessResult, WordLength)
+	for i := range WordLength {
+		result[i] = GuessResult{Letter: string(guess[i]), Status: patternDigits[p%3]}
+		p /= 3
+	}
+	return result
+}
+
+// FeedbackMatrix holds the pattern of every allowed guess against every candidate target, so
+// solvers and adversarial modes can split the remaining candidates by feedback without
+// re-evaluating guesses. Rows are precomputed when the whole matrix fits in the memory bound
+// and otherwise memoized on first use until the bound is reached; rows beyond it are computed
+// on each call.
+type FeedbackMatrix struct {
+	guesses    map[string]int
+	candidates []string
+	maxBytes   int
+
+	mu     sync.RWMutex
+	rows   [][]Pattern
+	stored int
+}
+
+// NewFeedbackMatrix indexes guesses and candidates and precomputes their patterns when
+// len(guesses) * len(candidates) fits in maxBytes.
+func NewFeedbackMatrix(guesses, candidates []string, maxBytes int) *FeedbackMatrix {
+	m := &FeedbackMatrix{
+		guesses:    make(map[string]int, len(guesses)),
+		candidates: slices.Clone(candidates),
+		maxBytes:   maxBytes,
+	}
+	for _, g := range guesses {
+		if _, ok := m.guesses[g]; !ok {
+			m.guesses[g] = len(m.guesses)
+		}
+	}
+	m.rows = make([][]Pattern, len(m.guesses))
+	if len(m.guesses)*len(m.candidates) <= maxBytes {
+		m.precompute()
+	}
+	return m
+}
+
+// precompute fills every row, spreading the guesses across GOMAXPROCS workers.
+func (m *FeedbackMatrix) precompute() {
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for range runtime.GOMAXPROCS(0) {
+		wg.Go(func() {
+			for g := range jobs {
+				m.rows[m.guesses[g]] = m.computeRow(g)
+			}
+		})
+	}
+	for g := range m.guesses {
+		jobs <- g
+	}
+	close(jobs)
+	wg.Wait()
+	m.stored = len(m.guesses) * len(m.candidates)
+}
+
+// computeRow evaluates guess against every candidate.
+func (m *FeedbackMatrix) computeRow(guess string) []Pattern {
+	row := make([]Pattern, len(m.candidates))
+	for j, target := range m.candidates {
+		row[j] = ComputePattern(guess, target)
+	}
+	return row
+}
+
+// row returns the patterns of guess against every candidate, memoizing it while the matrix
+// is under its memory bound.
+func (m *FeedbackMatrix) row(guess string) []Pattern {
+	i, ok := m.guesses[guess]
+	if !ok {
+		return m.computeRow(guess)
+	}
+	m.mu.RLock()
+	row := m.rows[i]
+	m.mu.RUnlock()
+	if row != nil {
+		return row
+	}
+	row = m.computeRow(guess)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rows[i] == nil && m.stored+len(row) <= m.maxBytes {
+		m.rows[i] = row
+		m.stored += len(row)
+	}
+	return row
+}
+
+// Candidates returns the number of candidate targets.
+func (m *FeedbackMatrix) Candidates() int {
+	return len(m.candidates)
+}
+
+// Candidate returns the candidate word at index i.
+func (m *FeedbackMatrix) Candidate(i int) string {
+	return m.candidates[i]
+}
+
+// Bytes returns the memory held by stored pattern rows.
+func (m *FeedbackMatrix) Bytes() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.stored
+}
+
+// Partition groups the candidate indexes in remaining by the pattern guess would show against
+// each of them.
+func (m *FeedbackMatrix) Partition(guess string, remaining []int) map[Pattern][]int {
+	row := m.row(guess)
+	buckets := make(map[Pattern][]int)
+	for _, j := range remaining {
+		buckets[row[j]] = append(buckets[row[j]], j)
+	}
+	return buckets
+}
+
+// Narrow plays the adversary: it answers guess with the pattern that keeps the most candidates
+// in remaining, breaking ties by the lowest pattern so the answer is deterministic, and returns
+// that pattern with the candidates still consistent with it.
+func (m *FeedbackMatrix) Narrow(guess string, remaining []int) (Pattern, []int) {
+	var best Pattern
+	var bestSet []int
+	for p, set := range m.Partition(guess, remaining) {
+		if bestSet == nil || cmp.Or(cmp.Compare(len(set), len(bestSet)), cmp.Compare(best, p)) > 0 {
+			best, bestSet = p, set
+		}
+	}
+	return best, bestSet
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+var patternWords = []string{"APPLE", "TABLE", "CRANE", "TRACE", "SPEED", "ERASE", "LLAMA", "ALLOY", "PAPER", "EERIE"}
+
+func TestComputePatternMatchesCheckGuess(t *testing.T) {
+	for _, guess := range patternWords {
+		for _, target := range patternWords {
+			want := CheckGuess(guess, target)
+			if got := ComputePattern(guess, target).Results(guess); !slices.Equal(got, want) {
+				t.Errorf("ComputePattern(%s, %s) = %v, want %v", guess, target, got, want)
+			}
+		}
+	}
+	if ComputePattern("CRANE", "CRANE") != PatternSolved {
+		t.Error("matching guess is not PatternSolved")
+	}
+}
+
+func TestFeedbackMatrixNarrow(t *testing.T) {
+	for _, maxBytes := range []int{1 << 20, len(patternWords), 0} {
+		m := NewFeedbackMatrix(patternWords, patternWords, maxBytes)
+		if m.Bytes() > maxBytes {
+			t.Errorf("matrix holds %d bytes, over its bound of %d", m.Bytes(), maxBytes)
+		}
+		remaining := make([]int, m.Candidates())
+		for i := range remaining {
+			remaining[i] = i
+		}
+		total := 0
+		for p, set := range m.Partition("CRANE", remaining) {
+			total += len(set)
+			for _, j := range set {
+				if ComputePattern("CRANE", m.Candidate(j)) != p {
+					t.Errorf("%s is in the wrong bucket", m.Candidate(j))
+				}
+			}
+		}
+		if total != len(remaining) {
+			t.Errorf("partition covers %d of %d candidates", total, len(remaining))
+		}
+
+		p, kept := m.Narrow("CRANE", remaining)
+		if p == PatternSolved || len(kept) == 0 {
+			t.Fatalf("Narrow() = %v, %v", p, kept)
+		}
+		for q, set := range m.Partition("CRANE", remaining) {
+			if len(set) > len(kept) {
+				t.Errorf("bucket %d keeps %d candidates, more than the chosen %d", q, len(set), len(kept))
+			}
+		}
+	}
+}
+
+func BenchmarkCheckGuess(b *testing.B) {
+	for b.Loop() {
+		CheckGuess("CRANE", "TRACE")
+	}
+}
+
+func BenchmarkComputePattern(b *testing.B) {
+	for b.Loop() {
+		ComputePattern("CRANE", "TRACE")
+	}
+}
+
+func BenchmarkNarrow(b *testing.B) {
+	candidates := make([]string, 0, 2500)
+	for _, a := range "ABCDEFGHIJ" {
+		for _, c := range "ABCDE" {
+			for _, d := range "ABCDEFGHIJKLMNOPQRSTUVWXY" {
+				candidates = append(candidates, string([]rune{a, 'R', c, d, 'E'}))
+			}
+		}
+	}
+	m := NewFeedbackMatrix(candidates, candidates, 64<<20)
+	remaining := make([]int, m.Candidates())
+	for i := range remaining {
+		remaining[i] = i
+	}
+	for b.Loop() {
+		m.Narrow("CRANE", remaining)
+	}
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// ParseWordList decodes a JSON word list and returns the entries that are exactly WordLength
+// letters long, along with the words that were skipped.
+func ParseWordList(data []byte) ([]WordEntry, []string, error) {
+	var wl WordList
+	if err := json.Unmarshal(data, &wl); err != nil {
+		return nil, nil, err
+	}
+
+	words := make([]WordEntry, 0, len(wl.Words))
+	var skipped []string
+	for _, entry := range wl.Words {
+		if len(entry.Word) != WordLength {
+			skipped = append(skipped, entry.Word)
+			continue
+		}
+		words = append(words, entry)
+	}
+	return words, skipped, nil
+}
+
+// LoadWordList reads and parses a JSON word list file.
+func LoadWordList(path string) ([]WordEntry, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ParseWordList(data)
+}
+
+// ParseAcceptedWords parses a newline-separated list of accepted guesses into an uppercase set.
+func ParseAcceptedWords(data []byte) map[string]struct{} {
+	lines := strings.Split(string(data), "\n")
+	accepted := make(map[string]struct{}, len(lines))
+	for _, w := range lines {
+		w = strings.TrimSpace(w)
+		if w == "" {
+			continue
+		}
+		accepted[strings.ToUpper(w)] = struct{}{}
+	}
+	return accepted
+}
+
+// LoadAcceptedWords reads and parses an accepted-words file.
+func LoadAcceptedWords(path string) (map[string]struct{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAcceptedWords(data), nil
+}
+
+// WordSet builds a lookup set from a word list.
+func WordSet(words []WordEntry) map[string]struct{} {
+	set := make(map[string]struct{}, len(words))
+	for _, entry := range words {
+		set[entry.Word] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/internal/htmx"
+)
+
+// HX-Trigger event names. static/hx-trigger.schema.json describes each payload and
+// static/client.js handles each event; events_test.go keeps the three in step.
+const (
+	TriggerProgressStale       = "progress-stale"
+	TriggerClearCompletedWords = "clear-completed-words"
+	TriggerCSRFInvalid         = "csrf-invalid"
+	TriggerRateLimitExceeded   = "rate-limit-exceeded"
+
+	// Server errors are sent as three top-level keys rather than one event, so clients can
+	// read the code without unwrapping a detail object.
+	TriggerServerErrorCode    = "server_error_code"
+	TriggerServerErrorMessage = "server_error_message"
+	TriggerRequestID          = "request_id"
+)
+
+// packEvent is the payload of events about a word pack.
+type packEvent struct {
+	Pack string `json:"pack"`
+}
+
+// csrfInvalidEvent is the payload of TriggerCSRFInvalid.
+type csrfInvalidEvent struct {
+	Reason string `json:"reason"`
+}
+
+// Triggers collects the HTMX events sent in one response's HX-Trigger header.
+type Triggers map[string]any
+
+// progressStale tells the client its saved progress for pack predates the current word list.
+func (t Triggers) progressStale(pack string) Triggers {
+	t[TriggerProgressStale] = packEvent{Pack: pack}
+	return t
+}
+
+// clearCompletedWords tells the client every word in pack is done and its progress was reset.
+func (t Triggers) clearCompletedWords(pack string) Triggers {
+	t[TriggerClearCompletedWords] = packEvent{Pack: pack}
+	return t
+}
+
+// csrfInvalid tells the client its CSRF token was rejected and why.
+func (t Triggers) csrfInvalid(reason string) Triggers {
+	t[TriggerCSRFInvalid] = csrfInvalidEvent{Reason: reason}
+	return t
+}
+
+// rateLimitExceeded tells the client it is being rate limited.
+func (t Triggers) rateLimitExceeded() Triggers {
+	t[TriggerRateLimitExceeded] = struct{}{}
+	return t
+}
+
+// serverError reports an error code with its user-facing message and the request ID.
+func (t Triggers) serverError(code, requestID string) Triggers {
+	t[TriggerServerErrorCode] = code
+	t[TriggerServerErrorMessage] = errorMessage(code)
+	t[TriggerRequestID] = requestID
+	return t
+}
+
+// set writes the events to the HX-Trigger header. It does nothing when there are none.
+func (t Triggers) set(c *gin.Context) {
+	if err := htmx.Trigger(c.Writer.Header(), t); err != nil {
+		logWarn("Failed to marshal HX-Trigger payload: %v", err)
+	}
+}
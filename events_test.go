@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// triggerSchema is the subset of JSON Schema used by static/hx-trigger.schema.json.
+type triggerSchema struct {
+	Type                 string                    `json:"type"`
+	Properties           map[string]*triggerSchema `json:"properties"`
+	Required             []string                  `json:"required"`
+	AdditionalProperties *bool                     `json:"additionalProperties"`
+	Enum                 []string                  `json:"enum"`
+	DependentRequired    map[string][]string       `json:"dependentRequired"`
+}
+
+// loadTriggerSchema reads the checked-in HX-Trigger schema.
+func loadTriggerSchema(t *testing.T) *triggerSchema {
+	t.Helper()
+	data, err := os.ReadFile("static/hx-trigger.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s triggerSchema
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+// validate reports the first way v fails to match the schema.
+func (s *triggerSchema) validate(path string, v any) string {
+	switch s.Type {
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return path + " is not a string"
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			return path + " is not one of " + strings.Join(s.Enum, ", ")
+		}
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return path + " is not an object"
+		}
+		for _, key := range s.Required {
+			if _, ok := obj[key]; !ok {
+				return path + " is missing " + key
+			}
+		}
+		for key, deps := range s.DependentRequired {
+			if _, ok := obj[key]; !ok {
+				continue
+			}
+			for _, dep := range deps {
+				if _, ok := obj[dep]; !ok {
+					return path + " has " + key + " without " + dep
+				}
+			}
+		}
+		for key, val := range obj {
+			prop, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return path + " has unknown key " + key
+				}
+				continue
+			}
+			if msg := prop.validate(path+"."+key, val); msg != "" {
+				return msg
+			}
+		}
+	}
+	return ""
+}
+
+func TestTriggersMatchSchema(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	schema := loadTriggerSchema(t)
+	tests := map[string]Triggers{
+		"progress stale and clear": Triggers{}.progressStale(DefaultPackName).clearCompletedWords("animals"),
+		"csrf invalid":             Triggers{}.csrfInvalid(CSRFReasonExpired),
+		"rate limit":               Triggers{}.rateLimitExceeded(),
+		"server error":             Triggers{}.serverError(ErrorCodeNotInWordList, "req-1"),
+	}
+	for name, triggers := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		triggers.set(c)
+		var header any
+		if err := json.Unmarshal([]byte(w.Header().Get("HX-Trigger")), &header); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if msg := schema.validate("HX-Trigger", header); msg != "" {
+			t.Errorf("%s: %s", name, msg)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	Triggers{}.set(c)
+	if w.Header().Get("HX-Trigger") != "" {
+		t.Error("Expected no header without events")
+	}
+}
+
+func TestClientHandlesEveryTrigger(t *testing.T) {
+	schema := loadTriggerSchema(t)
+	client, err := os.ReadFile("static/client.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []string{
+		TriggerProgressStale, TriggerClearCompletedWords, TriggerCSRFInvalid, TriggerRateLimitExceeded,
+		TriggerServerErrorCode, TriggerServerErrorMessage, TriggerRequestID,
+	}
+	for _, event := range events {
+		if _, ok := schema.Properties[event]; !ok {
+			t.Errorf("Schema does not describe %s", event)
+		}
+		if event != TriggerRequestID && !strings.Contains(string(client), event) {
+			t.Errorf("client.js does not handle %s", event)
+		}
+	}
+	if len(schema.Properties) != len(events) {
+		t.Errorf("Schema describes %d events, events.go defines %d", len(schema.Properties), len(events))
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// experimentNamePattern restricts experiment and variant names so they are safe in metric names.
+var experimentNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Experiment is an A/B test splitting sessions evenly across its variants.
+type Experiment struct {
+	Name     string
+	Variants []string
+}
+
+// Experiments is the set of running experiments, configured with EXPERIMENTS.
+type Experiments []Experiment
+
+// parseExperiments reads a spec such as "hint-button=control,early;word-pick=random,rare".
+// Each experiment needs at least two variants.
+func parseExperiments(spec string) (Experiments, error) {
+	var experiments Experiments
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !experimentNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid experiment %q", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate experiment %q", name)
+		}
+		seen[name] = true
+		exp := Experiment{Name: name}
+		for _, v := range strings.Split(list, ",") {
+			v = strings.TrimSpace(v)
+			if !experimentNamePattern.MatchString(v) {
+				return nil, fmt.Errorf("invalid variant %q in experiment %q", v, name)
+			}
+			exp.Variants = append(exp.Variants, v)
+		}
+		if len(exp.Variants) < 2 {
+			return nil, fmt.Errorf("experiment %q needs at least two variants", name)
+		}
+		experiments = append(experiments, exp)
+	}
+	return experiments, nil
+}
+
+// bucket returns the variant of e for sessionID. The same session always lands in the same
+// variant, and experiments are bucketed independently of each other.
+func (e Experiment) bucket(sessionID string) string {
+	sum := sha256.Sum256([]byte(e.Name + "\x00" + sessionID))
+	return e.Variants[binary.BigEndian.Uint64(sum[:8])%uint64(len(e.Variants))]
+}
+
+// assign returns the variant of every experiment for sessionID, keyed by experiment name.
+func (e Experiments) assign(sessionID string) map[string]string {
+	variants := make(map[string]string, len(e))
+	for _, exp := range e {
+		variants[exp.Name] = exp.bucket(sessionID)
+	}
+	return variants
+}
+
+// experimentVariant returns the session's variant of the named experiment, or "" when it is
+// not running, so code paths can branch on it.
+func (app *App) experimentVariant(sessionID, name string) string {
+	for _, exp := range app.Experiments {
+		if exp.Name == name {
+			return exp.bucket(sessionID)
+		}
+	}
+	return ""
+}
+
+// recordExperiments counts event (started, won, lost) against each of the session's variants.
+func (app *App) recordExperiments(sessionID, event string) {
+	for _, exp := range app.Experiments {
+		app.incMetric(MetricExperimentPrefix + exp.Name + "_" + exp.bucket(sessionID) + "_" + event)
+	}
+}
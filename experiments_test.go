@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseExperiments(t *testing.T) {
+	got, err := parseExperiments(" hint-button=control,early ; word-pick=random, rare,common ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Name != "hint-button" || len(got[1].Variants) != 3 || got[1].Variants[1] != "rare" {
+		t.Errorf("parseExperiments() = %+v", got)
+	}
+	if got, err := parseExperiments(""); err != nil || got != nil {
+		t.Errorf("parseExperiments(\"\") = %v, %v, want none", got, err)
+	}
+
+	for _, spec := range []string{
+		"solo=only",
+		"Bad=a,b",
+		"x=a,B",
+		"x=a,b;x=c,d",
+		"no-variants",
+	} {
+		if _, err := parseExperiments(spec); err == nil {
+			t.Errorf("parseExperiments(%q) should fail", spec)
+		}
+	}
+}
+
+func TestExperimentBucketing(t *testing.T) {
+	exp := Experiment{Name: "hint-button", Variants: []string{"control", "early"}}
+	counts := map[string]int{}
+	for i := range 1000 {
+		id := fmt.Sprintf("session-%04d", i)
+		v := exp.bucket(id)
+		if exp.bucket(id) != v {
+			t.Fatalf("bucket(%s) is not deterministic", id)
+		}
+		counts[v]++
+	}
+	if counts["control"] < 400 || counts["early"] < 400 {
+		t.Errorf("bucket split = %v, want roughly even", counts)
+	}
+
+	app := &App{Experiments: Experiments{exp}, Metrics: newMetrics()}
+	variant := app.experimentVariant("session-0001", "hint-button")
+	if variant != exp.bucket("session-0001") {
+		t.Errorf("experimentVariant() = %q", variant)
+	}
+	if got := app.experimentVariant("session-0001", "unknown"); got != "" {
+		t.Errorf("experimentVariant(unknown) = %q, want empty", got)
+	}
+	if got := app.Experiments.assign("session-0001"); got["hint-button"] != variant {
+		t.Errorf("assign() = %v", got)
+	}
+
+	app.recordExperiments("session-0001", "won")
+	if got := app.Metrics.Get(MetricExperimentPrefix + "hint-button_" + variant + "_won"); got == nil || got.String() != "1" {
+		t.Errorf("experiment metric = %v, want 1", got)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"cmp"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/internal/htmx"
+)
+
+// Word feedback ratings
+const (
+	FeedbackTooObscure = "too_obscure"
+	FeedbackFine       = "fine"
+	FeedbackTooEasy    = "too_easy"
+)
+
+// feedbackRatings is the set of accepted ratings.
+var feedbackRatings = map[string]bool{FeedbackTooObscure: true, FeedbackFine: true, FeedbackTooEasy: true}
+
+// wordFeedback is the tally of ratings one word has received.
+type wordFeedback struct {
+	Word       string `json:"word"`
+	TooObscure int    `json:"too_obscure"`
+	Fine       int    `json:"fine"`
+	TooEasy    int    `json:"too_easy"`
+}
+
+// total returns the number of ratings for the word.
+func (f wordFeedback) total() int {
+	return f.TooObscure + f.Fine + f.TooEasy
+}
+
+// wordFeedbackView is a word's tally with the shares used to spot unfair words.
+type wordFeedbackView struct {
+	wordFeedback
+	Total           int     `json:"total"`
+	TooObscureShare float64 `json:"too_obscure_share"`
+	TooEasyShare    float64 `json:"too_easy_share"`
+}
+
+// WordFeedback collects post-game ratings per word, persisted to a JSON file, so curators can
+// prune words players find unfair.
+type WordFeedback struct {
+	mu      sync.RWMutex
+	storage Storage
+	path    string
+	words   map[string]*wordFeedback
+}
+
+// newWordFeedback creates an empty tally persisted at path (empty path disables persistence).
+func newWordFeedback(path string) *WordFeedback {
+	return &WordFeedback{storage: DirStorage{}, path: path, words: make(map[string]*wordFeedback)}
+}
+
+// load reads tallies from disk. A missing file is not an error.
+func (wf *WordFeedback) load() error {
+	var words []*wordFeedback
+	if found, err := readJSONFile(wf.storage, wf.path, &words); err != nil || !found {
+		return err
+	}
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+	for _, w := range words {
+		wf.words[w.Word] = w
+	}
+	return nil
+}
+
+// save writes the tallies to disk atomically. Callers must hold wf.mu.
+func (wf *WordFeedback) save() error {
+	words := slices.SortedFunc(maps.Values(wf.words), func(a, b *wordFeedback) int {
+		return cmp.Compare(a.Word, b.Word)
+	})
+	return writeJSONFile(wf.storage, wf.path, words)
+}
+
+// record adds one rating for word; unknown ratings are ignored.
+func (wf *WordFeedback) record(word, rating string) error {
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+	f, ok := wf.words[word]
+	if !ok {
+		f = &wordFeedback{Word: word}
+	}
+	switch rating {
+	case FeedbackTooObscure:
+		f.TooObscure++
+	case FeedbackFine:
+		f.Fine++
+	case FeedbackTooEasy:
+		f.TooEasy++
+	default:
+		return nil
+	}
+	wf.words[word] = f
+	return wf.save()
+}
+
+// weight returns how strongly word is favored by weighted selection: 1 until it has a few
+// ratings, then down to 0.25 as more players call it too obscure.
+func (wf *WordFeedback) weight(word string) float64 {
+	if wf == nil {
+		return 1
+	}
+	wf.mu.RLock()
+	defer wf.mu.RUnlock()
+	f, ok := wf.words[word]
+	if !ok || f.total() < 3 {
+		return 1
+	}
+	return 1 - 0.75*float64(f.TooObscure)/float64(f.total())
+}
+
+// list returns every rated word with at least minVotes ratings, most often called obscure first.
+func (wf *WordFeedback) list(minVotes int) []wordFeedbackView {
+	wf.mu.RLock()
+	defer wf.mu.RUnlock()
+	views := make([]wordFeedbackView, 0, len(wf.words))
+	for _, f := range wf.words {
+		total := f.total()
+		if total == 0 || total < minVotes {
+			continue
+		}
+		views = append(views, wordFeedbackView{
+			wordFeedback:    *f,
+			Total:           total,
+			TooObscureShare: float64(f.TooObscure) / float64(total),
+			TooEasyShare:    float64(f.TooEasy) / float64(total),
+		})
+	}
+	slices.SortFunc(views, func(a, b wordFeedbackView) int {
+		return cmp.Or(
+			cmp.Compare(b.TooObscureShare, a.TooObscureShare),
+			cmp.Compare(b.Total, a.Total),
+			cmp.Compare(a.Word, b.Word),
+		)
+	})
+	return views
+}
+
+// wordFeedbackHandler records a rating of the session's finished game's word. The word comes
+// from the server-side game, so clients can only rate words they have just played, once each.
+func (app *App) wordFeedbackHandler(c *gin.Context) {
+	rating := c.PostForm("rating")
+	if !feedbackRatings[rating] {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "rating must be too_obscure, fine, or too_easy")
+		return
+	}
+
+	sessionID, _ := c.Cookie(app.cookieName(SessionCookieName))
+	app.SessionMutex.Lock()
+	game, exists := app.GameSessions[sessionID]
+	var word string
+	var rated bool
+	if exists && game.GameOver && !game.Custom {
+		word, rated = game.SessionWord, game.Rated
+		game.Rated = true
+	}
+	app.SessionMutex.Unlock()
+
+	switch {
+	case word == "":
+		writeProblem(c, http.StatusNotFound, ErrorCodeNoActiveGame, "no finished game to rate")
+		return
+	case rated:
+		writeProblem(c, http.StatusConflict, ErrorCodeDuplicate, "this word has already been rated")
+		return
+	}
+
+	if err := app.Feedback.record(word, rating); err != nil {
+		logWarn("Failed to save word feedback: %v", err)
+	}
+
+	if htmx.IsRequest(c.Request) {
+		c.HTML(http.StatusOK, "word-feedback", gin.H{"game": game})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rated": true})
+}
+
+// adminWordFeedbackHandler lists word ratings for curation, optionally only words with at
+// least ?min_votes= ratings.
+func (app *App) adminWordFeedbackHandler(c *gin.Context) {
+	minVotes, _ := strconv.Atoi(c.Query("min_votes"))
+	c.JSON(http.StatusOK, gin.H{"words": app.Feedback.list(minVotes)})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestWordFeedbackPersistsAndRanks(t *testing.T) {
+	st := newMemStorage()
+	wf := newWordFeedback("word-feedback.json")
+	wf.storage = st
+	for _, r := range []struct{ word, rating string }{
+		{"CRANE", FeedbackFine}, {"CRANE", FeedbackFine}, {"CRANE", FeedbackTooEasy},
+		{"XYLYL", FeedbackTooObscure}, {"XYLYL", FeedbackTooObscure}, {"XYLYL", FeedbackFine},
+		{"SLATE", FeedbackTooObscure},
+	} {
+		if err := wf.record(r.word, r.rating); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reloaded := newWordFeedback("word-feedback.json")
+	reloaded.storage = st
+	if err := reloaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	got := reloaded.list(2)
+	if len(got) != 2 || got[0].Word != "XYLYL" || got[1].Word != "CRANE" {
+		t.Fatalf("list(2) = %+v, want XYLYL then CRANE", got)
+	}
+	if got[0].Total != 3 || got[0].TooObscure != 2 || got[1].TooEasyShare != 1.0/3 {
+		t.Errorf("unexpected tallies: %+v", got)
+	}
+	if all := reloaded.list(0); len(all) != 3 || all[0].Word != "SLATE" {
+		t.Errorf("list(0) = %+v, want SLATE first", all)
+	}
+}
+
+func TestWordFeedbackHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Feedback = newWordFeedback("")
+	router := gin.New()
+	router.POST(RouteFeedback, app.wordFeedbackHandler)
+
+	rate := func(rating string) int {
+		req := httptest.NewRequest(http.MethodPost, RouteFeedback, strings.NewReader(url.Values{"rating": {rating}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session-123"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	app.GameSessions["session-123"] = &GameState{GameState: engine.GameState{SessionWord: "CRANE"}}
+	if code := rate(FeedbackFine); code != http.StatusNotFound {
+		t.Errorf("rating an unfinished game = %d, want 404", code)
+	}
+	app.GameSessions["session-123"].GameOver = true
+	if code := rate("great"); code != http.StatusBadRequest {
+		t.Errorf("unknown rating = %d, want 400", code)
+	}
+	if code := rate(FeedbackTooObscure); code != http.StatusOK {
+		t.Errorf("first rating = %d, want 200", code)
+	}
+	if code := rate(FeedbackFine); code != http.StatusConflict {
+		t.Errorf("second rating = %d, want 409", code)
+	}
+	if got := app.Feedback.list(0); len(got) != 1 || got[0].TooObscure != 1 || got[0].Fine != 0 {
+		t.Errorf("recorded feedback = %+v", got)
+	}
+}
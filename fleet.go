@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Fleet event types
+const (
+	FleetBlocklistAdd    = "blocklist_add"
+	FleetBlocklistRemove = "blocklist_remove"
+	FleetSchedulePin     = "schedule_pin"
+	FleetScheduleUnpin   = "schedule_unpin"
+	FleetDailyRoll       = "daily_roll"
+	FleetStatsOutcome    = "stats_outcome"
+)
+
+// fleetDialTimeout bounds connecting to the event bus.
+const fleetDialTimeout = 5 * time.Second
+
+// fleetMaxBackoff caps the wait between reconnect attempts.
+const fleetMaxBackoff = 30 * time.Second
+
+// FleetEvent is an admin change made on one instance that the rest of the fleet should apply.
+type FleetEvent struct {
+	Type    string `json:"type"`
+	Origin  string `json:"origin"`
+	Entry   string `json:"entry,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Word    string `json:"word,omitempty"`
+	Won     bool   `json:"won,omitempty"`
+	Guesses int    `json:"guesses,omitempty"`
+	// RequestID is the request that made the change on the origin instance.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// EventBus carries encoded fleet events between instances.
+type EventBus interface {
+	// Publish sends a message to every subscribed instance, including this one.
+	Publish(msg []byte) error
+	// Subscribe delivers messages until the process exits, reconnecting as needed.
+	Subscribe(deliver func([]byte))
+}
+
+// Fleet propagates admin changes to the other replicas of a deployment, so blocklist edits,
+// pinned puzzles, and forced daily rollovers made through one instance apply everywhere.
+// Each instance still writes the change to its own storage, so a shared data directory sees
+// the same write more than once and a per-instance one stays in step.
+type Fleet struct {
+	bus     EventBus
+	origin  string
+	breaker *CircuitBreaker
+}
+
+// newFleet returns a fleet publishing over bus under a fresh instance ID.
+func newFleet(bus EventBus) *Fleet {
+	return &Fleet{bus: bus, origin: uuid.NewString()}
+}
+
+// broadcast publishes event, made by the request in ctx, to the other instances. It is a no-op
+// without a fleet; failures are logged, since the change has already been applied locally.
+func (app *App) broadcast(ctx context.Context, event FleetEvent) {
+	if app.Fleet == nil {
+		return
+	}
+	event.Origin = app.Fleet.origin
+	event.RequestID = requestIDFrom(ctx)
+	msg, err := json.Marshal(event)
+	if err == nil {
+		err = app.Fleet.breaker.call(context.Background(), func(context.Context) error {
+			return app.Fleet.bus.Publish(msg)
+		})
+	}
+	if err != nil {
+		app.incMetric(MetricFleetPublishFailures)
+		logWarn("Failed to publish %s fleet event: %v", event.Type, err)
+		return
+	}
+	app.incMetric(MetricFleetEventsPublished)
+}
+
+// listenFleet applies events from other instances for the life of the process.
+func (app *App) listenFleet() {
+	app.Fleet.bus.Subscribe(func(msg []byte) {
+		var event FleetEvent
+		if err := json.Unmarshal(msg, &event); err != nil {
+			logWarn("Ignoring malformed fleet event: %v", err)
+			return
+		}
+		if event.Origin == app.Fleet.origin {
+			return
+		}
+		if err := app.applyFleetEvent(event); err != nil {
+			logWarn("[request_id=%v] Failed to apply %s fleet event: %v", event.RequestID, event.Type, err)
+			return
+		}
+		app.incMetric(MetricFleetEventsReceived)
+	})
+}
+
+// applyFleetEvent makes the change described by an event from another instance.
+func (app *App) applyFleetEvent(event FleetEvent) error {
+	switch event.Type {
+	case FleetBlocklistAdd:
+		_, err := app.Blocklist.add(event.Entry)
+		return err
+	case FleetBlocklistRemove:
+		_, err := app.Blocklist.remove(event.Entry)
+		return err
+	case FleetSchedulePin, FleetScheduleUnpin:
+		date, err := parsePuzzleDate(event.Date)
+		if err != nil {
+			return err
+		}
+		if event.Type == FleetScheduleUnpin {
+			_, err = app.Calendar.unpin(date)
+			return err
+		}
+		return app.Calendar.pin(date, event.Word)
+	case FleetDailyRoll:
+		app.Daily.forceRoll(app.now())
+		return nil
+	case FleetStatsOutcome:
+		app.Stats.record(gameOutcome{Date: event.Date, Won: event.Won, Guesses: event.Guesses})
+		return nil
+	}
+	return fmt.Errorf("unknown event type %q", event.Type)
+}
+
+// fleetChannel returns the pub/sub channel for a namespace, keeping namespaced deployments
+// that share a Redis server apart.
+func fleetChannel(namespace string) string {
+	if namespace == "" {
+		return "vortludo:events"
+	}
+	return "vortludo:" + namespace + ":events"
+}
+
+// RedisBus is an EventBus over Redis pub/sub, speaking just enough of the Redis protocol to
+// publish and subscribe. Publishes share one connection; the subscriber holds its own.
+type RedisBus struct {
+	addr     string
+	user     string
+	password string
+	tls      bool
+	channel  string
+
+	mu   sync.Mutex
+	conn *redisConn
+}
+
+// redisConn is a connection to Redis with a buffered reader for replies.
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// newRedisBus parses a redis:// or rediss:// URL, with optional user and password, into a
+// bus on channel. An empty URL returns nil, which disables the fleet.
+func newRedisBus(rawURL, channel string) (*RedisBus, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	bus := &RedisBus{addr: u.Host, tls: u.Scheme == "rediss", channel: channel}
+	if u.Port() == "" {
+		bus.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if password, ok := u.User.Password(); ok {
+		bus.user, bus.password = u.User.Username(), password
+	}
+	return bus, nil
+}
+
+// dial connects and authenticates.
+func (b *RedisBus) dial() (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: fleetDialTimeout}
+	var conn net.Conn
+	var err error
+	if b.tls {
+		conn, err = tls.DialWithDialer(dialer, "tcp", b.addr, nil)
+	} else {
+		conn, err = dialer.Dial("tcp", b.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rc := &redisConn{Conn: conn, r: bufio.NewReader(conn)}
+	if b.password != "" {
+		args := []string{"AUTH", b.password}
+		if b.user != "" {
+			args = []string{"AUTH", b.user, b.password}
+		}
+		if _, err := rc.do(args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+	}
+	return rc, nil
+}
+
+// Publish sends msg on the bus channel, reconnecting once if the shared connection broke.
+func (b *RedisBus) Publish(msg []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var err error
+	for range 2 {
+		if b.conn == nil {
+			if b.conn, err = b.dial(); err != nil {
+				return err
+			}
+		}
+		b.conn.SetDeadline(time.Now().Add(fleetDialTimeout))
+		if _, err = b.conn.do("PUBLISH", b.channel, string(msg)); err == nil {
+			return nil
+		}
+		b.conn.Close()
+		b.conn = nil
+	}
+	return err
+}
+
+// Subscribe delivers messages published on the bus channel, reconnecting with backoff.
+func (b *RedisBus) Subscribe(deliver func([]byte)) {
+	backoff := time.Second
+	for {
+		err := b.subscribeOnce(deliver, func() { backoff = time.Second })
+		logWarn("Fleet subscription lost, retrying in %v: %v", backoff, err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, fleetMaxBackoff)
+	}
+}
+
+// subscribeOnce subscribes on a new connection and delivers messages until it fails.
+// subscribed is called once the subscription is confirmed.
+func (b *RedisBus) subscribeOnce(deliver func([]byte), subscribed func()) error {
+	conn, err := b.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.send("SUBSCRIBE", b.channel); err != nil {
+		return err
+	}
+	for {
+		reply, err := conn.read()
+		if err != nil {
+			return err
+		}
+		parts, _ := reply.([]any)
+		if len(parts) != 3 {
+			continue
+		}
+		switch kind, _ := parts[0].(string); kind {
+		case "subscribe":
+			subscribed()
+		case "message":
+			if payload, ok := parts[2].(string); ok {
+				deliver([]byte(payload))
+			}
+		}
+	}
+}
+
+// do sends a command and reads its reply.
+func (c *redisConn) do(args ...string) (any, error) {
+	if err := c.send(args...); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// send writes a command as an array of bulk strings.
+func (c *redisConn) send(args ...string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := io.WriteString(c.Conn, b.String())
+	return err
+}
+
+// read parses one reply: simple and bulk strings become string, integers int64, arrays []any,
+// and a nil bulk string or array nil. Error replies are returned as errors.
+func (c *redisConn) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty redis reply")
+	}
+	kind, rest := line[0], line[1:]
+	switch kind {
+	case '+':
+		return rest, nil
+	case '-':
+		return nil, errors.New(rest)
+	case ':':
+		return strconv.ParseInt(rest, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected redis reply %q", line)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Form limits
+const (
+	// MaxFormBodyBytes caps the size of any form POST body.
+	MaxFormBodyBytes = 64 << 10
+	// MaxProgressTokenLen caps the progress token, enough for a bitmap over 20k words.
+	MaxProgressTokenLen = 4096
+)
+
+// formFieldRule describes the accepted shape of a single form field.
+type formFieldRule struct {
+	MaxLen  int
+	Pattern *regexp.Regexp
+}
+
+// formFieldRules lists the limits for known form fields. Fields not listed are only
+// bounded by MaxFormBodyBytes; handlers still validate their meaning.
+var formFieldRules = map[string]formFieldRule{
+	"guess":    {MaxLen: 32, Pattern: regexp.MustCompile(`^[A-Za-z\s]*$`)},
+	"progress": {MaxLen: MaxProgressTokenLen, Pattern: regexp.MustCompile(`^[A-Za-z0-9_-]*$`)},
+	"pack":     {MaxLen: 64, Pattern: regexp.MustCompile(`^[a-z0-9-]*$`)},
+	"word":     {MaxLen: 32, Pattern: regexp.MustCompile(`^[A-Za-z\s]*$`)},
+	"hint":     {MaxLen: maxSuggestionHintLength * 4},
+	"note":     {MaxLen: maxSuggestionNoteLength * 4},
+	"row":      {MaxLen: 2, Pattern: regexp.MustCompile(`^[0-9]*$`)},
+	"code":     {MaxLen: 16, Pattern: regexp.MustCompile(`^[A-Za-z0-9\s]*$`)},
+	"name":     {MaxLen: MaxRoomMemberName * 4},
+	"email":    {MaxLen: maxEmailLength},
+	"endpoint": {MaxLen: maxPushEndpointLen},
+}
+
+var errFormTooLarge = errors.New("form body too large")
+
+// validateFormField checks a single value against its rule, if any.
+func validateFormField(name, value string) error {
+	rule, ok := formFieldRules[name]
+	if !ok {
+		return nil
+	}
+	if len(value) > rule.MaxLen {
+		return fmt.Errorf("field %s exceeds %d bytes", name, rule.MaxLen)
+	}
+	if rule.Pattern != nil && !rule.Pattern.MatchString(value) {
+		return fmt.Errorf("field %s contains invalid characters", name)
+	}
+	return nil
+}
+
+// parseLimitedForm reads a form POST body of at most MaxFormBodyBytes and
+// validates every known field before any handler parses it.
+func parseLimitedForm(c *gin.Context) error {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxFormBodyBytes)
+	var err error
+	if c.ContentType() == gin.MIMEMultipartPOSTForm {
+		err = c.Request.ParseMultipartForm(MaxFormBodyBytes)
+	} else {
+		err = c.Request.ParseForm()
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return errFormTooLarge
+		}
+		return err
+	}
+	for name, values := range c.Request.PostForm {
+		for _, v := range values {
+			if err := validateFormField(name, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// formLimitMiddleware rejects oversized or malformed form POSTs before CSRF checks and handlers run.
+// Admin routes take JSON bodies and are skipped.
+func (app *App) formLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || strings.HasPrefix(c.Request.URL.Path, RouteAdmin+"/") {
+			c.Next()
+			return
+		}
+		if err := parseLimitedForm(c); err != nil {
+			app.incMetric(MetricRejectedForms)
+			logWarn("Rejected form for %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+			if errors.Is(err, errFormTooLarge) {
+				writeProblem(c, http.StatusRequestEntityTooLarge, ErrorCodePayloadTooLarge, "request too large")
+				return
+			}
+			writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+		c.Next()
+	}
+}
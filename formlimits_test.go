@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateFormField(t *testing.T) {
+	tests := []struct {
+		name, value string
+		ok          bool
+	}{
+		{"guess", "crane", true},
+		{"guess", "cr<ne", false},
+		{"guess", strings.Repeat("a", 33), false},
+		{"progress", "AQAAAAAAAAAA_-x", true},
+		{"progress", `["CRANE"]`, false},
+		{"progress", strings.Repeat("A", MaxProgressTokenLen+1), false},
+		{"pack", "animals", true},
+		{"pack", "../etc", false},
+		{"unlisted", strings.Repeat("x", 1000), true},
+	}
+	for _, tt := range tests {
+		if err := validateFormField(tt.name, tt.value); (err == nil) != tt.ok {
+			t.Errorf("validateFormField(%s, %.20q) = %v, want ok=%v", tt.name, tt.value, err, tt.ok)
+		}
+	}
+}
+
+func TestFormLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{Metrics: newMetrics()}
+	router := gin.New()
+	router.Use(app.formLimitMiddleware())
+	router.POST("/guess", func(c *gin.Context) { c.String(http.StatusOK, c.PostForm("guess")) })
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/guess", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post(url.Values{"guess": {"crane"}}.Encode()); w.Code != http.StatusOK || w.Body.String() != "crane" {
+		t.Errorf("Expected valid form to pass, got %d %q", w.Code, w.Body.String())
+	}
+	if w := post(url.Values{"guess": {"<script>"}}.Encode()); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid characters, got %d", w.Code)
+	}
+	if w := post("other=" + strings.Repeat("x", MaxFormBodyBytes)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for oversized body, got %d", w.Code)
+	}
+	if got := app.Metrics.Get(MetricRejectedForms).String(); got != "2" {
+		t.Errorf("Expected 2 rejected forms, got %s", got)
+	}
+}
@@ -5,14 +5,19 @@ import (
 	"crypto/rand"
 	"math/big"
 	"slices"
-	"time"
 
+	"github.com/mooship/vortludo/engine"
 	"github.com/samber/lo"
 )
 
-// getRandomWordEntry returns a random WordEntry from the loaded word list.
+// getRandomWordEntry returns a random WordEntry from the default word list.
 func (app *App) getRandomWordEntry(ctx context.Context) WordEntry {
-	reqID, _ := ctx.Value(requestIDKey).(string)
+	return randomSelector{}.Select(ctx, app.lists().WordList, selectionRequest{})
+}
+
+// pickRandomWordEntry returns a random WordEntry from words.
+func pickRandomWordEntry(ctx context.Context, words []WordEntry) WordEntry {
+	reqID := requestIDFrom(ctx)
 
 	select {
 	case <-ctx.Done():
@@ -21,89 +26,80 @@ func (app *App) getRandomWordEntry(ctx context.Context) WordEntry {
 		} else {
 			logWarn("getRandomWordEntry cancelled: %v", ctx.Err())
 		}
-		return app.WordList[0]
+		return words[0]
 	default:
 	}
 
-	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(app.WordList))))
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
 	if err != nil {
 		if reqID != "" {
 			logWarn("[request_id=%v] Error generating random number: %v, using fallback", reqID, err)
 		} else {
 			logWarn("Error generating random number: %v, using fallback", err)
 		}
-		return app.WordList[0]
+		return words[0]
 	}
 
 	if reqID != "" {
-		logInfo("[request_id=%v] Selected random word index: %d", reqID, n.Int64())
+		logDebug("[request_id=%v] Selected random word index: %d", reqID, n.Int64())
 	}
-	return app.WordList[n.Int64()]
+	return words[n.Int64()]
 }
 
-// getRandomWordEntryExcluding returns a random WordEntry excluding completed words.
-// Returns the selected word and a boolean indicating if all words are completed (reset needed).
-func (app *App) getRandomWordEntryExcluding(ctx context.Context, completedWords []string) (WordEntry, bool) {
-	reqID, _ := ctx.Value(requestIDKey).(string)
+// selectWordEntry picks a WordEntry from pack excluding completed words, using the word selector
+// for the request's mode. Returns the selected word and a boolean indicating if all words are
+// completed (reset needed).
+func (app *App) selectWordEntry(ctx context.Context, pack *WordPack, completedWords []string, req selectionRequest) (WordEntry, bool) {
+	reqID := requestIDFrom(ctx)
+	selector := app.selector(req.Mode)
 
-	if len(completedWords) == 0 {
-		return app.getRandomWordEntry(ctx), false
+	if len(completedWords) == 0 || req.Mode == SelectionModeDaily {
+		return selector.Select(ctx, pack.Words, req), false
 	}
 
-	availableWords := lo.Filter(app.WordList, func(entry WordEntry, _ int) bool {
+	availableWords := lo.Filter(pack.Words, func(entry WordEntry, _ int) bool {
 		return !slices.Contains(completedWords, entry.Word)
 	})
 
 	if len(availableWords) == 0 {
 		if reqID != "" {
-			logInfo("[request_id=%v] All words completed, reset needed. Total words: %d, Completed: %d", reqID, len(app.WordList), len(completedWords))
-		} else {
-			logInfo("All words completed, reset needed. Total words: %d, Completed: %d", len(app.WordList), len(completedWords))
-		}
-		return app.getRandomWordEntry(ctx), true
-	}
-
-	select {
-	case <-ctx.Done():
-		if reqID != "" {
-			logWarn("[request_id=%v] getRandomWordEntryExcluding cancelled: %v", reqID, ctx.Err())
-		} else {
-			logWarn("getRandomWordEntryExcluding cancelled: %v", ctx.Err())
-		}
-		return availableWords[0], false
-	default:
-	}
-
-	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(availableWords))))
-	if err != nil {
-		if reqID != "" {
-			logWarn("[request_id=%v] Error generating random number for filtered words: %v, using fallback", reqID, err)
+			logInfo("[request_id=%v] All words completed in pack %s, reset needed. Total words: %d, Completed: %d", reqID, pack.Name, len(pack.Words), len(completedWords))
 		} else {
-			logWarn("Error generating random number for filtered words: %v, using fallback", err)
+			logInfo("All words completed in pack %s, reset needed. Total words: %d, Completed: %d", pack.Name, len(pack.Words), len(completedWords))
 		}
-		return availableWords[0], false
+		return selector.Select(ctx, pack.Words, req), true
 	}
 
-	selected := availableWords[n.Int64()]
+	selected := selector.Select(ctx, availableWords, req)
 	if reqID != "" {
-		logInfo("[request_id=%v] Selected word from %d available options (excluding %d completed): %s", reqID, len(availableWords), len(completedWords), selected.Word)
+		logDebug("[request_id=%v] Selected word from %d available options (excluding %d completed): %s", reqID, len(availableWords), len(completedWords), redactWord(selected.Word))
 	} else {
-		logInfo("Selected word from %d available options (excluding %d completed): %s", len(availableWords), len(completedWords), selected.Word)
+		logDebug("Selected word from %d available options (excluding %d completed): %s", len(availableWords), len(completedWords), redactWord(selected.Word))
 	}
 
 	return selected, false
 }
 
-// getHintForWord returns the hint for a given word, or an empty string if not found.
+// getHintForWord returns the hint for a given word of the default pack, or an empty string if
+// not found.
 func (app *App) getHintForWord(wordValue string) string {
+	return app.hintIn(app.lists(), DefaultPackName, wordValue)
+}
+
+// hintIn returns the hint for a word played from pack in one version of the word lists. A word
+// missing from pack gets the hint of the first pack listing it.
+func (app *App) hintIn(lists *WordLists, pack, wordValue string) string {
 	if wordValue == "" {
 		return ""
 	}
-	hint, ok := app.HintMap[wordValue]
+	if hint, ok := lists.PackHints[pack][wordValue]; ok {
+		return hint
+	}
+	hint, ok := lists.HintMap[wordValue]
 	if ok {
 		return hint
 	}
-	logWarn("Hint not found for word: %s", wordValue)
+	logWarn("Hint not found for word: %s", redactWord(wordValue))
 	return ""
 }
 
@@ -119,165 +115,126 @@ func (app *App) getTargetWord(ctx context.Context, game *GameState) string {
 	if game.SessionWord == "" {
 		selectedEntry := app.getRandomWordEntry(ctx)
 		game.SessionWord = selectedEntry.Word
-		logWarn("SessionWord was empty, assigned random word: %s", selectedEntry.Word)
+		logWarn("SessionWord was empty, assigned random word: %s", redactWord(selectedEntry.Word))
 	}
 	return game.SessionWord
 }
 
-// updateGameState updates the game state after a guess, handling win/lose logic.
+// updateGameState updates the game state after a guess, handling win/lose logic. The guess is
+// applied under SessionMutex so a journal flush never snapshots a half-applied guess.
 func (app *App) updateGameState(ctx context.Context, game *GameState, guess, targetWord string, result []GuessResult, isInvalid bool) {
-	reqID, _ := ctx.Value(requestIDKey).(string)
-
-	if game.CurrentRow >= MaxGuesses {
-		return
-	}
-
-	game.Guesses[game.CurrentRow] = result
-	game.GuessHistory = append(game.GuessHistory, guess)
-	game.LastAccessTime = time.Now()
+	reqID := requestIDFrom(ctx)
 
-	if !isInvalid && guess == targetWord {
-		game.Won = true
-		game.GameOver = true
+	app.SessionMutex.Lock()
+	outcome := game.ApplyGuess(guess, targetWord, result, !isInvalid)
+	app.SessionMutex.Unlock()
+	switch outcome {
+	case engine.OutcomeWon:
 		if reqID != "" {
-			logInfo("[request_id=%v] Player won! Target word was: %s", reqID, targetWord)
+			logInfo("[request_id=%v] Player won! Target word was: %s", reqID, redactWord(targetWord))
 		} else {
-			logInfo("Player won! Target word was: %s", targetWord)
+			logInfo("Player won! Target word was: %s", redactWord(targetWord))
 		}
-	} else {
-		game.CurrentRow++
-
-		if game.CurrentRow >= MaxGuesses {
-			game.GameOver = true
-			if reqID != "" {
-				logInfo("[request_id=%v] Player lost. Target word was: %s", reqID, targetWord)
-			} else {
-				logInfo("Player lost. Target word was: %s", targetWord)
-			}
+	case engine.OutcomeLost:
+		if reqID != "" {
+			logInfo("[request_id=%v] Player lost. Target word was: %s", reqID, redactWord(targetWord))
+		} else {
+			logInfo("Player lost. Target word was: %s", redactWord(targetWord))
 		}
 	}
-
-	if game.GameOver {
-		game.TargetWord = targetWord
-	}
 }
 
 // checkGuess compares a guess to the target word and returns per-letter results.
 func checkGuess(guess, target string) []GuessResult {
-	result := make([]GuessResult, WordLength)
-	var targetCopy []rune
-	var pooledBuf []rune
-	usedPool := false
-	if appInstance := getAppInstance(); appInstance != nil && appInstance.RuneBufPool != nil {
-		if v := appInstance.RuneBufPool.Get(); v != nil {
-			if ptr, ok := v.(*[]rune); ok && ptr != nil {
-				pooledBuf = *ptr
-				targetCopy = pooledBuf[:WordLength]
-				copy(targetCopy, []rune(target))
-				usedPool = true
-			} else {
-				targetCopy = []rune(target)
-			}
-		} else {
-			targetCopy = []rune(target)
-		}
-	} else {
-		targetCopy = []rune(target)
-	}
-
-	for i := range WordLength {
-		if guess[i] == target[i] {
-			result[i] = GuessResult{Letter: string(guess[i]), Status: GuessStatusCorrect}
-			targetCopy[i] = ' '
-		}
-	}
+	return engine.CheckGuess(guess, target)
+}
 
-	for i := range WordLength {
-		if result[i].Status == "" {
-			letter := string(guess[i])
-			result[i].Letter = letter
+// isValidWord returns true if the word is in the playable word set.
+func (app *App) isValidWord(word string) bool {
+	return app.lists().isValid(word)
+}
 
-			found := false
-			for j := range WordLength {
-				if targetCopy[j] == rune(guess[i]) {
-					result[i].Status = GuessStatusPresent
-					targetCopy[j] = ' '
-					found = true
-					break
-				}
-			}
+// isAcceptedWord returns true if the word is an accepted guess in mode, with the mode's overlay
+// layered over the accepted guess set. An empty mode checks the accepted guess set alone.
+func (app *App) isAcceptedWord(mode, word string) bool {
+	return app.lists().isAccepted(mode, word)
+}
 
-			if !found {
-				result[i].Status = GuessStatusAbsent
-			}
-		}
-	}
+// newGame returns a fresh game for the given target word.
+func newGame(target string) *GameState {
+	return &GameState{GameState: *engine.NewGame(target)}
+}
 
-	if usedPool {
-		for i := range pooledBuf {
-			pooledBuf[i] = 0
-		}
-		if appInstance := getAppInstance(); appInstance != nil && appInstance.RuneBufPool != nil {
-			buf := pooledBuf
-			appInstance.RuneBufPool.Put(&buf)
-		}
-	}
+// cloneGame returns a copy of game that shares none of its slices. Callers must hold
+// SessionMutex, since guesses are applied under it.
+func cloneGame(game *GameState) *GameState {
+	clone := *game
+	clone.Guesses = make([][]GuessResult, len(game.Guesses))
+	for i, row := range game.Guesses {
+		clone.Guesses[i] = slices.Clone(row)
+	}
+	clone.GuessHistory = slices.Clone(game.GuessHistory)
+	clone.Revealed = slices.Clone(game.Revealed)
+	clone.Completed = slices.Clone(game.Completed)
+	return &clone
+}
 
-	return result
+// addRow appends a row to the board, allowing one more guess.
+func (g *GameState) addRow() {
+	g.AddRow()
+	g.ExtraRows++
 }
 
-// isValidWord returns true if the word is in the playable word set.
-func (app *App) isValidWord(word string) bool {
-	_, ok := app.WordSet[word]
-	return ok
+// reveal marks the first position of target not yet solved on the board or revealed, and
+// returns it, or -1 when every position is already known.
+func (g *GameState) reveal(target string) int {
+	for i := range min(len(target), WordLength) {
+		if slices.Contains(g.Revealed, i) || g.solvedAt(i) {
+			continue
+		}
+		g.Revealed = append(g.Revealed, i)
+		return i
+	}
+	return -1
 }
 
-// isAcceptedWord returns true if the word is in the accepted guess set.
-func (app *App) isAcceptedWord(word string) bool {
-	_, ok := app.AcceptedWordSet[word]
-	return ok
+// solvedAt reports whether any guess on the board has a correct letter at position i.
+func (g *GameState) solvedAt(i int) bool {
+	for _, row := range g.Guesses {
+		if i < len(row) && row[i].Status == GuessStatusCorrect {
+			return true
+		}
+	}
+	return false
 }
 
 // createNewGame initializes a new GameState for a session and stores it.
 func (app *App) createNewGame(ctx context.Context, sessionID string) *GameState {
 	selectedEntry := app.getRandomWordEntry(ctx)
-	logInfo("New game created for session %s with word: %s (hint: %s)", sessionID, selectedEntry.Word, selectedEntry.Hint)
-	guesses := lo.Times(MaxGuesses, func(_ int) []GuessResult {
-		return lo.Times(WordLength, func(_ int) GuessResult { return GuessResult{} })
-	})
-	game := &GameState{
-		Guesses:        guesses,
-		CurrentRow:     0,
-		GameOver:       false,
-		Won:            false,
-		TargetWord:     "",
-		SessionWord:    selectedEntry.Word,
-		GuessHistory:   []string{},
-		LastAccessTime: time.Now(),
-	}
-	app.GameSessions[sessionID] = game
+	logInfo("New game created for session %s with word: %s (hint: %s)", redactSession(sessionID), redactWord(selectedEntry.Word), redactWord(selectedEntry.Hint))
+	game := newGame(selectedEntry.Word)
+	game.Pack = DefaultPackName
+	app.pinWords(game)
+	app.saveGameState(sessionID, game)
+	app.Journal.start(ctx, sessionID, game)
+	app.recordExperiments(sessionID, "started")
 	return game
 }
 
-// createNewGameWithCompletedWords initializes a new GameState excluding completed words.
-func (app *App) createNewGameWithCompletedWords(ctx context.Context, sessionID string, completedWords []string) (*GameState, bool) {
-	selectedEntry, needsReset := app.getRandomWordEntryExcluding(ctx, completedWords)
-	logInfo("New game created for session %s with word: %s (hint: %s, completed words: %d, needs reset: %v)",
-		sessionID, selectedEntry.Word, selectedEntry.Hint, len(completedWords), needsReset)
-
-	guesses := lo.Times(MaxGuesses, func(_ int) []GuessResult {
-		return lo.Times(WordLength, func(_ int) GuessResult { return GuessResult{} })
-	})
-	game := &GameState{
-		Guesses:        guesses,
-		CurrentRow:     0,
-		GameOver:       false,
-		Won:            false,
-		TargetWord:     "",
-		SessionWord:    selectedEntry.Word,
-		GuessHistory:   []string{},
-		LastAccessTime: time.Now(),
-	}
-	app.GameSessions[sessionID] = game
+// createNewGameWithCompletedWords initializes a new GameState from pack excluding completed words,
+// chosen by the word selector for req.
+func (app *App) createNewGameWithCompletedWords(ctx context.Context, sessionID string, pack *WordPack, completedWords []string, req selectionRequest) (*GameState, bool) {
+	selectedEntry, needsReset := app.selectWordEntry(ctx, pack, completedWords, req)
+	logInfo("New game created for session %s with word: %s (pack: %s, hint: %s, completed words: %d, needs reset: %v)",
+		redactSession(sessionID), redactWord(selectedEntry.Word), pack.Name, redactWord(selectedEntry.Hint), len(completedWords), needsReset)
+	game := newGame(selectedEntry.Word)
+	game.Pack = pack.Name
+	app.pinWords(game)
+	if !needsReset {
+		game.Completed = pack.completionBitmap(completedWords)
+	}
+	app.saveGameState(sessionID, game)
+	app.Journal.start(ctx, sessionID, game)
+	app.recordExperiments(sessionID, "started")
 	return game, needsReset
 }
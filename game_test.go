@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"testing"
+
+	"github.com/mooship/vortludo/engine"
 )
 
 func testAppWithWords(words []WordEntry) *App {
@@ -15,11 +17,13 @@ func testAppWithWords(words []WordEntry) *App {
 		hintMap[w.Word] = w.Hint
 	}
 	return &App{
-		WordList:        words,
-		WordSet:         wordSet,
-		AcceptedWordSet: acceptedSet,
-		HintMap:         hintMap,
-		GameSessions:    make(map[string]*GameState),
+		Words: newWordRegistry(&WordLists{
+			WordList:        words,
+			WordSet:         wordSet,
+			AcceptedWordSet: acceptedSet,
+			HintMap:         hintMap,
+		}),
+		GameSessions: make(map[string]*GameState),
 	}
 }
 
@@ -49,11 +53,11 @@ func TestGetRandomWordEntryExcluding(t *testing.T) {
 	words := []WordEntry{{Word: "apple", Hint: "fruit"}, {Word: "table", Hint: "furniture"}}
 	app := testAppWithWords(words)
 	ctx := dummyContext()
-	w, reset := app.getRandomWordEntryExcluding(ctx, []string{"apple"})
+	w, reset := app.selectWordEntry(ctx, app.wordPack(DefaultPackName), []string{"apple"}, selectionRequest{})
 	if w.Word != "table" || reset {
 		t.Errorf("Expected table, got %v, reset=%v", w.Word, reset)
 	}
-	w, reset = app.getRandomWordEntryExcluding(ctx, []string{"apple", "table"})
+	w, reset = app.selectWordEntry(ctx, app.wordPack(DefaultPackName), []string{"apple", "table"}, selectionRequest{})
 	if reset != true {
 		t.Error("Expected reset=true when all words completed")
 	}
@@ -85,7 +89,7 @@ func TestGetTargetWord(t *testing.T) {
 	words := []WordEntry{{Word: "apple", Hint: "fruit"}}
 	app := testAppWithWords(words)
 	ctx := dummyContext()
-	game := &GameState{SessionWord: ""}
+	game := &GameState{GameState: engine.GameState{SessionWord: ""}}
 	w := app.getTargetWord(ctx, game)
 	if w != "apple" {
 		t.Errorf("Expected 'apple', got %v", w)
@@ -102,13 +106,15 @@ func TestUpdateGameState_WinLose(t *testing.T) {
 	app := testAppWithWords(words)
 	ctx := dummyContext()
 	game := &GameState{
-		Guesses:      make([][]GuessResult, MaxGuesses),
-		CurrentRow:   0,
-		GameOver:     false,
-		Won:          false,
-		TargetWord:   "",
-		SessionWord:  "apple",
-		GuessHistory: []string{},
+		GameState: engine.GameState{
+			Guesses:      make([][]GuessResult, MaxGuesses),
+			CurrentRow:   0,
+			GameOver:     false,
+			Won:          false,
+			TargetWord:   "",
+			SessionWord:  "apple",
+			GuessHistory: []string{},
+		},
 	}
 	result := []GuessResult{{Letter: "a", Status: GuessStatusCorrect}, {Letter: "p", Status: GuessStatusCorrect}, {Letter: "p", Status: GuessStatusCorrect}, {Letter: "l", Status: GuessStatusCorrect}, {Letter: "e", Status: GuessStatusCorrect}}
 	app.updateGameState(ctx, game, "apple", "apple", result, false)
@@ -117,13 +123,15 @@ func TestUpdateGameState_WinLose(t *testing.T) {
 	}
 	// Test lose
 	game = &GameState{
-		Guesses:      make([][]GuessResult, MaxGuesses),
-		CurrentRow:   MaxGuesses - 1,
-		GameOver:     false,
-		Won:          false,
-		TargetWord:   "",
-		SessionWord:  "apple",
-		GuessHistory: []string{},
+		GameState: engine.GameState{
+			Guesses:      make([][]GuessResult, MaxGuesses),
+			CurrentRow:   MaxGuesses - 1,
+			GameOver:     false,
+			Won:          false,
+			TargetWord:   "",
+			SessionWord:  "apple",
+			GuessHistory: []string{},
+		},
 	}
 	app.updateGameState(ctx, game, "wrong", "apple", result, false)
 	if !game.GameOver || game.Won {
@@ -159,6 +167,22 @@ func TestCheckGuess(t *testing.T) {
 	}
 }
 
+func TestAddRowAndReveal(t *testing.T) {
+	g := newGame("APPLE")
+	g.addRow()
+	if g.ExtraRows != 1 || g.Rows() != MaxGuesses+1 {
+		t.Fatalf("after addRow: %d extra rows, %d rows", g.ExtraRows, g.Rows())
+	}
+	g.ApplyGuess("TABLE", "APPLE", checkGuess("TABLE", "APPLE"), true)
+
+	// TABLE solves positions 3 and 4 (L, E), so reveals skip them.
+	for _, want := range []int{0, 1, 2, -1} {
+		if got := g.reveal("APPLE"); got != want {
+			t.Errorf("reveal() = %d, want %d", got, want)
+		}
+	}
+}
+
 func TestIsValidWordAndIsAcceptedWord(t *testing.T) {
 	words := []WordEntry{{Word: "apple", Hint: "fruit"}}
 	app := testAppWithWords(words)
@@ -168,10 +192,10 @@ func TestIsValidWordAndIsAcceptedWord(t *testing.T) {
 	if app.isValidWord("table") {
 		t.Error("table should not be valid")
 	}
-	if !app.isAcceptedWord("apple") {
+	if !app.isAcceptedWord("", "apple") {
 		t.Error("apple should be accepted")
 	}
-	if app.isAcceptedWord("table") {
+	if app.isAcceptedWord("", "table") {
 		t.Error("table should not be accepted")
 	}
 }
@@ -196,12 +220,18 @@ func TestCreateNewGameWithCompletedWords(t *testing.T) {
 	words := []WordEntry{{Word: "apple", Hint: "fruit"}, {Word: "table", Hint: "furniture"}}
 	app := testAppWithWords(words)
 	ctx := dummyContext()
-	game, reset := app.createNewGameWithCompletedWords(ctx, "sess2", []string{"apple"})
+	game, reset := app.createNewGameWithCompletedWords(ctx, "sess2", app.wordPack(DefaultPackName), []string{"apple"}, selectionRequest{})
 	if game.SessionWord != "table" || reset {
 		t.Error("Should select 'table' and reset=false")
 	}
-	_, reset = app.createNewGameWithCompletedWords(ctx, "sess3", []string{"apple", "table"})
+	_, reset = app.createNewGameWithCompletedWords(ctx, "sess3", app.wordPack(DefaultPackName), []string{"apple", "table"}, selectionRequest{})
 	if !reset {
 		t.Error("Should set reset=true when all words completed")
 	}
 }
+
+func BenchmarkCheckGuess(b *testing.B) {
+	for b.Loop() {
+		checkGuess("CRANE", "TRACE")
+	}
+}
@@ -1,4 +1,4 @@
-module vortludo
+module github.com/mooship/vortludo
 
 go 1.25
 
@@ -8,7 +8,10 @@ require (
 	go.eigsys.de/gin-cachecontrol/v2 v2.3.0
 )
 
-require github.com/samber/lo v1.51.0
+require (
+	github.com/samber/lo v1.51.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+)
 
 require (
 	github.com/goccy/go-yaml v1.18.0 // indirect
@@ -25,7 +28,6 @@ require (
 	github.com/bytedance/sonic/loader v0.3.0 // indirect
 	github.com/cloudwego/base64x v0.1.6 // indirect
 	github.com/gabriel-vasile/mimetype v1.4.9 // indirect
-	github.com/gin-contrib/gzip v1.2.3
 	github.com/gin-contrib/sse v1.1.0 // indirect
 	github.com/go-playground/locales v0.14.1 // indirect
 	github.com/go-playground/universal-translator v0.18.1 // indirect
@@ -44,7 +46,7 @@ require (
 	golang.org/x/arch v0.20.0 // indirect
 	golang.org/x/crypto v0.40.0 // indirect
 	golang.org/x/net v0.42.0 // indirect
-	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/sys v0.35.0
 	golang.org/x/text v0.27.0 // indirect
 	golang.org/x/time v0.13.0
 	google.golang.org/protobuf v1.36.9 // indirect
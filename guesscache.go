@@ -0,0 +1,80 @@
+package main
+
+import (
+	"container/list"
+	"slices"
+	"sync"
+)
+
+// guessCacheKey identifies one guess evaluated against one target.
+type guessCacheKey struct {
+	Guess  string
+	Target string
+}
+
+// guessCacheEntry is a cached evaluation, stored in the recency list.
+type guessCacheEntry struct {
+	key    guessCacheKey
+	result []GuessResult
+}
+
+// GuessCache is a fixed-size LRU of guess evaluations. Popular openers are checked against the
+// same target over and over, so caching the results saves the evaluation on most of them.
+type GuessCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[guessCacheKey]*list.Element
+}
+
+// newGuessCache returns a cache holding up to size evaluations, or nil when size is not
+// positive, which disables caching.
+func newGuessCache(size int) *GuessCache {
+	if size <= 0 {
+		return nil
+	}
+	return &GuessCache{size: size, order: list.New(), entries: make(map[guessCacheKey]*list.Element, size)}
+}
+
+// check returns the evaluation of guess against target and whether it came from the cache.
+// Callers get their own copy, since results are stored on game boards.
+func (gc *GuessCache) check(guess, target string) ([]GuessResult, bool) {
+	if gc == nil {
+		return checkGuess(guess, target), false
+	}
+	key := guessCacheKey{Guess: guess, Target: target}
+	gc.mu.Lock()
+	if el, ok := gc.entries[key]; ok {
+		gc.order.MoveToFront(el)
+		result := slices.Clone(el.Value.(*guessCacheEntry).result)
+		gc.mu.Unlock()
+		return result, true
+	}
+	gc.mu.Unlock()
+
+	result := checkGuess(guess, target)
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	if _, ok := gc.entries[key]; !ok {
+		gc.entries[key] = gc.order.PushFront(&guessCacheEntry{key: key, result: slices.Clone(result)})
+		if gc.order.Len() > gc.size {
+			oldest := gc.order.Back()
+			gc.order.Remove(oldest)
+			delete(gc.entries, oldest.Value.(*guessCacheEntry).key)
+		}
+	}
+	return result, false
+}
+
+// evaluateGuess checks guess against target through the guess cache, counting hits and misses.
+func (app *App) evaluateGuess(guess, target string) []GuessResult {
+	result, hit := app.GuessCache.check(guess, target)
+	if app.GuessCache != nil {
+		if hit {
+			app.incMetric(MetricGuessCacheHits)
+		} else {
+			app.incMetric(MetricGuessCacheMisses)
+		}
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGuessCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	gc := newGuessCache(2)
+	if _, hit := gc.check("CRANE", "TRACE"); hit {
+		t.Fatal("first evaluation reported a cache hit")
+	}
+	result, hit := gc.check("CRANE", "TRACE")
+	if !hit || !slices.Equal(result, checkGuess("CRANE", "TRACE")) {
+		t.Fatalf("second evaluation = %v, hit %v", result, hit)
+	}
+
+	result[0].Status = "tampered"
+	if again, _ := gc.check("CRANE", "TRACE"); again[0].Status == "tampered" {
+		t.Error("caller modified the cached result")
+	}
+
+	gc.check("SLATE", "TRACE")
+	gc.check("CRANE", "TRACE")
+	gc.check("ADIEU", "TRACE")
+	if _, hit := gc.check("SLATE", "TRACE"); hit {
+		t.Error("least recently used entry was not evicted")
+	}
+	if _, hit := gc.check("ADIEU", "TRACE"); !hit {
+		t.Error("recent entry was evicted")
+	}
+}
+
+func TestEvaluateGuessCountsHits(t *testing.T) {
+	app := &App{Metrics: newMetrics(), GuessCache: newGuessCache(8)}
+	app.evaluateGuess("CRANE", "TRACE")
+	app.evaluateGuess("CRANE", "TRACE")
+	if hits := app.Metrics.Get(MetricGuessCacheHits); hits == nil || hits.String() != "1" {
+		t.Errorf("hits = %v, want 1", hits)
+	}
+	if misses := app.Metrics.Get(MetricGuessCacheMisses); misses == nil || misses.String() != "1" {
+		t.Errorf("misses = %v, want 1", misses)
+	}
+
+	if newGuessCache(0) != nil {
+		t.Error("size 0 should disable the cache")
+	}
+}
@@ -2,16 +2,15 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"slices"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/samber/lo"
+	"github.com/mooship/vortludo/engine"
+	"github.com/mooship/vortludo/internal/htmx"
 )
 
 // homeHandler renders the main game page for the current session.
@@ -19,15 +18,26 @@ func (app *App) homeHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	sessionID := app.getOrCreateSession(c)
 	game := app.getGameState(ctx, sessionID)
-	hint := app.getHintForWord(game.SessionWord)
+	hint := app.gameHint(game)
 
-	csrfToken, _ := c.Cookie("csrf_token")
+	csrfToken, _ := c.Cookie(app.cookieName(CSRFCookieName))
 	c.HTML(http.StatusOK, "index.html", gin.H{
-		"title":      "Vortludo - A Libre Wordle Clone",
-		"message":    "Guess the 5-letter word!",
-		"hint":       hint,
-		"game":       game,
-		"csrf_token": csrfToken,
+		"title":             "Vortludo - A Libre Wordle Clone",
+		"message":           "Guess the 5-letter word!",
+		"hint":              hint,
+		"game":              game,
+		"csrf_token":        csrfToken,
+		"cookie_prefix":     app.cookieName(""),
+		"wasm":              app.WasmEnabled,
+		"packs":             app.lists().PackNames,
+		"word_list_version": app.lists().WordListVersion,
+		"experiments":       app.Experiments.assign(sessionID),
+		"daily_summary":     app.dailySolveSummary(game),
+		"streak":            app.streakStatus(c, game),
+		"bonus_offered":     app.Bonus.offered(sessionID, game),
+		"coins":             app.coinsStatus(c, game),
+		"reminders":         app.Reminders != nil,
+		"vapid_key":         app.vapidPublicKey(),
 	})
 }
 
@@ -35,74 +45,104 @@ func (app *App) homeHandler(c *gin.Context) {
 func (app *App) newGameHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	sessionID := app.getOrCreateSession(c)
-	logInfo("Creating new game for session: %s", sessionID)
+	logDebug("Creating new game for session: %s", redactSession(sessionID))
 
+	kids := requestedKids(c)
+	pack := app.wordPack(c.PostForm("pack"))
+	if kids {
+		pack = app.wordPack(app.Kids.Pack)
+	}
 	var completedWords []string
+	triggers := Triggers{}
 	if c.Request.Method == "POST" {
-		completedWordsStr := c.PostForm("completedWords")
-		if completedWordsStr != "" {
-			if err := json.Unmarshal([]byte(completedWordsStr), &completedWords); err != nil {
-				logWarn("Failed to parse completed words: %v", err)
-				completedWords = []string{}
+		if token := c.PostForm("progress"); token != "" {
+			bitmap, err := app.Progress.verify(pack, token)
+			if errors.Is(err, errProgressStale) {
+				logInfo("Progress token for pack %s predates the current word list; resetting", pack.Name)
+				triggers.progressStale(pack.Name)
+			} else if err != nil {
+				logWarn("Ignoring progress token for pack %s: %v", pack.Name, err)
 			} else {
-				validCompletedWords := lo.Filter(completedWords, func(word string, _ int) bool {
-					_, exists := app.WordSet[word]
-					if !exists {
-						logWarn("Invalid completed word ignored: %s", word)
-					}
-					return exists
-				})
-				completedWords = validCompletedWords
-				logInfo("Validated %d completed words for session %s", len(completedWords), sessionID)
+				completedWords = pack.completedWords(bitmap)
+				logDebug("Verified %d completed words for session %s", len(completedWords), redactSession(sessionID))
 			}
 		}
 	}
 
-	app.SessionMutex.Lock()
-	delete(app.GameSessions, sessionID)
-	app.SessionMutex.Unlock()
-	logInfo("Cleared old session data for: %s", sessionID)
+	// A daily puzzle already under way, or already finished, is resumed rather than restarted,
+	// so its answer cannot be replayed once known.
+	daily := requestedDaily(c)
+	var today time.Time
+	if daily {
+		today = app.todaysPuzzle(c)
+	}
+	resumed := daily && c.Query("reset") != "1" && app.getGameState(ctx, sessionID).Daily == today.Format(time.DateOnly)
+	if !resumed {
+		app.Rooms.leave(sessionID)
+		app.SessionMutex.Lock()
+		delete(app.GameSessions, sessionID)
+		app.SessionMutex.Unlock()
+		app.incMetric(MetricSessionsReset)
+		logDebug("Cleared old session data for: %s", redactSession(sessionID))
+	}
 
 	if c.Query("reset") == "1" {
 		c.SetSameSite(http.SameSiteStrictMode)
 		secure := app.IsProduction
-		c.SetCookie(SessionCookieName, "", -1, "/", "", secure, true)
+		c.SetCookie(app.cookieName(SessionCookieName), "", -1, "/", "", secure, true)
 
 		newSessionID := uuid.NewString()
-		c.SetSameSite(http.SameSiteStrictMode)
-		c.SetCookie(SessionCookieName, newSessionID, int(app.CookieMaxAge.Seconds()), "/", "", secure, true)
-		logInfo("Created new session ID: %s", newSessionID)
+		app.setSessionCookie(c, newSessionID)
+		app.incMetric(MetricSessionsCreated)
+		logInfo("Created new session ID: %s", redactSession(newSessionID))
 
-		if len(completedWords) > 0 {
-			_, needsReset := app.createNewGameWithCompletedWords(ctx, newSessionID, completedWords)
-			if needsReset {
-				c.Header("HX-Trigger", "clear-completed-words")
-			}
-		} else {
-			app.createNewGame(ctx, newSessionID)
-		}
 		sessionID = newSessionID
-	} else {
-		if len(completedWords) > 0 {
-			_, needsReset := app.createNewGameWithCompletedWords(ctx, sessionID, completedWords)
-			if needsReset {
-				c.Header("HX-Trigger", "clear-completed-words")
-			}
+	}
+
+	started := resumed
+	if daily && !resumed {
+		_, started = app.createDailyGame(ctx, sessionID, today)
+	}
+	if daily {
+		pack = app.wordPack(DefaultPackName)
+	}
+	if !started {
+		req := selectionRequest{Mode: SelectionModeCasual}
+		if requestedPurist(c) {
+			req.Mode = SelectionModePurist
 		} else {
-			app.createNewGame(ctx, sessionID)
+			req.Skill = app.playerSkill(c, sessionID)
 		}
+		game, needsReset := app.createNewGameWithCompletedWords(ctx, sessionID, pack, completedWords, req)
+		if needsReset {
+			triggers.clearCompletedWords(pack.Name)
+		}
+		game.Purist = requestedPurist(c)
+		if game.Purist {
+			app.Journal.record(ctx, sessionID, game, JournalPurist)
+		}
+		if kids {
+			app.makeKids(ctx, sessionID, game, app.Kids.MaxGuesses)
+		}
+	}
+	triggers.set(c)
+
+	if !resumed {
+		app.trackEvent(c, EventGameStarted, map[string]string{"pack": pack.Name})
 	}
 
-	isHTMX := c.GetHeader("HX-Request") == "true"
-	if isHTMX {
+	if htmx.IsRequest(c.Request) || wantsJSONView(c) {
 		game := app.getGameState(ctx, sessionID)
-		hint := app.getHintForWord(game.SessionWord)
-		csrfToken, _ := c.Cookie("csrf_token")
-		c.HTML(http.StatusOK, "game-content", gin.H{
-			"game":       game,
-			"hint":       hint,
-			"newGame":    true,
-			"csrf_token": csrfToken,
+		hint := app.gameHint(game)
+		csrfToken, _ := c.Cookie(app.cookieName(CSRFCookieName))
+		renderFragment(c, "game-content", gin.H{
+			"game":        game,
+			"hint":        hint,
+			"newGame":     true,
+			"oob":         true,
+			"csrf_token":  csrfToken,
+			"experiments": app.Experiments.assign(sessionID),
+			"coins":       app.coinsStatus(c, game),
 		})
 	} else {
 		c.Redirect(http.StatusSeeOther, RouteHome)
@@ -114,104 +154,121 @@ func (app *App) guessHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	sessionID := app.getOrCreateSession(c)
 	game := app.getGameState(ctx, sessionID)
-	hint := app.getHintForWord(game.SessionWord)
-
-	renderBoard := func(errCode string) {
-		csrfToken, _ := c.Cookie("csrf_token")
-		if errCode != "" {
-			payload := map[string]string{"server_error_code": errCode}
-			if b, jerr := json.Marshal(payload); jerr == nil {
-				c.Header("HX-Trigger", string(b))
-			} else {
-				logWarn("Failed to marshal HX-Trigger payload: %v", jerr)
-			}
-		}
-		c.HTML(http.StatusOK, "game-content", gin.H{
-			"game":       game,
-			"hint":       hint,
-			"error_code": errCode,
-			"csrf_token": csrfToken,
-		})
-	}
+	hint := app.gameHint(game)
 
-	renderFullPage := func(errCode string) {
-		csrfToken, _ := c.Cookie("csrf_token")
-		if errCode != "" {
-			payload := map[string]string{"server_error_code": errCode}
-			if b, jerr := json.Marshal(payload); jerr == nil {
-				c.Header("HX-Trigger", string(b))
-			} else {
-				logWarn("Failed to marshal HX-Trigger payload: %v", jerr)
-			}
-		}
-		c.HTML(http.StatusOK, "index.html", gin.H{
-			"title":      "Vortludo - A Libre Wordle Clone",
-			"message":    "Guess the 5-letter word!",
-			"hint":       hint,
-			"game":       game,
-			"error_code": errCode,
-			"csrf_token": csrfToken,
-		})
+	if err := app.validateGameState(c, game); err != nil {
+		app.renderGameError(c, game, hint, err.Error())
+		return
 	}
 
-	isHTMX := c.GetHeader("HX-Request") == "true"
-	var errCode string
-	if err := app.validateGameState(c, game); err != nil {
-		errCode = err.Error()
-		if isHTMX {
-			renderBoard(errCode)
-		} else {
-			renderFullPage(errCode)
-		}
+	guess := engine.NormalizeGuess(c.PostForm("guess"))
+	if !app.gameLists(game).isAccepted(acceptedMode(game), guess) {
+		app.renderGameError(c, game, hint, ErrorCodeWordNotAccepted)
 		return
 	}
 
-	guess := normalizeGuess(c.PostForm("guess"))
-	if !app.isAcceptedWord(guess) {
-		errCode = ErrorCodeWordNotAccepted
-		if isHTMX {
-			renderBoard(errCode)
-		} else {
-			renderFullPage(errCode)
-		}
+	if room := app.coopRoom(sessionID, game); room != nil {
+		app.roomGuessHandler(c, sessionID, room, guess, hint)
 		return
 	}
 
 	if slices.Contains(game.GuessHistory, guess) {
-		errCode = ErrorCodeDuplicateGuess
-		if isHTMX {
-			renderBoard(errCode)
-		} else {
-			renderFullPage(errCode)
-		}
+		app.renderGameError(c, game, hint, ErrorCodeDuplicateGuess)
 		return
 	}
-	if err := app.processGuess(ctx, c, sessionID, game, guess, isHTMX, hint); err != nil {
-		errCode = err.Error()
-		if isHTMX {
-			renderBoard(errCode)
-		} else {
-			renderFullPage(errCode)
-		}
+
+	if err := app.processGuess(ctx, c, sessionID, game, guess, hint); err != nil {
+		app.renderGameError(c, game, hint, err.Error())
 		return
 	}
 }
 
-// gameStateHandler renders the current game board as an HTML fragment.
+// gameStateHandler renders the current game board as an HTML fragment, or as JSON with
+// format=json.
 func (app *App) gameStateHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	sessionID := app.getOrCreateSession(c)
 	game := app.getGameState(ctx, sessionID)
-	hint := app.getHintForWord(game.SessionWord)
+	hint := app.gameHint(game)
 
-	csrfToken, _ := c.Cookie("csrf_token")
-	c.HTML(http.StatusOK, "game-content", gin.H{
-		"game":       game,
-		"hint":       hint,
-		"csrf_token": csrfToken,
+	csrfToken, _ := c.Cookie(app.cookieName(CSRFCookieName))
+	renderFragment(c, "game-content", gin.H{
+		"game":          game,
+		"hint":          hint,
+		"oob":           htmx.IsRequest(c.Request),
+		"csrf_token":    csrfToken,
+		"challenge_url": app.challengeURL(game),
+		"experiments":   app.Experiments.assign(sessionID),
+		"daily_summary": app.dailySolveSummary(game),
+		"streak":        app.streakStatus(c, game),
+		"bonus_offered": app.Bonus.offered(sessionID, game),
+		"coins":         app.coinsStatus(c, game),
 	})
 }
 
+// hintAPIHandler returns the current game's hint as JSON for API clients and counts the reveal.
+// Unlike the HTML routes it never starts a game, so clients without a session get 404.
+func (app *App) hintAPIHandler(c *gin.Context) {
+	sessionID, _ := c.Cookie(app.cookieName(SessionCookieName))
+	app.SessionMutex.Lock()
+	game, exists := app.GameSessions[sessionID]
+	var word, version, pack string
+	var hintsUsed int
+	purist := exists && game.Purist
+	if exists && !purist {
+		game.HintsUsed++
+		game.LastAccessTime = app.now()
+		word, version, pack, hintsUsed = game.SessionWord, game.WordsVersion, game.Pack, game.HintsUsed
+	}
+	app.SessionMutex.Unlock()
+	if exists && !purist {
+		app.Journal.record(c.Request.Context(), sessionID, game, JournalHint)
+	}
+
+	if !exists {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNoActiveGame, "no active game")
+		return
+	}
+	if purist {
+		writeProblem(c, http.StatusForbidden, ErrorCodeHintsDisabled, "hints are disabled in purist mode")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"hint":       app.hintIn(app.gameLists(&GameState{WordsVersion: version}), pack, word),
+		"hints_used": hintsUsed,
+	})
+}
+
+// validateGuessHandler reports whether a guess would be accepted without consuming a row.
+// The duplicate check only applies when the request carries a session with an active game.
+func (app *App) validateGuessHandler(c *gin.Context) {
+	guess := engine.NormalizeGuess(c.Query("guess"))
+	sessionID, _ := c.Cookie(app.cookieName(SessionCookieName))
+	app.SessionMutex.RLock()
+	game := app.GameSessions[sessionID]
+	accepted := app.gameLists(game).isAccepted(acceptedMode(game), guess)
+	duplicate := game != nil && slices.Contains(game.GuessHistory, guess)
+	app.SessionMutex.RUnlock()
+
+	code := ""
+	switch {
+	case len(guess) != WordLength:
+		code = ErrorCodeInvalidLength
+	case !accepted:
+		code = ErrorCodeWordNotAccepted
+	case duplicate:
+		code = ErrorCodeDuplicateGuess
+	}
+
+	body := gin.H{"guess": guess, "valid": code == ""}
+	if code != "" {
+		body["error_code"] = code
+		body["message"] = errorMessage(code)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, body)
+}
+
 // retryWordHandler resets the game state for the current session but keeps the same word.
 func (app *App) retryWordHandler(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -224,35 +281,73 @@ func (app *App) retryWordHandler(c *gin.Context) {
 		c.Redirect(http.StatusSeeOther, "/")
 		return
 	}
-	sessionWord := game.SessionWord
-	guesses := lo.Times(MaxGuesses, func(_ int) []GuessResult {
-		return lo.Times(WordLength, func(_ int) GuessResult { return GuessResult{} })
-	})
-	newGame := &GameState{
-		Guesses:        guesses,
-		CurrentRow:     0,
-		GameOver:       false,
-		Won:            false,
-		TargetWord:     "",
-		SessionWord:    sessionWord,
-		GuessHistory:   []string{},
-		LastAccessTime: time.Now(),
-	}
-	app.GameSessions[sessionID] = newGame
+	retry := newGame(game.SessionWord)
+	retry.Pack = game.Pack
+	retry.Purist = game.Purist
+	retry.WordsVersion = game.WordsVersion
+	app.GameSessions[sessionID] = retry
 	app.SessionMutex.Unlock()
+	app.Journal.start(ctx, sessionID, retry)
+	if game.Kids {
+		app.makeKids(ctx, sessionID, retry, game.Rows())
+	}
+	app.trackEvent(c, EventGameStarted, map[string]string{"retry": "true"})
 	c.Redirect(http.StatusSeeOther, "/")
 }
 
-// healthzHandler returns a JSON health check with server stats.
+// acceptedWordsHandler serves the accepted-word list, including word pack words, as plain text for
+// the client-side engine. The mode query selects a game mode's list when it has an overlay.
+func (app *App) acceptedWordsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", app.lists().acceptedText(c.Query("mode")))
+}
+
+// manifestHandler serves the PWA manifest. Its version member carries the word list version so
+// installed clients can tell when cached word data is out of date.
+func (app *App) manifestHandler(c *gin.Context) {
+	c.Header("Content-Type", "application/manifest+json")
+	c.JSON(http.StatusOK, gin.H{
+		"name":             "Vortludo",
+		"short_name":       "Vortludo",
+		"description":      "A libre Wordle clone.",
+		"start_url":        "/",
+		"display":          "standalone",
+		"background_color": "#f4f1e8",
+		"theme_color":      "#f4f1e8",
+		"version":          app.lists().WordListVersion,
+		"icons": []gin.H{
+			{"src": "/static/favicons/android-chrome-192x192.png", "sizes": "192x192", "type": "image/png"},
+			{"src": "/static/favicons/android-chrome-512x512.png", "sizes": "512x512", "type": "image/png"},
+		},
+	})
+}
+
+// healthzHandler returns a JSON health check with server stats and dependency check results.
 func (app *App) healthzHandler(c *gin.Context) {
 	uptime := time.Since(app.StartTime)
+	status, checks := app.runHealthChecks()
+	if status != HealthStatusOK {
+		logWarn("Health check degraded: %+v", checks)
+	}
+	dataBytes, dataFiles, err := dirUsage(DataDir)
+	if err != nil {
+		logWarn("Failed to measure %s usage: %v", DataDir, err)
+	}
+	lists := app.lists()
 	c.JSON(http.StatusOK, gin.H{
-		"status":         "ok",
-		"env":            map[bool]string{true: "production", false: "development"}[app.IsProduction],
-		"words_loaded":   len(app.WordList),
-		"accepted_words": len(app.AcceptedWordSet),
-		"uptime":         formatUptime(uptime),
-		"timestamp":      time.Now().UTC().Format(time.RFC3339),
+		"status":            status,
+		"checks":            checks,
+		"env":               map[bool]string{true: "production", false: "development"}[app.IsProduction],
+		"namespace":         app.Namespace,
+		"words_loaded":      len(lists.WordList),
+		"accepted_words":    len(lists.AcceptedWordSet),
+		"word_list_version": lists.WordListVersion,
+		"pack_versions":     app.packVersions(),
+		"sessions":          app.activeSessionCount(),
+		"max_sessions":      app.MaxSessions,
+		"session_timeout":   app.Retention.Sessions.String(),
+		"data_dir":          gin.H{"bytes": dataBytes, "files": dataFiles},
+		"uptime":            formatUptime(uptime),
+		"timestamp":         app.now().UTC().Format(time.RFC3339),
 	})
 }
 
@@ -266,39 +361,51 @@ func (app *App) validateGameState(_ *gin.Context, game *GameState) error {
 	return nil
 }
 
-// normalizeGuess trims and uppercases a guess string for comparison.
-func normalizeGuess(input string) string {
-	return strings.ToUpper(strings.TrimSpace(input))
-}
-
-func (app *App) processGuess(ctx context.Context, c *gin.Context, sessionID string, game *GameState, guess string, isHTMX bool, hint string) error {
-	logInfo("Session %s guessed: %s (attempt %d/%d)", sessionID, guess, game.CurrentRow+1, MaxGuesses)
+// processGuess evaluates a validated guess, updates and saves the game, and renders the result.
+func (app *App) processGuess(ctx context.Context, c *gin.Context, sessionID string, game *GameState, guess string, hint string) error {
+	logDebug("Session %s guessed: %s (attempt %d/%d)", redactSession(sessionID), redactWord(guess), game.CurrentRow+1, game.Rows())
 
 	if len(guess) != WordLength {
-		logWarn("Session %s submitted invalid length guess: %s (%d letters)", sessionID, guess, len(guess))
+		logWarn("Session %s submitted invalid length guess: %s (%d letters)", redactSession(sessionID), redactWord(guess), len(guess))
 		return errors.New(ErrorCodeInvalidLength)
 	}
 
-	if game.CurrentRow >= MaxGuesses {
-		logWarn("Session %s attempted guess after max guesses reached", sessionID)
+	if game.CurrentRow >= game.Rows() {
+		logWarn("Session %s attempted guess after max guesses reached", redactSession(sessionID))
 		return errors.New(ErrorCodeNoMoreGuesses)
 	}
 
 	targetWord := app.getTargetWord(ctx, game)
-	isInvalid := !app.isValidWord(guess)
-	result := checkGuess(guess, targetWord)
+	isInvalid := !app.gameLists(game).isValid(guess)
+	result := app.evaluateGuess(guess, targetWord)
+	previousRow := game.CurrentRow
 	app.updateGameState(ctx, game, guess, targetWord, result, isInvalid)
+	if app.coopRoom(sessionID, game) == nil {
+		app.journalGuess(ctx, sessionID, game, guess)
+	}
+	if game.GameOver && !game.Custom && game.Daily == "" {
+		app.issueProgressToken(game)
+	}
 	app.saveGameState(sessionID, game)
+	app.Classrooms.report(sessionID, game)
+	if game.GameOver {
+		app.trackGameOver(c, game)
+		if app.claimDailyResult(c, sessionID, game) {
+			app.recordGameOutcome(c, game)
+			app.recordPlayerGame(c, sessionID, game)
+		}
+		app.awardCoins(c, sessionID, game)
+		if game.Won {
+			app.recordExperiments(sessionID, "won")
+		} else {
+			app.recordExperiments(sessionID, "lost")
+		}
+	}
 
-	if isHTMX {
-		c.HTML(http.StatusOK, "game-content", gin.H{"game": game, "hint": hint})
-	} else {
-		c.HTML(http.StatusOK, "index.html", gin.H{
-			"title":   "Vortludo - A Libre Wordle Clone",
-			"message": "Guess the 5-letter word!",
-			"hint":    hint,
-			"game":    game,
-		})
+	if htmx.IsRequest(c.Request) && !wantsJSONView(c) && !game.GameOver && game.CurrentRow == previousRow+1 {
+		app.renderGuessUpdate(c, game)
+		return nil
 	}
+	app.renderGame(c, game, hint, nil)
 	return nil
 }
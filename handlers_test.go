@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHintAPIHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE", Hint: "bird"}})
+	app.createNewGame(dummyContext(), "session-with-game")
+	router := gin.New()
+	router.GET(RouteHintAPI, app.hintAPIHandler)
+
+	get := func(sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", RouteHintAPI, nil)
+		if sessionID != "" {
+			req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: sessionID})
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get(""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a session, got %d", w.Code)
+	}
+	if len(app.GameSessions) != 1 {
+		t.Error("Hint API should not create games")
+	}
+
+	for want := 1; want <= 2; want++ {
+		w := get("session-with-game")
+		var body struct {
+			Hint      string `json:"hint"`
+			HintsUsed int    `json:"hints_used"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != http.StatusOK || body.Hint != "bird" || body.HintsUsed != want {
+			t.Errorf("Got %d %+v, want hint bird used %d", w.Code, body, want)
+		}
+	}
+}
+
+func TestValidateGuessHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE", Hint: "bird"}, {Word: "SLATE", Hint: "rock"}})
+	game := app.createNewGame(dummyContext(), "session-with-game")
+	game.GuessHistory = append(game.GuessHistory, "SLATE")
+	router := gin.New()
+	router.GET(RouteValidate, app.validateGuessHandler)
+
+	tests := []struct {
+		guess, code string
+	}{
+		{"crane", ""},
+		{"cran", ErrorCodeInvalidLength},
+		{"zzzzz", ErrorCodeWordNotAccepted},
+		{"slate", ErrorCodeDuplicateGuess},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", RouteValidate+"?guess="+tt.guess, nil)
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session-with-game"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var body struct {
+			Valid     bool   `json:"valid"`
+			ErrorCode string `json:"error_code"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != http.StatusOK || body.Valid != (tt.code == "") || body.ErrorCode != tt.code {
+			t.Errorf("%s: got %d %+v, want code %q", tt.guess, w.Code, body, tt.code)
+		}
+	}
+	if game.CurrentRow != 0 || len(game.GuessHistory) != 1 {
+		t.Error("Validation should not consume a row")
+	}
+}
+
+func TestNewGameHandlerStaleProgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Progress = newProgressTokens(newKeyring([]byte("test-secret")))
+	old := newWordPack(DefaultPackName, []WordEntry{{Word: "CRANE"}})
+	token := app.Progress.issue(old, old.completionBitmap([]string{"CRANE"}))
+
+	router := gin.New()
+	router.POST(RouteNewGame, app.newGameHandler)
+	req := httptest.NewRequest("POST", RouteNewGame, strings.NewReader(url.Values{"progress": {token}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var triggers map[string]map[string]string
+	if err := json.Unmarshal([]byte(w.Header().Get("HX-Trigger")), &triggers); err != nil {
+		t.Fatalf("HX-Trigger %q: %v", w.Header().Get("HX-Trigger"), err)
+	}
+	if triggers["progress-stale"]["pack"] != DefaultPackName {
+		t.Errorf("Expected progress-stale trigger for %s, got %v", DefaultPackName, triggers)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Health status constants
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+)
+
+// healthCheck is the result of a single dependency check reported by /healthz.
+type healthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// runHealthChecks runs all dependency checks and returns the overall status with per-check details.
+func (app *App) runHealthChecks() (string, []healthCheck) {
+	checks := []healthCheck{
+		checkDirWritable(DataDir),
+		app.checkWordListFreshness(),
+		checkDiskSpace(DataDir, uint64(app.HealthMinFreeMB)*1024*1024),
+		app.checkSessionCapacity(),
+		app.checkSessionStore(),
+		app.checkCircuits(),
+	}
+	status := HealthStatusOK
+	for _, check := range checks {
+		if check.Status != HealthStatusOK {
+			status = HealthStatusDegraded
+			break
+		}
+	}
+	return status, checks
+}
+
+// checkDirWritable verifies that a file can be created and removed in dir.
+func checkDirWritable(dir string) healthCheck {
+	check := healthCheck{Name: "data_dir_writable", Status: HealthStatusOK}
+	f, err := os.CreateTemp(dir, ".healthz-*")
+	if err != nil {
+		check.Status = HealthStatusDegraded
+		check.Detail = fmt.Sprintf("cannot write to %s: %v", dir, err)
+		return check
+	}
+	name := f.Name()
+	f.Close()
+	if err := os.Remove(name); err != nil {
+		check.Status = HealthStatusDegraded
+		check.Detail = fmt.Sprintf("cannot remove probe file in %s: %v", dir, err)
+	}
+	return check
+}
+
+// checkWordListFreshness reports whether the word lists are loaded and unchanged on disk since
+// they were last loaded.
+func (app *App) checkWordListFreshness() healthCheck {
+	check := healthCheck{Name: "word_list", Status: HealthStatusOK}
+	lists := app.lists()
+	loadedAt := lists.LoadedAt
+	if loadedAt.IsZero() {
+		loadedAt = app.StartTime
+	}
+	if len(lists.WordList) == 0 || len(lists.AcceptedWordSet) == 0 {
+		check.Status = HealthStatusDegraded
+		check.Detail = "word lists are empty"
+		return check
+	}
+	for _, path := range []string{WordsFile, AcceptedWordsFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			check.Status = HealthStatusDegraded
+			check.Detail = fmt.Sprintf("cannot stat %s: %v", filepath.Base(path), err)
+			return check
+		}
+		if info.ModTime().After(loadedAt) {
+			check.Status = HealthStatusDegraded
+			check.Detail = fmt.Sprintf("%s changed on disk at %s; reload or restart to pick it up", filepath.Base(path), info.ModTime().UTC().Format(time.RFC3339))
+			return check
+		}
+	}
+	return check
+}
+
+// checkDiskSpace reports whether the filesystem holding dir has at least minFree bytes available.
+func checkDiskSpace(dir string, minFree uint64) healthCheck {
+	check := healthCheck{Name: "disk_space", Status: HealthStatusOK}
+	free, err := diskFreeBytes(dir)
+	if err != nil {
+		check.Detail = fmt.Sprintf("unavailable: %v", err)
+		return check
+	}
+	check.Detail = fmt.Sprintf("%d MB free", free/1024/1024)
+	if free < minFree {
+		check.Status = HealthStatusDegraded
+		check.Detail = fmt.Sprintf("only %d MB free, below %d MB threshold", free/1024/1024, minFree/1024/1024)
+	}
+	return check
+}
+
+// checkSessionCapacity reports whether the session store is close to MaxSessions, at which point
+// idle sessions start being evicted.
+func (app *App) checkSessionCapacity() healthCheck {
+	check := healthCheck{Name: "session_capacity", Status: HealthStatusOK}
+	count := app.activeSessionCount()
+	if app.MaxSessions <= 0 {
+		check.Detail = fmt.Sprintf("%d sessions, no limit", count)
+		return check
+	}
+	check.Detail = fmt.Sprintf("%d of %d sessions", count, app.MaxSessions)
+	if count >= app.MaxSessions*9/10 {
+		check.Status = HealthStatusDegraded
+		check.Detail += "; evicting idle sessions"
+	}
+	return check
+}
+
+// dirUsage returns the total size and number of regular files under dir.
+func dirUsage(dir string) (int64, int, error) {
+	var size int64
+	var files int
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		files++
+		return nil
+	})
+	return size, files, err
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckDirWritable(t *testing.T) {
+	dir := t.TempDir()
+	if check := checkDirWritable(dir); check.Status != HealthStatusOK {
+		t.Errorf("Expected writable temp dir, got %+v", check)
+	}
+	if check := checkDirWritable(filepath.Join(dir, "missing")); check.Status != HealthStatusDegraded {
+		t.Errorf("Expected degraded for missing dir, got %+v", check)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected probe file to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	if check := checkDiskSpace(t.TempDir(), 0); check.Status != HealthStatusOK {
+		t.Errorf("Expected ok with zero threshold, got %+v", check)
+	}
+	if _, err := diskFreeBytes(t.TempDir()); err == nil {
+		if check := checkDiskSpace(t.TempDir(), ^uint64(0)); check.Status != HealthStatusDegraded {
+			t.Errorf("Expected degraded with impossible threshold, got %+v", check)
+		}
+	}
+}
+
+func TestCheckWordListFreshnessEmpty(t *testing.T) {
+	app := testAppWithWords(nil)
+	if check := app.checkWordListFreshness(); check.Status != HealthStatusDegraded {
+		t.Errorf("Expected degraded for empty word list, got %+v", check)
+	}
+}
+
+func TestCheckSessionCapacity(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.createNewGame(dummyContext(), "session-a")
+	if check := app.checkSessionCapacity(); check.Status != HealthStatusOK {
+		t.Errorf("Expected ok without a limit, got %+v", check)
+	}
+	app.MaxSessions = 1
+	if check := app.checkSessionCapacity(); check.Status != HealthStatusDegraded {
+		t.Errorf("Expected degraded at the limit, got %+v", check)
+	}
+}
+
+func TestDirUsage(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte("12345"), 0o600)
+	os.Mkdir(filepath.Join(dir, "sub"), 0o750)
+	os.WriteFile(filepath.Join(dir, "sub", "b.json"), []byte("123"), 0o600)
+	size, files, err := dirUsage(dir)
+	if err != nil || size != 8 || files != 2 {
+		t.Errorf("dirUsage() = %d, %d, %v; want 8, 2", size, files, err)
+	}
+}
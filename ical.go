@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// iCalendar feed constants
+const (
+	// CalendarFeedDays is the number of daily puzzles listed ahead, starting with today's.
+	CalendarFeedDays = 14
+	// CalendarEventLength is the length of each puzzle event in calendar apps.
+	CalendarEventLength = 30 * time.Minute
+	// StreakReminderLead is how long before the rollover a player with an unplayed puzzle and a
+	// streak to lose is reminded.
+	StreakReminderLead = 3 * time.Hour
+	// calendarRefresh asks calendar apps to fetch the feed again after this long, so streak
+	// reminders disappear soon after the puzzle is played.
+	calendarRefresh = "PT1H"
+	// icsLineLimit is the longest content line allowed before folding (RFC 5545).
+	icsLineLimit = 75
+)
+
+// icsEscaper escapes TEXT values (RFC 5545 section 3.3.11).
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// icsWriter builds an iCalendar document with CRLF line endings and folded long lines.
+type icsWriter struct {
+	b strings.Builder
+}
+
+// line writes one content line, folding it into continuation lines past icsLineLimit octets
+// without splitting a UTF-8 sequence.
+func (w *icsWriter) line(name, value string) {
+	s := name + ":" + value
+	for len(s) > icsLineLimit {
+		cut := icsLineLimit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.b.WriteString(s[:cut] + "\r\n")
+		s = " " + s[cut:]
+	}
+	w.b.WriteString(s + "\r\n")
+}
+
+// icsTime formats t as a UTC DATE-TIME value.
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// calendarToken returns the secret part of a player's calendar feed URL. It is signed
+// separately from the player cookie, so a shared feed URL cannot be turned into the cookie.
+func (p *PlayerIDs) calendarToken(id string) string {
+	return p.sign("calendar:", id)
+}
+
+// verifyCalendarToken returns the player ID in a calendar token. Feeds subscribed under a
+// retired key keep working for as long as that key is accepted.
+func (p *PlayerIDs) verifyCalendarToken(token string) (string, bool) {
+	return p.verifySigned("calendar:", token)
+}
+
+// feedLocation returns the timezone a feed is built in: the tz query parameter when player
+// timezones are enabled, otherwise the daily schedule's own.
+func (app *App) feedLocation(c *gin.Context) *time.Location {
+	if app.Daily.UserTimezones {
+		if loc, err := time.LoadLocation(c.Query("tz")); err == nil && c.Query("tz") != "" {
+			return loc
+		}
+	}
+	return app.Daily.Location
+}
+
+// writeCalendar writes a feed of the next CalendarFeedDays daily puzzles in loc, plus a streak
+// reminder when streak is positive and today's puzzle is unplayed.
+func (app *App) writeCalendar(c *gin.Context, loc *time.Location, streak int, cacheControl string) {
+	now := app.now()
+	host := c.Request.Host
+	var w icsWriter
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//Vortludo//Daily Puzzle//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.line("X-WR-CALNAME", "Vortludo")
+	w.line("REFRESH-INTERVAL;VALUE=DURATION", calendarRefresh)
+	w.line("X-PUBLISHED-TTL", calendarRefresh)
+
+	today := now.In(loc)
+	if now.Before(app.Daily.rolloverAt(now, loc)) {
+		today = today.AddDate(0, 0, -1)
+	}
+	for i := range CalendarFeedDays {
+		day := time.Date(today.Year(), today.Month(), today.Day()+i, 12, 0, 0, 0, loc)
+		start := app.Daily.rolloverAt(day, loc)
+		date := day.Format(time.DateOnly)
+		playURL := app.absoluteURL(c, dailyLink(date))
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", "puzzle-"+date+"@"+host)
+		w.line("DTSTAMP", icsTime(now))
+		w.line("DTSTART", icsTime(start))
+		w.line("DTEND", icsTime(start.Add(CalendarEventLength)))
+		w.line("SUMMARY", icsEscaper.Replace("Vortludo puzzle for "+date))
+		w.line("DESCRIPTION", icsEscaper.Replace("A new daily puzzle is out. Play at "+playURL))
+		w.line("URL", playURL)
+		w.line("TRANSP", "TRANSPARENT")
+		w.line("END", "VEVENT")
+	}
+
+	if streak > 0 {
+		end := app.Daily.nextRollover(now, loc)
+		date := today.Format(time.DateOnly)
+		playURL := app.absoluteURL(c, dailyLink(date))
+		summary := fmt.Sprintf("Your %d-day Vortludo streak ends soon", streak)
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", "streak-"+date+"@"+host)
+		w.line("DTSTAMP", icsTime(now))
+		w.line("DTSTART", icsTime(end.Add(-StreakReminderLead)))
+		w.line("DTEND", icsTime(end))
+		w.line("SUMMARY", icsEscaper.Replace(summary))
+		w.line("DESCRIPTION", icsEscaper.Replace("Play today's puzzle before the rollover to keep your streak: "+playURL))
+		w.line("URL", playURL)
+		w.line("TRANSP", "TRANSPARENT")
+		w.line("BEGIN", "VALARM")
+		w.line("ACTION", "DISPLAY")
+		w.line("TRIGGER", "PT0S")
+		w.line("DESCRIPTION", icsEscaper.Replace(summary))
+		w.line("END", "VALARM")
+		w.line("END", "VEVENT")
+	}
+	w.line("END", "VCALENDAR")
+
+	c.Header("Cache-Control", cacheControl)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(w.b.String()))
+}
+
+// calendarFeedHandler serves the public feed of upcoming daily puzzles.
+func (app *App) calendarFeedHandler(c *gin.Context) {
+	app.writeCalendar(c, app.feedLocation(c), 0, "public, max-age=3600")
+}
+
+// playerCalendarHandler serves a remembered player's feed: the daily puzzles plus a reminder
+// on days their streak is at risk, meaning they have a streak and have not played today.
+func (app *App) playerCalendarHandler(c *gin.Context) {
+	if app.PlayerIDs == nil || app.Players == nil {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "calendar not found")
+		return
+	}
+	id, ok := app.PlayerIDs.verifyCalendarToken(strings.TrimSuffix(c.Param("token"), ".ics"))
+	if !ok {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "calendar not found")
+		return
+	}
+	loc := app.feedLocation(c)
+	today := app.Daily.puzzleDate(app.now(), loc).Format(time.DateOnly)
+	history, _, summary := app.Players.summary(playerStatsKey(id), today)
+	streak := summary.CurrentStreak
+	if len(history) > 0 && history[len(history)-1].PuzzleDate == today {
+		streak = 0
+	}
+	app.writeCalendar(c, loc, streak, "private, max-age=900")
+}
+
+// calendarLinkHandler returns the feed URL for this browser: a personal feed with streak
+// reminders for a remembered player, otherwise the public feed. With player timezones
+// enabled, the player's timezone is carried in the URL, since calendar apps send no cookies.
+func (app *App) calendarLinkHandler(c *gin.Context) {
+	query := ""
+	if app.Daily.UserTimezones {
+		query = "?tz=" + app.Daily.location(c).String()
+	}
+	path, personal := RouteCalendar+"/daily.ics", false
+	if app.PlayerIDs != nil && app.PlayerCookieMaxAge > 0 {
+		value, _ := c.Cookie(app.cookieName(PlayerCookieName))
+		if id, ok := app.PlayerIDs.verify(value); ok {
+			path, personal = RouteCalendar+"/"+app.PlayerIDs.calendarToken(id)+".ics", true
+		}
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"url": app.absoluteURL(c, path+query), "personal": personal})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestICSLineFolding(t *testing.T) {
+	var w icsWriter
+	w.line("DESCRIPTION", strings.Repeat("é", 60))
+	for _, line := range strings.Split(strings.TrimSuffix(w.b.String(), "\r\n"), "\r\n") {
+		if len(line) > icsLineLimit {
+			t.Errorf("line is %d octets, want at most %d", len(line), icsLineLimit)
+		}
+	}
+	unfolded := strings.ReplaceAll(w.b.String(), "\r\n ", "")
+	if unfolded != "DESCRIPTION:"+strings.Repeat("é", 60)+"\r\n" {
+		t.Errorf("unfolded line = %q", unfolded)
+	}
+	if got := icsEscaper.Replace("a,b;c\\d\ne"); got != `a\,b\;c\\d\ne` {
+		t.Errorf("escaped = %q", got)
+	}
+}
+
+func TestCalendarFeeds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clock := newFakeClock()
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Clock = clock
+	app.Daily = newDailySchedule("UTC", 0, false)
+	app.Players = newPlayerStatsStore(StreakFreezeRules{})
+	app.PlayerIDs = newPlayerIDs(newKeyring(make([]byte, 32)))
+	app.PlayerCookieMaxAge = 365 * 24 * time.Hour
+	router := gin.New()
+	router.GET(RouteCalendar+"/daily.ics", app.calendarFeedHandler)
+	router.GET(RouteCalendar+"/link", app.calendarLinkHandler)
+	router.GET(RouteCalendar+"/:token", app.playerCalendarHandler)
+	get := func(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(RouteCalendar + "/daily.ics")
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("public feed = %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if got := strings.Count(body, "BEGIN:VEVENT"); got != CalendarFeedDays {
+		t.Errorf("public feed has %d events, want %d", got, CalendarFeedDays)
+	}
+	if !strings.Contains(body, "DTSTART:20300101T000000Z") || !strings.Contains(body, "UID:puzzle-2030-01-14@") {
+		t.Errorf("public feed misses the expected puzzles:\n%s", body)
+	}
+	if !strings.Contains(body, "URL:http://example.com"+dailyLink("2030-01-14")+"\r\n") {
+		t.Errorf("Expected each event to link to its own puzzle:\n%s", body)
+	}
+	if strings.Contains(body, "CRANE") || strings.Contains(body, "VALARM") {
+		t.Error("Expected the public feed to hold no words or reminders")
+	}
+
+	player := &http.Cookie{Name: PlayerCookieName, Value: app.PlayerIDs.issue("player-one")}
+	var link struct {
+		URL      string `json:"url"`
+		Personal bool   `json:"personal"`
+	}
+	if err := json.Unmarshal(get(RouteCalendar+"/link", player).Body.Bytes(), &link); err != nil || !link.Personal {
+		t.Fatalf("link = %+v, %v", link, err)
+	}
+	path := strings.TrimPrefix(link.URL, "http://example.com")
+	if strings.Contains(path, app.PlayerIDs.issue("player-one")) {
+		t.Error("Expected the feed token to differ from the player cookie")
+	}
+
+	// No streak yet: no reminder.
+	if body := get(path).Body.String(); strings.Contains(body, "VALARM") {
+		t.Error("Expected no reminder without a streak")
+	}
+	app.Players.record(playerStatsKey("player-one"), GameRecord{Word: "SLATE", Won: true, Guesses: 3, PuzzleDate: "2029-12-31"})
+	body = get(path).Body.String()
+	if !strings.Contains(body, "BEGIN:VALARM") || !strings.Contains(body, "DTSTART:20300101T210000Z") || !strings.Contains(body, "1-day Vortludo streak") {
+		t.Errorf("Expected a streak reminder before the rollover:\n%s", body)
+	}
+	app.Players.record(playerStatsKey("player-one"), GameRecord{Word: "CRANE", Won: true, Guesses: 2, PuzzleDate: "2030-01-01"})
+	if body := get(path).Body.String(); strings.Contains(body, "VALARM") {
+		t.Error("Expected no reminder once today's puzzle is played")
+	}
+
+	if w := get(RouteCalendar + "/player-one.forged.ics"); w.Code != http.StatusNotFound {
+		t.Errorf("forged token = %d, want 404", w.Code)
+	}
+	if err := json.Unmarshal(get(RouteCalendar+"/link").Body.Bytes(), &link); err != nil || link.Personal || !strings.HasSuffix(link.URL, "/daily.ics") {
+		t.Errorf("link without a player = %+v, %v", link, err)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IntegrityProblemLimit caps how many problems an integrity report lists.
+const IntegrityProblemLimit = 50
+
+// integrityFilePause spaces out journal checks so a scan never competes with live traffic for
+// the journal lock or the disk.
+const integrityFilePause = 5 * time.Millisecond
+
+// Journal check outcomes
+const (
+	journalOK       = "ok"
+	journalRepaired = "repaired"
+	journalRemoved  = "removed"
+)
+
+// IntegrityReport summarizes one integrity scan of the session journals and live sessions.
+// Journals carry no checksums, so they are checked by replaying them: a torn or unreadable
+// tail is cut off, and a journal with no replayable game is deleted.
+type IntegrityReport struct {
+	StartedAt        time.Time `json:"started_at"`
+	DurationMS       int64     `json:"duration_ms"`
+	JournalsChecked  int       `json:"journals_checked"`
+	JournalsRepaired int       `json:"journals_repaired"`
+	JournalsRemoved  int       `json:"journals_removed"`
+	SessionsChecked  int       `json:"sessions_checked"`
+	SessionsInvalid  int       `json:"sessions_invalid"`
+	SessionsRepaired int       `json:"sessions_repaired"`
+	Problems         []string  `json:"problems"`
+}
+
+// problem records a finding, dropping it once the report already lists IntegrityProblemLimit.
+func (r *IntegrityReport) problem(format string, args ...any) {
+	if len(r.Problems) < IntegrityProblemLimit {
+		r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+	}
+}
+
+// IntegrityScanner runs integrity scans one at a time and keeps the latest report.
+type IntegrityScanner struct {
+	mu      sync.Mutex
+	running sync.Mutex
+	last    *IntegrityReport
+}
+
+// newIntegrityScanner returns a scanner with no report yet.
+func newIntegrityScanner() *IntegrityScanner {
+	return &IntegrityScanner{}
+}
+
+// latest returns the most recent report, or nil before the first scan completes.
+func (s *IntegrityScanner) latest() *IntegrityReport {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// runIntegrityScans scans every interval for the life of the process.
+func (app *App) runIntegrityScans(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		report := app.scanIntegrity()
+		if report.JournalsRepaired+report.JournalsRemoved+report.SessionsInvalid > 0 {
+			logWarn("Integrity scan repaired %d and removed %d journals; %d invalid sessions, %d repaired",
+				report.JournalsRepaired, report.JournalsRemoved, report.SessionsInvalid, report.SessionsRepaired)
+		}
+	}
+}
+
+// scanIntegrity checks the session journals and then the live sessions, repairing what it
+// can, and records the report and metrics. Concurrent calls wait for the running scan.
+func (app *App) scanIntegrity() *IntegrityReport {
+	app.Integrity.running.Lock()
+	defer app.Integrity.running.Unlock()
+
+	start := time.Now()
+	report := &IntegrityReport{StartedAt: start, Problems: []string{}}
+	if app.Leases.leader(JobIntegrityScan) {
+		app.scanJournals(report)
+	}
+	app.scanSessions(report)
+	report.DurationMS = time.Since(start).Milliseconds()
+
+	app.Integrity.mu.Lock()
+	app.Integrity.last = report
+	app.Integrity.mu.Unlock()
+
+	if app.Metrics != nil {
+		app.Metrics.Add(MetricIntegrityScans, 1)
+		app.Metrics.Add(MetricIntegrityJournalsRepaired, int64(report.JournalsRepaired))
+		app.Metrics.Add(MetricIntegrityJournalsRemoved, int64(report.JournalsRemoved))
+		app.Metrics.Add(MetricIntegritySessionsInvalid, int64(report.SessionsInvalid))
+		app.Metrics.Add(MetricIntegritySessionsRepaired, int64(report.SessionsRepaired))
+	}
+	return report
+}
+
+// scanJournals checks each journal file in turn, pausing between files.
+func (app *App) scanJournals(report *IntegrityReport) {
+	if app.Journal == nil {
+		return
+	}
+	for _, dir := range app.Journal.dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				report.problem("journal dir %s unreadable: %v", dir, err)
+			}
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || filepath.Ext(e.Name()) != ".log" {
+				continue
+			}
+			report.JournalsChecked++
+			outcome, err := app.Journal.check(filepath.Join(dir, e.Name()), app.isValidWord)
+			switch {
+			case err != nil:
+				report.problem("journal %s: %v", e.Name(), err)
+			case outcome == journalRepaired:
+				report.JournalsRepaired++
+				report.problem("journal %s: unreadable tail removed", e.Name())
+			case outcome == journalRemoved:
+				report.JournalsRemoved++
+				report.problem("journal %s: no replayable game, deleted", e.Name())
+			}
+			time.Sleep(integrityFilePause)
+		}
+	}
+}
+
+// check replays the journal file at path, cutting off anything after the last good record or
+// deleting the file when nothing in it replays. The rewrite goes through a temporary file so
+// a crash mid-repair leaves either the old or the new journal.
+func (j *SessionJournal) check(path string, valid func(string) bool) (string, error) {
+	lock := j.fileLock(filepath.Base(path))
+	lock.Lock()
+	defer lock.Unlock()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return journalOK, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	game, used := replayJournalPrefix(data, valid)
+	if game == nil {
+		return journalRemoved, os.Remove(path)
+	}
+	if used == len(data) {
+		return journalOK, nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data[:used], 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return journalRepaired, nil
+}
+
+// scanSessions checks the invariants of every live game. Broken games are rebuilt from their
+// journal when it replays cleanly; otherwise they are moved to quarantine and the player
+// starts over on their next request.
+func (app *App) scanSessions(report *IntegrityReport) {
+	broken := make(map[string]*GameState)
+	app.SessionMutex.RLock()
+	for id, game := range app.GameSessions {
+		report.SessionsChecked++
+		if problem := gameIntegrityProblem(game); problem != "" {
+			broken[id] = game
+			report.SessionsInvalid++
+			report.problem("session %s: %s", redactSession(id), problem)
+		}
+	}
+	app.SessionMutex.RUnlock()
+
+	now := app.now()
+	for id, game := range broken {
+		replacement, err := app.Journal.load(id, 0, time.Now(), app.isValidWord)
+		if err != nil || gameIntegrityProblem(replacement) != "" {
+			replacement = nil
+		}
+		app.SessionMutex.Lock()
+		if app.GameSessions[id] == game {
+			if replacement != nil {
+				replacement.LastAccessTime = game.LastAccessTime
+				app.GameSessions[id] = replacement
+				report.SessionsRepaired++
+			} else {
+				app.Quarantine.add(id, game, "invalid", now)
+				delete(app.GameSessions, id)
+			}
+		}
+		app.SessionMutex.Unlock()
+	}
+}
+
+// gameIntegrityProblem describes the first broken invariant of game, or returns "" when the
+// game is consistent.
+func gameIntegrityProblem(game *GameState) string {
+	rows := MaxGuesses + game.ExtraRows
+	switch {
+	case len(game.SessionWord) != WordLength:
+		return "target word has the wrong length"
+	case game.ExtraRows < 0 || game.HintsUsed < 0:
+		return "negative counter"
+	case len(game.Guesses) != rows:
+		return fmt.Sprintf("board has %d rows, want %d", len(game.Guesses), rows)
+	case game.CurrentRow < 0 || game.CurrentRow > rows:
+		return fmt.Sprintf("current row %d out of range", game.CurrentRow)
+	case !game.GameOver && (game.Won || game.CurrentRow == rows):
+		return "finished game not marked over"
+	}
+	played := game.CurrentRow
+	if game.Won {
+		played++
+	}
+	if len(game.GuessHistory) != played {
+		return fmt.Sprintf("%d guesses in history, want %d", len(game.GuessHistory), played)
+	}
+	for i, row := range game.Guesses {
+		if len(row) != WordLength {
+			return fmt.Sprintf("row %d has %d tiles", i, len(row))
+		}
+		if i < played && !strings.EqualFold(guessLetters(row), game.GuessHistory[i]) {
+			return fmt.Sprintf("row %d does not match its guess", i)
+		}
+	}
+	return ""
+}
+
+// guessLetters joins the letters of a board row.
+func guessLetters(row []GuessResult) string {
+	var b strings.Builder
+	for _, r := range row {
+		b.WriteString(r.Letter)
+	}
+	return b.String()
+}
+
+// adminIntegrityHandler returns the latest integrity report, or 404 before the first scan.
+func (app *App) adminIntegrityHandler(c *gin.Context) {
+	report := app.Integrity.latest()
+	if report == nil {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "no integrity scan has run yet")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// adminIntegrityScanHandler runs an integrity scan now and returns its report.
+func (app *App) adminIntegrityScanHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, app.scanIntegrity())
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestIntegrityScanRepairsJournals(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Journal = newSessionJournal(t.TempDir())
+	app.Integrity = newIntegrityScanner()
+
+	app.Journal.start(dummyContext(), "session-torn", &GameState{GameState: engine.GameState{SessionWord: "CRANE"}})
+	app.Journal.record(dummyContext(), "session-torn", nil, JournalHint)
+	torn := app.Journal.path("session-torn")
+	f, err := os.OpenFile(torn, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("bogus\nguess\tSLATE\n")
+	f.Close()
+	garbage := filepath.Join(app.Journal.dirs[0], "garbage.log")
+	if err := os.WriteFile(garbage, []byte("guess\tCRANE\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	report := app.scanIntegrity()
+	if report.JournalsChecked != 2 || report.JournalsRepaired != 1 || report.JournalsRemoved != 1 {
+		t.Fatalf("report = %+v, want 2 checked, 1 repaired, 1 removed", report)
+	}
+	data, _ := os.ReadFile(torn)
+	if string(data) != "new\tCRANE\t\t\t\nhint\n" {
+		t.Errorf("repaired journal = %q", data)
+	}
+	if _, err := os.Stat(garbage); !os.IsNotExist(err) {
+		t.Errorf("journal without a game not removed: %v", err)
+	}
+	if app.Integrity.latest() != report {
+		t.Error("latest report not kept")
+	}
+}
+
+func TestIntegrityScanRepairsSessions(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Journal = newSessionJournal(t.TempDir())
+	app.Integrity = newIntegrityScanner()
+	app.Quarantine = newSessionQuarantine(time.Hour, 10)
+
+	good := app.createNewGame(dummyContext(), "session-good")
+	journaled := app.createNewGame(dummyContext(), "session-journaled")
+	journaled.GuessHistory = append(journaled.GuessHistory, "SLATE")
+	lost := app.createNewGame(dummyContext(), "session-lost")
+	lost.Guesses = lost.Guesses[:2]
+	os.Remove(app.Journal.path("session-lost"))
+
+	report := app.scanIntegrity()
+	if report.SessionsChecked != 3 || report.SessionsInvalid != 2 || report.SessionsRepaired != 1 {
+		t.Fatalf("report = %+v, want 3 checked, 2 invalid, 1 repaired", report)
+	}
+	if app.GameSessions["session-good"] != good {
+		t.Error("consistent session replaced")
+	}
+	if g := app.GameSessions["session-journaled"]; g == journaled || gameIntegrityProblem(g) != "" {
+		t.Errorf("broken session not rebuilt from its journal: %+v", g)
+	}
+	if _, ok := app.GameSessions["session-lost"]; ok {
+		t.Error("unrepairable session left in the live store")
+	}
+	if _, ok := app.Quarantine.take("session-lost", app.now()); !ok {
+		t.Error("unrepairable session not quarantined")
+	}
+}
@@ -0,0 +1,67 @@
+// Package htmx reads HTMX request headers and writes HTMX response headers, so handlers do not
+// spell out header names and JSON encodings by hand.
+package htmx
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Request and response header names
+const (
+	HeaderRequest  = "HX-Request"
+	HeaderTrigger  = "HX-Trigger"
+	HeaderRedirect = "HX-Redirect"
+	HeaderReswap   = "HX-Reswap"
+	HeaderRetarget = "HX-Retarget"
+)
+
+// Swap strategies for Reswap and OOB
+const (
+	SwapInnerHTML = "innerHTML"
+	SwapOuterHTML = "outerHTML"
+	SwapNone      = "none"
+)
+
+// IsRequest reports whether r was issued by HTMX.
+func IsRequest(r *http.Request) bool {
+	return r.Header.Get(HeaderRequest) == "true"
+}
+
+// Redirect tells HTMX to navigate the whole page to url instead of swapping the response.
+func Redirect(h http.Header, url string) {
+	h.Set(HeaderRedirect, url)
+}
+
+// Reswap overrides the swap strategy of the triggering element.
+func Reswap(h http.Header, strategy string) {
+	h.Set(HeaderReswap, strategy)
+}
+
+// Retarget swaps the response into the element matching selector instead of the request target.
+func Retarget(h http.Header, selector string) {
+	h.Set(HeaderRetarget, selector)
+}
+
+// Trigger sets the HX-Trigger header to events encoded as JSON. Nothing is written when events
+// is empty.
+func Trigger(h http.Header, events map[string]any) error {
+	if len(events) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	h.Set(HeaderTrigger, string(b))
+	return nil
+}
+
+// OOB returns an hx-swap-oob attribute value that swaps with strategy, into the element
+// matching selector when it is not empty and into the element with the same id otherwise.
+func OOB(strategy, selector string) string {
+	if selector == "" {
+		return strategy
+	}
+	return strategy + ":" + selector
+}
@@ -0,0 +1,56 @@
+package htmx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	if IsRequest(r) {
+		t.Error("Plain request reported as HTMX")
+	}
+	r.Header.Set(HeaderRequest, "true")
+	if !IsRequest(r) {
+		t.Error("HTMX request not detected")
+	}
+}
+
+func TestResponseHeaders(t *testing.T) {
+	h := http.Header{}
+	Redirect(h, "/captcha")
+	Reswap(h, SwapNone)
+	Retarget(h, "#board")
+	if err := Trigger(h, map[string]any{"csrf-invalid": map[string]string{"reason": "expired"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		HeaderRedirect: "/captcha",
+		HeaderReswap:   "none",
+		HeaderRetarget: "#board",
+		HeaderTrigger:  `{"csrf-invalid":{"reason":"expired"}}`,
+	}
+	for name, value := range want {
+		if got := h.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+
+	empty := http.Header{}
+	if err := Trigger(empty, nil); err != nil || len(empty) != 0 {
+		t.Errorf("Expected no header for no events, got %v, %v", empty, err)
+	}
+	if err := Trigger(empty, map[string]any{"bad": func() {}}); err == nil {
+		t.Error("Expected an error for an unencodable event")
+	}
+}
+
+func TestOOB(t *testing.T) {
+	if got := OOB(SwapOuterHTML, ""); got != "outerHTML" {
+		t.Errorf("OOB without selector = %q", got)
+	}
+	if got := OOB(SwapInnerHTML, "#keyboard"); got != "innerHTML:#keyboard" {
+		t.Errorf("OOB with selector = %q", got)
+	}
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session journal record types
+const (
+	JournalNew    = "new"
+	JournalPurist = "purist"
+	JournalGuess  = "guess"
+	JournalHint   = "hint"
+	JournalReveal = "reveal"
+	JournalRow    = "row"
+	// JournalKids turns the game into a kids game with the given number of rows.
+	JournalKids = "kids"
+	// JournalSnapshot holds a whole game, written in place of an event when earlier events
+	// were skipped.
+	JournalSnapshot = "snapshot"
+)
+
+// journalLockStripes is how many locks journal files are spread over, so writes for different
+// sessions rarely wait on each other.
+const journalLockStripes = 64
+
+// journalFinalBackoff is the wait before each retry of a failed game-over journal write.
+var journalFinalBackoff = []time.Duration{50 * time.Millisecond, 200 * time.Millisecond, 800 * time.Millisecond}
+
+// errJournalEmpty is returned when a journal has no complete "new" record to replay from.
+var errJournalEmpty = errors.New("journal has no game")
+
+// errPersistBudget is returned when a write is skipped because the request is about to time out.
+var errPersistBudget = errors.New("not enough request time left to persist")
+
+// SessionJournal appends each session's game events to a small per-session file, one
+// tab-separated line per event, so a game can be rebuilt after the process crashes or
+// restarts. Records are never rewritten: a new game truncates the file and later events are
+// appended, and a torn final line from a crash mid-write is ignored on replay. Journals can be
+// spread over several directories, each session's file placed by a consistent hash ring.
+type SessionJournal struct {
+	// files guards the journal files, striped by file name. A file's lock covers its copies
+	// on every shard.
+	files [journalLockStripes]sync.Mutex
+	// mu guards dirty.
+	mu     sync.Mutex
+	dirs   []string
+	ring   *HashRing
+	health *StoreHealth
+	// reserve is the request time that must be left for a write to be attempted.
+	reserve time.Duration
+	// dirty holds sessions whose journal missed a write. Their next write replaces the
+	// journal with a snapshot, since appending after a gap would replay the wrong game.
+	dirty map[string]struct{}
+}
+
+// newSessionJournal returns a journal writing under a comma-separated list of shard
+// directories, or nil when there are none, which disables journaling.
+func newSessionJournal(spec string) *SessionJournal {
+	var dirs []string
+	for dir := range strings.SplitSeq(spec, ",") {
+		if dir = strings.TrimSpace(dir); dir != "" && !slices.Contains(dirs, filepath.Clean(dir)) {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+	return &SessionJournal{dirs: dirs, ring: newHashRing(dirs), dirty: make(map[string]struct{})}
+}
+
+// fileName returns the journal file name for a session. Session IDs are hashed so they never
+// hit disk.
+func (j *SessionJournal) fileName(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:16]) + ".log"
+}
+
+// fileLock returns the lock guarding the journal file name.
+func (j *SessionJournal) fileLock(name string) *sync.Mutex {
+	return &j.files[ringHash(name)%journalLockStripes]
+}
+
+// path returns the journal file for a session on the shard that owns it.
+func (j *SessionJournal) path(sessionID string) string {
+	name := j.fileName(sessionID)
+	return filepath.Join(j.ring.owner(name), name)
+}
+
+// write appends one record, or replaces the file with it when truncate is set. Each record is
+// a single write so concurrent appends never interleave within a line. The write is skipped
+// when ctx is within reserve of its deadline, leaving the game in memory only; a skipped or
+// failed write marks the session dirty.
+func (j *SessionJournal) write(ctx context.Context, sessionID string, truncate bool, fields ...string) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if truncate {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	lock := j.fileLock(j.fileName(sessionID))
+	lock.Lock()
+	defer lock.Unlock()
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < j.reserve {
+		j.setDirty(sessionID, true)
+		return errPersistBudget
+	}
+	start := time.Now()
+	err := j.append(sessionID, flags, strings.Join(fields, "\t")+"\n")
+	j.health.observe(time.Since(start), err)
+	if err != nil {
+		j.setDirty(sessionID, true)
+		return err
+	}
+	if truncate {
+		j.setDirty(sessionID, false)
+	}
+	return nil
+}
+
+// setDirty marks or clears a session whose journal missed a write.
+func (j *SessionJournal) setDirty(sessionID string, dirty bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if dirty {
+		j.dirty[sessionID] = struct{}{}
+	} else {
+		delete(j.dirty, sessionID)
+	}
+}
+
+// isDirty reports whether the session's journal missed a write.
+func (j *SessionJournal) isDirty(sessionID string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, ok := j.dirty[sessionID]
+	return ok
+}
+
+// append writes one line to the session's journal. Callers must hold the file's lock.
+func (j *SessionJournal) append(sessionID string, flags int, line string) error {
+	path := j.path(sessionID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		logWarn("Failed to create session journal dir: %v", err)
+		return err
+	}
+	if err := j.settle(j.fileName(sessionID)); err != nil {
+		logWarn("Failed to move session journal for %s to its shard: %v", redactSession(sessionID), err)
+		return err
+	}
+	f, err := os.OpenFile(path, flags, 0o600)
+	if err != nil {
+		logWarn("Failed to open session journal for %s: %v", redactSession(sessionID), err)
+		return err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(line); err != nil {
+		logWarn("Failed to append to session journal for %s: %v", redactSession(sessionID), err)
+		return err
+	}
+	return nil
+}
+
+// start begins a new journal for game, replacing the session's previous one.
+func (j *SessionJournal) start(ctx context.Context, sessionID string, game *GameState) error {
+	if j == nil {
+		return nil
+	}
+	var flags []string
+	if game.Custom {
+		flags = append(flags, "custom")
+	}
+	if game.Purist {
+		flags = append(flags, JournalPurist)
+	}
+	if game.Daily != "" {
+		flags = append(flags, ModeDaily+":"+game.Daily)
+	}
+	fields := []string{JournalNew, game.SessionWord, game.Pack, hex.EncodeToString(game.Completed), strings.Join(flags, ",")}
+	if game.WordsVersion != "" {
+		fields = append(fields, game.WordsVersion)
+	}
+	return j.write(ctx, sessionID, true, fields...)
+}
+
+// record appends an event, already applied to game, to the session's journal. A dirty journal
+// is replaced with a snapshot of game instead.
+func (j *SessionJournal) record(ctx context.Context, sessionID string, game *GameState, fields ...string) error {
+	if j == nil {
+		return nil
+	}
+	if j.isDirty(sessionID) {
+		return j.snapshot(ctx, sessionID, game)
+	}
+	return j.write(ctx, sessionID, false, fields...)
+}
+
+// recordFinal records the event that ended game. The final state is what a restored session
+// needs to keep its result and progress token, so the write ignores the request budget.
+func (j *SessionJournal) recordFinal(sessionID string, game *GameState, fields ...string) error {
+	return j.record(context.Background(), sessionID, game, fields...)
+}
+
+// dirtySessions returns the sessions whose journal missed a write.
+func (j *SessionJournal) dirtySessions() []string {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return slices.Collect(maps.Keys(j.dirty))
+}
+
+// forget drops a session from the dirty set, for sessions that no longer exist.
+func (j *SessionJournal) forget(sessionID string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.dirty, sessionID)
+}
+
+// snapshot replaces the session's journal with the whole of game.
+func (j *SessionJournal) snapshot(ctx context.Context, sessionID string, game *GameState) error {
+	data, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+	return j.write(ctx, sessionID, true, JournalSnapshot, base64.RawStdEncoding.EncodeToString(data))
+}
+
+// load rebuilds the session's game from its journal. Journals untouched for longer than
+// maxAge are treated as missing when maxAge is positive. File times are wall-clock, so now
+// should be too rather than the app's shifted clock.
+func (j *SessionJournal) load(sessionID string, maxAge time.Duration, now time.Time, valid func(string) bool) (*GameState, error) {
+	if j == nil {
+		return nil, errJournalEmpty
+	}
+	lock := j.fileLock(j.fileName(sessionID))
+	lock.Lock()
+	path := j.path(sessionID)
+	if err := j.settle(j.fileName(sessionID)); err != nil {
+		logWarn("Failed to move session journal for %s to its shard: %v", redactSession(sessionID), err)
+	}
+	info, err := os.Stat(path)
+	var data []byte
+	if err == nil {
+		data, err = os.ReadFile(path)
+	}
+	lock.Unlock()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errJournalEmpty
+	}
+	if err != nil {
+		return nil, err
+	}
+	if maxAge > 0 && now.Sub(info.ModTime()) > maxAge {
+		return nil, errJournalEmpty
+	}
+	return replayJournal(data, valid)
+}
+
+// prune deletes journals untouched since before cutoff and returns how many were removed.
+func (j *SessionJournal) prune(cutoff time.Time) int {
+	paths, _ := j.stale(cutoff)
+	removed := 0
+	for _, path := range paths {
+		if j.removeStale(path, cutoff) == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// stale returns the journal files untouched since before cutoff, with any shard that could
+// not be listed reported as an error.
+func (j *SessionJournal) stale(cutoff time.Time) ([]string, []error) {
+	if j == nil {
+		return nil, nil
+	}
+	var paths []string
+	var errs []error
+	for _, dir := range j.dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil || e.IsDir() || filepath.Ext(e.Name()) != ".log" || !info.ModTime().Before(cutoff) {
+				continue
+			}
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	return paths, errs
+}
+
+// removeStale deletes a journal file if it is still untouched since before cutoff.
+func (j *SessionJournal) removeStale(path string, cutoff time.Time) error {
+	lock := j.fileLock(filepath.Base(path))
+	lock.Lock()
+	defer lock.Unlock()
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) || (err == nil && !info.ModTime().Before(cutoff)) {
+		return errOpSkipped
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// journalID names a journal file in reports. File names are already hashes of session IDs.
+func journalID(path string) string {
+	return filepath.Base(path)
+}
+
+// replayJournal applies the complete records in data to rebuild a game. Guesses are scored
+// again, with valid deciding whether a guess can win as in processGuess. Replay stops at the
+// first incomplete or unreadable record, keeping everything before it.
+func replayJournal(data []byte, valid func(string) bool) (*GameState, error) {
+	game, _ := replayJournalPrefix(data, valid)
+	if game == nil {
+		return nil, errJournalEmpty
+	}
+	return game, nil
+}
+
+// replayJournalPrefix replays data like replayJournal and also returns how many bytes of it
+// were replayed, so anything after that point is a torn or unreadable tail.
+func replayJournalPrefix(data []byte, valid func(string) bool) (*GameState, int) {
+	var game *GameState
+	used := 0
+	lines := strings.Split(string(data), "\n")
+	// The text after the last newline is empty, or a record torn by a crash.
+	for _, line := range lines[:len(lines)-1] {
+		fields := strings.Split(line, "\t")
+		if fields[0] == JournalNew || fields[0] == JournalSnapshot {
+			g, err := journalGame(fields)
+			if err != nil {
+				break
+			}
+			game = g
+		} else if game == nil || !applyJournalEvent(game, fields, valid) {
+			break
+		}
+		used += len(line) + 1
+	}
+	return game, used
+}
+
+// journalGame builds the game a "new" or "snapshot" record starts from.
+func journalGame(fields []string) (*GameState, error) {
+	if fields[0] == JournalSnapshot {
+		if len(fields) != 2 {
+			return nil, errors.New("malformed snapshot record")
+		}
+		data, err := base64.RawStdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		var game GameState
+		if err := json.Unmarshal(data, &game); err != nil {
+			return nil, err
+		}
+		if len(game.SessionWord) != WordLength || len(game.Guesses) != MaxGuesses+game.ExtraRows {
+			return nil, errors.New("malformed snapshot record")
+		}
+		return &game, nil
+	}
+	return journalNewGame(fields)
+}
+
+// journalNewGame builds the fresh game described by a "new" record.
+func journalNewGame(fields []string) (*GameState, error) {
+	if (len(fields) != 5 && len(fields) != 6) || len(fields[1]) != WordLength {
+		return nil, errors.New("malformed new record")
+	}
+	completed, err := hex.DecodeString(fields[3])
+	if err != nil {
+		return nil, err
+	}
+	game := newGame(fields[1])
+	game.Pack = fields[2]
+	if len(completed) > 0 {
+		game.Completed = completed
+	}
+	for _, flag := range strings.Split(fields[4], ",") {
+		switch flag {
+		case "custom":
+			game.Custom = true
+		case JournalPurist:
+			game.Purist = true
+		default:
+			if date, ok := strings.CutPrefix(flag, ModeDaily+":"); ok {
+				game.Daily = date
+			}
+		}
+	}
+	if len(fields) == 6 {
+		game.WordsVersion = fields[5]
+	}
+	return game, nil
+}
+
+// applyJournalEvent replays one event record on game, reporting false if it is malformed.
+func applyJournalEvent(game *GameState, fields []string, valid func(string) bool) bool {
+	switch {
+	case fields[0] == JournalGuess && len(fields) == 2 && len(fields[1]) == WordLength:
+		game.ApplyGuess(fields[1], game.SessionWord, checkGuess(fields[1], game.SessionWord), valid(fields[1]))
+	case fields[0] == JournalPurist && len(fields) == 1:
+		game.Purist = true
+	case fields[0] == JournalHint && len(fields) == 1:
+		game.HintsUsed++
+	case fields[0] == JournalReveal && len(fields) == 1:
+		game.reveal(game.SessionWord)
+	case fields[0] == JournalRow && len(fields) == 1:
+		game.addRow()
+	case fields[0] == JournalKids && len(fields) == 2:
+		rows, err := strconv.Atoi(fields[1])
+		if err != nil || rows > MaxKidsGuesses {
+			return false
+		}
+		kidsRows(game, rows)
+	default:
+		return false
+	}
+	return true
+}
+
+// journalGuess records a guess already applied to game. Near the request deadline an ordinary
+// guess is kept in memory only and the journal catches up with a snapshot later, so a slow
+// disk cannot turn a guess into a timeout. The guess that ends the game is always written.
+func (app *App) journalGuess(ctx context.Context, sessionID string, game *GameState, guess string) {
+	if !game.GameOver {
+		if err := app.Journal.record(ctx, sessionID, game, JournalGuess, guess); errors.Is(err, errPersistBudget) {
+			app.incMetric(MetricPersistDeferred)
+			logWarn("Deferred journaling a guess for session %s: request deadline near", redactSession(sessionID))
+		}
+		return
+	}
+	if err := app.Journal.recordFinal(sessionID, game, JournalGuess, guess); err != nil {
+		logWarn("Failed to journal the end of the game for session %s, retrying: %v", redactSession(sessionID), err)
+		app.SessionMutex.RLock()
+		final := cloneGame(game)
+		app.SessionMutex.RUnlock()
+		go app.retryFinalJournal(sessionID, game, final)
+	}
+}
+
+// retryFinalJournal retries a failed game-over journal write with backoff, off the request
+// path, before leaving it to the periodic flush. Each retry snapshots final, a copy of game
+// taken when the write failed, and retries stop once the session has moved on to another game
+// or another write has caught the journal up, so a newer game is never overwritten.
+func (app *App) retryFinalJournal(sessionID string, game, final *GameState) {
+	var err error
+	for _, wait := range journalFinalBackoff {
+		time.Sleep(wait)
+		if !app.Journal.isDirty(sessionID) {
+			return
+		}
+		app.SessionMutex.RLock()
+		current, ok := app.GameSessions[sessionID]
+		app.SessionMutex.RUnlock()
+		if !ok {
+			app.Journal.forget(sessionID)
+			return
+		}
+		if current != game {
+			return
+		}
+		app.incMetric(MetricPersistFinalRetries)
+		if err = app.Journal.snapshot(context.Background(), sessionID, final); err == nil {
+			return
+		}
+	}
+	logWarn("Failed to journal the end of the game for session %s after %d retries: %v", redactSession(sessionID), len(journalFinalBackoff), err)
+}
+
+// flushDirtyJournals writes a snapshot for every session whose journal missed a write, so a
+// game that goes quiet after a skipped write is still recoverable. Each snapshot is of a copy
+// taken under SessionMutex, since a guess may be applied to the game meanwhile. Sessions no
+// longer in memory are forgotten. It returns how many journals were written.
+func (app *App) flushDirtyJournals(ctx context.Context) int {
+	flushed := 0
+	for _, id := range app.Journal.dirtySessions() {
+		app.SessionMutex.RLock()
+		game, ok := app.GameSessions[id]
+		if ok {
+			game = cloneGame(game)
+		}
+		app.SessionMutex.RUnlock()
+		if !ok {
+			app.Journal.forget(id)
+			continue
+		}
+		if err := app.Journal.snapshot(ctx, id, game); err != nil {
+			logWarn("Failed to flush journal for session %s: %v", redactSession(id), err)
+			continue
+		}
+		flushed++
+	}
+	return flushed
+}
+
+// flushJournals flushes dirty journals every interval for the life of the process.
+func (app *App) flushJournals(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if n := app.flushDirtyJournals(context.Background()); n > 0 {
+			if app.Metrics != nil {
+				app.Metrics.Add(MetricPersistFlushed, int64(n))
+			}
+			logInfo("Flushed %d dirty session journals", n)
+		}
+	}
+}
+
+// restoreFromJournal rebuilds a session missing from memory from its journal, for example
+// after a restart, and stores it. It returns nil when there is nothing to restore.
+func (app *App) restoreFromJournal(sessionID string) *GameState {
+	if app.Journal == nil {
+		return nil
+	}
+	game, err := app.Journal.load(sessionID, app.Retention.Sessions, time.Now(), app.isValidWord)
+	if err != nil {
+		if !errors.Is(err, errJournalEmpty) {
+			logWarn("Failed to restore session %s from journal: %v", redactSession(sessionID), err)
+		}
+		return nil
+	}
+	if game.GameOver && !game.Custom && game.Daily == "" {
+		app.issueProgressToken(game)
+	}
+	app.saveGameState(sessionID, game)
+	app.incMetric(MetricSessionsJournalRestored)
+	logInfo("Restored session %s from its journal", redactSession(sessionID))
+	return game
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestSessionJournalRestoresAfterRestart(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Journal = newSessionJournal(t.TempDir())
+	game := app.createNewGame(dummyContext(), "session-123")
+	guess := "SLATE"
+	if game.SessionWord == guess {
+		guess = "CRANE"
+	}
+	app.Journal.record(dummyContext(), "session-123", game, JournalGuess, guess)
+	app.Journal.record(dummyContext(), "session-123", game, JournalHint)
+
+	delete(app.GameSessions, "session-123")
+	restored := app.getGameState(dummyContext(), "session-123")
+	if restored.SessionWord != game.SessionWord || len(restored.GuessHistory) != 1 || restored.HintsUsed != 1 {
+		t.Fatalf("restored game = %+v, want the journaled word, guess, and hint", restored)
+	}
+	if restored.Guesses[0][0].Status == "" {
+		t.Error("restored guess was not scored")
+	}
+}
+
+func TestReplayJournalIgnoresTornRecord(t *testing.T) {
+	valid := func(string) bool { return true }
+	game, err := replayJournal([]byte("new\tCRANE\tclassic\t\t\nguess\tSLATE\nguess\tCRA"), valid)
+	if err != nil || len(game.GuessHistory) != 1 || game.GameOver {
+		t.Fatalf("replay = %+v, %v; want only the complete guess", game, err)
+	}
+	game, err = replayJournal([]byte("new\tCRANE\tclassic\t\tpurist\nguess\tCRANE\nrow\n"), valid)
+	if err != nil || !game.Won || !game.Purist {
+		t.Errorf("replay = %+v, %v; want a won purist game", game, err)
+	}
+	if _, err := replayJournal([]byte("guess\tCRANE\n"), valid); err == nil {
+		t.Error("journal without a new record replayed")
+	}
+}
+
+func TestSessionJournalPrunesStaleFiles(t *testing.T) {
+	j := newSessionJournal(t.TempDir())
+	j.start(dummyContext(), "session-old", &GameState{GameState: engine.GameState{SessionWord: "CRANE"}})
+	j.start(dummyContext(), "session-new", &GameState{GameState: engine.GameState{SessionWord: "SLATE"}})
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(j.path("session-old"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.load("session-old", time.Hour, time.Now(), nil); err != errJournalEmpty {
+		t.Errorf("stale journal loaded: %v", err)
+	}
+	if n := j.prune(time.Now().Add(-time.Hour)); n != 1 {
+		t.Errorf("prune() = %d, want 1", n)
+	}
+	if _, err := os.Stat(j.path("session-new")); err != nil {
+		t.Errorf("fresh journal pruned: %v", err)
+	}
+}
+
+func TestSessionJournalSnapshotsAfterSkippedWrite(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Journal = newSessionJournal(t.TempDir())
+	app.Journal.reserve = time.Second
+	game := app.createNewGame(dummyContext(), "session-123")
+
+	rushed, cancel := context.WithTimeout(dummyContext(), 10*time.Millisecond)
+	defer cancel()
+	game.HintsUsed++
+	if err := app.Journal.record(rushed, "session-123", game, JournalHint); err != errPersistBudget {
+		t.Fatalf("record() near the deadline = %v, want errPersistBudget", err)
+	}
+	game.HintsUsed++
+	if err := app.Journal.record(dummyContext(), "session-123", game, JournalHint); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := app.Journal.load("session-123", 0, time.Now(), app.isValidWord)
+	if err != nil || restored.HintsUsed != 2 || restored.SessionWord != game.SessionWord {
+		t.Errorf("restored = %+v, %v; want both hints from the snapshot", restored, err)
+	}
+	if app.Journal.isDirty("session-123") {
+		t.Error("journal still dirty after its snapshot")
+	}
+}
+
+func TestFlushDirtyJournals(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Journal = newSessionJournal(t.TempDir())
+	app.Journal.reserve = time.Second
+	game := app.createNewGame(dummyContext(), "session-live")
+	app.createNewGame(dummyContext(), "session-gone")
+
+	rushed, cancel := context.WithTimeout(dummyContext(), 10*time.Millisecond)
+	defer cancel()
+	game.HintsUsed++
+	app.Journal.record(rushed, "session-live", game, JournalHint)
+	app.Journal.record(rushed, "session-gone", nil, JournalHint)
+	delete(app.GameSessions, "session-gone")
+
+	if n := app.flushDirtyJournals(dummyContext()); n != 1 {
+		t.Fatalf("flushDirtyJournals() = %d, want 1", n)
+	}
+	if dirty := app.Journal.dirtySessions(); len(dirty) != 0 {
+		t.Errorf("dirty after flush: %v", dirty)
+	}
+	restored, err := app.Journal.load("session-live", 0, time.Now(), app.isValidWord)
+	if err != nil || restored.HintsUsed != 1 {
+		t.Errorf("restored = %+v, %v; want the hint from the flushed snapshot", restored, err)
+	}
+}
+
+func TestFlushDirtyJournalsWhileGuessing(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Journal = newSessionJournal(t.TempDir())
+	game := app.createNewGame(dummyContext(), "session-123")
+	target := game.SessionWord
+
+	done := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			app.Journal.setDirty("session-123", true)
+			app.flushDirtyJournals(dummyContext())
+		}
+	}()
+	for range engine.MaxGuesses {
+		guess := "SLATE"
+		if target == guess {
+			guess = "CRANE"
+		}
+		app.updateGameState(dummyContext(), game, guess, target, checkGuess(guess, target), false)
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	<-flushed
+
+	app.Journal.setDirty("session-123", true)
+	app.flushDirtyJournals(dummyContext())
+	restored, err := app.Journal.load("session-123", 0, time.Now(), app.isValidWord)
+	if err != nil || !restored.GameOver || len(restored.GuessHistory) != engine.MaxGuesses {
+		t.Errorf("restored = %+v, %v; want the lost game from the last flush", restored, err)
+	}
+}
+
+func TestJournalFinalGuessIgnoresBudgetAndRetries(t *testing.T) {
+	root := t.TempDir()
+	blocker := root + "/journal"
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Metrics = newMetrics()
+	app.Journal = newSessionJournal(blocker)
+	app.Journal.reserve = time.Hour
+	game := &GameState{GameState: engine.GameState{SessionWord: "CRANE", Guesses: engine.NewBoard()}}
+	game.ApplyGuess("CRANE", "CRANE", checkGuess("CRANE", "CRANE"), true)
+	app.saveGameState("session-123", game)
+
+	// The journal dir cannot be created until the file in its way is removed, which only
+	// happens once the request has returned, so the retries must run after it.
+	rushed, cancel := context.WithTimeout(dummyContext(), time.Millisecond)
+	defer cancel()
+	app.journalGuess(rushed, "session-123", game, "CRANE")
+	app.SessionMutex.Lock()
+	game.HintsUsed++
+	app.SessionMutex.Unlock()
+	os.Remove(blocker)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for app.Journal.isDirty("session-123") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	restored, err := app.Journal.load("session-123", 0, time.Now(), app.isValidWord)
+	if err != nil || !restored.Won || restored.HintsUsed != 0 {
+		t.Fatalf("restored = %+v, %v; want the won game as it was when the write failed", restored, err)
+	}
+	if app.Metrics.Get(MetricPersistFinalRetries) == nil {
+		t.Error("retries not counted")
+	}
+}
+
+func TestJournalWritesForDifferentSessionsDoNotWait(t *testing.T) {
+	j := newSessionJournal(t.TempDir())
+	a, b := "session-aaa", "session-bbb"
+	for j.fileLock(j.fileName(a)) == j.fileLock(j.fileName(b)) {
+		b += "b"
+	}
+	lock := j.fileLock(j.fileName(a))
+	lock.Lock()
+	defer lock.Unlock()
+	done := make(chan error)
+	go func() { done <- j.start(dummyContext(), b, newGame("CRANE")) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a write for one session waited on another session's journal")
+	}
+}
+
+// benchmarkJournal returns an app with a journal holding a game of three guesses.
+func benchmarkJournal(b *testing.B) (*App, *GameState) {
+	app := testAppWithWords([]WordEntry{{Word: "TRACE"}, {Word: "CRANE"}, {Word: "SLATE"}, {Word: "TRACK"}})
+	app.Journal = newSessionJournal(b.TempDir())
+	game := newGame("TRACE")
+	game.SessionWord = "TRACE"
+	game.Pack = DefaultPackName
+	if err := app.Journal.start(dummyContext(), "session-123", game); err != nil {
+		b.Fatal(err)
+	}
+	for _, guess := range []string{"CRANE", "SLATE", "TRACK"} {
+		game.ApplyGuess(guess, "TRACE", checkGuess(guess, "TRACE"), true)
+		app.Journal.record(dummyContext(), "session-123", game, JournalGuess, guess)
+	}
+	return app, game
+}
+
+func BenchmarkSessionJournalSave(b *testing.B) {
+	app, game := benchmarkJournal(b)
+	for b.Loop() {
+		if err := app.Journal.snapshot(dummyContext(), "session-123", game); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSessionJournalLoad(b *testing.B) {
+	app, _ := benchmarkJournal(b)
+	for b.Loop() {
+		if _, err := app.Journal.load("session-123", 0, time.Now(), app.isValidWord); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"sync/atomic"
+)
+
+// keyIDLength is the length of a signing key ID, in base64url characters.
+const keyIDLength = 4
+
+// signingKey is one secret in a Keyring. Its ID is derived from the secret, so tokens can name
+// the key that signed them without operators having to assign IDs.
+type signingKey struct {
+	id     string
+	secret []byte
+}
+
+// Keyring holds the secret that signs new tokens and the retired secrets still accepted when
+// verifying old ones, so rotating PROGRESS_SECRET does not invalidate every outstanding
+// progress token, custom game link, player cookie, and calendar feed at once. Tokens carry the
+// ID of the key that signed them; tokens from before key IDs carry none and are checked against
+// every key.
+type Keyring struct {
+	keys []signingKey
+	// retiredUses counts tokens accepted under a retired key, shared by derived keyrings, so
+	// operators can tell when a retired secret is safe to drop.
+	retiredUses *atomic.Int64
+}
+
+// newKeyring returns a keyring that signs with current and also accepts the previous secrets.
+func newKeyring(current []byte, previous ...[]byte) *Keyring {
+	kr := &Keyring{retiredUses: new(atomic.Int64)}
+	for _, secret := range append([][]byte{current}, previous...) {
+		kr.keys = append(kr.keys, signingKey{id: keyID(secret), secret: secret})
+	}
+	return kr
+}
+
+// loadKeyring returns the keyring for PROGRESS_SECRET and the whitespace-separated retired
+// secrets in PROGRESS_SECRET_PREVIOUS. An empty current secret generates a random key, which
+// means nothing signed survives a restart.
+func loadKeyring(current, previous string) *Keyring {
+	secret := []byte(current)
+	if current == "" {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			logFatal("Failed to generate signing key: %v", err)
+		}
+		logWarn("PROGRESS_SECRET not set; completion progress, game links, and player IDs will reset when the server restarts")
+	}
+	var retired [][]byte
+	for _, s := range strings.Fields(previous) {
+		retired = append(retired, []byte(s))
+	}
+	kr := newKeyring(secret, retired...)
+	if len(retired) > 0 {
+		logInfo("Signing with key %s; still accepting %s", kr.current().id, strings.Join(kr.retiredIDs(), ", "))
+	}
+	return kr
+}
+
+// keyID returns the ID of secret: a short HMAC of a fixed label, which identifies the key
+// without revealing anything about it.
+func keyID(secret []byte) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte("key-id"))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))[:keyIDLength]
+}
+
+// current returns the key new tokens are signed with.
+func (kr *Keyring) current() signingKey {
+	return kr.keys[0]
+}
+
+// retiredIDs returns the IDs of the keys only accepted for verification.
+func (kr *Keyring) retiredIDs() []string {
+	ids := make([]string, 0, len(kr.keys)-1)
+	for _, k := range kr.keys[1:] {
+		ids = append(ids, k.id)
+	}
+	return ids
+}
+
+// verify reports whether check accepts a token under one of the keys it may have been signed
+// with: the key named by id, or every key when id is empty because the token predates key IDs.
+// A token accepted under a retired key is counted in retiredUses.
+func (kr *Keyring) verify(id string, check func(key signingKey) bool) bool {
+	for _, k := range kr.keys {
+		if (id == "" || k.id == id) && check(k) {
+			if k.id != kr.current().id {
+				kr.retiredUses.Add(1)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// retiredVerifications returns the number of tokens accepted under a retired key.
+func (kr *Keyring) retiredVerifications() int64 {
+	return kr.retiredUses.Load()
+}
+
+// derive returns a keyring of independent 32-byte subkeys for label, so other token types
+// share the secrets' configuration and rotation without sharing their keys. Subkeys keep the
+// ID of the secret they were derived from.
+func (kr *Keyring) derive(label string) *Keyring {
+	derived := &Keyring{retiredUses: kr.retiredUses}
+	for _, k := range kr.keys {
+		h := hmac.New(sha256.New, k.secret)
+		h.Write([]byte(label))
+		derived.keys = append(derived.keys, signingKey{id: k.id, secret: h.Sum(nil)})
+	}
+	return derived
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+)
+
+func TestKeyringRotationKeepsOldTokens(t *testing.T) {
+	oldKeys := newKeyring([]byte("old-secret"))
+	rotated := newKeyring([]byte("new-secret"), []byte("old-secret"))
+	dropped := newKeyring([]byte("new-secret"))
+	if oldKeys.current().id != rotated.retiredIDs()[0] || rotated.current().id == oldKeys.current().id {
+		t.Fatalf("Expected key IDs to follow the secrets, got %s and %v", rotated.current().id, rotated.retiredIDs())
+	}
+
+	pack := newWordPack("animals", []WordEntry{{Word: "CAMEL"}, {Word: "HORSE"}})
+	progress := newProgressTokens(oldKeys).issue(pack, pack.completionBitmap([]string{"HORSE"}))
+	if _, err := newProgressTokens(rotated).verify(pack, progress); err != nil {
+		t.Errorf("Expected progress token to survive rotation, got %v", err)
+	}
+	if _, err := newProgressTokens(dropped).verify(pack, progress); err == nil {
+		t.Error("Expected progress token to fail once its key is dropped")
+	}
+
+	game := newGameTokens(oldKeys.derive("custom-games")).seal("animals", "CAMEL")
+	if _, word, err := newGameTokens(rotated.derive("custom-games")).open(game); err != nil || word != "CAMEL" {
+		t.Errorf("Expected game link to survive rotation, got %q, %v", word, err)
+	}
+	if _, _, err := newGameTokens(dropped.derive("custom-games")).open(game); err == nil {
+		t.Error("Expected game link to fail once its key is dropped")
+	}
+
+	oldIDs, newIDs := newPlayerIDs(oldKeys.derive("player-ids")), newPlayerIDs(rotated.derive("player-ids"))
+	if id, ok := newIDs.verify(oldIDs.issue("p1")); !ok || id != "p1" {
+		t.Errorf("Expected player cookie to survive rotation, got %q, %v", id, ok)
+	}
+	if id, ok := newIDs.verifyCalendarToken(oldIDs.calendarToken("p1")); !ok || id != "p1" {
+		t.Errorf("Expected calendar token to survive rotation, got %q, %v", id, ok)
+	}
+	if _, ok := newIDs.verify(oldIDs.calendarToken("p1")); ok {
+		t.Error("Expected a calendar token not to pass as a player cookie")
+	}
+	if rotated.retiredVerifications() != 4 {
+		t.Errorf("Expected 4 retired key verifications, got %d", rotated.retiredVerifications())
+	}
+
+	if _, ok := newIDs.verify(newIDs.issue("p1")); !ok || rotated.retiredVerifications() != 4 {
+		t.Error("Expected a current-key cookie to verify without counting as retired")
+	}
+	if _, ok := newIDs.verify("p1.zzzz." + playerMAC(rotated.derive("player-ids").current().secret, "", "p1")); ok {
+		t.Error("Expected an unknown key ID to be rejected")
+	}
+}
+
+func TestKeyringAcceptsTokensWithoutKeyIDs(t *testing.T) {
+	secret := []byte("legacy-secret")
+	keys := newKeyring([]byte("new-secret"), secret)
+	pack := newWordPack("animals", []WordEntry{{Word: "CAMEL"}, {Word: "HORSE"}})
+
+	payload := binary.BigEndian.AppendUint64([]byte{progressTokenFormatV1}, pack.Version)
+	payload = append(payload, 0b10)
+	progress := base64.RawURLEncoding.EncodeToString(append(payload, progressMAC(secret, pack.Name, payload)...))
+	if bitmap, err := newProgressTokens(keys).verify(pack, progress); err != nil || pack.completedWords(bitmap)[0] != "HORSE" {
+		t.Errorf("Expected a version 1 progress token to verify, got %v", err)
+	}
+
+	playerKey := keys.derive("player-ids").keys[1].secret
+	if id, ok := newPlayerIDs(keys.derive("player-ids")).verify("p1." + playerMAC(playerKey, "", "p1")); !ok || id != "p1" {
+		t.Errorf("Expected a cookie without a key ID to verify, got %q, %v", id, ok)
+	}
+
+	gameKey := keys.derive("custom-games").keys[1].secret
+	block, _ := aes.NewCipher(gameKey)
+	aead, _ := cipher.NewGCM(block)
+	nonce := make([]byte, aead.NonceSize())
+	game := base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte("animals\x00CAMEL"), nil))
+	if _, word, err := newGameTokens(keys.derive("custom-games")).open(game); err != nil || word != "CAMEL" {
+		t.Errorf("Expected a game link without a key ID to open, got %q, %v", word, err)
+	}
+}
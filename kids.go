@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModeKids is the family-friendly new-game mode: words from a simple pack, more guesses, hints
+// always shown, gentler messages, and stats of its own.
+const ModeKids = "kids"
+
+// Kids board sizes: DefaultKidsMaxGuesses rows unless KIDS_MAX_GUESSES says otherwise, up to
+// MaxKidsGuesses
+const (
+	DefaultKidsMaxGuesses = 10
+	MaxKidsGuesses        = 20
+)
+
+// KidsRules configures kids mode. Pack names the word pack kids games draw from, falling back
+// to the default pack when it does not exist, and MaxGuesses is the number of rows on the board.
+type KidsRules struct {
+	Pack       string
+	MaxGuesses int
+}
+
+// kidsErrorMessages replaces the error messages shown in kids games with gentler ones.
+var kidsErrorMessages = map[string]string{
+	ErrorCodeGameOver:        "That game is finished. Let's play another one!",
+	ErrorCodeInvalidLength:   "Words need 5 letters. Keep going!",
+	ErrorCodeNoMoreGuesses:   "That was a great try! Let's start a new game.",
+	ErrorCodeNotInWordList:   "Hmm, we don't know that word. Try another one!",
+	ErrorCodeWordNotAccepted: "Hmm, we don't know that word. Try another one!",
+	ErrorCodeDuplicateGuess:  "You tried that word already. Pick a new one!",
+}
+
+// newKidsRules returns the kids rules for pack and maxGuesses, keeping the board between the
+// regular MaxGuesses and MaxKidsGuesses rows.
+func newKidsRules(pack string, maxGuesses int) KidsRules {
+	return KidsRules{Pack: pack, MaxGuesses: min(max(maxGuesses, MaxGuesses), MaxKidsGuesses)}
+}
+
+// requestedKids reports whether a new-game request asked for kids mode.
+func requestedKids(c *gin.Context) bool {
+	return c.PostForm("mode") == ModeKids
+}
+
+// gameErrorMessage returns the user-facing message for an error code in game.
+func gameErrorMessage(game *GameState, code string) string {
+	if game != nil && game.Kids {
+		if msg, ok := kidsErrorMessages[code]; ok {
+			return msg
+		}
+	}
+	return errorMessage(code)
+}
+
+// kidsRows gives game at least rows guesses.
+func kidsRows(game *GameState, rows int) {
+	game.Kids = true
+	for game.Rows() < rows {
+		game.addRow()
+	}
+}
+
+// makeKids turns a new game into a kids game with rows guesses and journals the change.
+func (app *App) makeKids(ctx context.Context, sessionID string, game *GameState, rows int) {
+	kidsRows(game, rows)
+	app.Journal.record(ctx, sessionID, game, JournalKids, strconv.Itoa(rows))
+}
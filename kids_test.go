@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestKidsGamesUseTheirPackRowsAndStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{GameSessions: make(map[string]*GameState), Kids: newKidsRules(ModeKids, 50), Coins: CoinRules{PerWin: 10}}
+	app.registerWordPacks([]*WordPack{
+		newWordPack(DefaultPackName, []WordEntry{{Word: "CRANE", Hint: "a bird"}}),
+		newWordPack(ModeKids, []WordEntry{{Word: "APPLE", Hint: "a fruit"}}),
+	}, nil, nil)
+	app.Journal = newSessionJournal(t.TempDir())
+	app.Players = newPlayerStatsStore(StreakFreezeRules{})
+
+	router := gin.New()
+	router.POST(RouteNewGame, app.newGameHandler)
+	req := httptest.NewRequest("POST", RouteNewGame, strings.NewReader(url.Values{"mode": {ModeKids}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session-kids"})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	game := app.GameSessions["session-kids"]
+	if game == nil || !game.Kids || game.Pack != ModeKids || game.SessionWord != "APPLE" || game.Rows() != MaxKidsGuesses {
+		t.Fatalf("kids game = %+v, want APPLE from the kids pack on %d rows", game, MaxKidsGuesses)
+	}
+	if app.gameHint(game) != "a fruit" || app.casualGame("session-kids", game) {
+		t.Error("kids games should show hints and stay out of the coin economy")
+	}
+	if msg := gameErrorMessage(game, ErrorCodeWordNotAccepted); msg == errorMessage(ErrorCodeWordNotAccepted) {
+		t.Errorf("kids message = %q, want the gentler text", msg)
+	}
+
+	delete(app.GameSessions, "session-kids")
+	restored := app.getGameState(dummyContext(), "session-kids")
+	if !restored.Kids || restored.Rows() != MaxKidsGuesses || len(restored.Guesses) != MaxKidsGuesses {
+		t.Errorf("restored game = %+v, want the kids rows back from the journal", restored)
+	}
+
+	restored.GameOver, restored.Won, restored.GuessHistory = true, true, []string{"APPLE"}
+	app.recordPlayerGame(&gin.Context{}, "session-kids", restored)
+	if _, _, s := app.Players.summary("session-kids", ""); s.Played != 0 {
+		t.Errorf("regular stats = %+v, want the kids game kept apart", s)
+	}
+	if _, _, s := app.Players.summary(statsBucket("session-kids", ModeKids), ""); s.Played != 1 {
+		t.Errorf("kids stats = %+v, want the kids game", s)
+	}
+}
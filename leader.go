@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Singleton jobs, each run by one replica at a time when leases are enabled
+const (
+	JobJournalCleanup = "journal-cleanup"
+	JobIntegrityScan  = "integrity-scan"
+	JobStatsFlush     = "stats-flush"
+	JobReminders      = "reminders"
+	JobPush           = "push"
+)
+
+// jobLease is the lease file for one job.
+type jobLease struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// errLeaseTaken is returned when another replica claimed a lease first.
+var errLeaseTaken = errors.New("lease claimed by another replica")
+
+// JobLeases elects one replica to run each singleton job through lease files in shared storage.
+// A replica holds a job's lease until it expires, renewing it halfway through, and another
+// replica takes over once a lease lapses. Claims are atomic: a missing lease file is created
+// exclusively, so of several replicas claiming at once only one succeeds, and an expired one
+// is first moved aside and checked. Only the holder writes a live lease. Lease times are
+// wall-clock, since replicas may shift their app clocks differently.
+type JobLeases struct {
+	mu      sync.Mutex
+	storage LockStorage
+	dir     string
+	holder  string
+	ttl     time.Duration
+	held    map[string]time.Time
+	now     func() time.Time
+}
+
+// newJobLeases returns leases stored under dir lasting ttl, or nil when ttl is not positive,
+// which runs every job on every replica as a single instance would.
+func newJobLeases(dir string, ttl time.Duration) *JobLeases {
+	if ttl <= 0 {
+		return nil
+	}
+	return &JobLeases{
+		storage: DirStorage{},
+		dir:     dir,
+		holder:  uuid.NewString(),
+		ttl:     ttl,
+		held:    make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// leaseName returns the lease file for job.
+func (l *JobLeases) leaseName(job string) string {
+	return path.Join(l.dir, job+".lock")
+}
+
+// leader reports whether this replica should run job now, claiming or renewing its lease as
+// needed. It is always true without leases.
+func (l *JobLeases) leader(job string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if expires, ok := l.held[job]; ok && now.Before(expires.Add(-l.ttl/2)) {
+		return true
+	}
+
+	name := l.leaseName(job)
+	var lease jobLease
+	found, err := readJSONFile(l.storage, name, &lease)
+	if err != nil {
+		logWarn("Failed to read %s lease: %v", job, err)
+		return l.stillHeld(job, now)
+	}
+	live := found && now.Before(lease.ExpiresAt)
+	if live && lease.Holder != l.holder {
+		delete(l.held, job)
+		return false
+	}
+	claim := jobLease{Holder: l.holder, ExpiresAt: now.Add(l.ttl)}
+	if live {
+		// Nobody else touches a live lease, so the holder renews it in place.
+		err = writeJSONFile(l.storage, name, claim)
+	} else {
+		err = l.claim(name, lease, found, claim)
+	}
+	if errors.Is(err, errLeaseTaken) {
+		delete(l.held, job)
+		return false
+	}
+	if err != nil {
+		logWarn("Failed to write %s lease: %v", job, err)
+		return l.stillHeld(job, now)
+	}
+	if _, ok := l.held[job]; !ok {
+		logInfo("Took the %s lease", job)
+	}
+	l.held[job] = claim.ExpiresAt
+	return true
+}
+
+// claim takes the lease name, which is missing or holds the expired lease read. An expired
+// lease is moved aside under a name of this replica's own, and if what was moved is not the
+// lease read, another replica claimed it in between and it is put back. The new lease file is
+// then created exclusively. Callers must hold l.mu.
+func (l *JobLeases) claim(name string, expired jobLease, found bool, claim jobLease) error {
+	if found {
+		aside := name + "." + l.holder
+		if err := l.storage.Rename(name, aside); errors.Is(err, fs.ErrNotExist) {
+			return errLeaseTaken
+		} else if err != nil {
+			return err
+		}
+		data, err := l.storage.ReadFile(aside)
+		var moved jobLease
+		if err == nil {
+			err = json.Unmarshal(data, &moved)
+		}
+		if err == nil && (moved.Holder != expired.Holder || !moved.ExpiresAt.Equal(expired.ExpiresAt)) {
+			if err := l.storage.CreateExclusive(name, data); err != nil && !errors.Is(err, fs.ErrExist) {
+				logWarn("Failed to put back a lease moved during takeover: %v", err)
+			}
+			err = errLeaseTaken
+		}
+		l.storage.Remove(aside)
+		if err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(claim)
+	if err != nil {
+		return err
+	}
+	if err := l.storage.CreateExclusive(name, data); errors.Is(err, fs.ErrExist) {
+		return errLeaseTaken
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// stillHeld keeps running a job whose lease could not be renewed until the lease it already
+// holds runs out. Callers must hold l.mu.
+func (l *JobLeases) stillHeld(job string, now time.Time) bool {
+	expires, ok := l.held[job]
+	if ok && now.Before(expires) {
+		return true
+	}
+	delete(l.held, job)
+	return false
+}
+
+// release gives up every lease this replica holds so another can take over straight away,
+// for example on shutdown.
+func (l *JobLeases) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for job := range l.held {
+		name := l.leaseName(job)
+		var lease jobLease
+		if found, err := readJSONFile(l.storage, name, &lease); err == nil && found && lease.Holder == l.holder && now.Before(lease.ExpiresAt) {
+			if err := l.storage.Remove(name); err != nil {
+				logWarn("Failed to release %s lease: %v", job, err)
+			}
+		}
+		delete(l.held, job)
+	}
+}
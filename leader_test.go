@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJobLeasesElectOneReplica(t *testing.T) {
+	st := newMemStorage()
+	now := time.Date(2030, 4, 1, 12, 0, 0, 0, time.UTC)
+	replica := func() *JobLeases {
+		l := newJobLeases("leases", time.Minute)
+		l.storage = st
+		l.now = func() time.Time { return now }
+		return l
+	}
+	a, b := replica(), replica()
+
+	if !a.leader(JobStatsFlush) || b.leader(JobStatsFlush) {
+		t.Fatal("want only the first replica to take the lease")
+	}
+	if !b.leader(JobJournalCleanup) {
+		t.Error("leases for other jobs should be independent")
+	}
+	now = now.Add(45 * time.Second)
+	if !a.leader(JobStatsFlush) || b.leader(JobStatsFlush) {
+		t.Error("holder should renew its lease past the halfway point")
+	}
+	now = now.Add(50 * time.Second)
+	if b.leader(JobStatsFlush) {
+		t.Error("renewed lease taken over before it expired")
+	}
+
+	a.release()
+	if !b.leader(JobStatsFlush) {
+		t.Error("released lease not taken over")
+	}
+	if a.leader(JobStatsFlush) {
+		t.Error("released replica still leads")
+	}
+	var nilLeases *JobLeases
+	if !nilLeases.leader(JobStatsFlush) {
+		t.Error("without leases every replica should run every job")
+	}
+}
+
+func TestJobLeasesConcurrentClaims(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2030, 4, 1, 12, 0, 0, 0, time.UTC)
+	replica := func() *JobLeases {
+		l := newJobLeases(dir, time.Minute)
+		l.now = func() time.Time { return now }
+		return l
+	}
+	replicas := []*JobLeases{replica(), replica(), replica(), replica()}
+	old := replica()
+
+	// race runs leader for job on every replica at once and returns how many won.
+	race := func(job string) int {
+		var wg sync.WaitGroup
+		var won atomic.Int32
+		start := make(chan struct{})
+		for _, l := range replicas {
+			wg.Go(func() {
+				<-start
+				if l.leader(job) {
+					won.Add(1)
+				}
+			})
+		}
+		close(start)
+		wg.Wait()
+		return int(won.Load())
+	}
+	for i := range 100 {
+		job := "fresh-" + strconv.Itoa(i)
+		if n := race(job); n != 1 {
+			t.Fatalf("%d replicas took the new %s lease, want 1", n, job)
+		}
+		expired := "expired-" + strconv.Itoa(i)
+		if !old.leader(expired) {
+			t.Fatalf("first claim of %s failed", expired)
+		}
+	}
+
+	now = now.Add(2 * time.Minute)
+	for i := range 100 {
+		job := "expired-" + strconv.Itoa(i)
+		if n := race(job); n != 1 {
+			t.Fatalf("%d replicas took over the expired %s lease, want 1", n, job)
+		}
+	}
+}
+
+func TestGlobalStatsFlushOnlyOnLeader(t *testing.T) {
+	st := newMemStorage()
+	gs := newGlobalStats("stats.json")
+	gs.storage = st
+	leading := false
+	gs.leader = func() bool { return leading }
+	gs.apply(gameOutcome{Date: "2030-04-01", Won: true, Guesses: 3})
+
+	gs.flush()
+	if _, err := st.ReadFile("stats.json"); err == nil {
+		t.Fatal("follower wrote global stats")
+	}
+	leading = true
+	gs.flush()
+	if _, err := st.ReadFile("stats.json"); err != nil {
+		t.Errorf("pending stats not written after taking the lease: %v", err)
+	}
+}
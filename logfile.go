@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// backupTimeFormat is the timestamp suffix appended to rotated log files.
+const backupTimeFormat = "20060102T150405.000"
+
+// rotatingFile is an io.WriteCloser that rotates its file when it exceeds a size or age limit
+// and prunes rotated backups beyond a count or age limit.
+type rotatingFile struct {
+	mu          sync.Mutex
+	path        string
+	maxSize     int64
+	rotateEvery time.Duration
+	maxBackups  int
+	maxAge      time.Duration
+	file        *os.File
+	size        int64
+	openedAt    time.Time
+}
+
+// newRotatingFile opens (or creates) the log file at path. Zero limits disable the corresponding rule.
+func newRotatingFile(path string, maxSize int64, rotateEvery time.Duration, maxBackups int, maxAge time.Duration) (*rotatingFile, error) {
+	rf := &rotatingFile{
+		path:        path,
+		maxSize:     maxSize,
+		rotateEvery: rotateEvery,
+		maxBackups:  maxBackups,
+		maxAge:      maxAge,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// open opens the current log file for appending. Callers must hold rf.mu or own rf exclusively.
+func (rf *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.file = f
+	rf.size = info.Size()
+	rf.openedAt = time.Now()
+	return nil
+}
+
+// Write appends p to the log file, rotating first if the write would exceed the limits.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	sizeExceeded := rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize
+	ageExceeded := rf.rotateEvery > 0 && time.Since(rf.openedAt) >= rf.rotateEvery
+	if sizeExceeded || ageExceeded {
+		if err := rf.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Log rotation failed: %v\n", err)
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Close closes the current log file.
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// rotate renames the current file to a timestamped backup, reopens the log, and prunes backups.
+// Callers must hold rf.mu.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	backup := rf.path + "." + time.Now().Format(backupTimeFormat)
+	if err := os.Rename(rf.path, backup); err != nil {
+		if reopenErr := rf.open(); reopenErr != nil {
+			return reopenErr
+		}
+		return err
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+	rf.prune()
+	return nil
+}
+
+// prune deletes backups beyond maxBackups (oldest first) and backups older than maxAge.
+func (rf *rotatingFile) prune() {
+	backups, err := filepath.Glob(rf.path + ".*")
+	if err != nil {
+		return
+	}
+	backups = slices.DeleteFunc(backups, func(name string) bool {
+		_, err := time.Parse(backupTimeFormat, strings.TrimPrefix(name, rf.path+"."))
+		return err != nil
+	})
+	slices.Sort(backups)
+	slices.Reverse(backups)
+
+	for i, name := range backups {
+		expired := false
+		if rf.maxAge > 0 {
+			if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) > rf.maxAge {
+				expired = true
+			}
+		}
+		if (rf.maxBackups > 0 && i >= rf.maxBackups) || expired {
+			if err := os.Remove(name); err != nil {
+				fmt.Fprintf(os.Stderr, "[WARN] Failed to remove old log file %s: %v\n", name, err)
+			}
+		}
+	}
+}
+
+// configureFileLogging mirrors application and request logs to a rotating file when LOG_FILE is set.
+// It returns the file so it can be closed on shutdown, or nil when file logging is disabled.
+func configureFileLogging() io.Closer {
+	path := os.Getenv("LOG_FILE")
+	if path == "" {
+		return nil
+	}
+	rf, err := newRotatingFile(
+		path,
+		int64(getEnvInt("LOG_MAX_SIZE_MB", 50))*1024*1024,
+		getEnvDuration("LOG_ROTATE_INTERVAL", 24*time.Hour),
+		getEnvInt("LOG_MAX_BACKUPS", 7),
+		getEnvDuration("LOG_MAX_AGE", 14*24*time.Hour),
+	)
+	if err != nil {
+		logWarn("Failed to open log file %s, logging to stderr only: %v", path, err)
+		return nil
+	}
+	log.SetOutput(io.MultiWriter(os.Stderr, rf))
+	gin.DefaultWriter = io.MultiWriter(os.Stdout, rf)
+	gin.DefaultErrorWriter = io.MultiWriter(os.Stderr, rf)
+	logInfo("Logging to %s", path)
+	return rf
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	rf, err := newRotatingFile(path, 10, 0, 2, 0)
+	if err != nil {
+		t.Fatalf("newRotatingFile failed: %v", err)
+	}
+	defer rf.Close()
+
+	for i := 0; i < 4; i++ {
+		if _, err := rf.Write([]byte("12345678\n")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Errorf("Expected 2 backups after pruning, got %d: %v", len(backups), backups)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if strings.Count(string(data), "\n") != 1 {
+		t.Errorf("Expected current log to hold one line, got %q", data)
+	}
+}
+
+func TestRotatingFileAppendsWithoutLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	rf, err := newRotatingFile(path, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("newRotatingFile failed: %v", err)
+	}
+	rf.Write([]byte("one\n"))
+	rf.Write([]byte("two\n"))
+	rf.Close()
+
+	if _, err := rf.Write([]byte("three\n")); err == nil {
+		t.Error("Expected write after close to fail")
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "one\ntwo\n" {
+		t.Errorf("Unexpected log contents %q", data)
+	}
+}
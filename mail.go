@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxEmailLength is the longest address accepted, per RFC 5321.
+const maxEmailLength = 254
+
+// errMailLimit is returned once the mailer has sent its daily limit.
+var errMailLimit = errors.New("daily email limit reached")
+
+// Mailer sends plain-text email through an SMTP relay, upgrading to TLS with STARTTLS when the
+// server offers it. It stops sending for the rest of the UTC day after DailyLimit messages, so
+// a bug or abuse cannot turn the relay into a spam cannon.
+type Mailer struct {
+	Host       string
+	Port       int
+	From       string
+	DailyLimit int
+	// Timeout bounds the delivery of one message.
+	Timeout  time.Duration
+	envelope string
+	auth     smtp.Auth
+	breaker  *CircuitBreaker
+	mu       sync.Mutex
+	day      string
+	sent     int
+	now      func() time.Time
+	// deliver hands a message to the relay. Tests replace it to capture messages.
+	deliver func(ctx context.Context, to string, msg []byte) error
+}
+
+// newMailer returns a mailer for the relay at host:port, or nil when host or from is empty,
+// which disables email. From may carry a display name. Username and password are only used
+// when both are set.
+func newMailer(host string, port int, username, password, from string, dailyLimit int) *Mailer {
+	if host == "" || from == "" {
+		return nil
+	}
+	m := &Mailer{Host: host, Port: port, From: from, DailyLimit: dailyLimit, envelope: from, now: time.Now}
+	if addr, err := mail.ParseAddress(from); err == nil {
+		m.envelope = addr.Address
+	}
+	if username != "" && password != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	m.deliver = m.deliverSMTP
+	return m
+}
+
+// parseEmail checks that s is a bare email address, without a display name, and returns it
+// trimmed.
+func parseEmail(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || len(s) > maxEmailLength || strings.ContainsAny(s, "\r\n<>") {
+		return "", false
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || addr.Address != s {
+		return "", false
+	}
+	return s, true
+}
+
+// reserve counts one message against today's limit, reporting false once it is used up.
+func (m *Mailer) reserve() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if day := m.now().UTC().Format(time.DateOnly); day != m.day {
+		m.day, m.sent = day, 0
+	}
+	if m.DailyLimit > 0 && m.sent >= m.DailyLimit {
+		return false
+	}
+	m.sent++
+	return true
+}
+
+// send emails a plain-text message to the address to, with any extra headers.
+func (m *Mailer) send(ctx context.Context, to, subject, body string, headers map[string]string) error {
+	if !m.reserve() {
+		return errMailLimit
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\nSubject: %s\r\n", m.From, to, subject)
+	fmt.Fprintf(&b, "Date: %s\r\nMessage-ID: <%s@%s>\r\n", m.now().Format(time.RFC1123Z), uuid.NewString(), m.Host)
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, headers[name])
+	}
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	msg := []byte(b.String())
+	if m.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.Timeout)
+		defer cancel()
+	}
+	return m.breaker.call(ctx, func(ctx context.Context) error {
+		return m.deliver(ctx, to, msg)
+	})
+}
+
+// deliverSMTP sends msg over one SMTP connection, bounded by the context's deadline.
+func (m *Mailer) deliverSMTP(ctx context.Context, to string, msg []byte) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(m.Host, strconv.Itoa(m.Port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.Host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if err := client.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(m.envelope); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
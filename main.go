@@ -2,41 +2,52 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"html/template"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
-	cachecontrol "go.eigsys.de/gin-cachecontrol/v2"
-
-	ginGzip "github.com/gin-contrib/gzip"
 
 	"golang.org/x/time/rate"
 
 	"github.com/gin-gonic/gin"
 
-	"github.com/samber/lo"
+	"github.com/mooship/vortludo/engine"
 )
 
 // main is the entry point for the application. It loads configuration, sets up routes, and starts the server.
 func main() {
 	_ = godotenv.Load()
+	configureLogLevel()
+	if len(os.Args) > 1 && os.Args[1] == "maintenance" {
+		os.Exit(runMaintenance(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if logFile := configureFileLogging(); logFile != nil {
+		defer logFile.Close()
+	}
 
 	isProduction := os.Getenv("GIN_MODE") == "release" || os.Getenv("ENV") == "production"
 	logInfo("Starting Vortludo in %s mode", map[bool]string{true: "production", false: "development"}[isProduction])
 
-	wordList, wordSet, err := loadWords()
+	secretLoader, err := newSecretLoader()
+	if err != nil {
+		logFatal("Failed to load secrets: %v", err)
+	}
+	secrets, err := secretLoader.loadSecrets(context.Background(), "PROGRESS_SECRET", "PROGRESS_SECRET_PREVIOUS", "ADMIN_TOKEN", "CAPTCHA_SECRET", "SMTP_PASSWORD", "FLEET_REDIS_URL")
+	if err != nil {
+		logFatal("Failed to load secrets: %v", err)
+	}
+
+	packs, err := loadWordPacks()
 	if err != nil {
 		logFatal("Failed to load words: %v", err)
 	}
-	logInfo("Loaded %d words from dictionary", len(wordList))
+	logInfo("Loaded %d words from dictionary in %d word packs", len(packs[0].Words), len(packs))
 
 	acceptedWordSet, err := loadAcceptedWords()
 	if err != nil {
@@ -44,71 +55,326 @@ func main() {
 	}
 	logInfo("Loaded %d accepted words", len(acceptedWordSet))
 
-	hintMap := buildHintMap(wordList)
+	overlays, err := loadAcceptedOverlays(AcceptedOverlaysDir)
+	if err != nil {
+		logFatal("Failed to load accepted-word overlays: %v", err)
+	}
+
+	namespace, ok := parseNamespace(os.Getenv("NAMESPACE"))
+	if !ok {
+		logFatal("Invalid NAMESPACE %q: use up to 32 lowercase letters, digits, and dashes", os.Getenv("NAMESPACE"))
+	}
+	if namespace != "" {
+		logInfo("Using namespace %s", namespace)
+	}
+
+	experiments, err := parseExperiments(os.Getenv("EXPERIMENTS"))
+	if err != nil {
+		logFatal("Invalid EXPERIMENTS: %v", err)
+	}
+
+	clockOffset := getEnvDuration("CLOCK_OFFSET", 0)
+	if clockOffset != 0 {
+		logWarn("Clock shifted by CLOCK_OFFSET=%v; daily rollover, bans, and rate limits follow the shifted time", clockOffset)
+	}
+	clock := newClock(clockOffset)
+
+	blocklist := newBlocklist(
+		getEnvString("BLOCKLIST_FILE", dataPath(namespace, "blocklist.json")),
+		getEnvInt("ABUSE_STRIKE_THRESHOLD", 20),
+		getEnvDuration("ABUSE_STRIKE_WINDOW", 10*time.Minute),
+		getEnvDuration("ABUSE_BAN_DURATION", 30*time.Minute),
+	)
+	blocklist.clock = clock
+	if err := blocklist.load(); err != nil {
+		logWarn("Failed to load blocklist: %v", err)
+	}
+
+	calendar := newPuzzleCalendar(getEnvString("PUZZLE_CALENDAR_FILE", dataPath(namespace, "puzzle-calendar.json")))
+	if err := calendar.load(); err != nil {
+		logWarn("Failed to load puzzle calendar: %v", err)
+	}
+
+	suggestions := newSuggestionQueue(getEnvString("SUGGESTIONS_FILE", dataPath(namespace, "suggestions.json")))
+	if err := suggestions.load(); err != nil {
+		logWarn("Failed to load word suggestions: %v", err)
+	}
+
+	feedback := newWordFeedback(getEnvString("WORD_FEEDBACK_FILE", dataPath(namespace, "word-feedback.json")))
+	if err := feedback.load(); err != nil {
+		logWarn("Failed to load word feedback: %v", err)
+	}
+	selectors, err := newWordSelectors(os.Getenv("WORD_SELECTION"), os.Getenv("WORD_SELECTION_PURIST"), feedback)
+	if err != nil {
+		logFatal("Invalid word selection: %v", err)
+	}
+
+	preferences := newPreferenceStore(getEnvString("PREFERENCES_FILE", dataPath(namespace, "preferences.json")))
+	if err := preferences.load(); err != nil {
+		logWarn("Failed to load preferences: %v", err)
+	}
+
+	leases := newJobLeases(getEnvString("LEADER_LEASE_DIR", dataPath(namespace, "leases")), getEnvDuration("LEADER_LEASE_TTL", 0))
+	stats := newGlobalStats(getEnvString("GLOBAL_STATS_FILE", dataPath(namespace, "global-stats.json")))
+	if err := stats.load(); err != nil {
+		logWarn("Failed to load global stats: %v", err)
+	}
+	stats.leader = func() bool { return leases.leader(JobStatsFlush) }
+	stats.start(getEnvDuration("GLOBAL_STATS_FLUSH_INTERVAL", time.Minute))
+	players := newPlayerStatsStore(StreakFreezeRules{
+		WinsPerFreeze: getEnvInt("STREAK_FREEZE_WINS", 5),
+		MaxFreezes:    getEnvInt("STREAK_MAX_FREEZES", 2),
+	})
+	players.path = getEnvString("PLAYER_STATS_FILE", dataPath(namespace, "player-stats.json"))
+	players.leader = stats.leader
+
+	maxSessions := getEnvInt("MAX_SESSIONS", 50000)
+	retention := loadRetentionPolicy()
+	cookieMaxAge := getEnvDuration("COOKIE_MAX_AGE", retention.Sessions)
+	for _, warning := range append(sessionLifetimeWarnings(cookieMaxAge, retention.Sessions), retention.warnings()...) {
+		logWarn("%s", warning)
+	}
+	keys := loadKeyring(secrets["PROGRESS_SECRET"], secrets["PROGRESS_SECRET_PREVIOUS"])
 
 	app := &App{
-		WordList:        wordList,
-		WordSet:         wordSet,
-		AcceptedWordSet: acceptedWordSet,
-		HintMap:         hintMap,
-		GameSessions:    make(map[string]*GameState),
-		IsProduction:    isProduction,
-		StartTime:       time.Now(),
-		CookieMaxAge:    getEnvDuration("COOKIE_MAX_AGE", 2*time.Hour),
-		StaticCacheAge:  getEnvDuration("STATIC_CACHE_AGE", 5*time.Minute),
-		RateLimitRPS:    getEnvInt("RATE_LIMIT_RPS", 5),
-		RateLimitBurst:  getEnvInt("RATE_LIMIT_BURST", 10),
-		LimiterMap:      make(map[string]*rate.Limiter),
-		RuneBufPool: &sync.Pool{
-			New: func() any { buf := make([]rune, WordLength); return &buf },
+		GameSessions:  make(map[string]*GameState),
+		IsProduction:  isProduction,
+		Namespace:     namespace,
+		PublicBaseURL: strings.TrimSuffix(os.Getenv("PUBLIC_BASE_URL"), "/"),
+		Experiments:   experiments,
+		Selectors:     selectors,
+		GuessCache:    newGuessCache(getEnvInt("GUESS_CACHE_SIZE", 4096)),
+		Journal:       newSessionJournal(os.Getenv("SESSION_JOURNAL_DIR")),
+		Integrity:     newIntegrityScanner(),
+		Leases:        leases,
+		StoreHealth: newStoreHealth(
+			getEnvDuration("SHED_LATENCY_THRESHOLD", 250*time.Millisecond),
+			getEnvInt("SHED_FAILURE_THRESHOLD", 3),
+			getEnvDuration("SHED_WINDOW", 30*time.Second),
+			getEnvDuration("SHED_RETRY_AFTER", 5*time.Second),
+		),
+		StartTime:          time.Now(),
+		Clock:              clock,
+		CookieMaxAge:       cookieMaxAge,
+		Retention:          retention,
+		PlayerCookieMaxAge: getEnvDuration("PLAYER_COOKIE_MAX_AGE", 0),
+		MaxSessions:        maxSessions,
+		StaticCacheAge:     getEnvDuration("STATIC_CACHE_AGE", 5*time.Minute),
+		RequestTimeout:     getEnvDuration("REQUEST_TIMEOUT", 10*time.Second),
+		Daily: newDailySchedule(
+			os.Getenv("DAILY_TIMEZONE"),
+			getEnvInt("DAILY_ROLLOVER_HOUR", 0),
+			os.Getenv("DAILY_USER_TIMEZONE") == "true",
+		),
+		HealthMinFreeMB:      getEnvInt("HEALTH_MIN_FREE_MB", 100),
+		RateLimitRPS:         getEnvInt("RATE_LIMIT_RPS", 5),
+		RateLimitBurst:       getEnvInt("RATE_LIMIT_BURST", 10),
+		HintRateInterval:     getEnvDuration("HINT_RATE_INTERVAL", 10*time.Second),
+		HintRateBurst:        getEnvInt("HINT_RATE_BURST", 3),
+		ValidateRateInterval: getEnvDuration("VALIDATE_RATE_INTERVAL", 200*time.Millisecond),
+		ValidateRateBurst:    getEnvInt("VALIDATE_RATE_BURST", 10),
+		ExportRateInterval:   getEnvDuration("EXPORT_RATE_INTERVAL", 30*time.Second),
+		ExportRateBurst:      getEnvInt("EXPORT_RATE_BURST", 2),
+		LimiterMap:           make(map[string]*rate.Limiter),
+		Metrics:              newMetrics(),
+		Blocklist:            blocklist,
+		Calendar:             calendar,
+		Suggestions:          suggestions,
+		Preferences:          preferences,
+		Content:              newContentPages(getEnvString("CONTENT_DIR", DefaultContentDir)),
+		Feedback:             feedback,
+		Coins: CoinRules{
+			PerWin:       getEnvInt("COINS_PER_WIN", 10),
+			RevealCost:   getEnvInt("COIN_REVEAL_COST", 5),
+			ExtraRowCost: getEnvInt("COIN_EXTRA_ROW_COST", 15),
 		},
+		Kids:       newKidsRules(getEnvString("KIDS_PACK", ModeKids), getEnvInt("KIDS_MAX_GUESSES", DefaultKidsMaxGuesses)),
+		Keys:       keys,
+		Progress:   newProgressTokens(keys),
+		Games:      newGameTokens(keys.derive("custom-games")),
+		PlayerIDs:  newPlayerIDs(keys.derive("player-ids")),
+		Stats:      stats,
+		Players:    players,
+		Spectate:   newSpectateLinks(),
+		Rooms:      newCoopRooms(),
+		Classrooms: newClassrooms(),
+		Transfers:  newTransfers(),
+		UsedTokens: newUsedTokens(getEnvString("USED_TOKENS_FILE", dataPath(namespace, "used-tokens.json"))),
+		Quarantine: newSessionQuarantine(getEnvDuration("SESSION_QUARANTINE_GRACE", 24*time.Hour), maxSessions),
+		AdminToken: secrets["ADMIN_TOKEN"],
+		Captcha: newCaptcha(
+			os.Getenv("CAPTCHA_PROVIDER"),
+			os.Getenv("CAPTCHA_SITE_KEY"),
+			secrets["CAPTCHA_SECRET"],
+			getEnvInt("CAPTCHA_STRIKE_THRESHOLD", 5),
+			getEnvDuration("CAPTCHA_PASS_DURATION", time.Hour),
+		),
+		Analytics: newAnalytics(
+			os.Getenv("ANALYTICS_MODE"),
+			os.Getenv("ANALYTICS_ENDPOINT"),
+			os.Getenv("ANALYTICS_DOMAIN"),
+		),
 	}
 
-	setGlobalApp(app)
+	circuitThreshold := getEnvInt("CIRCUIT_FAILURE_THRESHOLD", 5)
+	circuitCooldown := getEnvDuration("CIRCUIT_COOLDOWN", 30*time.Second)
+	if app.Captcha != nil {
+		app.Captcha.clock = clock
+		app.Captcha.breaker = newCircuitBreaker("captcha", circuitThreshold, circuitCooldown, getEnvDuration("CAPTCHA_TIMEOUT", 3*time.Second))
+	}
+	if app.Analytics != nil && app.Analytics.events != nil {
+		app.Analytics.breaker = newCircuitBreaker("analytics", circuitThreshold, circuitCooldown, getEnvDuration("ANALYTICS_TIMEOUT", 2*time.Second))
+	}
+	if app.Journal != nil {
+		app.Journal.health = app.StoreHealth
+		app.Journal.reserve = getEnvDuration("PERSIST_MIN_BUDGET", app.RequestTimeout/10)
+		if interval := getEnvDuration("JOURNAL_FLUSH_INTERVAL", 5*time.Second); interval > 0 {
+			go app.flushJournals(interval)
+		}
+		if len(app.Journal.dirs) > 1 {
+			go app.rebalanceJournals()
+		}
+	}
 
-	router := gin.Default()
+	app.Players.maxHistory = retention.HistoryGames
+	if err := app.Players.load(); err != nil {
+		logWarn("Failed to load player stats: %v", err)
+	}
+	go app.flushPlayerStats(getEnvDuration("PLAYER_STATS_FLUSH_INTERVAL", time.Minute))
+	app.Players.archive = newPlayerArchive(
+		getEnvString("PLAYER_ARCHIVE_DIR", dataPath(namespace, "archive")),
+		time.Duration(getEnvInt("PLAYER_ARCHIVE_DAYS", 0))*24*time.Hour,
+	)
+	if app.Players.archive != nil {
+		go app.archivePlayers(time.Hour)
+	}
+
+	if retention.Sessions > 0 {
+		go app.sweepSessions(max(retention.Sessions/4, time.Minute))
+	}
+	bus, err := newRedisBus(secrets["FLEET_REDIS_URL"], fleetChannel(namespace))
+	if err != nil {
+		logFatal("Invalid FLEET_REDIS_URL: %v", err)
+	}
+	if bus != nil {
+		app.Fleet = newFleet(bus)
+		app.Fleet.breaker = newCircuitBreaker("fleet", circuitThreshold, circuitCooldown, 0)
+		go app.listenFleet()
+	} else if leases != nil {
+		logWarn("LEADER_LEASE_TTL is set without FLEET_REDIS_URL; games finished on other replicas are left out of global stats")
+	}
+	mailer := newMailer(
+		os.Getenv("SMTP_HOST"),
+		getEnvInt("SMTP_PORT", 587),
+		os.Getenv("SMTP_USERNAME"),
+		secrets["SMTP_PASSWORD"],
+		os.Getenv("SMTP_FROM"),
+		getEnvInt("EMAIL_DAILY_LIMIT", 500),
+	)
+	app.Reminders = newReminders(getEnvString("REMINDERS_FILE", dataPath(namespace, "reminders.json")), mailer, app.PublicBaseURL)
+	if app.Reminders != nil {
+		mailer.now = clock.Now
+		mailer.Timeout = getEnvDuration("SMTP_TIMEOUT", 10*time.Second)
+		mailer.breaker = newCircuitBreaker("smtp", circuitThreshold, circuitCooldown, 0)
+		app.Reminders.RateInterval = getEnvDuration("REMINDER_RATE_INTERVAL", time.Minute)
+		app.Reminders.RateBurst = getEnvInt("REMINDER_RATE_BURST", 3)
+		if err := app.Reminders.load(); err != nil {
+			logWarn("Failed to load reminder subscribers: %v", err)
+		}
+		go app.runReminders()
+	} else if mailer != nil {
+		logWarn("SMTP_HOST is set without PUBLIC_BASE_URL; email reminders are disabled")
+	}
+	if subject := os.Getenv("VAPID_SUBJECT"); subject != "" {
+		keys, err := loadVAPIDKeys(DirStorage{}, getEnvString("VAPID_KEY_FILE", dataPath(namespace, "vapid.json")), subject)
+		if err != nil {
+			logFatal("Failed to load VAPID keys: %v", err)
+		}
+		app.Push = newWebPush(getEnvString("PUSH_SUBSCRIPTIONS_FILE", dataPath(namespace, "push-subscriptions.json")), keys, getEnvDuration("PUSH_TIMEOUT", 10*time.Second))
+		app.Push.breaker = newCircuitBreaker("push", circuitThreshold, circuitCooldown, 0)
+		if err := app.Push.load(); err != nil {
+			logWarn("Failed to load push subscriptions: %v", err)
+		}
+		go app.runPush()
+	}
+	if app.AdminToken != "" {
+		audit, err := openAuditLog(getEnvString("AUDIT_LOG_FILE", dataPath(namespace, "audit.jsonl")))
+		if err != nil {
+			logFatal("Failed to open audit log: %v", err)
+		}
+		defer audit.close()
+		app.Audit = audit
+	}
+	if interval := getEnvDuration("INTEGRITY_SCAN_INTERVAL", time.Hour); interval > 0 {
+		go app.runIntegrityScans(interval)
+	}
+	if retention.scheduled() {
+		go app.runRetention(retentionInterval)
+	}
+
+	app.registerWordPacks(packs, acceptedWordSet, overlays)
+	lists := app.lists()
+	app.Patterns = newFeedbackMatrix(lists.AcceptedWordSet, lists.WordSet, getEnvInt("FEEDBACK_MATRIX_MAX_MB", 64)<<20)
+	app.Bonus = newBonusRounds(getEnvDuration("BONUS_ROUND_DURATION", 30*time.Second), lists.AcceptedWordSet, packs)
+	app.registerGauges()
+	app.publishNamespace()
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	if currentLogLevel <= LogLevelInfo {
+		router.Use(gin.Logger())
+	}
 
 	router.Use(requestIDMiddleware())
 	router.Use(securityHeadersMiddleware())
+	router.Use(clientHintsMiddleware())
+	router.Use(app.blocklistMiddleware())
+	router.Use(app.bodyLimitMiddleware())
+	router.Use(app.formLimitMiddleware())
+	router.Use(app.apiValidationMiddleware())
+	router.Use(app.loadSheddingMiddleware())
 
 	router.Use(app.csrfMiddleware())
 	router.Use(app.validateCSRFMiddleware())
 
-	router.Use(ginGzip.Gzip(ginGzip.DefaultCompression,
-		ginGzip.WithExcludedExtensions([]string{".svg", ".ico", ".png", ".jpg", ".jpeg", ".gif"}),
-		ginGzip.WithExcludedPaths([]string{"/static/fonts"})))
+	router.Use(gzipMiddleware(loadGzipConfig()))
 
 	if err := router.SetTrustedProxies([]string{"127.0.0.1"}); err != nil {
 		logWarn("Failed to set trusted proxies: %v", err)
 	}
 
-	if isProduction {
-		router.Use(func(c *gin.Context) {
-			app.applyCacheHeaders(c, true)
-		})
-	} else {
-		router.Use(func(c *gin.Context) {
-			app.applyCacheHeaders(c, false)
-		})
-	}
-
-	funcMap := template.FuncMap{"hasPrefix": strings.HasPrefix}
+	router.Use(app.cacheHeadersMiddleware(isProduction))
 
-	var baseTplDir string
+	var baseTplDir, staticDir string
 	if isProduction && dirExists("dist") {
 		logInfo("Serving assets from dist/ directory")
 		baseTplDir = filepath.ToSlash(filepath.Join("dist", "templates"))
-		router.Static("/static", "./dist/static")
+		staticDir = "./dist/static"
 	} else {
 		logInfo("Serving development assets from source directories")
 		baseTplDir = "templates"
-		router.Static("/static", "./static")
+		staticDir = "./static"
+	}
+	router.GET("/static/*filepath", staticHandler(staticDir))
+	router.HEAD("/static/*filepath", staticHandler(staticDir))
+
+	app.WasmEnabled = fileExists(filepath.Join(staticDir, "engine.wasm")) && fileExists(filepath.Join(staticDir, "wasm_exec.js"))
+	if app.WasmEnabled {
+		logInfo("Client-side WASM engine enabled")
 	}
 
 	rootPattern := filepath.ToSlash(filepath.Join(baseTplDir, "*.html"))
 	partialsPattern := filepath.ToSlash(filepath.Join(baseTplDir, "partials", "*.html"))
 
-	master := template.New("").Funcs(funcMap)
+	integrity, err := loadAssetIntegrity(staticDir, CDNIntegrityFile)
+	if err != nil {
+		logWarn("Failed to compute asset integrity hashes: %v", err)
+	}
+	sprite, err := loadIconSprite(staticDir)
+	if err != nil {
+		logWarn("Failed to load icon sprite: %v", err)
+	}
+	master := template.New("").Funcs(templateFuncs(integrity, sprite))
 	if _, err := master.ParseGlob(rootPattern); err != nil {
 		logFatal("Failed to parse root templates: %v", err)
 	}
@@ -116,14 +382,112 @@ func main() {
 		logFatal("Failed to parse partial templates: %v", err)
 	}
 	router.SetHTMLTemplate(master)
+	if app.PrintTemplates, err = loadPrintTemplates(filepath.Join(baseTplDir, PrintTemplatesDir)); err != nil {
+		logWarn("Failed to parse print sheet templates: %v", err)
+	}
 
-	router.GET("/", app.homeHandler)
-	router.GET("/new-game", app.newGameHandler)
-	router.POST("/new-game", app.rateLimitMiddleware(), app.newGameHandler)
-	router.POST("/guess", app.rateLimitMiddleware(), app.guessHandler)
-	router.GET("/game-state", app.gameStateHandler)
-	router.POST("/retry-word", app.rateLimitMiddleware(), app.retryWordHandler)
-	router.GET("/healthz", app.healthzHandler)
+	requestTimeout := app.timeoutMiddleware(app.RequestTimeout)
+	healthTimeout := app.timeoutMiddleware(min(app.RequestTimeout, 2*time.Second))
+
+	router.GET("/", requestTimeout, app.homeHandler)
+	router.GET("/new-game", requestTimeout, app.captchaMiddleware(), app.newGameHandler)
+	router.POST("/new-game", requestTimeout, app.rateLimitMiddleware(), app.captchaMiddleware(), app.newGameHandler)
+	router.POST("/guess", requestTimeout, app.rateLimitMiddleware(), app.captchaMiddleware(), app.guessHandler)
+	router.GET("/game-state", requestTimeout, app.gameStateHandler)
+	router.GET(RouteAccepted, requestTimeout, app.acceptedWordsHandler)
+	router.GET(RouteManifest, requestTimeout, app.manifestHandler)
+	router.GET(RouteGlobalStats, requestTimeout, app.globalStatsHandler)
+	router.GET(RouteStatsWidgets, requestTimeout, app.statsWidgetsHandler)
+	for _, name := range contentPageNames {
+		router.GET("/"+name, requestTimeout, app.contentPageHandler(name))
+	}
+	router.GET(RouteDataInventory, requestTimeout, app.dataInventoryHandler)
+	router.GET(RouteStatsExport, requestTimeout, app.exportRateLimitMiddleware(), app.exportStatsHandler)
+	router.POST(RouteStatsImport, requestTimeout, app.rateLimitMiddleware(), app.importStatsHandler)
+	router.POST(RouteSpectate, requestTimeout, app.rateLimitMiddleware(), app.enableSpectateHandler)
+	router.POST(RouteSpectate+"/stop", requestTimeout, app.rateLimitMiddleware(), app.disableSpectateHandler)
+	router.GET(RouteSpectate+"/:token", requestTimeout, app.spectatePageHandler)
+	router.GET(RouteSpectate+"/:token/board", requestTimeout, app.spectateBoardHandler)
+	router.POST(RouteRoom, requestTimeout, app.rateLimitMiddleware(), app.createRoomHandler)
+	router.POST(RouteRoom+"/join", requestTimeout, app.rateLimitMiddleware(), app.joinRoomHandler)
+	router.POST(RouteRoom+"/leave", requestTimeout, app.rateLimitMiddleware(), app.leaveRoomHandler)
+	router.GET(RouteRoom+"/state", requestTimeout, app.roomStateHandler)
+	router.GET(RoutePrint, requestTimeout, app.printSheetHandler)
+	router.GET(RouteCalendar+"/daily.ics", requestTimeout, app.calendarFeedHandler)
+	router.GET(RouteCalendar+"/link", requestTimeout, app.calendarLinkHandler)
+	router.GET(RouteCalendar+"/:token", requestTimeout, app.playerCalendarHandler)
+	router.GET(RouteTransfer, requestTimeout, app.transferPageHandler)
+	router.POST(RouteTransfer, requestTimeout, app.rateLimitMiddleware(), app.createTransferHandler)
+	router.POST(RouteTransfer+"/redeem", requestTimeout, app.rateLimitMiddleware(), app.transferRateLimitMiddleware(), app.redeemTransferHandler)
+	router.POST(RouteTransfer+"/undo", requestTimeout, app.rateLimitMiddleware(), app.undoMergeHandler)
+	router.GET(RouteTransfer+"/:code", requestTimeout, app.transferLinkHandler)
+	router.GET(RouteServiceWorker, requestTimeout, serviceWorkerHandler(staticDir))
+	if app.Push != nil {
+		router.POST(RoutePush+"/subscribe", requestTimeout, app.rateLimitMiddleware(), app.pushSubscribeHandler)
+		router.POST(RoutePush+"/unsubscribe", requestTimeout, app.rateLimitMiddleware(), app.pushUnsubscribeHandler)
+	}
+	if app.Reminders != nil {
+		router.GET(RouteReminders, requestTimeout, app.remindersPageHandler)
+		router.POST(RouteReminders, requestTimeout, app.rateLimitMiddleware(), app.reminderRateLimitMiddleware(), app.captchaMiddleware(), app.subscribeHandler)
+		router.GET(RouteReminders+"/confirm/:token", requestTimeout, app.confirmPageHandler)
+		router.POST(RouteReminders+"/confirm/:token", requestTimeout, app.rateLimitMiddleware(), app.confirmSubscriptionHandler)
+		router.GET(RouteReminders+"/unsubscribe/:token", requestTimeout, app.unsubscribePageHandler)
+		router.POST(RouteReminders+"/unsubscribe/:token", requestTimeout, app.rateLimitMiddleware(), app.unsubscribeHandler)
+	}
+	router.GET(RouteClassroom, requestTimeout, app.classroomPageHandler)
+	router.POST(RouteClassroom, requestTimeout, app.rateLimitMiddleware(), app.createClassroomHandler)
+	router.POST(RouteClassroom+"/join", requestTimeout, app.rateLimitMiddleware(), app.joinClassroomHandler)
+	teacher := router.Group(RouteClassroom+"/:code/teacher", app.classroomTeacherMiddleware())
+	teacher.GET("", requestTimeout, app.classroomDashboardHandler)
+	teacher.GET("/progress", requestTimeout, app.classroomProgressHandler)
+	// The event stream outlives requestTimeout and ends itself after ClassroomStreamDuration.
+	teacher.GET("/events", app.classroomEventsHandler)
+	router.GET(RouteNextDaily, requestTimeout, app.nextPuzzleHandler)
+	router.GET(RouteDaily, requestTimeout, app.captchaMiddleware(), app.dailyHandler)
+	router.POST(RouteGamesAPI, requestTimeout, app.rateLimitMiddleware(), app.createGameAPIHandler)
+	router.GET(RoutePreferencesAPI, requestTimeout, app.preferencesHandler)
+	router.PUT(RoutePreferencesAPI, requestTimeout, app.rateLimitMiddleware(), app.putPreferencesHandler)
+	router.GET(RoutePlay+"/:token", requestTimeout, app.playGameHandler)
+	router.GET(RouteQR, requestTimeout, app.qrHandler)
+	router.GET(RouteHintAPI, requestTimeout, app.hintRateLimitMiddleware(), app.hintAPIHandler)
+	router.GET(RouteValidate, requestTimeout, app.validateRateLimitMiddleware(), app.validateGuessHandler)
+	router.POST("/retry-word", requestTimeout, app.rateLimitMiddleware(), app.retryWordHandler)
+	router.GET(RouteSuggest, requestTimeout, app.suggestPageHandler)
+	router.POST(RouteSuggest, requestTimeout, app.rateLimitMiddleware(), app.captchaMiddleware(), app.suggestWordHandler)
+	router.POST(RouteFeedback, requestTimeout, app.rateLimitMiddleware(), app.wordFeedbackHandler)
+	router.POST(RouteBonus+"/start", requestTimeout, app.rateLimitMiddleware(), app.bonusStartHandler)
+	router.POST(RouteBonus+"/answer", requestTimeout, app.rateLimitMiddleware(), app.bonusAnswerHandler)
+	router.POST(RouteCoins+"/reveal", requestTimeout, app.rateLimitMiddleware(), app.coinRevealHandler)
+	router.POST(RouteCoins+"/extra-row", requestTimeout, app.rateLimitMiddleware(), app.coinExtraRowHandler)
+	router.GET(RouteCaptcha, requestTimeout, app.captchaPageHandler)
+	router.POST(RouteCaptcha, requestTimeout, app.rateLimitMiddleware(), app.captchaVerifyHandler)
+	router.GET("/healthz", healthTimeout, app.healthzHandler)
+	router.GET("/metrics", healthTimeout, app.metricsHandler)
+
+	admin := router.Group(RouteAdmin, requestTimeout, app.adminAuthMiddleware(), app.auditMiddleware())
+	admin.GET("/audit", app.adminAuditHandler)
+	admin.GET("/audit/export", app.adminAuditExportHandler)
+	admin.GET("/blocklist", app.adminBlocklistHandler)
+	admin.POST("/blocklist", app.adminBlocklistAddHandler)
+	admin.DELETE("/blocklist", app.adminBlocklistRemoveHandler)
+	admin.POST("/cleanup", app.adminCleanupHandler)
+	admin.GET("/daily", app.adminDailyHandler)
+	admin.POST("/daily/roll", app.adminDailyRollHandler)
+	admin.GET("/daily/schedule", app.adminScheduleHandler)
+	admin.POST("/daily/schedule", app.adminScheduleAddHandler)
+	admin.DELETE("/daily/schedule", app.adminScheduleRemoveHandler)
+	admin.GET("/sessions/quarantine", app.adminQuarantineHandler)
+	admin.POST("/sessions/quarantine/purge", app.adminQuarantinePurgeHandler)
+	admin.GET("/sessions/integrity", app.adminIntegrityHandler)
+	admin.POST("/sessions/integrity/scan", app.adminIntegrityScanHandler)
+	admin.POST("/sessions/rebalance", app.adminRebalanceHandler)
+	admin.POST("/sessions/:id/restore", app.adminRestoreSessionHandler)
+	admin.GET("/stats", app.adminStatsHandler)
+	admin.GET("/suggestions", app.adminSuggestionsHandler)
+	admin.GET("/words/feedback", app.adminWordFeedbackHandler)
+	admin.POST("/words/reload", app.adminReloadWordsHandler)
+	admin.POST("/suggestions/:id/approve", app.adminReviewSuggestionHandler(SuggestionApproved))
+	admin.POST("/suggestions/:id/reject", app.adminReviewSuggestionHandler(SuggestionRejected))
 
 	app.startServer(router)
 }
@@ -162,84 +526,31 @@ func (app *App) startServer(router *gin.Engine) {
 		logFatal("Server failed to start: %v", err)
 	}
 	<-idleConnsClosed
+	app.Stats.stop()
+	app.Players.flush()
+	app.flushDirtyJournals(context.Background())
+	app.Leases.release()
 	logInfo("Server shutdown complete")
 }
 
-// applyCacheHeaders sets HTTP cache headers for static and dynamic content based on environment.
-func (app *App) applyCacheHeaders(c *gin.Context, production bool) {
-	if production {
-		if strings.HasPrefix(c.Request.URL.Path, "/static/") {
-			cachecontrol.New(cachecontrol.Config{
-				Public: true,
-				MaxAge: cachecontrol.Duration(app.StaticCacheAge),
-			})(c)
-			c.Header("Vary", "Accept-Encoding")
-		} else {
-			cachecontrol.New(cachecontrol.Config{
-				NoStore:        true,
-				NoCache:        true,
-				MustRevalidate: true,
-			})(c)
-		}
-	} else {
-		cachecontrol.New(cachecontrol.Config{
-			NoStore:        true,
-			NoCache:        true,
-			MustRevalidate: true,
-		})(c)
-	}
-}
-
-// loadWords loads the playable words from data/words.json and returns a filtered list and set.
+// loadWords loads the playable words from the words file and returns a filtered list and set.
 func loadWords() ([]WordEntry, map[string]struct{}, error) {
-	logInfo("Loading words from data/words.json")
+	logInfo("Loading words from %s", WordsFile)
 
-	data, err := os.ReadFile("data/words.json")
+	wordList, skipped, err := engine.LoadWordList(WordsFile)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	var wl WordList
-	if err := json.Unmarshal(data, &wl); err != nil {
-		return nil, nil, err
+	for _, word := range skipped {
+		logWarn("Skipping word %q: not 5 letters", word)
 	}
 
-	wordList := lo.Filter(wl.Words, func(entry WordEntry, _ int) bool {
-		if len(entry.Word) != 5 {
-			logWarn("Skipping word %q: not 5 letters", entry.Word)
-			return false
-		}
-		return true
-	})
-
-	wordSet := make(map[string]struct{}, len(wordList))
-	lo.ForEach(wordList, func(entry WordEntry, _ int) {
-		wordSet[entry.Word] = struct{}{}
-	})
-
 	logInfo("Successfully loaded %d words", len(wordList))
-	return wordList, wordSet, nil
+	return wordList, engine.WordSet(wordList), nil
 }
 
-// loadAcceptedWords loads the accepted guess words from data/accepted_words.txt.
+// loadAcceptedWords loads the accepted guess words from the accepted words file.
 func loadAcceptedWords() (map[string]struct{}, error) {
-	logInfo("Loading accepted words from data/accepted_words.txt")
-
-	data, err := os.ReadFile("data/accepted_words.txt")
-	if err != nil {
-		return nil, err
-	}
-
-	lines := strings.Split(string(data), "\n")
-	acceptedWordSet := make(map[string]struct{}, len(lines))
-
-	for _, w := range lines {
-		w = strings.TrimSpace(w)
-		if w == "" {
-			continue
-		}
-		acceptedWordSet[strings.ToUpper(w)] = struct{}{}
-	}
-
-	return acceptedWordSet, nil
+	logInfo("Loading accepted words from %s", AcceptedWordsFile)
+	return engine.LoadAcceptedWords(AcceptedWordsFile)
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metric name constants
+const (
+	MetricCSRFFailureMissing        = "csrf_failures_missing"
+	MetricCSRFFailureExpired        = "csrf_failures_expired"
+	MetricCSRFFailureMismatch       = "csrf_failures_mismatch"
+	MetricBlockedRequests           = "blocked_requests"
+	MetricAbuseBans                 = "abuse_bans"
+	MetricRejectedForms             = "rejected_forms"
+	MetricRejectedAPIRequests       = "rejected_api_requests"
+	MetricRejectedBodies            = "rejected_bodies"
+	MetricCaptchaChallenges         = "captcha_challenges"
+	MetricCaptchaPassed             = "captcha_passed"
+	MetricCaptchaFailed             = "captcha_failed"
+	MetricSessionsCreated           = "sessions_created"
+	MetricSessionsReset             = "sessions_reset"
+	MetricSessionsActive            = "sessions_active"
+	MetricSessionsEvicted           = "sessions_evicted"
+	MetricSessionsRestored          = "sessions_restored"
+	MetricSessionsJournalRestored   = "sessions_journal_restored"
+	MetricSessionsExpired           = "sessions_expired"
+	MetricDataDirBytes              = "data_dir_bytes"
+	MetricDataDirFiles              = "data_dir_files"
+	MetricNamespace                 = "namespace"
+	MetricGuessCacheHits            = "guess_cache_hits"
+	MetricGuessCacheMisses          = "guess_cache_misses"
+	MetricFeedbackMatrixBytes       = "feedback_matrix_bytes"
+	MetricFleetEventsPublished      = "fleet_events_published"
+	MetricFleetEventsReceived       = "fleet_events_received"
+	MetricFleetPublishFailures      = "fleet_publish_failures"
+	MetricPersistDeferred           = "persist_deferred"
+	MetricPersistFlushed            = "persist_flushed"
+	MetricPersistFinalRetries       = "persist_final_retries"
+	MetricJournalsRebalanced        = "journals_rebalanced"
+	MetricGamesArchived             = "games_archived"
+	MetricArchiveRehydrated         = "archive_rehydrated"
+	MetricShedRequests              = "shed_requests"
+	MetricStoreWriteAverageMS       = "session_store_write_avg_ms"
+	MetricStoreWriteFailures        = "session_store_write_failures"
+	MetricIntegrityScans            = "integrity_scans"
+	MetricIntegrityJournalsRepaired = "integrity_journals_repaired"
+	MetricIntegrityJournalsRemoved  = "integrity_journals_removed"
+	MetricIntegritySessionsInvalid  = "integrity_sessions_invalid"
+	MetricIntegritySessionsRepaired = "integrity_sessions_repaired"
+	MetricRemindersSent             = "reminders_sent"
+	MetricReminderFailures          = "reminder_send_failures"
+	MetricReminderSubscribers       = "reminder_subscribers"
+	MetricPushSent                  = "push_sent"
+	MetricPushFailures              = "push_failures"
+	MetricPushExpired               = "push_expired"
+	MetricPushSubscribers           = "push_subscribers"
+	MetricTransfersCreated          = "transfers_created"
+	MetricTransfersRedeemed         = "transfers_redeemed"
+	MetricStatsMerges               = "stats_merges"
+	MetricStatsMergesUndone         = "stats_merges_undone"
+	MetricAuditFailures             = "audit_write_failures"
+	MetricRetiredKeyVerifications   = "retired_key_verifications"
+	MetricTokenReplays              = "token_replays"
+	MetricRetentionDeleted          = "retention_deleted"
+	// MetricCircuitPrefix starts circuit_<integration> breaker gauges.
+	MetricCircuitPrefix = "circuit_"
+	// MetricExperimentPrefix starts experiment_<name>_<variant>_<event> counters.
+	MetricExperimentPrefix = "experiment_"
+)
+
+// newMetrics returns an unpublished expvar map used to hold application counters.
+func newMetrics() *expvar.Map {
+	return new(expvar.Map).Init()
+}
+
+// registerGauges publishes gauges computed from live application state.
+func (app *App) registerGauges() {
+	if app.Metrics == nil {
+		return
+	}
+	app.Metrics.Set(MetricSessionsActive, expvar.Func(func() any {
+		return app.activeSessionCount()
+	}))
+	app.Metrics.Set(MetricDataDirBytes, expvar.Func(func() any {
+		size, _, _ := dirUsage(DataDir)
+		return size
+	}))
+	app.Metrics.Set(MetricDataDirFiles, expvar.Func(func() any {
+		_, files, _ := dirUsage(DataDir)
+		return files
+	}))
+	if app.StoreHealth != nil {
+		app.Metrics.Set(MetricStoreWriteAverageMS, expvar.Func(func() any {
+			return app.StoreHealth.status().AverageTook.Milliseconds()
+		}))
+		app.Metrics.Set(MetricStoreWriteFailures, expvar.Func(func() any {
+			return app.StoreHealth.status().Failures
+		}))
+	}
+	if app.Reminders != nil {
+		app.Metrics.Set(MetricReminderSubscribers, expvar.Func(func() any {
+			return app.Reminders.confirmed()
+		}))
+	}
+	if app.Push != nil {
+		app.Metrics.Set(MetricPushSubscribers, expvar.Func(func() any {
+			return app.Push.count()
+		}))
+	}
+	if app.Keys != nil {
+		app.Metrics.Set(MetricRetiredKeyVerifications, expvar.Func(func() any {
+			return app.Keys.retiredVerifications()
+		}))
+	}
+	for _, b := range app.circuitBreakers() {
+		app.Metrics.Set(MetricCircuitPrefix+b.Name, expvar.Func(func() any {
+			return b.view()
+		}))
+	}
+	if app.Patterns != nil {
+		app.Metrics.Set(MetricFeedbackMatrixBytes, expvar.Func(func() any {
+			return app.Patterns.Bytes()
+		}))
+	}
+}
+
+// incMetric increments the named counter by one. It is a no-op when metrics are not configured.
+func (app *App) incMetric(name string) {
+	if app.Metrics == nil {
+		return
+	}
+	app.Metrics.Add(name, 1)
+}
+
+// addMetric adds n to the named counter. It is a no-op when metrics are not configured.
+func (app *App) addMetric(name string, n int) {
+	if app.Metrics == nil {
+		return
+	}
+	app.Metrics.Add(name, int64(n))
+}
+
+// metricsHandler returns all application counters as JSON.
+func (app *App) metricsHandler(c *gin.Context) {
+	if app.Metrics == nil {
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(app.Metrics.String()))
+}
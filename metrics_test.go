@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestIncMetric(t *testing.T) {
+	app := &App{}
+	app.incMetric(MetricSessionsCreated)
+
+	app.Metrics = newMetrics()
+	app.incMetric(MetricSessionsCreated)
+	app.incMetric(MetricSessionsCreated)
+	if got := app.Metrics.Get(MetricSessionsCreated); got == nil || got.String() != "2" {
+		t.Errorf("Expected sessions_created to be 2, got %v", got)
+	}
+}
+
+func TestRegisterGauges(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "apple", Hint: "fruit"}})
+	app.Metrics = newMetrics()
+	app.registerGauges()
+	app.createNewGame(dummyContext(), "sess1")
+	app.createNewGame(dummyContext(), "sess2")
+	if got := app.Metrics.Get(MetricSessionsActive); got == nil || got.String() != "2" {
+		t.Errorf("Expected sessions_active to be 2, got %v", got)
+	}
+}
@@ -3,12 +3,17 @@ package main
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/mooship/vortludo/internal/htmx"
 	"golang.org/x/time/rate"
 )
 
@@ -31,6 +36,16 @@ func securityHeadersMiddleware() gin.HandlerFunc {
 
 // getLimiter returns a rate limiter for the given key (usually client IP).
 func (app *App) getLimiter(key string) *rate.Limiter {
+	rps := app.RateLimitRPS
+	if rps <= 0 {
+		rps = 1
+	}
+	return app.getScopedLimiter(key, rate.Every(time.Second/time.Duration(rps)), app.RateLimitBurst)
+}
+
+// getScopedLimiter returns the rate limiter stored under key, creating it with limit and burst.
+// Callers prefix keys to keep limiters for different endpoints separate.
+func (app *App) getScopedLimiter(key string, limit rate.Limit, burst int) *rate.Limiter {
 	app.LimiterMutex.RLock()
 	lim, ok := app.LimiterMap[key]
 	app.LimiterMutex.RUnlock()
@@ -47,27 +62,114 @@ func (app *App) getLimiter(key string) *rate.Limiter {
 	if key == "" || key == "::1" {
 		logWarn("Rate limiter key is empty or loopback: %q", key)
 	}
-	rps := app.RateLimitRPS
-	if rps <= 0 {
-		rps = 1
-	}
-	lim = rate.NewLimiter(rate.Every(time.Second/time.Duration(rps)), app.RateLimitBurst)
+	lim = rate.NewLimiter(limit, burst)
 	app.LimiterMap[key] = lim
 	return lim
 }
 
+// blocklistMiddleware rejects requests from blocklisted or temporarily banned client IPs.
+func (app *App) blocklistMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if app.Blocklist != nil && app.Blocklist.isBlocked(c.ClientIP()) {
+			app.incMetric(MetricBlockedRequests)
+			writeProblem(c, http.StatusForbidden, ErrorCodeAccessDenied, "access denied")
+			return
+		}
+		c.Next()
+	}
+}
+
+// recordAbuse registers an abuse strike (rate limit hit, CSRF failure) against the client IP.
+func (app *App) recordAbuse(c *gin.Context) {
+	if app.Blocklist == nil {
+		return
+	}
+	ip := c.ClientIP()
+	if app.Blocklist.recordStrike(ip) {
+		app.incMetric(MetricAbuseBans)
+		logWarn("Temporarily banned %s after repeated abuse", ip)
+	}
+}
+
 // rateLimitMiddleware returns a Gin middleware that enforces per-client rate limiting.
 func (app *App) rateLimitMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		key := c.ClientIP()
-		if !app.getLimiter(key).Allow() {
-			if c.GetHeader("HX-Request") == "true" {
-				c.Header("HX-Trigger", "rate-limit-exceeded")
-			}
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests. Please slow down."})
+		if !app.getLimiter(key).AllowN(app.now(), 1) {
+			app.rejectRateLimited(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// hintRateLimitMiddleware applies the stricter hint API limit of one request per
+// HintRateInterval per client IP, with bursts of HintRateBurst.
+func (app *App) hintRateLimitMiddleware() gin.HandlerFunc {
+	return app.scopedRateLimitMiddleware("hint", app.HintRateInterval, 10*time.Second, app.HintRateBurst)
+}
+
+// validateRateLimitMiddleware limits guess pre-validation to one request per
+// ValidateRateInterval per client IP, with bursts of ValidateRateBurst.
+func (app *App) validateRateLimitMiddleware() gin.HandlerFunc {
+	return app.scopedRateLimitMiddleware("validate", app.ValidateRateInterval, 200*time.Millisecond, app.ValidateRateBurst)
+}
+
+// exportRateLimitMiddleware limits stats exports to one request per ExportRateInterval per
+// client IP, with bursts of ExportRateBurst.
+func (app *App) exportRateLimitMiddleware() gin.HandlerFunc {
+	return app.scopedRateLimitMiddleware("export", app.ExportRateInterval, 30*time.Second, app.ExportRateBurst)
+}
+
+// scopedRateLimitMiddleware enforces a per-client limit kept separate from the general
+// limiter under the given scope. A non-positive interval falls back to fallback.
+func (app *App) scopedRateLimitMiddleware(scope string, interval, fallback time.Duration, burst int) gin.HandlerFunc {
+	if interval <= 0 {
+		interval = fallback
+	}
+	burst = max(burst, 1)
+	retryAfter := strconv.Itoa(int(math.Ceil(interval.Seconds())))
+	return func(c *gin.Context) {
+		if !app.getScopedLimiter(scope+"|"+c.ClientIP(), rate.Every(interval), burst).AllowN(app.now(), 1) {
+			c.Header("Retry-After", retryAfter)
+			app.rejectRateLimited(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// rejectRateLimited records the strike and aborts the request with 429 Too Many Requests.
+func (app *App) rejectRateLimited(c *gin.Context) {
+	app.recordAbuse(c)
+	if htmx.IsRequest(c.Request) {
+		Triggers{}.rateLimitExceeded().set(c)
+	}
+	writeProblem(c, http.StatusTooManyRequests, ErrorCodeRateLimited, "too many requests, please slow down")
+}
+
+// timeoutMiddleware bounds a request with a deadline and cancels its context when the deadline passes.
+// If the handler chain finishes without writing a response after the deadline, a 503 is returned.
+func (app *App) timeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
 			return
 		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
 		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			reqID := requestIDFrom(ctx)
+			logWarn("[request_id=%v] Request to %s exceeded timeout of %v", reqID, c.Request.URL.Path, timeout)
+			if !c.Writer.Written() {
+				writeProblem(c, http.StatusServiceUnavailable, ErrorCodeTimeout, "request timed out, please try again")
+			}
+		}
 	}
 }
 
@@ -85,12 +187,43 @@ func requestIDMiddleware() gin.HandlerFunc {
 	}
 }
 
+// errorRefLength is how much of the request ID is shown to players as an error reference; it is
+// enough to find the request in the logs.
+const errorRefLength = 8
+
+// requestIDFrom returns the request ID carried by ctx, or "" outside a request.
+func requestIDFrom(ctx context.Context) string {
+	reqID, _ := ctx.Value(requestIDKey).(string)
+	return reqID
+}
+
+// errorRef returns the short reference shown with errors so players can quote it to support.
+func errorRef(c *gin.Context) string {
+	reqID := requestIDFrom(c.Request.Context())
+	return reqID[:min(len(reqID), errorRefLength)]
+}
+
+// setOutboundRequestID passes the request ID in req's context on to a third party, so its logs
+// can be matched with ours.
+func setOutboundRequestID(req *http.Request) {
+	if reqID := requestIDFrom(req.Context()); reqID != "" {
+		req.Header.Set("X-Request-Id", reqID)
+	}
+}
+
 // validateCSRFMiddleware enforces that unsafe methods include a matching CSRF token
 func (app *App) validateCSRFMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// The game generator API is called by bots without a session, and never reads one. Mail
+		// clients unsubscribe with a one-click POST, authorized by the secret token in its path.
+		if strings.HasPrefix(c.Request.URL.Path, RouteAdmin+"/") || c.Request.URL.Path == RouteGamesAPI ||
+			strings.HasPrefix(c.Request.URL.Path, RouteReminders+"/unsubscribe/") {
+			c.Next()
+			return
+		}
 		method := c.Request.Method
 		if method == http.MethodPost || method == http.MethodPut || method == http.MethodDelete || method == http.MethodPatch {
-			cookie, _ := c.Cookie("csrf_token")
+			cookie, _ := c.Cookie(app.cookieName(CSRFCookieName))
 			header := c.GetHeader("X-CSRF-Token")
 			form := c.PostForm("csrf_token")
 			var token string
@@ -99,8 +232,8 @@ func (app *App) validateCSRFMiddleware() gin.HandlerFunc {
 			} else if form != "" {
 				token = form
 			}
-			if token == "" || cookie == "" || token != cookie {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "invalid csrf token"})
+			if reason := csrfFailureReason(token, cookie); reason != "" {
+				app.rejectCSRF(c, reason)
 				return
 			}
 		}
@@ -108,18 +241,54 @@ func (app *App) validateCSRFMiddleware() gin.HandlerFunc {
 	}
 }
 
+// csrfFailureReason classifies a CSRF check, returning an empty string when the token is valid.
+// A submitted token without a cookie means the cookie expired since the page was rendered.
+func csrfFailureReason(token, cookie string) string {
+	switch {
+	case token == "":
+		return CSRFReasonMissing
+	case cookie == "":
+		return CSRFReasonExpired
+	case token != cookie:
+		return CSRFReasonMismatch
+	default:
+		return ""
+	}
+}
+
+// rejectCSRF records a CSRF failure and aborts the request. HTMX clients receive a csrf-invalid
+// trigger so the page can prompt a refresh and pick up the fresh token cookie.
+func (app *App) rejectCSRF(c *gin.Context, reason string) {
+	switch reason {
+	case CSRFReasonMissing:
+		app.incMetric(MetricCSRFFailureMissing)
+	case CSRFReasonExpired:
+		app.incMetric(MetricCSRFFailureExpired)
+	default:
+		app.incMetric(MetricCSRFFailureMismatch)
+	}
+	logWarn("CSRF validation failed (%s) for %s %s", reason, c.Request.Method, c.Request.URL.Path)
+	app.recordAbuse(c)
+
+	if htmx.IsRequest(c.Request) {
+		Triggers{}.csrfInvalid(reason).set(c)
+		htmx.Reswap(c.Writer.Header(), htmx.SwapNone)
+	}
+	writeProblem(c, http.StatusForbidden, csrfErrorCodes[reason], "invalid csrf token: "+reason)
+}
+
 // csrfMiddleware ensures a per-session CSRF token cookie exists and stores it in the context.
 // It does not validate requests; handlers should validate the token on unsafe methods.
 func (app *App) csrfMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token, err := c.Cookie("csrf_token")
+		token, err := c.Cookie(app.cookieName(CSRFCookieName))
 		if err != nil || len(token) < 8 {
 			b := make([]byte, 32)
 			if _, err := rand.Read(b); err == nil {
 				token = fmt.Sprintf("%x", b)
 				secure := app.IsProduction
 				c.SetSameSite(http.SameSiteLaxMode)
-				c.SetCookie("csrf_token", token, int(app.CookieMaxAge.Seconds()), "/", "", secure, false)
+				c.SetCookie(app.cookieName(CSRFCookieName), token, int(app.CookieMaxAge.Seconds()), "/", "", secure, false)
 			}
 		}
 		c.Set("csrf_token", token)
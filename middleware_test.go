@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+func TestCSRFFailureReason(t *testing.T) {
+	cases := []struct {
+		token, cookie, expected string
+	}{
+		{"", "abc", CSRFReasonMissing},
+		{"", "", CSRFReasonMissing},
+		{"abc", "", CSRFReasonExpired},
+		{"abc", "def", CSRFReasonMismatch},
+		{"abc", "abc", ""},
+	}
+	for _, c := range cases {
+		if got := csrfFailureReason(c.token, c.cookie); got != c.expected {
+			t.Errorf("csrfFailureReason(%q, %q) = %q, want %q", c.token, c.cookie, got, c.expected)
+		}
+	}
+}
+
+func TestValidateCSRFMiddleware_HTMXTrigger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{Metrics: newMetrics()}
+	router := gin.New()
+	router.Use(app.validateCSRFMiddleware())
+	router.POST(RouteGuess, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", RouteGuess, nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("X-CSRF-Token", "stale")
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected 403, got %d", w.Code)
+	}
+	if w.Header().Get("HX-Trigger") != `{"csrf-invalid":{"reason":"expired"}}` {
+		t.Errorf("Unexpected HX-Trigger %q", w.Header().Get("HX-Trigger"))
+	}
+	if got := app.Metrics.Get(MetricCSRFFailureExpired); got == nil || got.String() != "1" {
+		t.Errorf("Expected expired counter to be 1, got %v", got)
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{}
+	router := gin.New()
+	router.GET("/slow", app.timeoutMiddleware(10*time.Millisecond), func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 after timeout, got %d", w.Code)
+	}
+}
+
+func TestHintRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{
+		LimiterMap:       make(map[string]*rate.Limiter),
+		HintRateInterval: time.Minute,
+		HintRateBurst:    2,
+		RateLimitRPS:     5,
+		RateLimitBurst:   10,
+	}
+	router := gin.New()
+	router.GET(RouteHintAPI, app.hintRateLimitMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", RouteHintAPI, nil))
+		if w.Code != want {
+			t.Fatalf("request %d: expected %d, got %d", i+1, want, w.Code)
+		}
+		if want == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "60" {
+			t.Errorf("Expected Retry-After 60, got %q", w.Header().Get("Retry-After"))
+		}
+	}
+
+	if !app.getLimiter("192.0.2.1").Allow() {
+		t.Error("Hint limiter should not consume the general rate limit")
+	}
+}
+
+func TestOutboundRequestIDAndErrorRef(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.WithValue(context.Background(), requestIDKey, "abcdef1234567890")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://example.com/verify", nil)
+	setOutboundRequestID(req)
+	if got := req.Header.Get("X-Request-Id"); got != "abcdef1234567890" {
+		t.Errorf("outbound X-Request-Id = %q", got)
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	if ref := errorRef(c); ref != "abcdef12" {
+		t.Errorf("errorRef() = %q, want abcdef12", ref)
+	}
+}
+
+func BenchmarkLimiterLookup(b *testing.B) {
+	app := &App{LimiterMap: make(map[string]*rate.Limiter), RateLimitRPS: 5, RateLimitBurst: 10}
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("192.0.2.%d", i)
+		app.getLimiter(keys[i])
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			app.getLimiter(keys[i%len(keys)])
+			i++
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"expvar"
+	"path/filepath"
+	"regexp"
+)
+
+// NamespacesDir holds the data files of namespaced deployments, one directory per namespace.
+const NamespacesDir = "data/namespaces"
+
+// namespacePattern restricts namespaces to values that are safe in cookie names and paths.
+var namespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// parseNamespace validates the NAMESPACE setting, which lets several instances (for example one
+// per language community) share a host or backend without their cookies, data files, or metrics
+// colliding. An empty namespace keeps the unprefixed names.
+func parseNamespace(ns string) (string, bool) {
+	if ns == "" {
+		return "", true
+	}
+	return ns, namespacePattern.MatchString(ns)
+}
+
+// dataPath returns the default path of a data file, under NamespacesDir/<ns>/ when a namespace
+// is set.
+func dataPath(namespace, name string) string {
+	if namespace == "" {
+		return filepath.ToSlash(filepath.Join(DataDir, name))
+	}
+	return filepath.ToSlash(filepath.Join(NamespacesDir, namespace, name))
+}
+
+// cookieName prefixes a cookie name with the app's namespace.
+func (app *App) cookieName(name string) string {
+	if app.Namespace == "" {
+		return name
+	}
+	return app.Namespace + "_" + name
+}
+
+// publishNamespace labels the metrics with the namespace so scrapers can tell instances apart.
+func (app *App) publishNamespace() {
+	if app.Metrics == nil || app.Namespace == "" {
+		return
+	}
+	label := new(expvar.String)
+	label.Set(app.Namespace)
+	app.Metrics.Set(MetricNamespace, label)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseNamespace(t *testing.T) {
+	tests := map[string]bool{
+		"":                                   true,
+		"eo":                                 true,
+		"team-42":                            true,
+		"EO":                                 false,
+		"-eo":                                false,
+		"eo/../x":                            false,
+		"eo_x":                               false,
+		"a234567890123456789012345678901234": false,
+	}
+	for ns, want := range tests {
+		if _, ok := parseNamespace(ns); ok != want {
+			t.Errorf("parseNamespace(%q) ok = %v, want %v", ns, ok, want)
+		}
+	}
+}
+
+func TestDataPath(t *testing.T) {
+	if got := dataPath("", "blocklist.json"); got != "data/blocklist.json" {
+		t.Errorf("dataPath without namespace = %q", got)
+	}
+	if got := dataPath("eo", "blocklist.json"); got != "data/namespaces/eo/blocklist.json" {
+		t.Errorf("dataPath with namespace = %q", got)
+	}
+}
+
+func TestNamespacedSessionCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Namespace = "eo"
+	if got := app.cookieName(SessionCookieName); got != "eo_session_id" {
+		t.Fatalf("cookieName() = %q", got)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "unprefixed-session"})
+	if id := app.getOrCreateSession(c); id == "unprefixed-session" {
+		t.Error("Expected another namespace's cookie to be ignored")
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 || cookies[0].Name != "eo_session_id" {
+		t.Errorf("Expected a prefixed session cookie, got %v", cookies)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"hash"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mooship/vortludo/engine"
+)
+
+// ModeCustom is the mode of games started from a custom game link.
+const ModeCustom = "custom"
+
+// Accepted-word overlay file suffixes: <mode>.txt adds words to a mode, <mode>.only.txt limits it
+const (
+	overlayExtraSuffix = ".txt"
+	overlayOnlySuffix  = ".only.txt"
+)
+
+// AcceptedOverlay adjusts the accepted guesses of one game mode. It holds only its own words
+// and is layered over the shared accepted set on each lookup, so no mode keeps a copy of the
+// base list. Extra words are accepted on top of the base set. When Only is set it replaces the
+// base set for the mode, though playable words stay accepted so every target can be guessed.
+type AcceptedOverlay struct {
+	Extra map[string]struct{}
+	Only  map[string]struct{}
+}
+
+// loadAcceptedOverlays reads the overlay files in dir keyed by mode. A missing directory is
+// not an error.
+func loadAcceptedOverlays(dir string) (map[string]*AcceptedOverlay, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+overlayExtraSuffix))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	overlays := make(map[string]*AcceptedOverlay)
+	for _, file := range files {
+		name := filepath.Base(file)
+		mode, only := strings.CutSuffix(name, overlayOnlySuffix)
+		if !only {
+			mode = strings.TrimSuffix(name, overlayExtraSuffix)
+		}
+		if !packNamePattern.MatchString(mode) {
+			logWarn("Skipping accepted-word overlay %s: invalid mode name", file)
+			continue
+		}
+		words, err := engine.LoadAcceptedWords(file)
+		if err != nil {
+			return nil, fmt.Errorf("overlay %s: %w", file, err)
+		}
+		overlay := overlays[mode]
+		if overlay == nil {
+			overlay = &AcceptedOverlay{}
+			overlays[mode] = overlay
+		}
+		if only {
+			overlay.Only = words
+		} else {
+			overlay.Extra = words
+		}
+		logInfo("Loaded accepted-word overlay %s for %s games with %d words", name, mode, len(words))
+	}
+	return overlays, nil
+}
+
+// acceptedMode returns the overlay mode that applies to guesses in game. Games that are not
+// custom, purist, or kids games, and requests without a game, are casual.
+func acceptedMode(game *GameState) string {
+	switch {
+	case game == nil:
+		return SelectionModeCasual
+	case game.Custom:
+		return ModeCustom
+	case game.Purist:
+		return ModePurist
+	case game.Kids:
+		return ModeKids
+	}
+	return SelectionModeCasual
+}
+
+// isAccepted reports whether word is an accepted guess in mode, resolving the mode's overlay
+// over the base set. Modes without an overlay, including "", use the base set.
+func (l *WordLists) isAccepted(mode, word string) bool {
+	overlay := l.Overlays[mode]
+	if overlay == nil {
+		return inSet(l.AcceptedWordSet, word)
+	}
+	if inSet(overlay.Extra, word) {
+		return true
+	}
+	if overlay.Only == nil {
+		return inSet(l.AcceptedWordSet, word)
+	}
+	return inSet(overlay.Only, word) || inSet(l.WordSet, word)
+}
+
+// acceptedText returns the accepted words of mode as sorted newline-separated text, for the
+// client-side engine. Only modes with an overlay build a list; the rest share the base text.
+func (l *WordLists) acceptedText(mode string) []byte {
+	overlay := l.Overlays[mode]
+	if overlay == nil {
+		return l.AcceptedWordsText
+	}
+	var words []string
+	for _, set := range []map[string]struct{}{l.AcceptedWordSet, overlay.Extra, overlay.Only} {
+		for word := range set {
+			if l.isAccepted(mode, word) {
+				words = append(words, word)
+			}
+		}
+	}
+	slices.Sort(words)
+	return []byte(strings.Join(slices.Compact(words), "\n") + "\n")
+}
+
+// writeOverlays adds every overlay to a word list version hash, so changing one yields a new
+// version and games in progress keep the overlay they started with.
+func writeOverlays(h hash.Hash, overlays map[string]*AcceptedOverlay) {
+	for _, mode := range slices.Sorted(maps.Keys(overlays)) {
+		overlay := overlays[mode]
+		fmt.Fprintf(h, "overlay:%s\n%s\n--\n%s\n", mode,
+			strings.Join(slices.Sorted(maps.Keys(overlay.Extra)), "\n"),
+			strings.Join(slices.Sorted(maps.Keys(overlay.Only)), "\n"))
+	}
+}
+
+// inSet reports whether word is in set.
+func inSet(set map[string]struct{}, word string) bool {
+	_, ok := set[word]
+	return ok
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAcceptedOverlaysByMode(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"purist.txt":      "zymic\n",
+		"custom.only.txt": "CRANE\nSLATE\n",
+		"Bad Mode.txt":    "TRUCK\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	overlays, err := loadAcceptedOverlays(dir)
+	if err != nil || len(overlays) != 2 {
+		t.Fatalf("loadAcceptedOverlays() = %v, %v; want purist and custom", overlays, err)
+	}
+
+	app := &App{GameSessions: make(map[string]*GameState)}
+	accepted := map[string]struct{}{"CRANE": {}, "SLATE": {}, "TRUCK": {}}
+	app.registerWordPacks([]*WordPack{newWordPack(DefaultPackName, []WordEntry{{Word: "PLANT"}})}, accepted, overlays)
+
+	tests := []struct {
+		mode, word string
+		want       bool
+	}{
+		{SelectionModeCasual, "TRUCK", true},
+		{SelectionModeCasual, "ZYMIC", false},
+		{ModePurist, "ZYMIC", true},
+		{ModePurist, "TRUCK", true},
+		{ModeCustom, "SLATE", true},
+		{ModeCustom, "TRUCK", false},
+		{ModeCustom, "PLANT", true},
+	}
+	for _, tt := range tests {
+		if got := app.isAcceptedWord(tt.mode, tt.word); got != tt.want {
+			t.Errorf("isAcceptedWord(%s, %s) = %v, want %v", tt.mode, tt.word, got, tt.want)
+		}
+	}
+	if got := acceptedMode(&GameState{Custom: true, Purist: true}); got != ModeCustom {
+		t.Errorf("acceptedMode(custom purist game) = %s", got)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(RouteAccepted, app.acceptedWordsHandler)
+	for mode, want := range map[string]string{
+		"":         "CRANE\nPLANT\nSLATE\nTRUCK\n",
+		ModeCustom: "CRANE\nPLANT\nSLATE\n",
+		ModePurist: "CRANE\nPLANT\nSLATE\nTRUCK\nZYMIC\n",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", RouteAccepted+"?mode="+mode, nil))
+		if got := w.Body.String(); got != want {
+			t.Errorf("accepted words for %q = %q, want %q", mode, got, want)
+		}
+	}
+
+	plain := newWordLists([]*WordPack{app.lists().Packs[DefaultPackName]}, accepted, nil)
+	if plain.WordListVersion == app.lists().WordListVersion {
+		t.Error("Expected the overlays to change the word list version")
+	}
+}
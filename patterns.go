@@ -0,0 +1,21 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/mooship/vortludo/engine"
+)
+
+// newFeedbackMatrix builds the feedback pattern matrix of accepted guesses against playable
+// words for the solver and adversarial features, or returns nil when maxBytes is not positive.
+func newFeedbackMatrix(accepted, playable map[string]struct{}, maxBytes int) *engine.FeedbackMatrix {
+	if maxBytes <= 0 {
+		return nil
+	}
+	start := time.Now()
+	m := engine.NewFeedbackMatrix(slices.Sorted(maps.Keys(accepted)), slices.Sorted(maps.Keys(playable)), maxBytes)
+	logInfo("Feedback matrix ready: %d candidates, %d KB precomputed in %v", m.Candidates(), m.Bytes()>>10, time.Since(start).Round(time.Millisecond))
+	return m
+}
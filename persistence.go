@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Storage is the file access used by the JSON-backed stores (blocklist, calendar, suggestions,
+// global stats). Names are slash-separated paths relative to the storage root.
+type Storage interface {
+	// ReadFile returns the file's contents, or an error matching fs.ErrNotExist if it is missing.
+	ReadFile(name string) ([]byte, error)
+	// WriteFile replaces the file's contents atomically, creating parent directories as needed.
+	WriteFile(name string, data []byte) error
+}
+
+// LockStorage is Storage that can also create a file only when it is missing, move files, and
+// remove them, which lets lease claims be atomic.
+type LockStorage interface {
+	Storage
+	// CreateExclusive writes data as name only if name does not exist, failing with an error
+	// matching fs.ErrExist otherwise. The file never appears partly written.
+	CreateExclusive(name string, data []byte) error
+	// Rename moves oldName to newName, replacing newName, and fails with an error matching
+	// fs.ErrNotExist if oldName is missing.
+	Rename(oldName, newName string) error
+	// Remove deletes name.
+	Remove(name string) error
+}
+
+// DirStorage stores files on disk under Root. An empty Root resolves names against the working
+// directory, which is how the data/... defaults are written.
+type DirStorage struct {
+	Root string
+}
+
+// path resolves name against the root. Absolute names are used as-is.
+func (d DirStorage) path(name string) string {
+	name = filepath.FromSlash(name)
+	if d.Root == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(d.Root, name)
+}
+
+// ReadFile reads name from disk.
+func (d DirStorage) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(d.path(name))
+}
+
+// WriteFile writes data to a uniquely named temporary file beside name, syncs it, and renames
+// it into place, so concurrent writers never share a temporary file and a crash leaves either
+// the old contents or the new.
+func (d DirStorage) WriteFile(name string, data []byte) error {
+	path := d.path(name)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// CreateExclusive writes data to a temporary file and hard-links it as name, which fails if
+// name exists, so the file appears with all of its contents or not at all.
+func (d DirStorage) CreateExclusive(name string, data []byte) error {
+	path := d.path(name)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Link(tmp, path)
+	}
+	return err
+}
+
+// Rename moves oldName to newName on disk.
+func (d DirStorage) Rename(oldName, newName string) error {
+	return os.Rename(d.path(oldName), d.path(newName))
+}
+
+// Remove deletes name from disk.
+func (d DirStorage) Remove(name string) error {
+	return os.Remove(d.path(name))
+}
+
+// syncDir flushes a directory so a rename in it survives a crash. It is best effort: some
+// platforms cannot sync directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// MemStorage keeps files in memory, for tests and ephemeral deployments.
+type MemStorage struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// newMemStorage returns an empty in-memory storage.
+func newMemStorage() *MemStorage {
+	return &MemStorage{files: make(map[string][]byte)}
+}
+
+// ReadFile returns a copy of name's contents.
+func (m *MemStorage) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// WriteFile stores a copy of data as name.
+func (m *MemStorage) WriteFile(name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// CreateExclusive stores a copy of data as name unless name exists.
+func (m *MemStorage) CreateExclusive(name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; ok {
+		return &fs.PathError{Op: "create", Path: name, Err: fs.ErrExist}
+	}
+	m.files[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// Rename moves oldName to newName.
+func (m *MemStorage) Rename(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[oldName]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldName, Err: fs.ErrNotExist}
+	}
+	delete(m.files, oldName)
+	m.files[newName] = data
+	return nil
+}
+
+// Remove deletes name.
+func (m *MemStorage) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+// readJSONFile decodes name into v. A missing file or empty name leaves v untouched and is not
+// an error; found reports whether anything was read.
+func readJSONFile(st Storage, name string, v any) (found bool, err error) {
+	if name == "" {
+		return false, nil
+	}
+	data, err := st.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// writeJSONFile encodes v as indented JSON and writes it to name. An empty name disables
+// persistence.
+func writeJSONFile(st Storage, name string, v any) error {
+	if name == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	return st.WriteFile(name, data)
+}
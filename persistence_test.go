@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestDirStorage(t *testing.T) {
+	st := DirStorage{Root: t.TempDir()}
+	if _, err := st.ReadFile("nested/missing.json"); !os.IsNotExist(err) {
+		t.Errorf("Expected not-exist for a missing file, got %v", err)
+	}
+	if err := st.WriteFile("nested/state.json", []byte("one")); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.WriteFile("nested/state.json", []byte("two")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := st.ReadFile("nested/state.json"); err != nil || string(data) != "two" {
+		t.Errorf("ReadFile() = %q, %v", data, err)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(st.Root, "nested", "*.tmp")); len(tmps) != 0 {
+		t.Errorf("Expected the temporary file to be renamed away, found %v", tmps)
+	}
+}
+
+func TestDirStorageConcurrentWrites(t *testing.T) {
+	st := DirStorage{Root: t.TempDir()}
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Go(func() {
+			errs <- st.WriteFile("state.json", []byte(strconv.Itoa(i)))
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent WriteFile() = %v", err)
+		}
+	}
+	if data, err := st.ReadFile("state.json"); err != nil || len(data) == 0 {
+		t.Errorf("ReadFile() after concurrent writes = %q, %v", data, err)
+	}
+}
+
+func TestJSONFileHelpers(t *testing.T) {
+	st := newMemStorage()
+	var v map[string]int
+	if found, err := readJSONFile(st, "counts.json", &v); found || err != nil {
+		t.Errorf("Expected a missing file to be skipped, got %v, %v", found, err)
+	}
+	if err := writeJSONFile(st, "counts.json", map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := readJSONFile(st, "counts.json", &v); !found || err != nil || v["a"] != 1 {
+		t.Errorf("readJSONFile() = %v, %v, %v", found, err, v)
+	}
+
+	st.WriteFile("corrupt.json", []byte("{"))
+	if _, err := readJSONFile(st, "corrupt.json", &v); err == nil {
+		t.Error("Expected corrupt JSON to fail")
+	}
+	if err := writeJSONFile(st, "", v); err != nil {
+		t.Errorf("Expected an empty name to disable writes, got %v", err)
+	}
+}
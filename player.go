@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PlayerCookieName holds the long-lived anonymous player ID when remember-me is enabled.
+const PlayerCookieName = "player_id"
+
+// playerContextKey caches the request's player ID in the Gin context.
+const playerContextKey = "player_id"
+
+// PlayerIDs issues and verifies signed anonymous player IDs. Unlike a session, which lasts
+// SESSION_TIMEOUT, a player ID lives for PLAYER_COOKIE_MAX_AGE, so personal stats and streaks
+// survive session expiry without accounts.
+type PlayerIDs struct {
+	keys *Keyring
+}
+
+// newPlayerIDs returns a signer keyed by keys.
+func newPlayerIDs(keys *Keyring) *PlayerIDs {
+	return &PlayerIDs{keys: keys}
+}
+
+// playerMAC signs a player ID, prefixed by the label of the value it appears in.
+func playerMAC(secret []byte, label, id string) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(label + id))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:16])
+}
+
+// sign returns "id.keyID.signature" for id under label, signed with the current key.
+func (p *PlayerIDs) sign(label, id string) string {
+	key := p.keys.current()
+	return id + "." + key.id + "." + playerMAC(key.secret, label, id)
+}
+
+// verifySigned returns the player ID in a value made by sign with label. Values from before
+// key IDs, "id.signature", are checked against every key.
+func (p *PlayerIDs) verifySigned(label, value string) (string, bool) {
+	parts := strings.Split(value, ".")
+	var id, signer, sig string
+	switch len(parts) {
+	case 2:
+		id, sig = parts[0], parts[1]
+	case 3:
+		id, signer, sig = parts[0], parts[1], parts[2]
+	default:
+		return "", false
+	}
+	if id == "" || !p.keys.verify(signer, func(key signingKey) bool {
+		return hmac.Equal([]byte(sig), []byte(playerMAC(key.secret, label, id)))
+	}) {
+		return "", false
+	}
+	return id, true
+}
+
+// issue returns the signed cookie value for id.
+func (p *PlayerIDs) issue(id string) string {
+	return p.sign("", id)
+}
+
+// verify returns the player ID in a signed cookie value.
+func (p *PlayerIDs) verify(value string) (string, bool) {
+	return p.verifySigned("", value)
+}
+
+// rememberPlayer reads the player cookie, issuing a new ID when it is missing or forged, and
+// reissues the cookie so its lifetime slides with activity and it moves to the current signing
+// key. A new ID adopts the session's stats so nothing recorded before remember-me took effect
+// is lost. It is a no-op when remember-me is disabled.
+func (app *App) rememberPlayer(c *gin.Context, sessionID string) {
+	if app.PlayerIDs == nil || app.PlayerCookieMaxAge <= 0 {
+		return
+	}
+	value, _ := c.Cookie(app.cookieName(PlayerCookieName))
+	id, ok := app.PlayerIDs.verify(value)
+	if !ok {
+		id = uuid.NewString()
+		if app.Players != nil {
+			app.Players.adopt(sessionID, playerStatsKey(id))
+			for _, mode := range []string{ModePurist, ModeKids} {
+				app.Players.adopt(statsBucket(sessionID, mode), statsBucket(playerStatsKey(id), mode))
+			}
+		}
+	}
+	c.Set(playerContextKey, id)
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(app.cookieName(PlayerCookieName), app.PlayerIDs.issue(id), int(app.PlayerCookieMaxAge.Seconds()), "/", "", app.IsProduction, true)
+}
+
+// playerStatsKey is the PlayerStatsStore key for a remembered player. The prefix keeps player
+// IDs and session IDs from colliding.
+func playerStatsKey(id string) string {
+	return "player:" + id
+}
+
+// playerKey returns the key personal stats are stored under for this request: the remembered
+// player when there is one, otherwise the session.
+func (app *App) playerKey(c *gin.Context, sessionID string) string {
+	if id := c.GetString(playerContextKey); id != "" {
+		return playerStatsKey(id)
+	}
+	if app.PlayerIDs != nil && app.PlayerCookieMaxAge > 0 {
+		value, _ := c.Cookie(app.cookieName(PlayerCookieName))
+		if id, ok := app.PlayerIDs.verify(value); ok {
+			return playerStatsKey(id)
+		}
+	}
+	return sessionID
+}
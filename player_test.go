@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestPlayerIDs(t *testing.T) {
+	p := newPlayerIDs(newKeyring(make([]byte, 32)))
+	value := p.issue("abc")
+	if id, ok := p.verify(value); !ok || id != "abc" {
+		t.Errorf("verify(%q) = %q, %v", value, id, ok)
+	}
+	for _, forged := range []string{"", "abc", "abc.", "abd" + value[3:], value + "x"} {
+		if _, ok := p.verify(forged); ok {
+			t.Errorf("Expected %q to be rejected", forged)
+		}
+	}
+}
+
+func TestRememberedPlayerKeepsStatsAcrossSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Players = newPlayerStatsStore(StreakFreezeRules{})
+	app.PlayerIDs = newPlayerIDs(newKeyring(make([]byte, 32)))
+	app.PlayerCookieMaxAge = 365 * 24 * time.Hour
+	app.Players.record("first-session", GameRecord{Word: "SLATE", Won: true, Guesses: 3})
+
+	visit := func(sessionID string, cookies ...*http.Cookie) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.AddCookie(&http.Cookie{Name: SessionCookieName, Value: sessionID})
+		for _, cookie := range cookies {
+			c.Request.AddCookie(cookie)
+		}
+		app.getOrCreateSession(c)
+		return c, w
+	}
+
+	c, w := visit("first-session")
+	var player *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == PlayerCookieName {
+			player = cookie
+		}
+	}
+	if player == nil {
+		t.Fatal("Expected a player cookie")
+	}
+	app.recordPlayerGame(c, "first-session", &GameState{GameState: engine.GameState{SessionWord: "CRANE", Won: true, GuessHistory: []string{"CRANE"}}})
+
+	c, _ = visit("second-session", player)
+	if _, _, s := app.Players.summary(app.playerKey(c, "second-session"), ""); s.Played != 2 || s.CurrentStreak != 2 {
+		t.Errorf("Expected both games to follow the player to a new session, got %+v", s)
+	}
+}
@@ -0,0 +1,580 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stats export format constants
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+)
+
+// GameRecord is one finished game in a player's history.
+type GameRecord struct {
+	FinishedAt time.Time `json:"finished_at"`
+	Word       string    `json:"word"`
+	Pack       string    `json:"pack"`
+	Won        bool      `json:"won"`
+	Guesses    int       `json:"guesses"`
+	HintsUsed  int       `json:"hints_used"`
+	PuzzleDate string    `json:"puzzle_date,omitempty"`
+	Mode       string    `json:"mode,omitempty"`
+}
+
+// playerSummary aggregates a player's history.
+type playerSummary struct {
+	Played        int     `json:"played"`
+	Won           int     `json:"won"`
+	WinRate       float64 `json:"win_rate"`
+	CurrentStreak int     `json:"current_streak"`
+	MaxStreak     int     `json:"max_streak"`
+	// FreezesRemaining and FreezesUsed track the streak freezes of StreakFreezeRules.
+	FreezesRemaining int             `json:"freezes_remaining"`
+	FreezesUsed      int             `json:"freezes_used"`
+	Distribution     [MaxGuesses]int `json:"distribution"`
+	BonusPoints      int             `json:"bonus_points"`
+	Coins            int             `json:"coins"`
+}
+
+// statsCarry is the running state of summarize partway through a history, so the games
+// before that point can be archived without changing the aggregates.
+type statsCarry struct {
+	Summary          playerSummary
+	LastDate         string
+	WinsTowardFreeze int
+	// First is when the oldest game folded in finished.
+	First time.Time
+}
+
+// add folds the next game of a history, ordered oldest first, into the carry.
+func (c *statsCarry) add(rec GameRecord, rules StreakFreezeRules) {
+	if c.First.IsZero() {
+		c.First = rec.FinishedAt
+	}
+	s := &c.Summary
+	s.bridgeGap(missedDays(c.LastDate, rec.PuzzleDate))
+	if rec.PuzzleDate != "" {
+		c.LastDate = rec.PuzzleDate
+	}
+	s.Played++
+	if !rec.Won {
+		s.CurrentStreak = 0
+		return
+	}
+	s.Won++
+	s.CurrentStreak++
+	s.MaxStreak = max(s.MaxStreak, s.CurrentStreak)
+	if rec.Guesses >= 1 && rec.Guesses <= MaxGuesses {
+		s.Distribution[rec.Guesses-1]++
+	}
+	if rules.WinsPerFreeze > 0 {
+		c.WinsTowardFreeze++
+		if c.WinsTowardFreeze == rules.WinsPerFreeze {
+			c.WinsTowardFreeze = 0
+			s.FreezesRemaining = min(s.FreezesRemaining+1, rules.MaxFreezes)
+		}
+	}
+}
+
+// summarize computes totals, streaks, and the winning guess distribution from a history
+// ordered oldest first. Missed puzzle days, including those up to today, end the current
+// streak unless freezes earned under rules cover them.
+func summarize(history []GameRecord, rules StreakFreezeRules, today string) playerSummary {
+	return summarizeFrom(statsCarry{}, history, rules, today)
+}
+
+// summarizeFrom is summarize for a history whose earlier games were already folded into carry.
+func summarizeFrom(carry statsCarry, history []GameRecord, rules StreakFreezeRules, today string) playerSummary {
+	for _, rec := range history {
+		carry.add(rec, rules)
+	}
+	s := carry.Summary
+	s.bridgeGap(missedDays(carry.LastDate, today))
+	if s.Played > 0 {
+		s.WinRate = float64(s.Won) / float64(s.Played)
+	}
+	return s
+}
+
+// PlayerStatsStore keeps the finished-game history of each session in memory, along with stats
+// imported from other clients keyed by source. With an archive, old games are moved out of
+// memory and only their aggregates stay here, in carry. Everything but pending merge undos is
+// persisted to a JSON file, written when flushed after a change.
+type PlayerStatsStore struct {
+	mu      sync.RWMutex
+	storage Storage
+	path    string
+	dirty   bool
+	// leader reports whether this replica writes the store; nil means it always does.
+	leader  func() bool
+	players map[string][]GameRecord
+	imports map[string]map[string]importedStats
+	bonus   map[string]int
+	coins   map[string]int
+	carry   map[string]statsCarry
+	// streakFloor is the best max streak a key brought into a merge, kept even when the merged
+	// history no longer shows it.
+	streakFloor map[string]int
+	merges      map[string]mergeUndo
+	// dailies holds the daily puzzle dates each key has had a result recorded for, newest
+	// last and at most maxStatsDays of them, so a replayed daily is only counted once.
+	dailies map[string][]string
+	streaks StreakFreezeRules
+	archive *PlayerArchive
+	// maxHistory caps the finished games kept per key; the oldest are dropped first.
+	maxHistory int
+}
+
+// newPlayerStatsStore returns an empty store that computes streaks under rules.
+func newPlayerStatsStore(rules StreakFreezeRules) *PlayerStatsStore {
+	return &PlayerStatsStore{
+		storage:     DirStorage{},
+		players:     make(map[string][]GameRecord),
+		imports:     make(map[string]map[string]importedStats),
+		bonus:       make(map[string]int),
+		coins:       make(map[string]int),
+		carry:       make(map[string]statsCarry),
+		streakFloor: make(map[string]int),
+		merges:      make(map[string]mergeUndo),
+		dailies:     make(map[string][]string),
+		streaks:     rules,
+		maxHistory:  DefaultHistoryGames,
+	}
+}
+
+// playerStatsFile is the persisted form of a PlayerStatsStore.
+type playerStatsFile struct {
+	Players     map[string][]GameRecord            `json:"players"`
+	Imports     map[string]map[string]storedImport `json:"imports,omitempty"`
+	Bonus       map[string]int                     `json:"bonus,omitempty"`
+	Coins       map[string]int                     `json:"coins,omitempty"`
+	Carry       map[string]statsCarry              `json:"carry,omitempty"`
+	StreakFloor map[string]int                     `json:"streak_floor,omitempty"`
+	Dailies     map[string][]string                `json:"dailies,omitempty"`
+}
+
+// storedImport is an import as persisted, keeping the fingerprint the export leaves out so a
+// repeated import is still recognized after a restart.
+type storedImport struct {
+	importedStats
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// load reads the store from disk. A missing file is not an error.
+func (ps *PlayerStatsStore) load() error {
+	var file playerStatsFile
+	if found, err := readJSONFile(ps.storage, ps.path, &file); err != nil || !found {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for key, history := range file.Players {
+		ps.players[key] = ps.trimHistory(history)
+	}
+	for key, bySource := range file.Imports {
+		ps.imports[key] = make(map[string]importedStats, len(bySource))
+		for source, imp := range bySource {
+			imp.importedStats.Fingerprint = imp.Fingerprint
+			ps.imports[key][source] = imp.importedStats
+		}
+	}
+	maps.Copy(ps.bonus, file.Bonus)
+	maps.Copy(ps.coins, file.Coins)
+	maps.Copy(ps.carry, file.Carry)
+	maps.Copy(ps.streakFloor, file.StreakFloor)
+	for key, dates := range file.Dailies {
+		for _, date := range dates {
+			ps.claimDailyLocked(key, date)
+		}
+	}
+	ps.dirty = false
+	return nil
+}
+
+// save writes the store to disk atomically. Callers must hold ps.mu.
+func (ps *PlayerStatsStore) save() error {
+	file := playerStatsFile{
+		Players:     ps.players,
+		Imports:     make(map[string]map[string]storedImport, len(ps.imports)),
+		Bonus:       ps.bonus,
+		Coins:       ps.coins,
+		Carry:       ps.carry,
+		StreakFloor: ps.streakFloor,
+		Dailies:     ps.dailies,
+	}
+	for key, bySource := range ps.imports {
+		file.Imports[key] = make(map[string]storedImport, len(bySource))
+		for source, imp := range bySource {
+			file.Imports[key][source] = storedImport{importedStats: imp, Fingerprint: imp.Fingerprint}
+		}
+	}
+	return writeJSONFile(ps.storage, ps.path, file)
+}
+
+// flush saves the store if anything changed since the last save and this replica holds the
+// stats lease. Other replicas keep their changes pending in case they take over.
+func (ps *PlayerStatsStore) flush() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if !ps.dirty || (ps.leader != nil && !ps.leader()) {
+		return
+	}
+	if err := ps.save(); err != nil {
+		logWarn("Failed to save player stats: %v", err)
+		return
+	}
+	ps.dirty = false
+}
+
+// flushPlayerStats saves changed player stats every interval for the life of the process.
+func (app *App) flushPlayerStats(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		app.Players.flush()
+	}
+}
+
+// record appends a finished game to the session's history.
+func (ps *PlayerStatsStore) record(sessionID string, rec GameRecord) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	history := append(ps.players[sessionID], rec)
+	ps.players[sessionID] = ps.trimHistory(history)
+	ps.dirty = true
+}
+
+// trimHistory drops the oldest games of history beyond the history cap.
+func (ps *PlayerStatsStore) trimHistory(history []GameRecord) []GameRecord {
+	if len(history) > ps.maxHistory {
+		return history[len(history)-ps.maxHistory:]
+	}
+	return history
+}
+
+// adopt moves the history and imports recorded under one key to another, merging with anything
+// already there. It is used when a session becomes a remembered player. Archived games of the
+// old key are brought back into memory first, to be archived again under the new one.
+func (ps *PlayerStatsStore) adopt(from, to string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.dirty = true
+	if _, ok := ps.carry[from]; ok {
+		if archived, err := ps.archive.take(from); err != nil {
+			logWarn("Failed to rehydrate archived games for adoption: %v", err)
+		} else {
+			ps.players[from] = append(archived, ps.players[from]...)
+			delete(ps.carry, from)
+		}
+	}
+	if history, ok := ps.players[from]; ok {
+		merged := append(ps.players[to], history...)
+		slices.SortStableFunc(merged, func(a, b GameRecord) int { return a.FinishedAt.Compare(b.FinishedAt) })
+		ps.players[to] = ps.trimHistory(merged)
+		delete(ps.players, from)
+	}
+	if imports, ok := ps.imports[from]; ok {
+		if ps.imports[to] == nil {
+			ps.imports[to] = make(map[string]importedStats)
+		}
+		maps.Copy(ps.imports[to], imports)
+		delete(ps.imports, from)
+	}
+	if points, ok := ps.bonus[from]; ok {
+		ps.bonus[to] += points
+		delete(ps.bonus, from)
+	}
+	if coins, ok := ps.coins[from]; ok {
+		ps.coins[to] += coins
+		delete(ps.coins, from)
+	}
+	for _, date := range ps.dailies[from] {
+		ps.claimDailyLocked(to, date)
+	}
+	delete(ps.dailies, from)
+}
+
+// claimDaily marks the daily puzzle of date as recorded for key, reporting false when it
+// already was.
+func (ps *PlayerStatsStore) claimDaily(key, date string) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.claimDailyLocked(key, date)
+}
+
+// claimDailyLocked is claimDaily for callers holding ps.mu. Only the latest maxStatsDays dates
+// are kept, which covers every day the global stats show.
+func (ps *PlayerStatsStore) claimDailyLocked(key, date string) bool {
+	dates := ps.dailies[key]
+	i, found := slices.BinarySearch(dates, date)
+	if found {
+		return false
+	}
+	dates = slices.Insert(dates, i, date)
+	if len(dates) > maxStatsDays {
+		dates = dates[len(dates)-maxStatsDays:]
+	}
+	ps.dailies[key] = dates
+	ps.dirty = true
+	return true
+}
+
+// addBonus adds bonus round points to the session's score.
+func (ps *PlayerStatsStore) addBonus(sessionID string, points int) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.bonus[sessionID] += points
+	ps.dirty = true
+}
+
+// bonusPoints returns the session's bonus round score.
+func (ps *PlayerStatsStore) bonusPoints(sessionID string) int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.bonus[sessionID]
+}
+
+// addCoins credits coins to the session's balance.
+func (ps *PlayerStatsStore) addCoins(sessionID string, coins int) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.coins[sessionID] += coins
+	ps.dirty = true
+}
+
+// spendCoins debits cost from the session's balance, reporting false when it is too low.
+func (ps *PlayerStatsStore) spendCoins(sessionID string, cost int) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.coins[sessionID] < cost {
+		return false
+	}
+	ps.coins[sessionID] -= cost
+	ps.dirty = true
+	return true
+}
+
+// coinBalance returns the session's coin balance.
+func (ps *PlayerStatsStore) coinBalance(sessionID string) int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.coins[sessionID]
+}
+
+// history returns a copy of the session's finished games, oldest first.
+func (ps *PlayerStatsStore) history(sessionID string) []GameRecord {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return append([]GameRecord{}, ps.players[sessionID]...)
+}
+
+// importStats stores stats imported from source, replacing any earlier import from the same
+// source. It reports false when the same stats were already imported.
+func (ps *PlayerStatsStore) importStats(sessionID string, imp importedStats) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	bySource := ps.imports[sessionID]
+	if bySource == nil {
+		bySource = make(map[string]importedStats)
+		ps.imports[sessionID] = bySource
+	}
+	if prev, ok := bySource[imp.Source]; ok && prev.Fingerprint == imp.Fingerprint {
+		return false
+	}
+	bySource[imp.Source] = imp
+	ps.dirty = true
+	return true
+}
+
+// imported returns the session's imported stats sorted by source.
+func (ps *PlayerStatsStore) imported(sessionID string) []importedStats {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return slices.SortedFunc(maps.Values(ps.imports[sessionID]), func(a, b importedStats) int {
+		return cmp.Compare(a.Source, b.Source)
+	})
+}
+
+// summary returns the session's aggregates across local history and imported stats, with
+// streaks evaluated as of the puzzle date today.
+func (ps *PlayerStatsStore) summary(sessionID, today string) ([]GameRecord, []importedStats, playerSummary) {
+	ps.mu.RLock()
+	history := append([]GameRecord{}, ps.players[sessionID]...)
+	carry := ps.carry[sessionID]
+	floor := ps.streakFloor[sessionID]
+	ps.mu.RUnlock()
+	imports := ps.imported(sessionID)
+	summary := mergeImported(summarizeFrom(carry, history, ps.streaks, today), imports)
+	summary.MaxStreak = max(summary.MaxStreak, floor)
+	summary.BonusPoints = ps.bonusPoints(sessionID)
+	summary.Coins = ps.coinBalance(sessionID)
+	return history, imports, summary
+}
+
+// skillWindow is how many recent games the skill estimate used for word selection looks at.
+const skillWindow = 20
+
+// playerSkill is a rolling estimate of a player's skill over their recent games.
+type playerSkill struct {
+	Games      int
+	SolveRate  float64
+	AvgGuesses float64
+}
+
+// estimateSkill summarizes the last skillWindow games of history, ordered oldest first.
+// AvgGuesses only counts wins.
+func estimateSkill(history []GameRecord) playerSkill {
+	recent := history[max(len(history)-skillWindow, 0):]
+	skill := playerSkill{Games: len(recent)}
+	wins, guesses := 0, 0
+	for _, rec := range recent {
+		if rec.Won {
+			wins++
+			guesses += rec.Guesses
+		}
+	}
+	if skill.Games > 0 {
+		skill.SolveRate = float64(wins) / float64(skill.Games)
+	}
+	if wins > 0 {
+		skill.AvgGuesses = float64(guesses) / float64(wins)
+	}
+	return skill
+}
+
+// level maps the skill to 0 (struggling) through 1 (solves quickly every time), weighting
+// solve rate and guess speed equally.
+func (s playerSkill) level() float64 {
+	speed := 0.0
+	if s.AvgGuesses > 0 {
+		speed = (MaxGuesses - s.AvgGuesses) / (MaxGuesses - 1)
+	}
+	return min(max((s.SolveRate+speed)/2, 0), 1)
+}
+
+// playerSkill estimates the skill of the player behind the request from their casual games.
+func (app *App) playerSkill(c *gin.Context, sessionID string) playerSkill {
+	if app.Players == nil {
+		return playerSkill{}
+	}
+	return estimateSkill(app.Players.history(app.playerKey(c, sessionID)))
+}
+
+// claimDailyResult reports whether a finished game's result should be recorded: always for
+// games other than the daily puzzle, and for a daily only the first time the player finishes
+// that date, so replaying it cannot inflate the global stats or the player's history.
+func (app *App) claimDailyResult(c *gin.Context, sessionID string, game *GameState) bool {
+	if game.Daily == "" || app.Players == nil {
+		return true
+	}
+	return app.Players.claimDaily(app.playerKey(c, sessionID), game.Daily)
+}
+
+// recordPlayerGame adds a finished game to the player's personal history, or to the separate
+// bucket for purist and kids games.
+func (app *App) recordPlayerGame(c *gin.Context, sessionID string, game *GameState) {
+	if app.Players == nil {
+		return
+	}
+	app.Players.record(statsBucket(app.playerKey(c, sessionID), gameMode(game)), GameRecord{
+		FinishedAt: app.now().UTC(),
+		Word:       game.SessionWord,
+		Pack:       game.Pack,
+		Won:        game.Won,
+		Guesses:    len(game.GuessHistory),
+		HintsUsed:  game.HintsUsed,
+		PuzzleDate: app.playerPuzzleDate(c),
+		Mode:       gameMode(game),
+	})
+}
+
+// exportStatsHandler returns the requesting session's game history and aggregates as JSON, or
+// as CSV with format=csv. CSV exports the history by default and the aggregates with
+// table=summary, so each download is a single table. mode=purist or mode=kids exports the
+// stats of that mode.
+func (app *App) exportStatsHandler(c *gin.Context) {
+	sessionID, _ := c.Cookie(app.cookieName(SessionCookieName))
+	if app.Players == nil || sessionID == "" {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNoActiveSession, "no active session")
+		return
+	}
+	key := statsBucket(app.playerKey(c, sessionID), c.Query("mode"))
+	history, imports, summary := app.Players.summary(key, app.playerPuzzleDate(c))
+	history, err := app.rehydrateHistory(key, history)
+	if err != nil {
+		logWarn("Failed to rehydrate archived games: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "archived games are unavailable, please try again later")
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+
+	switch c.DefaultQuery("format", ExportFormatJSON) {
+	case ExportFormatJSON:
+		c.Header("Content-Disposition", `attachment; filename="vortludo-stats.json"`)
+		c.JSON(http.StatusOK, gin.H{
+			"summary":  summary,
+			"history":  history,
+			"imported": imports,
+		})
+	case ExportFormatCSV:
+		c.Header("Content-Disposition", `attachment; filename="vortludo-stats.csv"`)
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		w := csv.NewWriter(c.Writer)
+		if c.Query("table") == "summary" {
+			writeSummaryCSV(w, summary)
+		} else {
+			writeHistoryCSV(w, history)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			logWarn("Failed to write stats export: %v", err)
+		}
+	default:
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "format must be json or csv")
+	}
+}
+
+// writeHistoryCSV writes one row per finished game.
+func writeHistoryCSV(w *csv.Writer, history []GameRecord) {
+	_ = w.Write([]string{"finished_at", "word", "pack", "won", "guesses", "hints_used", "puzzle_date"})
+	for _, rec := range history {
+		_ = w.Write([]string{
+			rec.FinishedAt.Format(time.RFC3339),
+			rec.Word,
+			rec.Pack,
+			strconv.FormatBool(rec.Won),
+			strconv.Itoa(rec.Guesses),
+			strconv.Itoa(rec.HintsUsed),
+			rec.PuzzleDate,
+		})
+	}
+}
+
+// writeSummaryCSV writes the aggregates as metric,value rows.
+func writeSummaryCSV(w *csv.Writer, s playerSummary) {
+	_ = w.Write([]string{"metric", "value"})
+	_ = w.Write([]string{"played", strconv.Itoa(s.Played)})
+	_ = w.Write([]string{"won", strconv.Itoa(s.Won)})
+	_ = w.Write([]string{"win_rate", strconv.FormatFloat(s.WinRate, 'f', 4, 64)})
+	_ = w.Write([]string{"current_streak", strconv.Itoa(s.CurrentStreak)})
+	_ = w.Write([]string{"max_streak", strconv.Itoa(s.MaxStreak)})
+	_ = w.Write([]string{"freezes_remaining", strconv.Itoa(s.FreezesRemaining)})
+	_ = w.Write([]string{"freezes_used", strconv.Itoa(s.FreezesUsed)})
+	for i, n := range s.Distribution {
+		_ = w.Write([]string{"won_in_" + strconv.Itoa(i+1), strconv.Itoa(n)})
+	}
+	_ = w.Write([]string{"bonus_points", strconv.Itoa(s.BonusPoints)})
+	_ = w.Write([]string{"coins", strconv.Itoa(s.Coins)})
+}
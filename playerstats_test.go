@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestSummarize(t *testing.T) {
+	s := summarize([]GameRecord{
+		{Won: true, Guesses: 3},
+		{Won: true, Guesses: 4},
+		{Won: false, Guesses: MaxGuesses},
+		{Won: true, Guesses: 3},
+	}, StreakFreezeRules{}, "")
+	if s.Played != 4 || s.Won != 3 || s.WinRate != 0.75 {
+		t.Errorf("Unexpected totals: %+v", s)
+	}
+	if s.CurrentStreak != 1 || s.MaxStreak != 2 {
+		t.Errorf("Expected streaks 1/2, got %d/%d", s.CurrentStreak, s.MaxStreak)
+	}
+	if s.Distribution[2] != 2 || s.Distribution[3] != 1 {
+		t.Errorf("Unexpected distribution: %v", s.Distribution)
+	}
+}
+
+func TestSummarizeStreakFreezes(t *testing.T) {
+	win := func(date string) GameRecord { return GameRecord{Won: true, Guesses: 3, PuzzleDate: date} }
+	rules := StreakFreezeRules{WinsPerFreeze: 2, MaxFreezes: 1}
+
+	// Two wins earn a freeze, which covers the missed 3rd. Two more wins earn another, but one
+	// freeze cannot cover missing both the 6th and 7th.
+	history := []GameRecord{win("2026-03-01"), win("2026-03-02"), win("2026-03-04"), win("2026-03-05")}
+	s := summarize(history, rules, "2026-03-05")
+	if s.CurrentStreak != 4 || s.FreezesUsed != 1 || s.FreezesRemaining != 1 {
+		t.Errorf("after one missed day: %+v", s)
+	}
+	if s := summarize(history, rules, "2026-03-06"); s.CurrentStreak != 4 {
+		t.Errorf("today not yet played should not break the streak, got %+v", s)
+	}
+	if s := summarize(history, rules, "2026-03-08"); s.CurrentStreak != 0 || s.MaxStreak != 4 {
+		t.Errorf("two missed days with one freeze should end the streak, got %+v", s)
+	}
+
+	if s := summarize(history, StreakFreezeRules{}, "2026-03-05"); s.CurrentStreak != 2 || s.MaxStreak != 2 || s.FreezesRemaining != 0 {
+		t.Errorf("without freezes a missed day ends the streak, got %+v", s)
+	}
+}
+
+func TestExportStatsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{Players: newPlayerStatsStore(StreakFreezeRules{})}
+	app.recordPlayerGame(&gin.Context{}, "mine", &GameState{GameState: engine.GameState{SessionWord: "CRANE", Won: true, GuessHistory: []string{"SLATE", "CRANE"}}, Pack: DefaultPackName})
+	app.recordPlayerGame(&gin.Context{}, "other", &GameState{GameState: engine.GameState{SessionWord: "TIGER"}, Pack: "animals"})
+	router := gin.New()
+	router.GET(RouteStatsExport, app.exportStatsHandler)
+
+	get := func(query, sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", RouteStatsExport+query, nil)
+		if sessionID != "" {
+			req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: sessionID})
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get("", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a session, got %d", w.Code)
+	}
+
+	var body struct {
+		Summary playerSummary `json:"summary"`
+		History []GameRecord  `json:"history"`
+	}
+	w := get("", "mine")
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.History) != 1 || body.History[0].Word != "CRANE" || body.Summary.Distribution[1] != 1 {
+		t.Errorf("Unexpected JSON export: %+v", body)
+	}
+
+	w = get("?format=csv", "mine")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if w.Header().Get("Content-Type") != "text/csv; charset=utf-8" || len(lines) != 2 || !strings.Contains(lines[1], "CRANE,classic,true,2,0") {
+		t.Errorf("Unexpected CSV export: %q", w.Body.String())
+	}
+	if w := get("?format=csv&table=summary", "mine"); !strings.Contains(w.Body.String(), "won_in_2,1") {
+		t.Errorf("Unexpected summary CSV: %q", w.Body.String())
+	}
+	if w := get("?format=xml", "mine"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown format, got %d", w.Code)
+	}
+}
+
+func TestPlayerStatsStorePersistence(t *testing.T) {
+	st := newMemStorage()
+	ps := newPlayerStatsStore(StreakFreezeRules{})
+	ps.storage, ps.path = st, "player-stats.json"
+	ps.record("player-1", GameRecord{Word: "CRANE", Won: true, Guesses: 3, PuzzleDate: "2030-01-01"})
+	ps.addCoins("player-1", 25)
+	ps.addBonus("player-1", 7)
+	ps.importStats("player-1", importedStats{Source: "nyt", Played: 10, Won: 8, Fingerprint: "abc"})
+	ps.claimDaily("player-1", "2030-01-01")
+	ps.flush()
+
+	reloaded := newPlayerStatsStore(StreakFreezeRules{})
+	reloaded.storage, reloaded.path = st, "player-stats.json"
+	if err := reloaded.load(); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	history, imports, summary := reloaded.summary("player-1", "")
+	if len(history) != 1 || history[0].Word != "CRANE" || summary.Coins != 25 || summary.BonusPoints != 7 {
+		t.Errorf("reloaded history %+v and summary %+v, want the saved game, coins, and bonus", history, summary)
+	}
+	if len(imports) != 1 || imports[0].Played != 10 {
+		t.Errorf("reloaded imports = %+v, want the nyt import", imports)
+	}
+	if reloaded.importStats("player-1", importedStats{Source: "nyt", Fingerprint: "abc"}) {
+		t.Error("repeated import accepted after reload")
+	}
+	if reloaded.claimDaily("player-1", "2030-01-01") {
+		t.Error("daily claimed again after reload")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Preferences constants
+const (
+	// PreferencesCookieName holds the preferences of a player without remember-me.
+	PreferencesCookieName = "prefs"
+	// PreferencesCookieMaxAge is how long an anonymous player's preferences cookie lasts.
+	PreferencesCookieMaxAge = 365 * 24 * time.Hour
+)
+
+// Preferences are a player's display and play settings. Empty strings mean the client default.
+type Preferences struct {
+	// Theme is "light" or "dark".
+	Theme string `json:"theme"`
+	// Language is a BCP 47 language tag such as "en" or "pt-BR".
+	Language string `json:"language"`
+	// KeyboardLayout is the on-screen keyboard: qwerty, azerty, qwertz, dvorak, or colemak.
+	KeyboardLayout string `json:"keyboard_layout"`
+	// Mode is the game mode new games start in: "" for normal, ModePurist, or ModeKids.
+	Mode       string `json:"mode"`
+	HardMode   bool   `json:"hard_mode"`
+	Colorblind bool   `json:"colorblind"`
+}
+
+// PreferenceStore keeps the preferences of remembered players, so they follow the player to
+// every device signed in as them.
+type PreferenceStore struct {
+	mu      sync.Mutex
+	storage Storage
+	path    string
+	prefs   map[string]Preferences
+}
+
+// newPreferenceStore returns an empty store persisted to path. An empty path keeps it in memory.
+func newPreferenceStore(path string) *PreferenceStore {
+	return &PreferenceStore{storage: DirStorage{}, path: path, prefs: make(map[string]Preferences)}
+}
+
+// load reads persisted preferences from disk. A missing file is not an error.
+func (ps *PreferenceStore) load() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	_, err := readJSONFile(ps.storage, ps.path, &ps.prefs)
+	if ps.prefs == nil {
+		ps.prefs = make(map[string]Preferences)
+	}
+	return err
+}
+
+// get returns the preferences saved for key.
+func (ps *PreferenceStore) get(key string) (Preferences, bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	prefs, ok := ps.prefs[key]
+	return prefs, ok
+}
+
+// set saves the preferences for key.
+func (ps *PreferenceStore) set(key string, prefs Preferences) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.prefs[key] = prefs
+	return writeJSONFile(ps.storage, ps.path, ps.prefs)
+}
+
+// preferencesKey returns the store key of the remembered player making the request, or false
+// when remember-me is off or the player cookie is missing.
+func (app *App) preferencesKey(c *gin.Context) (string, bool) {
+	if app.Preferences == nil || app.PlayerIDs == nil || app.PlayerCookieMaxAge <= 0 {
+		return "", false
+	}
+	value, _ := c.Cookie(app.cookieName(PlayerCookieName))
+	id, ok := app.PlayerIDs.verify(value)
+	if !ok {
+		return "", false
+	}
+	return playerStatsKey(id), true
+}
+
+// cookiePreferences returns the preferences in the preferences cookie, or the defaults when it
+// is missing or unreadable.
+func (app *App) cookiePreferences(c *gin.Context) Preferences {
+	var prefs Preferences
+	value, err := c.Cookie(app.cookieName(PreferencesCookieName))
+	if err != nil {
+		return prefs
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &prefs) != nil {
+		return Preferences{}
+	}
+	return prefs
+}
+
+// setPreferencesCookie stores prefs in the preferences cookie.
+func (app *App) setPreferencesCookie(c *gin.Context, prefs Preferences) {
+	data, _ := json.Marshal(prefs)
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(app.cookieName(PreferencesCookieName), base64.RawURLEncoding.EncodeToString(data), int(PreferencesCookieMaxAge.Seconds()), "/", "", app.IsProduction, true)
+}
+
+// preferencesHandler returns the player's preferences. Synced is true when they come from the
+// remembered player and so are shared across devices; otherwise they come from this browser's
+// cookie.
+func (app *App) preferencesHandler(c *gin.Context) {
+	prefs, synced := app.cookiePreferences(c), false
+	if key, ok := app.preferencesKey(c); ok {
+		if saved, ok := app.Preferences.get(key); ok {
+			prefs, synced = saved, true
+		}
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs, "synced": synced})
+}
+
+// putPreferencesHandler replaces the player's preferences. They are saved for the remembered
+// player when there is one, and always in the cookie, so this browser keeps them if
+// remember-me is turned off later.
+func (app *App) putPreferencesHandler(c *gin.Context) {
+	var prefs Preferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, errAPIBody.Error())
+		return
+	}
+	synced := false
+	if key, ok := app.preferencesKey(c); ok {
+		if err := app.Preferences.set(key, prefs); err != nil {
+			logWarn("Failed to save preferences: %v", err)
+			writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not save preferences")
+			return
+		}
+		synced = true
+	}
+	app.setPreferencesCookie(c, prefs)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs, "synced": synced})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPreferencesAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Preferences = newPreferenceStore("")
+	app.PlayerIDs = newPlayerIDs(newKeyring(make([]byte, 32)))
+	app.PlayerCookieMaxAge = 365 * 24 * time.Hour
+	router := gin.New()
+	router.Use(app.apiValidationMiddleware())
+	router.GET(RoutePreferencesAPI, app.preferencesHandler)
+	router.PUT(RoutePreferencesAPI, app.putPreferencesHandler)
+
+	type response struct {
+		Preferences Preferences `json:"preferences"`
+		Synced      bool        `json:"synced"`
+	}
+	do := func(method, body string, cookies ...*http.Cookie) (*httptest.ResponseRecorder, response) {
+		req := httptest.NewRequest(method, RoutePreferencesAPI, strings.NewReader(body))
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	if w, resp := do("GET", ""); w.Code != http.StatusOK || resp.Synced || resp.Preferences != (Preferences{}) {
+		t.Fatalf("Expected default preferences, got %d %+v", w.Code, resp)
+	}
+
+	w, resp := do("PUT", `{"theme":"dark","keyboard_layout":"azerty","colorblind":true}`)
+	if w.Code != http.StatusOK || resp.Synced {
+		t.Fatalf("anonymous PUT = %d %+v", w.Code, resp)
+	}
+	var prefsCookie *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == PreferencesCookieName {
+			prefsCookie = cookie
+		}
+	}
+	if prefsCookie == nil {
+		t.Fatal("Expected an anonymous PUT to set the preferences cookie")
+	}
+	if _, resp := do("GET", "", prefsCookie); resp.Synced || resp.Preferences.Theme != "dark" || !resp.Preferences.Colorblind {
+		t.Errorf("Expected preferences from the cookie, got %+v", resp)
+	}
+
+	player := &http.Cookie{Name: PlayerCookieName, Value: app.PlayerIDs.issue("player-one")}
+	if w, resp := do("PUT", `{"theme":"light","language":"pt-BR","mode":"purist","hard_mode":true}`, player, prefsCookie); w.Code != http.StatusOK || !resp.Synced {
+		t.Fatalf("remembered PUT = %d %+v", w.Code, resp)
+	}
+	want := Preferences{Theme: "light", Language: "pt-BR", Mode: ModePurist, HardMode: true}
+	if _, resp := do("GET", "", player); !resp.Synced || resp.Preferences != want {
+		t.Errorf("Expected synced preferences on another device, got %+v", resp)
+	}
+
+	for _, body := range []string{`{"theme":"neon"}`, `{"hard_mode":"yes"}`, `{"font":"serif"}`, `{"language":"en_US"}`} {
+		if w, _ := do("PUT", body, player); w.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s = %d, want 400", body, w.Code)
+		}
+	}
+}
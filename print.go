@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+// Print sheet constants
+const (
+	// PrintTemplatesDir is the directory, under the template root, holding the print sheet's
+	// own template set.
+	PrintTemplatesDir = "print"
+	DefaultPrintGrids = 4
+	MaxPrintGrids     = 12
+)
+
+// printGrid is one blank board on a print sheet.
+type printGrid struct {
+	Number int
+	Rows   []int
+	Cols   []int
+}
+
+// loadPrintTemplates parses the print sheet templates in dir. They form a set of their own so
+// printable pages never pick up the game's partials or scripts.
+func loadPrintTemplates(dir string) (*template.Template, error) {
+	return template.New("").ParseGlob(filepath.ToSlash(filepath.Join(dir, "*.html")))
+}
+
+// printGrids returns n blank boards of MaxGuesses rows of WordLength squares.
+func printGrids(n int) []printGrid {
+	rows, cols := make([]int, MaxGuesses), make([]int, WordLength)
+	grids := make([]printGrid, n)
+	for i := range grids {
+		grids[i] = printGrid{Number: i + 1, Rows: rows, Cols: cols}
+	}
+	return grids
+}
+
+// printSheetHandler renders a printable page of blank boards for offline play, with the day's
+// hint and a QR code linking to that day's puzzle. The grids query parameter sets the number of boards.
+func (app *App) printSheetHandler(c *gin.Context) {
+	if app.PrintTemplates == nil {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "print sheets are unavailable")
+		return
+	}
+	grids := DefaultPrintGrids
+	if raw := c.Query("grids"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > MaxPrintGrids {
+			writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "grids must be between 1 and "+strconv.Itoa(MaxPrintGrids))
+			return
+		}
+		grids = n
+	}
+
+	date := app.Daily.puzzleDate(app.now(), app.Daily.location(c))
+	entry, _ := app.dailyWord(date)
+	siteURL := app.absoluteURL(c, dailyLink(date.Format(time.DateOnly)))
+	var qr template.HTML
+	if q, err := qrcode.New(siteURL, qrcode.Medium); err != nil {
+		logWarn("Failed to encode print sheet QR code: %v", err)
+	} else {
+		qr = template.HTML(qrSVG(q.Bitmap()))
+	}
+
+	var buf bytes.Buffer
+	err := app.PrintTemplates.ExecuteTemplate(&buf, "sheet.html", gin.H{
+		"date":    date.Format(time.DateOnly),
+		"hint":    entry.Hint,
+		"grids":   printGrids(grids),
+		"siteURL": siteURL,
+		"qr":      qr,
+	})
+	if err != nil {
+		logWarn("Failed to render print sheet: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not render print sheet")
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
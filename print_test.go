@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPrintSheet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tpl, err := loadPrintTemplates("templates/print")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &App{GameSessions: make(map[string]*GameState), Daily: newDailySchedule("UTC", 0, false), PrintTemplates: tpl}
+	app.registerWordPacks([]*WordPack{newWordPack(DefaultPackName, []WordEntry{{Word: "CRANE", Hint: "a tall bird"}})}, nil, nil)
+	router := gin.New()
+	router.GET(RoutePrint, app.printSheetHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", RoutePrint+"?grids=3", nil))
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "a tall bird") || !strings.Contains(body, "<svg") {
+		t.Fatalf("print sheet = %d: %s", w.Code, body)
+	}
+	if today := app.Daily.puzzleDate(time.Now(), time.UTC).Format(time.DateOnly); !strings.Contains(body, "://example.com"+dailyLink(today)) {
+		t.Errorf("Expected the print sheet to link to the puzzle of %s: %s", today, body)
+	}
+	if strings.Contains(body, "CRANE") {
+		t.Error("Expected the print sheet to leave out the word")
+	}
+	if got := strings.Count(body, "<table"); got != 3 {
+		t.Errorf("print sheet has %d boards, want 3", got)
+	}
+	if got := strings.Count(body, "<td></td>"); got != 3*MaxGuesses*WordLength {
+		t.Errorf("print sheet has %d squares, want %d", got, 3*MaxGuesses*WordLength)
+	}
+
+	for _, grids := range []string{"0", "99", "many"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", RoutePrint+"?grids="+grids, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("grids=%s = %d, want 400", grids, w.Code)
+		}
+	}
+}
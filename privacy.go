@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timezoneCookieMaxAge matches the max-age client.js gives the tz cookie.
+const timezoneCookieMaxAge = 365 * 24 * time.Hour
+
+// Retention of data kept without a configured lifetime
+const (
+	RetentionUntilRestart = "until the server restarts"
+	RetentionUntilDeleted = "until deleted"
+	RetentionUntilCleared = "until you clear this site's data"
+)
+
+// DataInventory lists the personal data this instance handles: the cookies it sets, what the
+// client keeps in browser storage, what the server stores, and what is sent to third parties.
+// It is built from the running configuration, so features that are turned off are left out and
+// retention periods are the configured ones, keeping the privacy page in step with the code.
+type DataInventory struct {
+	Cookies      []DataItem `json:"cookies"`
+	Browser      []DataItem `json:"browser_storage"`
+	Server       []DataItem `json:"server"`
+	ThirdParties []DataItem `json:"third_parties"`
+}
+
+// DataItem is one cookie, storage key, record, or recipient in the inventory.
+type DataItem struct {
+	Name    string   `json:"name"`
+	Purpose string   `json:"purpose"`
+	Fields  []string `json:"fields,omitempty"`
+	dataRetention
+}
+
+// dataRetention is how long a DataItem is kept. Seconds is set when the retention is a
+// configured duration rather than an event such as unsubscribing.
+type dataRetention struct {
+	Retention        string `json:"retention"`
+	RetentionSeconds int64  `json:"retention_seconds,omitempty"`
+}
+
+// retention returns a retention of d, or of otherwise when d is not positive.
+func retention(d time.Duration, otherwise string) dataRetention {
+	if d <= 0 {
+		return dataRetention{Retention: otherwise}
+	}
+	return dataRetention{Retention: retentionText(d), RetentionSeconds: int64(d.Seconds())}
+}
+
+// retentionText describes d in the largest whole unit of days, hours, minutes, or seconds.
+func retentionText(d time.Duration) string {
+	unit, name := time.Minute, "minute"
+	switch {
+	case d%(24*time.Hour) == 0:
+		unit, name = 24*time.Hour, "day"
+	case d%time.Hour == 0:
+		unit, name = time.Hour, "hour"
+	case d < time.Minute:
+		unit, name = time.Second, "second"
+	}
+	n := int64(d / unit)
+	if n != 1 {
+		name += "s"
+	}
+	return fmt.Sprintf("%d %s", n, name)
+}
+
+// jsonFields returns the JSON field names of the struct v, so stored records are described by
+// the same names they are saved under.
+func jsonFields(v any) []string {
+	t := reflect.TypeOf(v)
+	fields := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// dataInventory returns the inventory of this instance's configuration.
+func (app *App) dataInventory() DataInventory {
+	inv := DataInventory{
+		Cookies: []DataItem{
+			{
+				Name:          app.cookieName(SessionCookieName),
+				Purpose:       "Links your browser to your game in progress.",
+				Fields:        []string{"random session ID"},
+				dataRetention: retention(app.CookieMaxAge, "until you close the browser"),
+			},
+			{
+				Name:          app.cookieName(CSRFCookieName),
+				Purpose:       "Protects your game from requests made by other sites.",
+				Fields:        []string{"random token"},
+				dataRetention: retention(app.CookieMaxAge, "until you close the browser"),
+			},
+			{
+				Name:          app.cookieName(PreferencesCookieName),
+				Purpose:       "Keeps your display and play settings.",
+				Fields:        jsonFields(Preferences{}),
+				dataRetention: retention(PreferencesCookieMaxAge, ""),
+			},
+			{
+				Name:          TimezoneCookieName,
+				Purpose:       "Tells the server your timezone for the daily puzzle countdown.",
+				Fields:        []string{"timezone name"},
+				dataRetention: retention(timezoneCookieMaxAge, ""),
+			},
+			{
+				Name:          app.cookieName(classroomTeacherCookie),
+				Purpose:       "Lets a teacher manage a classroom they created. Only set for classroom pages.",
+				Fields:        []string{"random teacher key"},
+				dataRetention: retention(ClassroomTTL, ""),
+			},
+		},
+		Browser: []DataItem{
+			{
+				Name:          "theme",
+				Purpose:       "Your light or dark theme.",
+				dataRetention: dataRetention{Retention: RetentionUntilCleared},
+			},
+			{
+				Name:          "vortludo-purist, vortludo-kids",
+				Purpose:       "Whether purist or kids mode is on.",
+				dataRetention: dataRetention{Retention: RetentionUntilCleared},
+			},
+			{
+				Name:          "vortludo-progress:<pack>",
+				Purpose:       "A signed record of which words of each pack you have completed.",
+				dataRetention: dataRetention{Retention: RetentionUntilCleared},
+			},
+		},
+		Server: []DataItem{
+			{
+				Name:          "Games in progress",
+				Purpose:       "Your current word, guesses, and game settings.",
+				Fields:        []string{"session ID", "word", "guesses", "mode", "pack"},
+				dataRetention: retention(app.Retention.Sessions, RetentionUntilRestart),
+			},
+			{
+				Name:          "Game history",
+				Purpose:       fmt.Sprintf("Your latest %d finished games, for your stats, streaks, and export.", app.Retention.HistoryGames),
+				Fields:        append([]string{"session or player ID"}, jsonFields(GameRecord{})...),
+				dataRetention: retention(app.Retention.HistoryAge, RetentionUntilRestart),
+			},
+			{
+				Name:          "Daily totals",
+				Purpose:       "Anonymous counts of games played and won each day. Skipped when your browser sends Do Not Track.",
+				dataRetention: dataRetention{Retention: RetentionUntilDeleted},
+			},
+			{
+				Name:          "Rate limits",
+				Purpose:       "Limits how fast one client can send requests.",
+				Fields:        []string{"IP address"},
+				dataRetention: dataRetention{Retention: RetentionUntilRestart},
+			},
+			{
+				Name:          "Spent links and codes",
+				Purpose:       "Stops single-use transfer codes and links from being used twice.",
+				Fields:        []string{"one-way hash of the code"},
+				dataRetention: dataRetention{Retention: "until the code expires"},
+			},
+		},
+	}
+	if app.Journal != nil {
+		inv.Server[0].Purpose += " Also written to disk so a restart does not lose it."
+	}
+	if app.Players != nil && app.Players.path != "" {
+		inv.Server[1].Purpose += " Also written to disk so a restart does not lose it."
+		inv.Server[1].dataRetention = retention(app.Retention.HistoryAge, RetentionUntilDeleted)
+	}
+	if app.Players != nil && app.Players.archive != nil && (app.Retention.HistoryAge <= 0 || app.Retention.HistoryAge > app.Players.archive.After) {
+		archived := RetentionUntilDeleted
+		if app.Retention.HistoryAge > 0 {
+			archived = "until " + retentionText(app.Retention.HistoryAge) + " old"
+		}
+		inv.Server[1].Retention = fmt.Sprintf("in memory for %s, then archived to disk %s", retentionText(app.Players.archive.After), archived)
+	}
+	if app.Retention.DailyStats > 0 {
+		inv.Server[2].Retention = fmt.Sprintf("per day for %s, then as monthly totals %s", retentionText(app.Retention.DailyStats), RetentionUntilDeleted)
+	}
+	if app.PlayerIDs != nil && app.PlayerCookieMaxAge > 0 {
+		inv.Cookies = append(inv.Cookies, DataItem{
+			Name:          app.cookieName(PlayerCookieName),
+			Purpose:       "Remembers you as the same player, so your stats, streaks, and preferences last beyond one session.",
+			Fields:        []string{"signed random player ID"},
+			dataRetention: retention(app.PlayerCookieMaxAge, ""),
+		})
+		inv.Server = append(inv.Server, DataItem{
+			Name:          "Preferences",
+			Purpose:       "Your settings, shared by every device remembered as you.",
+			Fields:        append([]string{"player ID"}, jsonFields(Preferences{})...),
+			dataRetention: dataRetention{Retention: RetentionUntilDeleted},
+		})
+	}
+	if app.Reminders != nil {
+		inv.Server = append(inv.Server, DataItem{
+			Name:          "Email reminders",
+			Purpose:       "Sends the daily hint to addresses that asked for it.",
+			Fields:        jsonFields(Subscriber{}),
+			dataRetention: dataRetention{Retention: "until you unsubscribe"},
+		})
+	}
+	if app.Push != nil {
+		inv.Server = append(inv.Server, DataItem{
+			Name:          "Push notifications",
+			Purpose:       "Notifies browsers that subscribed when a new puzzle is out.",
+			Fields:        jsonFields(PushSubscription{}),
+			dataRetention: dataRetention{Retention: "until you turn notifications off"},
+		})
+	}
+	if app.Captcha != nil {
+		inv.Server = append(inv.Server, DataItem{
+			Name:          "CAPTCHA passes",
+			Purpose:       "Remembers clients that solved a CAPTCHA so they are not asked again.",
+			Fields:        []string{"IP address"},
+			dataRetention: retention(app.Captcha.PassDuration, RetentionUntilRestart),
+		})
+		inv.ThirdParties = append(inv.ThirdParties, DataItem{
+			Name:          hostOf(app.Captcha.config().VerifyURL),
+			Purpose:       "Checks clients sending unusual traffic. Only involved when a CAPTCHA is shown.",
+			Fields:        []string{"IP address", "CAPTCHA response"},
+			dataRetention: dataRetention{Retention: "per the provider's policy"},
+		})
+	}
+	if app.Audit != nil {
+		inv.Server = append(inv.Server, DataItem{
+			Name:          "Admin audit log",
+			Purpose:       "Records changes made by administrators. Players are not logged.",
+			Fields:        jsonFields(AuditEntry{}),
+			dataRetention: retention(app.Retention.Audit, RetentionUntilDeleted),
+		})
+	}
+	if currentLogLevel <= LogLevelInfo {
+		inv.Server = append(inv.Server, DataItem{
+			Name:          "Request log",
+			Purpose:       "Written to the server log for troubleshooting.",
+			Fields:        []string{"IP address", "method", "path", "status", "time"},
+			dataRetention: dataRetention{Retention: "as long as the operator keeps server logs"},
+		})
+	}
+	if app.Analytics != nil && app.Analytics.Mode == AnalyticsModePlausible {
+		inv.ThirdParties = append(inv.ThirdParties, DataItem{
+			Name:          hostOf(app.Analytics.Endpoint),
+			Purpose:       "Anonymous gameplay analytics, with no identifiers or IP addresses. Skipped when your browser sends Do Not Track.",
+			Fields:        []string{"event name", "page URL"},
+			dataRetention: dataRetention{Retention: "per the provider's policy"},
+		})
+	}
+	return inv
+}
+
+// hostOf returns the host of rawURL, or rawURL itself when it does not parse.
+func hostOf(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
+
+// dataInventoryHandler serves the data inventory as JSON.
+func (app *App) dataInventoryHandler(c *gin.Context) {
+	c.Header("Cache-Control", "no-cache")
+	c.JSON(http.StatusOK, app.dataInventory())
+}
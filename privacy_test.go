@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRetentionText(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{24 * time.Hour, "1 day"},
+		{30 * 24 * time.Hour, "30 days"},
+		{12 * time.Hour, "12 hours"},
+		{90 * time.Minute, "90 minutes"},
+		{30 * time.Second, "30 seconds"},
+	}
+	for _, tt := range tests {
+		if got := retentionText(tt.d); got != tt.want {
+			t.Errorf("retentionText(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+// inventoryItem returns the item named name in items.
+func inventoryItem(items []DataItem, name string) (DataItem, bool) {
+	i := slices.IndexFunc(items, func(item DataItem) bool { return item.Name == name })
+	if i < 0 {
+		return DataItem{}, false
+	}
+	return items[i], true
+}
+
+func TestDataInventoryFollowsConfig(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.CookieMaxAge = 2 * time.Hour
+	app.Retention.Sessions = 2 * time.Hour
+
+	inv := app.dataInventory()
+	session, ok := inventoryItem(inv.Cookies, SessionCookieName)
+	if !ok || session.Retention != "2 hours" || session.RetentionSeconds != 7200 {
+		t.Errorf("session cookie = %+v", session)
+	}
+	if _, ok := inventoryItem(inv.Cookies, PlayerCookieName); ok {
+		t.Error("Expected no player cookie without remember-me")
+	}
+	if _, ok := inventoryItem(inv.Server, "Email reminders"); ok {
+		t.Error("Expected no reminders while they are disabled")
+	}
+
+	app.Retention.HistoryAge = 90 * 24 * time.Hour
+	if history, _ := inventoryItem(app.dataInventory().Server, "Game history"); history.Retention != "90 days" {
+		t.Errorf("game history retention = %q, want the configured policy", history.Retention)
+	}
+
+	app.Namespace = "beta"
+	app.PlayerIDs = newPlayerIDs(newKeyring(make([]byte, 32)))
+	app.PlayerCookieMaxAge = 30 * 24 * time.Hour
+	inv = app.dataInventory()
+	player, ok := inventoryItem(inv.Cookies, "beta_"+PlayerCookieName)
+	if !ok || player.Retention != "30 days" {
+		t.Errorf("player cookie = %+v, %v", player, ok)
+	}
+	prefs, ok := inventoryItem(inv.Server, "Preferences")
+	if !ok || !slices.Contains(prefs.Fields, "keyboard_layout") {
+		t.Errorf("preferences = %+v, %v", prefs, ok)
+	}
+}
+
+func TestDataInventoryOnPrivacyPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "privacy.md"), []byte("# Privacy\n\nPolicy."), 0o600)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Content = newContentPages(dir)
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.GET("/privacy", app.contentPageHandler("privacy"))
+	router.GET(RouteDataInventory, app.dataInventoryHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", RouteDataInventory, nil))
+	var inv DataInventory
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &inv) != nil {
+		t.Fatalf("inventory = %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"retention":`) {
+		t.Error("Expected retention to be flattened into each item")
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/privacy", nil))
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, `id="data-inventory"`) || !strings.Contains(body, "<code>"+SessionCookieName+"</code>") {
+		t.Fatalf("privacy page = %d:\n%s", w.Code, body)
+	}
+	if w.Header().Get("Last-Modified") != "" {
+		t.Error("Expected no Last-Modified on a page that follows the configuration")
+	}
+}
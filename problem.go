@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RFC 7807 problem details
+const (
+	ProblemContentType = "application/problem+json"
+	// ProblemTypePrefix is prepended to an ErrorCode constant to form a problem type URI.
+	ProblemTypePrefix = "urn:vortludo:error:"
+)
+
+// csrfErrorCodes maps CSRF failure reasons to their error codes.
+var csrfErrorCodes = map[string]string{
+	CSRFReasonMissing:  ErrorCodeCSRFMissing,
+	CSRFReasonExpired:  ErrorCodeCSRFExpired,
+	CSRFReasonMismatch: ErrorCodeCSRFMismatch,
+}
+
+// problem is an RFC 7807 problem details body. Code repeats the ErrorCode constant at the end
+// of Type so clients can branch on it without parsing the URI.
+type problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []fieldError `json:"errors,omitempty"`
+}
+
+// fieldError describes one invalid request field.
+type fieldError struct {
+	Field  string `json:"field"`
+	In     string `json:"in"`
+	Detail string `json:"detail"`
+}
+
+// writeProblem aborts the request with a problem details response for an ErrorCode constant.
+func writeProblem(c *gin.Context, status int, code, detail string, errs ...fieldError) {
+	p := problem{
+		Type:      ProblemTypePrefix + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		Code:      code,
+		RequestID: requestIDFrom(c.Request.Context()),
+		Errors:    errs,
+	}
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(status, p)
+}
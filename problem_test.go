@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddlewareErrorsAreProblems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{}
+	tests := []struct {
+		name   string
+		reject func(c *gin.Context)
+		status int
+		code   string
+	}{
+		{"rate limit", app.rejectRateLimited, http.StatusTooManyRequests, ErrorCodeRateLimited},
+		{"csrf expired", func(c *gin.Context) { app.rejectCSRF(c, CSRFReasonExpired) }, http.StatusForbidden, ErrorCodeCSRFExpired},
+		{"csrf missing", func(c *gin.Context) { app.rejectCSRF(c, CSRFReasonMissing) }, http.StatusForbidden, ErrorCodeCSRFMissing},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/guess", nil)
+		tt.reject(c)
+
+		var p problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if w.Code != tt.status || w.Header().Get("Content-Type") != ProblemContentType {
+			t.Errorf("%s: got %d %q", tt.name, w.Code, w.Header().Get("Content-Type"))
+		}
+		if p.Code != tt.code || p.Type != ProblemTypePrefix+tt.code || p.Status != tt.status || p.Instance != "/guess" || p.Title == "" {
+			t.Errorf("%s: unexpected problem %+v", tt.name, p)
+		}
+	}
+}
+
+func TestCSRFErrorCodesCoverEveryReason(t *testing.T) {
+	for _, reason := range []string{CSRFReasonMissing, CSRFReasonExpired, CSRFReasonMismatch} {
+		if csrfErrorCodes[reason] == "" {
+			t.Errorf("No error code for CSRF reason %s", reason)
+		}
+	}
+}
+
+func TestProblemCarriesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(requestIDMiddleware())
+	router.GET("/fail", func(c *gin.Context) {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "missing")
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/fail", nil)
+	req.Header.Set("X-Request-Id", "abcdef1234567890")
+	router.ServeHTTP(w, req)
+
+	var p problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.RequestID != "abcdef1234567890" {
+		t.Errorf("problem request_id = %q, want the request's ID", p.RequestID)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"slices"
+)
+
+// Progress token formats, the leading byte of every token payload. Version 2 adds the ID of
+// the signing key after the format byte.
+const (
+	progressTokenFormatV1 = 1
+	progressTokenFormat   = 2
+)
+
+// progressMACSize is the number of HMAC-SHA256 bytes kept in a progress token.
+const progressMACSize = 16
+
+// Progress token errors
+var (
+	errProgressMalformed = errors.New("malformed progress token")
+	errProgressSignature = errors.New("invalid progress token signature")
+	errProgressStale     = errors.New("progress token is for a different word list version")
+)
+
+// ProgressTokens issues and verifies signed completion bitmaps. A token records which words of a
+// pack a player has finished, indexed by position in the pack, and is bound to the pack name and
+// its word list version so clients cannot forge or replay it against another list.
+type ProgressTokens struct {
+	keys *Keyring
+}
+
+// newProgressTokens returns a signer keyed by keys.
+func newProgressTokens(keys *Keyring) *ProgressTokens {
+	return &ProgressTokens{keys: keys}
+}
+
+// progressMAC signs a token payload for the given pack.
+func progressMAC(secret []byte, pack string, payload []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(pack))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return h.Sum(nil)[:progressMACSize]
+}
+
+// issue returns a signed token for the pack's completion bitmap. It returns an empty string when
+// no signer is configured.
+func (pt *ProgressTokens) issue(pack *WordPack, bitmap []byte) string {
+	if pt == nil || pack == nil {
+		return ""
+	}
+	key := pt.keys.current()
+	payload := make([]byte, 0, 1+keyIDLength+8+len(bitmap)+progressMACSize)
+	payload = append(payload, progressTokenFormat)
+	payload = append(payload, key.id...)
+	payload = binary.BigEndian.AppendUint64(payload, pack.Version)
+	payload = append(payload, bitmap...)
+	return base64.RawURLEncoding.EncodeToString(append(payload, progressMAC(key.secret, pack.Name, payload)...))
+}
+
+// verify checks a token against the pack and returns its completion bitmap.
+func (pt *ProgressTokens) verify(pack *WordPack, token string) ([]byte, error) {
+	if pt == nil || pack == nil {
+		return nil, errProgressSignature
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) == 0 {
+		return nil, errProgressMalformed
+	}
+	// header is the length of the format byte and key ID before the word list version.
+	header := 1
+	switch raw[0] {
+	case progressTokenFormatV1:
+	case progressTokenFormat:
+		header = 1 + keyIDLength
+	default:
+		return nil, errProgressMalformed
+	}
+	if len(raw) < header+8+progressMACSize {
+		return nil, errProgressMalformed
+	}
+	payload, sig := raw[:len(raw)-progressMACSize], raw[len(raw)-progressMACSize:]
+	signed := pt.keys.verify(string(raw[1:header]), func(key signingKey) bool {
+		return hmac.Equal(sig, progressMAC(key.secret, pack.Name, payload))
+	})
+	if !signed {
+		return nil, errProgressSignature
+	}
+	if binary.BigEndian.Uint64(payload[header:header+8]) != pack.Version {
+		return nil, errProgressStale
+	}
+	bitmap := payload[header+8:]
+	if len(bitmap) > (len(pack.Words)+7)/8 {
+		return nil, errProgressMalformed
+	}
+	return bitmap, nil
+}
+
+// wordListVersion hashes a word list so any change to its words or their order yields a new version.
+func wordListVersion(words []WordEntry) uint64 {
+	h := fnv.New64a()
+	for _, entry := range words {
+		h.Write([]byte(entry.Word))
+		h.Write([]byte{'\n'})
+	}
+	return h.Sum64()
+}
+
+// completedWords returns the pack words whose bits are set in bitmap.
+func (p *WordPack) completedWords(bitmap []byte) []string {
+	var words []string
+	for i, entry := range p.Words {
+		if i/8 < len(bitmap) && bitmap[i/8]&(1<<(i%8)) != 0 {
+			words = append(words, entry.Word)
+		}
+	}
+	return words
+}
+
+// completionBitmap returns a bitmap with the bits of the given pack words set. Words outside the
+// pack are ignored.
+func (p *WordPack) completionBitmap(words []string) []byte {
+	var bitmap []byte
+	for _, word := range words {
+		bitmap = p.markCompleted(bitmap, word)
+	}
+	return bitmap
+}
+
+// markCompleted returns a copy of bitmap with the bit for word set, growing it as needed.
+func (p *WordPack) markCompleted(bitmap []byte, word string) []byte {
+	i := slices.IndexFunc(p.Words, func(entry WordEntry) bool { return entry.Word == word })
+	if i < 0 {
+		return bitmap
+	}
+	out := make([]byte, max(len(bitmap), i/8+1))
+	copy(out, bitmap)
+	out[i/8] |= 1 << (i % 8)
+	return out
+}
+
+// issueProgressToken sets the progress token of a finished game, marking its word completed in
+// the pack on top of the words the game started with.
+func (app *App) issueProgressToken(game *GameState) {
+	pack := app.wordPack(game.Pack)
+	token := app.Progress.issue(pack, pack.markCompleted(game.Completed, game.SessionWord))
+	app.SessionMutex.Lock()
+	game.ProgressToken = token
+	app.SessionMutex.Unlock()
+}
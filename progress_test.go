@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestProgressTokenRoundTrip(t *testing.T) {
+	pt := newProgressTokens(newKeyring([]byte("test-secret")))
+	pack := newWordPack("animals", []WordEntry{{Word: "CAMEL"}, {Word: "HORSE"}, {Word: "TIGER"}})
+
+	bitmap := pack.completionBitmap([]string{"TIGER", "CAMEL", "NOTIN"})
+	token := pt.issue(pack, bitmap)
+	got, err := pt.verify(pack, token)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if words := pack.completedWords(got); !slices.Equal(words, []string{"CAMEL", "TIGER"}) {
+		t.Errorf("Expected CAMEL and TIGER, got %v", words)
+	}
+
+	other := newWordPack("food", pack.Words)
+	if _, err := pt.verify(other, token); !errors.Is(err, errProgressSignature) {
+		t.Errorf("Expected signature error for another pack, got %v", err)
+	}
+
+	changed := newWordPack("animals", append(slices.Clone(pack.Words), WordEntry{Word: "ZEBRA"}))
+	if _, err := pt.verify(changed, token); !errors.Is(err, errProgressStale) {
+		t.Errorf("Expected stale error after the list changed, got %v", err)
+	}
+
+	tampered := []byte(token)
+	tampered[12] ^= 1
+	if _, err := pt.verify(pack, string(tampered)); err == nil {
+		t.Error("Expected tampered token to be rejected")
+	}
+	if _, err := pt.verify(pack, "not-a-token"); !errors.Is(err, errProgressMalformed) {
+		t.Errorf("Expected malformed error, got %v", err)
+	}
+}
+
+func TestNewGameWithProgressMarksCompleted(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}, {Word: "SLATE"}})
+	app.Progress = newProgressTokens(newKeyring([]byte("test-secret")))
+	pack := app.wordPack(DefaultPackName)
+
+	game, reset := app.createNewGameWithCompletedWords(dummyContext(), "sess", pack, []string{"CRANE"}, selectionRequest{})
+	if reset || game.SessionWord != "SLATE" {
+		t.Fatalf("Expected SLATE without reset, got %s reset=%v", game.SessionWord, reset)
+	}
+	token := app.Progress.issue(pack, pack.markCompleted(game.Completed, game.SessionWord))
+	bitmap, err := app.Progress.verify(pack, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if words := pack.completedWords(bitmap); !slices.Equal(words, []string{"CRANE", "SLATE"}) {
+		t.Errorf("Expected both words completed, got %v", words)
+	}
+}
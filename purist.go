@@ -0,0 +1,39 @@
+package main
+
+import "github.com/gin-gonic/gin"
+
+// ModePurist is the new-game mode that hides hints and keeps its own stats.
+const ModePurist = "purist"
+
+// requestedPurist reports whether a new-game request asked for purist mode.
+func requestedPurist(c *gin.Context) bool {
+	return c.PostForm("mode") == ModePurist
+}
+
+// gameHint returns the hint shown for game; purist games have none.
+func (app *App) gameHint(game *GameState) string {
+	if game == nil || game.Purist {
+		return ""
+	}
+	return app.hintIn(app.gameLists(game), game.Pack, game.SessionWord)
+}
+
+// statsBucket returns the PlayerStatsStore key holding a player's results in the given mode.
+// Purist and kids games keep their own stats apart from the regular history.
+func statsBucket(key, mode string) string {
+	if mode == ModePurist || mode == ModeKids {
+		return key + ":" + mode
+	}
+	return key
+}
+
+// gameMode returns the mode recorded with a finished game.
+func gameMode(game *GameState) string {
+	switch {
+	case game.Purist:
+		return ModePurist
+	case game.Kids:
+		return ModeKids
+	}
+	return ""
+}
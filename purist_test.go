@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestPuristGamesHideHintsAndKeepSeparateStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE", Hint: "a bird"}})
+	app.Players = newPlayerStatsStore(StreakFreezeRules{})
+
+	regular := &GameState{GameState: engine.GameState{SessionWord: "CRANE", GameOver: true, Won: true, GuessHistory: []string{"CRANE"}}}
+	purist := &GameState{GameState: engine.GameState{SessionWord: "CRANE", GameOver: true, Won: true, GuessHistory: []string{"SLATE", "CRANE"}}, Purist: true}
+	if app.gameHint(regular) != "a bird" || app.gameHint(purist) != "" {
+		t.Errorf("gameHint() = %q / %q, want the hint only outside purist mode", app.gameHint(regular), app.gameHint(purist))
+	}
+
+	app.recordPlayerGame(&gin.Context{}, "session-123", regular)
+	app.recordPlayerGame(&gin.Context{}, "session-123", purist)
+	if _, _, s := app.Players.summary("session-123", ""); s.Played != 1 || s.Distribution[0] != 1 {
+		t.Errorf("regular stats = %+v, want only the regular game", s)
+	}
+	history, _, s := app.Players.summary(statsBucket("session-123", ModePurist), "")
+	if s.Played != 1 || s.Distribution[1] != 1 || history[0].Mode != ModePurist {
+		t.Errorf("purist stats = %+v %+v, want only the purist game", s, history)
+	}
+
+	app.GameSessions["session-123"] = &GameState{GameState: engine.GameState{SessionWord: "CRANE"}, Purist: true}
+	router := gin.New()
+	router.GET(RouteHintAPI, app.hintAPIHandler)
+	req := httptest.NewRequest(http.MethodGet, RouteHintAPI, nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session-123"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden || app.GameSessions["session-123"].HintsUsed != 0 {
+		t.Errorf("hint API in purist mode = %d with %d hints used, want 403 and none", w.Code, app.GameSessions["session-123"].HintsUsed)
+	}
+}
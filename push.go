@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceWorkerFile is the service worker script in the static directory.
+const ServiceWorkerFile = "sw.js"
+
+// Push limits
+const (
+	maxPushSubscriptions = 10000
+	maxPushEndpointLen   = 1024
+	// PushPollInterval bounds the wait between checks for subscriptions due a notification, so
+	// quiet hours ending and forced rollovers are picked up between rollovers.
+	PushPollInterval = 15 * time.Minute
+	// pushMinTTL is the shortest time a push service is asked to hold an undelivered notification.
+	pushMinTTL = time.Minute
+)
+
+// pushServiceHosts are the push services subscriptions may point at; a host matches itself
+// and its subdomains. Endpoints anywhere else are refused so the server never posts to a
+// URL a client made up.
+var pushServiceHosts = []string{
+	"fcm.googleapis.com",
+	"updates.push.services.mozilla.com",
+	"push.apple.com",
+	"notify.windows.com",
+}
+
+// Push subscription validation errors, shown to the subscriber.
+var (
+	errPushEndpoint    = errors.New("endpoint must be an https URL on a known push service")
+	errPushQuietHours  = errors.New("quiet hours must be between 0 and 23")
+	errPushTimezone    = errors.New("unknown timezone")
+	errPushFull        = errors.New("too many push subscriptions, try again later")
+	errPushGone        = errors.New("push subscription expired")
+	errPushUnavailable = errors.New("push service unavailable")
+)
+
+// PushSubscription is a browser's opt-in to "new puzzle" notifications. Notifications carry no
+// payload, so only the endpoint is kept and the service worker shows a fixed message. Quiet
+// hours run from QuietStart up to QuietEnd in Timezone, wrapping past midnight; equal hours
+// mean none.
+type PushSubscription struct {
+	Endpoint   string    `json:"endpoint"`
+	Timezone   string    `json:"timezone"`
+	QuietStart int       `json:"quietStart"`
+	QuietEnd   int       `json:"quietEnd"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSent   string    `json:"lastSent,omitempty"`
+}
+
+// quiet reports whether t falls in the subscription's quiet hours, in its own timezone.
+func (s PushSubscription) quiet(t time.Time, loc *time.Location) bool {
+	if s.QuietStart == s.QuietEnd {
+		return false
+	}
+	h := t.In(loc).Hour()
+	if s.QuietStart < s.QuietEnd {
+		return h >= s.QuietStart && h < s.QuietEnd
+	}
+	return h >= s.QuietStart || h < s.QuietEnd
+}
+
+// WebPush sends Web Push notifications when a new daily puzzle is available to the browsers
+// that opted in, persisted to a JSON file.
+type WebPush struct {
+	mu            sync.Mutex
+	storage       Storage
+	path          string
+	subscriptions map[string]*PushSubscription
+	keys          *VAPIDKeys
+	client        *http.Client
+	breaker       *CircuitBreaker
+}
+
+// newWebPush returns a push sender signing with keys, persisted at path (empty path disables
+// persistence).
+func newWebPush(path string, keys *VAPIDKeys, timeout time.Duration) *WebPush {
+	return &WebPush{
+		storage:       DirStorage{},
+		path:          path,
+		subscriptions: make(map[string]*PushSubscription),
+		keys:          keys,
+		client:        &http.Client{Timeout: timeout},
+	}
+}
+
+// validPushEndpoint reports whether endpoint is an https URL on one of pushServiceHosts.
+func validPushEndpoint(endpoint string) bool {
+	if len(endpoint) > maxPushEndpointLen {
+		return false
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Port() != "" {
+		return false
+	}
+	host := u.Hostname()
+	for _, allowed := range pushServiceHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseQuietHour parses an hour of the day, treating an empty value as 0.
+func parseQuietHour(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	h, err := strconv.Atoi(s)
+	if err != nil || h < 0 || h > 23 {
+		return 0, errPushQuietHours
+	}
+	return h, nil
+}
+
+// load reads persisted subscriptions from disk. A missing file is not an error.
+func (p *WebPush) load() error {
+	var subscriptions []PushSubscription
+	if found, err := readJSONFile(p.storage, p.path, &subscriptions); err != nil || !found {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, sub := range subscriptions {
+		p.subscriptions[sub.Endpoint] = &sub
+	}
+	return nil
+}
+
+// save writes the subscriptions to disk atomically. Callers must hold p.mu.
+func (p *WebPush) save() error {
+	subscriptions := make([]*PushSubscription, 0, len(p.subscriptions))
+	for _, endpoint := range slices.Sorted(maps.Keys(p.subscriptions)) {
+		subscriptions = append(subscriptions, p.subscriptions[endpoint])
+	}
+	return writeJSONFile(p.storage, p.path, subscriptions)
+}
+
+// subscribe adds sub, or updates the quiet hours and timezone of an existing subscription
+// with the same endpoint.
+func (p *WebPush) subscribe(sub PushSubscription) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if existing, ok := p.subscriptions[sub.Endpoint]; ok {
+		existing.Timezone, existing.QuietStart, existing.QuietEnd = sub.Timezone, sub.QuietStart, sub.QuietEnd
+		return p.save()
+	}
+	if len(p.subscriptions) >= maxPushSubscriptions {
+		return errPushFull
+	}
+	p.subscriptions[sub.Endpoint] = &sub
+	return p.save()
+}
+
+// unsubscribe removes the subscription for endpoint, reporting whether there was one.
+func (p *WebPush) unsubscribe(endpoint string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.subscriptions[endpoint]; !ok {
+		return false, nil
+	}
+	delete(p.subscriptions, endpoint)
+	return true, p.save()
+}
+
+// due returns the subscriptions not yet notified of the puzzle for date.
+func (p *WebPush) due(date string) []PushSubscription {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var due []PushSubscription
+	for _, endpoint := range slices.Sorted(maps.Keys(p.subscriptions)) {
+		if sub := p.subscriptions[endpoint]; sub.LastSent != date {
+			due = append(due, *sub)
+		}
+	}
+	return due
+}
+
+// record marks the sent endpoints as notified for date and drops the expired ones.
+func (p *WebPush) record(sent, gone []string, date string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, endpoint := range sent {
+		if sub, ok := p.subscriptions[endpoint]; ok {
+			sub.LastSent = date
+		}
+	}
+	for _, endpoint := range gone {
+		delete(p.subscriptions, endpoint)
+	}
+	return p.save()
+}
+
+// count returns the number of subscriptions.
+func (p *WebPush) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.subscriptions)
+}
+
+// push sends a payload-less notification to endpoint, held by the push service for ttl and
+// replacing any earlier one not yet delivered. It returns errPushGone when the subscription
+// no longer exists.
+func (p *WebPush) push(ctx context.Context, endpoint string, ttl time.Duration, now time.Time) error {
+	auth, err := p.keys.authorization(endpoint, now)
+	if err != nil {
+		return err
+	}
+	var gone bool
+	err = p.breaker.call(ctx, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", auth)
+		req.Header.Set("TTL", strconv.Itoa(int(max(ttl, pushMinTTL).Seconds())))
+		req.Header.Set("Urgency", "normal")
+		req.Header.Set("Topic", "daily-puzzle")
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+			// An expired subscription says nothing about the push service's health.
+			gone = true
+			return nil
+		case resp.StatusCode >= 300:
+			return fmt.Errorf("%w: status %d", errPushUnavailable, resp.StatusCode)
+		}
+		return nil
+	})
+	if err == nil && gone {
+		return errPushGone
+	}
+	return err
+}
+
+// runPush notifies subscribers shortly after each daily rollover for the life of the process.
+// It checks at least every PushPollInterval, so subscribers in quiet hours at the rollover
+// are notified once their quiet hours end.
+func (app *App) runPush() {
+	for {
+		now := app.now()
+		wait := min(app.Daily.nextRollover(now, app.Daily.Location).Sub(now)+time.Second, PushPollInterval)
+		time.Sleep(wait)
+		if app.Leases.leader(JobPush) {
+			app.sendPushes(context.Background())
+		}
+	}
+}
+
+// sendPushes notifies every subscription not yet told about today's puzzle and outside its
+// quiet hours. Expired subscriptions are dropped, and the run stops early if the push circuit
+// opens, leaving the rest for the next one.
+func (app *App) sendPushes(ctx context.Context) int {
+	now := app.now()
+	date := app.Daily.puzzleDate(now, app.Daily.Location)
+	day := date.Format(time.DateOnly)
+	ttl := app.Daily.nextRollover(now, app.Daily.Location).Sub(now)
+	locations := make(map[string]*time.Location)
+	var sent, gone []string
+subscriptions:
+	for _, sub := range app.Push.due(day) {
+		loc, ok := locations[sub.Timezone]
+		if !ok {
+			loc = loadDailyLocation(sub.Timezone)
+			locations[sub.Timezone] = loc
+		}
+		if sub.quiet(now, loc) {
+			continue
+		}
+		err := app.Push.push(ctx, sub.Endpoint, ttl, now)
+		switch {
+		case errors.Is(err, errPushGone):
+			gone = append(gone, sub.Endpoint)
+			continue
+		case err != nil:
+			app.incMetric(MetricPushFailures)
+			logWarn("Failed to send push notification: %v", err)
+			if errors.Is(err, errCircuitOpen) {
+				break subscriptions
+			}
+			continue
+		}
+		sent = append(sent, sub.Endpoint)
+	}
+	if len(sent)+len(gone) == 0 {
+		return 0
+	}
+	if err := app.Push.record(sent, gone, day); err != nil {
+		logWarn("Failed to save push subscriptions: %v", err)
+	}
+	app.Metrics.Add(MetricPushSent, int64(len(sent)))
+	app.Metrics.Add(MetricPushExpired, int64(len(gone)))
+	logInfo("Sent %d push notifications for %s, dropped %d expired subscriptions", len(sent), day, len(gone))
+	return len(sent)
+}
+
+// vapidPublicKey returns the key browsers subscribe with, or "" when push is disabled.
+func (app *App) vapidPublicKey() string {
+	if app.Push == nil {
+		return ""
+	}
+	return app.Push.keys.PublicKey
+}
+
+// pushSubscribeHandler saves a browser's push subscription with its quiet hours and timezone.
+// The first notification is for the next puzzle, since the current one is already out.
+func (app *App) pushSubscribeHandler(c *gin.Context) {
+	now := app.now()
+	sub := PushSubscription{
+		Endpoint:  c.PostForm("endpoint"),
+		Timezone:  c.DefaultPostForm("timezone", "UTC"),
+		CreatedAt: now,
+		LastSent:  app.Daily.puzzleDate(now, app.Daily.Location).Format(time.DateOnly),
+	}
+	var err error
+	if !validPushEndpoint(sub.Endpoint) {
+		err = errPushEndpoint
+	} else if _, tzErr := time.LoadLocation(sub.Timezone); tzErr != nil || sub.Timezone == "" || sub.Timezone == "Local" {
+		err = errPushTimezone
+	} else if sub.QuietStart, err = parseQuietHour(c.PostForm("quiet_start")); err == nil {
+		sub.QuietEnd, err = parseQuietHour(c.PostForm("quiet_end"))
+	}
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+	if err := app.Push.subscribe(sub); err != nil {
+		if errors.Is(err, errPushFull) {
+			writeProblem(c, http.StatusServiceUnavailable, ErrorCodeQueueFull, err.Error())
+			return
+		}
+		logWarn("Failed to save push subscriptions: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not save subscription")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subscribed": true, "quiet_start": sub.QuietStart, "quiet_end": sub.QuietEnd})
+}
+
+// pushUnsubscribeHandler removes the push subscription for the posted endpoint.
+func (app *App) pushUnsubscribeHandler(c *gin.Context) {
+	removed, err := app.Push.unsubscribe(c.PostForm("endpoint"))
+	if err != nil {
+		logWarn("Failed to save push subscriptions: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not remove subscription")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
+
+// serviceWorkerHandler serves the service worker from the site root so its scope covers the
+// whole app. It is revalidated on every load so updates reach installed clients.
+func serviceWorkerHandler(staticDir string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Content-Type", "text/javascript; charset=utf-8")
+		c.File(filepath.Join(staticDir, ServiceWorkerFile))
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pushTransport answers push requests with the status for each endpoint.
+type pushTransport struct {
+	status   map[string]int
+	requests []*http.Request
+}
+
+func (p *pushTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.requests = append(p.requests, req)
+	return &http.Response{StatusCode: p.status[req.URL.String()], Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestValidPushEndpoint(t *testing.T) {
+	tests := map[string]bool{
+		"https://fcm.googleapis.com/fcm/send/abc":                 true,
+		"https://web.push.apple.com/QGx":                          true,
+		"https://updates.push.services.mozilla.com/wpush/v2/x":    true,
+		"http://fcm.googleapis.com/fcm/send/abc":                  false,
+		"https://fcm.googleapis.com.evil.example/abc":             false,
+		"https://evilpush.apple.com/abc":                          false,
+		"https://fcm.googleapis.com:8443/abc":                     false,
+		"https://user@fcm.googleapis.com/abc":                     false,
+		"https://169.254.169.254/latest":                          false,
+		"https://fcm.googleapis.com/" + strings.Repeat("a", 1024): false,
+	}
+	for endpoint, want := range tests {
+		if got := validPushEndpoint(endpoint); got != want {
+			t.Errorf("validPushEndpoint(%q) = %v, want %v", endpoint, got, want)
+		}
+	}
+}
+
+func TestPushQuietHours(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	at := func(hour int) time.Time { return time.Date(2026, 3, 1, hour-2, 30, 0, 0, time.UTC) }
+	overnight := PushSubscription{QuietStart: 22, QuietEnd: 7}
+	daytime := PushSubscription{QuietStart: 9, QuietEnd: 17}
+	tests := []struct {
+		sub  PushSubscription
+		hour int
+		want bool
+	}{
+		{overnight, 23, true},
+		{overnight, 3, true},
+		{overnight, 7, false},
+		{overnight, 12, false},
+		{daytime, 9, true},
+		{daytime, 17, false},
+		{PushSubscription{}, 3, false},
+	}
+	for _, tt := range tests {
+		if got := tt.sub.quiet(at(tt.hour), loc); got != tt.want {
+			t.Errorf("quiet(%d-%d at %d:30) = %v, want %v", tt.sub.QuietStart, tt.sub.QuietEnd, tt.hour, got, tt.want)
+		}
+	}
+}
+
+func TestVAPIDKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vapid.json")
+	keys, err := loadVAPIDKeys(DirStorage{}, path, "mailto:admin@vortludo.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := loadVAPIDKeys(DirStorage{}, path, "mailto:admin@vortludo.example")
+	if err != nil || again.PublicKey != keys.PublicKey {
+		t.Fatalf("Expected the saved key pair back, got %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	auth, err := keys.authorization("https://fcm.googleapis.com/fcm/send/abc", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, key, ok := strings.Cut(strings.TrimPrefix(auth, "vapid t="), ", k=")
+	if !ok || key != keys.PublicKey {
+		t.Fatalf("authorization = %q", auth)
+	}
+	parts := strings.Split(token, ".")
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Aud != "https://fcm.googleapis.com" ||
+		claims.Exp != now.Add(VAPIDTokenLifetime).Unix() || claims.Sub != "mailto:admin@vortludo.example" {
+		t.Errorf("claims = %+v, %v", claims, err)
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(keys.PublicKey)
+	public, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if len(sig) != 64 || !ecdsa.Verify(public, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Error("Expected the token to verify with the public key")
+	}
+}
+
+func TestSendPushes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := loadVAPIDKeys(DirStorage{}, filepath.Join(t.TempDir(), "vapid.json"), "mailto:admin@vortludo.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const (
+		live  = "https://fcm.googleapis.com/fcm/send/live"
+		gone  = "https://fcm.googleapis.com/fcm/send/gone"
+		quiet = "https://web.push.apple.com/quiet"
+		fresh = "https://updates.push.services.mozilla.com/wpush/v2/fresh"
+	)
+	transport := &pushTransport{status: map[string]int{live: http.StatusCreated, gone: http.StatusGone, fresh: http.StatusCreated}}
+	app := &App{Daily: newDailySchedule("UTC", 0, false), Metrics: newMetrics()}
+	app.Push = newWebPush(filepath.Join(t.TempDir(), "push.json"), keys, time.Second)
+	app.Push.client.Transport = transport
+
+	hour := time.Now().UTC().Hour()
+	for _, sub := range []PushSubscription{
+		{Endpoint: live, Timezone: "UTC"},
+		{Endpoint: gone, Timezone: "UTC"},
+		{Endpoint: quiet, Timezone: "UTC", QuietStart: hour, QuietEnd: (hour + 1) % 24},
+	} {
+		if err := app.Push.subscribe(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := gin.New()
+	router.POST(RoutePush+"/subscribe", app.pushSubscribeHandler)
+	subscribe := func(form url.Values) int {
+		req := httptest.NewRequest("POST", RoutePush+"/subscribe", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	for _, form := range []url.Values{
+		{"endpoint": {"https://attacker.example/hook"}},
+		{"endpoint": {fresh}, "timezone": {"Mars/Olympus"}},
+		{"endpoint": {fresh}, "quiet_start": {"25"}},
+	} {
+		if code := subscribe(form); code != http.StatusBadRequest {
+			t.Errorf("subscribe(%v) = %d, want 400", form, code)
+		}
+	}
+	if code := subscribe(url.Values{"endpoint": {fresh}, "timezone": {"Europe/Paris"}, "quiet_start": {"22"}, "quiet_end": {"7"}}); code != http.StatusOK {
+		t.Fatalf("subscribe = %d", code)
+	}
+
+	if n := app.sendPushes(context.Background()); n != 1 {
+		t.Fatalf("sendPushes() = %d, want only the live subscription", n)
+	}
+	if len(transport.requests) != 2 {
+		t.Fatalf("pushed %d times, want the live and gone endpoints", len(transport.requests))
+	}
+	req := transport.requests[0]
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "vapid t=") || req.Header.Get("TTL") == "" || req.ContentLength != 0 {
+		t.Errorf("push headers = %v", req.Header)
+	}
+	if app.Push.count() != 3 {
+		t.Errorf("count() = %d, want the expired subscription dropped", app.Push.count())
+	}
+	if app.sendPushes(context.Background()) != 0 {
+		t.Error("Expected one notification per puzzle date")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+// QR code rendering constants
+const (
+	QRImageSize = 256
+	QRCacheAge  = 24 * 60 * 60
+)
+
+// shareablePath reports whether path is an in-app link worth encoding as a QR code: a
+// challenge or custom game, a spectate link, or a team invite. Anything else is rejected so
+// the endpoint cannot be used to mint QR codes for arbitrary sites.
+func shareablePath(path string) bool {
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme != "" || u.Host != "" || len(path) > 512 {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(u.Path, RoutePlay+"/"), strings.HasPrefix(u.Path, RouteSpectate+"/"):
+		return u.RawQuery == ""
+	case u.Path == RouteHome:
+		return u.Query().Get("room") != "" && len(u.Query()) == 1
+	}
+	return false
+}
+
+// absoluteURL resolves path against PUBLIC_BASE_URL, or against the request's own origin when
+// it is not set. The request's origin comes from its Host and X-Forwarded-Proto headers, which
+// a client can set to anything.
+func (app *App) absoluteURL(c *gin.Context, path string) string {
+	if app.PublicBaseURL != "" {
+		return app.PublicBaseURL + path
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + path
+}
+
+// qrSVG renders a QR bitmap as a scalable SVG with one square per dark module.
+func qrSVG(bitmap [][]bool) string {
+	var b strings.Builder
+	n := len(bitmap)
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String()
+}
+
+// qrHandler renders a QR code for a shareable in-app link given in the path query parameter,
+// as a PNG by default or as SVG with format=svg.
+func (app *App) qrHandler(c *gin.Context) {
+	path := c.Query("path")
+	if !shareablePath(path) {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "path must be a challenge, spectate, or team link")
+		return
+	}
+	q, err := qrcode.New(app.absoluteURL(c, path), qrcode.Medium)
+	if err != nil {
+		logWarn("Failed to encode QR code: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not encode QR code")
+		return
+	}
+
+	// Without a configured base URL the code encodes the request's own origin, so shared caches
+	// must not hand it to other clients.
+	cacheScope := "private"
+	if app.PublicBaseURL != "" {
+		cacheScope = "public"
+	}
+	c.Header("Cache-Control", fmt.Sprintf("%s, max-age=%d", cacheScope, QRCacheAge))
+	switch c.DefaultQuery("format", "png") {
+	case "png":
+		png, err := q.PNG(QRImageSize)
+		if err != nil {
+			logWarn("Failed to render QR code: %v", err)
+			writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not render QR code")
+			return
+		}
+		c.Data(http.StatusOK, "image/png", png)
+	case "svg":
+		c.Data(http.StatusOK, "image/svg+xml", []byte(qrSVG(q.Bitmap())))
+	default:
+		c.Header("Cache-Control", "no-store")
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "format must be png or svg")
+	}
+}
+
+// challengeURL returns a link that lets a friend play the same word as a finished game. It is
+// empty while the game is in progress so the link cannot leak the answer early.
+func (app *App) challengeURL(game *GameState) string {
+	if app.Games == nil || game == nil || !game.GameOver {
+		return ""
+	}
+	return RoutePlay + "/" + app.Games.seal(app.wordPack(game.Pack).Name, game.SessionWord)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestShareablePath(t *testing.T) {
+	tests := map[string]bool{
+		"/play/abc":                  true,
+		"/spectate/abc":              true,
+		"/?room=ABC234":              true,
+		"/":                          false,
+		"/?room=ABC234&next=/admin":  false,
+		"/play/abc?x=1":              false,
+		"/admin/stats":               false,
+		"https://evil.example/play/": false,
+		"//evil.example/play/abc":    false,
+	}
+	for path, want := range tests {
+		if got := shareablePath(path); got != want {
+			t.Errorf("shareablePath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestQRHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{}
+	router := gin.New()
+	router.GET(RouteQR, app.qrHandler)
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", RouteQR+"?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	path := url.QueryEscape("/play/abc")
+	if w := get("path=" + path); w.Code != http.StatusOK || !bytes.HasPrefix(w.Body.Bytes(), []byte("\x89PNG")) {
+		t.Errorf("Expected a PNG, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w := get("path=" + path + "&format=svg"); w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "<svg") {
+		t.Errorf("Expected an SVG, got %d %q", w.Code, w.Body.String())
+	}
+	if w := get("path=" + url.QueryEscape("https://example.com")); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an external URL, got %d", w.Code)
+	}
+	if w := get("path=" + path + "&format=gif"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown format, got %d", w.Code)
+	}
+	if cc := get("path=" + path).Header().Get("Cache-Control"); !strings.HasPrefix(cc, "private") {
+		t.Errorf("Cache-Control without PUBLIC_BASE_URL = %q, want private", cc)
+	}
+	app.PublicBaseURL = "https://vortludo.example"
+	if cc := get("path=" + path).Header().Get("Cache-Control"); !strings.HasPrefix(cc, "public") {
+		t.Errorf("Cache-Control with PUBLIC_BASE_URL = %q, want public", cc)
+	}
+}
+
+func TestAbsoluteURLIgnoresRequestHostWhenConfigured(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.Host = "evil.example"
+	c.Request.Header.Set("X-Forwarded-Proto", "https")
+	app := &App{}
+	if got := app.absoluteURL(c, "/play/abc"); got != "https://evil.example/play/abc" {
+		t.Errorf("absoluteURL() without a base URL = %s, want the request's origin", got)
+	}
+	app.PublicBaseURL = "https://vortludo.example"
+	if got := app.absoluteURL(c, "/play/abc"); got != "https://vortludo.example/play/abc" {
+		t.Errorf("absoluteURL() = %s, want the configured base URL", got)
+	}
+}
+
+func TestChallengeShareRendersOnlyWhenGameOver(t *testing.T) {
+	tpl := parseTestTemplates(t)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Games = newGameTokens(newKeyring(make([]byte, 32)))
+	game := newGame("CRANE")
+	if app.challengeURL(game) != "" {
+		t.Error("Challenge link should not be offered mid-game")
+	}
+
+	game.ApplyGuess("CRANE", "CRANE", checkGuess("CRANE", "CRANE"), true)
+	challenge := app.challengeURL(game)
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "game-board", gin.H{"game": game, "challenge_url": challenge}); err != nil {
+		t.Fatal(err)
+	}
+	token := strings.TrimPrefix(challenge, RoutePlay+"/")
+	if !strings.Contains(buf.String(), "/qr?path=%2fplay%2f"+token) {
+		t.Errorf("Expected a QR code for %s in:\n%s", challenge, buf.String())
+	}
+}
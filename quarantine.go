@@ -0,0 +1,151 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuarantineListLimit caps how many quarantined sessions the admin listing returns.
+const QuarantineListLimit = 100
+
+// SessionQuarantine holds sessions the server dropped on its own (currently capacity eviction)
+// for a grace period, so a bad deploy or a misconfigured limit does not wipe player progress
+// for good. Sessions leave quarantine when restored or once the grace period has passed.
+type SessionQuarantine struct {
+	mu      sync.Mutex
+	grace   time.Duration
+	limit   int
+	entries map[string]quarantinedSession
+}
+
+// quarantinedSession is a dropped session awaiting restore or purge.
+type quarantinedSession struct {
+	Game   *GameState
+	Reason string
+	At     time.Time
+}
+
+// quarantineEntry describes a quarantined session for the admin listing.
+type quarantineEntry struct {
+	SessionID string    `json:"session_id"`
+	Reason    string    `json:"reason"`
+	At        time.Time `json:"quarantined_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// newSessionQuarantine returns a quarantine keeping sessions for grace, holding at most limit
+// of them. A zero grace disables quarantine.
+func newSessionQuarantine(grace time.Duration, limit int) *SessionQuarantine {
+	return &SessionQuarantine{grace: grace, limit: limit, entries: make(map[string]quarantinedSession)}
+}
+
+// add quarantines a session. When full, the oldest entries are purged first.
+func (sq *SessionQuarantine) add(sessionID string, game *GameState, reason string, now time.Time) {
+	if sq == nil || sq.grace <= 0 {
+		return
+	}
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	sq.purgeLocked(now)
+	if sq.limit > 0 && len(sq.entries) >= sq.limit {
+		ids := sq.sortedIDsLocked()
+		for _, id := range ids[:len(ids)-sq.limit+1] {
+			delete(sq.entries, id)
+		}
+	}
+	sq.entries[sessionID] = quarantinedSession{Game: game, Reason: reason, At: now}
+}
+
+// take removes and returns a quarantined session that is still within its grace period.
+func (sq *SessionQuarantine) take(sessionID string, now time.Time) (*GameState, bool) {
+	if sq == nil {
+		return nil, false
+	}
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	sq.purgeLocked(now)
+	entry, ok := sq.entries[sessionID]
+	delete(sq.entries, sessionID)
+	return entry.Game, ok
+}
+
+// list returns up to QuarantineListLimit quarantined sessions, most recent first, and the total.
+func (sq *SessionQuarantine) list(now time.Time) ([]quarantineEntry, int) {
+	if sq == nil {
+		return []quarantineEntry{}, 0
+	}
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	sq.purgeLocked(now)
+	ids := sq.sortedIDsLocked()
+	slices.Reverse(ids)
+	entries := make([]quarantineEntry, 0, min(len(ids), QuarantineListLimit))
+	for _, id := range ids[:min(len(ids), QuarantineListLimit)] {
+		e := sq.entries[id]
+		entries = append(entries, quarantineEntry{SessionID: id, Reason: e.Reason, At: e.At, ExpiresAt: e.At.Add(sq.grace)})
+	}
+	return entries, len(ids)
+}
+
+// ids returns the quarantined session IDs, oldest first, only those quarantined for reason
+// when it is set.
+func (sq *SessionQuarantine) ids(reason string, now time.Time) []string {
+	if sq == nil {
+		return nil
+	}
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	sq.purgeLocked(now)
+	ids := sq.sortedIDsLocked()
+	if reason != "" {
+		ids = slices.DeleteFunc(ids, func(id string) bool { return sq.entries[id].Reason != reason })
+	}
+	return ids
+}
+
+// purgeLocked drops sessions whose grace period has passed. Callers must hold mu.
+func (sq *SessionQuarantine) purgeLocked(now time.Time) {
+	for id, e := range sq.entries {
+		if now.Sub(e.At) >= sq.grace {
+			delete(sq.entries, id)
+		}
+	}
+}
+
+// sortedIDsLocked returns the quarantined session IDs, oldest first. Callers must hold mu.
+func (sq *SessionQuarantine) sortedIDsLocked() []string {
+	ids := make([]string, 0, len(sq.entries))
+	for id := range sq.entries {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		return sq.entries[a].At.Compare(sq.entries[b].At)
+	})
+	return ids
+}
+
+// adminQuarantineHandler lists quarantined sessions.
+func (app *App) adminQuarantineHandler(c *gin.Context) {
+	entries, total := app.Quarantine.list(app.now())
+	c.JSON(http.StatusOK, gin.H{"sessions": entries, "total": total})
+}
+
+// adminRestoreSessionHandler moves a quarantined session back into the live store. The player
+// picks it up again on their next request, since their cookie still carries the session ID.
+func (app *App) adminRestoreSessionHandler(c *gin.Context) {
+	sessionID := c.Param("id")
+	game, ok := app.Quarantine.take(sessionID, app.now())
+	if !ok {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "session not in quarantine")
+		return
+	}
+	app.saveGameState(sessionID, game)
+	app.incMetric(MetricSessionsRestored)
+	logInfo("Admin restored quarantined session %s", redactSession(sessionID))
+	auditChange(c, redactSession(sessionID), "quarantined", "restored")
+	c.JSON(http.StatusOK, gin.H{"session_id": sessionID, "restored": true})
+}
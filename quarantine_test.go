@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSessionQuarantine(t *testing.T) {
+	now := time.Unix(1000, 0)
+	sq := newSessionQuarantine(time.Hour, 2)
+	sq.add("first-session", newGame("CRANE"), "capacity", now)
+	sq.add("second-session", newGame("SLATE"), "capacity", now.Add(time.Minute))
+	sq.add("third-session", newGame("TRACE"), "capacity", now.Add(2*time.Minute))
+	if _, ok := sq.take("first-session", now); ok {
+		t.Error("Expected the oldest session to be dropped when the quarantine is full")
+	}
+	if entries, total := sq.list(now); total != 2 || entries[0].SessionID != "third-session" {
+		t.Errorf("Expected newest first, got %d %+v", total, entries)
+	}
+	if game, ok := sq.take("second-session", now); !ok || game.SessionWord != "SLATE" {
+		t.Errorf("take() = %v, %v", game, ok)
+	}
+	if _, ok := sq.take("second-session", now); ok {
+		t.Error("Expected a taken session to leave quarantine")
+	}
+	if _, ok := sq.take("third-session", now.Add(2*time.Minute+time.Hour)); ok {
+		t.Error("Expected sessions past the grace period to be purged")
+	}
+
+	disabled := newSessionQuarantine(0, 10)
+	disabled.add("first-session", newGame("CRANE"), "capacity", now)
+	if _, total := disabled.list(now); total != 0 {
+		t.Error("Expected a zero grace period to disable quarantine")
+	}
+}
+
+func TestEvictedSessionCanBeRestored(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.MaxSessions = 10
+	app.Quarantine = newSessionQuarantine(time.Hour, 10)
+	for i := range 10 {
+		app.saveGameState("session-"+strconv.Itoa(i), newGame("CRANE"))
+		app.GameSessions["session-"+strconv.Itoa(i)].LastAccessTime = time.Unix(int64(i), 0)
+	}
+	evicted := app.GameSessions["session-0"]
+	app.saveGameState("newcomer", newGame("CRANE"))
+
+	router := gin.New()
+	router.POST("/admin/sessions/:id/restore", app.adminRestoreSessionHandler)
+	restore := func(id string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/admin/sessions/"+id+"/restore", nil))
+		return w.Code
+	}
+	if code := restore("session-0"); code != http.StatusOK || app.GameSessions["session-0"] != evicted {
+		t.Errorf("Expected the evicted session to be restored, got %d", code)
+	}
+	if code := restore("session-0"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 restoring twice, got %d", code)
+	}
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reminder subscriber statuses
+const (
+	SubscriberPending   = "pending"
+	SubscriberConfirmed = "confirmed"
+)
+
+// Reminder limits
+const (
+	maxPendingSubscribers = 1000
+	// SubscriptionConfirmTTL is how long a confirmation link stays valid.
+	SubscriptionConfirmTTL = 48 * time.Hour
+	// subscriptionResendCooldown is the least time between confirmation emails to one address.
+	subscriptionResendCooldown = time.Hour
+	// ReminderPollInterval bounds the wait between checks for subscribers due a reminder, so a
+	// forced rollover is picked up without waiting for the next scheduled one.
+	ReminderPollInterval = 15 * time.Minute
+)
+
+// errSubscribersFull is returned when too many subscriptions await confirmation.
+var errSubscribersFull = errors.New("too many pending subscriptions, try again later")
+
+// Subscriber is an email address signed up for daily puzzle reminders. Token is secret and
+// appears only in the subscriber's own confirmation and unsubscribe links.
+type Subscriber struct {
+	Email         string    `json:"email"`
+	Token         string    `json:"token"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"createdAt"`
+	ConfirmSentAt time.Time `json:"confirmSentAt,omitzero"`
+	ConfirmedAt   time.Time `json:"confirmedAt,omitzero"`
+	LastSent      string    `json:"lastSent,omitempty"`
+}
+
+// Reminders emails the daily hint and a play link to subscribers who confirmed their address
+// (double opt-in), persisted to a JSON file. BaseURL is the site's public origin, used for the
+// links in emails since they are sent outside any request.
+type Reminders struct {
+	mu           sync.Mutex
+	storage      Storage
+	path         string
+	subscribers  map[string]*Subscriber
+	mailer       *Mailer
+	BaseURL      string
+	RateInterval time.Duration
+	RateBurst    int
+}
+
+// newReminders returns reminders persisted at path (empty path disables persistence), or nil
+// when mailer is nil or baseURL is empty, which disables them.
+func newReminders(path string, mailer *Mailer, baseURL string) *Reminders {
+	if mailer == nil || baseURL == "" {
+		return nil
+	}
+	return &Reminders{
+		storage:     DirStorage{},
+		path:        path,
+		subscribers: make(map[string]*Subscriber),
+		mailer:      mailer,
+		BaseURL:     strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// subscriberKey is the key an address is stored under; addresses differing only in case are
+// one subscriber.
+func subscriberKey(email string) string {
+	return strings.ToLower(email)
+}
+
+// newSubscriberToken returns a random token for a subscriber's links.
+func newSubscriberToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// load reads persisted subscribers from disk. A missing file is not an error.
+func (r *Reminders) load() error {
+	var subscribers []Subscriber
+	if found, err := readJSONFile(r.storage, r.path, &subscribers); err != nil || !found {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sub := range subscribers {
+		r.subscribers[subscriberKey(sub.Email)] = &sub
+	}
+	return nil
+}
+
+// save writes the subscribers to disk atomically, sorted by address. Callers must hold r.mu.
+func (r *Reminders) save() error {
+	subscribers := make([]*Subscriber, 0, len(r.subscribers))
+	for _, key := range slices.Sorted(maps.Keys(r.subscribers)) {
+		subscribers = append(subscribers, r.subscribers[key])
+	}
+	return writeJSONFile(r.storage, r.path, subscribers)
+}
+
+// subscribe records a pending subscription for email and reports whether a confirmation email
+// should go out. Confirmed addresses get none, and a pending one gets another only after
+// subscriptionResendCooldown, so the form cannot be used to flood an inbox.
+func (r *Reminders) subscribe(email string, now time.Time) (Subscriber, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := 0
+	for key, sub := range r.subscribers {
+		if sub.Status != SubscriberPending {
+			continue
+		}
+		if now.Sub(sub.CreatedAt) > SubscriptionConfirmTTL {
+			delete(r.subscribers, key)
+			continue
+		}
+		pending++
+	}
+
+	sub, ok := r.subscribers[subscriberKey(email)]
+	switch {
+	case ok && sub.Status == SubscriberConfirmed:
+		return *sub, false, nil
+	case ok && now.Sub(sub.ConfirmSentAt) < subscriptionResendCooldown:
+		return *sub, false, nil
+	case !ok:
+		if pending >= maxPendingSubscribers {
+			return Subscriber{}, false, errSubscribersFull
+		}
+		token, err := newSubscriberToken()
+		if err != nil {
+			return Subscriber{}, false, err
+		}
+		sub = &Subscriber{Email: email, Token: token, Status: SubscriberPending, CreatedAt: now}
+		r.subscribers[subscriberKey(email)] = sub
+	}
+	sub.ConfirmSentAt = now
+	return *sub, true, r.save()
+}
+
+// find returns the subscriber whose links carry token. Callers must hold r.mu.
+func (r *Reminders) find(token string) (string, *Subscriber, bool) {
+	if token == "" {
+		return "", nil, false
+	}
+	for key, sub := range r.subscribers {
+		if tokenMatches(token, sub.Token) {
+			return key, sub, true
+		}
+	}
+	return "", nil, false
+}
+
+// confirm completes the subscription with token, reporting false when the link is unknown or
+// has expired.
+func (r *Reminders) confirm(token string, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, sub, ok := r.find(token)
+	if !ok || (sub.Status == SubscriberPending && now.Sub(sub.CreatedAt) > SubscriptionConfirmTTL) {
+		return false, nil
+	}
+	if sub.Status == SubscriberConfirmed {
+		return true, nil
+	}
+	sub.Status, sub.ConfirmedAt = SubscriberConfirmed, now
+	return true, r.save()
+}
+
+// unsubscribe removes the subscriber with token, reporting whether there was one.
+func (r *Reminders) unsubscribe(token string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, _, ok := r.find(token)
+	if !ok {
+		return false, nil
+	}
+	delete(r.subscribers, key)
+	return true, r.save()
+}
+
+// due returns the confirmed subscribers not yet sent the reminder for date.
+func (r *Reminders) due(date string) []Subscriber {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []Subscriber
+	for _, key := range slices.Sorted(maps.Keys(r.subscribers)) {
+		if sub := r.subscribers[key]; sub.Status == SubscriberConfirmed && sub.LastSent != date {
+			due = append(due, *sub)
+		}
+	}
+	return due
+}
+
+// markSent records that the reminder for date went to each address in emails.
+func (r *Reminders) markSent(emails []string, date string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, email := range emails {
+		if sub, ok := r.subscribers[subscriberKey(email)]; ok {
+			sub.LastSent = date
+		}
+	}
+	return r.save()
+}
+
+// confirmed returns the number of confirmed subscribers.
+func (r *Reminders) confirmed() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, sub := range r.subscribers {
+		if sub.Status == SubscriberConfirmed {
+			n++
+		}
+	}
+	return n
+}
+
+// link returns the absolute URL of path on the public site.
+func (r *Reminders) link(path string) string {
+	return r.BaseURL + path
+}
+
+// unsubscribeHeaders returns the List-Unsubscribe headers for sub, letting mail clients offer
+// one-click unsubscribe (RFC 8058).
+func (r *Reminders) unsubscribeHeaders(sub Subscriber) map[string]string {
+	return map[string]string{
+		"List-Unsubscribe":      "<" + r.link(RouteReminders+"/unsubscribe/"+sub.Token) + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
+// sendConfirmation emails sub the link that completes their subscription.
+func (r *Reminders) sendConfirmation(ctx context.Context, sub Subscriber) error {
+	body := "Someone, hopefully you, asked for daily Vortludo puzzle reminders at this address.\n\n" +
+		"Confirm your subscription within 48 hours:\n" + r.link(RouteReminders+"/confirm/"+sub.Token) + "\n\n" +
+		"If this wasn't you, ignore this email and you won't hear from us again.\n"
+	return r.mailer.send(ctx, sub.Email, "Confirm your Vortludo daily reminders", body, nil)
+}
+
+// sendReminder emails sub the hint for the puzzle on date and a link to play it.
+func (r *Reminders) sendReminder(ctx context.Context, sub Subscriber, date, hint string) error {
+	var body strings.Builder
+	body.WriteString("Today's Vortludo puzzle is ready.\n\n")
+	if hint != "" {
+		body.WriteString("Hint: " + hint + "\n\n")
+	}
+	body.WriteString("Play: " + r.link(dailyLink(date)) + "\n\n")
+	body.WriteString("Unsubscribe: " + r.link(RouteReminders+"/unsubscribe/"+sub.Token) + "\n")
+	return r.mailer.send(ctx, sub.Email, "Vortludo daily puzzle for "+date, body.String(), r.unsubscribeHeaders(sub))
+}
+
+// runReminders sends reminders shortly after each daily rollover for the life of the process.
+// It checks at least every ReminderPollInterval, and subscribers are only sent once per puzzle
+// date, so restarts and forced rollovers neither skip nor repeat a day.
+func (app *App) runReminders() {
+	for {
+		now := app.now()
+		wait := min(app.Daily.nextRollover(now, app.Daily.Location).Sub(now)+time.Second, ReminderPollInterval)
+		time.Sleep(wait)
+		if app.Leases.leader(JobReminders) {
+			app.sendReminders(context.Background())
+		}
+	}
+}
+
+// sendReminders emails today's reminder to every confirmed subscriber not yet sent it. It
+// stops early when the mailer's daily limit is reached or its circuit opens, leaving the rest
+// for the next run.
+func (app *App) sendReminders(ctx context.Context) int {
+	date := app.Daily.puzzleDate(app.now(), app.Daily.Location)
+	entry, ok := app.dailyWord(date)
+	if !ok {
+		return 0
+	}
+	day := date.Format(time.DateOnly)
+	var sent []string
+	for _, sub := range app.Reminders.due(day) {
+		err := app.Reminders.sendReminder(ctx, sub, day, entry.Hint)
+		if err != nil {
+			app.incMetric(MetricReminderFailures)
+			logWarn("Failed to send daily reminder: %v", err)
+			if errors.Is(err, errMailLimit) || errors.Is(err, errCircuitOpen) {
+				break
+			}
+			continue
+		}
+		sent = append(sent, sub.Email)
+	}
+	if len(sent) == 0 {
+		return 0
+	}
+	if err := app.Reminders.markSent(sent, day); err != nil {
+		logWarn("Failed to save reminder subscribers: %v", err)
+	}
+	app.Metrics.Add(MetricRemindersSent, int64(len(sent)))
+	logInfo("Sent %d daily reminders for %s", len(sent), day)
+	return len(sent)
+}
+
+// reminderRateLimitMiddleware limits subscription requests to one per Reminders.RateInterval
+// per client IP, with bursts of Reminders.RateBurst.
+func (app *App) reminderRateLimitMiddleware() gin.HandlerFunc {
+	return app.scopedRateLimitMiddleware("reminders", app.Reminders.RateInterval, time.Minute, app.Reminders.RateBurst)
+}
+
+// renderReminders renders the reminders page in state, one of form, pending, confirm,
+// confirmed, unsubscribe, unsubscribed, or invalid.
+func (app *App) renderReminders(c *gin.Context, status int, state string, data gin.H) {
+	csrfToken, _ := c.Cookie(app.cookieName(CSRFCookieName))
+	data["title"] = "Vortludo - Daily Reminders"
+	data["csrf_token"] = csrfToken
+	data["state"] = state
+	c.Header("Cache-Control", "no-store")
+	c.HTML(status, "reminders.html", data)
+}
+
+// remindersPageHandler shows the reminder sign-up form.
+func (app *App) remindersPageHandler(c *gin.Context) {
+	app.renderReminders(c, http.StatusOK, "form", gin.H{})
+}
+
+// subscribeHandler signs an address up for reminders and emails it a confirmation link. The
+// response is the same whether or not the address was already subscribed, so the form cannot
+// be used to find out who is.
+func (app *App) subscribeHandler(c *gin.Context) {
+	email, ok := parseEmail(c.PostForm("email"))
+	if !ok {
+		if wantsJSON(c) {
+			writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "enter a valid email address")
+			return
+		}
+		app.renderReminders(c, http.StatusBadRequest, "form", gin.H{"error": "enter a valid email address", "error_ref": errorRef(c), "email": c.PostForm("email")})
+		return
+	}
+	sub, send, err := app.Reminders.subscribe(email, app.now())
+	if err != nil {
+		status, code := http.StatusInternalServerError, ErrorCodeInternal
+		if errors.Is(err, errSubscribersFull) {
+			status, code = http.StatusServiceUnavailable, ErrorCodeQueueFull
+		} else {
+			logWarn("Failed to save reminder subscribers: %v", err)
+			err = errors.New("could not save your subscription, try again later")
+		}
+		if wantsJSON(c) {
+			writeProblem(c, status, code, err.Error())
+			return
+		}
+		app.renderReminders(c, status, "form", gin.H{"error": err.Error(), "error_ref": errorRef(c), "email": email})
+		return
+	}
+	if send {
+		if err := app.Reminders.sendConfirmation(c.Request.Context(), sub); err != nil {
+			app.incMetric(MetricReminderFailures)
+			logWarn("Failed to send reminder confirmation: %v", err)
+		}
+	}
+	if wantsJSON(c) {
+		c.JSON(http.StatusAccepted, gin.H{"status": SubscriberPending})
+		return
+	}
+	app.renderReminders(c, http.StatusAccepted, "pending", gin.H{})
+}
+
+// confirmPageHandler asks the subscriber to confirm. Confirming takes a POST so that mail
+// scanners following the link cannot complete the opt-in on their own.
+func (app *App) confirmPageHandler(c *gin.Context) {
+	app.renderReminders(c, http.StatusOK, "confirm", gin.H{"token": c.Param("token")})
+}
+
+// confirmSubscriptionHandler completes a subscription from its confirmation link.
+func (app *App) confirmSubscriptionHandler(c *gin.Context) {
+	ok, err := app.Reminders.confirm(c.Param("token"), app.now())
+	if err != nil {
+		logWarn("Failed to save reminder subscribers: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not confirm subscription")
+		return
+	}
+	if !ok {
+		app.renderReminders(c, http.StatusNotFound, "invalid", gin.H{})
+		return
+	}
+	app.renderReminders(c, http.StatusOK, "confirmed", gin.H{})
+}
+
+// unsubscribePageHandler asks the subscriber to confirm unsubscribing.
+func (app *App) unsubscribePageHandler(c *gin.Context) {
+	app.renderReminders(c, http.StatusOK, "unsubscribe", gin.H{"token": c.Param("token")})
+}
+
+// unsubscribeHandler removes a subscriber, from the unsubscribe page or a mail client's
+// one-click unsubscribe. An unknown token is treated as already unsubscribed.
+func (app *App) unsubscribeHandler(c *gin.Context) {
+	if _, err := app.Reminders.unsubscribe(c.Param("token")); err != nil {
+		logWarn("Failed to save reminder subscribers: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not unsubscribe")
+		return
+	}
+	app.renderReminders(c, http.StatusOK, "unsubscribed", gin.H{})
+}
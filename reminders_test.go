@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseEmail(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{" ana@example.com ", true},
+		{"ana@example", true},
+		{"Ana <ana@example.com>", false},
+		{"ana@example.com\r\nBcc: x@example.com", false},
+		{"not-an-address", false},
+		{strings.Repeat("a", 250) + "@x.io", false},
+	}
+	for _, tt := range tests {
+		if _, ok := parseEmail(tt.in); ok != tt.want {
+			t.Errorf("parseEmail(%q) = %v, want %v", tt.in, ok, tt.want)
+		}
+	}
+}
+
+func TestRemindersDoubleOptIn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var sent []string
+	mailer := newMailer("smtp.example", 587, "", "", "Vortludo <noreply@vortludo.example>", 4)
+	mailer.deliver = func(_ context.Context, to string, msg []byte) error {
+		sent = append(sent, to+"\n"+string(msg))
+		return nil
+	}
+	app := &App{GameSessions: make(map[string]*GameState), Daily: newDailySchedule("UTC", 0, false), Metrics: newMetrics()}
+	app.registerWordPacks([]*WordPack{newWordPack(DefaultPackName, []WordEntry{{Word: "CRANE", Hint: "a tall bird"}})}, nil, nil)
+	path := filepath.Join(t.TempDir(), "reminders.json")
+	app.Reminders = newReminders(path, mailer, "https://vortludo.example/")
+
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.POST(RouteReminders, app.subscribeHandler)
+	router.GET(RouteReminders+"/confirm/:token", app.confirmPageHandler)
+	router.POST(RouteReminders+"/confirm/:token", app.confirmSubscriptionHandler)
+	router.POST(RouteReminders+"/unsubscribe/:token", app.unsubscribeHandler)
+	do := func(method, path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("POST", RouteReminders, url.Values{"email": {"Ana <ana@example.com>"}}); w.Code != http.StatusBadRequest || len(sent) != 0 {
+		t.Fatalf("subscribe(display name) = %d with %d emails", w.Code, len(sent))
+	}
+	for range 2 {
+		if w := do("POST", RouteReminders, url.Values{"email": {"ana@example.com"}}); w.Code != http.StatusAccepted {
+			t.Fatalf("subscribe = %d: %s", w.Code, w.Body)
+		}
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d confirmation emails, want one within the resend cooldown", len(sent))
+	}
+	token := regexp.MustCompile(`/reminders/confirm/([0-9a-f]+)`).FindStringSubmatch(sent[0])
+	if token == nil {
+		t.Fatalf("confirmation email has no link: %s", sent[0])
+	}
+
+	if app.sendReminders(context.Background()) != 0 {
+		t.Error("Expected no reminders before the address is confirmed")
+	}
+	do("GET", RouteReminders+"/confirm/"+token[1], nil)
+	if app.Reminders.confirmed() != 0 {
+		t.Error("Expected opening the confirmation link to leave the subscription pending")
+	}
+	if w := do("POST", RouteReminders+"/confirm/nope", nil); w.Code != http.StatusNotFound {
+		t.Errorf("confirm(unknown) = %d, want 404", w.Code)
+	}
+	if w := do("POST", RouteReminders+"/confirm/"+token[1], nil); w.Code != http.StatusOK || app.Reminders.confirmed() != 1 {
+		t.Fatalf("confirm = %d with %d confirmed", w.Code, app.Reminders.confirmed())
+	}
+	if w := do("POST", RouteReminders, url.Values{"email": {"ANA@example.com"}}); w.Code != http.StatusAccepted || len(sent) != 1 {
+		t.Errorf("Expected subscribing a confirmed address to send nothing, got %d emails", len(sent))
+	}
+
+	if n := app.sendReminders(context.Background()); n != 1 {
+		t.Fatalf("sendReminders() = %d, want 1", n)
+	}
+	reminder := sent[len(sent)-1]
+	if !strings.Contains(reminder, "Hint: a tall bird") || strings.Contains(reminder, "CRANE") ||
+		!strings.Contains(reminder, "List-Unsubscribe: <https://vortludo.example/reminders/unsubscribe/"+token[1]+">") {
+		t.Errorf("reminder = %s", reminder)
+	}
+	if app.sendReminders(context.Background()) != 0 {
+		t.Error("Expected one reminder per puzzle date")
+	}
+
+	restored := newReminders(path, mailer, "https://vortludo.example")
+	if err := restored.load(); err != nil || restored.confirmed() != 1 || len(restored.due(app.Daily.puzzleDate(time.Now(), time.UTC).Format(time.DateOnly))) != 0 {
+		t.Errorf("Expected the subscriber and last sent date to be persisted, got %v", err)
+	}
+
+	if w := do("POST", RouteReminders+"/unsubscribe/"+token[1], url.Values{"List-Unsubscribe": {"One-Click"}}); w.Code != http.StatusOK || app.Reminders.confirmed() != 0 {
+		t.Errorf("unsubscribe = %d with %d confirmed", w.Code, app.Reminders.confirmed())
+	}
+}
+
+func TestMailerDailyLimit(t *testing.T) {
+	mailer := newMailer("smtp.example", 587, "", "", "noreply@vortludo.example", 2)
+	delivered := 0
+	mailer.deliver = func(context.Context, string, []byte) error {
+		delivered++
+		return nil
+	}
+	for range 3 {
+		mailer.send(context.Background(), "ana@example.com", "hi", "body", nil)
+	}
+	if delivered != 2 {
+		t.Errorf("delivered %d emails, want the daily limit of 2", delivered)
+	}
+	if err := mailer.send(context.Background(), "ana@example.com", "hi", "body", nil); err != errMailLimit {
+		t.Errorf("send() over the limit = %v, want errMailLimit", err)
+	}
+	if newMailer("", 587, "", "", "noreply@vortludo.example", 2) != nil {
+		t.Error("Expected email to be disabled without SMTP_HOST")
+	}
+}
+
+func TestReminderLinkOpensTheHintedPuzzle(t *testing.T) {
+	app := dailyTestApp(t)
+	app.Metrics = newMetrics()
+	app.Daily.UserTimezones = true
+	var sent []string
+	mailer := newMailer("smtp.example", 587, "", "", "noreply@vortludo.example", 4)
+	mailer.deliver = func(_ context.Context, _ string, msg []byte) error {
+		sent = append(sent, string(msg))
+		return nil
+	}
+	app.Reminders = newReminders("", mailer, "https://vortludo.example")
+	sub, _, err := app.Reminders.subscribe("ana@example.com", app.now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Reminders.confirm(sub.Token, app.now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Just after the UTC rollover it is still yesterday in Los Angeles, so the reader's own
+	// daily puzzle is not the one the reminder describes.
+	app.Clock.(*fakeClock).advance(14 * time.Hour)
+	today := app.Daily.puzzleDate(app.now(), time.UTC)
+	for date, word := range map[time.Time]string{today: "CRANE", today.AddDate(0, 0, -1): "SLATE", today.AddDate(0, 0, 1): "TRACE"} {
+		if err := app.Calendar.pin(date, word); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if app.sendReminders(context.Background()) != 1 {
+		t.Fatal("Expected one reminder")
+	}
+	hint := regexp.MustCompile(`Hint: (.+)`).FindStringSubmatch(sent[0])
+	link := regexp.MustCompile(`Play: https://vortludo.example(/\S+)`).FindStringSubmatch(sent[0])
+	if hint == nil || link == nil {
+		t.Fatalf("reminder = %s", sent[0])
+	}
+
+	router := gin.New()
+	router.GET(RouteDaily, app.dailyHandler)
+	open := func(path, sessionID string) *GameState {
+		req := httptest.NewRequest("GET", path, nil)
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: sessionID})
+		req.AddCookie(&http.Cookie{Name: TimezoneCookieName, Value: "America/Los_Angeles"})
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		return app.GameSessions[sessionID]
+	}
+	game := open(link[1], "session-aaa")
+	if game == nil || game.SessionWord != "CRANE" || app.gameHint(game) != strings.TrimSpace(hint[1]) {
+		t.Errorf("reminder link %s opened %+v, want the puzzle hinted as %q", link[1], game, hint[1])
+	}
+	if game := open(dailyLink(today.AddDate(0, 0, 1).Format(time.DateOnly)), "session-bbb"); game == nil || game.SessionWord != "SLATE" {
+		t.Errorf("link to an unreleased date opened %+v, want the reader's own puzzle", game)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Single-use token kinds, which keep equal token strings of different kinds apart.
+const (
+	TokenKindTransfer  = "transfer"
+	TokenKindMergeUndo = "merge-undo"
+	TokenKindCaptcha   = "captcha"
+)
+
+// captchaTokenTTL is how long a provider's CAPTCHA response stays valid, after which the
+// provider rejects it anyway.
+const captchaTokenTTL = 5 * time.Minute
+
+// UsedTokens remembers single-use tokens that have been spent until they expire, so a token
+// cannot be spent twice even by another session, after a restart, or on another replica
+// sharing the data directory. Only a hash of each token is kept.
+//
+// Storage has no compare-and-swap, so two replicas spending the same token in the same instant
+// can both succeed; each replica still spends a token at most once.
+type UsedTokens struct {
+	mu      sync.Mutex
+	storage Storage
+	path    string
+	used    map[string]time.Time
+}
+
+// newUsedTokens returns a used-token store persisted to path. An empty path keeps it in memory.
+func newUsedTokens(path string) *UsedTokens {
+	return &UsedTokens{storage: DirStorage{}, path: path, used: make(map[string]time.Time)}
+}
+
+// usedTokenKey is the stored key of a token of kind.
+func usedTokenKey(kind, token string) string {
+	sum := sha256.Sum256([]byte(kind + "\x00" + token))
+	return hex.EncodeToString(sum[:])
+}
+
+// refreshLocked merges in tokens spent by other replicas and drops every token past its
+// expiry. Callers must hold ut.mu.
+func (ut *UsedTokens) refreshLocked(now time.Time) error {
+	stored := make(map[string]time.Time)
+	_, err := readJSONFile(ut.storage, ut.path, &stored)
+	maps.Copy(ut.used, stored)
+	for key, expires := range ut.used {
+		if !now.Before(expires) {
+			delete(ut.used, key)
+		}
+	}
+	return err
+}
+
+// spend marks token of kind as used until expires and reports whether it was unused. A token
+// already spent is rejected until its expiry, after which the token itself is no longer valid.
+// The token counts as spent even when it cannot be persisted.
+func (ut *UsedTokens) spend(kind, token string, expires, now time.Time) (bool, error) {
+	if ut == nil {
+		return true, nil
+	}
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+	if err := ut.refreshLocked(now); err != nil {
+		logWarn("Failed to read used tokens: %v", err)
+	}
+	key := usedTokenKey(kind, token)
+	if _, spent := ut.used[key]; spent {
+		return false, nil
+	}
+	ut.used[key] = expires
+	return true, writeJSONFile(ut.storage, ut.path, ut.used)
+}
+
+// count returns the number of spent tokens not yet expired.
+func (ut *UsedTokens) count() int {
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+	return len(ut.used)
+}
+
+// spendToken spends a single-use token of kind, counting replays. A store that cannot be
+// written is logged but does not fail the request, since the token is still spent in memory.
+func (app *App) spendToken(kind, token string, expires time.Time) bool {
+	ok, err := app.UsedTokens.spend(kind, token, expires, app.now())
+	if err != nil {
+		logWarn("Failed to persist used %s token: %v", kind, err)
+	}
+	if !ok {
+		app.incMetric(MetricTokenReplays)
+		logWarn("Rejected replayed %s token", kind)
+	}
+	return ok
+}
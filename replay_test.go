@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUsedTokensSpendOnce(t *testing.T) {
+	store := newMemStorage()
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	ut := newUsedTokens("used-tokens.json")
+	ut.storage = store
+
+	if ok, err := ut.spend(TokenKindTransfer, "ABCD2345", now.Add(time.Minute), now); !ok || err != nil {
+		t.Fatalf("Expected first spend to succeed, got %v, %v", ok, err)
+	}
+	if ok, _ := ut.spend(TokenKindTransfer, "ABCD2345", now.Add(time.Minute), now); ok {
+		t.Error("Expected a replayed token to be rejected")
+	}
+	if ok, _ := ut.spend(TokenKindCaptcha, "ABCD2345", now.Add(time.Minute), now); !ok {
+		t.Error("Expected the same string of another kind to be unspent")
+	}
+
+	other := newUsedTokens("used-tokens.json")
+	other.storage = store
+	if ok, _ := other.spend(TokenKindTransfer, "ABCD2345", now.Add(time.Minute), now); ok {
+		t.Error("Expected a token spent by another replica to be rejected")
+	}
+
+	later := now.Add(2 * time.Minute)
+	if ok, _ := other.spend(TokenKindMergeUndo, "fresh", later.Add(time.Minute), later); !ok {
+		t.Fatal("Expected a new token to be spent")
+	}
+	if n := other.count(); n != 1 {
+		t.Errorf("Expected expired tokens to be cleaned up, %d left", n)
+	}
+	data, _ := store.ReadFile("used-tokens.json")
+	if len(data) == 0 || string(data) == "{}" {
+		t.Error("Expected spent tokens to be persisted")
+	}
+}
+
+func TestRedeemTransferRejectsSpentCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Transfers = newTransfers()
+	app.UsedTokens = newUsedTokens("")
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.POST(RouteTransfer+"/redeem", app.redeemTransferHandler)
+
+	code, err := app.Transfers.create("phone-session-1", "", app.now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !app.spendToken(TokenKindTransfer, code, app.now().Add(TransferTTL)) {
+		t.Fatal("Expected the code to be unspent")
+	}
+	req := httptest.NewRequest("POST", RouteTransfer+"/redeem", strings.NewReader(url.Values{"code": {strings.ToLower(code)}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("redeem of a spent code = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/internal/htmx"
+)
+
+// errorMessages maps error codes to user-facing messages sent alongside HX-Trigger error events.
+var errorMessages = map[string]string{
+	ErrorCodeGameOver:          "Game is already over! Start a new game!",
+	ErrorCodeInvalidLength:     "Word must be 5 letters long!",
+	ErrorCodeNoMoreGuesses:     "No more guesses allowed! Start a new game!",
+	ErrorCodeNotInWordList:     "Word not recognised!",
+	ErrorCodeWordNotAccepted:   "Word not accepted. Try another word!",
+	ErrorCodeDuplicateGuess:    "You already guessed that word!",
+	ErrorCodeGuessConflict:     "A teammate guessed first. Check the board and try again!",
+	ErrorCodeCoinsUnavailable:  "Coins can only be used in casual games.",
+	ErrorCodeInsufficientCoins: "Not enough coins! Win casual games to earn more.",
+	ErrorCodeNothingToBuy:      "There is nothing more to buy for this game.",
+	ErrorCodeOverloaded:        "The server is busy. Please try again in a moment!",
+}
+
+// errorMessage returns the user-facing message for an error code.
+func errorMessage(code string) string {
+	if msg, ok := errorMessages[code]; ok {
+		return msg
+	}
+	return "An unexpected error occurred."
+}
+
+// setErrorTrigger sets an HX-Trigger header describing an error code, its message, and the request ID.
+func setErrorTrigger(c *gin.Context, errCode string) {
+	Triggers{}.serverError(errCode, requestIDFrom(c.Request.Context())).set(c)
+}
+
+// renderGame renders the game as a fragment for HTMX requests or as the full page otherwise.
+// Extra template data is merged over the defaults.
+func (app *App) renderGame(c *gin.Context, game *GameState, hint string, extra gin.H) {
+	csrfToken, _ := c.Cookie(app.cookieName(CSRFCookieName))
+	data := gin.H{
+		"game":          game,
+		"hint":          hint,
+		"csrf_token":    csrfToken,
+		"challenge_url": app.challengeURL(game),
+		"experiments":   app.Experiments.assign(c.GetString(sessionContextKey)),
+		"daily_summary": app.dailySolveSummary(game),
+		"streak":        app.streakStatus(c, game),
+		"bonus_offered": app.Bonus.offered(c.GetString(sessionContextKey), game),
+		"coins":         app.coinsStatus(c, game),
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	if htmx.IsRequest(c.Request) || wantsJSONView(c) {
+		data["oob"] = true
+		renderFragment(c, "game-content", data)
+		return
+	}
+	data["wasm"] = app.WasmEnabled
+	data["packs"] = app.lists().PackNames
+	data["word_list_version"] = app.lists().WordListVersion
+	if mode := acceptedMode(game); app.lists().Overlays[mode] != nil {
+		data["accepted_mode"] = mode
+	}
+	data["cookie_prefix"] = app.cookieName("")
+	data["title"] = "Vortludo - A Libre Wordle Clone"
+	data["message"] = "Guess the 5-letter word!"
+	c.HTML(http.StatusOK, "index.html", data)
+}
+
+// renderGuessUpdate sends only the row that was just guessed, the new active row, and the
+// keyboard as HTMX out-of-band swaps, leaving the rest of the board untouched.
+func (app *App) renderGuessUpdate(c *gin.Context, game *GameState) {
+	htmx.Reswap(c.Writer.Header(), htmx.SwapNone)
+	c.HTML(http.StatusOK, "guess-update", gin.H{
+		"game":        game,
+		"previousRow": game.CurrentRow - 1,
+		"oob":         true,
+	})
+}
+
+// renderGameError renders the game with an error code and signals the error to HTMX clients.
+func (app *App) renderGameError(c *gin.Context, game *GameState, hint, errCode string) {
+	triggers := Triggers{}.serverError(errCode, requestIDFrom(c.Request.Context()))
+	triggers[TriggerServerErrorMessage] = gameErrorMessage(game, errCode)
+	triggers.set(c)
+	app.renderGame(c, game, hint, gin.H{"error_code": errCode})
+}
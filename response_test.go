@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/internal/htmx"
+)
+
+func TestErrorMessage(t *testing.T) {
+	if errorMessage(ErrorCodeDuplicateGuess) != errorMessages[ErrorCodeDuplicateGuess] {
+		t.Error("Expected message for known error code")
+	}
+	if errorMessage("something_else") != "An unexpected error occurred." {
+		t.Error("Expected fallback message for unknown error code")
+	}
+}
+
+func TestSetErrorTrigger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	req := httptest.NewRequest("POST", RouteGuess, nil)
+	c.Request = req.WithContext(context.WithValue(req.Context(), requestIDKey, "req-123"))
+
+	setErrorTrigger(c, ErrorCodeGameOver)
+
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(w.Header().Get("HX-Trigger")), &payload); err != nil {
+		t.Fatalf("HX-Trigger is not valid JSON: %v", err)
+	}
+	if payload["server_error_code"] != ErrorCodeGameOver {
+		t.Errorf("Expected code %q, got %q", ErrorCodeGameOver, payload["server_error_code"])
+	}
+	if payload["server_error_message"] != errorMessages[ErrorCodeGameOver] {
+		t.Errorf("Unexpected message %q", payload["server_error_message"])
+	}
+	if payload["request_id"] != "req-123" {
+		t.Errorf("Expected request_id req-123, got %q", payload["request_id"])
+	}
+}
+
+func TestIsHTMXRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", RouteGameState, nil)
+	if htmx.IsRequest(c.Request) {
+		t.Error("Expected non-HTMX request")
+	}
+	c.Request.Header.Set("HX-Request", "true")
+	if !htmx.IsRequest(c.Request) {
+		t.Error("Expected HTMX request")
+	}
+}
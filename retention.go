@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Retention constants
+const (
+	// DefaultHistoryGames is how many finished games are kept per player by default.
+	DefaultHistoryGames = 1000
+	// retentionInterval is how often the retention job runs.
+	retentionInterval = time.Hour
+	// statsMonthLayout keys the monthly totals daily global stats are rolled up into.
+	statsMonthLayout = "2006-01"
+)
+
+// RetentionPolicy is how long each kind of stored data is kept. The session sweep enforces
+// Sessions and the retention job enforces the rest, so each data type has a single setting that
+// both the cleanup code and the privacy page's data inventory read. A zero duration keeps that
+// data until it is deleted by hand.
+type RetentionPolicy struct {
+	// Sessions is how long an idle game session is kept, set by SESSION_TIMEOUT.
+	Sessions time.Duration
+	// HistoryGames caps the finished games kept per player, the oldest dropped first, set by
+	// RETENTION_HISTORY_GAMES.
+	HistoryGames int
+	// HistoryAge is how long finished games are kept, in memory or archived, set by
+	// RETENTION_HISTORY_DAYS. Deleted games no longer count toward the player's stats.
+	HistoryAge time.Duration
+	// DailyStats is how long global stats keep per-day totals before rolling them up into
+	// monthly totals, set by RETENTION_DAILY_STATS_DAYS.
+	DailyStats time.Duration
+	// Audit is how long audit log entries are kept, set by RETENTION_AUDIT_DAYS.
+	Audit time.Duration
+}
+
+// loadRetentionPolicy reads the retention policy from the environment.
+func loadRetentionPolicy() RetentionPolicy {
+	days := func(key string) time.Duration {
+		return time.Duration(max(getEnvInt(key, 0), 0)) * 24 * time.Hour
+	}
+	p := RetentionPolicy{
+		Sessions:     getEnvDuration("SESSION_TIMEOUT", DefaultSessionTimeout),
+		HistoryGames: getEnvInt("RETENTION_HISTORY_GAMES", DefaultHistoryGames),
+		HistoryAge:   days("RETENTION_HISTORY_DAYS"),
+		DailyStats:   days("RETENTION_DAILY_STATS_DAYS"),
+		Audit:        days("RETENTION_AUDIT_DAYS"),
+	}
+	if p.HistoryGames < 1 {
+		logWarn("RETENTION_HISTORY_GAMES must be at least 1, using %d", DefaultHistoryGames)
+		p.HistoryGames = DefaultHistoryGames
+	}
+	return p
+}
+
+// warnings returns operator warnings about settings that undercut other features.
+func (p RetentionPolicy) warnings() []string {
+	var warnings []string
+	if p.DailyStats > 0 && p.DailyStats < maxStatsDays*24*time.Hour {
+		warnings = append(warnings, fmt.Sprintf("RETENTION_DAILY_STATS_DAYS (%s) is shorter than the %d days global stats can show; older days will show no games", retentionText(p.DailyStats), maxStatsDays))
+	}
+	return warnings
+}
+
+// scheduled reports whether any part of the policy needs the retention job.
+func (p RetentionPolicy) scheduled() bool {
+	return p.HistoryAge > 0 || p.DailyStats > 0 || p.Audit > 0
+}
+
+// runRetention applies the retention policy every interval for the life of the process.
+func (app *App) runRetention(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		app.applyRetention()
+	}
+}
+
+// applyRetention deletes finished games, rolls up daily global stats, and prunes audit log
+// entries that are past the retention policy, and counts what it removed.
+func (app *App) applyRetention() {
+	now := app.now().UTC()
+	p := app.Retention
+	if p.HistoryAge > 0 && app.Players != nil {
+		players, games, err := app.Players.expireBefore(now.Add(-p.HistoryAge))
+		if err != nil {
+			logWarn("Failed to delete some expired games: %v", err)
+		}
+		if games > 0 {
+			app.addMetric(MetricRetentionDeleted, games)
+			logInfo("Deleted %d finished games of %d players past RETENTION_HISTORY_DAYS", games, players)
+		}
+	}
+	if p.DailyStats > 0 && app.Stats != nil {
+		cutoff := app.Daily.puzzleDate(now, app.Daily.Location).Add(-p.DailyStats)
+		if days := app.Stats.rollUpBefore(cutoff.Format(time.DateOnly)); days > 0 {
+			app.addMetric(MetricRetentionDeleted, days)
+			logInfo("Rolled %d days of global stats up into monthly totals", days)
+		}
+	}
+	if p.Audit > 0 && app.Audit != nil {
+		n, err := app.Audit.pruneBefore(now.Add(-p.Audit))
+		if err != nil {
+			logWarn("Failed to prune the audit log: %v", err)
+		}
+		if n > 0 {
+			app.addMetric(MetricRetentionDeleted, n)
+			logInfo("Pruned %d audit log entries past RETENTION_AUDIT_DAYS", n)
+		}
+	}
+}
+
+// expireBefore deletes every player's games finished before cutoff, from memory and from the
+// archive. Unlike archiving, nothing is carried over, so the player's stats then cover only the
+// games kept.
+func (ps *PlayerStatsStore) expireBefore(cutoff time.Time) (players, games int, err error) {
+	keys := make(map[string]bool)
+	ps.mu.RLock()
+	for key, history := range ps.players {
+		if len(history) > 0 && history[0].FinishedAt.Before(cutoff) {
+			keys[key] = true
+		}
+	}
+	for key, carry := range ps.carry {
+		if carry.First.Before(cutoff) {
+			keys[key] = true
+		}
+	}
+	ps.mu.RUnlock()
+
+	for key := range keys {
+		n, keyErr := ps.expireKey(key, cutoff)
+		if keyErr != nil {
+			err = errors.Join(err, keyErr)
+			continue
+		}
+		if n > 0 {
+			players++
+			games += n
+		}
+	}
+	return players, games, err
+}
+
+// expireKey deletes one player's games finished before cutoff. Archived games are older than
+// any in memory, so the archive is only read when its oldest game is past the cutoff, and the
+// carried totals are rebuilt from what is left of it.
+func (ps *PlayerStatsStore) expireKey(key string, cutoff time.Time) (int, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	n := 0
+	if carry, ok := ps.carry[key]; ok && carry.First.Before(cutoff) {
+		kept, dropped, err := ps.archive.dropBefore(key, cutoff)
+		if err != nil {
+			return 0, err
+		}
+		var rebuilt statsCarry
+		for _, rec := range kept {
+			rebuilt.add(rec, ps.streaks)
+		}
+		if len(kept) == 0 {
+			delete(ps.carry, key)
+		} else {
+			ps.carry[key] = rebuilt
+		}
+		n += dropped
+		ps.dirty = true
+	}
+	history := ps.players[key]
+	i := 0
+	for i < len(history) && history[i].FinishedAt.Before(cutoff) {
+		i++
+	}
+	switch {
+	case i == 0:
+	case i == len(history):
+		delete(ps.players, key)
+		ps.dirty = true
+	default:
+		ps.players[key] = slices.Clone(history[i:])
+		ps.dirty = true
+	}
+	return n + i, nil
+}
+
+// rollUpBefore folds the per-day aggregates of puzzle dates before cutoff, YYYY-MM-DD, into
+// monthly aggregates keyed YYYY-MM, and returns how many days were folded.
+func (gs *GlobalStats) rollUpBefore(cutoff string) int {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	n := 0
+	for date, day := range gs.days {
+		if len(date) != len(time.DateOnly) || date >= cutoff {
+			continue
+		}
+		month := date[:len(statsMonthLayout)]
+		agg, ok := gs.days[month]
+		if !ok {
+			agg = &dailyAggregate{Date: month}
+			gs.days[month] = agg
+		}
+		agg.add(*day)
+		delete(gs.days, date)
+		n++
+	}
+	if n > 0 {
+		gs.dirty = true
+	}
+	return n
+}
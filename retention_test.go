@@ -0,0 +1,173 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadRetentionPolicy(t *testing.T) {
+	t.Setenv("SESSION_TIMEOUT", "2h")
+	t.Setenv("RETENTION_HISTORY_GAMES", "0")
+	t.Setenv("RETENTION_HISTORY_DAYS", "30")
+	t.Setenv("RETENTION_DAILY_STATS_DAYS", "90")
+	t.Setenv("RETENTION_AUDIT_DAYS", "-5")
+
+	p := loadRetentionPolicy()
+	want := RetentionPolicy{
+		Sessions:     2 * time.Hour,
+		HistoryGames: DefaultHistoryGames,
+		HistoryAge:   30 * 24 * time.Hour,
+		DailyStats:   90 * 24 * time.Hour,
+	}
+	if p != want {
+		t.Errorf("policy = %+v, want %+v", p, want)
+	}
+	if !p.scheduled() {
+		t.Error("Expected the retention job to be needed")
+	}
+	if len(p.warnings()) != 1 {
+		t.Errorf("warnings = %v, want one about daily stats shorter than the stats window", p.warnings())
+	}
+	if (RetentionPolicy{Sessions: time.Hour}).scheduled() {
+		t.Error("Expected no retention job when only sessions expire")
+	}
+}
+
+func TestHistoryCap(t *testing.T) {
+	ps := newPlayerStatsStore(StreakFreezeRules{})
+	ps.maxHistory = 2
+	for _, word := range []string{"CRANE", "SLATE", "PLANT"} {
+		ps.record("player", GameRecord{Word: word})
+	}
+	history, _, _ := ps.summary("player", "")
+	if len(history) != 2 || history[0].Word != "SLATE" {
+		t.Errorf("history = %+v, want the two latest games", history)
+	}
+}
+
+func TestExpireHistoryFromMemoryAndArchive(t *testing.T) {
+	ps := newPlayerStatsStore(StreakFreezeRules{})
+	ps.archive = newPlayerArchive("archive", 30*24*time.Hour)
+	ps.archive.storage = newMemStorage()
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return now.AddDate(0, 0, n) }
+	for _, rec := range []GameRecord{
+		{FinishedAt: day(-100), Word: "CRANE", Won: true, Guesses: 3},
+		{FinishedAt: day(-60), Word: "SLATE", Won: true, Guesses: 4},
+		{FinishedAt: day(-5), Word: "TIGER", Won: false, Guesses: MaxGuesses},
+		{FinishedAt: day(-1), Word: "PLANT", Won: true, Guesses: 2},
+	} {
+		ps.record("player", rec)
+	}
+	ps.record("recent", GameRecord{FinishedAt: day(-1), Word: "PLANT", Won: true, Guesses: 2})
+	if _, games, err := ps.archiveBefore(day(-30)); err != nil || games != 2 {
+		t.Fatalf("archived %d games: %v", games, err)
+	}
+
+	players, games, err := ps.expireBefore(day(-90))
+	if err != nil || players != 1 || games != 1 {
+		t.Fatalf("expired %d games of %d players: %v", games, players, err)
+	}
+	archived, _ := ps.archive.load("player")
+	if len(archived) != 1 || archived[0].Word != "SLATE" {
+		t.Errorf("archive = %+v, want only the game inside the retention period", archived)
+	}
+	if _, _, s := ps.summary("player", ""); s.Played != 3 {
+		t.Errorf("played = %d, want 3 once the expired game no longer counts", s.Played)
+	}
+
+	if _, games, err := ps.expireBefore(day(-3)); err != nil || games != 2 {
+		t.Fatalf("expired %d games: %v", games, err)
+	}
+	history, _, s := ps.summary("player", "")
+	if len(history) != 1 || s.Played != 1 {
+		t.Errorf("history = %+v, played = %d, want only the latest game", history, s.Played)
+	}
+	if _, ok := ps.carry["player"]; ok {
+		t.Error("Expected no carried totals once the archive is empty")
+	}
+	if history, _, _ := ps.summary("recent", ""); len(history) != 1 {
+		t.Errorf("recent history = %+v, want it untouched", history)
+	}
+}
+
+func TestRollUpDailyStats(t *testing.T) {
+	gs := newGlobalStats("stats.json")
+	gs.storage = newMemStorage()
+	for _, o := range []gameOutcome{
+		{Date: "2030-01-05", Won: true, Guesses: 3},
+		{Date: "2030-01-20", Won: false},
+		{Date: "2030-02-10", Won: true, Guesses: 4},
+		{Date: "2030-03-01", Won: true, Guesses: 2},
+	} {
+		gs.apply(o)
+	}
+	if n := gs.rollUpBefore("2030-03-01"); n != 3 {
+		t.Fatalf("rolled up %d days, want 3", n)
+	}
+	if err := gs.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := newGlobalStats("stats.json")
+	loaded.storage = gs.storage
+	if err := loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	jan := loaded.days["2030-01"]
+	if jan == nil || jan.Played != 2 || jan.Won != 1 || jan.Distribution[2] != 1 {
+		t.Errorf("January = %+v", jan)
+	}
+	if loaded.days["2030-02"] == nil || loaded.days["2030-01-05"] != nil {
+		t.Error("Expected days before the cutoff to be replaced by their month")
+	}
+	if day := loaded.day("2030-03-01"); day.Played != 1 {
+		t.Errorf("2030-03-01 = %+v, want the day kept", day)
+	}
+}
+
+func TestAuditPruneKeepsChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	al, err := openAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		al.record(AuditEntry{Time: now.AddDate(0, 0, i*10), Actor: "admin", Action: "POST /admin/daily/roll"})
+	}
+
+	if n, err := al.pruneBefore(now.AddDate(0, 0, 15)); err != nil || n != 2 {
+		t.Fatalf("pruned %d entries: %v", n, err)
+	}
+	al.record(AuditEntry{Time: now.AddDate(0, 0, 40), Actor: "admin", Action: "POST /admin/blocklist"})
+	entries, _ := al.entries()
+	if len(entries) != 4 || entries[0].Action != AuditActionPruned {
+		t.Fatalf("entries = %+v, want a checkpoint and three entries", entries)
+	}
+	if broken := verifyAuditChain(entries); broken != -1 {
+		t.Errorf("Expected the chain to verify after pruning, broken at %d", broken)
+	}
+
+	// Pruning everything leaves just a checkpoint, which a reopened log keeps chaining from.
+	if n, err := al.pruneBefore(now.AddDate(0, 0, 50)); err != nil || n != 3 {
+		t.Fatalf("pruned %d entries: %v", n, err)
+	}
+	al.close()
+	al, err = openAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer al.close()
+	al.record(AuditEntry{Time: now.AddDate(0, 0, 60), Actor: "admin", Action: "POST /admin/daily/roll"})
+	entries, _ = al.entries()
+	if len(entries) != 2 || verifyAuditChain(entries) != -1 {
+		t.Errorf("entries = %+v, want an intact chain from the checkpoint", entries)
+	}
+
+	entries[1].Actor = "someone else"
+	if broken := verifyAuditChain(entries); broken != 1 {
+		t.Errorf("Expected an edited entry after a checkpoint to break the chain, got %d", broken)
+	}
+}
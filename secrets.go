@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Secret loading constants
+const (
+	// DefaultSecretsDir is where Docker and Kubernetes mount secrets by convention.
+	DefaultSecretsDir = "/run/secrets"
+	// vaultPrefix marks a setting whose value is a Vault reference, "vault:<path>#<field>".
+	vaultPrefix = "vault:"
+	// vaultTimeout bounds each Vault read at startup.
+	vaultTimeout = 10 * time.Second
+	// maxSecretFileSize guards against pointing a *_FILE setting at something that is not a secret.
+	maxSecretFileSize = 64 * 1024
+)
+
+// SecretLoader resolves sensitive settings such as ADMIN_TOKEN from, in order:
+//   - the file named by KEY_FILE;
+//   - KEY itself, either as a plain value or as a Vault reference "vault:<path>#<field>";
+//   - a file named after the key, lowercased, in Dir (/run/secrets by default), which is how
+//     Docker and Kubernetes secrets are mounted.
+//
+// Vault is read over its HTTP API with VAULT_ADDR and VAULT_TOKEN (or VAULT_TOKEN_FILE), from a
+// KV version 2 or version 1 secrets engine. Secret values are never logged.
+type SecretLoader struct {
+	Dir            string
+	VaultAddr      string
+	VaultToken     string
+	VaultNamespace string
+	client         *http.Client
+	getenv         func(string) string
+}
+
+// newSecretLoader returns a loader configured from SECRETS_DIR and the VAULT_* settings.
+func newSecretLoader() (*SecretLoader, error) {
+	sl := &SecretLoader{
+		Dir:            getEnvString("SECRETS_DIR", DefaultSecretsDir),
+		VaultAddr:      strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/"),
+		VaultNamespace: os.Getenv("VAULT_NAMESPACE"),
+		client:         &http.Client{Timeout: vaultTimeout},
+		getenv:         os.Getenv,
+	}
+	token, err := sl.local("VAULT_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+	sl.VaultToken = token
+	return sl, nil
+}
+
+// load returns the secret setting key, or an empty string when it is not set anywhere. A
+// configured source that cannot be read is an error, so a broken mount or Vault outage fails
+// startup instead of silently running without the secret.
+func (sl *SecretLoader) load(ctx context.Context, key string) (string, error) {
+	value, err := sl.local(key)
+	if err != nil || !strings.HasPrefix(value, vaultPrefix) {
+		return value, err
+	}
+	return sl.vault(ctx, key, strings.TrimPrefix(value, vaultPrefix))
+}
+
+// local resolves key from KEY_FILE, KEY, or the secrets directory, without following Vault
+// references.
+func (sl *SecretLoader) local(key string) (string, error) {
+	if path := sl.getenv(key + "_FILE"); path != "" {
+		value, err := readSecretFile(path)
+		if err != nil {
+			return "", fmt.Errorf("%s_FILE: %w", key, err)
+		}
+		return value, nil
+	}
+	if value := sl.getenv(key); value != "" {
+		return value, nil
+	}
+	if sl.Dir == "" {
+		return "", nil
+	}
+	value, err := readSecretFile(filepath.Join(sl.Dir, strings.ToLower(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s in %s: %w", key, sl.Dir, err)
+	}
+	return value, nil
+}
+
+// readSecretFile reads a secret from a file, dropping the trailing newline editors and
+// "echo" leave behind.
+func readSecretFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() || info.Size() > maxSecretFileSize {
+		return "", fmt.Errorf("%s is not a secret file", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// vault reads ref, "<path>#<field>", from Vault. The path is the API path under /v1/, such as
+// "secret/data/vortludo" for a KV version 2 engine mounted at secret/.
+func (sl *SecretLoader) vault(ctx context.Context, key, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("%s: Vault reference must look like vault:<path>#<field>", key)
+	}
+	if sl.VaultAddr == "" || sl.VaultToken == "" {
+		return "", fmt.Errorf("%s: VAULT_ADDR and VAULT_TOKEN are required for Vault references", key)
+	}
+	ctx, cancel := context.WithTimeout(ctx, vaultTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sl.VaultAddr+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+	req.Header.Set("X-Vault-Token", sl.VaultToken)
+	if sl.VaultNamespace != "" {
+		req.Header.Set("X-Vault-Namespace", sl.VaultNamespace)
+	}
+	resp, err := sl.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: read from Vault: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: Vault returned %s for %s", key, resp.Status, path)
+	}
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("%s: decode Vault response: %w", key, err)
+	}
+	// KV version 2 nests the secret's fields under data.data; version 1 has them under data.
+	data := body.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		data = nested
+	}
+	value, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("%s: Vault secret %s has no string field %q", key, path, field)
+	}
+	return value, nil
+}
+
+// loadSecrets resolves each secret setting in keys with sl, stopping at the first error.
+func (sl *SecretLoader) loadSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	secrets := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value, err := sl.load(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		secrets[key] = value
+	}
+	return secrets, nil
+}
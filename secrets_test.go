@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretLoaderSources(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "token.txt"), []byte("from-file\n"), 0o600)
+	os.WriteFile(filepath.Join(dir, "captcha_secret"), []byte("from-dir"), 0o600)
+	env := map[string]string{
+		"ADMIN_TOKEN_FILE": filepath.Join(dir, "token.txt"),
+		"ADMIN_TOKEN":      "ignored",
+		"SMTP_PASSWORD":    "plain",
+		"PROGRESS_SECRET":  "vault:secret/data/vortludo#progress",
+		"FLEET_REDIS_URL":  "vault:kv/vortludo#redis",
+		"MISSING_FILE":     filepath.Join(dir, "nope"),
+	}
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/vortludo":
+			w.Write([]byte(`{"data":{"data":{"progress":"from-vault-v2"},"metadata":{"version":3}}}`))
+		case "/v1/kv/vortludo":
+			w.Write([]byte(`{"data":{"redis":"redis://from-vault-v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer vault.Close()
+	sl := &SecretLoader{Dir: dir, VaultAddr: vault.URL, VaultToken: "root", client: vault.Client(), getenv: func(k string) string { return env[k] }}
+
+	secrets, err := sl.loadSecrets(context.Background(), "ADMIN_TOKEN", "CAPTCHA_SECRET", "SMTP_PASSWORD", "PROGRESS_SECRET", "FLEET_REDIS_URL", "UNSET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"ADMIN_TOKEN":     "from-file",
+		"CAPTCHA_SECRET":  "from-dir",
+		"SMTP_PASSWORD":   "plain",
+		"PROGRESS_SECRET": "from-vault-v2",
+		"FLEET_REDIS_URL": "redis://from-vault-v1",
+		"UNSET":           "",
+	}
+	for key, value := range want {
+		if secrets[key] != value {
+			t.Errorf("%s = %q, want %q", key, secrets[key], value)
+		}
+	}
+
+	if _, err := sl.load(context.Background(), "MISSING"); err == nil {
+		t.Error("Expected a missing *_FILE to be an error")
+	}
+	env["BAD"] = "vault:secret/data/vortludo#absent"
+	if _, err := sl.load(context.Background(), "BAD"); err == nil {
+		t.Error("Expected a missing Vault field to be an error")
+	}
+	sl.VaultToken = "wrong"
+	if _, err := sl.load(context.Background(), "PROGRESS_SECRET"); err == nil {
+		t.Error("Expected a rejected Vault token to be an error")
+	}
+}
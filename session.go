@@ -2,48 +2,217 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// getOrCreateSession retrieves the session ID from the cookie or creates a new one.
+// sessionContextKey caches the request's session ID in the Gin context.
+const sessionContextKey = "session_id"
+
+// getOrCreateSession retrieves the session ID from the cookie or creates a new one. The cookie
+// is reissued on every call so its lifetime slides with activity; the server-side session
+// slides with it when getGameState records the access.
 func (app *App) getOrCreateSession(c *gin.Context) string {
-	sessionID, err := c.Cookie(SessionCookieName)
+	sessionID, err := c.Cookie(app.cookieName(SessionCookieName))
 	if err != nil || len(sessionID) < 10 {
 		sessionID = uuid.NewString()
-		c.SetSameSite(http.SameSiteStrictMode)
-		secure := app.IsProduction
-		c.SetCookie(SessionCookieName, sessionID, int(app.CookieMaxAge.Seconds()), "/", "", secure, true)
-		logInfo("Created new session: %s", sessionID)
+		app.incMetric(MetricSessionsCreated)
+		logInfo("Created new session: %s", redactSession(sessionID))
 	}
+	c.Set(sessionContextKey, sessionID)
+	app.setSessionCookie(c, sessionID)
+	app.rememberPlayer(c, sessionID)
 	return sessionID
 }
 
+// setSessionCookie issues the session cookie with a full CookieMaxAge.
+func (app *App) setSessionCookie(c *gin.Context, sessionID string) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(app.cookieName(SessionCookieName), sessionID, int(app.CookieMaxAge.Seconds()), "/", "", app.IsProduction, true)
+}
+
 // getGameState retrieves or creates the GameState for a session.
 func (app *App) getGameState(ctx context.Context, sessionID string) *GameState {
-	app.SessionMutex.RLock()
+	now := app.now()
+	app.SessionMutex.Lock()
 	game, exists := app.GameSessions[sessionID]
-	app.SessionMutex.RUnlock()
+	if exists && app.sessionExpired(game, now) {
+		app.Quarantine.add(sessionID, game, "expired", now)
+		delete(app.GameSessions, sessionID)
+		app.incMetric(MetricSessionsExpired)
+		exists = false
+	}
 	if exists {
-		app.SessionMutex.Lock()
-		game.LastAccessTime = time.Now()
-		app.SessionMutex.Unlock()
-		logInfo("Retrieved cached game state for session: %s, updated last access time.", sessionID)
+		game.LastAccessTime = now
+	}
+	app.SessionMutex.Unlock()
+	if exists {
+		logDebug("Retrieved cached game state for session: %s, updated last access time.", redactSession(sessionID))
 		return game
 	}
 
-	logInfo("Creating new game for session: %s", sessionID)
+	if game := app.restoreFromJournal(sessionID); game != nil {
+		return game
+	}
+	logDebug("Creating new game for session: %s", redactSession(sessionID))
 	return app.createNewGame(ctx, sessionID)
 }
 
-// saveGameState updates the in-memory game state for a session.
+// activeSessionCount returns the number of sessions currently held in memory.
+func (app *App) activeSessionCount() int {
+	app.SessionMutex.RLock()
+	defer app.SessionMutex.RUnlock()
+	return len(app.GameSessions)
+}
+
+// saveGameState updates the in-memory game state for a session, making room first when it is new.
 func (app *App) saveGameState(sessionID string, game *GameState) {
 	app.SessionMutex.Lock()
+	if _, exists := app.GameSessions[sessionID]; !exists {
+		app.evictIdleSessionsLocked()
+	}
 	app.GameSessions[sessionID] = game
-	game.LastAccessTime = time.Now()
+	game.LastAccessTime = app.now()
 	app.SessionMutex.Unlock()
-	logInfo("Updated in-memory game state for session: %s", sessionID)
+	logDebug("Updated in-memory game state for session: %s", redactSession(sessionID))
+}
+
+// evictIdleSessionsLocked drops the least recently used sessions once the store reaches
+// MaxSessions, down to 90% of it, so a burst of new visitors cannot exhaust memory on a small
+// host. Evicted sessions are quarantined so an operator can restore them. Callers must hold
+// SessionMutex for writing.
+func (app *App) evictIdleSessionsLocked() {
+	if app.MaxSessions <= 0 || len(app.GameSessions) < app.MaxSessions {
+		return
+	}
+	keep := app.MaxSessions * 9 / 10
+	ids := slices.SortedFunc(maps.Keys(app.GameSessions), func(a, b string) int {
+		return app.GameSessions[a].LastAccessTime.Compare(app.GameSessions[b].LastAccessTime)
+	})
+	evict := ids[:len(ids)-keep]
+	now := app.now()
+	for _, id := range evict {
+		app.Quarantine.add(id, app.GameSessions[id], "capacity", now)
+		delete(app.GameSessions, id)
+	}
+	if app.Metrics != nil {
+		app.Metrics.Add(MetricSessionsEvicted, int64(len(evict)))
+	}
+	logWarn("Session store reached %d sessions; evicted %d idle sessions", app.MaxSessions, len(evict))
+}
+
+// sessionExpired reports whether a session has been idle longer than the session retention.
+func (app *App) sessionExpired(game *GameState, now time.Time) bool {
+	return app.Retention.Sessions > 0 && now.Sub(game.LastAccessTime) > app.Retention.Sessions
+}
+
+// expireIdleSessions drops sessions idle longer than the session retention, quarantining them, and
+// returns how many were dropped.
+func (app *App) expireIdleSessions() int {
+	if app.Retention.Sessions <= 0 {
+		return 0
+	}
+	expired := 0
+	for _, id := range app.idleSessionIDs(app.Retention.Sessions) {
+		if app.expireSession(id, app.Retention.Sessions) {
+			expired++
+		}
+	}
+	return expired
+}
+
+// idleSessionIDs returns the sessions idle longer than maxAge.
+func (app *App) idleSessionIDs(maxAge time.Duration) []string {
+	now := app.now()
+	app.SessionMutex.RLock()
+	defer app.SessionMutex.RUnlock()
+	var ids []string
+	for id, game := range app.GameSessions {
+		if now.Sub(game.LastAccessTime) > maxAge {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// expireSession quarantines and drops a session if it is still idle longer than maxAge.
+func (app *App) expireSession(sessionID string, maxAge time.Duration) bool {
+	now := app.now()
+	app.SessionMutex.Lock()
+	defer app.SessionMutex.Unlock()
+	game, ok := app.GameSessions[sessionID]
+	if !ok || now.Sub(game.LastAccessTime) <= maxAge {
+		return false
+	}
+	app.Quarantine.add(sessionID, game, "expired", now)
+	delete(app.GameSessions, sessionID)
+	app.incMetric(MetricSessionsExpired)
+	return true
+}
+
+// sweepSessions expires idle sessions, abandoned co-op rooms, and stale abuse strikes every
+// interval for the life of the process.
+func (app *App) sweepSessions(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if n := app.expireIdleSessions(); n > 0 {
+			logInfo("Expired %d idle sessions", n)
+		}
+		if n := app.pruneRooms(); n > 0 {
+			logInfo("Closed %d abandoned co-op rooms", n)
+		}
+		app.Words.prune(app.wordVersionsInUse())
+		if app.Blocklist != nil {
+			app.Blocklist.prune()
+		}
+		if !app.Leases.leader(JobJournalCleanup) {
+			continue
+		}
+		if n := app.Journal.prune(time.Now().Add(-app.Retention.Sessions)); n > 0 {
+			logInfo("Pruned %d session journals", n)
+		}
+	}
+}
+
+// adminCleanupHandler runs the scheduled session sweep now as the cleanup operation,
+// expiring sessions and pruning journals idle longer than max-age (default SESSION_TIMEOUT).
+func (app *App) adminCleanupHandler(c *gin.Context) {
+	maxAge := app.Retention.Sessions
+	if raw := c.Query("max-age"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "max-age must be a duration such as 24h")
+			return
+		}
+		maxAge = d
+	}
+	if maxAge <= 0 {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "max-age must be positive")
+		return
+	}
+	runDestructiveHandler(c, app.cleanupOp(maxAge))
+}
+
+// sessionLifetimeWarnings checks that the session cookie and the server-side session last
+// about as long as each other. A cookie that outlives its session brings players back to a
+// reset board; a session that outlives its cookie holds state nobody can reach.
+func sessionLifetimeWarnings(cookieMaxAge, sessionTimeout time.Duration) []string {
+	var warnings []string
+	if sessionTimeout <= 0 {
+		return append(warnings, "SESSION_TIMEOUT is not positive; idle sessions are only dropped at MAX_SESSIONS")
+	}
+	if cookieMaxAge > sessionTimeout {
+		warnings = append(warnings, fmt.Sprintf("COOKIE_MAX_AGE (%v) outlives SESSION_TIMEOUT (%v); returning players may find their game reset", cookieMaxAge, sessionTimeout))
+	}
+	if cookieMaxAge < sessionTimeout {
+		warnings = append(warnings, fmt.Sprintf("SESSION_TIMEOUT (%v) outlives COOKIE_MAX_AGE (%v); sessions are kept after their cookie is gone", sessionTimeout, cookieMaxAge))
+	}
+	return warnings
 }
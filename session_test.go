@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestSaveGameStateEvictsIdleSessions(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Metrics = newMetrics()
+	app.MaxSessions = 10
+	clock := newFakeClock()
+	app.Clock = clock
+	for i := range 10 {
+		app.saveGameState("session-"+strconv.Itoa(i), newGame("CRANE"))
+		clock.advance(time.Second)
+	}
+
+	app.saveGameState("session-0", newGame("CRANE"))
+	if len(app.GameSessions) != 10 {
+		t.Fatalf("Updating an existing session should not evict, got %d sessions", len(app.GameSessions))
+	}
+
+	app.saveGameState("newcomer", newGame("CRANE"))
+	if len(app.GameSessions) != 10 {
+		t.Errorf("Expected 9 kept plus the newcomer, got %d sessions", len(app.GameSessions))
+	}
+	for _, id := range []string{"session-0", "newcomer", "session-9"} {
+		if _, ok := app.GameSessions[id]; !ok {
+			t.Errorf("Expected recently used session %s to be kept", id)
+		}
+	}
+	if _, ok := app.GameSessions["session-1"]; ok {
+		t.Error("Expected the least recently used session to be evicted")
+	}
+	if got := app.Metrics.Get(MetricSessionsEvicted); got == nil || got.String() != "1" {
+		t.Errorf("Expected sessions_evicted to be 1, got %v", got)
+	}
+}
+
+func TestIdleSessionsExpire(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Metrics = newMetrics()
+	app.Retention.Sessions = time.Hour
+	app.Quarantine = newSessionQuarantine(time.Hour, 10)
+	clock := newFakeClock()
+	app.Clock = clock
+	ctx := context.Background()
+
+	first := app.getGameState(ctx, "expiring-session")
+	clock.advance(59 * time.Minute)
+	if app.getGameState(ctx, "expiring-session") != first {
+		t.Fatal("Expected an active session to be kept")
+	}
+	clock.advance(61 * time.Minute)
+	if app.getGameState(ctx, "expiring-session") == first {
+		t.Error("Expected a new game after the session timed out")
+	}
+	if _, total := app.Quarantine.list(clock.Now()); total != 1 {
+		t.Error("Expected the expired session to be quarantined")
+	}
+
+	app.saveGameState("idle-session", newGame("CRANE"))
+	clock.advance(2 * time.Hour)
+	app.saveGameState("fresh-session", newGame("CRANE"))
+	if n := app.expireIdleSessions(); n != 2 {
+		t.Errorf("Expected the two idle sessions to expire, got %d", n)
+	}
+	if _, ok := app.GameSessions["fresh-session"]; !ok || len(app.GameSessions) != 1 {
+		t.Errorf("Expected only fresh-session to remain, got %d sessions", len(app.GameSessions))
+	}
+}
+
+func TestAdminCleanupHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Retention.Sessions = 24 * time.Hour
+	app.Quarantine = newSessionQuarantine(time.Hour, 10)
+	app.Journal = newSessionJournal(t.TempDir())
+	clock := newFakeClock()
+	app.Clock = clock
+	app.saveGameState("idle-session", newGame("CRANE"))
+	app.Journal.start(dummyContext(), "idle-session", &GameState{GameState: engine.GameState{SessionWord: "CRANE"}})
+	old := time.Now().Add(-3 * time.Hour)
+	if err := os.Chtimes(app.Journal.path("idle-session"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(3 * time.Hour)
+	app.saveGameState("fresh-session", newGame("CRANE"))
+
+	router := gin.New()
+	router.POST("/admin/cleanup", app.adminCleanupHandler)
+	cleanup := func(query string) (int, OpReport) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/admin/cleanup"+query, nil))
+		var report OpReport
+		json.Unmarshal(w.Body.Bytes(), &report)
+		return w.Code, report
+	}
+	both := map[string]int{"session": 1, "journal": 1}
+
+	if code, _ := cleanup("?max-age=soon"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad max-age, got %d", code)
+	}
+	if code, _ := cleanup("?max-age=2h&dry-run=maybe"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad dry-run, got %d", code)
+	}
+	if code, _ := cleanup("?max-age=2h"); code != http.StatusPreconditionRequired || len(app.GameSessions) != 2 {
+		t.Errorf("Expected 428 and no change without confirm, got %d", code)
+	}
+	if _, report := cleanup("?dry-run=true"); len(report.Candidates) != 0 {
+		t.Errorf("Default max-age report = %+v, want SESSION_TIMEOUT and no candidates", report)
+	}
+
+	code, report := cleanup("?max-age=2h&dry-run=true&verbose=true")
+	if code != http.StatusOK || !report.DryRun || !maps.Equal(report.Candidates, both) || len(report.Applied) != 0 {
+		t.Errorf("Dry run = %d %+v, want one session and one journal found, nothing removed", code, report)
+	}
+	if len(report.Items) != 2 || report.Items[0].ID != redactSession("idle-session") {
+		t.Errorf("Verbose items = %+v, want the redacted session and its journal", report.Items)
+	}
+	if len(app.GameSessions) != 2 {
+		t.Fatalf("Dry run removed sessions: %d left", len(app.GameSessions))
+	}
+
+	_, report = cleanup("?max-age=2h&confirm=cleanup")
+	if !maps.Equal(report.Applied, both) || len(report.Errors) != 0 || report.Items != nil {
+		t.Errorf("Cleanup = %+v, want one session and one journal removed", report)
+	}
+	if _, ok := app.GameSessions["fresh-session"]; !ok || len(app.GameSessions) != 1 {
+		t.Errorf("Expected only fresh-session to remain, got %d sessions", len(app.GameSessions))
+	}
+	if _, total := app.Quarantine.list(clock.Now()); total != 1 {
+		t.Error("Expected the cleaned-up session to be quarantined")
+	}
+}
+
+func TestSessionLifetimeWarnings(t *testing.T) {
+	if w := sessionLifetimeWarnings(2*time.Hour, 2*time.Hour); len(w) != 0 {
+		t.Errorf("Expected matching lifetimes to pass, got %v", w)
+	}
+	if w := sessionLifetimeWarnings(24*time.Hour, 2*time.Hour); len(w) != 1 || !strings.Contains(w[0], "COOKIE_MAX_AGE") {
+		t.Errorf("Expected a warning for a cookie outliving its session, got %v", w)
+	}
+	if w := sessionLifetimeWarnings(time.Hour, 2*time.Hour); len(w) != 1 {
+		t.Errorf("Expected a warning for a session outliving its cookie, got %v", w)
+	}
+	if w := sessionLifetimeWarnings(time.Hour, 0); len(w) != 1 {
+		t.Errorf("Expected a warning for a disabled timeout, got %v", w)
+	}
+}
+
+func TestSessionCookieSlidesWithActivity(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.CookieMaxAge = 2 * time.Hour
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "returning-session"})
+
+	if id := app.getOrCreateSession(c); id != "returning-session" {
+		t.Fatalf("Expected the existing session, got %s", id)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != "returning-session" || cookies[0].MaxAge != 7200 {
+		t.Errorf("Expected the session cookie to be reissued with a full lifetime, got %v", cookies)
+	}
+}
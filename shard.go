@@ -0,0 +1,181 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hashRingReplicas is how many points each shard gets on the ring. More points spread keys
+// more evenly at the cost of a larger ring.
+const hashRingReplicas = 64
+
+// ringPoint is one of a shard's positions on the ring.
+type ringPoint struct {
+	hash  uint64
+	shard string
+}
+
+// HashRing assigns keys to shards by consistent hashing: each shard is placed at several
+// points on a ring of 64-bit hashes and a key belongs to the first point at or after its own
+// hash. Adding or removing a shard only moves the keys next to its points, about one in N,
+// rather than reshuffling everything as hash-mod-N would.
+type HashRing struct {
+	points []ringPoint
+}
+
+// newHashRing places shards on a ring, or returns nil when there are none.
+func newHashRing(shards []string) *HashRing {
+	if len(shards) == 0 {
+		return nil
+	}
+	ring := &HashRing{}
+	for _, shard := range shards {
+		for i := range hashRingReplicas {
+			ring.points = append(ring.points, ringPoint{hash: ringHash(shard + "#" + strconv.Itoa(i)), shard: shard})
+		}
+	}
+	slices.SortFunc(ring.points, func(a, b ringPoint) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		}
+		return 0
+	})
+	return ring
+}
+
+// owner returns the shard key belongs to.
+func (r *HashRing) owner(key string) string {
+	h := ringHash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].shard
+}
+
+// ringHash hashes a key onto the ring. A cryptographic hash keeps the points of shards with
+// similar names from clustering.
+func ringHash(key string) uint64 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// settle moves a session's journal to its owning shard when it was found on another one,
+// for example after shards were added or removed. Where both copies exist the newer one
+// wins. Callers must hold the file's lock.
+func (j *SessionJournal) settle(name string) error {
+	owner := filepath.Join(j.ring.owner(name), name)
+	ownerInfo, ownerErr := os.Stat(owner)
+	for _, dir := range j.dirs {
+		stray := filepath.Join(dir, name)
+		if stray == owner {
+			continue
+		}
+		info, err := os.Stat(stray)
+		if err != nil {
+			continue
+		}
+		if ownerErr == nil && !info.ModTime().After(ownerInfo.ModTime()) {
+			os.Remove(stray)
+			continue
+		}
+		if err := moveFile(stray, owner); err != nil {
+			return err
+		}
+		ownerInfo, ownerErr = info, nil
+	}
+	return nil
+}
+
+// moveFile renames src to dst, copying through a temporary file when they are on different
+// filesystems so dst is never left half-written.
+func moveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	if os.Rename(src, dst) == nil {
+		return nil
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// misplaced lists the journals on a shard that does not own them. Journals are also moved
+// lazily when their session is next read or written, so this only matters after the shard
+// list changes.
+func (j *SessionJournal) misplaced() ([]string, []error) {
+	if j == nil {
+		return nil, nil
+	}
+	var names []string
+	var errs []error
+	for _, dir := range j.dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() && filepath.Ext(e.Name()) == ".log" && j.ring.owner(e.Name()) != dir {
+				names = append(names, e.Name())
+			}
+		}
+	}
+	return names, errs
+}
+
+// settleLocked moves one journal to its shard, taking the lock for just that file so a
+// rebalance does not hold up live traffic.
+func (j *SessionJournal) settleLocked(name string) error {
+	lock := j.fileLock(name)
+	lock.Lock()
+	defer lock.Unlock()
+	return j.settle(name)
+}
+
+// rebalanceJournals moves misplaced journals at startup and logs what moved.
+func (app *App) rebalanceJournals() {
+	op := app.journalRebalanceOp()
+	report, err := op.run(opOptions{Confirm: op.Name})
+	if err != nil {
+		logWarn("Failed to rebalance session journals: %v", err)
+		return
+	}
+	for _, problem := range report.Errors {
+		logWarn("Rebalancing session journals: %s", problem)
+	}
+}
+
+// adminRebalanceHandler moves misplaced journals to their shards as the journal-rebalance
+// operation.
+func (app *App) adminRebalanceHandler(c *gin.Context) {
+	if app.Journal == nil {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "session journal is not enabled")
+		return
+	}
+	runDestructiveHandler(c, app.journalRebalanceOp())
+}
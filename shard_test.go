@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestHashRingMovesFewKeysWhenShardAdded(t *testing.T) {
+	before := newHashRing([]string{"a", "b", "c"})
+	after := newHashRing([]string{"a", "b", "c", "d"})
+	counts := make(map[string]int)
+	moved := 0
+	const keys = 4000
+	for i := range keys {
+		key := fmt.Sprintf("session-%d", i)
+		counts[after.owner(key)]++
+		if old, now := before.owner(key), after.owner(key); old != now {
+			if now != "d" {
+				t.Fatalf("key %s moved from %s to %s, not to the new shard", key, old, now)
+			}
+			moved++
+		}
+	}
+	if moved < keys/8 || moved > keys/2 {
+		t.Errorf("%d of %d keys moved, want about a quarter", moved, keys)
+	}
+	for shard, n := range counts {
+		if n < keys/8 {
+			t.Errorf("shard %s owns only %d of %d keys", shard, n, keys)
+		}
+	}
+}
+
+func TestSessionJournalFollowsShardChanges(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	j := newSessionJournal(first)
+	var ids []string
+	for i := range 20 {
+		id := fmt.Sprintf("session-%03d", i)
+		ids = append(ids, id)
+		j.start(dummyContext(), id, &GameState{GameState: engine.GameState{SessionWord: "CRANE"}})
+	}
+
+	grown := newSessionJournal(first + ", " + second)
+	var moved []string
+	for _, id := range ids {
+		if filepath.Dir(grown.path(id)) == second {
+			moved = append(moved, id)
+		}
+	}
+	if len(moved) < 2 {
+		t.Fatalf("only %d of %d sessions belong to the new shard", len(moved), len(ids))
+	}
+
+	// A read moves the journal to its new shard on the spot.
+	if game, err := grown.load(moved[0], 0, time.Now(), nil); err != nil || game.SessionWord != "CRANE" {
+		t.Fatalf("load() = %+v, %v", game, err)
+	}
+	if _, err := os.Stat(grown.path(moved[0])); err != nil {
+		t.Errorf("journal not moved on read: %v", err)
+	}
+
+	app := &App{Journal: grown}
+	op := app.journalRebalanceOp()
+	report, err := op.run(opOptions{Confirm: OpJournalRebalance})
+	if err != nil || report.Applied["journal"] != len(moved)-1 || report.Candidates["journal"] != len(moved)-1 || len(report.Errors) != 0 {
+		t.Fatalf("rebalance = %+v, %v; want %d moved", report, err, len(moved)-1)
+	}
+	for _, id := range ids {
+		if _, err := os.Stat(grown.path(id)); err != nil {
+			t.Errorf("journal for %s not on its shard: %v", id, err)
+		}
+	}
+	if again, _ := op.run(opOptions{Confirm: OpJournalRebalance}); len(again.Candidates) != 0 {
+		t.Errorf("second rebalance found %v misplaced journals", again.Candidates)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// storeSampleLimit caps how many recent store writes StoreHealth remembers.
+const storeSampleLimit = 256
+
+// storeSample is the duration and outcome of one session store write.
+type storeSample struct {
+	At     time.Time
+	Took   time.Duration
+	Failed bool
+}
+
+// StoreHealth tracks recent session store writes and decides when write-heavy routes should
+// shed load. Only samples from the last Window count, so once writes are shed and no new
+// samples arrive, the store is treated as healthy again and traffic is let back in.
+type StoreHealth struct {
+	// LatencyThreshold sheds load when the average write over the window is slower. Zero
+	// disables the latency check.
+	LatencyThreshold time.Duration
+	// FailureThreshold sheds load when at least this many writes failed in the window. Zero
+	// disables the failure check.
+	FailureThreshold int
+	Window           time.Duration
+	RetryAfter       time.Duration
+
+	mu      sync.Mutex
+	samples []storeSample
+	next    int
+	now     func() time.Time
+}
+
+// storeStatus summarizes the store writes in the current window.
+type storeStatus struct {
+	Writes      int
+	Failures    int
+	AverageTook time.Duration
+}
+
+// newStoreHealth returns a tracker with the given thresholds.
+func newStoreHealth(latency time.Duration, failures int, window, retryAfter time.Duration) *StoreHealth {
+	return &StoreHealth{
+		LatencyThreshold: latency,
+		FailureThreshold: failures,
+		Window:           window,
+		RetryAfter:       retryAfter,
+		now:              time.Now,
+	}
+}
+
+// observe records one store write. It is a no-op on a nil tracker.
+func (h *StoreHealth) observe(took time.Duration, err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sample := storeSample{At: h.now(), Took: took, Failed: err != nil}
+	if len(h.samples) < storeSampleLimit {
+		h.samples = append(h.samples, sample)
+		return
+	}
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % storeSampleLimit
+}
+
+// status summarizes the writes observed within the window.
+func (h *StoreHealth) status() storeStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cutoff := h.now().Add(-h.Window)
+	var s storeStatus
+	var total time.Duration
+	for _, sample := range h.samples {
+		if sample.At.Before(cutoff) {
+			continue
+		}
+		s.Writes++
+		total += sample.Took
+		if sample.Failed {
+			s.Failures++
+		}
+	}
+	if s.Writes > 0 {
+		s.AverageTook = total / time.Duration(s.Writes)
+	}
+	return s
+}
+
+// overloaded reports whether the store is failing or slow enough to shed load, and why.
+func (h *StoreHealth) overloaded() (bool, string) {
+	if h == nil {
+		return false, ""
+	}
+	s := h.status()
+	if h.FailureThreshold > 0 && s.Failures >= h.FailureThreshold {
+		return true, fmt.Sprintf("%d of %d session store writes failed in the last %v", s.Failures, s.Writes, h.Window)
+	}
+	if h.LatencyThreshold > 0 && s.AverageTook > h.LatencyThreshold {
+		return true, fmt.Sprintf("session store writes average %v over the last %v", s.AverageTook.Round(time.Millisecond), h.Window)
+	}
+	return false, ""
+}
+
+// loadSheddingMiddleware answers write requests with 503 and Retry-After while the session
+// store is overloaded. Reads, which serve the fragments already on screen, and admin routes,
+// which an operator may need to recover, always go through.
+func (app *App) loadSheddingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if strings.HasPrefix(c.Request.URL.Path, RouteAdmin) {
+			c.Next()
+			return
+		}
+		if shed, _ := app.StoreHealth.overloaded(); shed {
+			app.incMetric(MetricShedRequests)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(app.StoreHealth.RetryAfter.Seconds()))))
+			writeProblem(c, http.StatusServiceUnavailable, ErrorCodeOverloaded, "server is busy, please try again shortly")
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkSessionStore reports whether write-heavy routes are currently being shed.
+func (app *App) checkSessionStore() healthCheck {
+	check := healthCheck{Name: "session_store", Status: HealthStatusOK}
+	if shed, reason := app.StoreHealth.overloaded(); shed {
+		check.Status = HealthStatusDegraded
+		check.Detail = reason + "; shedding writes"
+	}
+	return check
+}
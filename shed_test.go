@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStoreHealthThresholds(t *testing.T) {
+	now := time.Date(2030, 4, 1, 12, 0, 0, 0, time.UTC)
+	h := newStoreHealth(100*time.Millisecond, 2, 30*time.Second, 5*time.Second)
+	h.now = func() time.Time { return now }
+
+	h.observe(10*time.Millisecond, nil)
+	h.observe(10*time.Millisecond, errors.New("disk full"))
+	if shed, _ := h.overloaded(); shed {
+		t.Fatal("one failure should not shed load")
+	}
+	h.observe(10*time.Millisecond, errors.New("disk full"))
+	if shed, reason := h.overloaded(); !shed || reason == "" {
+		t.Fatal("failures at the threshold should shed load")
+	}
+
+	now = now.Add(time.Minute)
+	if shed, _ := h.overloaded(); shed {
+		t.Error("old failures should age out of the window")
+	}
+	h.observe(50*time.Millisecond, nil)
+	h.observe(250*time.Millisecond, nil)
+	if shed, _ := h.overloaded(); !shed {
+		t.Error("slow average writes should shed load")
+	}
+}
+
+func TestLoadSheddingMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{Metrics: newMetrics(), StoreHealth: newStoreHealth(0, 1, time.Minute, 3*time.Second)}
+	router := gin.New()
+	router.Use(app.loadSheddingMiddleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET(RouteGameState, ok)
+	router.POST(RouteGuess, ok)
+	router.POST(RouteAdmin+"/daily/roll", ok)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	if w := serve("POST", RouteGuess); w.Code != http.StatusOK {
+		t.Fatalf("healthy store: status = %d, want 200", w.Code)
+	}
+
+	app.StoreHealth.observe(time.Millisecond, errors.New("disk full"))
+	w := serve("POST", RouteGuess)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "3" {
+		t.Errorf("write while overloaded: status = %d, Retry-After = %q; want 503, 3", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve("GET", RouteGameState); w.Code != http.StatusOK {
+		t.Errorf("read while overloaded: status = %d, want 200", w.Code)
+	}
+	if w := serve("POST", RouteAdmin+"/daily/roll"); w.Code != http.StatusOK {
+		t.Errorf("admin write while overloaded: status = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SpectatePollInterval is how often the spectate page refreshes the board while a game is live.
+const SpectatePollInterval = "2s"
+
+// SpectateLinks maps opt-in, read-only spectate tokens to the sessions they follow.
+// A session has at most one token; revoking it invalidates existing links.
+type SpectateLinks struct {
+	mu        sync.RWMutex
+	sessions  map[string]string
+	bySession map[string]string
+}
+
+// newSpectateLinks returns an empty link registry.
+func newSpectateLinks() *SpectateLinks {
+	return &SpectateLinks{sessions: make(map[string]string), bySession: make(map[string]string)}
+}
+
+// enable returns the session's spectate token, creating one if needed.
+func (sl *SpectateLinks) enable(sessionID string) (string, error) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	if token, ok := sl.bySession[sessionID]; ok {
+		return token, nil
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	sl.sessions[token] = sessionID
+	sl.bySession[sessionID] = token
+	return token, nil
+}
+
+// disable revokes the session's spectate token, reporting whether one existed.
+func (sl *SpectateLinks) disable(sessionID string) bool {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	token, ok := sl.bySession[sessionID]
+	if ok {
+		delete(sl.bySession, sessionID)
+		delete(sl.sessions, token)
+	}
+	return ok
+}
+
+// session returns the session followed by token.
+func (sl *SpectateLinks) session(token string) (string, bool) {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+	sessionID, ok := sl.sessions[token]
+	return sessionID, ok
+}
+
+// spectatorRow builds a board row for spectators. Letters stay hidden until the game ends so the
+// board can be streamed without spoiling guesses; colours are always shown.
+func spectatorRow(game *GameState, row int) guessRowView {
+	view := guessRow(game, row, false)
+	view.Active = false
+	if game == nil || game.GameOver {
+		return view
+	}
+	for i, tile := range view.Tiles {
+		if tile.Letter != "" {
+			view.Tiles[i].Letter = ""
+			view.Tiles[i].Masked = true
+		}
+	}
+	return view
+}
+
+// spectatedGame returns a copy of the game followed by token, safe to render while the player
+// keeps guessing.
+func (app *App) spectatedGame(token string) (*GameState, bool) {
+	if app.Spectate == nil {
+		return nil, false
+	}
+	sessionID, ok := app.Spectate.session(token)
+	if !ok {
+		return nil, false
+	}
+	app.SessionMutex.RLock()
+	defer app.SessionMutex.RUnlock()
+	game, ok := app.GameSessions[sessionID]
+	if !ok {
+		return nil, false
+	}
+	return cloneGame(game), true
+}
+
+// enableSpectateHandler opts the current session into spectating and returns its read-only link.
+func (app *App) enableSpectateHandler(c *gin.Context) {
+	sessionID := app.getOrCreateSession(c)
+	token, err := app.Spectate.enable(sessionID)
+	if err != nil {
+		logWarn("Failed to create spectate link: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "could not create spectate link")
+		return
+	}
+	logInfo("Enabled spectating for session %s", redactSession(sessionID))
+	c.JSON(http.StatusOK, gin.H{"url": RouteSpectate + "/" + token})
+}
+
+// disableSpectateHandler revokes the current session's spectate link.
+func (app *App) disableSpectateHandler(c *gin.Context) {
+	sessionID, _ := c.Cookie(app.cookieName(SessionCookieName))
+	c.JSON(http.StatusOK, gin.H{"revoked": app.Spectate.disable(sessionID)})
+}
+
+// spectateData is the template data shared by the spectate page and its polled board.
+func spectateData(token string, game *GameState) gin.H {
+	return gin.H{
+		"game":         game,
+		"boardURL":     RouteSpectate + "/" + token + "/board",
+		"pollInterval": SpectatePollInterval,
+	}
+}
+
+// spectatePageHandler renders the read-only spectate page for a token.
+func (app *App) spectatePageHandler(c *gin.Context) {
+	token := c.Param("token")
+	game, ok := app.spectatedGame(token)
+	if !ok {
+		c.String(http.StatusNotFound, "spectate link not found")
+		return
+	}
+	data := spectateData(token, game)
+	data["title"] = "Vortludo - Spectating"
+	c.HTML(http.StatusOK, "spectate.html", data)
+}
+
+// spectateBoardHandler renders the board fragment polled by the spectate page. Once the game is
+// over the fragment no longer polls, so spectators keep the final, revealed board.
+func (app *App) spectateBoardHandler(c *gin.Context) {
+	token := c.Param("token")
+	game, ok := app.spectatedGame(token)
+	if !ok {
+		c.String(http.StatusNotFound, "spectate link not found")
+		return
+	}
+	renderFragment(c, "spectate-board", spectateData(token, game))
+}
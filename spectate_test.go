@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSpectateLinks(t *testing.T) {
+	sl := newSpectateLinks()
+	token, err := sl.enable("sess")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := sl.enable("sess"); again != token {
+		t.Error("Expected enabling twice to reuse the token")
+	}
+	if got, ok := sl.session(token); !ok || got != "sess" {
+		t.Errorf("session(token) = %q, %v", got, ok)
+	}
+	if !sl.disable("sess") {
+		t.Error("Expected disable to revoke the token")
+	}
+	if _, ok := sl.session(token); ok {
+		t.Error("Revoked token should not resolve")
+	}
+}
+
+// tileLetter matches a rendered tile containing a letter.
+var tileLetter = regexp.MustCompile(`data-reveal-delay="\d+"\s*>\s*[A-Z]\s*</div>`)
+
+func TestSpectateBoardHidesLettersUntilGameOver(t *testing.T) {
+	tpl := parseTestTemplates(t)
+	app := testAppWithWords([]WordEntry{{Word: "TRACE"}})
+	app.Spectate = newSpectateLinks()
+	game := newGame("TRACE")
+	game.ApplyGuess("CRANE", "TRACE", checkGuess("CRANE", "TRACE"), true)
+	app.GameSessions["sess"] = game
+	token, _ := app.Spectate.enable("sess")
+
+	render := func() string {
+		snapshot, ok := app.spectatedGame(token)
+		if !ok {
+			t.Fatal("Expected spectated game")
+		}
+		var buf bytes.Buffer
+		if err := tpl.ExecuteTemplate(&buf, "spectate-board", spectateData(token, snapshot)); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	live := render()
+	if tileLetter.MatchString(live) || strings.Contains(live, "TRACE") {
+		t.Error("Live spectate board should not reveal letters or the word")
+	}
+	if !strings.Contains(live, "tile-correct") || !strings.Contains(live, "hx-trigger") {
+		t.Error("Live spectate board should show statuses and keep polling")
+	}
+
+	game.ApplyGuess("TRACE", "TRACE", checkGuess("TRACE", "TRACE"), true)
+	done := render()
+	if !tileLetter.MatchString(done) || !strings.Contains(done, "TRACE") || strings.Contains(done, "hx-trigger") {
+		t.Error("Finished spectate board should reveal the word and stop polling")
+	}
+}
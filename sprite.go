@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IconSpriteFile is the SVG sprite in the static directory, written by cmd/sprite.
+const IconSpriteFile = "icons.svg"
+
+// symbolIDPattern extracts symbol ids from the sprite.
+var symbolIDPattern = regexp.MustCompile(`<symbol\b[^>]*\sid="([^"]+)"`)
+
+// iconSprite knows which icons the sprite holds and the versioned URL it is served at.
+type iconSprite struct {
+	url string
+	ids map[string]bool
+}
+
+// loadIconSprite reads the sprite from staticDir. A missing sprite is not an error; icons then
+// fall back to the Bootstrap Icons font.
+func loadIconSprite(staticDir string) (*iconSprite, error) {
+	data, err := os.ReadFile(filepath.Join(staticDir, IconSpriteFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	sprite := &iconSprite{
+		url: "/static/" + IconSpriteFile + "?v=" + hex.EncodeToString(sum[:4]),
+		ids: map[string]bool{},
+	}
+	for _, m := range symbolIDPattern.FindAllSubmatch(data, -1) {
+		sprite.ids[string(m[1])] = true
+	}
+	return sprite, nil
+}
+
+// icon renders the named Bootstrap icon with any extra classes, referencing the sprite when it
+// has the icon and the icon font otherwise.
+func (s *iconSprite) icon(name string, classes ...string) template.HTML {
+	class := template.HTMLEscapeString(strings.Join(append([]string{"bi"}, classes...), " "))
+	if s != nil && s.ids[name] {
+		href := template.HTMLEscapeString(s.url + "#" + name)
+		return template.HTML(`<svg class="` + class + `" aria-hidden="true"><use href="` + href + `"></use></svg>`)
+	}
+	return template.HTML(`<i class="` + class + ` bi-` + template.HTMLEscapeString(name) + `" aria-hidden="true"></i>`)
+}
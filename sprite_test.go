@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIconFallsBackToFont(t *testing.T) {
+	var sprite *iconSprite
+	want := `<i class="bi fs-4 bi-lightbulb" aria-hidden="true"></i>`
+	if got := string(sprite.icon("lightbulb", "fs-4")); got != want {
+		t.Errorf("icon() = %s, want %s", got, want)
+	}
+}
+
+func TestIconUsesSprite(t *testing.T) {
+	dir := t.TempDir()
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" style="display:none">
+  <symbol id="share" viewBox="0 0 16 16"><path d="M0 0h16v16H0z"/></symbol>
+</svg>
+`
+	if err := os.WriteFile(filepath.Join(dir, IconSpriteFile), []byte(svg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sprite, err := loadIconSprite(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := string(sprite.icon("share"))
+	if !strings.HasPrefix(got, `<svg class="bi" aria-hidden="true"><use href="/static/icons.svg?v=`) || !strings.Contains(got, `#share"></use></svg>`) {
+		t.Errorf("icon(share) = %s, want a sprite reference", got)
+	}
+	if got := string(sprite.icon("broadcast")); !strings.HasPrefix(got, "<i ") {
+		t.Errorf("icon(broadcast) = %s, want the font fallback for an icon missing from the sprite", got)
+	}
+
+	missing, err := loadIconSprite(t.TempDir())
+	if err != nil || missing != nil {
+		t.Errorf("loadIconSprite(empty dir) = %v, %v, want nil, nil", missing, err)
+	}
+}
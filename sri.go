@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CDNIntegrityFile holds the SRI hashes for CDN-loaded dependencies, written by cmd/sri.
+const CDNIntegrityFile = "cdn-integrity.json"
+
+// assetIntegrity maps an asset URL, as written in a template, to its SRI hash.
+type assetIntegrity map[string]string
+
+// loadAssetIntegrity hashes the CSS and JS files under staticDir and merges in the CDN hashes
+// from cdnFile when it exists.
+func loadAssetIntegrity(staticDir, cdnFile string) (assetIntegrity, error) {
+	integrity := assetIntegrity{}
+	err := filepath.WalkDir(staticDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		if ext != ".css" && ext != ".js" {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(staticDir, p)
+		if err != nil {
+			return err
+		}
+		integrity[path.Join("/static", filepath.ToSlash(rel))] = sriHash(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(cdnFile)
+	if os.IsNotExist(err) {
+		return integrity, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cdn map[string]string
+	if err := json.Unmarshal(data, &cdn); err != nil {
+		return nil, err
+	}
+	for url, hash := range cdn {
+		integrity[url] = hash
+	}
+	return integrity, nil
+}
+
+// sriHash returns the sha384 subresource integrity value for data.
+func sriHash(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// attr returns the integrity and crossorigin attributes for url, or nothing when its hash is
+// unknown so the asset still loads.
+func (a assetIntegrity) attr(url string) template.HTMLAttr {
+	hash, ok := a[url]
+	if !ok {
+		return ""
+	}
+	return template.HTMLAttr(`integrity="` + template.HTMLEscapeString(hash) + `" crossorigin="anonymous"`)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAssetIntegrity(t *testing.T) {
+	dir := t.TempDir()
+	static := filepath.Join(dir, "static")
+	if err := os.MkdirAll(filepath.Join(static, "favicons"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(static, "client.js"), []byte("alert(1)"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(static, "favicons", "favicon.ico"), []byte("icon"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cdn := filepath.Join(dir, CDNIntegrityFile)
+	if err := os.WriteFile(cdn, []byte(`{"https://cdn.example/lib@1.2.3/lib.js":"sha384-abc"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	integrity, err := loadAssetIntegrity(static, cdn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := integrity["/static/client.js"], sriHash([]byte("alert(1)")); got != want {
+		t.Errorf("client.js integrity = %q, want %q", got, want)
+	}
+	if _, ok := integrity["/static/favicons/favicon.ico"]; ok {
+		t.Error("non-script asset should not be hashed")
+	}
+	if got := integrity["https://cdn.example/lib@1.2.3/lib.js"]; got != "sha384-abc" {
+		t.Errorf("cdn integrity = %q, want sha384-abc", got)
+	}
+
+	if _, err := loadAssetIntegrity(static, filepath.Join(dir, "missing.json")); err != nil {
+		t.Errorf("missing cdn file should be ignored, got %v", err)
+	}
+}
+
+func TestSRIHash(t *testing.T) {
+	// Known value from the SRI spec examples: sha384 of "alert('Hello, world.');".
+	want := "sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO"
+	if got := sriHash([]byte("alert('Hello, world.');")); got != want {
+		t.Errorf("sriHash() = %s, want %s", got, want)
+	}
+}
+
+func TestTemplatesEmitIntegrity(t *testing.T) {
+	integrity, err := loadAssetIntegrity("static", CDNIntegrityFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := template.Must(template.New("").Funcs(templateFuncs(integrity, nil)).Parse(
+		`<script src="/static/client.js" {{sri "/static/client.js"}}></script><script src="/x.js" {{sri "/x.js"}}></script>`))
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `<script src="/static/client.js" integrity="` + integrity["/static/client.js"] + `" crossorigin="anonymous"></script><script src="/x.js" ></script>`
+	if got := buf.String(); got != want {
+		t.Errorf("rendered %s, want %s", got, want)
+	}
+	if !strings.HasPrefix(integrity["/static/client.js"], "sha384-") {
+		t.Errorf("client.js integrity = %q", integrity["/static/client.js"])
+	}
+}
@@ -1,7 +1,23 @@
 const WORD_LENGTH = 5;
-const MAX_GUESSES = 6;
 const ANIMATION_DELAY = 100;
-const COMPLETED_WORDS_KEY = 'vortludo-completed-words';
+const PROGRESS_KEY = 'vortludo-progress';
+const PURIST_KEY = 'vortludo-purist';
+const MODE_PURIST = 'purist';
+const KIDS_KEY = 'vortludo-kids';
+const MODE_KIDS = 'kids';
+const LEGACY_COMPLETED_WORDS_KEY = 'vortludo-completed-words';
+const DEFAULT_PACK = 'classic';
+const VALIDATE_URL = '/validate';
+const SPECTATE_URL = '/spectate';
+const ROOM_URL = '/room';
+const CLASSROOM_URL = '/classroom';
+const PUSH_URL = '/push';
+const CALENDAR_LINK_URL = '/calendar/link';
+const PREFERENCES_URL = '/api/v1/preferences';
+const SERVICE_WORKER_URL = '/sw.js';
+const ROOM_POLL_INTERVAL = 3000;
+
+const progressKey = (pack) => `${PROGRESS_KEY}:${pack || DEFAULT_PACK}`;
 
 const SELECTORS = {
     GAME_BOARD: '#game-board',
@@ -10,12 +26,16 @@ const SELECTORS = {
     FILLED_TILE: '.tile.filled',
     GAME_CONTENT_CONTAINER: '#game-content-container',
     CSRF_META: 'meta[name="csrf-token"]',
+    COOKIE_PREFIX_META: 'meta[name="cookie-prefix"]',
+    VAPID_META: 'meta[name="vapid-public-key"]',
     GUESS_INPUT: '#guess-input',
+    GUESS_ROW_INPUT: '#guess-row-input',
     GUESS_FORM: '#guess-form',
     SR_LIVE: '#sr-live',
     NOTIFICATION_TOAST: '#notification-toast',
     COPY_MODAL: '.modal',
     COPY_MODAL_TEXTAREA: '.copy-modal textarea',
+    DAILY_SUMMARY: '#daily-summary',
 };
 
 const CSS_CLASSES = {
@@ -85,6 +105,32 @@ const readCookie = (name) => {
     return parts.length === 2 ? parts.pop().split(';').shift() : '';
 };
 
+// Cookies are prefixed with the deployment's namespace, if any.
+const readCSRFCookie = () => {
+    const meta = document.querySelector(SELECTORS.COOKIE_PREFIX_META);
+    const prefix = meta ? meta.getAttribute('content') : '';
+    return readCookie(`${prefix}csrf_token`);
+};
+
+// base64URLToBytes decodes the server's VAPID public key for pushManager.subscribe.
+const base64URLToBytes = (value) => {
+    const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+    const padded = base64 + '='.repeat((4 - (base64.length % 4)) % 4);
+    return Uint8Array.from(atob(padded), (c) => c.charCodeAt(0));
+};
+
+// Report the browser timezone so the daily countdown can follow the player's local rollover.
+(() => {
+    try {
+        const tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+        if (tz && readCookie('tz') !== encodeURIComponent(tz)) {
+            document.cookie = `tz=${encodeURIComponent(tz)}; path=/; max-age=31536000; SameSite=Lax`;
+        }
+    } catch {
+        // Intl is unavailable; the server falls back to its configured timezone.
+    }
+})();
+
 window.gameApp = function () {
     return {
         currentGuess: '',
@@ -92,12 +138,17 @@ window.gameApp = function () {
         gameOver: false,
         hintVisible: false,
         isDarkMode: false,
-        keyStatus: {},
+        purist: localStorage.getItem(PURIST_KEY) === 'true',
+        preferences: {},
+        kids: localStorage.getItem(KIDS_KEY) === 'true',
+        pushEnabled: false,
         showCopyModal: false,
         copyModalText: '',
         submittingGuess: false,
         lastServerError: '',
         keepInputAfterError: false,
+        roomCode: '',
+        _roomTimer: null,
         _gameRows: null,
         _guessRows: null,
         _toast: null,
@@ -127,11 +178,68 @@ window.gameApp = function () {
                 text: 'You already guessed that word! 🔂',
                 type: 'warning',
             },
+            guess_conflict: {
+                text: 'A teammate guessed first. Check the board and try again! 🤝',
+                type: 'warning',
+            },
+            coins_unavailable: {
+                text: 'Coins can only be used in casual games. 🪙',
+                type: 'warning',
+            },
+            insufficient_coins: {
+                text: 'Not enough coins! Win casual games to earn more. 🪙',
+                type: 'warning',
+            },
+            nothing_to_buy: {
+                text: 'There is nothing more to buy for this game. 🪙',
+                type: 'info',
+            },
+            overloaded: {
+                text: 'The server is busy. Please try again in a moment! ⏳',
+                type: 'warning',
+            },
             unknown_error: {
                 text: 'An unexpected error occurred. ❗',
                 type: 'error',
             },
         },
+        // Kids games show gentler messages for the mistakes players make most.
+        kidsErrorCodeMessages: {
+            game_over: {
+                text: "That game is finished. Let's play another one! 🎈",
+                type: 'info',
+            },
+            invalid_length: {
+                text: 'Words need 5 letters. Keep going! ✏️',
+                type: 'info',
+            },
+            no_more_guesses: {
+                text: "That was a great try! Let's start a new game. 🌟",
+                type: 'info',
+            },
+            not_in_word_list: {
+                text: "Hmm, we don't know that word. Try another one! 🤔",
+                type: 'info',
+            },
+            word_not_accepted: {
+                text: "Hmm, we don't know that word. Try another one! 🤔",
+                type: 'info',
+            },
+            duplicate_guess: {
+                text: 'You tried that word already. Pick a new one! 🔄',
+                type: 'info',
+            },
+        },
+        // errorInfo returns the toast for an error code, using the kids messages in kids games.
+        errorInfo(code) {
+            const kidsGame =
+                document.querySelector(SELECTORS.GAME_BOARD)?.dataset.mode ===
+                MODE_KIDS;
+            return (
+                (kidsGame && this.kidsErrorCodeMessages[code]) ||
+                this.errorCodeMessages[code]
+            );
+        },
         getGameRows() {
             if (!this._gameRows) {
                 this._gameRows = document.querySelectorAll(
@@ -157,6 +265,10 @@ window.gameApp = function () {
             this.initTheme();
             this.initToast();
             this.setupHTMXHandlers();
+            this.dropLegacyCompletedWords();
+            this.joinClassFromURL() || this.joinRoomFromURL() || this.pollRoom();
+            this.syncPushState();
+            this.loadPreferences();
             setTimeout(() => this.updateGameState(), 100);
         },
         initToast() {
@@ -178,7 +290,6 @@ window.gameApp = function () {
             this.currentRow = 0;
             this.gameOver = false;
             this.hintVisible = false;
-            this.keyStatus = {};
             this.submittingGuess = false;
             this.clearDOMCache();
         },
@@ -187,6 +298,55 @@ window.gameApp = function () {
             this.isDarkMode = savedTheme === 'dark';
             document.documentElement.setAttribute('data-bs-theme', savedTheme);
         },
+        // loadPreferences applies the theme and mode saved for this player, which follow a
+        // remembered player across devices. Settings this page has no control for are kept so
+        // savePreferences sends them back unchanged.
+        async loadPreferences() {
+            try {
+                const res = await fetch(PREFERENCES_URL, {
+                    headers: { Accept: 'application/json' },
+                });
+                if (!res.ok) return;
+                const { preferences, synced } = await res.json();
+                this.preferences = preferences;
+                if (!synced && !preferences.theme) return;
+                if (preferences.theme) {
+                    this.isDarkMode = preferences.theme === 'dark';
+                    document.documentElement.setAttribute(
+                        'data-bs-theme',
+                        preferences.theme
+                    );
+                    localStorage.setItem('theme', preferences.theme);
+                }
+                this.purist = preferences.mode === MODE_PURIST;
+                this.kids = preferences.mode === MODE_KIDS;
+                localStorage.setItem(PURIST_KEY, String(this.purist));
+                localStorage.setItem(KIDS_KEY, String(this.kids));
+            } catch (e) {
+                console.warn('Failed to load preferences:', e);
+            }
+        },
+        // savePreferences stores the current theme and mode for this player.
+        async savePreferences() {
+            this.preferences = {
+                ...this.preferences,
+                theme: this.isDarkMode ? 'dark' : 'light',
+                mode: this.kids ? MODE_KIDS : this.purist ? MODE_PURIST : '',
+            };
+            try {
+                await fetch(PREFERENCES_URL, {
+                    method: 'PUT',
+                    headers: {
+                        Accept: 'application/json',
+                        'Content-Type': 'application/json',
+                        'X-CSRF-Token': readCSRFCookie() || '',
+                    },
+                    body: JSON.stringify(this.preferences),
+                });
+            } catch (e) {
+                console.warn('Failed to save preferences:', e);
+            }
+        },
         _handleTriggerHeader(header) {
             if (!header) {
                 this.lastServerError = '';
@@ -196,18 +356,28 @@ window.gameApp = function () {
 
             try {
                 const parsed = JSON.parse(header);
+                if (typeof parsed['progress-stale'] !== 'undefined') {
+                    this.dropStaleProgress(parsed['progress-stale']?.pack);
+                }
                 if (typeof parsed['clear-completed-words'] !== 'undefined') {
-                    this.clearCompletedWords();
+                    this.clearCompletedWords(
+                        parsed['clear-completed-words']?.pack
+                    );
                 }
                 if (parsed.server_error_code) {
                     const code = parsed.server_error_code;
-                    const info = this.errorCodeMessages[code] || {
-                        text: `An unexpected error occurred. (code: ${code}) ❗`,
+                    const info = this.errorInfo(code) || {
+                        text:
+                            parsed.server_error_message ||
+                            `An unexpected error occurred. (code: ${code}) ❗`,
                         type: 'error',
                     };
                     this.lastServerError = code;
                     this.keepInputAfterError = true;
-                    this.showToastNotification(info.text, info.type);
+                    this.showToastNotification(
+                        this.withErrorRef(info.text, parsed.request_id),
+                        info.type
+                    );
                     this.submittingGuess = false;
                     this.shakeCurrentRow();
                 } else {
@@ -241,6 +411,30 @@ window.gameApp = function () {
                 }
             });
 
+            // Guesses that don't end the game come back as out-of-band row and keyboard swaps
+            // with no main swap, so refresh state once the swapped rows have landed.
+            document.body.addEventListener('htmx:oobAfterSwap', (evt) => {
+                if (
+                    window.Alpine &&
+                    typeof window.Alpine.initTree === 'function'
+                ) {
+                    window.Alpine.initTree(evt.detail.target);
+                }
+                if (
+                    !evt.detail.target?.classList?.contains('guess-row') ||
+                    this._oobUpdateQueued
+                ) {
+                    return;
+                }
+                this._oobUpdateQueued = true;
+                queueMicrotask(() => {
+                    this._oobUpdateQueued = false;
+                    this.submittingGuess = false;
+                    this.clearDOMCache();
+                    this.updateGameState();
+                });
+            });
+
             document.body.addEventListener('htmx:beforeSwap', () => {
                 if (this.currentGuess) {
                     this.tempCurrentGuess = this.currentGuess;
@@ -248,12 +442,43 @@ window.gameApp = function () {
                 }
             });
 
+            document.body.addEventListener('csrf-invalid', () => {
+                this.submittingGuess = false;
+                this.showToastNotification(
+                    'Your session expired. Refreshing the page…',
+                    'warning'
+                );
+                setTimeout(() => window.location.reload(), 1500);
+            });
+
+            document.body.addEventListener('rate-limit-exceeded', () => {
+                this.submittingGuess = false;
+                this.showToastNotification(
+                    'Too many requests. Please slow down!',
+                    'warning'
+                );
+            });
+
             document.body.addEventListener('htmx:responseError', (evt) => {
-                const message =
-                    evt.detail.xhr.status === 429
-                        ? 'Too many requests. Please slow down!'
-                        : 'Connection error. Please try again!';
-                this.showToastNotification(message, 'warning');
+                // 403 and 429 responses carry csrf-invalid and rate-limit-exceeded triggers.
+                const status = evt.detail.xhr.status;
+                if (status === 403 || status === 429) {
+                    return;
+                }
+                let message = 'Connection error. Please try again!';
+                if (status === 400 || status === 413) {
+                    message = 'Invalid input. Please refresh and try again!';
+                } else if (status === 503) {
+                    message = this.errorCodeMessages.overloaded.text;
+                }
+                this.submittingGuess = false;
+                this.showToastNotification(
+                    this.withErrorRef(
+                        message,
+                        evt.detail.xhr.getResponseHeader('X-Request-Id')
+                    ),
+                    'warning'
+                );
             });
 
             document.body.addEventListener('htmx:sendError', () => {
@@ -283,7 +508,7 @@ window.gameApp = function () {
 
             if (window.htmx) {
                 htmx.on('htmx:configRequest', (evt) => {
-                    let token = readCookie('csrf_token');
+                    let token = readCSRFCookie();
                     if (!token) {
                         const meta = document.querySelector(
                             SELECTORS.CSRF_META
@@ -366,6 +591,9 @@ window.gameApp = function () {
             if (this.currentGuess.length < WORD_LENGTH) {
                 this.currentGuess += letter;
                 this.updateDisplay();
+                if (this.currentGuess.length === WORD_LENGTH) {
+                    this.previewGuess(this.currentGuess);
+                }
             } else {
                 this.showToastNotification(
                     `Word is already ${WORD_LENGTH} letters! Press Enter to submit!`,
@@ -374,17 +602,149 @@ window.gameApp = function () {
                 this.shakeCurrentRow();
             }
         },
+        // previewGuess asks the server whether a full guess would be accepted so the
+        // player is warned before submitting. Failures are ignored; submit still validates.
+        async previewGuess(guess) {
+            if (window.vortludo?.ready || this.gameOver) return;
+            try {
+                const res = await fetch(
+                    `${VALIDATE_URL}?guess=${encodeURIComponent(guess)}`,
+                    { headers: { Accept: 'application/json' } }
+                );
+                if (!res.ok) return;
+                const result = await res.json();
+                if (result.valid || this.currentGuess !== guess) return;
+                const info = this.errorInfo(result.error_code);
+                this.showToastNotification(
+                    info?.text ?? result.message,
+                    info?.type ?? 'warning'
+                );
+                this.shakeCurrentRow();
+            } catch {
+                // Preview is best-effort.
+            }
+        },
         deleteLetter() {
             if (this.currentGuess.length > 0) {
                 this.currentGuess = this.currentGuess.slice(0, -1);
                 this.updateDisplay();
             }
         },
+        togglePurist() {
+            this.purist = !this.purist;
+            localStorage.setItem(PURIST_KEY, String(this.purist));
+            if (this.purist && this.kids) {
+                this.kids = false;
+                localStorage.setItem(KIDS_KEY, 'false');
+            }
+            this.savePreferences();
+            this.showToastNotification(
+                this.purist
+                    ? 'Purist mode on: your next game has no hints and separate stats.'
+                    : 'Purist mode off from your next game.',
+                'info'
+            );
+        },
+        toggleKids() {
+            this.kids = !this.kids;
+            localStorage.setItem(KIDS_KEY, String(this.kids));
+            if (this.kids && this.purist) {
+                this.purist = false;
+                localStorage.setItem(PURIST_KEY, 'false');
+            }
+            this.savePreferences();
+            this.showToastNotification(
+                this.kids
+                    ? 'Kids mode on: your next game has easy words, more tries, and hints.'
+                    : 'Kids mode off from your next game.',
+                'info'
+            );
+        },
+        // pushRegistration returns the service worker registration, or null when the browser or
+        // server does not support push notifications.
+        async pushRegistration() {
+            const key = document.querySelector(SELECTORS.VAPID_META)?.content;
+            if (!key || !('serviceWorker' in navigator) || !('PushManager' in window)) {
+                return null;
+            }
+            return navigator.serviceWorker.register(SERVICE_WORKER_URL);
+        },
+        async syncPushState() {
+            const registration = await this.pushRegistration().catch(() => null);
+            this.pushEnabled = !!(await registration?.pushManager.getSubscription());
+        },
+        // togglePush opts in to or out of "new puzzle" notifications. Opting in asks for quiet
+        // hours, given in the browser's own timezone.
+        async togglePush() {
+            try {
+                const registration = await this.pushRegistration();
+                if (!registration) {
+                    this.showToastNotification(
+                        'Notifications are not supported in this browser.',
+                        'warning'
+                    );
+                    return;
+                }
+                const existing = await registration.pushManager.getSubscription();
+                if (existing) {
+                    await existing.unsubscribe();
+                    await this.postPush('unsubscribe', { endpoint: existing.endpoint });
+                    this.pushEnabled = false;
+                    this.showToastNotification('Puzzle notifications off.', 'info');
+                    return;
+                }
+                const quiet = window.prompt(
+                    'Quiet hours, when no notification is sent (for example 22-7). Leave blank for none:',
+                    ''
+                );
+                if (quiet === null) return;
+                const [quietStart = '', quietEnd = ''] = quiet
+                    .split('-')
+                    .map((h) => h.trim());
+                const subscription = await registration.pushManager.subscribe({
+                    userVisibleOnly: true,
+                    applicationServerKey: base64URLToBytes(
+                        document.querySelector(SELECTORS.VAPID_META).content
+                    ),
+                });
+                const res = await this.postPush('subscribe', {
+                    endpoint: subscription.endpoint,
+                    timezone: Intl.DateTimeFormat().resolvedOptions().timeZone,
+                    quiet_start: quietStart,
+                    quiet_end: quietEnd,
+                });
+                if (!res.ok) {
+                    await subscription.unsubscribe();
+                    throw new Error(`status ${res.status}`);
+                }
+                this.pushEnabled = true;
+                this.showToastNotification(
+                    "Puzzle notifications on. We'll let you know when a new puzzle is out.",
+                    'success'
+                );
+            } catch {
+                this.showToastNotification(
+                    'Could not change puzzle notifications.',
+                    'warning'
+                );
+            }
+        },
+        postPush(action, fields) {
+            return fetch(`${PUSH_URL}/${action}`, {
+                method: 'POST',
+                headers: {
+                    Accept: 'application/json',
+                    'X-CSRF-Token': readCSRFCookie() || '',
+                },
+                body: new URLSearchParams(fields),
+            });
+        },
         toggleTheme() {
             this.isDarkMode = !this.isDarkMode;
             const theme = this.isDarkMode ? 'dark' : 'light';
             document.documentElement.setAttribute('data-bs-theme', theme);
             localStorage.setItem('theme', theme);
+            this.savePreferences();
         },
         updateGameState() {
             const board = document.querySelector(SELECTORS.GAME_BOARD);
@@ -394,7 +754,7 @@ window.gameApp = function () {
             if (errEl) {
                 const code = errEl.getAttribute('data-error-code');
                 if (code) {
-                    const info = this.errorCodeMessages[code] || {
+                    const info = this.errorInfo(code) || {
                         text: `An unexpected error occurred. (code: ${code})`,
                         type: 'error',
                     };
@@ -417,6 +777,11 @@ window.gameApp = function () {
             if (!wasGameOver && this.gameOver) {
                 this.hintVisible = false;
             }
+            if (this.gameOver) {
+                this.saveProgressToken(
+                    gameOverContainer.dataset.progressToken
+                );
+            }
 
             const rows = this.getGuessRows();
             let completedRows = 0;
@@ -434,9 +799,8 @@ window.gameApp = function () {
             });
 
             this.currentRow = Math.min(completedRows, rows.length - 1);
-            this.updateKeyboardColors(rows);
             this.animateNewGuess(rows);
-            this.checkForWin(rows, gameOverContainer);
+            this.checkForWin(rows);
         },
         submitGuess() {
             if (
@@ -463,42 +827,26 @@ window.gameApp = function () {
                 this.shakeCurrentRow();
                 return;
             }
+            if (
+                window.vortludo?.ready &&
+                !window.vortludo.isAccepted(this.currentGuess)
+            ) {
+                const info = this.errorInfo('word_not_accepted');
+                this.showToastNotification(info.text, info.type);
+                this.shakeCurrentRow();
+                return;
+            }
             this.submittingGuess = true;
             const guessInput = document.querySelector(SELECTORS.GUESS_INPUT);
             if (guessInput) {
                 guessInput.value = this.currentGuess;
             }
+            const rowInput = document.querySelector(SELECTORS.GUESS_ROW_INPUT);
+            if (rowInput) {
+                rowInput.value = this.currentRow;
+            }
             htmx.trigger(SELECTORS.GUESS_FORM, 'submit');
         },
-        updateKeyboardColors(rows) {
-            this.keyStatus = {};
-            rows.forEach((row) => {
-                const tiles = row.querySelectorAll(SELECTORS.FILLED_TILE);
-                tiles.forEach((tile) => {
-                    const letter = tile.textContent;
-                    const status = tile.classList.contains(CSS_CLASSES.CORRECT)
-                        ? 'correct'
-                        : tile.classList.contains(CSS_CLASSES.PRESENT)
-                        ? 'present'
-                        : tile.classList.contains(CSS_CLASSES.ABSENT)
-                        ? 'absent'
-                        : '';
-                    if (letter && status) {
-                        if (
-                            !this.keyStatus[letter] ||
-                            status === 'correct' ||
-                            (status === 'present' &&
-                                this.keyStatus[letter] === 'absent')
-                        ) {
-                            this.keyStatus[letter] = status;
-                        }
-                    }
-                });
-            });
-        },
-        getKeyClass(letter) {
-            return this.keyStatus[letter] ?? '';
-        },
         animateNewGuess(allRows) {
             const rows = allRows || this.getGameRows();
             const row = rows?.[this.currentRow - 1];
@@ -507,19 +855,24 @@ window.gameApp = function () {
             const tiles = row.querySelectorAll(SELECTORS.FILLED_TILE);
             if (tiles.length !== WORD_LENGTH) return;
 
+            // Reveal timing comes from the server-rendered data-reveal-* attributes; the CSS
+            // animation-delay staggers the flip and the colour switches halfway through it.
             tiles.forEach((tile, index) => {
-                tile.style.setProperty('--tile-index', index);
-                setTimeout(() => {
-                    tile.classList.add(CSS_CLASSES.FLIP);
-                    setTimeout(
-                        () => tile.classList.add(CSS_CLASSES.FLIP_REVEALED),
-                        300
-                    );
-                }, index * ANIMATION_DELAY);
+                const delay =
+                    Number(tile.dataset.revealDelay) || index * ANIMATION_DELAY;
+                tile.classList.add(CSS_CLASSES.FLIP);
+                setTimeout(
+                    () => tile.classList.add(CSS_CLASSES.FLIP_REVEALED),
+                    delay + 300
+                );
             });
             row.classList.add(CSS_CLASSES.ANIMATED);
             row.classList.remove('submitting');
 
+            const revealTotal =
+                Number(row.dataset.revealTotal) ||
+                WORD_LENGTH * ANIMATION_DELAY + 400;
+
             setTimeout(() => {
                 const sr = document.querySelector(SELECTORS.SR_LIVE);
                 if (sr) {
@@ -540,9 +893,9 @@ window.gameApp = function () {
                         this.currentRow
                     } revealed: ${parts.join(', ')}.`;
                 }
-            }, WORD_LENGTH * ANIMATION_DELAY + 400);
+            }, revealTotal);
         },
-        checkForWin(allRows, gameOverContainer) {
+        checkForWin(allRows) {
             const rows = allRows || this.getGameRows();
             const winningRow = Array.from(rows).find(
                 (row) =>
@@ -554,21 +907,8 @@ window.gameApp = function () {
                 this.gameOver = true;
                 if (!winningRow.classList.contains(CSS_CLASSES.WINNER)) {
                     winningRow.classList.add(CSS_CLASSES.WINNER);
-                    winningRow
-                        .querySelectorAll(SELECTORS.TILE)
-                        .forEach((tile, index) => {
-                            tile.style.setProperty('--tile-index', index);
-                        });
                 }
                 this.launchConfetti();
-                const word = Array.from(
-                    winningRow.querySelectorAll('.' + CSS_CLASSES.CORRECT)
-                )
-                    .map((tile) => tile.textContent)
-                    .join('');
-                if (word) {
-                    this.saveCompletedWord(word.toUpperCase());
-                }
             } else {
                 const completedRowCount = Array.from(rows).filter((row) => {
                     const tiles = row.querySelectorAll(SELECTORS.FILLED_TILE);
@@ -583,7 +923,7 @@ window.gameApp = function () {
                     );
                 }).length;
 
-                if (completedRowCount === MAX_GUESSES) {
+                if (completedRowCount === rows.length) {
                     setTimeout(
                         () =>
                             this.showToastNotification(
@@ -592,10 +932,6 @@ window.gameApp = function () {
                             ),
                         1000
                     );
-                    const solution = gameOverContainer?.dataset.solution;
-                    if (solution) {
-                        this.saveCompletedWord(solution.toUpperCase());
-                    }
                 }
             }
         },
@@ -680,9 +1016,11 @@ window.gameApp = function () {
                 }
             });
 
-            let emojiGrid = `Vortludo ${
-                hasWon ? completedRowCount : 'X'
-            }/6\n\n`;
+            const mode =
+                document.querySelector(SELECTORS.GAME_BOARD)?.dataset.mode;
+            let emojiGrid = `Vortludo ${hasWon ? completedRowCount : 'X'}/${
+                this.getGuessRows().length
+            }${mode ? ` (${mode})` : ''}\n\n`;
 
             rows.forEach((row) => {
                 const tiles = row.querySelectorAll(SELECTORS.FILLED_TILE);
@@ -701,16 +1039,20 @@ window.gameApp = function () {
                     emojiGrid += '\n';
                 }
             });
+
+            const dailySummary = document
+                .querySelector(SELECTORS.DAILY_SUMMARY)
+                ?.textContent.trim();
+            if (dailySummary) {
+                emojiGrid += `\n${dailySummary}`;
+            }
             this.copyToClipboard(emojiGrid.trim());
         },
-        async copyToClipboard(text) {
+        async copyToClipboard(text, message = 'Results copied to clipboard!') {
             try {
                 if (navigator.clipboard && window.isSecureContext) {
                     await navigator.clipboard.writeText(text);
-                    this.showToastNotification(
-                        'Results copied to clipboard!',
-                        'success'
-                    );
+                    this.showToastNotification(message, 'success');
                     return;
                 }
                 this.openCopyModal(text);
@@ -722,6 +1064,186 @@ window.gameApp = function () {
                 );
             }
         },
+        // shareSpectateLink opts this session into spectating and copies the read-only link.
+        async shareSpectateLink() {
+            try {
+                const res = await fetch(SPECTATE_URL, {
+                    method: 'POST',
+                    headers: {
+                        Accept: 'application/json',
+                        'X-CSRF-Token': readCSRFCookie() || '',
+                    },
+                });
+                if (!res.ok) throw new Error(`status ${res.status}`);
+                const { url } = await res.json();
+                await this.copyToClipboard(
+                    new URL(url, window.location.origin).href,
+                    'Spectate link copied to clipboard!'
+                );
+            } catch {
+                this.showToastNotification(
+                    'Could not create a spectate link.',
+                    'warning'
+                );
+            }
+        },
+        // copyCalendarLink copies this player's calendar feed URL, which lists upcoming daily
+        // puzzles and, for a remembered player, reminders on days their streak is at risk.
+        async copyCalendarLink() {
+            try {
+                const res = await fetch(CALENDAR_LINK_URL, {
+                    headers: { Accept: 'application/json' },
+                });
+                if (!res.ok) throw new Error(`status ${res.status}`);
+                const { url } = await res.json();
+                await this.copyToClipboard(
+                    url,
+                    'Calendar link copied! Add it to your calendar app as a subscription.'
+                );
+            } catch {
+                this.showToastNotification(
+                    'Could not get a calendar link.',
+                    'warning'
+                );
+            }
+        },
+        copyChallengeLink(url) {
+            this.copyToClipboard(
+                new URL(url, window.location.origin).href,
+                'Challenge link copied to clipboard!'
+            );
+        },
+        // playWithFriends joins a team by code, or starts a new shared game and copies its code.
+        // A team link (/?room=CODE) passes the code in directly.
+        async playWithFriends(
+            code = window.prompt(
+                'Enter a team code to join, or leave blank to start a new team:'
+            )
+        ) {
+            if (code === null) return;
+            const joining = code.trim() !== '';
+            try {
+                const res = await fetch(
+                    joining ? `${ROOM_URL}/join` : ROOM_URL,
+                    {
+                        method: 'POST',
+                        headers: {
+                            Accept: 'application/json',
+                            'X-CSRF-Token': readCSRFCookie() || '',
+                        },
+                        body: new URLSearchParams({ code: code.trim() }),
+                    }
+                );
+                if (!res.ok) throw new Error(`status ${res.status}`);
+                const room = await res.json();
+                this.roomCode = room.code;
+                this.refreshBoard();
+                this.pollRoom();
+                if (joining) {
+                    this.showToastNotification(
+                        `Joined team ${room.code}!`,
+                        'success'
+                    );
+                } else {
+                    const link = new URL('/', window.location.origin);
+                    link.searchParams.set('room', room.code);
+                    await this.copyToClipboard(
+                        link.href,
+                        `Team ${room.code} link copied to clipboard!`
+                    );
+                }
+            } catch {
+                this.showToastNotification(
+                    joining
+                        ? 'Could not join that team.'
+                        : 'Could not start a team game.',
+                    'warning'
+                );
+            }
+        },
+        // joinRoomFromURL joins the team in a ?room= invite link, then drops it from the URL.
+        joinRoomFromURL() {
+            const url = new URL(window.location.href);
+            const code = url.searchParams.get('room');
+            if (!code) return false;
+            url.searchParams.delete('room');
+            history.replaceState(null, '', url.pathname + url.search);
+            this.playWithFriends(code);
+            return true;
+        },
+        // joinClassFromURL joins the classroom in a ?class= link, then drops it from the URL.
+        // Every student in the classroom plays the same word on a board of their own.
+        joinClassFromURL() {
+            const url = new URL(window.location.href);
+            const code = url.searchParams.get('class');
+            if (!code) return false;
+            url.searchParams.delete('class');
+            history.replaceState(null, '', url.pathname + url.search);
+            this.joinClass(code);
+            return true;
+        },
+        async joinClass(code) {
+            try {
+                const res = await fetch(`${CLASSROOM_URL}/join`, {
+                    method: 'POST',
+                    headers: {
+                        Accept: 'application/json',
+                        'X-CSRF-Token': readCSRFCookie() || '',
+                    },
+                    body: new URLSearchParams({ code: code.trim() }),
+                });
+                if (!res.ok) throw new Error(`status ${res.status}`);
+                const classroom = await res.json();
+                clearTimeout(this._roomTimer);
+                this.roomCode = '';
+                this.refreshBoard();
+                this.showToastNotification(
+                    `Joined classroom ${classroom.code}!`,
+                    'success'
+                );
+            } catch {
+                this.showToastNotification(
+                    'Could not join that classroom.',
+                    'warning'
+                );
+            }
+        },
+        // pollRoom checks the shared board while this session is in a team and reloads the
+        // board when a teammate has guessed.
+        async pollRoom() {
+            clearTimeout(this._roomTimer);
+            try {
+                const res = await fetch(`${ROOM_URL}/state`, {
+                    headers: { Accept: 'application/json' },
+                });
+                if (!res.ok) {
+                    this.roomCode = '';
+                    return;
+                }
+                const room = await res.json();
+                this.roomCode = room.code;
+                if (
+                    !this.submittingGuess &&
+                    (room.row !== this.currentRow ||
+                        room.game_over !== this.gameOver)
+                ) {
+                    this.refreshBoard();
+                }
+            } catch {
+                // Network hiccup; try again on the next tick.
+            }
+            this._roomTimer = setTimeout(
+                () => this.pollRoom(),
+                ROOM_POLL_INTERVAL
+            );
+        },
+        refreshBoard() {
+            this.clearDOMCache();
+            htmx.ajax('GET', '/game-state', {
+                target: SELECTORS.GAME_CONTENT_CONTAINER,
+                swap: 'innerHTML',
+            });
+        },
         openCopyModal(text) {
             this.copyModalText = text;
             const modalEl = document.querySelector(SELECTORS.COPY_MODAL);
@@ -756,6 +1278,13 @@ window.gameApp = function () {
                 );
             }
         },
+        withErrorRef(message, requestId) {
+            // The short reference matches the start of the request ID in the server logs.
+            if (!requestId) {
+                return message;
+            }
+            return `${message} (error ref: ${String(requestId).slice(0, 8)})`;
+        },
         showToastNotification(message, type = 'info') {
             this.toastMessage = message;
             this.toastType = type;
@@ -768,26 +1297,29 @@ window.gameApp = function () {
         shouldHideKeyboard() {
             return this.gameOver;
         },
-        getCompletedWords() {
+        currentPack() {
+            return (
+                document.querySelector(SELECTORS.GAME_BOARD)?.dataset.pack ||
+                DEFAULT_PACK
+            );
+        },
+        getProgressToken(pack = this.currentPack()) {
             try {
-                const completed = localStorage.getItem(COMPLETED_WORDS_KEY);
-                return completed ? JSON.parse(completed) : [];
+                return localStorage.getItem(progressKey(pack)) || '';
             } catch {
                 this._storageErrorToast('load');
-                return [];
+                return '';
             }
         },
-        saveCompletedWord(word) {
+        // saveProgressToken stores the server-signed progress issued when a game ends.
+        saveProgressToken(token) {
+            if (!token) return;
             try {
-                const completed = this.getCompletedWords();
-                if (!completed.includes(word)) {
-                    completed.push(word);
-                    localStorage.setItem(
-                        COMPLETED_WORDS_KEY,
-                        JSON.stringify(completed)
-                    );
+                const pack = this.currentPack();
+                if (this.getProgressToken(pack) !== token) {
+                    localStorage.setItem(progressKey(pack), token);
                     this.showToastNotification(
-                        `Word "${word}" added to your completed list!`,
+                        'Word added to your completed list!',
                         'success'
                     );
                 }
@@ -795,9 +1327,9 @@ window.gameApp = function () {
                 this._storageErrorToast('save');
             }
         },
-        clearCompletedWords() {
+        clearCompletedWords(pack = this.currentPack()) {
             try {
-                localStorage.removeItem(COMPLETED_WORDS_KEY);
+                localStorage.removeItem(progressKey(pack));
                 this.showToastNotification(
                     "🎉 Congratulations! You've completed all words! Progress reset.",
                     'success'
@@ -806,6 +1338,30 @@ window.gameApp = function () {
                 this._storageErrorToast('clear');
             }
         },
+        // dropStaleProgress forgets progress signed against a word list the server no longer serves.
+        dropStaleProgress(pack = this.currentPack()) {
+            try {
+                localStorage.removeItem(progressKey(pack));
+                this.showToastNotification(
+                    'The word list was updated, so your completed words were reset.',
+                    'info'
+                );
+            } catch {
+                this._storageErrorToast('clear');
+            }
+        },
+        // dropLegacyCompletedWords removes word lists stored before progress became server-signed.
+        dropLegacyCompletedWords() {
+            try {
+                Object.keys(localStorage)
+                    .filter((key) =>
+                        key.startsWith(LEGACY_COMPLETED_WORDS_KEY)
+                    )
+                    .forEach((key) => localStorage.removeItem(key));
+            } catch {
+                // Storage may be unavailable; nothing to clean up.
+            }
+        },
         _storageErrorToast(action) {
             const messages = {
                 load: 'Could not load completed words from your browser storage.',
@@ -818,14 +1374,25 @@ window.gameApp = function () {
             );
         },
         prepareNewGameData(event) {
-            const completedWords = this.getCompletedWords();
             const form = event.target;
-            const completedWordsInput = form.querySelector(
-                'input[name="completedWords"]'
-            );
-            if (completedWordsInput && completedWords.length > 0) {
-                completedWordsInput.value = JSON.stringify(completedWords);
+            const pack = form.elements.pack?.value || this.currentPack();
+            const token = this.getProgressToken(pack);
+            const progressInput = form.querySelector('input[name="progress"]');
+            if (progressInput && token) {
+                progressInput.value = token;
+            }
+            let modeInput = form.elements.mode;
+            if (!modeInput) {
+                modeInput = document.createElement('input');
+                modeInput.type = 'hidden';
+                modeInput.name = 'mode';
+                form.appendChild(modeInput);
             }
+            modeInput.value = this.kids
+                ? MODE_KIDS
+                : this.purist
+                  ? MODE_PURIST
+                  : '';
         },
     };
 };
@@ -0,0 +1,30 @@
+(async () => {
+    if (typeof Go === 'undefined' || !('WebAssembly' in window)) {
+        return;
+    }
+    try {
+        const go = new Go();
+        const result = await WebAssembly.instantiateStreaming(
+            fetch('/static/engine.wasm'),
+            go.importObject
+        );
+        go.run(result.instance);
+        // The version query keeps cached lists from outliving a word list update.
+        const version =
+            document.querySelector('meta[name="word-list-version"]')
+                ?.content || '';
+        // Modes with their own accepted words fetch that list instead.
+        const mode =
+            document.querySelector('meta[name="accepted-words-mode"]')
+                ?.content || '';
+        const response = await fetch(
+            `/accepted-words?v=${encodeURIComponent(version)}` +
+                (mode ? `&mode=${encodeURIComponent(mode)}` : '')
+        );
+        if (response.ok && window.vortludo) {
+            window.vortludo.loadAcceptedWords(await response.text());
+        }
+    } catch (err) {
+        console.warn('Client-side engine unavailable:', err);
+    }
+})();
@@ -0,0 +1,31 @@
+// Service worker for "new puzzle" notifications. Pushes carry no payload, so every
+// notification shows the same message and opens the daily puzzle.
+const DAILY_URL = '/daily';
+
+self.addEventListener('push', (event) => {
+    event.waitUntil(
+        self.registration.showNotification('New Vortludo puzzle', {
+            body: "Today's puzzle is ready. Can you get it in six?",
+            icon: '/static/favicons/android-chrome-192x192.png',
+            tag: 'daily-puzzle',
+        })
+    );
+});
+
+self.addEventListener('notificationclick', (event) => {
+    event.notification.close();
+    event.waitUntil(
+        self.clients
+            .matchAll({ type: 'window', includeUncontrolled: true })
+            .then((windows) => {
+                const open = windows.find((w) => 'navigate' in w);
+                if (!open) {
+                    return self.clients.openWindow(DAILY_URL);
+                }
+                return open
+                    .navigate(DAILY_URL)
+                    .catch(() => null)
+                    .then((w) => (w || open).focus());
+            })
+    );
+});
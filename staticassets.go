@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AcceptCH lists the client hints requested from browsers; Sec-CH-DPR picks high-density images.
+const AcceptCH = "Sec-CH-DPR"
+
+// HighDPRThreshold is the device pixel ratio from which @2x image variants are preferred.
+const HighDPRThreshold = 1.5
+
+// imageFormat is a modern image encoding served in place of the original when accepted.
+type imageFormat struct {
+	MediaType string
+	Ext       string
+}
+
+// imageFormats are the alternative encodings in order of preference.
+var imageFormats = []imageFormat{
+	{MediaType: "image/avif", Ext: ".avif"},
+	{MediaType: "image/webp", Ext: ".webp"},
+}
+
+// variantSourceExts are the image types that may have AVIF, WebP, or @2x variants on disk.
+var variantSourceExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true}
+
+// clientHintsMiddleware asks browsers, on page responses, to send the client hints used to
+// pick image variants.
+func clientHintsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, "/static/") {
+			c.Header("Accept-CH", AcceptCH)
+		}
+		c.Next()
+	}
+}
+
+// staticHandler serves files from dir under /static, swapping images for an AVIF, WebP, or @2x
+// variant stored next to the original when the client supports it. Vary is only added for
+// files that have variants, so other assets keep a single cache entry.
+func staticHandler(dir string) gin.HandlerFunc {
+	fsys := gin.Dir(dir, false)
+	server := http.StripPrefix("/static", http.FileServer(fsys))
+	return func(c *gin.Context) {
+		name := path.Clean("/" + c.Param("filepath"))
+		if variantSourceExts[strings.ToLower(path.Ext(name))] {
+			chosen, vary := pickImageVariant(fsys, name, c.GetHeader("Accept"), requestDPR(c.Request))
+			for _, v := range vary {
+				c.Writer.Header().Add("Vary", v)
+			}
+			if chosen != name {
+				c.Request.URL.Path = "/static" + chosen
+				c.Request.URL.RawPath = ""
+			}
+		}
+		server.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// pickImageVariant returns the best file to serve for name and the request headers the choice
+// depends on. Higher density wins over format, and the original is the fallback.
+func pickImageVariant(fsys http.FileSystem, name, accept string, dpr float64) (string, []string) {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	dense := base + "@2x" + ext
+
+	var vary []string
+	hasDense := fileInFS(fsys, dense)
+	if hasDense {
+		vary = append(vary, AcceptCH)
+	}
+	for _, f := range imageFormats {
+		if fileInFS(fsys, base+f.Ext) || (hasDense && fileInFS(fsys, base+"@2x"+f.Ext)) {
+			vary = append(vary, "Accept")
+			break
+		}
+	}
+
+	candidates := []string{name}
+	if hasDense && dpr >= HighDPRThreshold {
+		candidates = []string{dense, name}
+	}
+	for _, candidate := range candidates {
+		stem := strings.TrimSuffix(candidate, ext)
+		for _, f := range imageFormats {
+			if acceptsMediaType(accept, f.MediaType) && fileInFS(fsys, stem+f.Ext) {
+				return stem + f.Ext, vary
+			}
+		}
+		if fileInFS(fsys, candidate) {
+			return candidate, vary
+		}
+	}
+	return name, vary
+}
+
+// fileInFS reports whether name exists in fsys as a regular file.
+func fileInFS(fsys http.FileSystem, name string) bool {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	return err == nil && info.Mode().IsRegular()
+}
+
+// acceptsMediaType reports whether an Accept header lists mediaType with a non-zero quality.
+func acceptsMediaType(accept, mediaType string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(fields[0]), mediaType) {
+			continue
+		}
+		for _, param := range fields[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(key) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// requestDPR returns the device pixel ratio from the Sec-CH-DPR or legacy DPR hint, or 1.
+func requestDPR(r *http.Request) float64 {
+	for _, header := range []string{"Sec-CH-DPR", "DPR"} {
+		if v := r.Header.Get(header); v != "" {
+			if dpr, err := strconv.ParseFloat(v, 64); err == nil && dpr > 0 {
+				return dpr
+			}
+		}
+	}
+	return 1
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStaticHandlerImageVariants(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"logo.png":     "png",
+		"logo.webp":    "webp",
+		"logo.avif":    "avif",
+		"logo@2x.png":  "png2x",
+		"logo@2x.webp": "webp2x",
+		"plain.png":    "plain",
+		"style.css":    "css",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := gin.New()
+	router.GET("/static/*filepath", staticHandler(dir))
+
+	tests := []struct {
+		name, path, accept, dpr, want, vary string
+	}{
+		{"original", "/static/logo.png", "image/png", "", "png", "Sec-CH-DPR,Accept"},
+		{"avif preferred", "/static/logo.png", "image/avif,image/webp,*/*", "", "avif", "Sec-CH-DPR,Accept"},
+		{"webp", "/static/logo.png", "image/webp,*/*", "", "webp", "Sec-CH-DPR,Accept"},
+		{"avif refused", "/static/logo.png", "image/avif;q=0,image/webp", "", "webp", "Sec-CH-DPR,Accept"},
+		{"high density", "/static/logo.png", "image/avif,image/webp", "2", "webp2x", "Sec-CH-DPR,Accept"},
+		{"high density original", "/static/logo.png", "image/png", "3", "png2x", "Sec-CH-DPR,Accept"},
+		{"no variants", "/static/plain.png", "image/avif,image/webp", "2", "plain", ""},
+		{"not an image", "/static/style.css", "image/avif", "", "css", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			if tt.dpr != "" {
+				req.Header.Set("Sec-CH-DPR", tt.dpr)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+			if got := strings.Join(w.Header().Values("Vary"), ","); got != tt.vary {
+				t.Errorf("Vary = %q, want %q", got, tt.vary)
+			}
+		})
+	}
+}
+
+func TestClientHintsMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(clientHintsMiddleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/static/*filepath", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := w.Header().Get("Accept-CH"); got != AcceptCH {
+		t.Errorf("Accept-CH on page = %q, want %q", got, AcceptCH)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/static/client.js", nil))
+	if got := w.Header().Get("Accept-CH"); got != "" {
+		t.Errorf("Accept-CH on static asset = %q, want none", got)
+	}
+}
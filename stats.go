@@ -0,0 +1,336 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Global stats limits
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+// gameOutcome is a single finished game queued for aggregation.
+type gameOutcome struct {
+	Date    string
+	Won     bool
+	Guesses int
+}
+
+// dailyAggregate is the rolled-up outcome of every game finished on one puzzle date.
+type dailyAggregate struct {
+	Date         string          `json:"date"`
+	Played       int             `json:"played"`
+	Won          int             `json:"won"`
+	WinGuesses   int             `json:"winGuesses"`
+	Distribution [MaxGuesses]int `json:"distribution"`
+}
+
+// add folds another aggregate or outcome total into d.
+func (d *dailyAggregate) add(o dailyAggregate) {
+	d.Played += o.Played
+	d.Won += o.Won
+	d.WinGuesses += o.WinGuesses
+	for i, n := range o.Distribution {
+		d.Distribution[i] += n
+	}
+}
+
+// statsView is the public form of an aggregate with derived rates.
+type statsView struct {
+	Date           string          `json:"date,omitempty"`
+	Played         int             `json:"played"`
+	Won            int             `json:"won"`
+	SolveRate      float64         `json:"solve_rate"`
+	AverageGuesses float64         `json:"average_guesses"`
+	Distribution   [MaxGuesses]int `json:"distribution"`
+}
+
+// view derives solve rate and average winning guesses from d.
+func (d dailyAggregate) view() statsView {
+	v := statsView{Date: d.Date, Played: d.Played, Won: d.Won, Distribution: d.Distribution}
+	if d.Played > 0 {
+		v.SolveRate = float64(d.Won) / float64(d.Played)
+	}
+	if d.Won > 0 {
+		v.AverageGuesses = float64(d.WinGuesses) / float64(d.Won)
+	}
+	return v
+}
+
+// GlobalStats rolls finished games up into per-day aggregates in the background, so global stats
+// are served from a small summary instead of scanning sessions on demand. Aggregates are
+// persisted to a JSON file keyed by YYYY-MM-DD, or by YYYY-MM once the retention policy has
+// rolled old days up into months.
+type GlobalStats struct {
+	mu      sync.RWMutex
+	storage Storage
+	path    string
+	days    map[string]*dailyAggregate
+	dirty   bool
+	events  chan gameOutcome
+	done    chan struct{}
+	// leader reports whether this replica writes the aggregates; nil means it always does.
+	leader func() bool
+}
+
+// newGlobalStats creates an empty aggregator persisted at path (empty path disables persistence).
+func newGlobalStats(path string) *GlobalStats {
+	return &GlobalStats{storage: DirStorage{}, path: path, days: make(map[string]*dailyAggregate)}
+}
+
+// load reads aggregates from disk. A missing file is not an error.
+func (gs *GlobalStats) load() error {
+	var days []*dailyAggregate
+	if found, err := readJSONFile(gs.storage, gs.path, &days); err != nil || !found {
+		return err
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	for _, day := range days {
+		if _, monthErr := time.Parse(statsMonthLayout, day.Date); monthErr == nil {
+			gs.days[day.Date] = day
+			continue
+		}
+		if _, err := parsePuzzleDate(day.Date); err != nil {
+			logWarn("Skipping invalid global stats date %q: %v", day.Date, err)
+			continue
+		}
+		gs.days[day.Date] = day
+	}
+	return nil
+}
+
+// save writes the aggregates to disk atomically. Callers must hold gs.mu.
+func (gs *GlobalStats) save() error {
+	days := slices.SortedFunc(maps.Values(gs.days), func(a, b *dailyAggregate) int {
+		return cmp.Compare(a.Date, b.Date)
+	})
+	return writeJSONFile(gs.storage, gs.path, days)
+}
+
+// start launches the aggregation goroutine, which folds queued outcomes into the daily
+// aggregates and flushes them to disk every flushInterval.
+func (gs *GlobalStats) start(flushInterval time.Duration) {
+	gs.events = make(chan gameOutcome, 1024)
+	gs.done = make(chan struct{})
+	go gs.run(flushInterval)
+}
+
+// run aggregates outcomes until the queue is closed, then flushes once more.
+func (gs *GlobalStats) run(flushInterval time.Duration) {
+	defer close(gs.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case outcome, ok := <-gs.events:
+			if !ok {
+				gs.flush()
+				return
+			}
+			gs.apply(outcome)
+		case <-ticker.C:
+			gs.flush()
+		}
+	}
+}
+
+// stop drains the queue and writes the final aggregates.
+func (gs *GlobalStats) stop() {
+	if gs == nil || gs.events == nil {
+		return
+	}
+	close(gs.events)
+	<-gs.done
+}
+
+// apply folds one outcome into its day's aggregate.
+func (gs *GlobalStats) apply(o gameOutcome) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	day, ok := gs.days[o.Date]
+	if !ok {
+		day = &dailyAggregate{Date: o.Date}
+		gs.days[o.Date] = day
+	}
+	day.Played++
+	if o.Won && o.Guesses >= 1 && o.Guesses <= MaxGuesses {
+		day.Won++
+		day.WinGuesses += o.Guesses
+		day.Distribution[o.Guesses-1]++
+	}
+	gs.dirty = true
+}
+
+// flush saves the aggregates if anything changed since the last save and this replica holds
+// the stats lease. Other replicas keep their changes pending in case they take over.
+func (gs *GlobalStats) flush() {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if !gs.dirty || (gs.leader != nil && !gs.leader()) {
+		return
+	}
+	if err := gs.save(); err != nil {
+		logWarn("Failed to save global stats: %v", err)
+		return
+	}
+	gs.dirty = false
+}
+
+// record queues an outcome for aggregation, dropping it if the queue is full so requests never block.
+func (gs *GlobalStats) record(o gameOutcome) {
+	if gs.events == nil {
+		gs.apply(o)
+		return
+	}
+	select {
+	case gs.events <- o:
+	default:
+		logWarn("Global stats queue full, dropping outcome for %s", o.Date)
+	}
+}
+
+// recent returns the aggregates for the last n puzzle dates ending at end, oldest first, along
+// with their combined totals. Days without games are included with zero counts.
+func (gs *GlobalStats) recent(end time.Time, n int) ([]statsView, statsView) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	var total dailyAggregate
+	views := make([]statsView, 0, n)
+	for i := n - 1; i >= 0; i-- {
+		date := end.AddDate(0, 0, -i).Format(time.DateOnly)
+		day := dailyAggregate{Date: date}
+		if agg, ok := gs.days[date]; ok {
+			day = *agg
+		}
+		total.add(day)
+		views = append(views, day.view())
+	}
+	return views, total.view()
+}
+
+// day returns the aggregate for one puzzle date, with zero counts if no games finished on it.
+func (gs *GlobalStats) day(date string) statsView {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	if agg, ok := gs.days[date]; ok {
+		return agg.view()
+	}
+	return dailyAggregate{Date: date}.view()
+}
+
+// dailySolveSummary describes how everyone did on a daily puzzle for the game-over panel and
+// share text. It is empty until the game is over, for games other than the daily puzzle, and
+// before anyone has finished the puzzle.
+func (app *App) dailySolveSummary(game *GameState) string {
+	if app.Stats == nil || game == nil || !game.GameOver || game.Daily == "" {
+		return ""
+	}
+	puzzle := app.Stats.day(game.Daily)
+	if puzzle.Played == 0 {
+		return ""
+	}
+	summary := fmt.Sprintf("%d%% of players solved this puzzle", int(math.Round(puzzle.SolveRate*100)))
+	if puzzle.Won > 0 {
+		summary += fmt.Sprintf(", average %.1f guesses", puzzle.AverageGuesses)
+	}
+	return summary
+}
+
+// recordGameOutcome queues a finished daily game for the global stats under its puzzle date.
+// Other games are on words of their own, so they are left out.
+func (app *App) recordGameOutcome(c *gin.Context, game *GameState) {
+	if app.Stats == nil || game.Daily == "" || doNotTrack(c) {
+		return
+	}
+	outcome := gameOutcome{
+		Date:    game.Daily,
+		Won:     game.Won,
+		Guesses: len(game.GuessHistory),
+	}
+	app.Stats.record(outcome)
+	// With one replica writing the aggregates, every replica needs every outcome so whichever
+	// holds the lease has the full picture. Publishing must not hold up the guess, and runs
+	// after the request is done, so it must not use the request's context.
+	if app.Leases != nil && app.Fleet != nil {
+		ctx := context.WithoutCancel(c.Request.Context())
+		go app.broadcast(ctx, FleetEvent{Type: FleetStatsOutcome, Date: outcome.Date, Won: outcome.Won, Guesses: outcome.Guesses})
+	}
+}
+
+// statsDays parses the days query parameter, clamped to [1, maxStatsDays].
+func statsDays(c *gin.Context) int {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultStatsDays)))
+	if err != nil || days < 1 {
+		return defaultStatsDays
+	}
+	return min(days, maxStatsDays)
+}
+
+// globalStats returns the aggregates for the requested window ending at today's puzzle date.
+func (app *App) globalStats(c *gin.Context) ([]statsView, statsView) {
+	if app.Stats == nil {
+		return nil, dailyAggregate{}.view()
+	}
+	end := app.Daily.puzzleDate(app.now(), app.Daily.Location)
+	return app.Stats.recent(end, statsDays(c))
+}
+
+// globalStatsHandler returns solve rate, average guesses, and the guess distribution across
+// all players, overall and per day.
+func (app *App) globalStatsHandler(c *gin.Context) {
+	days, total := app.globalStats(c)
+	c.JSON(http.StatusOK, gin.H{
+		"total": total,
+		"days":  days,
+	})
+}
+
+// statsBar is one day of the admin chart, scaled against the busiest day shown.
+type statsBar struct {
+	statsView
+	HeightPercent int
+	SolvePercent  int
+}
+
+// adminStatsHandler renders the global stats as a chart page for operators.
+func (app *App) adminStatsHandler(c *gin.Context) {
+	days, total := app.globalStats(c)
+	maxPlayed := 1
+	for _, day := range days {
+		maxPlayed = max(maxPlayed, day.Played)
+	}
+	bars := make([]statsBar, len(days))
+	for i, day := range days {
+		bars[i] = statsBar{
+			statsView:     day,
+			HeightPercent: day.Played * 100 / maxPlayed,
+			SolvePercent:  int(day.SolveRate * 100),
+		}
+	}
+	distribution := make([]gin.H, len(total.Distribution))
+	for i, n := range total.Distribution {
+		distribution[i] = gin.H{"Guesses": i + 1, "Count": n}
+	}
+	c.HTML(http.StatusOK, "admin-stats.html", gin.H{
+		"title":        "Vortludo - Global Stats",
+		"total":        total,
+		"solvePercent": int(total.SolveRate * 100),
+		"bars":         bars,
+		"distribution": distribution,
+		"integrity":    app.Integrity.latest(),
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestGlobalStatsAggregation(t *testing.T) {
+	st := newMemStorage()
+	gs := newGlobalStats("global-stats.json")
+	gs.storage = st
+	gs.start(time.Hour)
+	gs.record(gameOutcome{Date: "2026-03-01", Won: true, Guesses: 3})
+	gs.record(gameOutcome{Date: "2026-03-01", Won: true, Guesses: 5})
+	gs.record(gameOutcome{Date: "2026-03-01", Won: false, Guesses: MaxGuesses})
+	gs.record(gameOutcome{Date: "2026-03-02", Won: true, Guesses: 1})
+	gs.stop()
+
+	reloaded := newGlobalStats("global-stats.json")
+	reloaded.storage = st
+	if err := reloaded.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	end, _ := parsePuzzleDate("2026-03-02")
+	days, total := reloaded.recent(end, 3)
+	if len(days) != 3 || days[0].Played != 0 || days[1].Played != 3 || days[2].Played != 1 {
+		t.Fatalf("Unexpected daily aggregates: %+v", days)
+	}
+	if days[1].Won != 2 || days[1].AverageGuesses != 4 {
+		t.Errorf("Expected 2 wins averaging 4 guesses, got %+v", days[1])
+	}
+	if total.Played != 4 || total.SolveRate != 0.75 || total.Distribution[0] != 1 || total.Distribution[2] != 1 {
+		t.Errorf("Unexpected totals: %+v", total)
+	}
+}
+
+func TestDailySolveSummary(t *testing.T) {
+	app := &App{Stats: newGlobalStats(""), Daily: newDailySchedule("", 0, false), Clock: newFakeClock()}
+	today := app.Daily.puzzleDate(app.now(), app.Daily.Location).Format(time.DateOnly)
+	game := &GameState{GameState: engine.GameState{GameOver: true, Won: true}, Daily: today}
+
+	if got := app.dailySolveSummary(game); got != "" {
+		t.Errorf("summary before any games = %q, want empty", got)
+	}
+	app.Stats.record(gameOutcome{Date: today, Won: false, Guesses: MaxGuesses})
+	if got, want := app.dailySolveSummary(game), "0% of players solved this puzzle"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+	app.Stats.record(gameOutcome{Date: today, Won: true, Guesses: 3})
+	app.Stats.record(gameOutcome{Date: today, Won: true, Guesses: 4})
+	if got, want := app.dailySolveSummary(game), "67% of players solved this puzzle, average 3.5 guesses"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+
+	if got := app.dailySolveSummary(&GameState{}); got != "" {
+		t.Errorf("summary for an unfinished game = %q, want empty", got)
+	}
+	if got := app.dailySolveSummary(&GameState{GameState: engine.GameState{GameOver: true}, Custom: true}); got != "" {
+		t.Errorf("summary for a custom game = %q, want empty", got)
+	}
+	if got := app.dailySolveSummary(&GameState{GameState: engine.GameState{GameOver: true}}); got != "" {
+		t.Errorf("summary for a game off the daily puzzle = %q, want empty", got)
+	}
+}
+
+func TestRecordGameOutcomeOnlyCountsDailyGames(t *testing.T) {
+	app := &App{Stats: newGlobalStats(""), Daily: newDailySchedule("", 0, false), Clock: newFakeClock()}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", RouteGuess, nil)
+	yesterday := app.Daily.puzzleDate(app.now(), app.Daily.Location).AddDate(0, 0, -1).Format(time.DateOnly)
+
+	app.recordGameOutcome(c, &GameState{GameState: engine.GameState{GameOver: true, Won: true, GuessHistory: []string{"CRANE"}}})
+	app.recordGameOutcome(c, &GameState{GameState: engine.GameState{GameOver: true, Won: true, GuessHistory: []string{"SLATE", "CRANE"}}, Daily: yesterday})
+	_, total := app.Stats.recent(app.Daily.puzzleDate(app.now(), app.Daily.Location), 2)
+	if total.Played != 1 || app.Stats.day(yesterday).Played != 1 || total.Distribution[1] != 1 {
+		t.Errorf("totals = %+v, want only the daily game, under its own puzzle date", total)
+	}
+}
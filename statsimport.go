@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportedGames bounds imported counters to reject obviously bogus stats.
+const maxImportedGames = 100000
+
+// importSourcePattern restricts import source names, such as "nyt" or "wordle-clone".
+var importSourcePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Stats import validation errors, returned to the client.
+var (
+	errImportSource       = errors.New("source must be a short lowercase name")
+	errImportRange        = errors.New("counts must be between 0 and 100000")
+	errImportWonPlayed    = errors.New("gamesWon cannot exceed gamesPlayed")
+	errImportStreak       = errors.New("streaks cannot exceed gamesWon and currentStreak cannot exceed maxStreak")
+	errImportGuessKey     = errors.New("guesses keys must be 1-6 or fail")
+	errImportDistribution = errors.New("guesses must add up to gamesWon and fail to the games lost")
+)
+
+// externalStats is the statistics object kept in localStorage by the NYT game and most clones:
+// totals, streaks, and a guess distribution keyed "1" to "6" plus "fail".
+type externalStats struct {
+	GamesPlayed   int            `json:"gamesPlayed"`
+	GamesWon      int            `json:"gamesWon"`
+	CurrentStreak int            `json:"currentStreak"`
+	MaxStreak     int            `json:"maxStreak"`
+	Guesses       map[string]int `json:"guesses"`
+}
+
+// statsImportRequest is the JSON body accepted by the stats import endpoint.
+type statsImportRequest struct {
+	Source string         `json:"source"`
+	Stats  *externalStats `json:"stats" binding:"required"`
+}
+
+// importedStats is a validated import, merged into the player's aggregates.
+type importedStats struct {
+	Source        string          `json:"source"`
+	Played        int             `json:"played"`
+	Won           int             `json:"won"`
+	CurrentStreak int             `json:"current_streak"`
+	MaxStreak     int             `json:"max_streak"`
+	Distribution  [MaxGuesses]int `json:"distribution"`
+	ImportedAt    time.Time       `json:"imported_at"`
+	Fingerprint   string          `json:"-"`
+}
+
+// validateExternalStats checks an external stats object for internal consistency and converts it.
+func validateExternalStats(source string, s *externalStats) (importedStats, error) {
+	imp := importedStats{Source: source}
+	if !importSourcePattern.MatchString(source) {
+		return imp, errImportSource
+	}
+	for _, n := range []int{s.GamesPlayed, s.GamesWon, s.CurrentStreak, s.MaxStreak} {
+		if n < 0 || n > maxImportedGames {
+			return imp, errImportRange
+		}
+	}
+	if s.GamesWon > s.GamesPlayed {
+		return imp, errImportWonPlayed
+	}
+	if s.MaxStreak > s.GamesWon || s.CurrentStreak > s.MaxStreak {
+		return imp, errImportStreak
+	}
+
+	if len(s.Guesses) > 0 {
+		wins := 0
+		for key, n := range s.Guesses {
+			if n < 0 || n > maxImportedGames {
+				return imp, errImportRange
+			}
+			if key == "fail" {
+				if n != s.GamesPlayed-s.GamesWon {
+					return imp, errImportDistribution
+				}
+				continue
+			}
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 1 || i > MaxGuesses {
+				return imp, errImportGuessKey
+			}
+			imp.Distribution[i-1] = n
+			wins += n
+		}
+		if wins != s.GamesWon {
+			return imp, errImportDistribution
+		}
+	}
+
+	imp.Played = s.GamesPlayed
+	imp.Won = s.GamesWon
+	imp.CurrentStreak = s.CurrentStreak
+	imp.MaxStreak = s.MaxStreak
+	imp.Fingerprint = importFingerprint(imp)
+	return imp, nil
+}
+
+// importFingerprint identifies the imported numbers so re-importing the same stats is a no-op.
+func importFingerprint(imp importedStats) string {
+	data, _ := json.Marshal([]any{imp.Source, imp.Played, imp.Won, imp.CurrentStreak, imp.MaxStreak, imp.Distribution})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// mergeImported adds imported stats to the local aggregates. Imported games are treated as
+// played before any local game, so an imported streak carries on while local games are all wins.
+func mergeImported(local playerSummary, imports []importedStats) playerSummary {
+	merged := local
+	for _, imp := range imports {
+		merged.Played += imp.Played
+		merged.Won += imp.Won
+		for i, n := range imp.Distribution {
+			merged.Distribution[i] += n
+		}
+		merged.MaxStreak = max(merged.MaxStreak, imp.MaxStreak)
+		if local.Won == local.Played {
+			carried := imp.CurrentStreak + local.CurrentStreak
+			merged.CurrentStreak = max(merged.CurrentStreak, carried)
+			merged.MaxStreak = max(merged.MaxStreak, carried)
+		}
+	}
+	if merged.Played > 0 {
+		merged.WinRate = float64(merged.Won) / float64(merged.Played)
+	}
+	return merged
+}
+
+// importStatsHandler merges stats exported from another Wordle clone into the requesting
+// session's stats. Importing the same source again replaces the earlier import.
+func (app *App) importStatsHandler(c *gin.Context) {
+	sessionID, _ := c.Cookie(app.cookieName(SessionCookieName))
+	if app.Players == nil || sessionID == "" {
+		writeProblem(c, http.StatusNotFound, ErrorCodeNoActiveSession, "no active session")
+		return
+	}
+
+	var req statsImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, "body must be JSON with a stats object")
+		return
+	}
+	if req.Source == "" {
+		req.Source = "nyt"
+	}
+	imp, err := validateExternalStats(req.Source, req.Stats)
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+	imp.ImportedAt = app.now().UTC()
+
+	key := app.playerKey(c, sessionID)
+	changed := app.Players.importStats(key, imp)
+	if changed {
+		logInfo("Imported %d games from %s for session %s", imp.Played, imp.Source, redactSession(sessionID))
+	}
+	_, _, summary := app.Players.summary(key, app.playerPuzzleDate(c))
+	c.JSON(http.StatusOK, gin.H{
+		"imported": changed,
+		"summary":  summary,
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestValidateExternalStats(t *testing.T) {
+	valid := externalStats{
+		GamesPlayed: 10, GamesWon: 8, CurrentStreak: 3, MaxStreak: 5,
+		Guesses: map[string]int{"1": 0, "2": 1, "3": 3, "4": 2, "5": 1, "6": 1, "fail": 2},
+	}
+	imp, err := validateExternalStats("nyt", &valid)
+	if err != nil {
+		t.Fatalf("Expected valid stats, got %v", err)
+	}
+	if imp.Distribution[2] != 3 || imp.Fingerprint == "" {
+		t.Errorf("Unexpected import: %+v", imp)
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		mutate func(*externalStats)
+		want   error
+	}{
+		{"bad source", "NYT!", func(*externalStats) {}, errImportSource},
+		{"negative", "nyt", func(s *externalStats) { s.GamesPlayed = -1 }, errImportRange},
+		{"won over played", "nyt", func(s *externalStats) { s.GamesWon = 11 }, errImportWonPlayed},
+		{"streak", "nyt", func(s *externalStats) { s.CurrentStreak = 6 }, errImportStreak},
+		{"key", "nyt", func(s *externalStats) { s.Guesses = map[string]int{"7": 8} }, errImportGuessKey},
+		{"sum", "nyt", func(s *externalStats) { s.Guesses = map[string]int{"3": 7} }, errImportDistribution},
+		{"fail", "nyt", func(s *externalStats) { s.Guesses = map[string]int{"3": 8, "fail": 1} }, errImportDistribution},
+	}
+	for _, tt := range tests {
+		s := valid
+		s.Guesses = map[string]int{"3": 8}
+		tt.mutate(&s)
+		if _, err := validateExternalStats(tt.source, &s); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestImportStatsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := &App{Players: newPlayerStatsStore(StreakFreezeRules{})}
+	app.recordPlayerGame(&gin.Context{}, "sess", &GameState{GameState: engine.GameState{Won: true, GuessHistory: []string{"CRANE", "SLATE"}}})
+	router := gin.New()
+	router.POST(RouteStatsImport, app.importStatsHandler)
+
+	post := func(body string) (int, bool, playerSummary) {
+		req := httptest.NewRequest("POST", RouteStatsImport, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "sess"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp struct {
+			Imported bool          `json:"imported"`
+			Summary  playerSummary `json:"summary"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Imported, resp.Summary
+	}
+
+	body := `{"stats": {"gamesPlayed": 4, "gamesWon": 3, "currentStreak": 2, "maxStreak": 2, "guesses": {"2": 1, "4": 2, "fail": 1}}}`
+	code, imported, summary := post(body)
+	if code != http.StatusOK || !imported {
+		t.Fatalf("Expected import to succeed, got %d imported=%v", code, imported)
+	}
+	if summary.Played != 5 || summary.Won != 4 || summary.Distribution[1] != 2 || summary.CurrentStreak != 3 || summary.MaxStreak != 3 {
+		t.Errorf("Unexpected merged summary: %+v", summary)
+	}
+
+	if code, imported, again := post(body); code != http.StatusOK || imported || again != summary {
+		t.Errorf("Expected re-import to be a no-op, got %d imported=%v %+v", code, imported, again)
+	}
+	if code, _, _ := post(`{"stats": {"gamesPlayed": 1, "gamesWon": 2}}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for inconsistent stats, got %d", code)
+	}
+}
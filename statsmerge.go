@@ -0,0 +1,255 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"time"
+)
+
+// MergeUndoWindow is how long a stats merge can be undone.
+const MergeUndoWindow = 24 * time.Hour
+
+// statsSnapshot is everything the store holds for one key, as it was before a merge.
+type statsSnapshot struct {
+	history []GameRecord
+	imports map[string]importedStats
+	bonus   int
+	coins   int
+	floor   int
+}
+
+// statsMerge records one key folded into another, so it can be undone.
+type statsMerge struct {
+	from, to string
+	before   statsSnapshot
+	moved    statsSnapshot
+	at       time.Time
+}
+
+// mergeUndo is the undo record of one merge request, which may cover several keys.
+type mergeUndo struct {
+	merges  []statsMerge
+	expires time.Time
+}
+
+// mergeResult reports what a merge did.
+type mergeResult struct {
+	// Games is the number of games moved across.
+	Games int `json:"games"`
+	// Duplicates is the number of games dropped because both sides played the same puzzle.
+	Duplicates int `json:"duplicates"`
+}
+
+// puzzleKey identifies the puzzle a game was played on, or is empty for games that are not a
+// dated puzzle, such as practice or custom games, which never conflict.
+func puzzleKey(rec GameRecord) string {
+	if rec.PuzzleDate == "" {
+		return ""
+	}
+	return rec.Mode + "|" + rec.Pack + "|" + rec.PuzzleDate
+}
+
+// betterRecord reports whether a should be kept over b when both are the same puzzle: a win
+// over a loss, then fewer guesses, then the earlier game.
+func betterRecord(a, b GameRecord) bool {
+	if a.Won != b.Won {
+		return a.Won
+	}
+	if a.Won && a.Guesses != b.Guesses {
+		return a.Guesses < b.Guesses
+	}
+	return a.FinishedAt.Before(b.FinishedAt)
+}
+
+// mergeHistories combines two histories, ordered oldest first. Games on the same puzzle are
+// deduplicated with betterRecord, keeping into's game on a tie, and everything else is kept.
+func mergeHistories(into, from []GameRecord) ([]GameRecord, int) {
+	merged := slices.Clone(into)
+	byPuzzle := make(map[string]int)
+	for i, rec := range merged {
+		if key := puzzleKey(rec); key != "" {
+			byPuzzle[key] = i
+		}
+	}
+	duplicates := 0
+	for _, rec := range from {
+		key := puzzleKey(rec)
+		if i, ok := byPuzzle[key]; ok && key != "" {
+			duplicates++
+			if betterRecord(rec, merged[i]) {
+				merged[i] = rec
+			}
+			continue
+		}
+		if key != "" {
+			byPuzzle[key] = len(merged)
+		}
+		merged = append(merged, rec)
+	}
+	slices.SortStableFunc(merged, func(a, b GameRecord) int { return a.FinishedAt.Compare(b.FinishedAt) })
+	return merged, duplicates
+}
+
+// rehydrateLocked brings key's archived games back into memory so they take part in a merge.
+// They are archived again by the next archive run.
+func (ps *PlayerStatsStore) rehydrateLocked(key string) {
+	if _, ok := ps.carry[key]; !ok {
+		return
+	}
+	archived, err := ps.archive.take(key)
+	if err != nil {
+		logWarn("Failed to rehydrate archived games for merge: %v", err)
+		return
+	}
+	ps.players[key] = append(archived, ps.players[key]...)
+	delete(ps.carry, key)
+	ps.dirty = true
+}
+
+// snapshotLocked returns what the store holds for key.
+func (ps *PlayerStatsStore) snapshotLocked(key string) statsSnapshot {
+	return statsSnapshot{
+		history: slices.Clone(ps.players[key]),
+		imports: maps.Clone(ps.imports[key]),
+		bonus:   ps.bonus[key],
+		coins:   ps.coins[key],
+		floor:   ps.streakFloor[key],
+	}
+}
+
+// restoreLocked replaces what the store holds for key with snap.
+func (ps *PlayerStatsStore) restoreLocked(key string, snap statsSnapshot) {
+	setOrDelete(ps.players, key, snap.history, len(snap.history) > 0)
+	setOrDelete(ps.imports, key, snap.imports, len(snap.imports) > 0)
+	setOrDelete(ps.bonus, key, snap.bonus, snap.bonus != 0)
+	setOrDelete(ps.coins, key, snap.coins, snap.coins != 0)
+	setOrDelete(ps.streakFloor, key, snap.floor, snap.floor > 0)
+	ps.dirty = true
+}
+
+// setOrDelete sets m[key] to v when keep is true and deletes it otherwise.
+func setOrDelete[V any](m map[string]V, key string, v V, keep bool) {
+	if keep {
+		m[key] = v
+	} else {
+		delete(m, key)
+	}
+}
+
+// merge folds the stats of each from key into its to key, for when a device that already has
+// stats signs in as an existing player. Conflicts are settled deterministically:
+//   - games on the same puzzle are deduplicated with betterRecord;
+//   - all other games, bonus points, and coins are summed;
+//   - imports from the same source keep the existing player's copy;
+//   - the best max streak of either side is kept, even if the merged history no longer
+//     shows it, since a streak earned on either device was earned.
+//
+// The returned token undoes the whole merge within MergeUndoWindow; it is empty when there
+// was nothing to merge.
+func (ps *PlayerStatsStore) merge(pairs [][2]string, now time.Time) (string, mergeResult, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", mergeResult{}, err
+	}
+	token := hex.EncodeToString(b)
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	var undo mergeUndo
+	var result mergeResult
+	for _, pair := range pairs {
+		from, to := pair[0], pair[1]
+		if from == to {
+			continue
+		}
+		ps.rehydrateLocked(from)
+		ps.rehydrateLocked(to)
+		moved, before := ps.snapshotLocked(from), ps.snapshotLocked(to)
+		if len(moved.history) == 0 && len(moved.imports) == 0 && moved.bonus == 0 && moved.coins == 0 {
+			continue
+		}
+		merged, duplicates := mergeHistories(before.history, moved.history)
+		merged = ps.trimHistory(merged)
+		imports := make(map[string]importedStats)
+		maps.Copy(imports, moved.imports)
+		maps.Copy(imports, before.imports)
+		floor := max(before.floor, moved.floor,
+			summarize(before.history, ps.streaks, "").MaxStreak,
+			summarize(moved.history, ps.streaks, "").MaxStreak)
+		ps.restoreLocked(to, statsSnapshot{
+			history: merged,
+			imports: imports,
+			bonus:   before.bonus + moved.bonus,
+			coins:   before.coins + moved.coins,
+			floor:   floor,
+		})
+		ps.restoreLocked(from, statsSnapshot{})
+		undo.merges = append(undo.merges, statsMerge{from: from, to: to, before: before, moved: moved, at: now})
+		result.Games += len(moved.history)
+		result.Duplicates += duplicates
+	}
+	if len(undo.merges) == 0 {
+		return "", result, nil
+	}
+	for t, u := range ps.merges {
+		if !now.Before(u.expires) {
+			delete(ps.merges, t)
+		}
+	}
+	undo.expires = now.Add(MergeUndoWindow)
+	ps.merges[token] = undo
+	return token, result, nil
+}
+
+// undoMerge separates the stats combined by the merge behind token, reporting false when the
+// token is unknown or past MergeUndoWindow. Games finished on the merged key since the merge
+// stay with it. Bonus points and coins go back at most to what the merged key still has, so
+// an undo cannot mint coins that were spent in between.
+func (ps *PlayerStatsStore) undoMerge(token string, now time.Time) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	undo, ok := ps.merges[token]
+	if !ok {
+		return false
+	}
+	delete(ps.merges, token)
+	if !now.Before(undo.expires) {
+		return false
+	}
+	for _, m := range slices.Backward(undo.merges) {
+		ps.rehydrateLocked(m.to)
+		ps.rehydrateLocked(m.from)
+		current := ps.snapshotLocked(m.to)
+		history := slices.Clone(m.before.history)
+		for _, rec := range current.history {
+			if rec.FinishedAt.After(m.at) {
+				history = append(history, rec)
+			}
+		}
+		imports := maps.Clone(current.imports)
+		for source := range m.moved.imports {
+			if _, kept := m.before.imports[source]; !kept {
+				delete(imports, source)
+			}
+		}
+		bonus := min(m.moved.bonus, current.bonus)
+		coins := min(m.moved.coins, current.coins)
+		ps.restoreLocked(m.to, statsSnapshot{
+			history: history,
+			imports: imports,
+			bonus:   current.bonus - bonus,
+			coins:   current.coins - coins,
+			floor:   m.before.floor,
+		})
+		from := ps.snapshotLocked(m.from)
+		ps.restoreLocked(m.from, statsSnapshot{
+			history: append(slices.Clone(m.moved.history), from.history...),
+			imports: m.moved.imports,
+			bonus:   from.bonus + bonus,
+			coins:   from.coins + coins,
+			floor:   m.moved.floor,
+		})
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeHistoriesDedupsPuzzles(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2030, 1, day, 12, 0, 0, 0, time.UTC) }
+	account := []GameRecord{
+		{FinishedAt: at(1), Word: "CRANE", Won: false, Guesses: 6, PuzzleDate: "2030-01-01"},
+		{FinishedAt: at(2), Word: "SLATE", Won: true, Guesses: 4, PuzzleDate: "2030-01-02"},
+	}
+	device := []GameRecord{
+		{FinishedAt: at(1).Add(time.Hour), Word: "CRANE", Won: true, Guesses: 5, PuzzleDate: "2030-01-01"},
+		{FinishedAt: at(2).Add(time.Hour), Word: "SLATE", Won: true, Guesses: 4, PuzzleDate: "2030-01-02"},
+		{FinishedAt: at(2).Add(2 * time.Hour), Word: "TRACE", Won: true, Guesses: 3},
+		{FinishedAt: at(3), Word: "PLANT", Won: true, Guesses: 2, PuzzleDate: "2030-01-03"},
+	}
+	merged, duplicates := mergeHistories(account, device)
+	if duplicates != 2 || len(merged) != 4 {
+		t.Fatalf("merged %d games with %d duplicates, want 4 and 2", len(merged), duplicates)
+	}
+	if !merged[0].Won {
+		t.Error("Expected a win to beat a loss on the same puzzle")
+	}
+	if !merged[1].FinishedAt.Equal(at(2)) {
+		t.Error("Expected the account's game to win a tie")
+	}
+	if merged[2].Word != "TRACE" || merged[3].Word != "PLANT" {
+		t.Errorf("Expected undated and new games to be kept in order, got %+v", merged)
+	}
+}
+
+func TestMergeAndUndo(t *testing.T) {
+	ps := newPlayerStatsStore(StreakFreezeRules{})
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	for day := 1; day <= 5; day++ {
+		ps.record("device", GameRecord{FinishedAt: time.Date(2030, 1, day, 9, 0, 0, 0, time.UTC), Won: true, Guesses: 3, PuzzleDate: time.Date(2030, 1, day, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)})
+	}
+	ps.record("player:one", GameRecord{FinishedAt: time.Date(2030, 1, 3, 10, 0, 0, 0, time.UTC), Won: false, Guesses: 6, PuzzleDate: "2030-01-03"})
+	ps.record("player:one", GameRecord{FinishedAt: time.Date(2030, 1, 8, 10, 0, 0, 0, time.UTC), Won: true, Guesses: 2})
+	ps.addCoins("device", 30)
+	ps.addCoins("player:one", 10)
+
+	token, result, err := ps.merge([][2]string{{"device", "player:one"}, {"device:purist", "player:one:purist"}}, now)
+	if err != nil || token == "" {
+		t.Fatalf("merge = %q, %v", token, err)
+	}
+	if result.Games != 5 || result.Duplicates != 1 {
+		t.Errorf("result = %+v, want 5 games and 1 duplicate", result)
+	}
+	_, _, s := ps.summary("player:one", "")
+	if s.Played != 6 || s.MaxStreak != 6 || s.Coins != 40 {
+		t.Errorf("merged summary = %+v", s)
+	}
+	if len(ps.history("device")) != 0 {
+		t.Error("Expected the device's games to move")
+	}
+
+	ps.spendCoins("player:one", 35)
+	ps.record("player:one", GameRecord{FinishedAt: now.Add(time.Hour), Won: true, Guesses: 4})
+	if !ps.undoMerge(token, now.Add(2*time.Hour)) {
+		t.Fatal("Expected the merge to be undone")
+	}
+	if got := len(ps.history("device")); got != 5 {
+		t.Errorf("device has %d games after undo, want 5", got)
+	}
+	if got := len(ps.history("player:one")); got != 3 {
+		t.Errorf("player has %d games after undo, want 3 including the new one", got)
+	}
+	if ps.coinBalance("device")+ps.coinBalance("player:one") != 5 || ps.coinBalance("player:one") != 0 {
+		t.Errorf("coins after undo = %d and %d, want 5 and 0", ps.coinBalance("device"), ps.coinBalance("player:one"))
+	}
+	if ps.undoMerge(token, now.Add(2*time.Hour)) {
+		t.Error("Expected a merge to be undone only once")
+	}
+
+	token, _, _ = ps.merge([][2]string{{"device", "player:one"}}, now)
+	if ps.undoMerge(token, now.Add(MergeUndoWindow)) {
+		t.Error("Expected undo to expire")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreakFreezeRules configures the grace mechanic: every WinsPerFreeze wins earn a streak
+// freeze, up to MaxFreezes held at once, and each missed puzzle day spends one instead of
+// breaking the streak. WinsPerFreeze 0 disables freezes, so any missed day ends a streak.
+type StreakFreezeRules struct {
+	WinsPerFreeze int
+	MaxFreezes    int
+}
+
+// streakStatus is the streak line shown on the game-over panel.
+type streakStatus struct {
+	CurrentStreak    int
+	FreezesRemaining int
+}
+
+// missedDays returns the number of whole puzzle days between two dates, exclusive. Unknown or
+// unparseable dates count as no gap, so records from before dates were kept never break a streak.
+func missedDays(from, to string) int {
+	if from == "" || to == "" {
+		return 0
+	}
+	a, errA := parsePuzzleDate(from)
+	b, errB := parsePuzzleDate(to)
+	if errA != nil || errB != nil {
+		return 0
+	}
+	return max(int(b.Sub(a).Round(24*time.Hour)/(24*time.Hour))-1, 0)
+}
+
+// bridgeGap spends freezes to cover missed days in the current streak, or ends the streak when
+// there are not enough of them.
+func (s *playerSummary) bridgeGap(missed int) {
+	if missed == 0 || s.CurrentStreak == 0 {
+		return
+	}
+	if s.FreezesRemaining >= missed {
+		s.FreezesRemaining -= missed
+		s.FreezesUsed += missed
+		return
+	}
+	s.CurrentStreak = 0
+}
+
+// playerPuzzleDate returns today's puzzle date in the player's timezone, or "" when no daily
+// schedule is configured.
+func (app *App) playerPuzzleDate(c *gin.Context) string {
+	if app.Daily == nil {
+		return ""
+	}
+	return app.Daily.puzzleDate(app.now(), app.Daily.location(c)).Format(time.DateOnly)
+}
+
+// streakStatus returns the player's streak and freezes for a finished game, or nil when the
+// game is still running or freezes are disabled.
+func (app *App) streakStatus(c *gin.Context, game *GameState) *streakStatus {
+	if app.Players == nil || app.Players.streaks.WinsPerFreeze <= 0 || game == nil || !game.GameOver {
+		return nil
+	}
+	sessionID := c.GetString(sessionContextKey)
+	if sessionID == "" {
+		return nil
+	}
+	key := statsBucket(app.playerKey(c, sessionID), gameMode(game))
+	_, _, summary := app.Players.summary(key, app.playerPuzzleDate(c))
+	return &streakStatus{CurrentStreak: summary.CurrentStreak, FreezesRemaining: summary.FreezesRemaining}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mooship/vortludo/engine"
+)
+
+// Suggestion status constants
+const (
+	SuggestionPending  = "pending"
+	SuggestionApproved = "approved"
+	SuggestionRejected = "rejected"
+)
+
+// Suggestion limits
+const (
+	maxSuggestionHintLength = 200
+	maxSuggestionNoteLength = 500
+	maxPendingSuggestions   = 1000
+)
+
+// Suggestion validation errors, shown to the submitter.
+var (
+	errSuggestionLength      = errors.New("word must be 5 letters long")
+	errSuggestionNotAccepted = errors.New("word is not in the accepted word list")
+	errSuggestionInWordList  = errors.New("word is already in the game")
+	errSuggestionDuplicate   = errors.New("word has already been suggested")
+	errSuggestionHint        = errors.New("hint is required and must be at most 200 characters")
+	errSuggestionNote        = errors.New("note must be at most 500 characters")
+	errSuggestionQueueFull   = errors.New("suggestion queue is full, try again later")
+)
+
+// Suggestion is a community-submitted word awaiting or past moderation.
+type Suggestion struct {
+	ID          string    `json:"id"`
+	Word        string    `json:"word"`
+	Hint        string    `json:"hint"`
+	Note        string    `json:"note,omitempty"`
+	Status      string    `json:"status"`
+	SubmittedAt time.Time `json:"submittedAt"`
+	ReviewedAt  time.Time `json:"reviewedAt,omitzero"`
+}
+
+// SuggestionQueue is the moderation queue for community word suggestions, persisted to a JSON file.
+type SuggestionQueue struct {
+	mu          sync.RWMutex
+	storage     Storage
+	path        string
+	suggestions []Suggestion
+}
+
+// newSuggestionQueue creates an empty queue persisted at path (empty path disables persistence).
+func newSuggestionQueue(path string) *SuggestionQueue {
+	return &SuggestionQueue{storage: DirStorage{}, path: path}
+}
+
+// load reads persisted suggestions from disk. A missing file is not an error.
+func (q *SuggestionQueue) load() error {
+	var suggestions []Suggestion
+	if found, err := readJSONFile(q.storage, q.path, &suggestions); err != nil || !found {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.suggestions = suggestions
+	return nil
+}
+
+// save writes the queue to disk atomically. Callers must hold q.mu.
+func (q *SuggestionQueue) save() error {
+	return writeJSONFile(q.storage, q.path, q.suggestions)
+}
+
+// submit adds a pending suggestion unless the word was already suggested or the queue is full.
+func (q *SuggestionQueue) submit(word, hint, note string) (Suggestion, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending := 0
+	for _, s := range q.suggestions {
+		if s.Word == word {
+			return Suggestion{}, errSuggestionDuplicate
+		}
+		if s.Status == SuggestionPending {
+			pending++
+		}
+	}
+	if pending >= maxPendingSuggestions {
+		return Suggestion{}, errSuggestionQueueFull
+	}
+	s := Suggestion{
+		ID:          uuid.NewString(),
+		Word:        word,
+		Hint:        hint,
+		Note:        note,
+		Status:      SuggestionPending,
+		SubmittedAt: time.Now().UTC(),
+	}
+	q.suggestions = append(q.suggestions, s)
+	return s, q.save()
+}
+
+// list returns suggestions with the given status, or all suggestions when status is empty.
+func (q *SuggestionQueue) list(status string) []Suggestion {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	out := make([]Suggestion, 0, len(q.suggestions))
+	for _, s := range q.suggestions {
+		if status == "" || s.Status == status {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// review sets the status of a suggestion, reporting whether it exists.
+func (q *SuggestionQueue) review(id, status string) (Suggestion, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := slices.IndexFunc(q.suggestions, func(s Suggestion) bool { return s.ID == id })
+	if i < 0 {
+		return Suggestion{}, false, nil
+	}
+	q.suggestions[i].Status = status
+	q.suggestions[i].ReviewedAt = time.Now().UTC()
+	return q.suggestions[i], true, q.save()
+}
+
+// validateSuggestion normalizes a submission and checks it against the word lists.
+func (app *App) validateSuggestion(word, hint, note string) (string, string, string, error) {
+	word = engine.NormalizeGuess(word)
+	hint = strings.TrimSpace(hint)
+	note = strings.TrimSpace(note)
+	switch {
+	case utf8.RuneCountInString(word) != WordLength:
+		return "", "", "", errSuggestionLength
+	case !app.isAcceptedWord("", word):
+		return "", "", "", errSuggestionNotAccepted
+	case app.isValidWord(word):
+		return "", "", "", errSuggestionInWordList
+	case hint == "" || utf8.RuneCountInString(hint) > maxSuggestionHintLength:
+		return "", "", "", errSuggestionHint
+	case utf8.RuneCountInString(note) > maxSuggestionNoteLength:
+		return "", "", "", errSuggestionNote
+	}
+	return word, hint, note, nil
+}
+
+// wantsJSON reports whether the client prefers a JSON response over HTML.
+func wantsJSON(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+}
+
+// renderSuggest renders the word suggestion page.
+func (app *App) renderSuggest(c *gin.Context, status int, data gin.H) {
+	csrfToken, _ := c.Cookie(app.cookieName(CSRFCookieName))
+	data["title"] = "Vortludo - Suggest a Word"
+	data["csrf_token"] = csrfToken
+	c.HTML(status, "suggest.html", data)
+}
+
+// suggestPageHandler shows the word suggestion form.
+func (app *App) suggestPageHandler(c *gin.Context) {
+	app.renderSuggest(c, http.StatusOK, gin.H{})
+}
+
+// suggestWordHandler validates a word suggestion and adds it to the moderation queue.
+func (app *App) suggestWordHandler(c *gin.Context) {
+	word, hint, note, err := app.validateSuggestion(c.PostForm("word"), c.PostForm("hint"), c.PostForm("note"))
+	status, code := http.StatusBadRequest, ErrorCodeInvalidRequest
+	if err == nil {
+		_, err = app.Suggestions.submit(word, hint, note)
+		switch {
+		case errors.Is(err, errSuggestionQueueFull):
+			status, code = http.StatusServiceUnavailable, ErrorCodeQueueFull
+		case errors.Is(err, errSuggestionDuplicate):
+			code = ErrorCodeDuplicate
+		case err != nil:
+			logWarn("Failed to save word suggestion: %v", err)
+			status, code = http.StatusInternalServerError, ErrorCodeInternal
+			err = errors.New("could not save suggestion, try again later")
+		}
+	}
+
+	if err != nil {
+		if wantsJSON(c) {
+			writeProblem(c, status, code, err.Error())
+			return
+		}
+		app.renderSuggest(c, status, gin.H{
+			"error":     err.Error(),
+			"error_ref": errorRef(c),
+			"word":      c.PostForm("word"),
+			"hint":      c.PostForm("hint"),
+			"note":      c.PostForm("note"),
+		})
+		return
+	}
+
+	logInfo("Word suggestion queued for moderation: %s", redactWord(word))
+	if wantsJSON(c) {
+		c.JSON(http.StatusCreated, gin.H{"status": SuggestionPending})
+		return
+	}
+	app.renderSuggest(c, http.StatusCreated, gin.H{"success": true})
+}
+
+// adminSuggestionsHandler lists word suggestions, optionally filtered by ?status=.
+func (app *App) adminSuggestionsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"suggestions": app.Suggestions.list(c.Query("status"))})
+}
+
+// adminReviewSuggestionHandler returns a handler that moves a suggestion to the given status.
+func (app *App) adminReviewSuggestionHandler(status string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s, ok, err := app.Suggestions.review(c.Param("id"), status)
+		if err != nil {
+			logWarn("Failed to save word suggestions: %v", err)
+			writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "failed to save suggestions")
+			return
+		}
+		if !ok {
+			writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "suggestion not found")
+			return
+		}
+		logInfo("Admin marked suggestion %s as %s", s.ID, status)
+		auditChange(c, s.ID, "", status)
+		c.JSON(http.StatusOK, s)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateSuggestion(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE", Hint: "bird"}})
+	app.lists().AcceptedWordSet["TIGER"] = struct{}{}
+
+	tests := []struct {
+		word, hint, note string
+		want             error
+	}{
+		{" tiger ", "striped cat", "", nil},
+		{"tig", "striped cat", "", errSuggestionLength},
+		{"zzzzz", "nonsense", "", errSuggestionNotAccepted},
+		{"crane", "bird", "", errSuggestionInWordList},
+		{"tiger", " ", "", errSuggestionHint},
+		{"tiger", strings.Repeat("a", 201), "", errSuggestionHint},
+		{"tiger", "striped cat", strings.Repeat("a", 501), errSuggestionNote},
+	}
+	for _, tt := range tests {
+		word, _, _, err := app.validateSuggestion(tt.word, tt.hint, tt.note)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("validateSuggestion(%q) error = %v, want %v", tt.word, err, tt.want)
+		}
+		if tt.want == nil && word != "TIGER" {
+			t.Errorf("validateSuggestion(%q) word = %q, want TIGER", tt.word, word)
+		}
+	}
+}
+
+func TestSuggestionQueue(t *testing.T) {
+	st := newMemStorage()
+	q := newSuggestionQueue("suggestions.json")
+	q.storage = st
+	s, err := q.submit("TIGER", "striped cat", "")
+	if err != nil {
+		t.Fatalf("submit() error = %v", err)
+	}
+	if _, err := q.submit("TIGER", "again", ""); !errors.Is(err, errSuggestionDuplicate) {
+		t.Errorf("duplicate submit() error = %v, want %v", err, errSuggestionDuplicate)
+	}
+
+	reviewed, ok, err := q.review(s.ID, SuggestionApproved)
+	if err != nil || !ok || reviewed.Status != SuggestionApproved {
+		t.Errorf("review() = %+v, %v, %v", reviewed, ok, err)
+	}
+	if _, ok, _ := q.review("missing", SuggestionRejected); ok {
+		t.Error("review() of missing suggestion reported success")
+	}
+
+	reloaded := newSuggestionQueue("suggestions.json")
+	reloaded.storage = st
+	if err := reloaded.load(); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if got := reloaded.list(SuggestionApproved); len(got) != 1 || got[0].Word != "TIGER" {
+		t.Errorf("list(approved) = %+v", got)
+	}
+	if got := reloaded.list(SuggestionPending); len(got) != 0 {
+		t.Errorf("list(pending) = %+v, want empty", got)
+	}
+}
+
+func TestSuggestWordHandlerJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE", Hint: "bird"}})
+	app.lists().AcceptedWordSet["TIGER"] = struct{}{}
+	app.Suggestions = newSuggestionQueue("")
+
+	post := func(word string) *httptest.ResponseRecorder {
+		form := url.Values{"word": {word}, "hint": {"striped cat"}}
+		req := httptest.NewRequest(http.MethodPost, RouteSuggest, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		app.suggestWordHandler(c)
+		return w
+	}
+
+	if w := post("tiger"); w.Code != http.StatusCreated {
+		t.Errorf("first suggestion status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if w := post("tiger"); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "already been suggested") {
+		t.Errorf("duplicate suggestion = %d %s", w.Code, w.Body.String())
+	}
+	if got := app.Suggestions.list(SuggestionPending); len(got) != 1 {
+		t.Errorf("pending suggestions = %d, want 1", len(got))
+	}
+}
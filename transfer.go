@@ -0,0 +1,309 @@
+package main
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+// Device transfer constants
+const (
+	// TransferCodeLength is the length of a transfer code, drawn from roomCodeAlphabet.
+	TransferCodeLength = 8
+	// TransferTTL is how long a transfer code can be redeemed.
+	TransferTTL = 5 * time.Minute
+	// MaxPendingTransfers caps the transfer codes held at once.
+	MaxPendingTransfers = 10000
+	// transferRedeemInterval and transferRedeemBurst limit code guesses per client IP.
+	transferRedeemInterval = 10 * time.Second
+	transferRedeemBurst    = 5
+)
+
+// errTransfersFull is returned when MaxPendingTransfers codes are already waiting.
+var errTransfersFull = errors.New("too many pending transfers, try again in a few minutes")
+
+// deviceTransfer is what a transfer code hands to the device that redeems it.
+type deviceTransfer struct {
+	sessionID string
+	// playerID is the remembered player, empty when remember-me is off.
+	playerID string
+	expires  time.Time
+}
+
+// Transfers holds short-lived, single-use codes that move a session to another device. A
+// session has at most one code; asking again replaces it. It also remembers, by stats merge
+// undo token, the identity a device had before redeeming, so undoing the merge can give it back.
+type Transfers struct {
+	mu        sync.Mutex
+	codes     map[string]deviceTransfer
+	bySession map[string]string
+	previous  map[string]deviceTransfer
+}
+
+// newTransfers returns an empty transfer registry.
+func newTransfers() *Transfers {
+	return &Transfers{
+		codes:     make(map[string]deviceTransfer),
+		bySession: make(map[string]string),
+		previous:  make(map[string]deviceTransfer),
+	}
+}
+
+// create returns a new code for sessionID and playerID, dropping expired codes and the
+// session's previous one.
+func (tr *Transfers) create(sessionID, playerID string, now time.Time) (string, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for code, t := range tr.codes {
+		if !now.Before(t.expires) {
+			delete(tr.codes, code)
+			delete(tr.bySession, t.sessionID)
+		}
+	}
+	if old, ok := tr.bySession[sessionID]; ok {
+		delete(tr.codes, old)
+	}
+	if len(tr.codes) >= MaxPendingTransfers {
+		return "", errTransfersFull
+	}
+	var code string
+	for {
+		var err error
+		if code, err = randomCode(TransferCodeLength); err != nil {
+			return "", err
+		}
+		if _, taken := tr.codes[code]; !taken {
+			break
+		}
+	}
+	tr.codes[code] = deviceTransfer{sessionID: sessionID, playerID: playerID, expires: now.Add(TransferTTL)}
+	tr.bySession[sessionID] = code
+	return code, nil
+}
+
+// normalizeTransferCode uppercases a typed transfer code and drops its spaces.
+func normalizeTransferCode(code string) string {
+	return strings.ReplaceAll(normalizeRoomCode(code), " ", "")
+}
+
+// redeem consumes code, so it works once, and returns what it transfers.
+func (tr *Transfers) redeem(code string, now time.Time) (deviceTransfer, bool) {
+	code = normalizeTransferCode(code)
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	t, ok := tr.codes[code]
+	if !ok {
+		return deviceTransfer{}, false
+	}
+	delete(tr.codes, code)
+	delete(tr.bySession, t.sessionID)
+	return t, now.Before(t.expires)
+}
+
+// rememberPrevious keeps the identity a device had before a merge, under the merge's undo
+// token, until MergeUndoWindow passes.
+func (tr *Transfers) rememberPrevious(token string, prev deviceTransfer, now time.Time) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for t, p := range tr.previous {
+		if !now.Before(p.expires) {
+			delete(tr.previous, t)
+		}
+	}
+	prev.expires = now.Add(MergeUndoWindow)
+	tr.previous[token] = prev
+}
+
+// takePrevious returns and forgets the identity kept under token.
+func (tr *Transfers) takePrevious(token string, now time.Time) (deviceTransfer, bool) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	prev, ok := tr.previous[token]
+	delete(tr.previous, token)
+	return prev, ok && now.Before(prev.expires)
+}
+
+// transferRateLimitMiddleware limits redeeming to one code per transferRedeemInterval per
+// client IP, with bursts of transferRedeemBurst, so codes cannot be guessed at speed.
+func (app *App) transferRateLimitMiddleware() gin.HandlerFunc {
+	return app.scopedRateLimitMiddleware("transfer", transferRedeemInterval, transferRedeemInterval, transferRedeemBurst)
+}
+
+// renderTransfer renders the device transfer page in state: "form" to start or enter a code,
+// "code" to show a new code, "redeem" to confirm a code from a link, "merged" to offer undoing
+// a stats merge, "undo-expired", or "invalid".
+func (app *App) renderTransfer(c *gin.Context, status int, state string, data gin.H) {
+	data["title"] = "Vortludo - Continue on Another Device"
+	// A device opening a transfer link usually has no CSRF cookie yet, so take the token
+	// csrfMiddleware just issued.
+	data["csrf_token"] = c.GetString("csrf_token")
+	data["state"] = state
+	c.Header("Cache-Control", "no-store")
+	c.HTML(status, "transfer.html", data)
+}
+
+// transferPageHandler offers to send this game to another device or to receive one by code.
+func (app *App) transferPageHandler(c *gin.Context) {
+	app.renderTransfer(c, http.StatusOK, "form", gin.H{})
+}
+
+// transferLinkHandler asks before redeeming a code from a transfer link, so a link preview or
+// prefetch cannot use up the code.
+func (app *App) transferLinkHandler(c *gin.Context) {
+	app.renderTransfer(c, http.StatusOK, "redeem", gin.H{"code": c.Param("code")})
+}
+
+// createTransferHandler issues a transfer code for the current session and remembered player,
+// shown with a link and a QR code for the other device.
+func (app *App) createTransferHandler(c *gin.Context) {
+	sessionID := app.getOrCreateSession(c)
+	code, err := app.Transfers.create(sessionID, c.GetString(playerContextKey), app.now())
+	if err != nil {
+		status, errorCode := http.StatusInternalServerError, ErrorCodeInternal
+		if errors.Is(err, errTransfersFull) {
+			status, errorCode = http.StatusServiceUnavailable, ErrorCodeQueueFull
+		} else {
+			logWarn("Failed to create transfer code: %v", err)
+			err = errors.New("could not create a transfer code")
+		}
+		if wantsJSON(c) {
+			writeProblem(c, status, errorCode, err.Error())
+			return
+		}
+		app.renderTransfer(c, status, "form", gin.H{"error": err.Error(), "error_ref": errorRef(c)})
+		return
+	}
+	app.incMetric(MetricTransfersCreated)
+	logInfo("Created transfer code for session %s", redactSession(sessionID))
+	path := RouteTransfer + "/" + code
+	if wantsJSON(c) {
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, gin.H{"code": code, "url": path, "expires_in": int(TransferTTL.Seconds())})
+		return
+	}
+	var qr template.HTML
+	if q, err := qrcode.New(app.absoluteURL(c, path), qrcode.Medium); err != nil {
+		logWarn("Failed to encode transfer QR code: %v", err)
+	} else {
+		qr = template.HTML(qrSVG(q.Bitmap()))
+	}
+	app.renderTransfer(c, http.StatusOK, "code", gin.H{
+		"code":    code,
+		"url":     app.absoluteURL(c, path),
+		"qr":      qr,
+		"minutes": int(TransferTTL.Minutes()),
+	})
+}
+
+// useIdentity points this device's session and player cookies at t. The player cookie is
+// cleared when t has no remembered player.
+func (app *App) useIdentity(c *gin.Context, t deviceTransfer) {
+	app.setSessionCookie(c, t.sessionID)
+	if app.PlayerIDs == nil || app.PlayerCookieMaxAge <= 0 {
+		return
+	}
+	if t.playerID == "" {
+		c.SetCookie(app.cookieName(PlayerCookieName), "", -1, "/", "", app.IsProduction, true)
+		return
+	}
+	c.SetCookie(app.cookieName(PlayerCookieName), app.PlayerIDs.issue(t.playerID), int(app.PlayerCookieMaxAge.Seconds()), "/", "", app.IsProduction, true)
+}
+
+// identityStatsKey is the PlayerStatsStore key of an identity, as playerKey picks it.
+func (app *App) identityStatsKey(t deviceTransfer) string {
+	if t.playerID != "" && app.PlayerIDs != nil && app.PlayerCookieMaxAge > 0 {
+		return playerStatsKey(t.playerID)
+	}
+	return t.sessionID
+}
+
+// redeemTransferHandler moves this device onto the session behind a transfer code: it takes
+// over the session cookie and, with remember-me, the player cookie, so the game in progress and
+// personal stats follow. Stats the device already had are merged into the player's, and the
+// merge can be undone within MergeUndoWindow. The device's previous session is left to expire.
+// The code is also spent in UsedTokens, so it cannot be replayed from any session.
+func (app *App) redeemTransferHandler(c *gin.Context) {
+	now := app.now()
+	code := normalizeTransferCode(c.PostForm("code"))
+	t, ok := app.Transfers.redeem(code, now)
+	if ok {
+		ok = app.spendToken(TokenKindTransfer, code, t.expires)
+	}
+	if !ok {
+		if wantsJSON(c) {
+			writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "transfer code is invalid or has expired")
+			return
+		}
+		app.renderTransfer(c, http.StatusNotFound, "invalid", gin.H{})
+		return
+	}
+	var prev deviceTransfer
+	prev.sessionID, _ = c.Cookie(app.cookieName(SessionCookieName))
+	if app.PlayerIDs != nil && app.PlayerCookieMaxAge > 0 {
+		value, _ := c.Cookie(app.cookieName(PlayerCookieName))
+		prev.playerID, _ = app.PlayerIDs.verify(value)
+	}
+	var undoToken string
+	var merged mergeResult
+	if from, to := app.identityStatsKey(prev), app.identityStatsKey(t); app.Players != nil && from != "" && from != to {
+		var pairs [][2]string
+		for _, mode := range []string{"", ModePurist, ModeKids} {
+			pairs = append(pairs, [2]string{statsBucket(from, mode), statsBucket(to, mode)})
+		}
+		var err error
+		if undoToken, merged, err = app.Players.merge(pairs, now); err != nil {
+			logWarn("Failed to merge stats into transferred session: %v", err)
+		} else if undoToken != "" {
+			app.Transfers.rememberPrevious(undoToken, prev, now)
+			app.incMetric(MetricStatsMerges)
+		}
+	}
+	app.useIdentity(c, t)
+	app.incMetric(MetricTransfersRedeemed)
+	logInfo("Transferred session %s to another device", redactSession(t.sessionID))
+	if wantsJSON(c) {
+		c.JSON(http.StatusOK, gin.H{"transferred": true, "merged": merged, "undo_token": undoToken})
+		return
+	}
+	if undoToken == "" {
+		c.Redirect(http.StatusSeeOther, RouteHome)
+		return
+	}
+	app.renderTransfer(c, http.StatusOK, "merged", gin.H{
+		"merged": merged,
+		"token":  undoToken,
+		"hours":  int(MergeUndoWindow.Hours()),
+	})
+}
+
+// undoMergeHandler undoes the stats merge of a transfer and puts this device back on the
+// session and player it had before redeeming.
+func (app *App) undoMergeHandler(c *gin.Context) {
+	now := app.now()
+	token := c.PostForm("token")
+	prev, ok := app.Transfers.takePrevious(token, now)
+	if !ok || !app.spendToken(TokenKindMergeUndo, token, prev.expires) || !app.Players.undoMerge(token, now) {
+		if wantsJSON(c) {
+			writeProblem(c, http.StatusNotFound, ErrorCodeNotFound, "merge cannot be undone")
+			return
+		}
+		app.renderTransfer(c, http.StatusNotFound, "undo-expired", gin.H{})
+		return
+	}
+	if prev.sessionID != "" {
+		app.useIdentity(c, prev)
+	}
+	app.incMetric(MetricStatsMergesUndone)
+	logInfo("Undid stats merge into session %s", redactSession(prev.sessionID))
+	if wantsJSON(c) {
+		c.JSON(http.StatusOK, gin.H{"undone": true})
+		return
+	}
+	c.Redirect(http.StatusSeeOther, RouteHome)
+}
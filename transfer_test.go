@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTransferCodes(t *testing.T) {
+	tr := newTransfers()
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	first, err := tr.create("session-one-abc", "", now)
+	if err != nil || len(first) != TransferCodeLength {
+		t.Fatalf("create = %q, %v", first, err)
+	}
+	code, _ := tr.create("session-one-abc", "", now)
+	if _, ok := tr.redeem(first, now); ok {
+		t.Error("Expected a replaced code to be rejected")
+	}
+	got, ok := tr.redeem(" "+strings.ToLower(code)+" ", now)
+	if !ok || got.sessionID != "session-one-abc" {
+		t.Fatalf("redeem = %+v, %v", got, ok)
+	}
+	if _, ok := tr.redeem(code, now); ok {
+		t.Error("Expected a code to work only once")
+	}
+	code, _ = tr.create("session-one-abc", "", now)
+	if _, ok := tr.redeem(code, now.Add(TransferTTL)); ok {
+		t.Error("Expected an expired code to be rejected")
+	}
+}
+
+func TestTransferMovesSessionAndPlayer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Transfers = newTransfers()
+	app.PlayerIDs = newPlayerIDs(newKeyring(make([]byte, 32)))
+	app.PlayerCookieMaxAge = 365 * 24 * time.Hour
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.POST(RouteTransfer, app.createTransferHandler)
+	router.POST(RouteTransfer+"/redeem", app.redeemTransferHandler)
+	router.GET(RouteTransfer+"/:code", app.transferLinkHandler)
+
+	req := httptest.NewRequest("POST", RouteTransfer, nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "phone-session-1"})
+	req.AddCookie(&http.Cookie{Name: PlayerCookieName, Value: app.PlayerIDs.issue("player-one")})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "<svg") {
+		t.Fatalf("create = %d: %s", w.Code, body)
+	}
+	code := app.Transfers.bySession["phone-session-1"]
+	if !strings.Contains(body, code) {
+		t.Fatalf("Expected the page to show code %s", code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", RouteTransfer+"/"+code, nil))
+	if w.Code != http.StatusOK || app.Transfers.bySession["phone-session-1"] != code {
+		t.Fatal("Expected opening a transfer link to leave the code unused")
+	}
+
+	redeem := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", RouteTransfer+"/redeem", strings.NewReader(url.Values{"code": {code}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "laptop-session"})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	w = redeem()
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("redeem = %d", w.Code)
+	}
+	cookies := map[string]string{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+	if cookies[SessionCookieName] != "phone-session-1" {
+		t.Errorf("session cookie = %q, want phone-session-1", cookies[SessionCookieName])
+	}
+	if id, ok := app.PlayerIDs.verify(cookies[PlayerCookieName]); !ok || id != "player-one" {
+		t.Errorf("player cookie = %q, want player-one", cookies[PlayerCookieName])
+	}
+	if w := redeem(); w.Code != http.StatusNotFound {
+		t.Errorf("second redeem = %d, want 404", w.Code)
+	}
+}
+
+func TestTransferMergesDeviceStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Transfers = newTransfers()
+	app.Players = newPlayerStatsStore(StreakFreezeRules{})
+	app.PlayerIDs = newPlayerIDs(newKeyring(make([]byte, 32)))
+	app.PlayerCookieMaxAge = 365 * 24 * time.Hour
+	app.Players.record(playerStatsKey("laptop-player"), GameRecord{Word: "SLATE", Won: true, Guesses: 3})
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.POST(RouteTransfer+"/redeem", app.redeemTransferHandler)
+	router.POST(RouteTransfer+"/undo", app.undoMergeHandler)
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "laptop-session"})
+		req.AddCookie(&http.Cookie{Name: PlayerCookieName, Value: app.PlayerIDs.issue("laptop-player")})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	code, _ := app.Transfers.create("phone-session-1", "phone-player", app.now())
+	w := post(RouteTransfer+"/redeem", url.Values{"code": {code}})
+	var redeemed struct {
+		Merged    mergeResult `json:"merged"`
+		UndoToken string      `json:"undo_token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &redeemed); err != nil || redeemed.Merged.Games != 1 || redeemed.UndoToken == "" {
+		t.Fatalf("redeem = %d %s", w.Code, w.Body.String())
+	}
+	if got := len(app.Players.history(playerStatsKey("phone-player"))); got != 1 {
+		t.Errorf("phone player has %d games, want the laptop's 1", got)
+	}
+
+	w = post(RouteTransfer+"/undo", url.Values{"token": {redeemed.UndoToken}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("undo = %d %s", w.Code, w.Body.String())
+	}
+	if got := len(app.Players.history(playerStatsKey("laptop-player"))); got != 1 {
+		t.Errorf("laptop player has %d games after undo, want 1", got)
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == SessionCookieName && cookie.Value != "laptop-session" {
+			t.Errorf("session cookie after undo = %q, want laptop-session", cookie.Value)
+		}
+	}
+}
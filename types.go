@@ -1,9 +1,12 @@
 package main
 
 import (
+	"expvar"
+	"html/template"
 	"sync"
 	"time"
 
+	"github.com/mooship/vortludo/engine"
 	"golang.org/x/time/rate"
 )
 
@@ -11,62 +14,99 @@ import (
 type contextKey string
 
 // WordEntry represents a word and its associated hint.
-type WordEntry struct {
-	Word string `json:"word"`
-	Hint string `json:"hint"`
-}
+type WordEntry = engine.WordEntry
 
 // WordList is a container for a list of WordEntry items, used for JSON unmarshalling.
-type WordList struct {
-	Words []WordEntry `json:"words"`
-}
+type WordList = engine.WordList
 
-// GameState holds the state of a user's current game session.
+// GameState holds the state of a user's current game session: the engine's game plus what the
+// server tracks about it. The engine's fields are embedded, so both are saved as one JSON object.
 type GameState struct {
-	Guesses        [][]GuessResult `json:"guesses"`
-	CurrentRow     int             `json:"currentRow"`
-	GameOver       bool            `json:"gameOver"`
-	Won            bool            `json:"won"`
-	TargetWord     string          `json:"targetWord"`
-	SessionWord    string          `json:"sessionWord"`
-	GuessHistory   []string        `json:"guessHistory"`
-	LastAccessTime time.Time       `json:"lastAccessTime"`
+	engine.GameState
+	Pack string `json:"pack,omitempty"`
+	// WordsVersion is the version of the word lists the game started with.
+	WordsVersion string `json:"wordsVersion,omitempty"`
+	// Daily is the puzzle date, YYYY-MM-DD, of a game of the daily puzzle.
+	Daily  string `json:"daily,omitempty"`
+	Custom bool   `json:"custom,omitempty"`
+	Purist bool   `json:"purist,omitempty"`
+	Kids   bool   `json:"kids,omitempty"`
+	Rated  bool   `json:"rated,omitempty"`
+	// ExtraRows counts the rows added to the board beyond MaxGuesses.
+	ExtraRows     int    `json:"extraRows,omitempty"`
+	Revealed      []int  `json:"revealed,omitempty"`
+	Completed     []byte `json:"completed,omitempty"`
+	ProgressToken string `json:"progressToken,omitempty"`
 }
 
 // GuessResult represents the result of a single letter in a guess.
-type GuessResult struct {
-	Letter string `json:"letter"`
-	Status string `json:"status"`
-}
+type GuessResult = engine.GuessResult
 
 // App is the main application struct holding all global state and configuration.
 type App struct {
-	WordList        []WordEntry
-	WordSet         map[string]struct{}
-	AcceptedWordSet map[string]struct{}
-	HintMap         map[string]string
-	GameSessions    map[string]*GameState
-	SessionMutex    sync.RWMutex
-	LimiterMap      map[string]*rate.Limiter
-	LimiterMutex    sync.RWMutex
-	IsProduction    bool
-	StartTime       time.Time
-	CookieMaxAge    time.Duration
-	StaticCacheAge  time.Duration
-	RateLimitRPS    int
-	RateLimitBurst  int
-	RuneBufPool     *sync.Pool
-}
-
-// globalApp holds a reference to the running App instance for small helpers.
-var globalApp *App
-
-// setGlobalApp sets the package-level App pointer.
-func setGlobalApp(a *App) {
-	globalApp = a
-}
-
-// getAppInstance returns the package-level App pointer (may be nil in tests).
-func getAppInstance() *App {
-	return globalApp
+	Words                *WordRegistry
+	GameSessions         map[string]*GameState
+	SessionMutex         sync.RWMutex
+	LimiterMap           map[string]*rate.Limiter
+	LimiterMutex         sync.RWMutex
+	IsProduction         bool
+	Namespace            string
+	PublicBaseURL        string
+	StartTime            time.Time
+	Clock                Clock
+	CookieMaxAge         time.Duration
+	Retention            RetentionPolicy
+	PlayerCookieMaxAge   time.Duration
+	MaxSessions          int
+	StaticCacheAge       time.Duration
+	RequestTimeout       time.Duration
+	HealthMinFreeMB      int
+	RateLimitRPS         int
+	RateLimitBurst       int
+	HintRateInterval     time.Duration
+	HintRateBurst        int
+	ValidateRateInterval time.Duration
+	ValidateRateBurst    int
+	ExportRateInterval   time.Duration
+	ExportRateBurst      int
+	Metrics              *expvar.Map
+	Blocklist            *Blocklist
+	Captcha              *Captcha
+	Analytics            *Analytics
+	AdminToken           string
+	WasmEnabled          bool
+	PrintTemplates       *template.Template
+	Daily                *DailySchedule
+	Calendar             *PuzzleCalendar
+	Suggestions          *SuggestionQueue
+	Feedback             *WordFeedback
+	Bonus                *BonusRounds
+	Coins                CoinRules
+	Kids                 KidsRules
+	Selectors            map[string]WordSelector
+	GuessCache           *GuessCache
+	Journal              *SessionJournal
+	Integrity            *IntegrityScanner
+	Fleet                *Fleet
+	Leases               *JobLeases
+	StoreHealth          *StoreHealth
+	Patterns             *engine.FeedbackMatrix
+	Keys                 *Keyring
+	Progress             *ProgressTokens
+	Games                *GameTokens
+	Stats                *GlobalStats
+	Players              *PlayerStatsStore
+	Spectate             *SpectateLinks
+	Rooms                *CoopRooms
+	Classrooms           *Classrooms
+	Transfers            *Transfers
+	UsedTokens           *UsedTokens
+	Preferences          *PreferenceStore
+	Content              *ContentPages
+	Audit                *AuditLog
+	Reminders            *Reminders
+	Push                 *WebPush
+	Quarantine           *SessionQuarantine
+	PlayerIDs            *PlayerIDs
+	Experiments          Experiments
 }
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,6 +24,15 @@ func dirExists(path string) bool {
 	return info.IsDir()
 }
 
+// fileExists returns true if the given path exists and is a regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return info.Mode().IsRegular()
+}
+
 // formatUptime returns a human-readable string for a duration.
 func formatUptime(d time.Duration) string {
 	seconds := int(d.Seconds()) % 60
@@ -49,6 +61,30 @@ func plural(n int) string {
 	return "s"
 }
 
+// getEnvString reads a string from the environment or returns a fallback.
+func getEnvString(key, fallback string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return fallback
+}
+
+// getEnvList reads a comma-separated list from the environment or returns a fallback. Blank
+// items are dropped, so an empty-but-set variable such as "," clears the list.
+func getEnvList(key string, fallback []string) []string {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	var items []string
+	for item := range strings.SplitSeq(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // getEnvDuration reads a time.Duration from the environment or returns a fallback.
 func getEnvDuration(key string, fallback time.Duration) time.Duration {
 	val := os.Getenv(key)
@@ -82,13 +118,89 @@ func parseInt(val string) (int, error) {
 	return strconv.Atoi(val)
 }
 
+// Log level constants, ordered from most to least verbose.
+const (
+	LogLevelDebug = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// currentLogLevel is the minimum level that is written to the log.
+var currentLogLevel = LogLevelInfo
+
+// parseLogLevel converts a level name (debug, info, warn, error) to a log level constant.
+func parseLogLevel(name string) (int, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LogLevelDebug, true
+	case "info":
+		return LogLevelInfo, true
+	case "warn", "warning":
+		return LogLevelWarn, true
+	case "error":
+		return LogLevelError, true
+	default:
+		return LogLevelInfo, false
+	}
+}
+
+// configureLogLevel sets the log level from the LOG_LEVEL environment variable, defaulting to info.
+func configureLogLevel() {
+	val := os.Getenv("LOG_LEVEL")
+	if val == "" {
+		return
+	}
+	level, ok := parseLogLevel(val)
+	if !ok {
+		logWarn("Invalid LOG_LEVEL %q, using info", val)
+	}
+	currentLogLevel = level
+}
+
+// isDebugLogging reports whether debug-level logging is enabled.
+func isDebugLogging() bool {
+	return currentLogLevel <= LogLevelDebug
+}
+
+// redactSession returns a short stable hash of a session ID so log lines can be correlated without exposing it.
+func redactSession(sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(sessionID))
+	return "sess-" + hex.EncodeToString(sum[:6])
+}
+
+// redactWord hides target words and hints in logs unless debug logging is enabled.
+func redactWord(word string) string {
+	if isDebugLogging() {
+		return word
+	}
+	return "[redacted]"
+}
+
+// logDebug logs a debug-level message.
+func logDebug(format string, v ...any) {
+	if !isDebugLogging() {
+		return
+	}
+	log.Printf("[DEBUG] "+format, v...)
+}
+
 // logInfo logs an info-level message.
 func logInfo(format string, v ...any) {
+	if currentLogLevel > LogLevelInfo {
+		return
+	}
 	log.Printf("[INFO] "+format, v...)
 }
 
 // logWarn logs a warning-level message.
 func logWarn(format string, v ...any) {
+	if currentLogLevel > LogLevelWarn {
+		return
+	}
 	log.Printf("[WARN] "+format, v...)
 }
 
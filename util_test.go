@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -88,3 +89,48 @@ func TestParseInt(t *testing.T) {
 		t.Errorf("parseInt(\"notanint\") should error")
 	}
 }
+
+func TestRedactSession(t *testing.T) {
+	id := "7f9c2ba4-e88f-4d2a-9c1e-1234567890ab"
+	got := redactSession(id)
+	if got == id || !strings.HasPrefix(got, "sess-") {
+		t.Errorf("redactSession(%q) = %q, want hashed value", id, got)
+	}
+	if redactSession(id) != got {
+		t.Error("redactSession should be stable")
+	}
+	if redactSession("") != "" {
+		t.Error("redactSession of empty ID should be empty")
+	}
+}
+
+func TestRedactWord(t *testing.T) {
+	defer func(level int) { currentLogLevel = level }(currentLogLevel)
+
+	currentLogLevel = LogLevelInfo
+	if got := redactWord("APPLE"); got != "[redacted]" {
+		t.Errorf("redactWord at info level = %q, want [redacted]", got)
+	}
+	currentLogLevel = LogLevelDebug
+	if got := redactWord("APPLE"); got != "APPLE" {
+		t.Errorf("redactWord at debug level = %q, want APPLE", got)
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	cases := map[string]int{
+		"debug":   LogLevelDebug,
+		"INFO":    LogLevelInfo,
+		" warn ":  LogLevelWarn,
+		"warning": LogLevelWarn,
+		"error":   LogLevelError,
+	}
+	for in, want := range cases {
+		if got, ok := parseLogLevel(in); !ok || got != want {
+			t.Errorf("parseLogLevel(%q) = %d, %v; want %d, true", in, got, ok, want)
+		}
+	}
+	if got, ok := parseLogLevel("verbose"); ok || got != LogLevelInfo {
+		t.Errorf("parseLogLevel(\"verbose\") = %d, %v; want info, false", got, ok)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// VAPIDTokenLifetime is how long a signed VAPID token is valid; push services reject tokens
+// lasting over 24 hours.
+const VAPIDTokenLifetime = 12 * time.Hour
+
+// vapidKeyFile is the persisted form of a VAPID key pair.
+type vapidKeyFile struct {
+	PrivateKey string `json:"private_key"`
+}
+
+// VAPIDKeys identifies this server to push services (RFC 8292). Browsers bind each push
+// subscription to the public key, so the pair is generated once and persisted: a new key would
+// silently orphan every existing subscription.
+type VAPIDKeys struct {
+	private *ecdsa.PrivateKey
+	// PublicKey is the uncompressed P-256 public key, base64url-encoded, as browsers expect it
+	// for applicationServerKey.
+	PublicKey string
+	Subject   string
+}
+
+// loadVAPIDKeys reads the key pair at path, generating and saving one when the file is missing.
+func loadVAPIDKeys(storage Storage, path, subject string) (*VAPIDKeys, error) {
+	var file vapidKeyFile
+	found, err := readJSONFile(storage, path, &file)
+	if err != nil {
+		return nil, err
+	}
+	var key *ecdsa.PrivateKey
+	if found {
+		raw, err := base64.RawURLEncoding.DecodeString(file.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("decode VAPID key: %w", err)
+		}
+		if key, err = ecdsa.ParseRawPrivateKey(elliptic.P256(), raw); err != nil {
+			return nil, fmt.Errorf("parse VAPID key: %w", err)
+		}
+	} else {
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			return nil, err
+		}
+		raw, err := key.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		if err := writeJSONFile(storage, path, vapidKeyFile{PrivateKey: base64.RawURLEncoding.EncodeToString(raw)}); err != nil {
+			return nil, err
+		}
+		logInfo("Generated VAPID keys at %s", path)
+	}
+	public, err := key.PublicKey.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &VAPIDKeys{private: key, PublicKey: base64.RawURLEncoding.EncodeToString(public), Subject: subject}, nil
+}
+
+// authorization returns the Authorization header value for a push to endpoint: an ES256 JWT
+// for the endpoint's origin, and the public key.
+func (k *VAPIDKeys) authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(VAPIDTokenLifetime).Unix(),
+		"sub": k.Subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return "vapid t=" + signingInput + "." + base64.RawURLEncoding.EncodeToString(sig) + ", k=" + k.PublicKey, nil
+}
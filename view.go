@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/mooship/vortludo/engine"
+)
+
+// tileView is a single board tile with its position in the reveal sequence. Masked tiles show
+// their status without the letter.
+type tileView struct {
+	Letter        string `json:"letter"`
+	Status        string `json:"status"`
+	Index         int    `json:"index"`
+	RevealDelayMs int64  `json:"revealDelayMs"`
+	Masked        bool   `json:"masked,omitempty"`
+}
+
+// guessRowView is the data for one board row, rendered on its own for out-of-band swaps.
+type guessRowView struct {
+	Index         int        `json:"index"`
+	Tiles         []tileView `json:"tiles"`
+	Active        bool       `json:"active"`
+	OOB           bool       `json:"-"`
+	RevealTotalMs int64      `json:"revealTotalMs"`
+}
+
+// templateFuncs returns the functions available to HTML templates; integrity supplies the SRI
+// attributes emitted by sri and sprite the icons rendered by icon.
+func templateFuncs(integrity assetIntegrity, sprite *iconSprite) template.FuncMap {
+	return template.FuncMap{
+		"hasPrefix":    strings.HasPrefix,
+		"sri":          integrity.attr,
+		"icon":         sprite.icon,
+		"guessRow":     guessRow,
+		"keyStatuses":  keyStatuses,
+		"spectatorRow": spectatorRow,
+	}
+}
+
+// guessRow builds the view for a board row; oob marks it for an HTMX out-of-band swap.
+func guessRow(game *GameState, row int, oob bool) guessRowView {
+	view := guessRowView{Index: row, OOB: oob}
+	if game == nil || row < 0 || row >= len(game.Guesses) {
+		return view
+	}
+	view.Active = row == game.CurrentRow && !game.GameOver
+	view.Tiles = make([]tileView, len(game.Guesses[row]))
+	for i, tile := range game.Guesses[row] {
+		view.Tiles[i] = tileView{
+			Letter:        tile.Letter,
+			Status:        tile.Status,
+			Index:         i,
+			RevealDelayMs: tileRevealDelay(i).Milliseconds(),
+		}
+	}
+	view.RevealTotalMs = (tileRevealDelay(len(view.Tiles)-1) + TileFlipDuration).Milliseconds()
+	return view
+}
+
+// tileRevealDelay returns how long after a row is revealed the tile at index starts flipping.
+func tileRevealDelay(index int) time.Duration {
+	return time.Duration(max(index, 0)) * TileRevealStagger
+}
+
+// keyStatuses returns the keyboard's letter statuses for game as a JSON object for Alpine.
+func keyStatuses(game *GameState) string {
+	if game == nil {
+		return "{}"
+	}
+	b, err := json.Marshal(engine.KeyStatuses(game.Guesses))
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func parseTestTemplates(t testing.TB) *template.Template {
+	t.Helper()
+	tpl := template.New("").Funcs(templateFuncs(nil, nil))
+	template.Must(tpl.ParseGlob("templates/*.html"))
+	template.Must(tpl.ParseGlob("templates/partials/*.html"))
+	return tpl
+}
+
+func TestKeyStatuses(t *testing.T) {
+	if got := keyStatuses(nil); got != "{}" {
+		t.Errorf("keyStatuses(nil) = %s, want {}", got)
+	}
+	game := newGame("TRACE")
+	game.ApplyGuess("CRANE", "TRACE", checkGuess("CRANE", "TRACE"), true)
+	want := `{"A":"correct","C":"present","E":"correct","N":"absent","R":"correct"}`
+	if got := keyStatuses(game); got != want {
+		t.Errorf("keyStatuses() = %s, want %s", got, want)
+	}
+}
+
+func TestGuessUpdateTemplate(t *testing.T) {
+	tpl := parseTestTemplates(t)
+	game := newGame("TRACE")
+	game.ApplyGuess("CRANE", "TRACE", checkGuess("CRANE", "TRACE"), true)
+
+	var buf bytes.Buffer
+	err := tpl.ExecuteTemplate(&buf, "guess-update", gin.H{"game": game, "previousRow": 0, "oob": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{`id="guess-row-0"`, `id="guess-row-1"`, `id="keyboard-keys"`, "tile-correct", "&#34;N&#34;:&#34;absent&#34;", "x-for="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("guess-update output missing %q", want)
+		}
+	}
+	if strings.Count(out, `hx-swap-oob="true"`) != 3 {
+		t.Errorf("Expected 3 out-of-band elements, got %d", strings.Count(out, `hx-swap-oob="true"`))
+	}
+	if strings.Contains(out, `id="guess-row-2"`) {
+		t.Error("guess-update should not render untouched rows")
+	}
+}
+
+func TestGameContentKeyboardOOB(t *testing.T) {
+	tpl := parseTestTemplates(t)
+	game := newGame("TRACE")
+
+	for _, oob := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := tpl.ExecuteTemplate(&buf, "game-content", gin.H{"game": game, "oob": oob}); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(buf.String(), `id="keyboard-keys"`); got != oob {
+			t.Errorf("oob=%v: keyboard rendered = %v", oob, got)
+		}
+	}
+}
+
+func TestGuessRowRevealTiming(t *testing.T) {
+	game := newGame("TRACE")
+	game.ApplyGuess("CRANE", "TRACE", checkGuess("CRANE", "TRACE"), true)
+
+	row := guessRow(game, 0, false)
+	if row.Active {
+		t.Error("Guessed row should not be active")
+	}
+	for i, tile := range row.Tiles {
+		if tile.Index != i || tile.RevealDelayMs != int64(i)*TileRevealStagger.Milliseconds() {
+			t.Errorf("Tile %d has index %d and delay %dms", i, tile.Index, tile.RevealDelayMs)
+		}
+	}
+	want := (4*TileRevealStagger + TileFlipDuration).Milliseconds()
+	if row.RevealTotalMs != want {
+		t.Errorf("RevealTotalMs = %d, want %d", row.RevealTotalMs, want)
+	}
+
+	tpl := parseTestTemplates(t)
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "guess-row", row); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{`data-reveal-total="1000"`, `data-reveal-delay="400"`, `--reveal-delay: 400ms`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("guess-row output missing %q", want)
+		}
+	}
+}
+
+func BenchmarkRenderGameContent(b *testing.B) {
+	tpl := parseTestTemplates(b)
+	game := newGame("TRACE")
+	for _, guess := range []string{"CRANE", "SLATE", "TRACK"} {
+		game.ApplyGuess(guess, "TRACE", checkGuess(guess, "TRACE"), true)
+	}
+	data := gin.H{"game": game, "hint": "Follow the path.", "csrf_token": "token", "oob": true}
+	for b.Loop() {
+		if err := tpl.ExecuteTemplate(io.Discard, "game-content", data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderGuessUpdate(b *testing.B) {
+	tpl := parseTestTemplates(b)
+	game := newGame("TRACE")
+	game.ApplyGuess("CRANE", "TRACE", checkGuess("CRANE", "TRACE"), true)
+	data := gin.H{"game": game, "previousRow": 0, "oob": true}
+	for b.Loop() {
+		if err := tpl.ExecuteTemplate(io.Discard, "guess-update", data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
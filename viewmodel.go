@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+// FragmentFormatJSON is the format query value that asks fragment endpoints for the view-model.
+const FragmentFormatJSON = "json"
+
+// gameViewModel is the board as the templates see it, for clients that render it themselves.
+// The target word is only included once the game is over.
+type gameViewModel struct {
+	Rows         []guessRowView    `json:"rows"`
+	Keys         map[string]string `json:"keys"`
+	CurrentRow   int               `json:"currentRow"`
+	GameOver     bool              `json:"gameOver"`
+	Won          bool              `json:"won"`
+	Pack         string            `json:"pack,omitempty"`
+	Purist       bool              `json:"purist,omitempty"`
+	Kids         bool              `json:"kids,omitempty"`
+	Daily        string            `json:"daily,omitempty"`
+	Word         string            `json:"word,omitempty"`
+	Hint         string            `json:"hint,omitempty"`
+	HintsUsed    int               `json:"hintsUsed"`
+	NewGame      bool              `json:"newGame,omitempty"`
+	ChallengeURL string            `json:"challengeUrl,omitempty"`
+	ErrorCode    string            `json:"errorCode,omitempty"`
+	ErrorMessage string            `json:"errorMessage,omitempty"`
+	DailySummary string            `json:"dailySummary,omitempty"`
+}
+
+// wantsJSONView reports whether the request asked for the view-model instead of HTML.
+func wantsJSONView(c *gin.Context) bool {
+	return c.Query("format") == FragmentFormatJSON
+}
+
+// newGameViewModel builds the view-model for a fragment's template data. Spectators get masked
+// rows and no keyboard until the game ends, as on the spectate page.
+func newGameViewModel(data gin.H, spectator bool) gameViewModel {
+	game, _ := data["game"].(*GameState)
+	if game == nil {
+		return gameViewModel{Rows: []guessRowView{}, Keys: map[string]string{}}
+	}
+	view := gameViewModel{
+		Rows:       make([]guessRowView, len(game.Guesses)),
+		Keys:       engine.KeyStatuses(game.Guesses),
+		CurrentRow: game.CurrentRow,
+		GameOver:   game.GameOver,
+		Won:        game.Won,
+		Pack:       game.Pack,
+		Purist:     game.Purist,
+		Kids:       game.Kids,
+		Daily:      game.Daily,
+		HintsUsed:  game.HintsUsed,
+	}
+	for i := range game.Guesses {
+		if spectator {
+			view.Rows[i] = spectatorRow(game, i)
+		} else {
+			view.Rows[i] = guessRow(game, i, false)
+		}
+	}
+	if spectator && !game.GameOver {
+		view.Keys = map[string]string{}
+	}
+	if game.GameOver {
+		view.Word = game.TargetWord
+	}
+	if !spectator {
+		view.Hint, _ = data["hint"].(string)
+		view.NewGame, _ = data["newGame"].(bool)
+		view.ChallengeURL, _ = data["challenge_url"].(string)
+		view.DailySummary, _ = data["daily_summary"].(string)
+	}
+	if code, _ := data["error_code"].(string); code != "" {
+		view.ErrorCode = code
+		view.ErrorMessage = gameErrorMessage(game, code)
+	}
+	return view
+}
+
+// renderFragment renders the named fragment template, or its view-model as JSON when the
+// request has format=json.
+func renderFragment(c *gin.Context, name string, data gin.H) {
+	if wantsJSONView(c) {
+		c.JSON(http.StatusOK, newGameViewModel(data, name == "spectate-board"))
+		return
+	}
+	c.HTML(http.StatusOK, name, data)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestGameViewModel(t *testing.T) {
+	game := newGame("TRACE")
+	game.ApplyGuess("CRANE", "TRACE", checkGuess("CRANE", "TRACE"), true)
+
+	view := newGameViewModel(gin.H{"game": game, "hint": "a clue", "error_code": ErrorCodeDuplicateGuess}, false)
+	if len(view.Rows) != len(game.Guesses) || view.Rows[0].Tiles[0].Letter != "C" || !view.Rows[1].Active {
+		t.Errorf("rows = %+v, want the guessed row first and the next row active", view.Rows[:2])
+	}
+	if view.Keys["R"] != "correct" || view.Hint != "a clue" || view.Word != "" {
+		t.Errorf("view = %+v, want keys and hint without the target word", view)
+	}
+	if view.ErrorCode != ErrorCodeDuplicateGuess || view.ErrorMessage != errorMessage(ErrorCodeDuplicateGuess) {
+		t.Errorf("error = %q %q", view.ErrorCode, view.ErrorMessage)
+	}
+
+	spectated := newGameViewModel(gin.H{"game": game, "hint": "a clue"}, true)
+	if tile := spectated.Rows[0].Tiles[0]; tile.Letter != "" || !tile.Masked {
+		t.Errorf("spectator tile = %+v, want masked", tile)
+	}
+	if len(spectated.Keys) != 0 || spectated.Hint != "" {
+		t.Errorf("spectator view = %+v, want no keys or hint before the game ends", spectated)
+	}
+
+	game.ApplyGuess("TRACE", "TRACE", checkGuess("TRACE", "TRACE"), true)
+	if over := newGameViewModel(gin.H{"game": game}, true); over.Word != "TRACE" || !over.Won || over.Rows[0].Tiles[0].Letter != "C" {
+		t.Errorf("finished view = %+v, want the revealed board and word", over)
+	}
+}
+
+func TestRenderFragmentJSON(t *testing.T) {
+	game := newGame("TRACE")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, RouteGameState+"?format=json", nil)
+
+	renderFragment(c, "game-content", gin.H{"game": game})
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var got gameViewModel
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Rows) != engine.MaxGuesses || got.CurrentRow != 0 || got.GameOver {
+		t.Errorf("decoded view = %+v", got)
+	}
+}
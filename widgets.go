@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/internal/htmx"
+)
+
+// statsWidgets is what the home page stats widgets show.
+type statsWidgets struct {
+	// CurrentStreak and MaxStreak are the player's daily streaks.
+	CurrentStreak int `json:"current_streak"`
+	MaxStreak     int `json:"max_streak"`
+	// BestGuesses is the fewest guesses the player has won in, or 0 before their first win.
+	BestGuesses int `json:"best_guesses"`
+	// PlayedToday is whether the player has finished today's daily puzzle.
+	PlayedToday bool `json:"played_today"`
+	// SolvedToday and PlayedTodayTotal count every player's games of today's daily puzzle.
+	SolvedToday      int `json:"solved_today"`
+	PlayedTodayTotal int `json:"played_today_total"`
+}
+
+// bestGuesses returns the fewest guesses of any win in a guess distribution, or 0 without wins.
+func bestGuesses(distribution [MaxGuesses]int) int {
+	for i, n := range distribution {
+		if n > 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// statsWidgets composes the widgets for the request's player from the personal and global
+// stats. The global counts are for the daily puzzle the player would get from /daily. A
+// visitor without a session gets only the global counts.
+func (app *App) statsWidgets(c *gin.Context) statsWidgets {
+	var w statsWidgets
+	today := app.todaysPuzzle(c).Format(time.DateOnly)
+	if app.Stats != nil {
+		puzzle := app.Stats.day(today)
+		w.SolvedToday, w.PlayedTodayTotal = puzzle.Won, puzzle.Played
+	}
+	sessionID, _ := c.Cookie(app.cookieName(SessionCookieName))
+	key := app.playerKey(c, sessionID)
+	if app.Players == nil || key == "" {
+		return w
+	}
+	history, _, summary := app.Players.summary(key, today)
+	w.CurrentStreak, w.MaxStreak = summary.CurrentStreak, summary.MaxStreak
+	w.BestGuesses = bestGuesses(summary.Distribution)
+	w.PlayedToday = len(history) > 0 && history[len(history)-1].PuzzleDate == today
+	return w
+}
+
+// statsWidgetsHandler serves the home page stats widgets: the player's streak, the solve count
+// of today's daily puzzle, and personal bests. The page loads them as a lazy HTMX fragment once the board is
+// showing, so they stay off the critical path; other clients get JSON.
+func (app *App) statsWidgetsHandler(c *gin.Context) {
+	widgets := app.statsWidgets(c)
+	c.Header("Cache-Control", "private, no-cache")
+	if htmx.IsRequest(c.Request) {
+		c.HTML(http.StatusOK, "stats-widgets", gin.H{"widgets": widgets, "max_guesses": MaxGuesses})
+		return
+	}
+	c.JSON(http.StatusOK, widgets)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStatsWidgets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	app := testAppWithWords([]WordEntry{{Word: "CRANE"}})
+	app.Players = newPlayerStatsStore(StreakFreezeRules{})
+	app.Stats = newGlobalStats("")
+	app.Clock = newFakeClock()
+	app.Daily = newDailySchedule("UTC", 0, false)
+	router := gin.New()
+	router.SetHTMLTemplate(parseTestTemplates(t))
+	router.GET(RouteStatsWidgets, app.statsWidgetsHandler)
+	get := func(htmx bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", RouteStatsWidgets, nil)
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "widget-session"})
+		if htmx {
+			req.Header.Set("HX-Request", "true")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	app.Players.record("widget-session", GameRecord{Word: "SLATE", Won: true, Guesses: 4, PuzzleDate: "2029-12-30"})
+	app.Players.record("widget-session", GameRecord{Word: "CRANE", Won: true, Guesses: 3, PuzzleDate: "2029-12-31"})
+	app.Stats.apply(gameOutcome{Date: "2030-01-01", Won: true, Guesses: 3})
+	app.Stats.apply(gameOutcome{Date: "2030-01-01", Won: false, Guesses: 6})
+
+	var widgets statsWidgets
+	if err := json.Unmarshal(get(false).Body.Bytes(), &widgets); err != nil {
+		t.Fatal(err)
+	}
+	want := statsWidgets{CurrentStreak: 2, MaxStreak: 2, BestGuesses: 3, SolvedToday: 1, PlayedTodayTotal: 2}
+	if widgets != want {
+		t.Errorf("widgets = %+v, want %+v", widgets, want)
+	}
+
+	body := get(true).Body.String()
+	for _, text := range []string{`id="stats-widgets"`, "Play today to keep it", `of 2 solved <a href="/daily">today's puzzle</a>`, "3/6", "2-day max streak"} {
+		if !strings.Contains(body, text) {
+			t.Errorf("Expected the fragment to contain %q:\n%s", text, body)
+		}
+	}
+
+	app.Players.record("widget-session", GameRecord{Word: "CRANE", Won: true, Guesses: 2, PuzzleDate: "2030-01-01"})
+	if body := get(true).Body.String(); strings.Contains(body, "Play today to keep it") || !strings.Contains(body, "2/6") {
+		t.Errorf("Expected the streak nudge to go once today is played:\n%s", body)
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mooship/vortludo/engine"
+)
+
+// packNamePattern restricts pack names to values that are safe in URLs, forms, and storage keys.
+var packNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// WordPack is a named list of playable words, such as a themed set of animals or foods.
+type WordPack struct {
+	Name    string
+	Words   []WordEntry
+	Set     map[string]struct{}
+	Version uint64
+}
+
+// newWordPack builds a pack from its words, deriving the lookup set and list version.
+func newWordPack(name string, words []WordEntry) *WordPack {
+	return &WordPack{Name: name, Words: words, Set: engine.WordSet(words), Version: wordListVersion(words)}
+}
+
+// loadWordPacks builds the word pack registry: the default pack from WordsFile followed by every
+// *.json file in PacksDir, sorted by name. A missing packs directory is not an error.
+func loadWordPacks() ([]*WordPack, error) {
+	words, _, err := loadWords()
+	if err != nil {
+		return nil, err
+	}
+	packs := []*WordPack{newWordPack(DefaultPackName, words)}
+
+	files, err := filepath.Glob(filepath.Join(PacksDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		if !packNamePattern.MatchString(name) || name == DefaultPackName {
+			logWarn("Skipping word pack %s: invalid or reserved name", file)
+			continue
+		}
+		pack, err := loadWordPack(name, file)
+		if err != nil {
+			logWarn("Skipping word pack %s: %v", file, err)
+			continue
+		}
+		packs = append(packs, pack)
+		logInfo("Loaded word pack %q with %d words", name, len(pack.Words))
+	}
+	return packs, nil
+}
+
+// loadWordPack reads a single word pack file in the same format as WordsFile.
+func loadWordPack(name, path string) (*WordPack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	words, skipped, err := engine.ParseWordList(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range skipped {
+		logWarn("Skipping word %q in pack %s: not 5 letters", word, name)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("pack %s has no playable words", name)
+	}
+	return newWordPack(name, words), nil
+}
+
+// WordLists is one version of every word list: the packs, the playable and accepted word
+// sets, and hints. Lists are never modified once built; a reload installs a new WordLists.
+type WordLists struct {
+	WordList          []WordEntry
+	WordSet           map[string]struct{}
+	AcceptedWordSet   map[string]struct{}
+	AcceptedWordsText []byte
+	WordListVersion   string
+	// HintMap holds each word's hint from the first pack listing it, for lookups without a pack.
+	HintMap map[string]string
+	// PackHints holds each pack's own hints, so a word in several packs keeps the hint of the
+	// pack it is played from.
+	PackHints map[string]map[string]string
+	Packs     map[string]*WordPack
+	PackNames []string
+	// Overlays adjust the accepted guesses per game mode.
+	Overlays map[string]*AcceptedOverlay
+	// LoadedAt is when the lists were read from disk.
+	LoadedAt time.Time
+}
+
+// newWordLists builds the lists for a pack registry. The default pack backs WordList, while
+// WordSet and the hints cover every pack so guesses and hints work regardless of the pack in
+// play. Pack words are also added to the accepted words so every target word can be guessed.
+func newWordLists(packs []*WordPack, accepted map[string]struct{}, overlays map[string]*AcceptedOverlay) *WordLists {
+	lists := &WordLists{
+		AcceptedWordSet: maps.Clone(accepted),
+		Overlays:        overlays,
+		Packs:           make(map[string]*WordPack, len(packs)),
+		PackNames:       make([]string, 0, len(packs)),
+		WordSet:         make(map[string]struct{}),
+		HintMap:         make(map[string]string),
+		PackHints:       make(map[string]map[string]string, len(packs)),
+		LoadedAt:        time.Now(),
+	}
+	if lists.AcceptedWordSet == nil {
+		lists.AcceptedWordSet = make(map[string]struct{})
+	}
+	for _, pack := range packs {
+		lists.Packs[pack.Name] = pack
+		lists.PackNames = append(lists.PackNames, pack.Name)
+		lists.PackHints[pack.Name] = buildHintMap(pack.Words)
+		for _, entry := range pack.Words {
+			lists.WordSet[entry.Word] = struct{}{}
+			lists.AcceptedWordSet[entry.Word] = struct{}{}
+			if _, exists := lists.HintMap[entry.Word]; !exists {
+				lists.HintMap[entry.Word] = entry.Hint
+			}
+		}
+	}
+	if len(packs) > 0 {
+		lists.WordList = packs[0].Words
+	}
+
+	sorted := slices.Sorted(maps.Keys(lists.AcceptedWordSet))
+	lists.AcceptedWordsText = []byte(strings.Join(sorted, "\n") + "\n")
+	lists.WordListVersion = wordListsVersion(packs, lists.AcceptedWordsText, overlays)
+	return lists
+}
+
+// WordRegistry holds the current word lists and every earlier version a live game still
+// uses. Games record the version they started with, so a reload never changes the target,
+// hint, or accepted guesses of a game in progress; old versions are dropped once no live
+// game refers to them.
+type WordRegistry struct {
+	current  atomic.Pointer[WordLists]
+	mu       sync.Mutex
+	versions map[string]*WordLists
+}
+
+// newWordRegistry returns a registry serving lists.
+func newWordRegistry(lists *WordLists) *WordRegistry {
+	r := &WordRegistry{versions: make(map[string]*WordLists)}
+	r.install(lists)
+	return r
+}
+
+// install makes lists current, keeping the previous version for games pinned to it.
+func (r *WordRegistry) install(lists *WordLists) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.versions[lists.WordListVersion] = lists
+	r.current.Store(lists)
+}
+
+// lists returns the current word lists. A nil registry has empty lists.
+func (r *WordRegistry) lists() *WordLists {
+	if r == nil {
+		return &WordLists{}
+	}
+	return r.current.Load()
+}
+
+// version returns the lists with the given version, or the current lists when that version
+// is unknown or has been dropped.
+func (r *WordRegistry) version(version string) *WordLists {
+	if r == nil {
+		return &WordLists{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lists, ok := r.versions[version]; ok {
+		return lists
+	}
+	return r.current.Load()
+}
+
+// prune drops every version other than the current one that is not in inUse, and returns
+// the versions still held.
+func (r *WordRegistry) prune(inUse map[string]bool) []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current := r.current.Load().WordListVersion
+	for version := range r.versions {
+		if version != current && !inUse[version] {
+			delete(r.versions, version)
+		}
+	}
+	return slices.Sorted(maps.Keys(r.versions))
+}
+
+// isValid reports whether word is playable in these lists.
+func (l *WordLists) isValid(word string) bool {
+	_, ok := l.WordSet[word]
+	return ok
+}
+
+// registerWordPacks installs the pack registry, accepted words, and accepted-word overlays as
+// the app's word lists.
+func (app *App) registerWordPacks(packs []*WordPack, accepted map[string]struct{}, overlays map[string]*AcceptedOverlay) {
+	lists := newWordLists(packs, accepted, overlays)
+	if app.Words == nil {
+		app.Words = newWordRegistry(lists)
+		return
+	}
+	app.Words.install(lists)
+}
+
+// lists returns the current word lists, for new games and anything not tied to a game.
+func (app *App) lists() *WordLists {
+	return app.Words.lists()
+}
+
+// gameLists returns the word lists a game started with, so its hint and accepted guesses stay
+// the same across a reload. Games from before pinning use the current lists.
+func (app *App) gameLists(game *GameState) *WordLists {
+	if game == nil || game.WordsVersion == "" {
+		return app.lists()
+	}
+	return app.Words.version(game.WordsVersion)
+}
+
+// pinWords ties a new game to the current word lists.
+func (app *App) pinWords(game *GameState) {
+	game.WordsVersion = app.lists().WordListVersion
+}
+
+// wordVersionsInUse returns the word list versions pinned by live games.
+func (app *App) wordVersionsInUse() map[string]bool {
+	inUse := make(map[string]bool)
+	app.SessionMutex.RLock()
+	for _, game := range app.GameSessions {
+		inUse[game.WordsVersion] = true
+	}
+	app.SessionMutex.RUnlock()
+	return inUse
+}
+
+// reloadWordLists reads the word packs, accepted words, and overlays from disk again and
+// installs them.
+// Games in progress keep the lists they started with.
+func (app *App) reloadWordLists() (*WordLists, error) {
+	packs, err := loadWordPacks()
+	if err != nil {
+		return nil, err
+	}
+	accepted, err := loadAcceptedWords()
+	if err != nil {
+		return nil, err
+	}
+	overlays, err := loadAcceptedOverlays(AcceptedOverlaysDir)
+	if err != nil {
+		return nil, err
+	}
+	lists := newWordLists(packs, accepted, overlays)
+	app.Words.install(lists)
+	return lists, nil
+}
+
+// adminReloadWordsHandler reloads the word lists and reports the new version alongside the
+// older versions still pinned by games in progress.
+func (app *App) adminReloadWordsHandler(c *gin.Context) {
+	previous := app.lists().WordListVersion
+	lists, err := app.reloadWordLists()
+	if err != nil {
+		logWarn("Failed to reload word lists: %v", err)
+		writeProblem(c, http.StatusInternalServerError, ErrorCodeInternal, "failed to reload word lists: "+err.Error())
+		return
+	}
+	held := app.Words.prune(app.wordVersionsInUse())
+	logInfo("Admin reloaded word lists: version %s (was %s), %d versions held", lists.WordListVersion, previous, len(held))
+	auditChange(c, "word lists", previous, lists.WordListVersion)
+	c.JSON(http.StatusOK, gin.H{
+		"version":  lists.WordListVersion,
+		"previous": previous,
+		"words":    len(lists.WordSet),
+		"packs":    lists.PackNames,
+		"overlays": slices.Sorted(maps.Keys(lists.Overlays)),
+		"held":     held,
+	})
+}
+
+// wordListsVersion combines every pack version, the accepted word list, and the overlays into a
+// single short version string, which changes whenever any list the client may have cached changes.
+func wordListsVersion(packs []*WordPack, accepted []byte, overlays map[string]*AcceptedOverlay) string {
+	h := fnv.New64a()
+	for _, pack := range packs {
+		fmt.Fprintf(h, "%s:%016x\n", pack.Name, pack.Version)
+	}
+	h.Write(accepted)
+	writeOverlays(h, overlays)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// packVersions returns each pack's word list version keyed by pack name, as embedded in progress tokens.
+func (app *App) packVersions() map[string]string {
+	packs := app.lists().Packs
+	versions := make(map[string]string, len(packs))
+	for name, pack := range packs {
+		versions[name] = fmt.Sprintf("%016x", pack.Version)
+	}
+	return versions
+}
+
+// wordPack returns the named pack, falling back to the default pack for unknown names.
+func (app *App) wordPack(name string) *WordPack {
+	lists := app.lists()
+	if pack, ok := lists.Packs[name]; ok {
+		return pack
+	}
+	if pack, ok := lists.Packs[DefaultPackName]; ok {
+		return pack
+	}
+	return &WordPack{Name: DefaultPackName, Words: lists.WordList, Set: lists.WordSet, Version: wordListVersion(lists.WordList)}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mooship/vortludo/engine"
+)
+
+func TestLoadWordPack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "animals.json")
+	data := `{"words": [{"word": "TIGER", "hint": "striped cat"}, {"word": "OX", "hint": "too short"}]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pack, err := loadWordPack("animals", path)
+	if err != nil {
+		t.Fatalf("loadWordPack() error = %v", err)
+	}
+	if len(pack.Words) != 1 || pack.Words[0].Word != "TIGER" {
+		t.Errorf("loadWordPack() words = %v, want [TIGER]", pack.Words)
+	}
+	if _, ok := pack.Set["TIGER"]; !ok {
+		t.Error("pack set missing TIGER")
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(empty, []byte(`{"words": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadWordPack("empty", empty); err == nil {
+		t.Error("loadWordPack() expected error for empty pack")
+	}
+}
+
+func TestRegisterWordPacks(t *testing.T) {
+	app := &App{}
+	app.registerWordPacks([]*WordPack{
+		{Name: DefaultPackName, Words: []WordEntry{{Word: "CRANE", Hint: "bird"}}},
+		{Name: "food", Words: []WordEntry{{Word: "PIZZA", Hint: "pie"}, {Word: "CRANE", Hint: "lifts loads"}}, Set: map[string]struct{}{"PIZZA": {}, "CRANE": {}}},
+	}, map[string]struct{}{"CRANE": {}}, nil)
+
+	if lists := app.lists(); len(lists.WordList) != 1 || lists.WordList[0].Word != "CRANE" {
+		t.Errorf("WordList = %v, want default pack words", lists.WordList)
+	}
+	if !app.isValidWord("PIZZA") || !app.isAcceptedWord("", "PIZZA") {
+		t.Error("pack words should be valid and accepted")
+	}
+	if hint := app.getHintForWord("CRANE"); hint != "bird" {
+		t.Errorf("hint for CRANE = %q, want default pack hint", hint)
+	}
+	food := &GameState{GameState: engine.GameState{SessionWord: "CRANE"}, Pack: "food"}
+	if hint := app.gameHint(food); hint != "lifts loads" {
+		t.Errorf("hint for CRANE played from food = %q, want the food pack's hint", hint)
+	}
+	if text := app.lists().AcceptedWordsText; string(text) != "CRANE\nPIZZA\n" {
+		t.Errorf("AcceptedWordsText = %q", text)
+	}
+	if got := app.wordPack("food").Name; got != "food" {
+		t.Errorf("wordPack(food) = %s", got)
+	}
+	if got := app.wordPack("missing").Name; got != DefaultPackName {
+		t.Errorf("wordPack(missing) = %s, want default", got)
+	}
+}
+
+func TestCreateNewGameWithPack(t *testing.T) {
+	app := testAppWithWords([]WordEntry{{Word: "CRANE", Hint: "bird"}})
+	app.registerWordPacks([]*WordPack{
+		{Name: DefaultPackName, Words: app.lists().WordList},
+		{Name: "food", Words: []WordEntry{{Word: "PIZZA", Hint: "pie"}}},
+	}, nil, nil)
+	game, reset := app.createNewGameWithCompletedWords(dummyContext(), "sess", app.wordPack("food"), nil, selectionRequest{})
+	if reset || game.SessionWord != "PIZZA" || game.Pack != "food" {
+		t.Errorf("game = %s/%s reset=%v, want PIZZA/food", game.SessionWord, game.Pack, reset)
+	}
+}
+
+func TestWordListVersion(t *testing.T) {
+	register := func(words ...string) *App {
+		entries := make([]WordEntry, len(words))
+		for i, w := range words {
+			entries[i] = WordEntry{Word: w}
+		}
+		app := &App{}
+		app.registerWordPacks([]*WordPack{newWordPack(DefaultPackName, entries)}, nil, nil)
+		return app
+	}
+
+	a, b := register("CRANE", "SLATE"), register("CRANE", "SLATE")
+	if v := a.lists().WordListVersion; v == "" || v != b.lists().WordListVersion {
+		t.Errorf("Expected a stable version, got %q and %q", v, b.lists().WordListVersion)
+	}
+	changed := register("CRANE", "TRUCK")
+	if changed.lists().WordListVersion == a.lists().WordListVersion {
+		t.Error("Expected the version to change with the word list")
+	}
+	if changed.packVersions()[DefaultPackName] == a.packVersions()[DefaultPackName] {
+		t.Error("Expected the pack version to change with its words")
+	}
+}
+
+func TestReloadKeepsGamesOnTheirWordLists(t *testing.T) {
+	app := &App{GameSessions: make(map[string]*GameState)}
+	app.registerWordPacks([]*WordPack{newWordPack(DefaultPackName, []WordEntry{{Word: "CRANE", Hint: "bird"}})}, nil, nil)
+	game := app.createNewGame(dummyContext(), "sess")
+	old := game.WordsVersion
+	if old == "" {
+		t.Fatal("new game was not pinned to a word list version")
+	}
+
+	app.registerWordPacks([]*WordPack{newWordPack(DefaultPackName, []WordEntry{{Word: "SLATE", Hint: "rock"}})}, nil, nil)
+	if app.isValidWord("CRANE") {
+		t.Fatal("expected CRANE to be gone from the current lists")
+	}
+	lists := app.gameLists(game)
+	if !lists.isValid("CRANE") || !lists.isAccepted(SelectionModeCasual, "CRANE") || app.gameHint(game) != "bird" {
+		t.Errorf("in-flight game lost its target or hint after a reload")
+	}
+	if next := app.createNewGame(dummyContext(), "other"); next.SessionWord != "SLATE" || next.WordsVersion == old {
+		t.Errorf("new game = %s pinned to %s, want SLATE on the new lists", next.SessionWord, next.WordsVersion)
+	}
+
+	if held := app.Words.prune(app.wordVersionsInUse()); len(held) != 2 {
+		t.Errorf("held versions = %v, want the pinned one kept", held)
+	}
+	app.GameSessions = map[string]*GameState{}
+	if held := app.Words.prune(app.wordVersionsInUse()); len(held) != 1 || held[0] == old {
+		t.Errorf("held versions = %v, want only the current one", held)
+	}
+}
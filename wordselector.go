@@ -0,0 +1,205 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// Word selector names, as set in WORD_SELECTION and WORD_SELECTION_PURIST
+const (
+	SelectorRandom      = "random"
+	SelectorWeighted    = "weighted"
+	SelectorAdaptive    = "adaptive"
+	SelectorAdversarial = "adversarial"
+)
+
+// Selection modes, each with its own word selector
+const (
+	SelectionModeCasual = "casual"
+	SelectionModePurist = ModePurist
+	SelectionModeDaily  = ModeDaily
+)
+
+// WordSelector picks the word for a new game from the candidates still available to the player.
+type WordSelector interface {
+	Select(ctx context.Context, candidates []WordEntry, req selectionRequest) WordEntry
+}
+
+// selectionRequest describes the game a word is being selected for.
+type selectionRequest struct {
+	// Mode picks the selector; empty means casual.
+	Mode string
+	// Skill is the player's recent form, used by the adaptive selector.
+	Skill playerSkill
+	// Date is the puzzle date, used by the daily selector.
+	Date time.Time
+}
+
+// newWordSelector returns the selector named in config. The weighted selector favors words that
+// players rated fair in feedback.
+func newWordSelector(name string, feedback *WordFeedback) (WordSelector, error) {
+	switch name {
+	case SelectorRandom:
+		return randomSelector{}, nil
+	case SelectorWeighted:
+		return weightedSelector{weight: feedback.weight}, nil
+	case SelectorAdaptive:
+		return adaptiveSelector{MinGames: 5}, nil
+	case SelectorAdversarial:
+		return adversarialSelector{}, nil
+	}
+	return nil, fmt.Errorf("unknown word selector %q", name)
+}
+
+// newWordSelectors builds the selector for each mode: casual and purist games use the named
+// selectors, while the daily puzzle is always deterministic so every instance agrees on it.
+func newWordSelectors(casual, purist string, feedback *WordFeedback) (map[string]WordSelector, error) {
+	casualSelector, err := newWordSelector(cmp.Or(casual, SelectorAdaptive), feedback)
+	if err != nil {
+		return nil, err
+	}
+	puristSelector, err := newWordSelector(cmp.Or(purist, SelectorRandom), feedback)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]WordSelector{
+		SelectionModeCasual: casualSelector,
+		SelectionModePurist: puristSelector,
+		SelectionModeDaily:  dailySelector{},
+	}, nil
+}
+
+// selector returns the word selector for mode, falling back to the casual one, then to uniform
+// random selection when none is configured.
+func (app *App) selector(mode string) WordSelector {
+	if s, ok := app.Selectors[cmp.Or(mode, SelectionModeCasual)]; ok {
+		return s
+	}
+	if mode == SelectionModeDaily {
+		return dailySelector{}
+	}
+	if s, ok := app.Selectors[SelectionModeCasual]; ok {
+		return s
+	}
+	return randomSelector{}
+}
+
+// randomSelector picks uniformly at random.
+type randomSelector struct{}
+
+// Select returns a random candidate.
+func (randomSelector) Select(ctx context.Context, candidates []WordEntry, _ selectionRequest) WordEntry {
+	return pickRandomWordEntry(ctx, candidates)
+}
+
+// dailySelector picks by hashing the puzzle date, so the same date always yields the same word.
+type dailySelector struct{}
+
+// Select returns the candidate for req.Date.
+func (dailySelector) Select(_ context.Context, candidates []WordEntry, req selectionRequest) WordEntry {
+	h := fnv.New64a()
+	h.Write([]byte(req.Date.Format(time.DateOnly)))
+	return candidates[h.Sum64()%uint64(len(candidates))]
+}
+
+// weightedSelector picks at random in proportion to each word's weight.
+type weightedSelector struct {
+	weight func(word string) float64
+}
+
+// Select returns a candidate drawn by weight, or a uniformly random one if every weight is zero.
+func (s weightedSelector) Select(ctx context.Context, candidates []WordEntry, _ selectionRequest) WordEntry {
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i, entry := range candidates {
+		weights[i] = max(s.weight(entry.Word), 0)
+		total += weights[i]
+	}
+	if total == 0 {
+		return pickRandomWordEntry(ctx, candidates)
+	}
+	point := rand.Float64() * total
+	for i, w := range weights {
+		if point < w {
+			return candidates[i]
+		}
+		point -= w
+	}
+	return candidates[len(candidates)-1]
+}
+
+// adaptiveSelector ranks candidates from easiest to hardest and picks at random from the band
+// matching the player's skill level. Players with fewer than MinGames recent games get a
+// uniformly random word until there is enough history to estimate their skill.
+type adaptiveSelector struct {
+	MinGames int
+}
+
+// Select returns a random candidate from the tenth of the ranking nearest the player's level.
+func (s adaptiveSelector) Select(ctx context.Context, candidates []WordEntry, req selectionRequest) WordEntry {
+	if req.Skill.Games < s.MinGames {
+		return pickRandomWordEntry(ctx, candidates)
+	}
+	return pickDifficultyBand(ctx, candidates, req.Skill.level())
+}
+
+// adversarialSelector always picks from the hardest tenth of the candidates, for players who
+// want a challenge regardless of their record.
+type adversarialSelector struct{}
+
+// Select returns a random candidate among the hardest.
+func (adversarialSelector) Select(ctx context.Context, candidates []WordEntry, _ selectionRequest) WordEntry {
+	return pickDifficultyBand(ctx, candidates, 1)
+}
+
+// pickDifficultyBand returns a random candidate from the tenth of the difficulty ranking around
+// level, where 0 is the easiest word and 1 the hardest.
+func pickDifficultyBand(ctx context.Context, candidates []WordEntry, level float64) WordEntry {
+	if len(candidates) < 3 {
+		return pickRandomWordEntry(ctx, candidates)
+	}
+	ranked := rankByDifficulty(candidates)
+	target := int(level * float64(len(ranked)-1))
+	width := max(len(ranked)/20, 1)
+	return pickRandomWordEntry(ctx, ranked[max(target-width, 0):min(target+width+1, len(ranked))])
+}
+
+// rankByDifficulty returns words ordered from easiest to hardest. A word is easier the more of
+// its distinct letters are common across words, so words built from frequent letters come
+// first and words with rare or repeated letters come last.
+func rankByDifficulty(words []WordEntry) []WordEntry {
+	freq := make(map[rune]int)
+	for _, entry := range words {
+		for _, r := range distinctLetters(entry.Word) {
+			freq[r]++
+		}
+	}
+	score := func(word string) int {
+		total := 0
+		for _, r := range distinctLetters(word) {
+			total += freq[r]
+		}
+		return total
+	}
+	ranked := slices.Clone(words)
+	slices.SortStableFunc(ranked, func(a, b WordEntry) int {
+		return cmp.Or(cmp.Compare(score(b.Word), score(a.Word)), cmp.Compare(a.Word, b.Word))
+	})
+	return ranked
+}
+
+// distinctLetters returns the letters of word without repeats.
+func distinctLetters(word string) []rune {
+	var letters []rune
+	for _, r := range word {
+		if !slices.Contains(letters, r) {
+			letters = append(letters, r)
+		}
+	}
+	return letters
+}
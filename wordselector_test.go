@@ -0,0 +1,71 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveSelectionFollowsSkill(t *testing.T) {
+	words := []WordEntry{{Word: "ARISE"}, {Word: "RAISE"}, {Word: "STARE"}, {Word: "FUZZY"}, {Word: "JAZZY"}}
+	ranked := rankByDifficulty(words)
+	if ranked[0].Word != "ARISE" || ranked[len(ranked)-1].Word != "FUZZY" {
+		t.Fatalf("rankByDifficulty() = %v, want common letters first and FUZZY last", ranked)
+	}
+
+	s := adaptiveSelector{MinGames: 5}
+	expert := estimateSkill(slices.Repeat([]GameRecord{{Won: true, Guesses: 2}}, 10))
+	novice := estimateSkill(slices.Repeat([]GameRecord{{Won: false}}, 10))
+	for range 20 {
+		if w := s.Select(dummyContext(), words, selectionRequest{Skill: expert}); w.Word == "ARISE" || w.Word == "RAISE" {
+			t.Fatalf("expert got %s, want one of the harder words", w.Word)
+		}
+		if w := s.Select(dummyContext(), words, selectionRequest{Skill: novice}); w.Word != "ARISE" && w.Word != "RAISE" {
+			t.Fatalf("novice got %s, want one of the easiest words", w.Word)
+		}
+	}
+	if expert.level() <= novice.level() || novice.level() != 0 {
+		t.Errorf("level() expert %.2f, novice %.2f", expert.level(), novice.level())
+	}
+}
+
+func TestWordSelectorsPerMode(t *testing.T) {
+	if _, err := newWordSelectors("cheating", "", nil); err == nil {
+		t.Error("unknown selector accepted")
+	}
+	selectors, err := newWordSelectors("", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &App{Selectors: selectors}
+	if _, ok := app.selector(SelectionModeCasual).(adaptiveSelector); !ok {
+		t.Errorf("casual selector = %T, want adaptive", app.selector(SelectionModeCasual))
+	}
+	if _, ok := app.selector(SelectionModePurist).(randomSelector); !ok {
+		t.Errorf("purist selector = %T, want random", app.selector(SelectionModePurist))
+	}
+
+	words := []WordEntry{{Word: "ARISE"}, {Word: "CRANE"}, {Word: "FUZZY"}}
+	date := time.Date(2030, 4, 1, 0, 0, 0, 0, time.UTC)
+	first := app.selector(SelectionModeDaily).Select(dummyContext(), words, selectionRequest{Date: date})
+	for range 10 {
+		if got := app.selector(SelectionModeDaily).Select(dummyContext(), words, selectionRequest{Date: date}); got.Word != first.Word {
+			t.Fatalf("daily selector picked %s then %s for the same date", first.Word, got.Word)
+		}
+	}
+}
+
+func TestWeightedSelectorSkipsZeroWeights(t *testing.T) {
+	s := weightedSelector{weight: func(word string) float64 {
+		if word == "CRANE" {
+			return 1
+		}
+		return 0
+	}}
+	words := []WordEntry{{Word: "ARISE"}, {Word: "CRANE"}, {Word: "FUZZY"}}
+	for range 20 {
+		if got := s.Select(dummyContext(), words, selectionRequest{}); got.Word != "CRANE" {
+			t.Fatalf("weighted selector picked %s, want CRANE", got.Word)
+		}
+	}
+}